@@ -0,0 +1,74 @@
+package apikeydto
+
+import (
+	"gomonitor/internal/domain/apikey"
+	"time"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=read write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (r *CreateAPIKeyRequest) ToDomainInput() apikey.CreateInput {
+	return apikey.CreateInput{
+		Name:      r.Name,
+		Scopes:    r.Scopes,
+		ExpiresAt: r.ExpiresAt,
+	}
+}
+
+type APIKeyRequest struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+func (r *APIKeyRequest) ToDomainInput() apikey.KeyInput {
+	return apikey.KeyInput{ID: r.ID}
+}
+
+// APIKeyResponse never exposes the key, only its prefix.
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func ToAPIKeyResponse(key *apikey.APIKey) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:     key.ID,
+		Name:   key.Name,
+		Prefix: key.Prefix,
+		Scopes: key.ScopeList(),
+
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+	}
+}
+
+func ToAPIKeyListResponse(keys []apikey.APIKey) []*APIKeyResponse {
+	resp := make([]*APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, ToAPIKeyResponse(&keys[i]))
+	}
+
+	return resp
+}
+
+// CreateAPIKeyResponse is the only response holding the key.
+type CreateAPIKeyResponse struct {
+	*APIKeyResponse
+	Key string `json:"key"`
+}
+
+func ToCreateAPIKeyResponse(out *apikey.CreateOutput) *CreateAPIKeyResponse {
+	return &CreateAPIKeyResponse{
+		APIKeyResponse: ToAPIKeyResponse(out.APIKey),
+		Key:            out.Key,
+	}
+}
@@ -0,0 +1,73 @@
+package apikeydto_test
+
+import (
+	apikeydto "gomonitor/internal/api/dto/apikey"
+	"gomonitor/internal/domain/apikey"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_CreateAPIKeyRequest(t *testing.T) {
+	expiresAt := time.Now()
+	req := &apikeydto.CreateAPIKeyRequest{
+		Name:      "ci",
+		Scopes:    []string{"read"},
+		ExpiresAt: &expiresAt,
+	}
+
+	expected := apikey.CreateInput{
+		Name:      "ci",
+		Scopes:    []string{"read"},
+		ExpiresAt: &expiresAt,
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
+
+func TestDto_APIKeyRequest(t *testing.T) {
+	req := &apikeydto.APIKeyRequest{ID: 3}
+
+	assert.EqualValues(t, apikey.KeyInput{ID: 3}, req.ToDomainInput())
+}
+
+func TestDto_APIKeyResponse(t *testing.T) {
+	now := time.Now()
+
+	keys := []apikey.APIKey{
+		{
+			ID:         1,
+			UserID:     7,
+			Name:       "ci",
+			Prefix:     "gmk_abcdefgh",
+			KeyHash:    "hash",
+			Scopes:     "read write",
+			CreatedAt:  now,
+			LastUsedAt: &now,
+		},
+	}
+
+	expected := []*apikeydto.APIKeyResponse{
+		{
+			ID:         1,
+			Name:       "ci",
+			Prefix:     "gmk_abcdefgh",
+			Scopes:     []string{"read", "write"},
+			CreatedAt:  now,
+			LastUsedAt: &now,
+		},
+	}
+
+	assert.EqualValues(t, expected, apikeydto.ToAPIKeyListResponse(keys))
+}
+
+func TestDto_CreateAPIKeyResponse(t *testing.T) {
+	resp := apikeydto.ToCreateAPIKeyResponse(&apikey.CreateOutput{
+		Key:    "gmk_secret",
+		APIKey: &apikey.APIKey{ID: 1, Scopes: "read"},
+	})
+
+	assert.Equal(t, "gmk_secret", resp.Key)
+	assert.Equal(t, uint(1), resp.ID)
+}
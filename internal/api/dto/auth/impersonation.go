@@ -0,0 +1,28 @@
+package authdto
+
+import (
+	"gomonitor/internal/domain/auth"
+	"time"
+)
+
+type ImpersonateRequest struct {
+	UserID uint `uri:"id" binding:"required"`
+}
+
+func (r *ImpersonateRequest) ToDomainInput() auth.ImpersonateInput {
+	return auth.ImpersonateInput{
+		UserID: r.UserID,
+	}
+}
+
+type ImpersonationResponse struct {
+	AccessToken string    `json:"token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func ToImpersonationResponse(output *auth.ImpersonationOutput) *ImpersonationResponse {
+	return &ImpersonationResponse{
+		AccessToken: output.AccessToken,
+		ExpiresAt:   output.AccessTokenExpiresAt,
+	}
+}
@@ -0,0 +1,19 @@
+package authdto_test
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/domain/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_Impersonation(t *testing.T) {
+	request := &authdto.ImpersonateRequest{UserID: 2}
+	assert.Equal(t, auth.ImpersonateInput{UserID: 2}, request.ToDomainInput())
+
+	expiresAt := time.Now()
+	resp := authdto.ToImpersonationResponse(&auth.ImpersonationOutput{AccessToken: "token", AccessTokenExpiresAt: expiresAt})
+	assert.Equal(t, &authdto.ImpersonationResponse{AccessToken: "token", ExpiresAt: expiresAt}, resp)
+}
@@ -4,7 +4,7 @@ import "gomonitor/internal/domain/auth"
 
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8,max=72"`
+	Password string `json:"password" binding:"required"`
 }
 
 func (r *LoginRequest) ToDomainInput() auth.LoginInput {
@@ -17,11 +17,27 @@ func (r *LoginRequest) ToDomainInput() auth.LoginInput {
 type LoginResponse struct {
 	RefreshToken string `json:"refresh_token"`
 	AccessToken  string `json:"token"`
+	// TokenType is DPoP when the tokens are bound to the key of the client, Bearer otherwise.
+	TokenType string `json:"token_type"`
 }
 
 func ToLoginResponse(output *auth.LoginOutput) *LoginResponse {
 	return &LoginResponse{
 		AccessToken:  output.AccessToken,
 		RefreshToken: output.RefreshToken,
+		TokenType:    output.TokenType,
+	}
+}
+
+// StepUpResponse replaces LoginResponse when an unusual login has to be completed with a mailed link.
+type StepUpResponse struct {
+	VerificationRequired bool   `json:"verification_required"`
+	Method               string `json:"method"`
+}
+
+func ToStepUpResponse(output *auth.StepUpOutput) *StepUpResponse {
+	return &StepUpResponse{
+		VerificationRequired: true,
+		Method:               output.Method,
 	}
 }
@@ -28,14 +28,25 @@ func TestDto_LoginResponse(t *testing.T) {
 	loginOutput := &auth.LoginOutput{
 		RefreshToken: "testRefreshToken",
 		AccessToken:  "testAccessToken",
+		TokenType:    auth.TokenTypeDPoP,
 	}
 
 	expectedLoginResponse := &authdto.LoginResponse{
 		RefreshToken: "testRefreshToken",
 		AccessToken:  "testAccessToken",
+		TokenType:    auth.TokenTypeDPoP,
 	}
 
 	loginResponse := authdto.ToLoginResponse(loginOutput)
 
 	assert.EqualValues(t, expectedLoginResponse, loginResponse)
 }
+
+func TestDto_StepUpResponse(t *testing.T) {
+	expected := &authdto.StepUpResponse{
+		VerificationRequired: true,
+		Method:               auth.StepUpMethodEmail,
+	}
+
+	assert.EqualValues(t, expected, authdto.ToStepUpResponse(&auth.StepUpOutput{Method: auth.StepUpMethodEmail}))
+}
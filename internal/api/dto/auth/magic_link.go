@@ -0,0 +1,23 @@
+package authdto
+
+import "gomonitor/internal/domain/auth"
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func (r *MagicLinkRequest) ToDomainInput() auth.MagicLinkInput {
+	return auth.MagicLinkInput{
+		Email: r.Email,
+	}
+}
+
+type ConsumeMagicLinkRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (r *ConsumeMagicLinkRequest) ToDomainInput() auth.ConsumeMagicLinkInput {
+	return auth.ConsumeMagicLinkInput{
+		Token: r.Token,
+	}
+}
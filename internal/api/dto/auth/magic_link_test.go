@@ -0,0 +1,21 @@
+package authdto_test
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/domain/auth"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_MagicLinkRequest(t *testing.T) {
+	req := &authdto.MagicLinkRequest{Email: "user@test.com"}
+
+	assert.EqualValues(t, auth.MagicLinkInput{Email: "user@test.com"}, req.ToDomainInput())
+}
+
+func TestDto_ConsumeMagicLinkRequest(t *testing.T) {
+	req := &authdto.ConsumeMagicLinkRequest{Token: "token"}
+
+	assert.EqualValues(t, auth.ConsumeMagicLinkInput{Token: "token"}, req.ToDomainInput())
+}
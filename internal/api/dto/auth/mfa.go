@@ -0,0 +1,37 @@
+package authdto
+
+import (
+	"gomonitor/internal/domain/auth"
+	"time"
+)
+
+// MFAChallengeResponse replaces LoginResponse when the user has MFA enabled.
+type MFAChallengeResponse struct {
+	MFARequired    bool      `json:"mfa_required"`
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	// Methods are the second factors the user can answer with, totp or passkey.
+	Methods []string `json:"methods,omitempty"`
+}
+
+func ToMFAChallengeResponse(output *auth.MFAChallengeOutput) *MFAChallengeResponse {
+	return &MFAChallengeResponse{
+		MFARequired:    true,
+		ChallengeToken: output.Token,
+		ExpiresAt:      output.ExpiresAt,
+		Methods:        output.Methods,
+	}
+}
+
+type VerifyMFARequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// A 6 digit TOTP code or a recovery code.
+	Code string `json:"code" binding:"required,min=6,max=16"`
+}
+
+func (r *VerifyMFARequest) ToDomainInput() auth.VerifyMFAInput {
+	return auth.VerifyMFAInput{
+		ChallengeToken: r.ChallengeToken,
+		Code:           r.Code,
+	}
+}
@@ -0,0 +1,41 @@
+package authdto_test
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/domain/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_ToMFAChallengeResponse(t *testing.T) {
+	expiresAt := time.Now()
+
+	resp := authdto.ToMFAChallengeResponse(&auth.MFAChallengeOutput{
+		Token:     "challenge",
+		ExpiresAt: expiresAt,
+		Methods:   []string{auth.MFAMethodTOTP, auth.MFAMethodPasskey},
+	})
+
+	assert.Equal(t, &authdto.MFAChallengeResponse{
+		MFARequired:    true,
+		ChallengeToken: "challenge",
+		ExpiresAt:      expiresAt,
+		Methods:        []string{"totp", "passkey"},
+	}, resp)
+}
+
+func TestDto_VerifyMFARequest(t *testing.T) {
+	req := &authdto.VerifyMFARequest{
+		ChallengeToken: "challenge",
+		Code:           "123456",
+	}
+
+	expected := auth.VerifyMFAInput{
+		ChallengeToken: "challenge",
+		Code:           "123456",
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
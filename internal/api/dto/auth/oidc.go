@@ -0,0 +1,18 @@
+package authdto
+
+import "gomonitor/internal/domain/auth"
+
+// OIDCCallbackRequest is the redirect back from the provider, with either a code or an error.
+type OIDCCallbackRequest struct {
+	Code  string `form:"code"`
+	State string `form:"state" binding:"required"`
+	Error string `form:"error"`
+}
+
+func (r *OIDCCallbackRequest) ToDomainInput() auth.OIDCCallbackInput {
+	return auth.OIDCCallbackInput{
+		Code:  r.Code,
+		State: r.State,
+		Error: r.Error,
+	}
+}
@@ -0,0 +1,25 @@
+package authdto_test
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/domain/auth"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_OIDCCallbackRequest(t *testing.T) {
+	req := &authdto.OIDCCallbackRequest{
+		Code:  "code",
+		State: "state",
+		Error: "access_denied",
+	}
+
+	expected := auth.OIDCCallbackInput{
+		Code:  "code",
+		State: "state",
+		Error: "access_denied",
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
@@ -0,0 +1,43 @@
+package authdto
+
+import (
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/pkg/webauthn"
+)
+
+// PasskeyLoginOptionsRequest starts a passkey login, as the second factor of a challenged login when
+// ChallengeToken is set.
+type PasskeyLoginOptionsRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+func (r *PasskeyLoginOptionsRequest) ToDomainInput() auth.PasskeyLoginOptionsInput {
+	return auth.PasskeyLoginOptionsInput{ChallengeToken: r.ChallengeToken}
+}
+
+// PasskeyLoginRequest is the credential returned by navigator.credentials.get, in the form of
+// PublicKeyCredential.toJSON.
+type PasskeyLoginRequest struct {
+	RawID    webauthn.URLEncoded `json:"rawId" binding:"required"`
+	Type     string              `json:"type" binding:"required,eq=public-key"`
+	Response AssertionResponse   `json:"response"`
+}
+
+type AssertionResponse struct {
+	ClientDataJSON    webauthn.URLEncoded `json:"clientDataJSON" binding:"required"`
+	AuthenticatorData webauthn.URLEncoded `json:"authenticatorData" binding:"required"`
+	Signature         webauthn.URLEncoded `json:"signature" binding:"required"`
+	UserHandle        webauthn.URLEncoded `json:"userHandle"`
+}
+
+func (r *PasskeyLoginRequest) ToDomainInput() auth.PasskeyLoginInput {
+	return auth.PasskeyLoginInput{
+		Response: &webauthn.AssertionResponse{
+			CredentialID:      r.RawID,
+			ClientDataJSON:    r.Response.ClientDataJSON,
+			AuthenticatorData: r.Response.AuthenticatorData,
+			Signature:         r.Response.Signature,
+			UserHandle:        r.Response.UserHandle,
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package authdto_test
+
+import (
+	"encoding/json"
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/pkg/webauthn"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDto_PasskeyLoginOptionsRequest(t *testing.T) {
+	req := &authdto.PasskeyLoginOptionsRequest{ChallengeToken: "challenge"}
+
+	assert.EqualValues(t, auth.PasskeyLoginOptionsInput{ChallengeToken: "challenge"}, req.ToDomainInput())
+}
+
+func TestDto_PasskeyLoginRequest(t *testing.T) {
+	body := `{
+		"id": "AQI",
+		"rawId": "AQI",
+		"type": "public-key",
+		"response": {
+			"clientDataJSON": "e30",
+			"authenticatorData": "AwQ",
+			"signature": "BQY",
+			"userHandle": "AAAAAAAAAAc"
+		},
+		"clientExtensionResults": {}
+	}`
+
+	var req authdto.PasskeyLoginRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	expected := auth.PasskeyLoginInput{
+		Response: &webauthn.AssertionResponse{
+			CredentialID:      []byte{1, 2},
+			ClientDataJSON:    []byte("{}"),
+			AuthenticatorData: []byte{3, 4},
+			Signature:         []byte{5, 6},
+			UserHandle:        []byte{0, 0, 0, 0, 0, 0, 0, 7},
+		},
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
@@ -0,0 +1,40 @@
+package authdto
+
+import "gomonitor/internal/domain/auth"
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func (r *ForgotPasswordRequest) ToDomainInput() auth.ForgotPasswordInput {
+	return auth.ForgotPasswordInput{
+		Email: r.Email,
+	}
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+func (r *ResetPasswordRequest) ToDomainInput() auth.ResetPasswordInput {
+	return auth.ResetPasswordInput{
+		Token:    r.Token,
+		Password: r.Password,
+	}
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+	// KeepOtherSessions skips revoking the sessions other than the current one.
+	KeepOtherSessions bool `json:"keep_other_sessions"`
+}
+
+func (r *ChangePasswordRequest) ToDomainInput() auth.ChangePasswordInput {
+	return auth.ChangePasswordInput{
+		CurrentPassword:   r.CurrentPassword,
+		NewPassword:       r.NewPassword,
+		KeepOtherSessions: r.KeepOtherSessions,
+	}
+}
@@ -0,0 +1,45 @@
+package authdto_test
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/domain/auth"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_ForgotPasswordRequest(t *testing.T) {
+	req := &authdto.ForgotPasswordRequest{Email: "user@test.com"}
+
+	assert.EqualValues(t, auth.ForgotPasswordInput{Email: "user@test.com"}, req.ToDomainInput())
+}
+
+func TestDto_ResetPasswordRequest(t *testing.T) {
+	req := &authdto.ResetPasswordRequest{
+		Token:    "token",
+		Password: "new-password",
+	}
+
+	expected := auth.ResetPasswordInput{
+		Token:    "token",
+		Password: "new-password",
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
+
+func TestDto_ChangePasswordRequest(t *testing.T) {
+	req := &authdto.ChangePasswordRequest{
+		CurrentPassword:   "old-password",
+		NewPassword:       "new-password",
+		KeepOtherSessions: true,
+	}
+
+	expected := auth.ChangePasswordInput{
+		CurrentPassword:   "old-password",
+		NewPassword:       "new-password",
+		KeepOtherSessions: true,
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
@@ -0,0 +1,48 @@
+package authdto
+
+import (
+	"gomonitor/internal/domain/auth"
+	"time"
+)
+
+// ReauthenticateRequest proves the credentials again, Code is required when the user enabled TOTP.
+type ReauthenticateRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"omitempty,min=6,max=16"`
+}
+
+func (r *ReauthenticateRequest) ToDomainInput() auth.ReauthenticateInput {
+	return auth.ReauthenticateInput{
+		Password: r.Password,
+		Code:     r.Code,
+	}
+}
+
+// PasskeyReauthenticateRequest is the credential returned by navigator.credentials.get for the
+// request options of a re-authentication.
+type PasskeyReauthenticateRequest struct {
+	PasskeyLoginRequest
+}
+
+func (r *PasskeyReauthenticateRequest) ToDomainInput() auth.PasskeyReauthenticateInput {
+	return auth.PasskeyReauthenticateInput{Response: r.PasskeyLoginRequest.ToDomainInput().Response}
+}
+
+// ReauthenticateResponse replaces the access token only, the refresh token of the session is unchanged.
+type ReauthenticateResponse struct {
+	AccessToken string    `json:"token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	AuthTime    time.Time `json:"auth_time"`
+	AMR         []string  `json:"amr"`
+}
+
+func ToReauthenticateResponse(output *auth.ReauthenticateOutput) *ReauthenticateResponse {
+	return &ReauthenticateResponse{
+		AccessToken: output.AccessToken,
+		TokenType:   output.TokenType,
+		ExpiresAt:   output.AccessTokenExpiresAt,
+		AuthTime:    output.AuthTime,
+		AMR:         output.AMR,
+	}
+}
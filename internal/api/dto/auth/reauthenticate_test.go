@@ -0,0 +1,31 @@
+package authdto_test
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/domain/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_Reauthenticate(t *testing.T) {
+	request := &authdto.ReauthenticateRequest{Password: "password123", Code: "123456"}
+	assert.Equal(t, auth.ReauthenticateInput{Password: "password123", Code: "123456"}, request.ToDomainInput())
+
+	now := time.Now()
+	resp := authdto.ToReauthenticateResponse(&auth.ReauthenticateOutput{
+		AccessToken:          "token",
+		TokenType:            auth.TokenTypeBearer,
+		AccessTokenExpiresAt: now.Add(time.Hour),
+		AuthTime:             now,
+		AMR:                  []string{auth.AMRPassword},
+	})
+	assert.Equal(t, &authdto.ReauthenticateResponse{
+		AccessToken: "token",
+		TokenType:   auth.TokenTypeBearer,
+		ExpiresAt:   now.Add(time.Hour),
+		AuthTime:    now,
+		AMR:         []string{auth.AMRPassword},
+	}, resp)
+}
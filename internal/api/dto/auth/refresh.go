@@ -13,11 +13,16 @@ func (r *RefreshRequest) ToDomainInput() auth.RefreshInput {
 }
 
 type RefreshResponse struct {
-	AccessToken string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"token"`
+	// TokenType is DPoP when the tokens are bound to the key of the client, Bearer otherwise.
+	TokenType string `json:"token_type"`
 }
 
 func ToRefreshResponse(output *auth.RefreshOutput) *RefreshResponse {
 	return &RefreshResponse{
-		AccessToken: output.AccessToken,
+		AccessToken:  output.AccessToken,
+		RefreshToken: output.RefreshToken,
+		TokenType:    output.TokenType,
 	}
 }
@@ -24,11 +24,15 @@ func TestDto_RefreshRequest(t *testing.T) {
 
 func TestDto_RefreshResponse(t *testing.T) {
 	refreshOutput := &auth.RefreshOutput{
-		AccessToken: "testAccessToken",
+		RefreshToken: "testRefreshToken",
+		AccessToken:  "testAccessToken",
+		TokenType:    auth.TokenTypeDPoP,
 	}
 
 	expectedRefreshResponse := &authdto.RefreshResponse{
-		AccessToken: "testAccessToken",
+		RefreshToken: "testRefreshToken",
+		AccessToken:  "testAccessToken",
+		TokenType:    auth.TokenTypeDPoP,
 	}
 
 	refreshResponse := authdto.ToRefreshResponse(refreshOutput)
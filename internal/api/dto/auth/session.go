@@ -0,0 +1,73 @@
+package authdto
+
+import (
+	"gomonitor/internal/domain/auth"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionRequest struct {
+	JTI string `uri:"jti" binding:"required,uuid"`
+}
+
+func (r *SessionRequest) ToDomainInput() auth.RevokeSessionInput {
+	// Already validated by the binding.
+	jti, _ := uuid.Parse(r.JTI)
+
+	return auth.RevokeSessionInput{
+		JTI: jti,
+	}
+}
+
+type UserSessionsRequest struct {
+	UserID uint `uri:"id" binding:"required"`
+}
+
+func (r *UserSessionsRequest) ToDomainInput() auth.ListSessionsInput {
+	return auth.ListSessionsInput{
+		UserID: &r.UserID,
+	}
+}
+
+type UserSessionRequest struct {
+	UserID uint   `uri:"id" binding:"required"`
+	JTI    string `uri:"jti" binding:"required,uuid"`
+}
+
+func (r *UserSessionRequest) ToDomainInput() auth.RevokeSessionInput {
+	jti, _ := uuid.Parse(r.JTI)
+
+	return auth.RevokeSessionInput{
+		UserID: &r.UserID,
+		JTI:    jti,
+	}
+}
+
+type SessionResponse struct {
+	JTI        uuid.UUID `json:"jti"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func ToSessionListResponse(sessions []auth.SessionOutput) []*SessionResponse {
+	resp := make([]*SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, &SessionResponse{
+			JTI:       session.JTI,
+			UserAgent: session.UserAgent,
+			IPAddress: session.IPAddress,
+			Current:   session.Current,
+
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+
+	return resp
+}
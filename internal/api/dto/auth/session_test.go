@@ -0,0 +1,61 @@
+package authdto_test
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/domain/auth"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDto_SessionRequests(t *testing.T) {
+	jti := uuid.New()
+
+	sessionRequest := &authdto.SessionRequest{JTI: jti.String()}
+	assert.Equal(t, auth.RevokeSessionInput{JTI: jti}, sessionRequest.ToDomainInput())
+
+	userSessionsRequest := &authdto.UserSessionsRequest{UserID: 2}
+	input := userSessionsRequest.ToDomainInput()
+	require.NotNil(t, input.UserID)
+	assert.Equal(t, uint(2), *input.UserID)
+
+	userSessionRequest := &authdto.UserSessionRequest{UserID: 2, JTI: jti.String()}
+	revokeInput := userSessionRequest.ToDomainInput()
+	require.NotNil(t, revokeInput.UserID)
+	assert.Equal(t, uint(2), *revokeInput.UserID)
+	assert.Equal(t, jti, revokeInput.JTI)
+}
+
+func TestDto_SessionListResponse(t *testing.T) {
+	now := time.Now()
+	sessions := []auth.SessionOutput{
+		{
+			JTI:        uuid.New(),
+			UserAgent:  "curl/8.0",
+			IPAddress:  "192.0.2.1",
+			CreatedAt:  now,
+			LastUsedAt: now,
+			ExpiresAt:  now.Add(time.Hour),
+			Current:    true,
+		},
+	}
+
+	resp := authdto.ToSessionListResponse(sessions)
+
+	require.Len(t, resp, 1)
+	assert.Equal(t, &authdto.SessionResponse{
+		JTI:        sessions[0].JTI,
+		UserAgent:  "curl/8.0",
+		IPAddress:  "192.0.2.1",
+		Current:    true,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}, resp[0])
+
+	assert.Empty(t, authdto.ToSessionListResponse(nil))
+	assert.NotNil(t, authdto.ToSessionListResponse(nil))
+}
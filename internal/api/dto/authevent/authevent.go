@@ -0,0 +1,63 @@
+package autheventdto
+
+import (
+	"gomonitor/internal/domain/authevent"
+	"time"
+)
+
+// ListAuthEventsRequest filters the log, from and to are RFC 3339 times. A listing continues from
+// the id of its last event with before_id.
+type ListAuthEventsRequest struct {
+	UserID    *uint          `form:"user_id" binding:"omitempty,min=1"`
+	Type      authevent.Type `form:"type"`
+	IPAddress string         `form:"ip" binding:"omitempty,ip"`
+	From      *time.Time     `form:"from"`
+	To        *time.Time     `form:"to"`
+	BeforeID  uint64         `form:"before_id"`
+	Limit     int            `form:"limit" binding:"omitempty,min=1"`
+}
+
+func (r *ListAuthEventsRequest) ToDomainInput() authevent.ListInput {
+	return authevent.ListInput{
+		UserID:    r.UserID,
+		Type:      r.Type,
+		IPAddress: r.IPAddress,
+		From:      r.From,
+		To:        r.To,
+		BeforeID:  r.BeforeID,
+		Limit:     r.Limit,
+	}
+}
+
+type AuthEventResponse struct {
+	ID        uint64         `json:"id"`
+	Type      authevent.Type `json:"type"`
+	UserID    *uint          `json:"user_id,omitempty"`
+	IPAddress string         `json:"ip_address"`
+	UserAgent string         `json:"user_agent"`
+	Reason    string         `json:"reason,omitempty"`
+	TraceID   string         `json:"trace_id,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func ToAuthEventResponse(event *authevent.Event) *AuthEventResponse {
+	return &AuthEventResponse{
+		ID:        event.ID,
+		Type:      event.Type,
+		UserID:    event.UserID,
+		IPAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+		Reason:    event.Reason,
+		TraceID:   event.TraceID,
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+func ToAuthEventListResponse(events []authevent.Event) []*AuthEventResponse {
+	resp := make([]*AuthEventResponse, 0, len(events))
+	for i := range events {
+		resp = append(resp, ToAuthEventResponse(&events[i]))
+	}
+
+	return resp
+}
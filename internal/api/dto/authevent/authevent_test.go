@@ -0,0 +1,70 @@
+package autheventdto_test
+
+import (
+	autheventdto "gomonitor/internal/api/dto/authevent"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_ListAuthEventsRequest(t *testing.T) {
+	from := time.Now().Add(-time.Hour)
+	to := time.Now()
+
+	req := &autheventdto.ListAuthEventsRequest{
+		UserID:    testutil.Ptr(uint(1)),
+		Type:      authevent.TypeLoginFailure,
+		IPAddress: "192.0.2.1",
+		From:      &from,
+		To:        &to,
+		BeforeID:  10,
+		Limit:     20,
+	}
+
+	expected := authevent.ListInput{
+		UserID:    testutil.Ptr(uint(1)),
+		Type:      authevent.TypeLoginFailure,
+		IPAddress: "192.0.2.1",
+		From:      &from,
+		To:        &to,
+		BeforeID:  10,
+		Limit:     20,
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
+
+func TestDto_AuthEventResponse(t *testing.T) {
+	now := time.Now()
+
+	events := []authevent.Event{
+		{
+			ID:        1,
+			Type:      authevent.TypeLoginFailure,
+			UserID:    testutil.Ptr(uint(2)),
+			IPAddress: "192.0.2.1",
+			UserAgent: "curl/8.0",
+			Reason:    authevent.ReasonInvalidCredentials,
+			TraceID:   "trace",
+			CreatedAt: now,
+		},
+	}
+
+	expected := []*autheventdto.AuthEventResponse{
+		{
+			ID:        1,
+			Type:      authevent.TypeLoginFailure,
+			UserID:    testutil.Ptr(uint(2)),
+			IPAddress: "192.0.2.1",
+			UserAgent: "curl/8.0",
+			Reason:    authevent.ReasonInvalidCredentials,
+			TraceID:   "trace",
+			CreatedAt: now,
+		},
+	}
+
+	assert.EqualValues(t, expected, autheventdto.ToAuthEventListResponse(events))
+}
@@ -0,0 +1,35 @@
+package mfadto
+
+import "gomonitor/internal/domain/mfa"
+
+type CodeRequest struct {
+	Code string `json:"code" binding:"required,min=6,max=16"`
+}
+
+func (r *CodeRequest) ToDomainInput() mfa.CodeInput {
+	return mfa.CodeInput{
+		Code: r.Code,
+	}
+}
+
+type EnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+func ToEnrollResponse(output *mfa.EnrollOutput) *EnrollResponse {
+	return &EnrollResponse{
+		Secret: output.Secret,
+		URI:    output.URI,
+	}
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func ToRecoveryCodesResponse(output *mfa.RecoveryCodesOutput) *RecoveryCodesResponse {
+	return &RecoveryCodesResponse{
+		RecoveryCodes: output.Codes,
+	}
+}
@@ -0,0 +1,33 @@
+package mfadto_test
+
+import (
+	mfadto "gomonitor/internal/api/dto/mfa"
+	"gomonitor/internal/domain/mfa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_CodeRequest(t *testing.T) {
+	req := &mfadto.CodeRequest{Code: "123456"}
+
+	assert.EqualValues(t, mfa.CodeInput{Code: "123456"}, req.ToDomainInput())
+}
+
+func TestDto_ToEnrollResponse(t *testing.T) {
+	resp := mfadto.ToEnrollResponse(&mfa.EnrollOutput{
+		Secret: "SECRET",
+		URI:    "otpauth://totp/gomonitor:test?secret=SECRET",
+	})
+
+	assert.Equal(t, &mfadto.EnrollResponse{
+		Secret: "SECRET",
+		URI:    "otpauth://totp/gomonitor:test?secret=SECRET",
+	}, resp)
+}
+
+func TestDto_ToRecoveryCodesResponse(t *testing.T) {
+	resp := mfadto.ToRecoveryCodesResponse(&mfa.RecoveryCodesOutput{Codes: []string{"aaaaa-bbbbb"}})
+
+	assert.Equal(t, []string{"aaaaa-bbbbb"}, resp.RecoveryCodes)
+}
@@ -0,0 +1,180 @@
+package oauthdto
+
+import (
+	"gomonitor/internal/domain/oauth"
+	"gomonitor/internal/pkg/dpop"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CreateClientRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=read write"`
+}
+
+func (r *CreateClientRequest) ToDomainInput() oauth.CreateClientInput {
+	return oauth.CreateClientInput{
+		Name:   r.Name,
+		Scopes: r.Scopes,
+	}
+}
+
+type ClientRequest struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+func (r *ClientRequest) ToDomainInput() oauth.ClientInput {
+	return oauth.ClientInput{ID: r.ID}
+}
+
+// ClientResponse never exposes the secret.
+type ClientResponse struct {
+	ID        uint      `json:"id"`
+	ClientID  string    `json:"client_id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToClientResponse(client *oauth.Client) *ClientResponse {
+	return &ClientResponse{
+		ID:        client.ID,
+		ClientID:  client.ClientID,
+		Name:      client.Name,
+		Scopes:    client.ScopeList(),
+		CreatedAt: client.CreatedAt,
+	}
+}
+
+func ToClientListResponse(clients []oauth.Client) []*ClientResponse {
+	resp := make([]*ClientResponse, 0, len(clients))
+	for i := range clients {
+		resp = append(resp, ToClientResponse(&clients[i]))
+	}
+
+	return resp
+}
+
+// CreateClientResponse is the only response holding the secret.
+type CreateClientResponse struct {
+	*ClientResponse
+	ClientSecret string `json:"client_secret"`
+}
+
+func ToCreateClientResponse(out *oauth.CreateClientOutput) *CreateClientResponse {
+	return &CreateClientResponse{
+		ClientResponse: ToClientResponse(out.Client),
+		ClientSecret:   out.ClientSecret,
+	}
+}
+
+// TokenRequest is a form encoded token request, the client may authenticate with HTTP Basic instead.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+func (r *TokenRequest) ToDomainInput() oauth.TokenInput {
+	return oauth.TokenInput{
+		GrantType:    r.GrantType,
+		ClientID:     r.ClientID,
+		ClientSecret: r.ClientSecret,
+		Scope:        r.Scope,
+	}
+}
+
+// TokenResponse is the access token response of RFC 6749 section 5.1.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+func ToTokenResponse(out *oauth.TokenOutput) *TokenResponse {
+	return &TokenResponse{
+		AccessToken: out.AccessToken,
+		TokenType:   out.TokenType,
+		ExpiresIn:   int64(out.ExpiresIn.Seconds()),
+		Scope:       strings.Join(out.Scopes, " "),
+	}
+}
+
+// IntrospectRequest is a form encoded introspection request, authenticated like a token request.
+// The hint is accepted but unused, only access tokens can be introspected.
+type IntrospectRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+func (r *IntrospectRequest) ToDomainInput() oauth.IntrospectInput {
+	return oauth.IntrospectInput{
+		ClientID:     r.ClientID,
+		ClientSecret: r.ClientSecret,
+		Token:        r.Token,
+	}
+}
+
+// IntrospectResponse is the introspection response of RFC 7662 section 2.2.
+// SessionStatus is an extension, it is also set on inactive tokens of a revoked or expired session.
+type IntrospectResponse struct {
+	Active    bool           `json:"active"`
+	Subject   string         `json:"sub,omitempty"`
+	Role      string         `json:"role,omitempty"`
+	ClientID  string         `json:"client_id,omitempty"`
+	Scope     string         `json:"scope,omitempty"`
+	TokenType string         `json:"token_type,omitempty"`
+	ExpiresAt int64          `json:"exp,omitempty"`
+	Actor     *ActorResponse `json:"act,omitempty"`
+	// Confirmation is set for DPoP bound tokens, as in RFC 9449 section 6.2.
+	Confirmation  *ConfirmationResponse `json:"cnf,omitempty"`
+	SessionStatus string                `json:"session_status,omitempty"`
+}
+
+// ConfirmationResponse holds the thumbprint of the key a token is bound to.
+type ConfirmationResponse struct {
+	JKT string `json:"jkt"`
+}
+
+// ActorResponse is the actor of an impersonation token, as in RFC 8693.
+type ActorResponse struct {
+	Subject string `json:"sub"`
+}
+
+func ToIntrospectResponse(out *oauth.IntrospectOutput) *IntrospectResponse {
+	resp := &IntrospectResponse{
+		Active:        out.Active,
+		SessionStatus: string(out.SessionStatus),
+	}
+	if !out.Active {
+		return resp
+	}
+
+	resp.Role = string(out.Role)
+	resp.ClientID = out.ClientID
+	resp.Scope = strings.Join(out.Scopes, " ")
+	resp.TokenType = oauth.TokenTypeBearer
+	resp.ExpiresAt = out.ExpiresAt.Unix()
+	if out.UserID != 0 {
+		resp.Subject = strconv.FormatUint(uint64(out.UserID), 10)
+	}
+	if out.ActorID != 0 {
+		resp.Actor = &ActorResponse{Subject: strconv.FormatUint(uint64(out.ActorID), 10)}
+	}
+	if out.JKT != "" {
+		resp.TokenType = dpop.TokenType
+		resp.Confirmation = &ConfirmationResponse{JKT: out.JKT}
+	}
+
+	return resp
+}
+
+// ErrorResponse is the error response of RFC 6749 section 5.2.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
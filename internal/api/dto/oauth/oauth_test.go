@@ -0,0 +1,151 @@
+package oauthdto_test
+
+import (
+	oauthdto "gomonitor/internal/api/dto/oauth"
+	"gomonitor/internal/domain/oauth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_CreateClientRequest(t *testing.T) {
+	req := &oauthdto.CreateClientRequest{
+		Name:   "billing",
+		Scopes: []string{"read"},
+	}
+
+	expected := oauth.CreateClientInput{
+		Name:   "billing",
+		Scopes: []string{"read"},
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
+
+func TestDto_ClientRequest(t *testing.T) {
+	req := &oauthdto.ClientRequest{ID: 3}
+
+	assert.EqualValues(t, oauth.ClientInput{ID: 3}, req.ToDomainInput())
+}
+
+func TestDto_CreateClientResponse(t *testing.T) {
+	now := time.Now()
+
+	resp := oauthdto.ToCreateClientResponse(&oauth.CreateClientOutput{
+		ClientSecret: "secret",
+		Client: &oauth.Client{
+			ID:         1,
+			ClientID:   "client",
+			Name:       "billing",
+			SecretHash: "hash",
+			Scopes:     "read write",
+			CreatedAt:  now,
+		},
+	})
+
+	assert.Equal(t, &oauthdto.CreateClientResponse{
+		ClientResponse: &oauthdto.ClientResponse{
+			ID:        1,
+			ClientID:  "client",
+			Name:      "billing",
+			Scopes:    []string{"read", "write"},
+			CreatedAt: now,
+		},
+		ClientSecret: "secret",
+	}, resp)
+}
+
+func TestDto_TokenRequest(t *testing.T) {
+	req := &oauthdto.TokenRequest{
+		GrantType:    "client_credentials",
+		Scope:        "read",
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}
+
+	expected := oauth.TokenInput{
+		GrantType:    "client_credentials",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scope:        "read",
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
+
+func TestDto_TokenResponse(t *testing.T) {
+	resp := oauthdto.ToTokenResponse(&oauth.TokenOutput{
+		AccessToken: "access-token",
+		TokenType:   "Bearer",
+		ExpiresIn:   15 * time.Minute,
+		Scopes:      []string{"read", "write"},
+	})
+
+	assert.Equal(t, &oauthdto.TokenResponse{
+		AccessToken: "access-token",
+		TokenType:   "Bearer",
+		ExpiresIn:   900,
+		Scope:       "read write",
+	}, resp)
+}
+
+func TestDto_IntrospectRequest(t *testing.T) {
+	req := &oauthdto.IntrospectRequest{
+		Token:         "access-token",
+		TokenTypeHint: "access_token",
+		ClientID:      "client",
+		ClientSecret:  "secret",
+	}
+
+	expected := oauth.IntrospectInput{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Token:        "access-token",
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
+
+func TestDto_IntrospectResponse(t *testing.T) {
+	expiresAt := time.Unix(1700000000, 0)
+
+	t.Run("client token", func(t *testing.T) {
+		resp := oauthdto.ToIntrospectResponse(&oauth.IntrospectOutput{
+			Active:    true,
+			ClientID:  "client",
+			Scopes:    []string{"read", "write"},
+			ExpiresAt: expiresAt,
+		})
+
+		assert.Equal(t, &oauthdto.IntrospectResponse{
+			Active:    true,
+			ClientID:  "client",
+			Scope:     "read write",
+			TokenType: "Bearer",
+			ExpiresAt: 1700000000,
+		}, resp)
+	})
+
+	t.Run("dpop bound token", func(t *testing.T) {
+		resp := oauthdto.ToIntrospectResponse(&oauth.IntrospectOutput{
+			Active:    true,
+			UserID:    2,
+			JKT:       "thumbprint",
+			ExpiresAt: expiresAt,
+		})
+
+		assert.Equal(t, "DPoP", resp.TokenType)
+		assert.Equal(t, &oauthdto.ConfirmationResponse{JKT: "thumbprint"}, resp.Confirmation)
+	})
+
+	t.Run("inactive token hides everything but the session", func(t *testing.T) {
+		resp := oauthdto.ToIntrospectResponse(&oauth.IntrospectOutput{
+			UserID:        2,
+			ExpiresAt:     expiresAt,
+			SessionStatus: oauth.SessionExpired,
+		})
+
+		assert.Equal(t, &oauthdto.IntrospectResponse{SessionStatus: "expired"}, resp)
+	})
+}
@@ -0,0 +1,74 @@
+package passkeydto
+
+import (
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/pkg/webauthn"
+	"time"
+)
+
+// RegisterPasskeyRequest holds the credential created by navigator.credentials.create, in the form of
+// PublicKeyCredential.toJSON.
+type RegisterPasskeyRequest struct {
+	Name       string                 `json:"name" binding:"max=100"`
+	Credential RegistrationCredential `json:"credential"`
+}
+
+type RegistrationCredential struct {
+	Type     string              `json:"type" binding:"required,eq=public-key"`
+	Response AttestationResponse `json:"response"`
+}
+
+type AttestationResponse struct {
+	ClientDataJSON    webauthn.URLEncoded `json:"clientDataJSON" binding:"required"`
+	AttestationObject webauthn.URLEncoded `json:"attestationObject" binding:"required"`
+}
+
+func (r *RegisterPasskeyRequest) ToDomainInput() passkey.RegisterInput {
+	return passkey.RegisterInput{
+		Name: r.Name,
+		Response: &webauthn.RegistrationResponse{
+			ClientDataJSON:    r.Credential.Response.ClientDataJSON,
+			AttestationObject: r.Credential.Response.AttestationObject,
+		},
+	}
+}
+
+type PasskeyRequest struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+func (r *PasskeyRequest) ToDomainInput() passkey.PasskeyInput {
+	return passkey.PasskeyInput{ID: r.ID}
+}
+
+// PasskeyResponse describes a passkey, the AAGUID tells its authenticator model.
+type PasskeyResponse struct {
+	ID             uint       `json:"id"`
+	Name           string     `json:"name"`
+	AAGUID         string     `json:"aaguid"`
+	BackupEligible bool       `json:"backup_eligible"`
+	BackedUp       bool       `json:"backed_up"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
+
+func ToPasskeyResponse(p *passkey.Passkey) *PasskeyResponse {
+	return &PasskeyResponse{
+		ID:             p.ID,
+		Name:           p.Name,
+		AAGUID:         p.AAGUID.String(),
+		BackupEligible: p.BackupEligible,
+		BackedUp:       p.BackedUp,
+		CreatedAt:      p.CreatedAt,
+		LastUsedAt:     p.LastUsedAt,
+	}
+}
+
+func ToPasskeyListResponse(passkeys []passkey.Passkey) []*PasskeyResponse {
+	resp := make([]*PasskeyResponse, 0, len(passkeys))
+	for i := range passkeys {
+		resp = append(resp, ToPasskeyResponse(&passkeys[i]))
+	}
+
+	return resp
+}
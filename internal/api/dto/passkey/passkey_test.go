@@ -0,0 +1,78 @@
+package passkeydto_test
+
+import (
+	"encoding/json"
+	passkeydto "gomonitor/internal/api/dto/passkey"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/pkg/webauthn"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDto_RegisterPasskeyRequest(t *testing.T) {
+	body := `{
+		"name": "Laptop",
+		"credential": {
+			"id": "AQI",
+			"rawId": "AQI",
+			"type": "public-key",
+			"response": {"clientDataJSON": "e30", "attestationObject": "oA", "transports": ["internal"]}
+		}
+	}`
+
+	var req passkeydto.RegisterPasskeyRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	expected := passkey.RegisterInput{
+		Name: "Laptop",
+		Response: &webauthn.RegistrationResponse{
+			ClientDataJSON:    []byte("{}"),
+			AttestationObject: []byte{0xa0},
+		},
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
+
+func TestDto_PasskeyRequest(t *testing.T) {
+	req := &passkeydto.PasskeyRequest{ID: 3}
+
+	assert.EqualValues(t, passkey.PasskeyInput{ID: 3}, req.ToDomainInput())
+}
+
+func TestDto_PasskeyResponse(t *testing.T) {
+	now := time.Now()
+	aaguid := uuid.New()
+
+	passkeys := []passkey.Passkey{
+		{
+			ID:             1,
+			UserID:         7,
+			CredentialID:   []byte{1},
+			PublicKey:      []byte{2},
+			SignCount:      4,
+			AAGUID:         aaguid,
+			Name:           "Laptop",
+			BackupEligible: true,
+			CreatedAt:      now,
+			LastUsedAt:     &now,
+		},
+	}
+
+	expected := []*passkeydto.PasskeyResponse{
+		{
+			ID:             1,
+			Name:           "Laptop",
+			AAGUID:         aaguid.String(),
+			BackupEligible: true,
+			CreatedAt:      now,
+			LastUsedAt:     &now,
+		},
+	}
+
+	assert.Equal(t, expected, passkeydto.ToPasskeyListResponse(passkeys))
+}
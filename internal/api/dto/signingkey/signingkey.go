@@ -0,0 +1,62 @@
+package signingkeydto
+
+import (
+	"gomonitor/internal/domain/signingkey"
+	"gomonitor/internal/pkg/jwt"
+	"time"
+)
+
+type CreateSigningKeyRequest struct {
+	TokenType jwt.TokenType `json:"token_type" binding:"required,oneof=access refresh"`
+	Algorithm string        `json:"algorithm" binding:"required,oneof=HS256 RS256 ES256 EdDSA"`
+}
+
+func (r *CreateSigningKeyRequest) ToDomainInput() signingkey.CreateKeyInput {
+	return signingkey.CreateKeyInput{
+		TokenType: r.TokenType,
+		Algorithm: r.Algorithm,
+	}
+}
+
+type SigningKeyRequest struct {
+	KID string `uri:"kid" binding:"required"`
+}
+
+func (r *SigningKeyRequest) ToDomainInput() signingkey.KeyInput {
+	return signingkey.KeyInput{
+		KID: r.KID,
+	}
+}
+
+// SigningKeyResponse never exposes the key material.
+type SigningKeyResponse struct {
+	KID        string        `json:"kid"`
+	TokenType  jwt.TokenType `json:"token_type"`
+	Algorithm  string        `json:"algorithm"`
+	Current    bool          `json:"current"`
+	CreatedAt  time.Time     `json:"created_at"`
+	PromotedAt *time.Time    `json:"promoted_at,omitempty"`
+	RetiredAt  *time.Time    `json:"retired_at,omitempty"`
+}
+
+func ToSigningKeyResponse(key *signingkey.SigningKey) *SigningKeyResponse {
+	return &SigningKeyResponse{
+		KID:       key.KID,
+		TokenType: key.TokenType,
+		Algorithm: key.Algorithm,
+		Current:   key.Current,
+
+		CreatedAt:  key.CreatedAt,
+		PromotedAt: key.PromotedAt,
+		RetiredAt:  key.RetiredAt,
+	}
+}
+
+func ToSigningKeyListResponse(keys []signingkey.SigningKey) []*SigningKeyResponse {
+	resp := make([]*SigningKeyResponse, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, ToSigningKeyResponse(&keys[i]))
+	}
+
+	return resp
+}
@@ -0,0 +1,60 @@
+package signingkeydto_test
+
+import (
+	signingkeydto "gomonitor/internal/api/dto/signingkey"
+	"gomonitor/internal/domain/signingkey"
+	"gomonitor/internal/pkg/jwt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_CreateSigningKeyRequest(t *testing.T) {
+	req := &signingkeydto.CreateSigningKeyRequest{
+		TokenType: jwt.TokenTypeAccess,
+		Algorithm: "EdDSA",
+	}
+
+	expected := signingkey.CreateKeyInput{
+		TokenType: jwt.TokenTypeAccess,
+		Algorithm: "EdDSA",
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
+
+func TestDto_SigningKeyRequest(t *testing.T) {
+	req := &signingkeydto.SigningKeyRequest{KID: "kid"}
+
+	assert.EqualValues(t, signingkey.KeyInput{KID: "kid"}, req.ToDomainInput())
+}
+
+func TestDto_SigningKeyResponse(t *testing.T) {
+	now := time.Now()
+
+	keys := []signingkey.SigningKey{
+		{
+			KID:        "kid",
+			TokenType:  jwt.TokenTypeAccess,
+			Algorithm:  "EdDSA",
+			Material:   "secret",
+			CreatedAt:  now,
+			PromotedAt: &now,
+			Current:    true,
+		},
+	}
+
+	expected := []*signingkeydto.SigningKeyResponse{
+		{
+			KID:        "kid",
+			TokenType:  jwt.TokenTypeAccess,
+			Algorithm:  "EdDSA",
+			Current:    true,
+			CreatedAt:  now,
+			PromotedAt: &now,
+		},
+	}
+
+	assert.EqualValues(t, expected, signingkeydto.ToSigningKeyListResponse(keys))
+}
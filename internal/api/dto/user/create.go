@@ -10,8 +10,10 @@ type CreateUserRequest struct {
 	Name     string             `json:"name" binding:"required"`
 	Email    string             `json:"email" binding:"required,email"`
 	UserName string             `json:"username" binding:"required"`
-	Password string             `json:"password" binding:"required,min=8,max=72"`
-	Role     *identity.UserRole `json:"role" binding:"omitempty,oneof=admin user"`
+	Password string             `json:"password" binding:"required"`
+	Role     *identity.UserRole `json:"role" binding:"omitempty,min=1,max=50"`
+	// EmailVerified skips the verification mail.
+	EmailVerified bool `json:"email_verified"`
 }
 
 func (r *CreateUserRequest) ToDomainInput() user.CreateUserInput {
@@ -21,16 +23,19 @@ func (r *CreateUserRequest) ToDomainInput() user.CreateUserInput {
 		UserName: r.UserName,
 		Password: r.Password,
 		Role:     r.Role,
+
+		EmailVerified: r.EmailVerified,
 	}
 }
 
 type CreateUserResponse struct {
-	ID        uint              `json:"id"`
-	Name      string            `json:"name"`
-	Email     string            `json:"email"`
-	UserName  string            `json:"username"`
-	Role      identity.UserRole `json:"role,omitempty"`
-	CreatedAt time.Time         `json:"created_at"`
+	ID              uint              `json:"id"`
+	Name            string            `json:"name"`
+	Email           string            `json:"email"`
+	UserName        string            `json:"username"`
+	Role            identity.UserRole `json:"role,omitempty"`
+	EmailVerifiedAt *time.Time        `json:"email_verified_at"`
+	CreatedAt       time.Time         `json:"created_at"`
 }
 
 func ToCreateUserResponse(user *user.User) *CreateUserResponse {
@@ -40,7 +45,8 @@ func ToCreateUserResponse(user *user.User) *CreateUserResponse {
 		Name:  user.Name,
 		Role:  user.Role,
 
-		CreatedAt: user.CreatedAt,
-		UserName:  user.UserName,
+		CreatedAt:       user.CreatedAt,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		UserName:        user.UserName,
 	}
 }
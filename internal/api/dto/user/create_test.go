@@ -19,6 +19,8 @@ func TestDto_CreateUserRequest(t *testing.T) {
 		Password: "test123",
 		UserName: "test",
 		Role:     role,
+
+		EmailVerified: true,
 	}
 
 	expectedCreateUserInput := user.CreateUserInput{
@@ -27,6 +29,8 @@ func TestDto_CreateUserRequest(t *testing.T) {
 		Password: "test123",
 		UserName: "test",
 		Role:     role,
+
+		EmailVerified: true,
 	}
 
 	createUserInput := createUserRequest.ToDomainInput()
@@ -0,0 +1,35 @@
+package userdto
+
+import "gomonitor/internal/domain/user"
+
+type RequestEmailVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func (r *RequestEmailVerificationRequest) ToDomainInput() user.RequestEmailVerificationInput {
+	return user.RequestEmailVerificationInput{
+		Email: r.Email,
+	}
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+func (r *ChangeEmailRequest) ToDomainInput() user.ChangeEmailInput {
+	return user.ChangeEmailInput{
+		NewEmail: r.Email,
+		Password: r.Password,
+	}
+}
+
+type ConfirmEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (r *ConfirmEmailRequest) ToDomainInput() user.ConfirmEmailInput {
+	return user.ConfirmEmailInput{
+		Token: r.Token,
+	}
+}
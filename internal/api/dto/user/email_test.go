@@ -0,0 +1,35 @@
+package userdto_test
+
+import (
+	userdto "gomonitor/internal/api/dto/user"
+	"gomonitor/internal/domain/user"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_RequestEmailVerificationRequest(t *testing.T) {
+	req := &userdto.RequestEmailVerificationRequest{Email: "test@test.com"}
+
+	assert.EqualValues(t, user.RequestEmailVerificationInput{Email: "test@test.com"}, req.ToDomainInput())
+}
+
+func TestDto_ChangeEmailRequest(t *testing.T) {
+	req := &userdto.ChangeEmailRequest{
+		Email:    "new@test.com",
+		Password: "password123",
+	}
+
+	expected := user.ChangeEmailInput{
+		NewEmail: "new@test.com",
+		Password: "password123",
+	}
+
+	assert.EqualValues(t, expected, req.ToDomainInput())
+}
+
+func TestDto_ConfirmEmailRequest(t *testing.T) {
+	req := &userdto.ConfirmEmailRequest{Token: "token"}
+
+	assert.EqualValues(t, user.ConfirmEmailInput{Token: "token"}, req.ToDomainInput())
+}
@@ -17,13 +17,16 @@ func (r *GetUserRequest) ToDomainInput() user.GetUserInput {
 }
 
 type GetUserResponse struct {
-	ID        uint              `json:"id"`
-	Name      string            `json:"name"`
-	Email     string            `json:"email"`
-	UserName  string            `json:"username"`
-	Role      identity.UserRole `json:"role,omitempty"`
-	CreatedAt time.Time         `json:"created_at"`
-	UpdatedAt time.Time
+	ID              uint              `json:"id"`
+	Name            string            `json:"name"`
+	Email           string            `json:"email"`
+	UserName        string            `json:"username"`
+	Role            identity.UserRole `json:"role,omitempty"`
+	EmailVerifiedAt *time.Time        `json:"email_verified_at"`
+	LastLoginAt     *time.Time        `json:"last_login_at"`
+	LastLoginIP     *string           `json:"last_login_ip"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time
 }
 
 func ToGetUserResponse(user *user.User) *GetUserResponse {
@@ -34,7 +37,10 @@ func ToGetUserResponse(user *user.User) *GetUserResponse {
 		Role:     user.Role,
 		UserName: user.UserName,
 
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		LastLoginAt:     user.LastLoginAt,
+		LastLoginIP:     user.LastLoginIP,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	}
 }
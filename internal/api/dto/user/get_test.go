@@ -4,6 +4,7 @@ import (
 	userdto "gomonitor/internal/api/dto/user"
 	"gomonitor/internal/domain/user"
 	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/testutil"
 	"testing"
 	"time"
 
@@ -36,6 +37,9 @@ func TestDto_GetUserResponse(t *testing.T) {
 		Role:      identity.RoleUser,
 		CreatedAt: now,
 		UpdatedAt: now,
+
+		LastLoginAt: &now,
+		LastLoginIP: testutil.Ptr("192.0.2.1"),
 	}
 
 	expectedGetUserResponse := &userdto.GetUserResponse{
@@ -46,6 +50,9 @@ func TestDto_GetUserResponse(t *testing.T) {
 		Role:      identity.RoleUser,
 		CreatedAt: now,
 		UpdatedAt: now,
+
+		LastLoginAt: &now,
+		LastLoginIP: testutil.Ptr("192.0.2.1"),
 	}
 
 	getUserResponse := userdto.ToGetUserResponse(user)
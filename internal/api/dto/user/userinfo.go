@@ -0,0 +1,30 @@
+package userdto
+
+import (
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/pkg/identity"
+	"strconv"
+)
+
+// UserInfoResponse is the profile of the caller with the standard claims of OpenID Connect, role is an extension.
+type UserInfoResponse struct {
+	Subject           string            `json:"sub"`
+	Name              string            `json:"name"`
+	PreferredUsername string            `json:"preferred_username"`
+	Email             string            `json:"email"`
+	EmailVerified     bool              `json:"email_verified"`
+	Role              identity.UserRole `json:"role"`
+	UpdatedAt         int64             `json:"updated_at"`
+}
+
+func ToUserInfoResponse(user *user.User) *UserInfoResponse {
+	return &UserInfoResponse{
+		Subject:           strconv.FormatUint(uint64(user.ID), 10),
+		Name:              user.Name,
+		PreferredUsername: user.UserName,
+		Email:             user.Email,
+		EmailVerified:     user.EmailVerified(),
+		Role:              user.Role,
+		UpdatedAt:         user.UpdatedAt.Unix(),
+	}
+}
@@ -0,0 +1,36 @@
+package userdto_test
+
+import (
+	userdto "gomonitor/internal/api/dto/user"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/pkg/identity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDto_UserInfoResponse(t *testing.T) {
+	updatedAt := time.Unix(1700000000, 0)
+
+	resp := userdto.ToUserInfoResponse(&user.User{
+		ID:              7,
+		Name:            "test",
+		Email:           "test@test.com",
+		UserName:        "tester",
+		Password:        "hash",
+		Role:            identity.RoleUser,
+		EmailVerifiedAt: &updatedAt,
+		UpdatedAt:       updatedAt,
+	})
+
+	assert.Equal(t, &userdto.UserInfoResponse{
+		Subject:           "7",
+		Name:              "test",
+		PreferredUsername: "tester",
+		Email:             "test@test.com",
+		EmailVerified:     true,
+		Role:              identity.RoleUser,
+		UpdatedAt:         1700000000,
+	}, resp)
+}
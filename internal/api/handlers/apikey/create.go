@@ -0,0 +1,28 @@
+package apikeyhandler
+
+import (
+	apikeydto "gomonitor/internal/api/dto/apikey"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) Create(c *gin.Context) {
+	var req apikeydto.CreateAPIKeyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	input := req.ToDomainInput()
+
+	out, err := h.service.Create(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, apikeydto.ToCreateAPIKeyResponse(out))
+}
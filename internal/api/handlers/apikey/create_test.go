@@ -0,0 +1,124 @@
+package apikeyhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	apikeydto "gomonitor/internal/api/dto/apikey"
+	apikeyhandler "gomonitor/internal/api/handlers/apikey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/apikey"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Create(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultRequest := apikeydto.CreateAPIKeyRequest{
+		Name:   "ci",
+		Scopes: []string{"read"},
+	}
+
+	tests := []struct {
+		name           string
+		body           any
+		setupMock      func(*mocks.MockAPIKeyService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "missing name",
+			body:           apikeydto.CreateAPIKeyRequest{Scopes: []string{"read"}},
+			setupMock:      func(m *mocks.MockAPIKeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing scopes",
+			body:           apikeydto.CreateAPIKeyRequest{Name: "ci"},
+			setupMock:      func(m *mocks.MockAPIKeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown scope",
+			body:           apikeydto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"admin"}},
+			setupMock:      func(m *mocks.MockAPIKeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: defaultRequest,
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Create", mock.Anything, defaultRequest.ToDomainInput()).
+					Return(nil, pkgerrors.NewForbiddenError())
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "success returns the key once",
+			body: defaultRequest,
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Create", mock.Anything, defaultRequest.ToDomainInput()).
+					Return(&apikey.CreateOutput{
+						Key: "gmk_secret",
+						APIKey: &apikey.APIKey{
+							ID:        1,
+							Name:      "ci",
+							Prefix:    "gmk_secr",
+							KeyHash:   "hash",
+							Scopes:    "read",
+							CreatedAt: time.Now(),
+						},
+					}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp apikeydto.CreateAPIKeyResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "gmk_secret", resp.Key)
+				assert.Equal(t, uint(1), resp.ID)
+				assert.Equal(t, []string{"read"}, resp.Scopes)
+				assert.NotContains(t, rec.Body.String(), "hash")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAPIKeyService{}
+			tt.setupMock(mockService)
+
+			h := apikeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/api-keys", h.Create)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
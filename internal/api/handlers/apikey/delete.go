@@ -0,0 +1,25 @@
+package apikeyhandler
+
+import (
+	apikeydto "gomonitor/internal/api/dto/apikey"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) Delete(c *gin.Context) {
+	var req apikeydto.APIKeyRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid ID parameter", err))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), req.ToDomainInput()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,73 @@
+package apikeyhandler_test
+
+import (
+	apikeyhandler "gomonitor/internal/api/handlers/apikey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/apikey"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandler_Delete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		setupMock      func(*mocks.MockAPIKeyService)
+		expectedStatus int
+	}{
+		{
+			name:           "invalid id",
+			path:           "/api-keys/0",
+			setupMock:      func(m *mocks.MockAPIKeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not found",
+			path: "/api-keys/1",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Delete", mock.Anything, apikey.KeyInput{ID: 1}).
+					Return(pkgerrors.NewNotFoundError(apikey.MsgAPIKeyNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			path: "/api-keys/1",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Delete", mock.Anything, apikey.KeyInput{ID: 1}).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAPIKeyService{}
+			tt.setupMock(mockService)
+
+			h := apikeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.DELETE("/api-keys/:id", h.Delete)
+
+			req := httptest.NewRequest(http.MethodDelete, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
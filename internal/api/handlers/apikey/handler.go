@@ -0,0 +1,33 @@
+package apikeyhandler
+
+import (
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/apikey"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logger   *slog.Logger
+	service  apikey.Service
+	authDeps *middlewares.AuthDeps
+}
+
+func NewHandler(logger *slog.Logger, svc apikey.Service, authDeps *middlewares.AuthDeps) *Handler {
+	return &Handler{
+		logger:   logger,
+		service:  svc,
+		authDeps: authDeps,
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	keys := r.Group("/users/me/api-keys", middlewares.AuthMiddleware(h.authDeps))
+	{
+		keys.GET("", h.List)
+		keys.POST("", middlewares.RequireRecentAuth(h.authDeps.RecentAuthMaxAge), h.Create)
+		keys.GET("/:id", h.Get)
+		keys.DELETE("/:id", h.Delete)
+	}
+}
@@ -0,0 +1,113 @@
+package apikeyhandler_test
+
+import (
+	apikeyhandler "gomonitor/internal/api/handlers/apikey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_NewHandler(t *testing.T) {
+	handler := apikeyhandler.NewHandler(slog.Default(), &mocks.MockAPIKeyService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+	assert.NotNil(t, handler)
+}
+
+func TestHandler_RegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "list route exists",
+			method:         http.MethodGet,
+			path:           "/api/v1/users/me/api-keys",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "create route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/users/me/api-keys",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "get route exists",
+			method:         http.MethodGet,
+			path:           "/api/v1/users/me/api-keys/1",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "delete route exists",
+			method:         http.MethodDelete,
+			path:           "/api/v1/users/me/api-keys/1",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "key routes don't accept PUT",
+			method:         http.MethodPut,
+			path:           "/api/v1/users/me/api-keys/1",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := apikeyhandler.NewHandler(slog.Default(), &mocks.MockAPIKeyService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.HandleMethodNotAllowed = true
+			router.Use(middlewares.ErrorMiddleware())
+
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_CreateRequiresRecentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwtManager := &mocks.MockJwtManager{}
+	jwtManager.On("ValidateAccessToken", "token").Return(&identity.Principal{
+		UserID:   1,
+		Role:     identity.RoleUser,
+		Source:   identity.AuthExternal,
+		AuthTime: time.Now().Add(-time.Hour),
+	}, nil)
+
+	h := apikeyhandler.NewHandler(slog.Default(), &mocks.MockAPIKeyService{}, &middlewares.AuthDeps{
+		RecentAuthMaxAge: 10 * time.Minute,
+		TokenManager:     jwtManager,
+	})
+
+	router := gin.New()
+	router.Use(middlewares.ErrorMiddleware())
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/api-keys", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+}
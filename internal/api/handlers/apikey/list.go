@@ -0,0 +1,36 @@
+package apikeyhandler
+
+import (
+	apikeydto "gomonitor/internal/api/dto/apikey"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) List(c *gin.Context) {
+	keys, err := h.service.List(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, apikeydto.ToAPIKeyListResponse(keys))
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	var req apikeydto.APIKeyRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid ID parameter", err))
+		return
+	}
+
+	key, err := h.service.Get(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, apikeydto.ToAPIKeyResponse(key))
+}
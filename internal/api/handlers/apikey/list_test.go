@@ -0,0 +1,131 @@
+package apikeyhandler_test
+
+import (
+	"encoding/json"
+	apikeydto "gomonitor/internal/api/dto/apikey"
+	apikeyhandler "gomonitor/internal/api/handlers/apikey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/apikey"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_List(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		setupMock      func(*mocks.MockAPIKeyService)
+		expectedStatus int
+		expectedLen    int
+	}{
+		{
+			name: "service error",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("List", mock.Anything).Return(nil, pkgerrors.NewInternalError())
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("List", mock.Anything).Return([]apikey.APIKey{{ID: 1}, {ID: 2}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAPIKeyService{}
+			tt.setupMock(mockService)
+
+			h := apikeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.GET("/api-keys", h.List)
+
+			req := httptest.NewRequest(http.MethodGet, "/api-keys", nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var resp []apikeydto.APIKeyResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Len(t, resp, tt.expectedLen)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Get(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		setupMock      func(*mocks.MockAPIKeyService)
+		expectedStatus int
+	}{
+		{
+			name:           "invalid id",
+			path:           "/api-keys/abc",
+			setupMock:      func(m *mocks.MockAPIKeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not found",
+			path: "/api-keys/1",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Get", mock.Anything, apikey.KeyInput{ID: 1}).
+					Return(nil, pkgerrors.NewNotFoundError(apikey.MsgAPIKeyNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			path: "/api-keys/1",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Get", mock.Anything, apikey.KeyInput{ID: 1}).Return(&apikey.APIKey{ID: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAPIKeyService{}
+			tt.setupMock(mockService)
+
+			h := apikeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.GET("/api-keys/:id", h.Get)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package authhandler
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/auth"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/opaquetoken"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cookieMode reports if the tokens are handed out as cookies instead of in the response body.
+func (h *Handler) cookieMode() bool {
+	return h.authDeps.Session != nil && h.authDeps.Session.CookieMode
+}
+
+// writeLogin responds with the token pair of a new session.
+func (h *Handler) writeLogin(c *gin.Context, login *auth.LoginOutput) {
+	if !h.cookieMode() {
+		c.JSON(http.StatusOK, authdto.ToLoginResponse(login))
+		return
+	}
+
+	h.writeSessionCookies(c, login.AccessToken, login.AccessTokenExpiresAt, login.RefreshToken, login.RefreshTokenExpiresAt)
+}
+
+// writeSessionCookies sets the token pair as HttpOnly cookies, along with a new CSRF token. The refresh
+// token is only sent back to the auth routes.
+func (h *Handler) writeSessionCookies(c *gin.Context, accessToken string, accessExpiresAt time.Time, refreshToken string, refreshExpiresAt time.Time) {
+	csrf, err := opaquetoken.New()
+	if err != nil {
+		_ = c.Error(pkgerrors.NewInternalError(err))
+		return
+	}
+
+	h.setCookie(c, middlewares.AccessTokenCookie, accessToken, "/", accessExpiresAt, true)
+	h.setCookie(c, middlewares.RefreshTokenCookie, refreshToken, h.refreshCookiePath(), refreshExpiresAt, true)
+	// The frontend reads it to echo it in the CSRF header, it lives as long as the session.
+	h.setCookie(c, middlewares.CSRFCookie, csrf, "/", refreshExpiresAt, false)
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) clearSessionCookies(c *gin.Context) {
+	h.setCookie(c, middlewares.AccessTokenCookie, "", "/", time.Time{}, true)
+	h.setCookie(c, middlewares.RefreshTokenCookie, "", h.refreshCookiePath(), time.Time{}, true)
+	h.setCookie(c, middlewares.CSRFCookie, "", "/", time.Time{}, false)
+}
+
+// setCookie deletes the cookie without expiry.
+func (h *Handler) setCookie(c *gin.Context, name, value, path string, expiresAt time.Time, httpOnly bool) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.authDeps.Session.CookieDomain,
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: h.authDeps.Session.CookieSameSite,
+	}
+	if !expiresAt.IsZero() {
+		cookie.MaxAge = max(int(time.Until(expiresAt).Seconds()), 1)
+	}
+
+	http.SetCookie(c.Writer, cookie)
+}
+
+func (h *Handler) refreshCookiePath() string {
+	if h.authPath == "" {
+		return "/"
+	}
+	return h.authPath
+}
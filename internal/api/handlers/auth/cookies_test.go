@@ -0,0 +1,194 @@
+package authhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	authdto "gomonitor/internal/api/dto/auth"
+	authhandler "gomonitor/internal/api/handlers/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var cookieSession = &config.SessionConfig{
+	CookieMode:        true,
+	CookieDomain:      "app.example.com",
+	CookieSameSite:    http.SameSiteStrictMode,
+	CredentialSources: []string{config.CredentialSourceHeader, config.CredentialSourceCookie},
+}
+
+func newCookieRouter(mockService *mocks.MockAuthService, jwtManager *mocks.MockJwtManager) *gin.Engine {
+	h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{
+		Session:      cookieSession,
+		TokenManager: jwtManager,
+	})
+
+	router := gin.New()
+	router.Use(middlewares.ErrorMiddleware())
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	return router
+}
+
+func cookiesByName(rec *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range rec.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	return cookies
+}
+
+func TestHandler_LoginCookieMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &mocks.MockAuthService{}
+	mockService.On("Login", mock.Anything, mock.Anything).Return(&auth.LoginOutput{
+		AccessToken:           "access-token",
+		RefreshToken:          "refresh-token",
+		AccessTokenExpiresAt:  time.Now().Add(time.Hour),
+		RefreshTokenExpiresAt: time.Now().Add(24 * time.Hour),
+	}, nil)
+
+	body, err := json.Marshal(authdto.LoginRequest{Email: "test@test.com", Password: "password"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	newCookieRouter(mockService, &mocks.MockJwtManager{}).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "access-token")
+
+	cookies := cookiesByName(rec)
+
+	access := cookies[middlewares.AccessTokenCookie]
+	require.NotNil(t, access)
+	assert.Equal(t, "access-token", access.Value)
+	assert.Equal(t, "/", access.Path)
+	assert.Equal(t, "app.example.com", access.Domain)
+	assert.True(t, access.HttpOnly)
+	assert.True(t, access.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, access.SameSite)
+	assert.InDelta(t, time.Hour.Seconds(), access.MaxAge, 5)
+
+	refresh := cookies[middlewares.RefreshTokenCookie]
+	require.NotNil(t, refresh)
+	assert.Equal(t, "refresh-token", refresh.Value)
+	assert.Equal(t, "/api/v1/auth", refresh.Path)
+	assert.True(t, refresh.HttpOnly)
+
+	csrf := cookies[middlewares.CSRFCookie]
+	require.NotNil(t, csrf)
+	assert.NotEmpty(t, csrf.Value)
+	assert.False(t, csrf.HttpOnly)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_RefreshCookieMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		csrfHeader     string
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:           "missing csrf token",
+			setupMock:      func(*mocks.MockAuthService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:       "refreshes from the cookie",
+			csrfHeader: "csrf",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("Refresh", mock.Anything, mock.MatchedBy(func(input auth.RefreshInput) bool {
+					return input.RefreshToken == "refresh-token"
+				})).Return(&auth.RefreshOutput{
+					AccessToken:           "new-access-token",
+					RefreshToken:          "new-refresh-token",
+					AccessTokenExpiresAt:  time.Now().Add(time.Hour),
+					RefreshTokenExpiresAt: time.Now().Add(24 * time.Hour),
+				}, nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+			req.AddCookie(&http.Cookie{Name: middlewares.RefreshTokenCookie, Value: "refresh-token"})
+			req.AddCookie(&http.Cookie{Name: middlewares.CSRFCookie, Value: "csrf"})
+			if tt.csrfHeader != "" {
+				req.Header.Set(middlewares.CSRFHeader, tt.csrfHeader)
+			}
+			rec := httptest.NewRecorder()
+
+			newCookieRouter(mockService, &mocks.MockJwtManager{}).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus == http.StatusNoContent {
+				cookies := cookiesByName(rec)
+				require.NotNil(t, cookies[middlewares.AccessTokenCookie])
+				assert.Equal(t, "new-access-token", cookies[middlewares.AccessTokenCookie].Value)
+				require.NotNil(t, cookies[middlewares.CSRFCookie])
+				assert.NotEqual(t, "csrf", cookies[middlewares.CSRFCookie].Value)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_LogoutCookieMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &mocks.MockAuthService{}
+	mockService.On("Logout", mock.Anything, mock.Anything).Return(nil)
+
+	jwtManager := &mocks.MockJwtManager{}
+	jwtManager.On("ValidateAccessToken", "access-token").Return(&identity.Principal{
+		UserID: 1,
+		Role:   identity.RoleUser,
+		Source: identity.AuthExternal,
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: middlewares.AccessTokenCookie, Value: "access-token"})
+	req.AddCookie(&http.Cookie{Name: middlewares.CSRFCookie, Value: "csrf"})
+	req.Header.Set(middlewares.CSRFHeader, "csrf")
+	rec := httptest.NewRecorder()
+
+	newCookieRouter(mockService, jwtManager).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	cookies := cookiesByName(rec)
+	for _, name := range []string{middlewares.AccessTokenCookie, middlewares.RefreshTokenCookie, middlewares.CSRFCookie} {
+		require.NotNil(t, cookies[name], name)
+		assert.Empty(t, cookies[name].Value)
+		assert.Negative(t, cookies[name].MaxAge)
+	}
+
+	mockService.AssertExpectations(t)
+}
@@ -3,36 +3,91 @@ package authhandler
 import (
 	"gomonitor/internal/api/middlewares"
 	"gomonitor/internal/domain/auth"
-	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/identity"
 	"log/slog"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	logger       *slog.Logger
-	service      auth.Service
-	tokenManager jwt.TokenManager
+	logger   *slog.Logger
+	service  auth.Service
+	authDeps *middlewares.AuthDeps
+	// authPath scopes the refresh token cookie, it's known once the routes are registered.
+	authPath string
 }
 
-func NewHandler(logger *slog.Logger, svc auth.Service, tokenManager jwt.TokenManager) *Handler {
+func NewHandler(logger *slog.Logger, svc auth.Service, authDeps *middlewares.AuthDeps) *Handler {
 	return &Handler{
-		logger:       logger,
-		service:      svc,
-		tokenManager: tokenManager,
+		logger:   logger,
+		service:  svc,
+		authDeps: authDeps,
 	}
 }
 
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	auth := r.Group("/auth")
+	h.authPath = auth.BasePath()
 	{
-		auth.POST("login", h.Login)
-		auth.POST("refresh", h.Refresh)
+		// The tokens issued by these are bound to the DPoP key of the client when it sends a proof.
+		dpopProof := middlewares.DPoPProof(h.authDeps)
+		auth.POST("login", dpopProof, h.Login)
+		auth.POST("refresh", dpopProof, h.Refresh)
+		auth.POST("mfa/verify", dpopProof, h.VerifyMFA)
+		auth.POST("passkeys/login/options", h.PasskeyLoginOptions)
+		auth.POST("passkeys/login", dpopProof, h.PasskeyLogin)
+		auth.POST("password/forgot", h.ForgotPassword)
+		auth.POST("password/reset", h.ResetPassword)
+		auth.POST("magic-link", h.RequestMagicLink)
+		auth.POST("magic-link/consume", dpopProof, h.ConsumeMagicLink)
+		auth.GET("oidc/login", h.OIDCLogin)
+		auth.GET("oidc/callback", h.OIDCCallback)
 
-		logout := auth.Group("logout", middlewares.AuthMiddleware(h.tokenManager))
+		logout := auth.Group("logout", middlewares.AuthMiddleware(h.authDeps))
 		{
 			logout.POST("", h.Logout)
 			logout.POST("all", h.LogoutAll)
 		}
+
+		reauthenticate := auth.Group("reauthenticate", middlewares.AuthMiddleware(h.authDeps))
+		{
+			reauthenticate.POST("", h.Reauthenticate)
+			reauthenticate.POST("passkey/options", h.PasskeyReauthenticateOptions)
+			reauthenticate.POST("passkey", h.PasskeyReauthenticate)
+		}
+
+		sessions := auth.Group("sessions", middlewares.AuthMiddleware(h.authDeps))
+		{
+			sessions.GET("", h.ListSessions)
+			sessions.DELETE(":jti", h.RevokeSession)
+		}
+	}
+
+	r.POST("/users/me/password",
+		middlewares.AuthMiddleware(h.authDeps),
+		middlewares.RequireRecentAuth(h.authDeps.RecentAuthMaxAge),
+		h.ChangePassword,
+	)
+
+	userSessions := r.Group("/users/:id/sessions", middlewares.AuthMiddleware(h.authDeps))
+	{
+		userSessions.GET("", h.ListUserSessions)
+		userSessions.DELETE("/:jti", h.RevokeUserSession)
+	}
+
+	r.POST("/admin/users/:id/impersonate",
+		middlewares.AuthMiddleware(h.authDeps),
+		middlewares.RequirePermission(identity.PermUsersImpersonate),
+		middlewares.RequireRecentAuth(h.authDeps.RecentAuthMaxAge),
+		h.Impersonate,
+	)
+}
+
+// clientInfo records where a session is used from.
+func clientInfo(c *gin.Context) auth.ClientInfo {
+	return auth.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+		DPoPJKT:   middlewares.DPoPThumbprint(c),
 	}
 }
@@ -4,17 +4,21 @@ import (
 	authhandler "gomonitor/internal/api/handlers/auth"
 	"gomonitor/internal/api/middlewares"
 	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/identity"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestHandler_NewHandler(t *testing.T) {
-	handler := authhandler.NewHandler(slog.Default(), &mocks.MockAuthService{}, &mocks.MockJwtManager{})
+	handler := authhandler.NewHandler(slog.Default(), &mocks.MockAuthService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
 
 	assert.NotNil(t, handler)
 }
@@ -43,6 +47,62 @@ func TestHandler_RegisterRoutes(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			shouldExist:    true,
 		},
+		{
+			name:           "mfa verify route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/mfa/verify",
+			expectedStatus: http.StatusBadRequest,
+			shouldExist:    true,
+		},
+		{
+			name:           "passkey login options route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/passkeys/login/options",
+			expectedStatus: http.StatusBadRequest,
+			shouldExist:    true,
+		},
+		{
+			name:           "passkey login route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/passkeys/login",
+			expectedStatus: http.StatusBadRequest,
+			shouldExist:    true,
+		},
+		{
+			name:           "forgot password route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/password/forgot",
+			expectedStatus: http.StatusBadRequest,
+			shouldExist:    true,
+		},
+		{
+			name:           "reset password route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/password/reset",
+			expectedStatus: http.StatusBadRequest,
+			shouldExist:    true,
+		},
+		{
+			name:           "magic link route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/magic-link",
+			expectedStatus: http.StatusBadRequest,
+			shouldExist:    true,
+		},
+		{
+			name:           "consume magic link route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/magic-link/consume",
+			expectedStatus: http.StatusBadRequest,
+			shouldExist:    true,
+		},
+		{
+			name:           "oidc callback route exists",
+			method:         http.MethodGet,
+			path:           "/api/v1/auth/oidc/callback",
+			expectedStatus: http.StatusBadRequest,
+			shouldExist:    true,
+		},
 		{
 			name:           "logout route exists",
 			method:         http.MethodPost,
@@ -50,6 +110,69 @@ func TestHandler_RegisterRoutes(t *testing.T) {
 			expectedStatus: http.StatusUnauthorized,
 			shouldExist:    true,
 		},
+		{
+			name:           "reauthenticate route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/reauthenticate",
+			expectedStatus: http.StatusUnauthorized,
+			shouldExist:    true,
+		},
+		{
+			name:           "passkey reauthenticate options route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/reauthenticate/passkey/options",
+			expectedStatus: http.StatusUnauthorized,
+			shouldExist:    true,
+		},
+		{
+			name:           "passkey reauthenticate route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/reauthenticate/passkey",
+			expectedStatus: http.StatusUnauthorized,
+			shouldExist:    true,
+		},
+		{
+			name:           "sessions route exists",
+			method:         http.MethodGet,
+			path:           "/api/v1/auth/sessions",
+			expectedStatus: http.StatusUnauthorized,
+			shouldExist:    true,
+		},
+		{
+			name:           "revoke session route exists",
+			method:         http.MethodDelete,
+			path:           "/api/v1/auth/sessions/" + uuid.NewString(),
+			expectedStatus: http.StatusUnauthorized,
+			shouldExist:    true,
+		},
+		{
+			name:           "change password route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/users/me/password",
+			expectedStatus: http.StatusUnauthorized,
+			shouldExist:    true,
+		},
+		{
+			name:           "user sessions route exists",
+			method:         http.MethodGet,
+			path:           "/api/v1/users/1/sessions",
+			expectedStatus: http.StatusUnauthorized,
+			shouldExist:    true,
+		},
+		{
+			name:           "revoke user session route exists",
+			method:         http.MethodDelete,
+			path:           "/api/v1/users/1/sessions/" + uuid.NewString(),
+			expectedStatus: http.StatusUnauthorized,
+			shouldExist:    true,
+		},
+		{
+			name:           "impersonate route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/admin/users/1/impersonate",
+			expectedStatus: http.StatusUnauthorized,
+			shouldExist:    true,
+		},
 		{
 			name:           "login only accepts POST",
 			method:         http.MethodGet,
@@ -71,7 +194,7 @@ func TestHandler_RegisterRoutes(t *testing.T) {
 			mockService := &mocks.MockAuthService{}
 			mockJwtManager := &mocks.MockJwtManager{}
 
-			h := authhandler.NewHandler(slog.Default(), mockService, mockJwtManager)
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: mockJwtManager})
 
 			gin.SetMode(gin.TestMode)
 			router := gin.New()
@@ -91,3 +214,53 @@ func TestHandler_RegisterRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_RoutesRequireRecentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{
+			name:   "impersonation",
+			method: http.MethodPost,
+			path:   "/api/v1/admin/users/2/impersonate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtManager := &mocks.MockJwtManager{}
+			jwtManager.On("ValidateAccessToken", "token").Return(&identity.Principal{
+				UserID:   1,
+				Role:     identity.RoleAdmin,
+				Source:   identity.AuthExternal,
+				AuthTime: time.Now().Add(-time.Hour),
+			}, nil)
+			permissions := &mocks.MockRBACService{}
+			permissions.On("Permissions", mock.Anything, identity.RoleAdmin).
+				Return([]identity.Permission{identity.PermUsersImpersonate}, nil)
+
+			h := authhandler.NewHandler(slog.Default(), &mocks.MockAuthService{}, &middlewares.AuthDeps{
+				Permissions:      permissions,
+				RecentAuthMaxAge: 10 * time.Minute,
+				TokenManager:     jwtManager,
+			})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package authhandler
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Impersonate returns the token in the body only, the admin's own session cookies are left alone.
+func (h *Handler) Impersonate(c *gin.Context) {
+	var req authdto.ImpersonateRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid ID parameter", err))
+		return
+	}
+
+	output, err := h.service.Impersonate(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, authdto.ToImpersonationResponse(output))
+}
@@ -0,0 +1,94 @@
+package authhandler_test
+
+import (
+	"encoding/json"
+	authdto "gomonitor/internal/api/dto/auth"
+	authhandler "gomonitor/internal/api/handlers/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Impersonate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	expiresAt := time.Now().Add(15 * time.Minute).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name           string
+		route          string
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "success",
+			route: "/admin/users/2/impersonate",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("Impersonate", mock.Anything, auth.ImpersonateInput{UserID: 2}).
+					Return(&auth.ImpersonationOutput{AccessToken: "impersonation", AccessTokenExpiresAt: expiresAt}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp authdto.ImpersonationResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "impersonation", resp.AccessToken)
+				assert.True(t, expiresAt.Equal(resp.ExpiresAt))
+				assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+				assert.Empty(t, rec.Result().Cookies())
+			},
+		},
+		{
+			name:           "invalid user id",
+			route:          "/admin/users/abc/impersonate",
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "forbidden",
+			route: "/admin/users/2/impersonate",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("Impersonate", mock.Anything, auth.ImpersonateInput{UserID: 2}).
+					Return(nil, pkgerrors.NewForbiddenError())
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/admin/users/:id/impersonate", h.Impersonate)
+
+			req := httptest.NewRequest(http.MethodPost, tt.route, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package authhandler_test
+
+import (
+	"encoding/json"
+	authhandler "gomonitor/internal/api/handlers/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/jwt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_JWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwks := jwt.JWKS{
+		Keys: []jwt.JWK{
+			{Kty: "OKP", Crv: "Ed25519", X: "x", Kid: "kid", Alg: "EdDSA", Use: "sig"},
+		},
+	}
+
+	mockJwtManager := &mocks.MockJwtManager{}
+	mockJwtManager.On("JWKS").Return(jwks)
+
+	h := authhandler.NewHandler(slog.Default(), &mocks.MockAuthService{}, &middlewares.AuthDeps{TokenManager: mockJwtManager})
+
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", h.JWKS)
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", http.NoBody)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp jwt.JWKS
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, jwks, resp)
+
+	mockJwtManager.AssertExpectations(t)
+}
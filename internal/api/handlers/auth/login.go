@@ -19,6 +19,7 @@ func (h *Handler) Login(c *gin.Context) {
 	}
 
 	input := req.ToDomainInput()
+	input.Client = clientInfo(c)
 
 	login, err := h.service.Login(c.Request.Context(), input)
 	if err != nil {
@@ -26,9 +27,19 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	resp := authdto.ToLoginResponse(login)
+	if login.MFAChallenge != nil {
+		logging.FromContext(c.Request.Context()).Info("login requires mfa", slog.String("user", input.Email))
+		c.JSON(http.StatusOK, authdto.ToMFAChallengeResponse(login.MFAChallenge))
+		return
+	}
+
+	if login.StepUp != nil {
+		logging.FromContext(c.Request.Context()).Info("login requires step-up verification", slog.String("user", input.Email))
+		c.JSON(http.StatusOK, authdto.ToStepUpResponse(login.StepUp))
+		return
+	}
 
 	logging.FromContext(c.Request.Context()).Info("successfull login attempt", slog.String("user", input.Email))
 
-	c.JSON(http.StatusOK, resp)
+	h.writeLogin(c, login)
 }
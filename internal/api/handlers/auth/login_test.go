@@ -87,6 +87,48 @@ func TestHandler_Login(t *testing.T) {
 				assert.Equal(t, "access-token", resp.AccessToken)
 			},
 		},
+		{
+			name: "mfa challenge",
+			requestBody: authdto.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("Login", mock.Anything, mock.Anything).Return(&auth.LoginOutput{
+					MFAChallenge: &auth.MFAChallengeOutput{Token: "challenge"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp map[string]any
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, true, resp["mfa_required"])
+				assert.Equal(t, "challenge", resp["challenge_token"])
+				assert.NotContains(t, resp, "token")
+				assert.NotContains(t, resp, "refresh_token")
+			},
+		},
+		{
+			name: "step-up verification",
+			requestBody: authdto.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("Login", mock.Anything, mock.Anything).Return(&auth.LoginOutput{
+					StepUp: &auth.StepUpOutput{Method: auth.StepUpMethodEmail},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp map[string]any
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, true, resp["verification_required"])
+				assert.Equal(t, auth.StepUpMethodEmail, resp["method"])
+				assert.NotContains(t, resp, "token")
+				assert.NotContains(t, resp, "refresh_token")
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -95,7 +137,7 @@ func TestHandler_Login(t *testing.T) {
 			mockJwtManager := &mocks.MockJwtManager{}
 			tt.setupMock(mockService)
 
-			h := authhandler.NewHandler(slog.Default(), mockService, mockJwtManager)
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: mockJwtManager})
 
 			router := gin.New()
 			router.HandleMethodNotAllowed = true
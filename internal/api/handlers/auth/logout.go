@@ -1,25 +1,34 @@
 package authhandler
 
 import (
+	"gomonitor/internal/domain/auth"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 func (h *Handler) Logout(c *gin.Context) {
-	if err := h.service.Logout(c.Request.Context()); err != nil {
+	if err := h.service.Logout(c.Request.Context(), auth.LogoutInput{Client: clientInfo(c)}); err != nil {
 		_ = c.Error(err)
 		return
 	}
 
+	if h.cookieMode() {
+		h.clearSessionCookies(c)
+	}
+
 	c.Status(http.StatusNoContent)
 }
 
 func (h *Handler) LogoutAll(c *gin.Context) {
-	if err := h.service.LogoutAll(c.Request.Context()); err != nil {
+	if err := h.service.LogoutAll(c.Request.Context(), auth.LogoutInput{Client: clientInfo(c)}); err != nil {
 		_ = c.Error(err)
 		return
 	}
 
+	if h.cookieMode() {
+		h.clearSessionCookies(c)
+	}
+
 	c.Status(http.StatusNoContent)
 }
@@ -26,7 +26,7 @@ func TestHandler_Logout(t *testing.T) {
 		{
 			name: "service returns error",
 			setupMock: func(m *mocks.MockAuthService) {
-				m.On("Logout", mock.Anything).
+				m.On("Logout", mock.Anything, mock.Anything).
 					Return(pkgerrors.NewUnauthorizedError("Invalid credentials"))
 			},
 			expectedStatus: http.StatusUnauthorized,
@@ -34,7 +34,7 @@ func TestHandler_Logout(t *testing.T) {
 		{
 			name: "successful logout",
 			setupMock: func(m *mocks.MockAuthService) {
-				m.On("Logout", mock.Anything).
+				m.On("Logout", mock.Anything, mock.Anything).
 					Return(nil)
 			},
 			expectedStatus: http.StatusNoContent,
@@ -47,7 +47,7 @@ func TestHandler_Logout(t *testing.T) {
 			mockJwtManager := &mocks.MockJwtManager{}
 			tt.setupMock(mockService)
 
-			h := authhandler.NewHandler(slog.Default(), mockService, mockJwtManager)
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: mockJwtManager})
 
 			router := gin.New()
 			router.HandleMethodNotAllowed = true
@@ -80,7 +80,7 @@ func TestHandler_LogoutAll(t *testing.T) {
 		{
 			name: "service returns error",
 			setupMock: func(m *mocks.MockAuthService) {
-				m.On("LogoutAll", mock.Anything).
+				m.On("LogoutAll", mock.Anything, mock.Anything).
 					Return(pkgerrors.NewUnauthorizedError("Invalid credentials"))
 			},
 			expectedStatus: http.StatusUnauthorized,
@@ -88,7 +88,7 @@ func TestHandler_LogoutAll(t *testing.T) {
 		{
 			name: "successful logout all",
 			setupMock: func(m *mocks.MockAuthService) {
-				m.On("LogoutAll", mock.Anything).
+				m.On("LogoutAll", mock.Anything, mock.Anything).
 					Return(nil)
 			},
 			expectedStatus: http.StatusNoContent,
@@ -101,7 +101,7 @@ func TestHandler_LogoutAll(t *testing.T) {
 			mockJwtManager := &mocks.MockJwtManager{}
 			tt.setupMock(mockService)
 
-			h := authhandler.NewHandler(slog.Default(), mockService, mockJwtManager)
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: mockJwtManager})
 
 			router := gin.New()
 			router.HandleMethodNotAllowed = true
@@ -0,0 +1,49 @@
+package authhandler
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestMagicLink answers the same whether the email is known or not.
+func (h *Handler) RequestMagicLink(c *gin.Context) {
+	var req authdto.MagicLinkRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	if err := h.service.RequestMagicLink(c.Request.Context(), req.ToDomainInput()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *Handler) ConsumeMagicLink(c *gin.Context) {
+	var req authdto.ConsumeMagicLinkRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	input := req.ToDomainInput()
+	input.Client = clientInfo(c)
+
+	login, err := h.service.ConsumeMagicLink(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("successfull magic link login")
+
+	h.writeLogin(c, login)
+}
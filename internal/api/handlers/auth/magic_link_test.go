@@ -0,0 +1,160 @@
+package authhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	authdto "gomonitor/internal/api/dto/auth"
+	authhandler "gomonitor/internal/api/handlers/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_RequestMagicLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:           "invalid email",
+			requestBody:    authdto.MagicLinkRequest{Email: "not-an-email"},
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "service error",
+			requestBody: authdto.MagicLinkRequest{Email: "user@test.com"},
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("RequestMagicLink", mock.Anything, mock.Anything).
+					Return(pkgerrors.NewInternalError(assert.AnError))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:        "accepted",
+			requestBody: authdto.MagicLinkRequest{Email: "user@test.com"},
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("RequestMagicLink", mock.Anything, auth.MagicLinkInput{Email: "user@test.com"}).
+					Return(nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/magic-link", h.RequestMagicLink)
+
+			body, err := json.Marshal(tt.requestBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/magic-link", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ConsumeMagicLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "missing token",
+			requestBody:    authdto.ConsumeMagicLinkRequest{},
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "invalid link",
+			requestBody: authdto.ConsumeMagicLinkRequest{Token: "link"},
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ConsumeMagicLink", mock.Anything, mock.Anything).
+					Return(nil, pkgerrors.NewUnauthorizedError(auth.MsgInvalidMagicLink))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "success",
+			requestBody: authdto.ConsumeMagicLinkRequest{Token: "link"},
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ConsumeMagicLink", mock.Anything, mock.MatchedBy(func(input auth.ConsumeMagicLinkInput) bool {
+					return input.Token == "link" && input.Client.UserAgent == "curl/8.0"
+				})).Return(&auth.LoginOutput{
+					RefreshToken: "refresh-token",
+					AccessToken:  "access-token",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp authdto.LoginResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "refresh-token", resp.RefreshToken)
+				assert.Equal(t, "access-token", resp.AccessToken)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/magic-link/consume", h.ConsumeMagicLink)
+
+			body, err := json.Marshal(tt.requestBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/magic-link/consume", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "curl/8.0")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
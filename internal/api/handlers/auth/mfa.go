@@ -0,0 +1,28 @@
+package authhandler
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	pkgerrors "gomonitor/internal/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) VerifyMFA(c *gin.Context) {
+	var req authdto.VerifyMFARequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	input := req.ToDomainInput()
+	input.Client = clientInfo(c)
+
+	login, err := h.service.VerifyMFA(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.writeLogin(c, login)
+}
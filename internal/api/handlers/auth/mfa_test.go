@@ -0,0 +1,106 @@
+package authhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	authdto "gomonitor/internal/api/dto/auth"
+	authhandler "gomonitor/internal/api/handlers/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/mfa"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_VerifyMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultRequest := authdto.VerifyMFARequest{
+		ChallengeToken: "challenge",
+		Code:           "123456",
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "missing code",
+			requestBody:    authdto.VerifyMFARequest{ChallengeToken: "challenge"},
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "invalid code",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("VerifyMFA", mock.Anything, mock.Anything).
+					Return(nil, pkgerrors.NewUnauthorizedError(mfa.MsgInvalidMFACode))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "success",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("VerifyMFA", mock.Anything, mock.MatchedBy(func(input auth.VerifyMFAInput) bool {
+					return input.ChallengeToken == "challenge" && input.Code == "123456" &&
+						input.Client.UserAgent == "curl/8.0"
+				})).Return(&auth.LoginOutput{
+					RefreshToken: "refresh-token",
+					AccessToken:  "access-token",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp authdto.LoginResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "refresh-token", resp.RefreshToken)
+				assert.Equal(t, "access-token", resp.AccessToken)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/mfa/verify", h.VerifyMFA)
+
+			body, err := json.Marshal(tt.requestBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/mfa/verify", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "curl/8.0")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
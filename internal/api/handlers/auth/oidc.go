@@ -0,0 +1,68 @@
+package authhandler
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcFlowCookie holds the encrypted flow between the redirect to the provider and the callback.
+const oidcFlowCookie = "oidc_flow"
+
+// OIDCLogin redirects the user to the provider.
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	login, err := h.service.StartOIDCLogin(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setOIDCFlowCookie(c, login.Flow, int(time.Until(login.ExpiresAt).Seconds()))
+	c.Redirect(http.StatusFound, login.AuthURL)
+}
+
+// OIDCCallback completes the login once the provider redirects back.
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	var req authdto.OIDCCallbackRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid callback parameters", err))
+		return
+	}
+
+	// The flow is single use, whatever the outcome.
+	flow, _ := c.Cookie(oidcFlowCookie)
+	setOIDCFlowCookie(c, "", -1)
+
+	input := req.ToDomainInput()
+	input.Flow = flow
+	input.Client = clientInfo(c)
+
+	login, err := h.service.CompleteOIDCLogin(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if login.MFAChallenge != nil {
+		logging.FromContext(c.Request.Context()).Info("oidc login requires mfa")
+		c.JSON(http.StatusOK, authdto.ToMFAChallengeResponse(login.MFAChallenge))
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("successfull oidc login")
+
+	h.writeLogin(c, login)
+}
+
+// setOIDCFlowCookie scopes the cookie to the oidc routes. Lax lets it come back with the top level
+// redirect from the provider, Secure is accepted by browsers on localhost as well.
+func setOIDCFlowCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcFlowCookie, value, maxAge, path.Dir(c.FullPath()), "", true, true)
+}
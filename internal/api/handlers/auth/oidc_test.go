@@ -0,0 +1,206 @@
+package authhandler_test
+
+import (
+	"encoding/json"
+	authdto "gomonitor/internal/api/dto/auth"
+	authhandler "gomonitor/internal/api/handlers/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newOIDCRouter(mockService *mocks.MockAuthService) *gin.Engine {
+	h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+	router := gin.New()
+	router.Use(middlewares.ErrorMiddleware())
+	router.GET("/auth/oidc/login", h.OIDCLogin)
+	router.GET("/auth/oidc/callback", h.OIDCCallback)
+
+	return router
+}
+
+func findCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestHandler_OIDCLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "oidc disabled",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("StartOIDCLogin", mock.Anything).
+					Return(nil, pkgerrors.NewNotFoundError(auth.MsgOIDCDisabled))
+			},
+			expectedStatus: http.StatusNotFound,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Nil(t, findCookie(rec, "oidc_flow"))
+			},
+		},
+		{
+			name: "redirects to the provider",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("StartOIDCLogin", mock.Anything).Return(&auth.OIDCLoginOutput{
+					AuthURL:   "https://idp.example.com/authorize?state=state",
+					Flow:      "flow",
+					ExpiresAt: time.Now().Add(10 * time.Minute),
+				}, nil)
+			},
+			expectedStatus: http.StatusFound,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, "https://idp.example.com/authorize?state=state", rec.Header().Get("Location"))
+
+				cookie := findCookie(rec, "oidc_flow")
+				require.NotNil(t, cookie)
+				assert.Equal(t, "flow", cookie.Value)
+				assert.Equal(t, "/auth/oidc", cookie.Path)
+				assert.True(t, cookie.HttpOnly)
+				assert.True(t, cookie.Secure)
+				assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+				assert.Positive(t, cookie.MaxAge)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil)
+			rec := httptest.NewRecorder()
+
+			newOIDCRouter(mockService).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_OIDCCallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		flow           string
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "missing state",
+			query:          "?code=code",
+			flow:           "flow",
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "login failed",
+			query: "?error=access_denied&state=state",
+			flow:  "flow",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("CompleteOIDCLogin", mock.Anything, mock.MatchedBy(func(input auth.OIDCCallbackInput) bool {
+					return input.Error == "access_denied" && input.Flow == "flow"
+				})).Return(nil, pkgerrors.NewUnauthorizedError(auth.MsgOIDCLoginFailed))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:  "mfa required",
+			query: "?code=code&state=state",
+			flow:  "flow",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("CompleteOIDCLogin", mock.Anything, mock.Anything).Return(&auth.LoginOutput{
+					MFAChallenge: &auth.MFAChallengeOutput{Token: "challenge", ExpiresAt: time.Now().Add(time.Minute)},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp authdto.MFAChallengeResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.True(t, resp.MFARequired)
+				assert.Equal(t, "challenge", resp.ChallengeToken)
+			},
+		},
+		{
+			name:  "success",
+			query: "?code=code&state=state",
+			flow:  "flow",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("CompleteOIDCLogin", mock.Anything, mock.MatchedBy(func(input auth.OIDCCallbackInput) bool {
+					return input.Code == "code" && input.State == "state" && input.Flow == "flow" &&
+						input.Client.UserAgent == "curl/8.0"
+				})).Return(&auth.LoginOutput{
+					RefreshToken: "refresh-token",
+					AccessToken:  "access-token",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp authdto.LoginResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "refresh-token", resp.RefreshToken)
+				assert.Equal(t, "access-token", resp.AccessToken)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback"+tt.query, nil)
+			req.Header.Set("User-Agent", "curl/8.0")
+			req.AddCookie(&http.Cookie{Name: "oidc_flow", Value: tt.flow})
+			rec := httptest.NewRecorder()
+
+			newOIDCRouter(mockService).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus != http.StatusBadRequest {
+				cookie := findCookie(rec, "oidc_flow")
+				require.NotNil(t, cookie)
+				assert.Empty(t, cookie.Value)
+				assert.Negative(t, cookie.MaxAge)
+			}
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
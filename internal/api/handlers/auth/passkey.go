@@ -0,0 +1,52 @@
+package authhandler
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasskeyLoginOptions returns the options of navigator.credentials.get, for a discoverable passkey
+// or for the passkeys of a challenged login.
+func (h *Handler) PasskeyLoginOptions(c *gin.Context) {
+	var req authdto.PasskeyLoginOptionsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	options, err := h.service.StartPasskeyLogin(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+// PasskeyLogin completes the login with the credential returned by the browser.
+func (h *Handler) PasskeyLogin(c *gin.Context) {
+	var req authdto.PasskeyLoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	input := req.ToDomainInput()
+	input.Client = clientInfo(c)
+
+	login, err := h.service.CompletePasskeyLogin(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	logging.FromContext(c.Request.Context()).Info("successfull passkey login")
+
+	h.writeLogin(c, login)
+}
@@ -0,0 +1,193 @@
+package authhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	authdto "gomonitor/internal/api/dto/auth"
+	authhandler "gomonitor/internal/api/handlers/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/webauthn"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_PasskeyLoginOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "invalid json",
+			requestBody:    `{`,
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "passkeys disabled",
+			requestBody: `{}`,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("StartPasskeyLogin", mock.Anything, auth.PasskeyLoginOptionsInput{}).
+					Return(nil, pkgerrors.NewNotFoundError(passkey.MsgPasskeysDisabled))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:        "second factor",
+			requestBody: `{"challenge_token":"challenge"}`,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("StartPasskeyLogin", mock.Anything, auth.PasskeyLoginOptionsInput{ChallengeToken: "challenge"}).
+					Return(&webauthn.RequestOptions{Challenge: "abc", RPID: "example.com"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp webauthn.RequestOptions
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "abc", resp.Challenge)
+				assert.Equal(t, "example.com", resp.RPID)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/passkeys/login/options", h.PasskeyLoginOptions)
+
+			req := httptest.NewRequest(http.MethodPost, "/passkeys/login/options", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_PasskeyLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultRequest := authdto.PasskeyLoginRequest{
+		RawID: webauthn.URLEncoded{1, 2},
+		Type:  "public-key",
+		Response: authdto.AssertionResponse{
+			ClientDataJSON:    webauthn.URLEncoded(`{}`),
+			AuthenticatorData: webauthn.URLEncoded{3},
+			Signature:         webauthn.URLEncoded{4},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "missing signature",
+			requestBody:    authdto.PasskeyLoginRequest{RawID: webauthn.URLEncoded{1}, Type: "public-key"},
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not a public key credential",
+			requestBody: authdto.PasskeyLoginRequest{
+				RawID:    defaultRequest.RawID,
+				Type:     "password",
+				Response: defaultRequest.Response,
+			},
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "invalid passkey",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("CompletePasskeyLogin", mock.Anything, mock.Anything).
+					Return(nil, pkgerrors.NewUnauthorizedError(passkey.MsgInvalidPasskey))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "success",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("CompletePasskeyLogin", mock.Anything, mock.MatchedBy(func(input auth.PasskeyLoginInput) bool {
+					return bytes.Equal(input.Response.CredentialID, []byte{1, 2}) &&
+						bytes.Equal(input.Response.Signature, []byte{4}) &&
+						input.Client.UserAgent == "curl/8.0"
+				})).Return(&auth.LoginOutput{
+					RefreshToken: "refresh-token",
+					AccessToken:  "access-token",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp authdto.LoginResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "refresh-token", resp.RefreshToken)
+				assert.Equal(t, "access-token", resp.AccessToken)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/passkeys/login", h.PasskeyLogin)
+
+			body, err := json.Marshal(tt.requestBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/passkeys/login", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "curl/8.0")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package authhandler
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPassword answers the same whether the email is known or not.
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req authdto.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	if err := h.service.ForgotPassword(c.Request.Context(), req.ToDomainInput()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req authdto.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), req.ToDomainInput()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var req authdto.ChangePasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	input := req.ToDomainInput()
+	input.Client = clientInfo(c)
+
+	if err := h.service.ChangePassword(c.Request.Context(), input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
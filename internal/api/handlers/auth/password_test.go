@@ -0,0 +1,230 @@
+package authhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	authdto "gomonitor/internal/api/dto/auth"
+	authhandler "gomonitor/internal/api/handlers/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ForgotPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:           "invalid email",
+			requestBody:    authdto.ForgotPasswordRequest{Email: "not-an-email"},
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "service error",
+			requestBody: authdto.ForgotPasswordRequest{Email: "user@test.com"},
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ForgotPassword", mock.Anything, mock.Anything).
+					Return(pkgerrors.NewInternalError(assert.AnError))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:        "accepted",
+			requestBody: authdto.ForgotPasswordRequest{Email: "user@test.com"},
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ForgotPassword", mock.Anything, auth.ForgotPasswordInput{Email: "user@test.com"}).
+					Return(nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/password/forgot", h.ForgotPassword)
+
+			body, err := json.Marshal(tt.requestBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/password/forgot", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultRequest := authdto.ResetPasswordRequest{
+		Token:    "token",
+		Password: "new-password",
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:        "password refused by the policy",
+			requestBody: authdto.ResetPasswordRequest{Token: "token", Password: "short"},
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ResetPassword", mock.Anything, auth.ResetPasswordInput{Token: "token", Password: "short"}).
+					Return(pkgerrors.NewValidationError(user.MsgPasswordPolicy, pkgerrors.FieldError{Field: "password", Code: "too_short"}))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "invalid token",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ResetPassword", mock.Anything, mock.Anything).
+					Return(pkgerrors.NewBadRequestError(auth.MsgInvalidResetToken))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "success",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ResetPassword", mock.Anything, defaultRequest.ToDomainInput()).
+					Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/password/reset", h.ResetPassword)
+
+			body, err := json.Marshal(tt.requestBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/password/reset", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultRequest := authdto.ChangePasswordRequest{
+		CurrentPassword: "old-password",
+		NewPassword:     "new-password",
+	}
+
+	defaultInput := defaultRequest.ToDomainInput()
+	defaultInput.Client = auth.ClientInfo{IPAddress: "192.0.2.1"}
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:           "missing new password",
+			requestBody:    authdto.ChangePasswordRequest{CurrentPassword: "old-password"},
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "new password refused by the policy",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ChangePassword", mock.Anything, defaultInput).
+					Return(pkgerrors.NewValidationError(user.MsgPasswordPolicy, pkgerrors.FieldError{Field: "new_password", Code: "reused"}))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "invalid current password",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ChangePassword", mock.Anything, mock.Anything).
+					Return(pkgerrors.NewUnauthorizedError(auth.MsgInvalidCurrentPassword))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "success",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ChangePassword", mock.Anything, defaultInput).
+					Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/users/me/password", h.ChangePassword)
+
+			body, err := json.Marshal(tt.requestBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/users/me/password", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package authhandler
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/auth"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reauthenticate answers the challenge of RequireRecentAuth. The session goes on, only its access token
+// is replaced to carry the new authentication time.
+func (h *Handler) Reauthenticate(c *gin.Context) {
+	var req authdto.ReauthenticateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	input := req.ToDomainInput()
+	input.Client = clientInfo(c)
+
+	output, err := h.service.Reauthenticate(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.writeReauthentication(c, output)
+}
+
+// PasskeyReauthenticateOptions returns the options of navigator.credentials.get to re-authenticate
+// with a passkey, for users who don't know their password.
+func (h *Handler) PasskeyReauthenticateOptions(c *gin.Context) {
+	options, err := h.service.StartPasskeyReauthentication(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+// PasskeyReauthenticate answers the challenge of RequireRecentAuth with the credential returned by the browser.
+func (h *Handler) PasskeyReauthenticate(c *gin.Context) {
+	var req authdto.PasskeyReauthenticateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	input := req.ToDomainInput()
+	input.Client = clientInfo(c)
+
+	output, err := h.service.CompletePasskeyReauthentication(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.writeReauthentication(c, output)
+}
+
+func (h *Handler) writeReauthentication(c *gin.Context, output *auth.ReauthenticateOutput) {
+	if h.cookieMode() {
+		h.setCookie(c, middlewares.AccessTokenCookie, output.AccessToken, "/", output.AccessTokenExpiresAt, true)
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, authdto.ToReauthenticateResponse(output))
+}
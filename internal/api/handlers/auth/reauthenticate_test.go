@@ -0,0 +1,210 @@
+package authhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	authdto "gomonitor/internal/api/dto/auth"
+	authhandler "gomonitor/internal/api/handlers/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/webauthn"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Reauthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultRequest := authdto.ReauthenticateRequest{Password: "password123", Code: "123456"}
+
+	defaultInput := defaultRequest.ToDomainInput()
+	defaultInput.Client = auth.ClientInfo{IPAddress: "192.0.2.1"}
+
+	authTime := time.Now().UTC().Truncate(time.Second)
+	output := &auth.ReauthenticateOutput{
+		AccessToken:          "access-token",
+		TokenType:            auth.TokenTypeBearer,
+		AccessTokenExpiresAt: authTime.Add(time.Hour),
+		AuthTime:             authTime,
+		AMR:                  []string{auth.AMRPassword, auth.AMROTP, auth.AMRMultiFactor},
+	}
+
+	tests := []struct {
+		name           string
+		session        *config.SessionConfig
+		requestBody    any
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "missing password",
+			requestBody:    authdto.ReauthenticateRequest{Code: "123456"},
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "invalid credentials",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("Reauthenticate", mock.Anything, defaultInput).
+					Return(nil, pkgerrors.NewUnauthorizedError(auth.MsgInvalidCredentials))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "success",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("Reauthenticate", mock.Anything, defaultInput).Return(output, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp authdto.ReauthenticateResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "access-token", resp.AccessToken)
+				assert.True(t, authTime.Equal(resp.AuthTime))
+				assert.Equal(t, output.AMR, resp.AMR)
+			},
+		},
+		{
+			name:        "cookie mode only replaces the access token",
+			session:     cookieSession,
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("Reauthenticate", mock.Anything, defaultInput).Return(output, nil)
+			},
+			expectedStatus: http.StatusNoContent,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				cookies := cookiesByName(rec)
+				require.Contains(t, cookies, middlewares.AccessTokenCookie)
+				assert.Equal(t, "access-token", cookies[middlewares.AccessTokenCookie].Value)
+				assert.NotContains(t, cookies, middlewares.RefreshTokenCookie)
+				assert.NotContains(t, cookies, middlewares.CSRFCookie)
+				assert.Empty(t, rec.Body.String())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{
+				Session:      tt.session,
+				TokenManager: &mocks.MockJwtManager{},
+			})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/auth/reauthenticate", h.Reauthenticate)
+
+			body, err := json.Marshal(tt.requestBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/reauthenticate", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_PasskeyReauthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultRequest := authdto.PasskeyReauthenticateRequest{PasskeyLoginRequest: authdto.PasskeyLoginRequest{
+		RawID: webauthn.URLEncoded{1, 2},
+		Type:  "public-key",
+		Response: authdto.AssertionResponse{
+			ClientDataJSON:    webauthn.URLEncoded(`{}`),
+			AuthenticatorData: webauthn.URLEncoded{3},
+			Signature:         webauthn.URLEncoded{4},
+		},
+	}}
+
+	defaultInput := defaultRequest.ToDomainInput()
+	defaultInput.Client = auth.ClientInfo{IPAddress: "192.0.2.1"}
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:           "missing credential",
+			requestBody:    authdto.PasskeyReauthenticateRequest{},
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "invalid passkey",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("CompletePasskeyReauthentication", mock.Anything, defaultInput).
+					Return(nil, pkgerrors.NewUnauthorizedError(passkey.MsgInvalidPasskey))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "success",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("CompletePasskeyReauthentication", mock.Anything, defaultInput).Return(&auth.ReauthenticateOutput{
+					AccessToken: "access-token",
+					TokenType:   auth.TokenTypeBearer,
+					AMR:         []string{auth.AMRHardwareKey},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/auth/reauthenticate/passkey", h.PasskeyReauthenticate)
+
+			body, err := json.Marshal(tt.requestBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/reauthenticate/passkey", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package authhandler
 
 import (
 	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/api/middlewares"
 	pkgerrors "gomonitor/internal/pkg/errors"
 	"net/http"
 
@@ -11,12 +12,20 @@ import (
 func (h *Handler) Refresh(c *gin.Context) {
 	var req authdto.RefreshRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	// In cookie mode the browser sends the refresh token, the request must prove it comes from the frontend.
+	if cookie, err := c.Cookie(middlewares.RefreshTokenCookie); h.cookieMode() && err == nil && cookie != "" {
+		if !middlewares.ValidCSRF(c) {
+			_ = c.Error(pkgerrors.NewForbiddenError())
+			return
+		}
+		req.RefreshToken = cookie
+	} else if err := c.ShouldBindJSON(&req); err != nil {
 		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
 		return
 	}
 
 	input := req.ToDomainInput()
+	input.Client = clientInfo(c)
 
 	refresh, err := h.service.Refresh(c.Request.Context(), input)
 	if err != nil {
@@ -24,6 +33,11 @@ func (h *Handler) Refresh(c *gin.Context) {
 		return
 	}
 
+	if h.cookieMode() {
+		h.writeSessionCookies(c, refresh.AccessToken, refresh.AccessTokenExpiresAt, refresh.RefreshToken, refresh.RefreshTokenExpiresAt)
+		return
+	}
+
 	resp := authdto.ToRefreshResponse(refresh)
 
 	c.JSON(http.StatusOK, resp)
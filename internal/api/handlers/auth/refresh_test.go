@@ -69,7 +69,8 @@ func TestHandler_Refresh(t *testing.T) {
 				m.On("Refresh", mock.Anything, mock.MatchedBy(func(input auth.RefreshInput) bool {
 					return input.RefreshToken == "refresh-token"
 				})).Return(&auth.RefreshOutput{
-					AccessToken: "access-token",
+					RefreshToken: "new-refresh-token",
+					AccessToken:  "access-token",
 				}, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -77,6 +78,7 @@ func TestHandler_Refresh(t *testing.T) {
 				var resp authdto.RefreshResponse
 				err := json.Unmarshal(rec.Body.Bytes(), &resp)
 				require.NoError(t, err)
+				assert.Equal(t, "new-refresh-token", resp.RefreshToken)
 				assert.Equal(t, "access-token", resp.AccessToken)
 			},
 		},
@@ -89,7 +91,7 @@ func TestHandler_Refresh(t *testing.T) {
 
 			tt.setupMock(mockService)
 
-			h := authhandler.NewHandler(slog.Default(), mockService, mockJwtManager)
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: mockJwtManager})
 
 			router := gin.New()
 			router.HandleMethodNotAllowed = true
@@ -0,0 +1,66 @@
+package authhandler
+
+import (
+	authdto "gomonitor/internal/api/dto/auth"
+	"gomonitor/internal/domain/auth"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) ListSessions(c *gin.Context) {
+	h.listSessions(c, auth.ListSessionsInput{})
+}
+
+func (h *Handler) RevokeSession(c *gin.Context) {
+	var req authdto.SessionRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid jti parameter", err))
+		return
+	}
+
+	h.revokeSession(c, req.ToDomainInput())
+}
+
+func (h *Handler) ListUserSessions(c *gin.Context) {
+	var req authdto.UserSessionsRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid ID parameter", err))
+		return
+	}
+
+	h.listSessions(c, req.ToDomainInput())
+}
+
+func (h *Handler) RevokeUserSession(c *gin.Context) {
+	var req authdto.UserSessionRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid session parameters", err))
+		return
+	}
+
+	h.revokeSession(c, req.ToDomainInput())
+}
+
+func (h *Handler) listSessions(c *gin.Context, input auth.ListSessionsInput) {
+	sessions, err := h.service.ListSessions(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authdto.ToSessionListResponse(sessions))
+}
+
+func (h *Handler) revokeSession(c *gin.Context, input auth.RevokeSessionInput) {
+	if err := h.service.RevokeSession(c.Request.Context(), input); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,185 @@
+package authhandler_test
+
+import (
+	"encoding/json"
+	authdto "gomonitor/internal/api/dto/auth"
+	authhandler "gomonitor/internal/api/handlers/auth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/testutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ListSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	sessions := []auth.SessionOutput{
+		{
+			JTI:        uuid.New(),
+			UserAgent:  "curl/8.0",
+			IPAddress:  "192.0.2.1",
+			CreatedAt:  time.Now(),
+			LastUsedAt: time.Now(),
+			ExpiresAt:  time.Now().Add(time.Hour),
+			Current:    true,
+		},
+	}
+
+	tests := []struct {
+		name           string
+		route          string
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "own sessions",
+			route: "/sessions",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ListSessions", mock.Anything, auth.ListSessionsInput{}).
+					Return(sessions, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp []authdto.SessionResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp, 1)
+				assert.Equal(t, sessions[0].JTI, resp[0].JTI)
+				assert.True(t, resp[0].Current)
+			},
+		},
+		{
+			name:  "user sessions",
+			route: "/users/2/sessions",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ListSessions", mock.Anything, auth.ListSessionsInput{UserID: testutil.Ptr(uint(2))}).
+					Return(sessions, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid user id",
+			route:          "/users/abc/sessions",
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "forbidden",
+			route: "/users/2/sessions",
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("ListSessions", mock.Anything, mock.Anything).
+					Return(nil, pkgerrors.NewForbiddenError())
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.GET("/sessions", h.ListSessions)
+			router.GET("/users/:id/sessions", h.ListUserSessions)
+
+			req := httptest.NewRequest(http.MethodGet, tt.route, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_RevokeSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jti := uuid.New()
+
+	tests := []struct {
+		name           string
+		route          string
+		setupMock      func(*mocks.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:  "own session",
+			route: "/sessions/" + jti.String(),
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("RevokeSession", mock.Anything, auth.RevokeSessionInput{JTI: jti}).
+					Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "invalid jti",
+			route:          "/sessions/not-a-uuid",
+			setupMock:      func(m *mocks.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "user session",
+			route: "/users/2/sessions/" + jti.String(),
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("RevokeSession", mock.Anything, auth.RevokeSessionInput{UserID: testutil.Ptr(uint(2)), JTI: jti}).
+					Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:  "session not found",
+			route: "/users/2/sessions/" + jti.String(),
+			setupMock: func(m *mocks.MockAuthService) {
+				m.On("RevokeSession", mock.Anything, mock.Anything).
+					Return(pkgerrors.NewNotFoundError(auth.MsgSessionNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthService{}
+			tt.setupMock(mockService)
+
+			h := authhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.DELETE("/sessions/:jti", h.RevokeSession)
+			router.DELETE("/users/:id/sessions/:jti", h.RevokeUserSession)
+
+			req := httptest.NewRequest(http.MethodDelete, tt.route, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package autheventhandler
+
+import (
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logger   *slog.Logger
+	service  authevent.Service
+	authDeps *middlewares.AuthDeps
+}
+
+func NewHandler(logger *slog.Logger, svc authevent.Service, authDeps *middlewares.AuthDeps) *Handler {
+	return &Handler{
+		logger:   logger,
+		service:  svc,
+		authDeps: authDeps,
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	events := r.Group("/admin/auth-events", middlewares.AuthMiddleware(h.authDeps))
+	{
+		events.GET("", middlewares.RequirePermission(identity.PermAuthEventsRead), h.List)
+	}
+}
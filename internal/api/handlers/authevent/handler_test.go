@@ -0,0 +1,63 @@
+package autheventhandler_test
+
+import (
+	autheventhandler "gomonitor/internal/api/handlers/authevent"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/mocks"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_NewHandler(t *testing.T) {
+	handler := autheventhandler.NewHandler(slog.Default(), &mocks.MockAuthEventService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+	assert.NotNil(t, handler)
+}
+
+func TestHandler_RegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "list route exists",
+			method:         http.MethodGet,
+			path:           "/api/v1/admin/auth-events",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "list route only accepts GET",
+			method:         http.MethodPost,
+			path:           "/api/v1/admin/auth-events",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := autheventhandler.NewHandler(slog.Default(), &mocks.MockAuthEventService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.HandleMethodNotAllowed = true
+			router.Use(middlewares.ErrorMiddleware())
+
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package autheventhandler
+
+import (
+	autheventdto "gomonitor/internal/api/dto/authevent"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) List(c *gin.Context) {
+	var req autheventdto.ListAuthEventsRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid query parameters", err))
+		return
+	}
+
+	events, err := h.service.List(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, autheventdto.ToAuthEventListResponse(events))
+}
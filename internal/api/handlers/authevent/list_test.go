@@ -0,0 +1,113 @@
+package autheventhandler_test
+
+import (
+	"encoding/json"
+	autheventdto "gomonitor/internal/api/dto/authevent"
+	autheventhandler "gomonitor/internal/api/handlers/authevent"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/testutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_List(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	from := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	events := []authevent.Event{
+		{
+			ID:        7,
+			Type:      authevent.TypeLoginFailure,
+			IPAddress: "192.0.2.1",
+			Reason:    authevent.ReasonInvalidCredentials,
+			CreatedAt: time.Now(),
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*mocks.MockAuthEventService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "invalid time",
+			query:          "?from=yesterday",
+			setupMock:      func(m *mocks.MockAuthEventService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid ip",
+			query:          "?ip=localhost",
+			setupMock:      func(m *mocks.MockAuthEventService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "service returns error",
+			query: "",
+			setupMock: func(m *mocks.MockAuthEventService) {
+				m.On("List", mock.Anything, authevent.ListInput{}).Return(nil, pkgerrors.NewForbiddenError())
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:  "success",
+			query: "?user_id=3&type=login_failure&ip=192.0.2.1&from=2026-01-01T00:00:00Z&before_id=10&limit=20",
+			setupMock: func(m *mocks.MockAuthEventService) {
+				m.On("List", mock.Anything, authevent.ListInput{
+					UserID:    testutil.Ptr(uint(3)),
+					Type:      authevent.TypeLoginFailure,
+					IPAddress: "192.0.2.1",
+					From:      &from,
+					BeforeID:  10,
+					Limit:     20,
+				}).Return(events, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp []autheventdto.AuthEventResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp, 1)
+				assert.Equal(t, uint64(7), resp[0].ID)
+				assert.Equal(t, authevent.ReasonInvalidCredentials, resp[0].Reason)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAuthEventService{}
+			tt.setupMock(mockService)
+
+			h := autheventhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.GET("/auth-events", h.List)
+
+			req := httptest.NewRequest(http.MethodGet, "/auth-events"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package mfahandler
+
+import (
+	mfadto "gomonitor/internal/api/dto/mfa"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) Enroll(c *gin.Context) {
+	enrollment, err := h.service.Enroll(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, mfadto.ToEnrollResponse(enrollment))
+}
+
+func (h *Handler) Confirm(c *gin.Context) {
+	var req mfadto.CodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	codes, err := h.service.Confirm(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, mfadto.ToRecoveryCodesResponse(codes))
+}
+
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req mfadto.CodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, mfadto.ToRecoveryCodesResponse(codes))
+}
+
+func (h *Handler) Disable(c *gin.Context) {
+	var req mfadto.CodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	if err := h.service.Disable(c.Request.Context(), req.ToDomainInput()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,191 @@
+package mfahandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	mfadto "gomonitor/internal/api/dto/mfa"
+	mfahandler "gomonitor/internal/api/handlers/mfa"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/mfa"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Enroll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		setupMock      func(*mocks.MockMFAService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "already enabled",
+			setupMock: func(m *mocks.MockMFAService) {
+				m.On("Enroll", mock.Anything).Return(nil, pkgerrors.NewConflictError(mfa.MsgMFAAlreadyEnabled))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "success",
+			setupMock: func(m *mocks.MockMFAService) {
+				m.On("Enroll", mock.Anything).Return(&mfa.EnrollOutput{Secret: "SECRET", URI: "otpauth://totp/x"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp mfadto.EnrollResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "SECRET", resp.Secret)
+				assert.Equal(t, "otpauth://totp/x", resp.URI)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockMFAService{}
+			tt.setupMock(mockService)
+
+			h := mfahandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/mfa/enroll", h.Enroll)
+
+			req := httptest.NewRequest(http.MethodPost, "/mfa/enroll", nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_CodeRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	input := mfa.CodeInput{Code: "123456"}
+	codes := &mfa.RecoveryCodesOutput{Codes: []string{"aaaaa-bbbbb"}}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           any
+		setupMock      func(*mocks.MockMFAService)
+		expectedStatus int
+		expectCodes    bool
+	}{
+		{
+			name:           "confirm invalid payload",
+			method:         http.MethodPost,
+			path:           "/mfa/confirm",
+			body:           mfadto.CodeRequest{Code: "1"},
+			setupMock:      func(m *mocks.MockMFAService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "confirm invalid code",
+			method: http.MethodPost,
+			path:   "/mfa/confirm",
+			body:   mfadto.CodeRequest{Code: "123456"},
+			setupMock: func(m *mocks.MockMFAService) {
+				m.On("Confirm", mock.Anything, input).Return(nil, pkgerrors.NewUnauthorizedError(mfa.MsgInvalidMFACode))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "confirm success",
+			method: http.MethodPost,
+			path:   "/mfa/confirm",
+			body:   mfadto.CodeRequest{Code: "123456"},
+			setupMock: func(m *mocks.MockMFAService) {
+				m.On("Confirm", mock.Anything, input).Return(codes, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectCodes:    true,
+		},
+		{
+			name:   "regenerate success",
+			method: http.MethodPost,
+			path:   "/mfa/recovery-codes",
+			body:   mfadto.CodeRequest{Code: "123456"},
+			setupMock: func(m *mocks.MockMFAService) {
+				m.On("RegenerateRecoveryCodes", mock.Anything, input).Return(codes, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectCodes:    true,
+		},
+		{
+			name:   "disable not enabled",
+			method: http.MethodDelete,
+			path:   "/mfa",
+			body:   mfadto.CodeRequest{Code: "123456"},
+			setupMock: func(m *mocks.MockMFAService) {
+				m.On("Disable", mock.Anything, input).Return(pkgerrors.NewBadRequestError(mfa.MsgMFANotEnabled))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "disable success",
+			method: http.MethodDelete,
+			path:   "/mfa",
+			body:   mfadto.CodeRequest{Code: "123456"},
+			setupMock: func(m *mocks.MockMFAService) {
+				m.On("Disable", mock.Anything, input).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockMFAService{}
+			tt.setupMock(mockService)
+
+			h := mfahandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/mfa/confirm", h.Confirm)
+			router.POST("/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+			router.DELETE("/mfa", h.Disable)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectCodes {
+				var resp mfadto.RecoveryCodesResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, codes.Codes, resp.RecoveryCodes)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
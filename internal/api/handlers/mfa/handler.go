@@ -0,0 +1,36 @@
+package mfahandler
+
+import (
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/mfa"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logger   *slog.Logger
+	service  mfa.Service
+	authDeps *middlewares.AuthDeps
+}
+
+func NewHandler(logger *slog.Logger, svc mfa.Service, authDeps *middlewares.AuthDeps) *Handler {
+	return &Handler{
+		logger:   logger,
+		service:  svc,
+		authDeps: authDeps,
+	}
+}
+
+// RegisterRoutes manages the factor of the caller, the login step lives on the auth handler.
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	mfa := r.Group("/auth/mfa", middlewares.AuthMiddleware(h.authDeps))
+	{
+		// Confirm only completes a factor from Enroll, requiring a recent authentication to start is enough.
+		recentAuth := middlewares.RequireRecentAuth(h.authDeps.RecentAuthMaxAge)
+		mfa.POST("/enroll", recentAuth, h.Enroll)
+		mfa.POST("/confirm", h.Confirm)
+		mfa.POST("/recovery-codes", recentAuth, h.RegenerateRecoveryCodes)
+		mfa.DELETE("", recentAuth, h.Disable)
+	}
+}
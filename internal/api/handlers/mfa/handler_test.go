@@ -0,0 +1,139 @@
+package mfahandler_test
+
+import (
+	mfahandler "gomonitor/internal/api/handlers/mfa"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_NewHandler(t *testing.T) {
+	handler := mfahandler.NewHandler(slog.Default(), &mocks.MockMFAService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+	assert.NotNil(t, handler)
+}
+
+func TestHandler_RegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "enroll route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/mfa/enroll",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "confirm route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/mfa/confirm",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "recovery codes route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/mfa/recovery-codes",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "disable route exists",
+			method:         http.MethodDelete,
+			path:           "/api/v1/auth/mfa",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "enroll route only accepts POST",
+			method:         http.MethodGet,
+			path:           "/api/v1/auth/mfa/enroll",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := mfahandler.NewHandler(slog.Default(), &mocks.MockMFAService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.HandleMethodNotAllowed = true
+			router.Use(middlewares.ErrorMiddleware())
+
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_RoutesRequireRecentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{
+			name:   "enroll",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/mfa/enroll",
+		},
+		{
+			name:   "regenerate recovery codes",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/mfa/recovery-codes",
+		},
+		{
+			name:   "disable",
+			method: http.MethodDelete,
+			path:   "/api/v1/auth/mfa",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtManager := &mocks.MockJwtManager{}
+			jwtManager.On("ValidateAccessToken", "token").Return(&identity.Principal{
+				UserID:   1,
+				Role:     identity.RoleUser,
+				Source:   identity.AuthExternal,
+				AuthTime: time.Now().Add(-time.Hour),
+			}, nil)
+
+			h := mfahandler.NewHandler(slog.Default(), &mocks.MockMFAService{}, &middlewares.AuthDeps{
+				RecentAuthMaxAge: 10 * time.Minute,
+				TokenManager:     jwtManager,
+			})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+		})
+	}
+}
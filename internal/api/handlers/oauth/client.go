@@ -0,0 +1,69 @@
+package oauthhandler
+
+import (
+	oauthdto "gomonitor/internal/api/dto/oauth"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreateClient(c *gin.Context) {
+	var req oauthdto.CreateClientRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	out, err := h.service.CreateClient(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, oauthdto.ToCreateClientResponse(out))
+}
+
+func (h *Handler) ListClients(c *gin.Context) {
+	clients, err := h.service.ListClients(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, oauthdto.ToClientListResponse(clients))
+}
+
+func (h *Handler) GetClient(c *gin.Context) {
+	var req oauthdto.ClientRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid ID parameter", err))
+		return
+	}
+
+	client, err := h.service.GetClient(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, oauthdto.ToClientResponse(client))
+}
+
+func (h *Handler) DeleteClient(c *gin.Context) {
+	var req oauthdto.ClientRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid ID parameter", err))
+		return
+	}
+
+	if err := h.service.DeleteClient(c.Request.Context(), req.ToDomainInput()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
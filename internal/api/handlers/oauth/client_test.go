@@ -0,0 +1,210 @@
+package oauthhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	oauthdto "gomonitor/internal/api/dto/oauth"
+	oauthhandler "gomonitor/internal/api/handlers/oauth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/oauth"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newClientRouter(mockService *mocks.MockOAuthService) *gin.Engine {
+	h := oauthhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+	router := gin.New()
+	router.Use(middlewares.ErrorMiddleware())
+	router.POST("/clients", h.CreateClient)
+	router.GET("/clients", h.ListClients)
+	router.GET("/clients/:id", h.GetClient)
+	router.DELETE("/clients/:id", h.DeleteClient)
+
+	return router
+}
+
+func TestHandler_CreateClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockOAuthService)
+		expectedStatus int
+	}{
+		{
+			name:           "unknown scope",
+			requestBody:    oauthdto.CreateClientRequest{Name: "billing", Scopes: []string{"admin"}},
+			setupMock:      func(*mocks.MockOAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "forbidden",
+			requestBody: oauthdto.CreateClientRequest{Name: "billing", Scopes: []string{"read"}},
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("CreateClient", mock.Anything, mock.Anything).Return(nil, pkgerrors.NewForbiddenError())
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:        "success",
+			requestBody: oauthdto.CreateClientRequest{Name: "billing", Scopes: []string{"read"}},
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("CreateClient", mock.Anything, oauth.CreateClientInput{Name: "billing", Scopes: []string{"read"}}).
+					Return(&oauth.CreateClientOutput{
+						ClientSecret: "secret",
+						Client:       &oauth.Client{ID: 1, ClientID: "client", Scopes: "read"},
+					}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockOAuthService{}
+			tt.setupMock(mockService)
+
+			body, err := json.Marshal(tt.requestBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/clients", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			newClientRouter(mockService).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus == http.StatusCreated {
+				var resp oauthdto.CreateClientResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "client", resp.ClientID)
+				assert.Equal(t, "secret", resp.ClientSecret)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ListClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &mocks.MockOAuthService{}
+	mockService.On("ListClients", mock.Anything).Return([]oauth.Client{{ID: 1}, {ID: 2}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/clients", nil)
+	rec := httptest.NewRecorder()
+
+	newClientRouter(mockService).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp []oauthdto.ClientResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp, 2)
+	assert.NotContains(t, rec.Body.String(), "secret")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		setupMock      func(*mocks.MockOAuthService)
+		expectedStatus int
+	}{
+		{
+			name:           "invalid id",
+			path:           "/clients/abc",
+			setupMock:      func(*mocks.MockOAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not found",
+			path: "/clients/3",
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("GetClient", mock.Anything, oauth.ClientInput{ID: 3}).
+					Return(nil, pkgerrors.NewNotFoundError(oauth.MsgClientNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			path: "/clients/3",
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("GetClient", mock.Anything, oauth.ClientInput{ID: 3}).Return(&oauth.Client{ID: 3}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockOAuthService{}
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			newClientRouter(mockService).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_DeleteClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		setupMock      func(*mocks.MockOAuthService)
+		expectedStatus int
+	}{
+		{
+			name: "not found",
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("DeleteClient", mock.Anything, oauth.ClientInput{ID: 3}).
+					Return(pkgerrors.NewNotFoundError(oauth.MsgClientNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("DeleteClient", mock.Anything, oauth.ClientInput{ID: 3}).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockOAuthService{}
+			tt.setupMock(mockService)
+
+			req := httptest.NewRequest(http.MethodDelete, "/clients/3", nil)
+			rec := httptest.NewRecorder()
+
+			newClientRouter(mockService).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package oauthhandler
+
+import (
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/oauth"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logger   *slog.Logger
+	service  oauth.Service
+	authDeps *middlewares.AuthDeps
+}
+
+func NewHandler(logger *slog.Logger, svc oauth.Service, authDeps *middlewares.AuthDeps) *Handler {
+	return &Handler{
+		logger:   logger,
+		service:  svc,
+		authDeps: authDeps,
+	}
+}
+
+// RegisterRoutes registers the client registry, the token endpoint lives outside of the API version.
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	clients := r.Group("/oauth/clients", middlewares.AuthMiddleware(h.authDeps))
+	{
+		clients.GET("", middlewares.RequirePermission(identity.PermOAuthClientsRead), h.ListClients)
+		clients.POST("", middlewares.RequirePermission(identity.PermOAuthClientsWrite), h.CreateClient)
+		clients.GET("/:id", middlewares.RequirePermission(identity.PermOAuthClientsRead), h.GetClient)
+		clients.DELETE("/:id", middlewares.RequirePermission(identity.PermOAuthClientsWrite), h.DeleteClient)
+	}
+}
@@ -0,0 +1,81 @@
+package oauthhandler_test
+
+import (
+	oauthhandler "gomonitor/internal/api/handlers/oauth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/mocks"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_NewHandler(t *testing.T) {
+	handler := oauthhandler.NewHandler(slog.Default(), &mocks.MockOAuthService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+	assert.NotNil(t, handler)
+}
+
+func TestHandler_RegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "list route exists",
+			method:         http.MethodGet,
+			path:           "/api/v1/oauth/clients",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "create route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/oauth/clients",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "get route exists",
+			method:         http.MethodGet,
+			path:           "/api/v1/oauth/clients/1",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "delete route exists",
+			method:         http.MethodDelete,
+			path:           "/api/v1/oauth/clients/1",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "client routes don't accept PUT",
+			method:         http.MethodPut,
+			path:           "/api/v1/oauth/clients/1",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := oauthhandler.NewHandler(slog.Default(), &mocks.MockOAuthService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.HandleMethodNotAllowed = true
+			router.Use(middlewares.ErrorMiddleware())
+
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package oauthhandler
+
+import (
+	"errors"
+	oauthdto "gomonitor/internal/api/dto/oauth"
+	"gomonitor/internal/domain/oauth"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Token is the token endpoint, its responses follow RFC 6749 rather than the API error format.
+func (h *Handler) Token(c *gin.Context) {
+	// Tokens must never be cached.
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req oauthdto.TokenRequest
+
+	if err := c.ShouldBindWith(&req, binding.Form); err != nil {
+		tokenError(c, pkgerrors.NewBadRequestError(oauth.MsgInvalidRequest, err))
+		return
+	}
+
+	input := req.ToDomainInput()
+
+	if err := clientCredentials(c, &input.ClientID, &input.ClientSecret); err != nil {
+		tokenError(c, err)
+		return
+	}
+
+	out, err := h.service.Token(c.Request.Context(), input)
+	if err != nil {
+		tokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, oauthdto.ToTokenResponse(out))
+}
+
+// Introspect is the introspection endpoint of RFC 7662, only registered clients may use it.
+func (h *Handler) Introspect(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req oauthdto.IntrospectRequest
+
+	if err := c.ShouldBindWith(&req, binding.Form); err != nil {
+		tokenError(c, pkgerrors.NewBadRequestError(oauth.MsgInvalidRequest, err))
+		return
+	}
+
+	input := req.ToDomainInput()
+
+	if err := clientCredentials(c, &input.ClientID, &input.ClientSecret); err != nil {
+		tokenError(c, err)
+		return
+	}
+
+	out, err := h.service.Introspect(c.Request.Context(), input)
+	if err != nil {
+		tokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, oauthdto.ToIntrospectResponse(out))
+}
+
+// clientCredentials reads HTTP Basic credentials into the ones of the form, a client may only use one method.
+func clientCredentials(c *gin.Context, clientID, clientSecret *string) error {
+	basicID, basicSecret, ok := c.Request.BasicAuth()
+	if !ok {
+		return nil
+	}
+
+	if *clientID != "" || *clientSecret != "" {
+		return pkgerrors.NewBadRequestError(oauth.MsgInvalidRequest)
+	}
+
+	// The credentials are form encoded before being put in the header.
+	var idErr, secretErr error
+	*clientID, idErr = url.QueryUnescape(basicID)
+	*clientSecret, secretErr = url.QueryUnescape(basicSecret)
+	if idErr != nil || secretErr != nil {
+		return pkgerrors.NewUnauthorizedError(oauth.MsgInvalidClient)
+	}
+
+	return nil
+}
+
+// tokenError renders client errors with their RFC 6749 code, the message of the service.
+func tokenError(c *gin.Context, err error) {
+	var appErr *pkgerrors.AppError
+	if !errors.As(err, &appErr) || appErr.StatusCode >= http.StatusInternalServerError {
+		_ = c.Error(err)
+		return
+	}
+
+	if appErr.StatusCode == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	c.JSON(appErr.StatusCode, oauthdto.ErrorResponse{Error: appErr.Message})
+}
@@ -0,0 +1,259 @@
+package oauthhandler_test
+
+import (
+	"encoding/json"
+	oauthdto "gomonitor/internal/api/dto/oauth"
+	oauthhandler "gomonitor/internal/api/handlers/oauth"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/oauth"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Token(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	issued := &oauth.TokenOutput{
+		AccessToken: "access-token",
+		TokenType:   oauth.TokenTypeBearer,
+		ExpiresIn:   15 * time.Minute,
+		Scopes:      []string{"read"},
+	}
+
+	tests := []struct {
+		name           string
+		form           url.Values
+		basicUser      string
+		basicPassword  string
+		setupMock      func(*mocks.MockOAuthService)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "missing grant type",
+			form:           url.Values{"client_id": {"client"}, "client_secret": {"secret"}},
+			setupMock:      func(*mocks.MockOAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  oauth.MsgInvalidRequest,
+		},
+		{
+			name:           "two authentication methods",
+			form:           url.Values{"grant_type": {"client_credentials"}, "client_id": {"client"}},
+			basicUser:      "client",
+			basicPassword:  "secret",
+			setupMock:      func(*mocks.MockOAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  oauth.MsgInvalidRequest,
+		},
+		{
+			name: "invalid client",
+			form: url.Values{"grant_type": {"client_credentials"}, "client_id": {"client"}, "client_secret": {"wrong"}},
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("Token", mock.Anything, mock.Anything).
+					Return(nil, pkgerrors.NewUnauthorizedError(oauth.MsgInvalidClient))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  oauth.MsgInvalidClient,
+		},
+		{
+			name: "service failure uses the api error format",
+			form: url.Values{"grant_type": {"client_credentials"}, "client_id": {"client"}, "client_secret": {"secret"}},
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("Token", mock.Anything, mock.Anything).Return(nil, pkgerrors.NewInternalError())
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "form credentials",
+			form: url.Values{"grant_type": {"client_credentials"}, "client_id": {"client"}, "client_secret": {"secret"}, "scope": {"read"}},
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("Token", mock.Anything, oauth.TokenInput{
+					GrantType: "client_credentials", ClientID: "client", ClientSecret: "secret", Scope: "read",
+				}).Return(issued, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:          "basic credentials",
+			form:          url.Values{"grant_type": {"client_credentials"}},
+			basicUser:     "client",
+			basicPassword: "se%2Bcret",
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("Token", mock.Anything, oauth.TokenInput{
+					GrantType: "client_credentials", ClientID: "client", ClientSecret: "se+cret",
+				}).Return(issued, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockOAuthService{}
+			tt.setupMock(mockService)
+
+			h := oauthhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/oauth/token", h.Token)
+
+			req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.basicUser != "" {
+				req.SetBasicAuth(tt.basicUser, tt.basicPassword)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+			if tt.expectedError != "" {
+				var resp oauthdto.ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expectedError, resp.Error)
+			}
+
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var resp oauthdto.TokenResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, oauthdto.TokenResponse{
+					AccessToken: "access-token",
+					TokenType:   "Bearer",
+					ExpiresIn:   900,
+					Scope:       "read",
+				}, resp)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Introspect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		form           url.Values
+		basicUser      string
+		basicPassword  string
+		setupMock      func(*mocks.MockOAuthService)
+		expectedStatus int
+		expectedError  string
+		expectedBody   string
+	}{
+		{
+			name:           "missing token",
+			form:           url.Values{"client_id": {"client"}, "client_secret": {"secret"}},
+			setupMock:      func(*mocks.MockOAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  oauth.MsgInvalidRequest,
+		},
+		{
+			name:           "two authentication methods",
+			form:           url.Values{"token": {"access-token"}, "client_secret": {"secret"}},
+			basicUser:      "client",
+			basicPassword:  "secret",
+			setupMock:      func(*mocks.MockOAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  oauth.MsgInvalidRequest,
+		},
+		{
+			name: "invalid client",
+			form: url.Values{"token": {"access-token"}, "client_id": {"client"}, "client_secret": {"wrong"}},
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("Introspect", mock.Anything, mock.Anything).
+					Return(nil, pkgerrors.NewUnauthorizedError(oauth.MsgInvalidClient))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  oauth.MsgInvalidClient,
+		},
+		{
+			name:          "active token",
+			form:          url.Values{"token": {"access-token"}, "token_type_hint": {"access_token"}},
+			basicUser:     "client",
+			basicPassword: "secret",
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("Introspect", mock.Anything, oauth.IntrospectInput{
+					ClientID: "client", ClientSecret: "secret", Token: "access-token",
+				}).Return(&oauth.IntrospectOutput{
+					Active:        true,
+					UserID:        2,
+					Role:          "user",
+					ActorID:       1,
+					ExpiresAt:     time.Unix(1700000000, 0),
+					SessionStatus: oauth.SessionActive,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"active":true,"sub":"2","role":"user","token_type":"Bearer","exp":1700000000,` +
+				`"act":{"sub":"1"},"session_status":"active"}`,
+		},
+		{
+			name: "revoked session",
+			form: url.Values{"token": {"access-token"}, "client_id": {"client"}, "client_secret": {"secret"}},
+			setupMock: func(m *mocks.MockOAuthService) {
+				m.On("Introspect", mock.Anything, mock.Anything).
+					Return(&oauth.IntrospectOutput{SessionStatus: oauth.SessionRevoked}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"active":false,"session_status":"revoked"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockOAuthService{}
+			tt.setupMock(mockService)
+
+			h := oauthhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/oauth/introspect", h.Introspect)
+
+			req := httptest.NewRequest(http.MethodPost, "/oauth/introspect", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.basicUser != "" {
+				req.SetBasicAuth(tt.basicUser, tt.basicPassword)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+			if tt.expectedError != "" {
+				var resp oauthdto.ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expectedError, resp.Error)
+			}
+
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
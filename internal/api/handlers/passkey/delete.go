@@ -0,0 +1,25 @@
+package passkeyhandler
+
+import (
+	passkeydto "gomonitor/internal/api/dto/passkey"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) Delete(c *gin.Context) {
+	var req passkeydto.PasskeyRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid ID parameter", err))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), req.ToDomainInput()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,73 @@
+package passkeyhandler_test
+
+import (
+	passkeyhandler "gomonitor/internal/api/handlers/passkey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandler_Delete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		setupMock      func(*mocks.MockPasskeyService)
+		expectedStatus int
+	}{
+		{
+			name:           "invalid id",
+			path:           "/passkeys/0",
+			setupMock:      func(m *mocks.MockPasskeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not found",
+			path: "/passkeys/1",
+			setupMock: func(m *mocks.MockPasskeyService) {
+				m.On("Delete", mock.Anything, passkey.PasskeyInput{ID: 1}).
+					Return(pkgerrors.NewNotFoundError(passkey.MsgPasskeyNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			path: "/passkeys/1",
+			setupMock: func(m *mocks.MockPasskeyService) {
+				m.On("Delete", mock.Anything, passkey.PasskeyInput{ID: 1}).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPasskeyService{}
+			tt.setupMock(mockService)
+
+			h := passkeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.DELETE("/passkeys/:id", h.Delete)
+
+			req := httptest.NewRequest(http.MethodDelete, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package passkeyhandler
+
+import (
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/passkey"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logger   *slog.Logger
+	service  passkey.Service
+	authDeps *middlewares.AuthDeps
+}
+
+func NewHandler(logger *slog.Logger, svc passkey.Service, authDeps *middlewares.AuthDeps) *Handler {
+	return &Handler{
+		logger:   logger,
+		service:  svc,
+		authDeps: authDeps,
+	}
+}
+
+// RegisterRoutes manages the passkeys of the caller, logging in with them is part of the auth routes.
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	passkeys := r.Group("/auth/passkeys", middlewares.AuthMiddleware(h.authDeps))
+	{
+		// Register only completes a ceremony from RegistrationOptions, requiring a recent authentication to start is enough.
+		recentAuth := middlewares.RequireRecentAuth(h.authDeps.RecentAuthMaxAge)
+		passkeys.GET("", h.List)
+		passkeys.POST("/registration/options", recentAuth, h.RegistrationOptions)
+		passkeys.POST("", h.Register)
+		passkeys.DELETE("/:id", recentAuth, h.Delete)
+	}
+}
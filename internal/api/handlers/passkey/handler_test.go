@@ -0,0 +1,134 @@
+package passkeyhandler_test
+
+import (
+	passkeyhandler "gomonitor/internal/api/handlers/passkey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_NewHandler(t *testing.T) {
+	handler := passkeyhandler.NewHandler(slog.Default(), &mocks.MockPasskeyService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+	assert.NotNil(t, handler)
+}
+
+func TestHandler_RegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "list route exists",
+			method:         http.MethodGet,
+			path:           "/api/v1/auth/passkeys",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "registration options route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/passkeys/registration/options",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "register route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/passkeys",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "delete route exists",
+			method:         http.MethodDelete,
+			path:           "/api/v1/auth/passkeys/1",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "registration options route only accepts POST",
+			method:         http.MethodGet,
+			path:           "/api/v1/auth/passkeys/registration/options",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := passkeyhandler.NewHandler(slog.Default(), &mocks.MockPasskeyService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.HandleMethodNotAllowed = true
+			router.Use(middlewares.ErrorMiddleware())
+
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_RoutesRequireRecentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{
+			name:   "registration options",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/passkeys/registration/options",
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			path:   "/api/v1/auth/passkeys/1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtManager := &mocks.MockJwtManager{}
+			jwtManager.On("ValidateAccessToken", "token").Return(&identity.Principal{
+				UserID:   1,
+				Role:     identity.RoleUser,
+				Source:   identity.AuthExternal,
+				AuthTime: time.Now().Add(-time.Hour),
+			}, nil)
+
+			h := passkeyhandler.NewHandler(slog.Default(), &mocks.MockPasskeyService{}, &middlewares.AuthDeps{
+				RecentAuthMaxAge: 10 * time.Minute,
+				TokenManager:     jwtManager,
+			})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package passkeyhandler
+
+import (
+	passkeydto "gomonitor/internal/api/dto/passkey"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) List(c *gin.Context) {
+	passkeys, err := h.service.List(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, passkeydto.ToPasskeyListResponse(passkeys))
+}
@@ -0,0 +1,75 @@
+package passkeyhandler_test
+
+import (
+	"encoding/json"
+	passkeydto "gomonitor/internal/api/dto/passkey"
+	passkeyhandler "gomonitor/internal/api/handlers/passkey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_List(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		setupMock      func(*mocks.MockPasskeyService)
+		expectedStatus int
+		expectedLen    int
+	}{
+		{
+			name: "passkeys disabled",
+			setupMock: func(m *mocks.MockPasskeyService) {
+				m.On("List", mock.Anything).Return(nil, pkgerrors.NewNotFoundError(passkey.MsgPasskeysDisabled))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			setupMock: func(m *mocks.MockPasskeyService) {
+				m.On("List", mock.Anything).Return([]passkey.Passkey{{ID: 1}, {ID: 2}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPasskeyService{}
+			tt.setupMock(mockService)
+
+			h := passkeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.GET("/passkeys", h.List)
+
+			req := httptest.NewRequest(http.MethodGet, "/passkeys", nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var resp []passkeydto.PasskeyResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Len(t, resp, tt.expectedLen)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package passkeyhandler
+
+import (
+	passkeydto "gomonitor/internal/api/dto/passkey"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegistrationOptions returns the options of navigator.credentials.create for a new passkey.
+func (h *Handler) RegistrationOptions(c *gin.Context) {
+	options, err := h.service.BeginRegistration(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+func (h *Handler) Register(c *gin.Context) {
+	var req passkeydto.RegisterPasskeyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	p, err := h.service.FinishRegistration(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, passkeydto.ToPasskeyResponse(p))
+}
@@ -0,0 +1,166 @@
+package passkeyhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	passkeydto "gomonitor/internal/api/dto/passkey"
+	passkeyhandler "gomonitor/internal/api/handlers/passkey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/webauthn"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_RegistrationOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		setupMock      func(*mocks.MockPasskeyService)
+		expectedStatus int
+	}{
+		{
+			name: "passkeys disabled",
+			setupMock: func(m *mocks.MockPasskeyService) {
+				m.On("BeginRegistration", mock.Anything).Return(nil, pkgerrors.NewNotFoundError(passkey.MsgPasskeysDisabled))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			setupMock: func(m *mocks.MockPasskeyService) {
+				m.On("BeginRegistration", mock.Anything).Return(&webauthn.CreationOptions{Challenge: "abc"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPasskeyService{}
+			tt.setupMock(mockService)
+
+			h := passkeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/passkeys/registration/options", h.RegistrationOptions)
+
+			req := httptest.NewRequest(http.MethodPost, "/passkeys/registration/options", nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var resp webauthn.CreationOptions
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "abc", resp.Challenge)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Register(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultRequest := passkeydto.RegisterPasskeyRequest{
+		Name: "Laptop",
+		Credential: passkeydto.RegistrationCredential{
+			Type: "public-key",
+			Response: passkeydto.AttestationResponse{
+				ClientDataJSON:    webauthn.URLEncoded(`{}`),
+				AttestationObject: webauthn.URLEncoded{0xa0},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		body           any
+		setupMock      func(*mocks.MockPasskeyService)
+		expectedStatus int
+	}{
+		{
+			name:           "missing attestation",
+			body:           passkeydto.RegisterPasskeyRequest{Credential: passkeydto.RegistrationCredential{Type: "public-key"}},
+			setupMock:      func(m *mocks.MockPasskeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid ceremony",
+			body: defaultRequest,
+			setupMock: func(m *mocks.MockPasskeyService) {
+				m.On("FinishRegistration", mock.Anything, mock.Anything).
+					Return(nil, pkgerrors.NewBadRequestError(passkey.MsgInvalidPasskeyCeremony))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "already registered",
+			body: defaultRequest,
+			setupMock: func(m *mocks.MockPasskeyService) {
+				m.On("FinishRegistration", mock.Anything, mock.Anything).
+					Return(nil, pkgerrors.NewConflictError(passkey.MsgPasskeyAlreadyRegistered))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "success",
+			body: defaultRequest,
+			setupMock: func(m *mocks.MockPasskeyService) {
+				m.On("FinishRegistration", mock.Anything, mock.MatchedBy(func(input passkey.RegisterInput) bool {
+					return input.Name == "Laptop" && bytes.Equal(input.Response.AttestationObject, []byte{0xa0})
+				})).Return(&passkey.Passkey{ID: 1, Name: "Laptop", PublicKey: []byte("secret")}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPasskeyService{}
+			tt.setupMock(mockService)
+
+			h := passkeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/passkeys", h.Register)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/passkeys", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus == http.StatusCreated {
+				var resp passkeydto.PasskeyResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, uint(1), resp.ID)
+				assert.Equal(t, "Laptop", resp.Name)
+				assert.NotContains(t, rec.Body.String(), "public_key")
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package signingkeyhandler
+
+import (
+	signingkeydto "gomonitor/internal/api/dto/signingkey"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) Create(c *gin.Context) {
+	var req signingkeydto.CreateSigningKeyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	input := req.ToDomainInput()
+
+	key, err := h.service.Create(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, signingkeydto.ToSigningKeyResponse(key))
+}
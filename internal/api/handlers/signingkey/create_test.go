@@ -0,0 +1,108 @@
+package signingkeyhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	signingkeydto "gomonitor/internal/api/dto/signingkey"
+	signingkeyhandler "gomonitor/internal/api/handlers/signingkey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/signingkey"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/jwt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Create(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultRequest := signingkeydto.CreateSigningKeyRequest{
+		TokenType: jwt.TokenTypeAccess,
+		Algorithm: "ES256",
+	}
+
+	tests := []struct {
+		name           string
+		body           any
+		setupMock      func(*mocks.MockSigningKeyService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "unsupported algorithm",
+			body: signingkeydto.CreateSigningKeyRequest{
+				TokenType: jwt.TokenTypeAccess,
+				Algorithm: "none",
+			},
+			setupMock:      func(m *mocks.MockSigningKeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid token type",
+			body: signingkeydto.CreateSigningKeyRequest{
+				TokenType: "id",
+				Algorithm: "ES256",
+			},
+			setupMock:      func(m *mocks.MockSigningKeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success",
+			body: defaultRequest,
+			setupMock: func(m *mocks.MockSigningKeyService) {
+				m.On("Create", mock.Anything, defaultRequest.ToDomainInput()).
+					Return(&signingkey.SigningKey{
+						KID:       "new",
+						TokenType: jwt.TokenTypeAccess,
+						Algorithm: "ES256",
+						CreatedAt: time.Now(),
+					}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp signingkeydto.SigningKeyResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "new", resp.KID)
+				assert.False(t, resp.Current)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockSigningKeyService{}
+			tt.setupMock(mockService)
+
+			h := signingkeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/keys", h.Create)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/keys", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
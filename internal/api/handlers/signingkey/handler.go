@@ -0,0 +1,34 @@
+package signingkeyhandler
+
+import (
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/signingkey"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	logger   *slog.Logger
+	service  signingkey.Service
+	authDeps *middlewares.AuthDeps
+}
+
+func NewHandler(logger *slog.Logger, svc signingkey.Service, authDeps *middlewares.AuthDeps) *Handler {
+	return &Handler{
+		logger:   logger,
+		service:  svc,
+		authDeps: authDeps,
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	keys := r.Group("/admin/keys", middlewares.AuthMiddleware(h.authDeps))
+	{
+		keys.GET("", middlewares.RequirePermission(identity.PermSigningKeysRead), h.List)
+		keys.POST("", middlewares.RequirePermission(identity.PermSigningKeysWrite), h.Create)
+		keys.POST("/:kid/promote", middlewares.RequirePermission(identity.PermSigningKeysWrite), h.Promote)
+		keys.POST("/:kid/retire", middlewares.RequirePermission(identity.PermSigningKeysWrite), h.Retire)
+	}
+}
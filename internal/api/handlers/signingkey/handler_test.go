@@ -0,0 +1,81 @@
+package signingkeyhandler_test
+
+import (
+	signingkeyhandler "gomonitor/internal/api/handlers/signingkey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/mocks"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_NewHandler(t *testing.T) {
+	handler := signingkeyhandler.NewHandler(slog.Default(), &mocks.MockSigningKeyService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+	assert.NotNil(t, handler)
+}
+
+func TestHandler_RegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "list route exists",
+			method:         http.MethodGet,
+			path:           "/api/v1/admin/keys",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "create route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/admin/keys",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "promote route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/admin/keys/kid/promote",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "retire route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/admin/keys/kid/retire",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "promote route only accepts POST",
+			method:         http.MethodGet,
+			path:           "/api/v1/admin/keys/kid/promote",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := signingkeyhandler.NewHandler(slog.Default(), &mocks.MockSigningKeyService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.HandleMethodNotAllowed = true
+			router.Use(middlewares.ErrorMiddleware())
+
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
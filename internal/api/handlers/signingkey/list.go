@@ -0,0 +1,18 @@
+package signingkeyhandler
+
+import (
+	signingkeydto "gomonitor/internal/api/dto/signingkey"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) List(c *gin.Context) {
+	keys, err := h.service.List(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, signingkeydto.ToSigningKeyListResponse(keys))
+}
@@ -0,0 +1,93 @@
+package signingkeyhandler_test
+
+import (
+	"encoding/json"
+	signingkeydto "gomonitor/internal/api/dto/signingkey"
+	signingkeyhandler "gomonitor/internal/api/handlers/signingkey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/signingkey"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/jwt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_List(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys := []signingkey.SigningKey{
+		{
+			KID:       "current",
+			TokenType: jwt.TokenTypeAccess,
+			Algorithm: "EdDSA",
+			Material:  "secret-material",
+			CreatedAt: time.Now(),
+			Current:   true,
+		},
+	}
+
+	tests := []struct {
+		name           string
+		setupMock      func(*mocks.MockSigningKeyService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "service returns error",
+			setupMock: func(m *mocks.MockSigningKeyService) {
+				m.On("List", mock.Anything).Return(nil, pkgerrors.NewForbiddenError())
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "success",
+			setupMock: func(m *mocks.MockSigningKeyService) {
+				m.On("List", mock.Anything).Return(keys, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp []signingkeydto.SigningKeyResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp, 1)
+				assert.Equal(t, "current", resp[0].KID)
+				assert.True(t, resp[0].Current)
+				assert.NotContains(t, rec.Body.String(), "secret-material")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockSigningKeyService{}
+			tt.setupMock(mockService)
+
+			h := signingkeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.GET("/keys", h.List)
+
+			req := httptest.NewRequest(http.MethodGet, "/keys", nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
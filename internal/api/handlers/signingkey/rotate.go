@@ -0,0 +1,43 @@
+package signingkeyhandler
+
+import (
+	signingkeydto "gomonitor/internal/api/dto/signingkey"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) Promote(c *gin.Context) {
+	var req signingkeydto.SigningKeyRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid kid parameter", err))
+		return
+	}
+
+	key, err := h.service.Promote(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, signingkeydto.ToSigningKeyResponse(key))
+}
+
+func (h *Handler) Retire(c *gin.Context) {
+	var req signingkeydto.SigningKeyRequest
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid kid parameter", err))
+		return
+	}
+
+	key, err := h.service.Retire(c.Request.Context(), req.ToDomainInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, signingkeydto.ToSigningKeyResponse(key))
+}
@@ -0,0 +1,121 @@
+package signingkeyhandler_test
+
+import (
+	"encoding/json"
+	signingkeydto "gomonitor/internal/api/dto/signingkey"
+	signingkeyhandler "gomonitor/internal/api/handlers/signingkey"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/signingkey"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/jwt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Rotate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	input := signingkey.KeyInput{KID: "kid"}
+	now := time.Now()
+
+	tests := []struct {
+		name           string
+		route          string
+		setupMock      func(*mocks.MockSigningKeyService)
+		expectedStatus int
+		validateResp   func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "promote success",
+			route: "/keys/kid/promote",
+			setupMock: func(m *mocks.MockSigningKeyService) {
+				m.On("Promote", mock.Anything, input).Return(&signingkey.SigningKey{
+					KID:        "kid",
+					TokenType:  jwt.TokenTypeAccess,
+					PromotedAt: &now,
+					Current:    true,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp signingkeydto.SigningKeyResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.True(t, resp.Current)
+			},
+		},
+		{
+			name:  "promote not found",
+			route: "/keys/kid/promote",
+			setupMock: func(m *mocks.MockSigningKeyService) {
+				m.On("Promote", mock.Anything, input).
+					Return(nil, pkgerrors.NewNotFoundError(signingkey.MsgKeyNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:  "retire current key",
+			route: "/keys/kid/retire",
+			setupMock: func(m *mocks.MockSigningKeyService) {
+				m.On("Retire", mock.Anything, input).
+					Return(nil, pkgerrors.NewConflictError(signingkey.MsgRetireCurrentKey))
+			},
+			expectedStatus: http.StatusConflict,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Contains(t, rec.Body.String(), signingkey.MsgRetireCurrentKey)
+			},
+		},
+		{
+			name:  "retire success",
+			route: "/keys/kid/retire",
+			setupMock: func(m *mocks.MockSigningKeyService) {
+				m.On("Retire", mock.Anything, input).Return(&signingkey.SigningKey{
+					KID:       "kid",
+					TokenType: jwt.TokenTypeAccess,
+					RetiredAt: &now,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validateResp: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var resp signingkeydto.SigningKeyResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.NotNil(t, resp.RetiredAt)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockSigningKeyService{}
+			tt.setupMock(mockService)
+
+			h := signingkeyhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/keys/:kid/promote", h.Promote)
+			router.POST("/keys/:kid/retire", h.Retire)
+
+			req := httptest.NewRequest(http.MethodPost, tt.route, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.validateResp != nil {
+				tt.validateResp(t, rec)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
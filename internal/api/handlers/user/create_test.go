@@ -106,7 +106,7 @@ func TestHandler_Create(t *testing.T) {
 			mockJwt := &mocks.MockJwtManager{}
 			tt.setupMock(mockService)
 
-			h := userhandler.NewHandler(slog.Default(), mockService, mockJwt)
+			h := userhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: mockJwt})
 
 			router := gin.New()
 			router.HandleMethodNotAllowed = true
@@ -139,3 +139,51 @@ func TestHandler_Create(t *testing.T) {
 		})
 	}
 }
+
+// API keys and clients carry no authentication time, creating users must not require a recent one.
+func TestHandler_CreateWithAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	request := userdto.CreateUserRequest{
+		Name:     "test",
+		Email:    "test@example.com",
+		UserName: "test",
+		Password: "testpassword123",
+	}
+
+	apiKeys := &mocks.MockAPIKeyService{}
+	apiKeys.On("Authenticate", mock.Anything, "key").Return(&identity.Principal{
+		UserID:      1,
+		Role:        identity.RoleAdmin,
+		Permissions: []identity.Permission{identity.PermUsersCreate},
+		Scopes:      []string{identity.ScopeRead, identity.ScopeWrite},
+		Source:      identity.AuthAPIKey,
+	}, nil)
+
+	mockService := &mocks.MockUserService{}
+	mockService.On("CreateUser", mock.Anything, request.ToDomainInput()).
+		Return(&user.User{ID: 2, Email: request.Email, Role: identity.RoleUser}, nil)
+
+	h := userhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{
+		APIKeys:          apiKeys,
+		RecentAuthMaxAge: 10 * time.Minute,
+		TokenManager:     &mocks.MockJwtManager{},
+	})
+
+	router := gin.New()
+	router.Use(middlewares.ErrorMiddleware())
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middlewares.APIKeyHeader, "key")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockService.AssertExpectations(t)
+}
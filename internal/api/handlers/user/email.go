@@ -0,0 +1,59 @@
+package userhandler
+
+import (
+	userdto "gomonitor/internal/api/dto/user"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestEmailVerification answers the same whether the email is known or not.
+func (h *Handler) RequestEmailVerification(c *gin.Context) {
+	var req userdto.RequestEmailVerificationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	if err := h.service.RequestEmailVerification(c.Request.Context(), req.ToDomainInput()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *Handler) ConfirmEmail(c *gin.Context) {
+	var req userdto.ConfirmEmailRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	if err := h.service.ConfirmEmail(c.Request.Context(), req.ToDomainInput()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ChangeEmail only sends the confirmation link, the email changes once it's confirmed.
+func (h *Handler) ChangeEmail(c *gin.Context) {
+	var req userdto.ChangeEmailRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(pkgerrors.NewBadRequestError("Invalid JSON payload", err))
+		return
+	}
+
+	if err := h.service.ChangeEmail(c.Request.Context(), req.ToDomainInput()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
@@ -0,0 +1,194 @@
+package userhandler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	userdto "gomonitor/internal/api/dto/user"
+	userhandler "gomonitor/internal/api/handlers/user"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_RequestEmailVerification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:           "invalid email",
+			requestBody:    userdto.RequestEmailVerificationRequest{Email: "not-an-email"},
+			setupMock:      func(m *mocks.MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "accepted",
+			requestBody: userdto.RequestEmailVerificationRequest{Email: "test@test.com"},
+			setupMock: func(m *mocks.MockUserService) {
+				m.On("RequestEmailVerification", mock.Anything, user.RequestEmailVerificationInput{Email: "test@test.com"}).
+					Return(nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockUserService{}
+			tt.setupMock(mockService)
+
+			h := userhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/email/verification", h.RequestEmailVerification)
+
+			rec := postJSON(t, router, "/email/verification", tt.requestBody)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ConfirmEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:           "missing token",
+			requestBody:    userdto.ConfirmEmailRequest{},
+			setupMock:      func(m *mocks.MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "invalid token",
+			requestBody: userdto.ConfirmEmailRequest{Token: "token"},
+			setupMock: func(m *mocks.MockUserService) {
+				m.On("ConfirmEmail", mock.Anything, mock.Anything).
+					Return(pkgerrors.NewBadRequestError(user.MsgInvalidEmailToken))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "confirmed",
+			requestBody: userdto.ConfirmEmailRequest{Token: "token"},
+			setupMock: func(m *mocks.MockUserService) {
+				m.On("ConfirmEmail", mock.Anything, user.ConfirmEmailInput{Token: "token"}).
+					Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockUserService{}
+			tt.setupMock(mockService)
+
+			h := userhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/email/verify", h.ConfirmEmail)
+
+			rec := postJSON(t, router, "/email/verify", tt.requestBody)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ChangeEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultRequest := userdto.ChangeEmailRequest{
+		Email:    "new@test.com",
+		Password: "password123",
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    any
+		setupMock      func(*mocks.MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:           "missing password",
+			requestBody:    userdto.ChangeEmailRequest{Email: "new@test.com"},
+			setupMock:      func(m *mocks.MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "email taken",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockUserService) {
+				m.On("ChangeEmail", mock.Anything, mock.Anything).
+					Return(pkgerrors.NewConflictError(user.MsgEmailTaken))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:        "accepted",
+			requestBody: defaultRequest,
+			setupMock: func(m *mocks.MockUserService) {
+				m.On("ChangeEmail", mock.Anything, defaultRequest.ToDomainInput()).
+					Return(nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockUserService{}
+			tt.setupMock(mockService)
+
+			h := userhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.POST("/me/email", h.ChangeEmail)
+
+			rec := postJSON(t, router, "/me/email", tt.requestBody)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func postJSON(t *testing.T, router *gin.Engine, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
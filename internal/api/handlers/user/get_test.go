@@ -92,7 +92,7 @@ func TestHandler_GetById(t *testing.T) {
 			mockJwt := &mocks.MockJwtManager{}
 			tt.setupMock(mockService)
 
-			h := userhandler.NewHandler(slog.Default(), mockService, mockJwt)
+			h := userhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: mockJwt})
 
 			router := gin.New()
 			router.HandleMethodNotAllowed = true
@@ -3,30 +3,37 @@ package userhandler
 import (
 	"gomonitor/internal/api/middlewares"
 	"gomonitor/internal/domain/user"
-	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/identity"
 	"log/slog"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	logger       *slog.Logger
-	service      user.Service
-	tokenManager jwt.TokenManager
+	logger   *slog.Logger
+	service  user.Service
+	authDeps *middlewares.AuthDeps
 }
 
-func NewHandler(logger *slog.Logger, svc user.Service, tokenManager jwt.TokenManager) *Handler {
+func NewHandler(logger *slog.Logger, svc user.Service, authDeps *middlewares.AuthDeps) *Handler {
 	return &Handler{
-		logger:       logger,
-		service:      svc,
-		tokenManager: tokenManager,
+		logger:   logger,
+		service:  svc,
+		authDeps: authDeps,
 	}
 }
 
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
-	users := r.Group("/users", middlewares.AuthMiddleware(h.tokenManager))
+	users := r.Group("/users", middlewares.AuthMiddleware(h.authDeps))
 	{
-		users.POST("", h.Create)
+		users.POST("", middlewares.RequirePermission(identity.PermUsersCreate), h.Create)
 		users.GET("/:id", h.GetByID)
+		users.POST("/me/email", middlewares.RequireRecentAuth(h.authDeps.RecentAuthMaxAge), h.ChangeEmail)
+	}
+
+	email := r.Group("/auth/email")
+	{
+		email.POST("verification", h.RequestEmailVerification)
+		email.POST("verify", h.ConfirmEmail)
 	}
 }
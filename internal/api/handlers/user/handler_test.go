@@ -4,17 +4,19 @@ import (
 	userhandler "gomonitor/internal/api/handlers/user"
 	"gomonitor/internal/api/middlewares"
 	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/identity"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestHandler_NewHandler(t *testing.T) {
-	handler := userhandler.NewHandler(slog.Default(), &mocks.MockUserService{}, &mocks.MockJwtManager{})
+	handler := userhandler.NewHandler(slog.Default(), &mocks.MockUserService{}, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
 
 	assert.NotNil(t, handler)
 }
@@ -43,6 +45,27 @@ func TestHandler_RegisterRoutes(t *testing.T) {
 			expectedStatus: http.StatusUnauthorized,
 			shouldExist:    true,
 		},
+		{
+			name:           "change email route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/users/me/email",
+			expectedStatus: http.StatusUnauthorized,
+			shouldExist:    true,
+		},
+		{
+			name:           "email verification route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/email/verification",
+			expectedStatus: http.StatusBadRequest,
+			shouldExist:    true,
+		},
+		{
+			name:           "confirm email route exists",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/email/verify",
+			expectedStatus: http.StatusBadRequest,
+			shouldExist:    true,
+		},
 		{
 			name:           "create route only accepts POST",
 			method:         http.MethodGet,
@@ -63,7 +86,7 @@ func TestHandler_RegisterRoutes(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := &mocks.MockUserService{}
 			jwtManager := &mocks.MockJwtManager{}
-			h := userhandler.NewHandler(slog.Default(), mockService, jwtManager)
+			h := userhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: jwtManager})
 
 			gin.SetMode(gin.TestMode)
 			router := gin.New()
@@ -83,3 +106,49 @@ func TestHandler_RegisterRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_RoutesRequireRecentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{
+			name:   "email change",
+			method: http.MethodPost,
+			path:   "/api/v1/users/me/email",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtManager := &mocks.MockJwtManager{}
+			jwtManager.On("ValidateAccessToken", "token").Return(&identity.Principal{
+				UserID:   1,
+				Role:     identity.RoleUser,
+				Source:   identity.AuthExternal,
+				AuthTime: time.Now().Add(-time.Hour),
+			}, nil)
+
+			h := userhandler.NewHandler(slog.Default(), &mocks.MockUserService{}, &middlewares.AuthDeps{
+				RecentAuthMaxAge: 10 * time.Minute,
+				TokenManager:     jwtManager,
+			})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+		})
+	}
+}
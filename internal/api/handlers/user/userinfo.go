@@ -0,0 +1,20 @@
+package userhandler
+
+import (
+	userdto "gomonitor/internal/api/dto/user"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserInfo is the OpenID Connect style userinfo endpoint, it describes the caller.
+func (h *Handler) UserInfo(c *gin.Context) {
+	user, err := h.service.CurrentUser(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, userdto.ToUserInfoResponse(user))
+}
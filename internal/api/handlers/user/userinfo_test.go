@@ -0,0 +1,81 @@
+package userhandler_test
+
+import (
+	userhandler "gomonitor/internal/api/handlers/user"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandler_UserInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		setupMock      func(*mocks.MockUserService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "oauth client",
+			setupMock: func(m *mocks.MockUserService) {
+				m.On("CurrentUser", mock.Anything).Return(nil, pkgerrors.NewForbiddenError())
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "success",
+			setupMock: func(m *mocks.MockUserService) {
+				m.On("CurrentUser", mock.Anything).Return(&user.User{
+					ID:        1,
+					Name:      "test",
+					Email:     "test@example.com",
+					UserName:  "tester",
+					Password:  "generated-hash",
+					Role:      identity.RoleUser,
+					UpdatedAt: time.Unix(1700000000, 0),
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"sub":"1","name":"test","preferred_username":"tester","email":"test@example.com",` +
+				`"email_verified":false,"role":"user","updated_at":1700000000}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockUserService{}
+			tt.setupMock(mockService)
+
+			h := userhandler.NewHandler(slog.Default(), mockService, &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}})
+
+			router := gin.New()
+			router.Use(middlewares.ErrorMiddleware())
+			router.GET("/userinfo", h.UserInfo)
+
+			req := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+				assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
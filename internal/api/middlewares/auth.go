@@ -1,40 +1,256 @@
 package middlewares
 
 import (
+	"context"
+	"crypto/subtle"
+	"gomonitor/internal/config"
+	"gomonitor/internal/observability/logging"
+	"gomonitor/internal/observability/tracing"
+	"gomonitor/internal/pkg/dpop"
 	pkgerrors "gomonitor/internal/pkg/errors"
 	"gomonitor/internal/pkg/identity"
 	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/revocation"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(tokenManager jwt.TokenManager) gin.HandlerFunc {
+// APIKeyHeader carries API keys, they are also accepted as bearer tokens.
+const APIKeyHeader = "X-API-Key"
+
+// Cookies of the browser session mode.
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	// CSRFCookie is readable by scripts, they echo it in CSRFHeader on state changing requests.
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// defaultCredentialSources are used without session config.
+var defaultCredentialSources = []string{config.CredentialSourceHeader, config.CredentialSourceQuery}
+
+// APIKeyAuthenticator resolves API keys to the principal they act as.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*identity.Principal, error)
+}
+
+// PermissionResolver resolves the permissions granted by a role.
+type PermissionResolver interface {
+	Permissions(ctx context.Context, role identity.UserRole) ([]identity.Permission, error)
+}
+
+// AuthDeps are the dependencies used to authenticate requests, shared by every handler.
+type AuthDeps struct {
+	// APIKeys is optional, without it API keys are refused.
+	APIKeys  APIKeyAuthenticator
+	Denylist revocation.Denylist
+	// DPoP is optional, without it proofs are ignored on the token endpoints and bound tokens are refused.
+	DPoP *dpop.Verifier
+	// Permissions is optional, without it principals are granted no permission.
+	Permissions PermissionResolver
+	// RecentAuthMaxAge is given to RequireRecentAuth by the routes of sensitive operations.
+	RecentAuthMaxAge time.Duration
+	// Session is optional, without it tokens are read from the header and the query.
+	Session      *config.SessionConfig
+	TokenManager jwt.TokenManager
+}
+
+type authOptions struct {
+	sources []string
+}
+
+type AuthOption func(*authOptions)
+
+// WithCredentialSources overrides the configured credential sources for a route group.
+func WithCredentialSources(sources ...string) AuthOption {
+	return func(o *authOptions) {
+		o.sources = sources
+	}
+}
+
+func AuthMiddleware(deps *AuthDeps, opts ...AuthOption) gin.HandlerFunc {
+	options := &authOptions{sources: defaultCredentialSources}
+	if deps.Session != nil {
+		options.sources = deps.Session.CredentialSources
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	return func(c *gin.Context) {
-		token := extractToken(c)
+		if key := extractAPIKey(c); key != "" {
+			authenticateAPIKey(c, deps, key)
+			return
+		}
+
+		token, source := extractToken(c, options.sources)
 		if token == "" {
 			_ = c.Error(pkgerrors.NewUnauthorizedError("Authorization header required"))
 			c.Abort()
 			return
 		}
 
-		principal, err := tokenManager.ValidateAccessToken(token)
+		// Browsers attach cookies to cross site requests too, only the frontend can echo the CSRF cookie.
+		if source == config.CredentialSourceCookie && !isSafeMethod(c.Request.Method) && !ValidCSRF(c) {
+			_ = c.Error(pkgerrors.NewForbiddenError())
+			c.Abort()
+			return
+		}
+
+		principal, err := deps.TokenManager.ValidateAccessToken(token)
 		if err != nil {
 			_ = c.Error(pkgerrors.NewUnauthorizedError("Invalid or expired token", err))
 			c.Abort()
 			return
 		}
 
-		authenticatedContext := identity.WithPrincipal(c.Request.Context(), principal)
-		c.Request = c.Request.WithContext(authenticatedContext)
-		c.Next()
+		if err := verifyDPoP(c, deps, principal, token, source); err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+
+		if deps.Denylist != nil && principal.SessionID != nil {
+			revoked, err := deps.Denylist.IsRevoked(c.Request.Context(), *principal.SessionID)
+			if err != nil {
+				_ = c.Error(pkgerrors.NewInternalError(err))
+				c.Abort()
+				return
+			}
+
+			if revoked {
+				_ = c.Error(pkgerrors.NewUnauthorizedError("Session has been revoked"))
+				c.Abort()
+				return
+			}
+		}
+
+		// Client credentials tokens are scoped like API keys, sessions pass unrestricted.
+		if !principal.HasScope(requiredScope(c.Request.Method)) {
+			_ = c.Error(pkgerrors.NewForbiddenError())
+			c.Abort()
+			return
+		}
+
+		authenticate(c, deps, principal)
 	}
 }
 
-func extractToken(c *gin.Context) string {
+// authenticateAPIKey authenticates the request with an API key, limited to reads without the write scope.
+func authenticateAPIKey(c *gin.Context, deps *AuthDeps, key string) {
+	if deps.APIKeys == nil {
+		_ = c.Error(pkgerrors.NewUnauthorizedError("API keys are not accepted"))
+		c.Abort()
+		return
+	}
+
+	principal, err := deps.APIKeys.Authenticate(c.Request.Context(), key)
+	if err != nil {
+		_ = c.Error(err)
+		c.Abort()
+		return
+	}
+
+	if !principal.HasScope(requiredScope(c.Request.Method)) {
+		_ = c.Error(pkgerrors.NewForbiddenError())
+		c.Abort()
+		return
+	}
+
+	authenticate(c, deps, principal)
+}
+
+// authenticate resolves the permissions of the principal and stores it in the request context.
+func authenticate(c *gin.Context, deps *AuthDeps, principal *identity.Principal) {
+	if deps.Permissions != nil && principal.Role != "" {
+		permissions, err := deps.Permissions.Permissions(c.Request.Context(), principal.Role)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		principal.Permissions = permissions
+	}
+
+	authenticatedContext := identity.WithPrincipal(c.Request.Context(), principal)
+
+	// The request span started before authentication, later spans are tagged by the span processor.
+	if principal.Impersonated() {
+		logger := logging.WithImpersonation(logging.FromContext(authenticatedContext), principal)
+		authenticatedContext = logging.WithContext(authenticatedContext, logger)
+		tracing.SetAttributes(authenticatedContext, tracing.ImpersonationAttributes(principal)...)
+	}
+
+	c.Request = c.Request.WithContext(authenticatedContext)
+	c.Next()
+}
+
+func requiredScope(method string) string {
+	if isSafeMethod(method) {
+		return identity.ScopeRead
+	}
+	return identity.ScopeWrite
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidCSRF reports if the request repeats the CSRF cookie in CSRFHeader, the double submit pattern.
+func ValidCSRF(c *gin.Context) bool {
+	cookie, err := c.Cookie(CSRFCookie)
+	if err != nil || cookie == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(c.GetHeader(CSRFHeader))) == 1
+}
+
+// extractAPIKey returns the key from its header, or from the Authorization header when it has the key prefix.
+func extractAPIKey(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+
 	authHeader := c.GetHeader("Authorization")
-	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		return authHeader[7:]
+	if token, ok := strings.CutPrefix(authHeader, "Bearer "); ok && strings.HasPrefix(token, identity.APIKeyPrefix) {
+		return token
+	}
+
+	return ""
+}
+
+// extractToken returns the access token from the first of the sources which has one.
+func extractToken(c *gin.Context, sources []string) (string, string) {
+	for _, source := range sources {
+		var token string
+		switch source {
+		case config.CredentialSourceHeader:
+			authHeader := c.GetHeader("Authorization")
+			if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+				token = authHeader[7:]
+			} else if bound, ok := strings.CutPrefix(authHeader, dpop.Scheme+" "); ok {
+				token = bound
+			}
+		case config.CredentialSourceCookie:
+			token, _ = c.Cookie(AccessTokenCookie)
+		case config.CredentialSourceQuery:
+			token = c.Query("token")
+		}
+
+		if token != "" {
+			return token, source
+		}
 	}
 
-	return c.Query("token")
+	return "", ""
 }
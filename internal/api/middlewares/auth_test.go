@@ -1,16 +1,23 @@
 package middlewares_test
 
 import (
+	"bytes"
 	"errors"
 	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/config"
 	"gomonitor/internal/mocks"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
 	"gomonitor/internal/pkg/identity"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestMiddleware_Auth(t *testing.T) {
@@ -88,7 +95,7 @@ func TestMiddleware_Auth(t *testing.T) {
 			// Without error middleware, auth middleware won't return correct statuses.
 			r.Use(middlewares.ErrorMiddleware())
 
-			r.Use(middlewares.AuthMiddleware(jwtManagerMock))
+			r.Use(middlewares.AuthMiddleware(&middlewares.AuthDeps{TokenManager: jwtManagerMock}))
 
 			r.GET("/test", tt.ginHandler)
 
@@ -107,3 +114,427 @@ func TestMiddleware_Auth(t *testing.T) {
 		})
 	}
 }
+
+func TestMiddleware_AuthDenylist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	sessionID := uuid.New()
+	sessionIdentity := &identity.Principal{
+		UserID:    1,
+		Role:      identity.RoleUser,
+		Source:    identity.AuthExternal,
+		SessionID: &sessionID,
+	}
+
+	tests := []struct {
+		name           string
+		principal      *identity.Principal
+		setupMock      func(*mocks.MockDenylist)
+		expectedStatus int
+	}{
+		{
+			name:      "active session",
+			principal: sessionIdentity,
+			setupMock: func(md *mocks.MockDenylist) {
+				md.On("IsRevoked", mock.Anything, sessionID).Return(false, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:      "revoked session",
+			principal: sessionIdentity,
+			setupMock: func(md *mocks.MockDenylist) {
+				md.On("IsRevoked", mock.Anything, sessionID).Return(true, nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:      "denylist and fallback unavailable",
+			principal: sessionIdentity,
+			setupMock: func(md *mocks.MockDenylist) {
+				md.On("IsRevoked", mock.Anything, sessionID).Return(false, errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "ended impersonation",
+			principal: &identity.Principal{
+				UserID:    2,
+				Role:      identity.RoleUser,
+				Source:    identity.AuthExternal,
+				ActorID:   1,
+				SessionID: &sessionID,
+			},
+			setupMock: func(md *mocks.MockDenylist) {
+				md.On("IsRevoked", mock.Anything, sessionID).Return(true, nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "token without session",
+			principal: &identity.Principal{
+				UserID: 1,
+				Role:   identity.RoleUser,
+				Source: identity.AuthExternal,
+			},
+			setupMock:      func(md *mocks.MockDenylist) {},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+
+			jwtManagerMock := &mocks.MockJwtManager{}
+			jwtManagerMock.On("ValidateAccessToken", "valid-token").Return(tt.principal, nil)
+
+			denylistMock := &mocks.MockDenylist{}
+			tt.setupMock(denylistMock)
+
+			r.Use(middlewares.ErrorMiddleware())
+			r.Use(middlewares.AuthMiddleware(&middlewares.AuthDeps{
+				Denylist:     denylistMock,
+				TokenManager: jwtManagerMock,
+			}))
+
+			r.GET("/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer valid-token")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			denylistMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMiddleware_AuthAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	readOnly := &identity.Principal{
+		UserID: 1,
+		Role:   identity.RoleUser,
+		Source: identity.AuthAPIKey,
+		Scopes: []string{identity.ScopeRead},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		header         string
+		value          string
+		setupMock      func(*mocks.MockAPIKeyService)
+		noAPIKeys      bool
+		expectedStatus int
+	}{
+		{
+			name:   "key header",
+			method: http.MethodGet,
+			header: middlewares.APIKeyHeader,
+			value:  "gmk_key",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Authenticate", mock.Anything, "gmk_key").Return(readOnly, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "bearer key",
+			method: http.MethodGet,
+			header: "Authorization",
+			value:  "Bearer gmk_key",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Authenticate", mock.Anything, "gmk_key").Return(readOnly, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "invalid key",
+			method: http.MethodGet,
+			header: middlewares.APIKeyHeader,
+			value:  "gmk_key",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Authenticate", mock.Anything, "gmk_key").
+					Return(nil, pkgerrors.NewUnauthorizedError("invalid or expired api key"))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "write without the scope",
+			method: http.MethodPost,
+			header: middlewares.APIKeyHeader,
+			value:  "gmk_key",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Authenticate", mock.Anything, "gmk_key").Return(readOnly, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "write with the scope",
+			method: http.MethodPost,
+			header: middlewares.APIKeyHeader,
+			value:  "gmk_key",
+			setupMock: func(m *mocks.MockAPIKeyService) {
+				m.On("Authenticate", mock.Anything, "gmk_key").Return(&identity.Principal{
+					UserID: 1,
+					Role:   identity.RoleUser,
+					Source: identity.AuthAPIKey,
+					Scopes: []string{identity.ScopeWrite},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "keys not accepted",
+			method:         http.MethodGet,
+			header:         middlewares.APIKeyHeader,
+			value:          "gmk_key",
+			setupMock:      func(m *mocks.MockAPIKeyService) {},
+			noAPIKeys:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+
+			apiKeysMock := &mocks.MockAPIKeyService{}
+			tt.setupMock(apiKeysMock)
+
+			deps := &middlewares.AuthDeps{TokenManager: &mocks.MockJwtManager{}}
+			if !tt.noAPIKeys {
+				deps.APIKeys = apiKeysMock
+			}
+
+			r.Use(middlewares.ErrorMiddleware())
+			r.Use(middlewares.AuthMiddleware(deps))
+
+			r.Handle(tt.method, "/test", func(c *gin.Context) {
+				principal, ok := identity.PrincipalFromContext(c.Request.Context())
+				assert.True(t, ok)
+				assert.Equal(t, identity.AuthAPIKey, principal.Source)
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/test", nil)
+			req.Header.Set(tt.header, tt.value)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			apiKeysMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMiddleware_AuthClientToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	readOnly := &identity.Principal{
+		ClientID: "client",
+		Source:   identity.AuthClient,
+		Scopes:   []string{identity.ScopeRead},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		expectedStatus int
+	}{
+		{
+			name:           "read with the scope",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "write without the scope",
+			method:         http.MethodDelete,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+
+			jwtMock := &mocks.MockJwtManager{}
+			jwtMock.On("ValidateAccessToken", "client-token").Return(readOnly, nil)
+
+			r.Use(middlewares.ErrorMiddleware())
+			r.Use(middlewares.AuthMiddleware(&middlewares.AuthDeps{TokenManager: jwtMock}))
+
+			r.Handle(tt.method, "/test", func(c *gin.Context) {
+				principal, ok := identity.PrincipalFromContext(c.Request.Context())
+				assert.True(t, ok)
+				assert.Equal(t, "client", principal.ClientID)
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/test", nil)
+			req.Header.Set("Authorization", "Bearer client-token")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			jwtMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMiddleware_AuthCredentialSources(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	validIdentity := &identity.Principal{
+		UserID: 1,
+		Role:   identity.RoleUser,
+		Source: identity.AuthExternal,
+	}
+
+	cookieSession := &config.SessionConfig{
+		CookieMode:        true,
+		CredentialSources: []string{config.CredentialSourceHeader, config.CredentialSourceCookie},
+	}
+
+	tests := []struct {
+		name           string
+		session        *config.SessionConfig
+		opts           []middlewares.AuthOption
+		method         string
+		setupRequest   func(*http.Request)
+		expectedStatus int
+	}{
+		{
+			name:   "query token by default",
+			method: http.MethodGet,
+			setupRequest: func(req *http.Request) {
+				req.URL.RawQuery = "token=valid-token"
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "query token turned off",
+			method: http.MethodGet,
+			opts:   []middlewares.AuthOption{middlewares.WithCredentialSources(config.CredentialSourceHeader)},
+			setupRequest: func(req *http.Request) {
+				req.URL.RawQuery = "token=valid-token"
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:    "cookie ignored without the source",
+			session: &config.SessionConfig{CredentialSources: []string{config.CredentialSourceHeader}},
+			method:  http.MethodGet,
+			setupRequest: func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: middlewares.AccessTokenCookie, Value: "valid-token"})
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:    "cookie on a safe method",
+			session: cookieSession,
+			method:  http.MethodGet,
+			setupRequest: func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: middlewares.AccessTokenCookie, Value: "valid-token"})
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "cookie without csrf token",
+			session: cookieSession,
+			method:  http.MethodPost,
+			setupRequest: func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: middlewares.AccessTokenCookie, Value: "valid-token"})
+				req.AddCookie(&http.Cookie{Name: middlewares.CSRFCookie, Value: "csrf"})
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:    "cookie with a wrong csrf token",
+			session: cookieSession,
+			method:  http.MethodPost,
+			setupRequest: func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: middlewares.AccessTokenCookie, Value: "valid-token"})
+				req.AddCookie(&http.Cookie{Name: middlewares.CSRFCookie, Value: "csrf"})
+				req.Header.Set(middlewares.CSRFHeader, "other")
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:    "cookie with the csrf token",
+			session: cookieSession,
+			method:  http.MethodPost,
+			setupRequest: func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: middlewares.AccessTokenCookie, Value: "valid-token"})
+				req.AddCookie(&http.Cookie{Name: middlewares.CSRFCookie, Value: "csrf"})
+				req.Header.Set(middlewares.CSRFHeader, "csrf")
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "header needs no csrf token",
+			session: cookieSession,
+			method:  http.MethodPost,
+			setupRequest: func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer valid-token")
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+
+			jwtManagerMock := &mocks.MockJwtManager{}
+			jwtManagerMock.On("ValidateAccessToken", "valid-token").Return(validIdentity, nil).Maybe()
+
+			r.Use(middlewares.ErrorMiddleware())
+			r.Use(middlewares.AuthMiddleware(&middlewares.AuthDeps{
+				Session:      tt.session,
+				TokenManager: jwtManagerMock,
+			}, tt.opts...))
+
+			r.Handle(tt.method, "/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/test", nil)
+			tt.setupRequest(req)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestMiddleware_AuthImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwtManagerMock := &mocks.MockJwtManager{}
+	jwtManagerMock.On("ValidateAccessToken", "impersonation-token").
+		Return(&identity.Principal{UserID: 2, Role: identity.RoleUser, Source: identity.AuthExternal, ActorID: 1}, nil)
+
+	var buf bytes.Buffer
+	r := gin.New()
+	r.Use(middlewares.ErrorMiddleware())
+	r.Use(middlewares.LoggingMiddleware(slog.New(slog.NewJSONHandler(&buf, nil))))
+	r.Use(middlewares.AuthMiddleware(&middlewares.AuthDeps{TokenManager: jwtManagerMock}))
+	r.GET("/test", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("handled")
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer impersonation-token")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, buf.String(), `"impersonation":{"user_id":2,"actor_id":1}`)
+}
@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/pkg/dpop"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dpopThumbprintKey = "dpop_jkt"
+
+// DPoPProof verifies the optional proof sent to a token endpoint, the tokens issued are then bound to
+// its key. Requests without a proof get bearer tokens.
+func DPoPProof(deps *AuthDeps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		proof := c.GetHeader(dpop.Header)
+		if proof == "" || deps.DPoP == nil {
+			c.Next()
+			return
+		}
+
+		jkt, err := deps.DPoP.Verify(c.Request.Context(), proof, dpop.Request{
+			Method: c.Request.Method,
+			URL:    dpop.RequestURL(c.Request),
+		})
+		if err != nil {
+			_ = c.Error(dpopError(err))
+			c.Abort()
+			return
+		}
+
+		c.Set(dpopThumbprintKey, jkt)
+		c.Next()
+	}
+}
+
+// DPoPThumbprint returns the thumbprint of the key whose proof DPoPProof accepted, empty without one.
+func DPoPThumbprint(c *gin.Context) string {
+	return c.GetString(dpopThumbprintKey)
+}
+
+// verifyDPoP checks that a bound token comes with a proof of its key, and that only bound tokens use
+// the DPoP scheme.
+func verifyDPoP(c *gin.Context, deps *AuthDeps, principal *identity.Principal, token, source string) error {
+	dpopScheme := source == config.CredentialSourceHeader && strings.HasPrefix(c.GetHeader("Authorization"), dpop.Scheme+" ")
+
+	if principal.JKT == "" {
+		if dpopScheme {
+			return pkgerrors.NewUnauthorizedError("Token is not bound to a DPoP key")
+		}
+		return nil
+	}
+
+	// Sent as a bearer token, it would be replayable by anyone who got hold of it.
+	if source == config.CredentialSourceHeader && !dpopScheme {
+		return pkgerrors.NewUnauthorizedError("Bound token requires the DPoP scheme")
+	}
+
+	proof := c.GetHeader(dpop.Header)
+	if proof == "" || deps.DPoP == nil {
+		return pkgerrors.NewUnauthorizedError("DPoP proof required")
+	}
+
+	jkt, err := deps.DPoP.Verify(c.Request.Context(), proof, dpop.Request{
+		Method:      c.Request.Method,
+		URL:         dpop.RequestURL(c.Request),
+		AccessToken: token,
+	})
+	if err != nil {
+		return dpopError(err)
+	}
+
+	if jkt != principal.JKT {
+		return pkgerrors.NewUnauthorizedError("Invalid DPoP proof")
+	}
+
+	return nil
+}
+
+func dpopError(err error) error {
+	if errors.Is(err, dpop.ErrInvalidProof) || errors.Is(err, dpop.ErrReplayed) {
+		return pkgerrors.NewUnauthorizedError("Invalid DPoP proof", err)
+	}
+	return pkgerrors.NewInternalError(err)
+}
@@ -0,0 +1,208 @@
+package middlewares_test
+
+import (
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/dpop"
+	"gomonitor/internal/pkg/dpop/dpoptest"
+	"gomonitor/internal/pkg/identity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const dpopURL = "http://example.com/test"
+
+func newDPoPVerifier() *dpop.Verifier {
+	client := &mocks.MockRedisClient{}
+	client.On("Eval", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("OK", nil)
+	return dpop.NewVerifier(dpop.NewRedisReplayStore(client), time.Minute)
+}
+
+func TestMiddleware_AuthDPoP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key, err := dpoptest.NewKey()
+	require.NoError(t, err)
+	other, err := dpoptest.NewKey()
+	require.NoError(t, err)
+
+	proof := func(t *testing.T, k *dpoptest.Key, method, url, token string) string {
+		p, err := k.Proof(method, url, token)
+		require.NoError(t, err)
+		return p
+	}
+
+	tests := []struct {
+		name           string
+		unbound        bool
+		withoutDPoP    bool
+		authorization  string
+		proof          func(t *testing.T) string
+		expectedStatus int
+	}{
+		{
+			name:          "bound token with proof",
+			authorization: "DPoP token",
+			proof: func(t *testing.T) string {
+				return proof(t, key, http.MethodGet, dpopURL, "token")
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:          "bound token as bearer",
+			authorization: "Bearer token",
+			proof: func(t *testing.T) string {
+				return proof(t, key, http.MethodGet, dpopURL, "token")
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "bound token without proof",
+			authorization:  "DPoP token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "proof of another key",
+			authorization: "DPoP token",
+			proof: func(t *testing.T) string {
+				return proof(t, other, http.MethodGet, dpopURL, "token")
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "proof of another request",
+			authorization: "DPoP token",
+			proof: func(t *testing.T) string {
+				return proof(t, key, http.MethodPost, dpopURL, "token")
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "proof without the token hash",
+			authorization: "DPoP token",
+			proof: func(t *testing.T) string {
+				return proof(t, key, http.MethodGet, dpopURL, "")
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "bound token without verifier",
+			withoutDPoP:   true,
+			authorization: "DPoP token",
+			proof: func(t *testing.T) string {
+				return proof(t, key, http.MethodGet, dpopURL, "token")
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "unbound token with dpop scheme",
+			unbound:        true,
+			authorization:  "DPoP token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "unbound token as bearer",
+			unbound:        true,
+			authorization:  "Bearer token",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal := &identity.Principal{UserID: 1, Role: identity.RoleUser, Source: identity.AuthExternal}
+			if !tt.unbound {
+				principal.JKT = key.Thumbprint()
+			}
+
+			jwtManager := &mocks.MockJwtManager{}
+			jwtManager.On("ValidateAccessToken", "token").Return(principal, nil)
+
+			deps := &middlewares.AuthDeps{TokenManager: jwtManager}
+			if !tt.withoutDPoP {
+				deps.DPoP = newDPoPVerifier()
+			}
+
+			r := gin.New()
+			r.Use(middlewares.ErrorMiddleware())
+			r.Use(middlewares.AuthMiddleware(deps))
+			r.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", tt.authorization)
+			if tt.proof != nil {
+				req.Header.Set(dpop.Header, tt.proof(t))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestMiddleware_DPoPProof(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key, err := dpoptest.NewKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		proof          func(t *testing.T) string
+		expectedStatus int
+		expectedJKT    string
+	}{
+		{
+			name:           "no proof",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "valid proof",
+			proof: func(t *testing.T) string {
+				p, err := key.Proof(http.MethodPost, dpopURL, "")
+				require.NoError(t, err)
+				return p
+			},
+			expectedStatus: http.StatusOK,
+			expectedJKT:    key.Thumbprint(),
+		},
+		{
+			name: "invalid proof",
+			proof: func(t *testing.T) string {
+				return "not-a-proof"
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var jkt string
+
+			r := gin.New()
+			r.Use(middlewares.ErrorMiddleware())
+			r.POST("/test", middlewares.DPoPProof(&middlewares.AuthDeps{DPoP: newDPoPVerifier()}), func(c *gin.Context) {
+				jkt = middlewares.DPoPThumbprint(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/test", nil)
+			if tt.proof != nil {
+				req.Header.Set(dpop.Header, tt.proof(t))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedJKT, jkt)
+		})
+	}
+}
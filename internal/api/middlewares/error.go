@@ -10,8 +10,9 @@ import (
 )
 
 type ErrorResponse struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Fields  []pkgerrors.FieldError `json:"fields,omitempty"`
 }
 
 func ErrorMiddleware() gin.HandlerFunc {
@@ -51,6 +52,7 @@ func ErrorMiddleware() gin.HandlerFunc {
 		c.JSON(appErr.StatusCode, ErrorResponse{
 			Code:    appErr.Code,
 			Message: appErr.Message,
+			Fields:  appErr.Fields,
 		})
 	}
 }
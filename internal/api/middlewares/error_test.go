@@ -49,6 +49,34 @@ func TestMiddleware_Error(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "validation error lists the fields",
+			ginHandler: func(c *gin.Context) {
+				_ = c.Error(pkgerrors.NewValidationError("invalid password", pkgerrors.FieldError{
+					Field:   "password",
+					Code:    "too_short",
+					Message: "must be at least 8 characters",
+				}))
+			},
+			expectedStatus: http.StatusBadRequest,
+			validateResp: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.JSONEq(t, `{
+					"code": "VALIDATION_ERROR",
+					"message": "invalid password",
+					"fields": [{"field": "password", "code": "too_short", "message": "must be at least 8 characters"}]
+				}`, w.Body.String())
+			},
+		},
+		{
+			name: "fields are omitted without violations",
+			ginHandler: func(c *gin.Context) {
+				_ = c.Error(pkgerrors.NewBadRequestError("bad request"))
+			},
+			expectedStatus: http.StatusBadRequest,
+			validateResp: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.NotContains(t, w.Body.String(), "fields")
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -62,6 +90,9 @@ func TestMiddleware_Error(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.validateResp != nil {
+				tt.validateResp(t, w)
+			}
 		})
 	}
 }
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission lets the request through if the principal has every permission, it runs after AuthMiddleware.
+func RequirePermission(permissions ...identity.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := identity.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			_ = c.Error(pkgerrors.NewUnauthorizedError("unauthenticated"))
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !principal.HasPermission(permission) {
+				logging.FromContext(c.Request.Context()).Warn("missing permission",
+					"permission", permission,
+					"user_id", principal.UserID,
+					"user_role", principal.Role,
+					"source", principal.Source,
+				)
+				_ = c.Error(pkgerrors.NewForbiddenError())
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
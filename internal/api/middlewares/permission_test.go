@@ -0,0 +1,154 @@
+package middlewares_test
+
+import (
+	"errors"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMiddleware_AuthPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		principal       *identity.Principal
+		setupMock       func(*mocks.MockRBACService)
+		expectedStatus  int
+		wantPermissions []identity.Permission
+	}{
+		{
+			name:      "permissions of the role",
+			principal: &identity.Principal{UserID: 1, Role: identity.RoleModerator, Source: identity.AuthExternal},
+			setupMock: func(m *mocks.MockRBACService) {
+				m.On("Permissions", mock.Anything, identity.RoleModerator).
+					Return([]identity.Permission{identity.PermUsersRead}, nil)
+			},
+			expectedStatus:  http.StatusOK,
+			wantPermissions: []identity.Permission{identity.PermUsersRead},
+		},
+		{
+			name:           "clients have no role",
+			principal:      &identity.Principal{ClientID: "client", Scopes: []string{identity.ScopeRead}, Source: identity.AuthClient},
+			setupMock:      func(m *mocks.MockRBACService) {},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:      "resolver error",
+			principal: &identity.Principal{UserID: 1, Role: identity.RoleUser, Source: identity.AuthExternal},
+			setupMock: func(m *mocks.MockRBACService) {
+				m.On("Permissions", mock.Anything, identity.RoleUser).
+					Return(nil, pkgerrors.NewInternalError(errors.New("db down")))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtManagerMock := &mocks.MockJwtManager{}
+			jwtManagerMock.On("ValidateAccessToken", "token").Return(tt.principal, nil)
+
+			rbacMock := &mocks.MockRBACService{}
+			tt.setupMock(rbacMock)
+
+			r := gin.New()
+			r.Use(middlewares.ErrorMiddleware())
+			r.Use(middlewares.AuthMiddleware(&middlewares.AuthDeps{
+				Permissions:  rbacMock,
+				TokenManager: jwtManagerMock,
+			}))
+
+			var got []identity.Permission
+			r.GET("/test", func(c *gin.Context) {
+				principal, _ := identity.PrincipalFromContext(c.Request.Context())
+				got = principal.Permissions
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.wantPermissions, got)
+			rbacMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMiddleware_RequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		principal      *identity.Principal
+		required       []identity.Permission
+		expectedStatus int
+	}{
+		{
+			name:           "unauthenticated",
+			required:       []identity.Permission{identity.PermUsersRead},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "missing permission",
+			principal: &identity.Principal{
+				UserID: 1,
+				Role:   identity.RoleUser,
+			},
+			required:       []identity.Permission{identity.PermUsersRead},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "missing one of the permissions",
+			principal: &identity.Principal{
+				UserID:      1,
+				Role:        identity.RoleModerator,
+				Permissions: []identity.Permission{identity.PermSessionsRead},
+			},
+			required:       []identity.Permission{identity.PermSessionsRead, identity.PermSessionsRevoke},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "granted",
+			principal: &identity.Principal{
+				UserID:      1,
+				Role:        identity.RoleModerator,
+				Permissions: []identity.Permission{identity.PermSessionsRead, identity.PermSessionsRevoke},
+			},
+			required:       []identity.Permission{identity.PermSessionsRead, identity.PermSessionsRevoke},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middlewares.ErrorMiddleware())
+			r.Use(func(c *gin.Context) {
+				if tt.principal != nil {
+					c.Request = c.Request.WithContext(identity.WithPrincipal(c.Request.Context(), tt.principal))
+				}
+			})
+
+			r.GET("/test", middlewares.RequirePermission(tt.required...), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"fmt"
+	"gomonitor/internal/observability/logging"
+	"gomonitor/internal/pkg/dpop"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MsgRecentAuthRequired is the message of the step-up challenge.
+const MsgRecentAuthRequired = "recent authentication required"
+
+// RequireRecentAuth lets the request through if the user proved their credentials within maxAge, it runs
+// after AuthMiddleware. Otherwise it answers with a step-up challenge (RFC 9470), the client re-authenticates
+// on POST /auth/reauthenticate, or POST /auth/reauthenticate/passkey, and retries with the new access token.
+func RequireRecentAuth(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := identity.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			_ = c.Error(pkgerrors.NewUnauthorizedError("unauthenticated"))
+			c.Abort()
+			return
+		}
+
+		// API keys, clients and impersonations carry no authentication time, they are never recent.
+		if principal.AuthTime.IsZero() || time.Since(principal.AuthTime) > maxAge {
+			logging.FromContext(c.Request.Context()).Warn("authentication too old",
+				"user_id", principal.UserID,
+				"source", principal.Source,
+				"auth_time", principal.AuthTime,
+			)
+
+			scheme := "Bearer"
+			if principal.JKT != "" {
+				scheme = dpop.Scheme
+			}
+			c.Header("WWW-Authenticate", fmt.Sprintf(
+				`%s error="insufficient_user_authentication", error_description="%s", max_age=%d`,
+				scheme, MsgRecentAuthRequired, int(maxAge.Seconds()),
+			))
+			_ = c.Error(pkgerrors.NewReauthenticationRequiredError(MsgRecentAuthRequired))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,83 @@
+package middlewares_test
+
+import (
+	"encoding/json"
+	"gomonitor/internal/api/middlewares"
+	"gomonitor/internal/pkg/identity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_RequireRecentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		principal      *identity.Principal
+		expectedStatus int
+		expectedHeader string
+	}{
+		{
+			name:           "unauthenticated",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "recent authentication",
+			principal:      &identity.Principal{UserID: 1, AuthTime: time.Now().Add(-time.Minute)},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "authentication too old",
+			principal:      &identity.Principal{UserID: 1, AuthTime: time.Now().Add(-time.Hour)},
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: `Bearer error="insufficient_user_authentication", error_description="recent authentication required", max_age=600`,
+		},
+		{
+			name:           "bound token is challenged with its scheme",
+			principal:      &identity.Principal{UserID: 1, JKT: "thumbprint", AuthTime: time.Now().Add(-time.Hour)},
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: `DPoP error="insufficient_user_authentication", error_description="recent authentication required", max_age=600`,
+		},
+		{
+			name:           "api keys have no authentication time",
+			principal:      &identity.Principal{UserID: 1, Source: identity.AuthAPIKey},
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: `Bearer error="insufficient_user_authentication", error_description="recent authentication required", max_age=600`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middlewares.ErrorMiddleware())
+			r.Use(func(c *gin.Context) {
+				if tt.principal != nil {
+					c.Request = c.Request.WithContext(identity.WithPrincipal(c.Request.Context(), tt.principal))
+				}
+			})
+
+			r.POST("/test", middlewares.RequireRecentAuth(10*time.Minute), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedHeader, w.Header().Get("WWW-Authenticate"))
+
+			if tt.expectedHeader != "" {
+				var body middlewares.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, "REAUTHENTICATION_REQUIRED", body.Code)
+				assert.Equal(t, middlewares.MsgRecentAuthRequired, body.Message)
+			}
+		})
+	}
+}
@@ -47,6 +47,27 @@ func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*App, fu
 		return nil, nil, fmt.Errorf("bootstrap failed: %w", err)
 	}
 
+	// Keep the keyrings in sync with keys rotated on other instances.
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	go container.Services.SigningKey.Run(syncCtx, cfg.Auth.KeyringSyncInterval)
+	go container.Services.AuthEvent.RunRetention(syncCtx, cfg.AuthEvents.PruneInterval)
+
+	// The recorder writes its buffered events once stopped, before the database is closed.
+	eventsDone := make(chan struct{})
+	go func() {
+		defer close(eventsDone)
+		container.AuthEvents.Run(syncCtx)
+	}()
+
+	cleanup := func(ctx context.Context) error {
+		stopSync()
+		select {
+		case <-eventsDone:
+		case <-ctx.Done():
+		}
+		return depsCleanup(ctx)
+	}
+
 	engine := gin.New()
 	engine.HandleMethodNotAllowed = true
 
@@ -67,13 +88,31 @@ func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*App, fu
 
 	userHandler := container.Handler.User
 	authHandler := container.Handler.Auth
+	signingKeyHandler := container.Handler.SigningKey
+	mfaHandler := container.Handler.MFA
+	apiKeyHandler := container.Handler.APIKey
+	oauthHandler := container.Handler.OAuth
+	passkeyHandler := container.Handler.Passkey
+	authEventHandler := container.Handler.AuthEvent
+
+	registerRoutes(engine, userHandler, authHandler, signingKeyHandler, mfaHandler, apiKeyHandler, oauthHandler, passkeyHandler, authEventHandler)
+
+	// Public keys for offline token verification by other services.
+	engine.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Client credentials grant for service to service calls.
+	engine.POST("/oauth/token", oauthHandler.Token)
+	engine.POST("/oauth/introspect", oauthHandler.Introspect)
 
-	registerRoutes(engine, userHandler, authHandler)
+	// Profile of the caller, like the userinfo endpoint of OpenID Connect.
+	userInfoAuth := middlewares.AuthMiddleware(container.AuthDeps)
+	engine.GET("/userinfo", userInfoAuth, userHandler.UserInfo)
+	engine.POST("/userinfo", userInfoAuth, userHandler.UserInfo)
 
 	return &App{
 		Engine: engine,
 		Addr:   cfg.HTTP.Address,
-	}, depsCleanup, nil
+	}, cleanup, nil
 }
 
 func healthHandler(c *gin.Context) {
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"gomonitor/internal/container"
+	"gomonitor/internal/domain/signingkey"
 	"gomonitor/internal/domain/user"
 	"gomonitor/internal/observability/logging"
 	"gomonitor/internal/pkg/identity"
@@ -18,6 +19,10 @@ func bootstrapApp(ctx context.Context, container *container.Container) error {
 			return err
 		}
 
+		if err := seedSigningKeys(ctx, tx, container); err != nil {
+			return err
+		}
+
 		return nil
 	})
 }
@@ -51,7 +56,9 @@ func createAdminUser(ctx context.Context, tx *gorm.DB, c *container.Container) e
 	principal := &identity.Principal{
 		UserID: 1,
 		Role:   identity.RoleAdmin,
-		Source: identity.AuthInternal,
+		// Permissions aren't resolved outside of requests, the bootstrap only creates the admin.
+		Permissions: []identity.Permission{identity.PermUsersCreate},
+		Source:      identity.AuthInternal,
 	}
 
 	ctxWithLogging := logging.WithContext(ctx, logger)
@@ -64,6 +71,8 @@ func createAdminUser(ctx context.Context, tx *gorm.DB, c *container.Container) e
 		Email:    cfg.Email,
 		Password: cfg.Password,
 		Role:     &role,
+		// The configured admin email is trusted.
+		EmailVerified: true,
 	}
 
 	newUser, err := userSvc.CreateUser(internalCtx, adminUser)
@@ -80,3 +89,21 @@ func createAdminUser(ctx context.Context, tx *gorm.DB, c *container.Container) e
 
 	return nil
 }
+
+// seedSigningKeys stores the configured token keys on first start and loads the stored keyring.
+func seedSigningKeys(ctx context.Context, tx *gorm.DB, c *container.Container) error {
+	signingKeySvc := signingkey.NewService(&signingkey.ServiceDeps{
+		AccessKeyring:  c.Deps.AccessKeyring,
+		Cipher:         c.Deps.SigningKeyCipher,
+		Logger:         c.Deps.Logger,
+		RefreshKeyring: c.Deps.RefreshKeyring,
+		Repo:           c.Repositories.SigningKey.WithTx(tx),
+	})
+
+	if err := signingKeySvc.Bootstrap(ctx); err != nil {
+		c.Deps.Logger.Error("error seeding signing keys", slog.Any("err", err))
+		return err
+	}
+
+	return nil
+}
@@ -1,34 +1,108 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 )
 
+// Supported access token signing methods.
+const (
+	SigningMethodHS256 = "HS256"
+	SigningMethodRS256 = "RS256"
+	SigningMethodES256 = "ES256"
+	SigningMethodEdDSA = "EdDSA"
+)
+
+var signingMethods = []string{SigningMethodHS256, SigningMethodRS256, SigningMethodES256, SigningMethodEdDSA}
+
+// Policies for checking revoked sessions while Redis is unavailable.
+const (
+	// RevocationFallbackClosed checks every request against Postgres.
+	RevocationFallbackClosed = "closed"
+	// RevocationFallbackOpen accepts tokens, revoked sessions stay usable until their access tokens expire.
+	RevocationFallbackOpen = "open"
+)
+
 // App auth configuration.
 type AuthConfig struct {
-	AccessTokenSecret  string
-	AccessTokenTTL     time.Duration
-	FakeHash           string
+	AccessTokenPrivateKeyFile string
+	AccessTokenSecret         string
+	AccessTokenSigningMethod  string
+	AccessTokenTTL            time.Duration
+	// DPoPProofTTL is how far the iat of DPoP proofs may be from now, in either direction.
+	DPoPProofTTL         time.Duration
+	EmailVerificationTTL time.Duration
+	EmailVerificationURL string
+	// FakeHash is not loaded from the env, it's derived from the current hasher at startup.
+	FakeHash string
+	// ImpersonationTTL is the lifetime of impersonation tokens, they can't be refreshed.
+	ImpersonationTTL    time.Duration
+	KeyringSyncInterval time.Duration
+	MagicLinkTTL        time.Duration
+	MagicLinkURL        string
+	MFAChallengeTTL     time.Duration
+	MFAEncryptionKey    []byte
+	MFAIssuer           string
+	PasswordResetTTL    time.Duration
+	PasswordResetURL    string
+	// RecentAuthMaxAge is how long after proving their credentials users may perform sensitive operations.
+	RecentAuthMaxAge   time.Duration
 	RefreshTokenSecret string
 	RefreshTokenTTL    time.Duration
+	// RequireVerifiedEmail refuses logins until the user confirms their email.
+	RequireVerifiedEmail bool
+	RevocationFallback   string
+	// SigningKeyEncryptionKey encrypts the stored signing keys, apart from the MFA secrets.
+	SigningKeyEncryptionKey []byte
+}
+
+// IsAsymmetric reports if access tokens are signed with a private key instead of the shared secret.
+func (c *AuthConfig) IsAsymmetric() bool {
+	return c.AccessTokenSigningMethod != "" && c.AccessTokenSigningMethod != SigningMethodHS256
 }
 
 func getAuthConfig() (*AuthConfig, error) {
 	var missing []string
+	signingMethod := getEnv("AUTH_ACCESS_TOKEN_SIGNING_METHOD", SigningMethodHS256)
+	privateKeyFile := getEnv("AUTH_ACCESS_TOKEN_PRIVATE_KEY_FILE", "")
 	accessToken := getEnv("AUTH_ACCESS_TOKEN_SECRET", "")
 	refreshToken := getEnv("AUTH_REFRESH_TOKEN_SECRET", "")
-	fakeHash := getEnv("AUTH_FAKE_HASH", "")
+	revocationFallback := getEnv("AUTH_REVOCATION_FALLBACK", RevocationFallbackClosed)
+	mfaEncryptionKey := getEnv("AUTH_MFA_ENCRYPTION_KEY", "")
+	mfaIssuer := getEnv("AUTH_MFA_ISSUER", "gomonitor")
+	signingKeyEncryptionKey := getEnv("AUTH_SIGNING_KEY_ENCRYPTION_KEY", "")
+	passwordResetURL := getEnv("AUTH_PASSWORD_RESET_URL", "http://localhost:8080/reset-password")
+	emailVerificationURL := getEnv("AUTH_EMAIL_VERIFICATION_URL", "http://localhost:8080/verify-email")
+	magicLinkURL := getEnv("AUTH_MAGIC_LINK_URL", "http://localhost:8080/magic-link")
+	requireVerifiedEmail := getBoolEnv("AUTH_REQUIRE_VERIFIED_EMAIL", false)
 
-	if accessToken == "" {
+	if !slices.Contains(signingMethods, signingMethod) {
+		return nil, fmt.Errorf("unsupported AUTH_ACCESS_TOKEN_SIGNING_METHOD: %s", signingMethod)
+	}
+
+	if revocationFallback != RevocationFallbackClosed && revocationFallback != RevocationFallbackOpen {
+		return nil, fmt.Errorf("unsupported AUTH_REVOCATION_FALLBACK: %s", revocationFallback)
+	}
+
+	// Asymmetric signing only needs the private key, the public one is derived from it.
+	if signingMethod == SigningMethodHS256 && accessToken == "" {
 		missing = append(missing, "AUTH_ACCESS_TOKEN_SECRET")
 	}
+	if signingMethod != SigningMethodHS256 && privateKeyFile == "" {
+		missing = append(missing, "AUTH_ACCESS_TOKEN_PRIVATE_KEY_FILE")
+	}
 	if refreshToken == "" {
 		missing = append(missing, "AUTH_REFRESH_TOKEN_SECRET")
 	}
-	if fakeHash == "" {
-		missing = append(missing, "AUTH_FAKE_HASH")
+	if mfaEncryptionKey == "" {
+		missing = append(missing, "AUTH_MFA_ENCRYPTION_KEY")
+	}
+	if signingKeyEncryptionKey == "" {
+		missing = append(missing, "AUTH_SIGNING_KEY_ENCRYPTION_KEY")
 	}
 
 	if len(missing) > 0 {
@@ -37,6 +111,14 @@ func getAuthConfig() (*AuthConfig, error) {
 
 	AccessTokenTTL := getEnv("AUTH_ACCESS_TOKEN_TTL", "1h")
 	refreshTokenTTL := getEnv("AUTH_REFRESH_TOKEN_TTL", "168h")
+	keyringSyncInterval := getEnv("AUTH_KEYRING_SYNC_INTERVAL", "30s")
+	impersonationTTL := getEnv("AUTH_IMPERSONATION_TTL", "15m")
+	mfaChallengeTTL := getEnv("AUTH_MFA_CHALLENGE_TTL", "5m")
+	passwordResetTTL := getEnv("AUTH_PASSWORD_RESET_TTL", "30m")
+	emailVerificationTTL := getEnv("AUTH_EMAIL_VERIFICATION_TTL", "24h")
+	dpopProofTTL := getEnv("AUTH_DPOP_PROOF_TTL", "1m")
+	magicLinkTTL := getEnv("AUTH_MAGIC_LINK_TTL", "15m")
+	recentAuthMaxAge := getEnv("AUTH_RECENT_AUTH_MAX_AGE", "10m")
 
 	accessTokenDuration, err := time.ParseDuration(AccessTokenTTL)
 	if err != nil {
@@ -48,11 +130,122 @@ func getAuthConfig() (*AuthConfig, error) {
 		return nil, fmt.Errorf("error parsing refreshTokenTTL: %v", err)
 	}
 
+	keyringSyncDuration, err := time.ParseDuration(keyringSyncInterval)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing keyringSyncInterval: %v", err)
+	}
+	if keyringSyncDuration <= 0 {
+		return nil, fmt.Errorf("AUTH_KEYRING_SYNC_INTERVAL must be positive: %s", keyringSyncInterval)
+	}
+
+	impersonationDuration, err := time.ParseDuration(impersonationTTL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing impersonationTTL: %v", err)
+	}
+	if impersonationDuration <= 0 {
+		return nil, fmt.Errorf("AUTH_IMPERSONATION_TTL must be positive: %s", impersonationTTL)
+	}
+
+	mfaChallengeDuration, err := time.ParseDuration(mfaChallengeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing mfaChallengeTTL: %v", err)
+	}
+	if mfaChallengeDuration <= 0 {
+		return nil, fmt.Errorf("AUTH_MFA_CHALLENGE_TTL must be positive: %s", mfaChallengeTTL)
+	}
+
+	passwordResetDuration, err := time.ParseDuration(passwordResetTTL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing passwordResetTTL: %v", err)
+	}
+	if passwordResetDuration <= 0 {
+		return nil, fmt.Errorf("AUTH_PASSWORD_RESET_TTL must be positive: %s", passwordResetTTL)
+	}
+
+	emailVerificationDuration, err := time.ParseDuration(emailVerificationTTL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing emailVerificationTTL: %v", err)
+	}
+	if emailVerificationDuration <= 0 {
+		return nil, fmt.Errorf("AUTH_EMAIL_VERIFICATION_TTL must be positive: %s", emailVerificationTTL)
+	}
+
+	magicLinkDuration, err := time.ParseDuration(magicLinkTTL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing magicLinkTTL: %v", err)
+	}
+	if magicLinkDuration <= 0 {
+		return nil, fmt.Errorf("AUTH_MAGIC_LINK_TTL must be positive: %s", magicLinkTTL)
+	}
+
+	dpopProofDuration, err := time.ParseDuration(dpopProofTTL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing dpopProofTTL: %v", err)
+	}
+	if dpopProofDuration <= 0 {
+		return nil, fmt.Errorf("AUTH_DPOP_PROOF_TTL must be positive: %s", dpopProofTTL)
+	}
+
+	recentAuthDuration, err := time.ParseDuration(recentAuthMaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing recentAuthMaxAge: %v", err)
+	}
+	if recentAuthDuration <= 0 {
+		return nil, fmt.Errorf("AUTH_RECENT_AUTH_MAX_AGE must be positive: %s", recentAuthMaxAge)
+	}
+
+	if !isAbsoluteURL(passwordResetURL) {
+		return nil, fmt.Errorf("invalid AUTH_PASSWORD_RESET_URL: %s", passwordResetURL)
+	}
+
+	if !isAbsoluteURL(emailVerificationURL) {
+		return nil, fmt.Errorf("invalid AUTH_EMAIL_VERIFICATION_URL: %s", emailVerificationURL)
+	}
+
+	if !isAbsoluteURL(magicLinkURL) {
+		return nil, fmt.Errorf("invalid AUTH_MAGIC_LINK_URL: %s", magicLinkURL)
+	}
+
+	// AES-256 key, the MFA secrets are stored encrypted with it.
+	mfaKey, err := base64.StdEncoding.DecodeString(mfaEncryptionKey)
+	if err != nil || len(mfaKey) != 32 {
+		return nil, fmt.Errorf("AUTH_MFA_ENCRYPTION_KEY must be 32 base64 encoded bytes")
+	}
+
+	// AES-256 key as well, the signing keys stored for rotation are encrypted with it.
+	signingKey, err := base64.StdEncoding.DecodeString(signingKeyEncryptionKey)
+	if err != nil || len(signingKey) != 32 {
+		return nil, fmt.Errorf("AUTH_SIGNING_KEY_ENCRYPTION_KEY must be 32 base64 encoded bytes")
+	}
+
 	return &AuthConfig{
-		AccessTokenSecret:  accessToken,
-		AccessTokenTTL:     accessTokenDuration,
-		FakeHash:           fakeHash,
-		RefreshTokenSecret: refreshToken,
-		RefreshTokenTTL:    refreshTokenDuration,
+		AccessTokenPrivateKeyFile: privateKeyFile,
+		AccessTokenSecret:         accessToken,
+		AccessTokenSigningMethod:  signingMethod,
+		AccessTokenTTL:            accessTokenDuration,
+		DPoPProofTTL:              dpopProofDuration,
+		EmailVerificationTTL:      emailVerificationDuration,
+		EmailVerificationURL:      emailVerificationURL,
+		ImpersonationTTL:          impersonationDuration,
+		KeyringSyncInterval:       keyringSyncDuration,
+		MagicLinkTTL:              magicLinkDuration,
+		MagicLinkURL:              magicLinkURL,
+		MFAChallengeTTL:           mfaChallengeDuration,
+		MFAEncryptionKey:          mfaKey,
+		MFAIssuer:                 mfaIssuer,
+		PasswordResetTTL:          passwordResetDuration,
+		PasswordResetURL:          passwordResetURL,
+		RecentAuthMaxAge:          recentAuthDuration,
+		RefreshTokenSecret:        refreshToken,
+		RefreshTokenTTL:           refreshTokenDuration,
+		RequireVerifiedEmail:      requireVerifiedEmail,
+		RevocationFallback:        revocationFallback,
+		SigningKeyEncryptionKey:   signingKey,
 	}, nil
 }
+
+// isAbsoluteURL validates the links sent by mail.
+func isAbsoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.IsAbs()
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Authentication event log configuration.
+type AuthEventsConfig struct {
+	// BufferSize is how many events wait to be written, more are dropped rather than slowing logins down.
+	BufferSize int
+	// BatchSize events are written at once, or whatever is buffered every FlushInterval.
+	BatchSize     int
+	FlushInterval time.Duration
+	// Retention is how long events are kept, older ones are pruned every PruneInterval.
+	Retention     time.Duration
+	PruneInterval time.Duration
+	// NewDeviceLookback is how far back the successful logins of a user are compared with a login to
+	// recognise their devices and networks. Logins from others are notified when NewDeviceNotify is set,
+	// or need step-up verification when NewDeviceStepUp is set.
+	NewDeviceLookback time.Duration
+	NewDeviceNotify   bool
+	NewDeviceStepUp   bool
+}
+
+func getAuthEventsConfig() (*AuthEventsConfig, error) {
+	batchSize := getIntEnv("AUTH_EVENTS_BATCH_SIZE", 100)
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("invalid AUTH_EVENTS_BATCH_SIZE: %d", batchSize)
+	}
+
+	bufferSize := getIntEnv("AUTH_EVENTS_BUFFER_SIZE", 10000)
+	if bufferSize < batchSize {
+		return nil, fmt.Errorf("AUTH_EVENTS_BUFFER_SIZE must be at least AUTH_EVENTS_BATCH_SIZE")
+	}
+
+	flushInterval, err := time.ParseDuration(getEnv("AUTH_EVENTS_FLUSH_INTERVAL", "1s"))
+	if err != nil || flushInterval <= 0 {
+		return nil, fmt.Errorf("error parsing AUTH_EVENTS_FLUSH_INTERVAL: %v", err)
+	}
+
+	retention, err := time.ParseDuration(getEnv("AUTH_EVENTS_RETENTION", "2160h"))
+	if err != nil || retention <= 0 {
+		return nil, fmt.Errorf("error parsing AUTH_EVENTS_RETENTION: %v", err)
+	}
+
+	pruneInterval, err := time.ParseDuration(getEnv("AUTH_EVENTS_PRUNE_INTERVAL", "1h"))
+	if err != nil || pruneInterval <= 0 {
+		return nil, fmt.Errorf("error parsing AUTH_EVENTS_PRUNE_INTERVAL: %v", err)
+	}
+
+	// Older logins are pruned, the devices would never be recognised.
+	newDeviceLookback, err := time.ParseDuration(getEnv("AUTH_NEW_DEVICE_LOOKBACK", "720h"))
+	if err != nil || newDeviceLookback <= 0 || newDeviceLookback > retention {
+		return nil, fmt.Errorf("AUTH_NEW_DEVICE_LOOKBACK must be a positive duration up to AUTH_EVENTS_RETENTION: %v", err)
+	}
+
+	return &AuthEventsConfig{
+		BufferSize:        bufferSize,
+		BatchSize:         batchSize,
+		FlushInterval:     flushInterval,
+		Retention:         retention,
+		PruneInterval:     pruneInterval,
+		NewDeviceLookback: newDeviceLookback,
+		NewDeviceNotify:   getBoolEnv("AUTH_NEW_DEVICE_NOTIFY", true),
+		NewDeviceStepUp:   getBoolEnv("AUTH_NEW_DEVICE_STEP_UP", false),
+	}, nil
+}
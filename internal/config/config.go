@@ -13,14 +13,20 @@ import (
 type Config struct {
 	Admin          *AdminConfig
 	Auth           *AuthConfig
+	AuthEvents     *AuthEventsConfig
 	CircuitBreaker *CircuitBreakerConfig
 	Database       *DatabaseConfig
 	HTTP           *HTTPConfig
 	Logging        *LoggingConfig
+	Mail           *MailConfig
+	OIDC           *OIDCConfig
+	Password       *PasswordConfig
 	ProjectRoot    string
 	RateLimit      *RateLimitConfig
 	Redis          *RedisConfig
+	Session        *SessionConfig
 	Tracing        *TracingConfig
+	WebAuthn       *WebAuthnConfig
 }
 
 // Load get all necessary configuration values.
@@ -39,21 +45,57 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	authEventsConfig, err := getAuthEventsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	mailConfig, err := getMailConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	oidcConfig, err := getOIDCConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	passwordConfig, err := getPasswordConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	ratelimitConfig, err := getRateLimitConfig()
 	if err != nil {
 		return nil, err
 	}
 
+	sessionConfig, err := getSessionConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	webAuthnConfig, err := getWebAuthnConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		Admin:          adminConfig,
 		Auth:           authConfig,
+		AuthEvents:     authEventsConfig,
 		CircuitBreaker: getCircuitBreakerConfig(),
 		Database:       getDatabaseConfig(),
 		HTTP:           getHTTPConfig(),
 		Logging:        getLoggingConfig(),
+		Mail:           mailConfig,
+		OIDC:           oidcConfig,
+		Password:       passwordConfig,
 		RateLimit:      ratelimitConfig,
 		Redis:          getRedisConfig(),
+		Session:        sessionConfig,
 		Tracing:        getTracingConfig(),
+		WebAuthn:       webAuthnConfig,
 	}, nil
 }
 
@@ -81,6 +123,21 @@ func getIntEnv(env string, defaultVal int) int {
 	return i
 }
 
+// getBoolEnv returns a boolean from the env variables, if invalid returns a default value.
+func getBoolEnv(env string, defaultVal bool) bool {
+	val := os.Getenv(env)
+	if val == "" {
+		return defaultVal
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		log.Printf("Using default %t for env: %s", defaultVal, env)
+		return defaultVal
+	}
+	return b
+}
+
 // loadEnv loads the enviromental values if running outside docker.
 func loadEnv() error {
 	appEnv := getEnv("ENVIRONMENT", "development")
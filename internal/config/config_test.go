@@ -17,7 +17,8 @@ func TestLoad_Success(t *testing.T) {
 	// Required Auth config
 	t.Setenv("AUTH_ACCESS_TOKEN_SECRET", "access")
 	t.Setenv("AUTH_REFRESH_TOKEN_SECRET", "refresh")
-	t.Setenv("AUTH_FAKE_HASH", "fakehash")
+	t.Setenv("AUTH_MFA_ENCRYPTION_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	t.Setenv("AUTH_SIGNING_KEY_ENCRYPTION_KEY", "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=")
 	t.Setenv("AUTH_ACCESS_TOKEN_TTL", "1h")
 	t.Setenv("AUTH_REFRESH_TOKEN_TTL", "168h")
 
@@ -32,6 +33,7 @@ func TestLoad_Success(t *testing.T) {
 	assert.NotNil(t, cfg.Database)
 	assert.NotNil(t, cfg.HTTP)
 	assert.NotNil(t, cfg.Logging)
+	assert.NotNil(t, cfg.Password)
 	assert.NotNil(t, cfg.Redis)
 	assert.NotNil(t, cfg.Tracing)
 }
@@ -46,7 +48,8 @@ func TestLoad_Error(t *testing.T) {
 
 	t.Setenv("AUTH_ACCESS_TOKEN_SECRET", "access")
 	t.Setenv("AUTH_REFRESH_TOKEN_SECRET", "refresh")
-	t.Setenv("AUTH_FAKE_HASH", "fakehash")
+	t.Setenv("AUTH_MFA_ENCRYPTION_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	t.Setenv("AUTH_SIGNING_KEY_ENCRYPTION_KEY", "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=")
 	t.Setenv("AUTH_ACCESS_TOKEN_TTL", "1h")
 	t.Setenv("AUTH_REFRESH_TOKEN_TTL", "168h")
 
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Supported mail drivers.
+const (
+	MailDriverSMTP = "smtp"
+	// MailDriverFile appends the messages to MAIL_FILE_PATH, for local development and tests.
+	MailDriverFile = "file"
+	// MailDriverLog writes the messages to the application log.
+	MailDriverLog = "log"
+)
+
+var mailDrivers = []string{MailDriverSMTP, MailDriverFile, MailDriverLog}
+
+// Mail configuration.
+type MailConfig struct {
+	Driver       string
+	From         string
+	FilePath     string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// getMailConfig loads the mail environments, SMTP is only validated when selected.
+func getMailConfig() (*MailConfig, error) {
+	cfg := &MailConfig{
+		Driver:       getEnv("MAIL_DRIVER", MailDriverLog),
+		From:         getEnv("MAIL_FROM", "no-reply@gomonitor.local"),
+		FilePath:     getEnv("MAIL_FILE_PATH", "mail.log"),
+		SMTPHost:     getEnv("MAIL_SMTP_HOST", ""),
+		SMTPPort:     getIntEnv("MAIL_SMTP_PORT", 587),
+		SMTPUsername: getEnv("MAIL_SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("MAIL_SMTP_PASSWORD", ""),
+	}
+
+	if !slices.Contains(mailDrivers, cfg.Driver) {
+		return nil, fmt.Errorf("unsupported MAIL_DRIVER: %s", cfg.Driver)
+	}
+
+	if cfg.Driver == MailDriverSMTP && cfg.SMTPHost == "" {
+		return nil, fmt.Errorf("missing mail config: MAIL_SMTP_HOST")
+	}
+
+	return cfg, nil
+}
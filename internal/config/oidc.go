@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"gomonitor/internal/pkg/identity"
+	"slices"
+	"strings"
+	"time"
+)
+
+// OpenID Connect login configuration, disabled without an issuer.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered at the provider, it must reach the callback route.
+	RedirectURL string
+	Scopes      []string
+	// FlowTTL bounds the time between the redirect to the provider and the callback.
+	FlowTTL time.Duration
+	// JITProvisioning creates unknown users on their first login, with DefaultRole.
+	JITProvisioning bool
+	DefaultRole     identity.UserRole
+}
+
+// Enabled reports if OpenID Connect login is configured.
+func (c *OIDCConfig) Enabled() bool {
+	return c != nil && c.IssuerURL != ""
+}
+
+func getOIDCConfig() (*OIDCConfig, error) {
+	flowTTL, err := time.ParseDuration(getEnv("OIDC_FLOW_TTL", "10m"))
+	if err != nil || flowTTL <= 0 {
+		return nil, fmt.Errorf("error parsing OIDC_FLOW_TTL: %v", err)
+	}
+
+	cfg := &OIDCConfig{
+		IssuerURL:       strings.TrimSuffix(getEnv("OIDC_ISSUER_URL", ""), "/"),
+		ClientID:        getEnv("OIDC_CLIENT_ID", ""),
+		ClientSecret:    getEnv("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:     getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/v1/auth/oidc/callback"),
+		Scopes:          strings.Fields(getEnv("OIDC_SCOPES", "openid email profile")),
+		FlowTTL:         flowTTL,
+		JITProvisioning: getBoolEnv("OIDC_JIT_PROVISIONING", false),
+		DefaultRole:     identity.UserRole(getEnv("OIDC_DEFAULT_ROLE", string(identity.RoleUser))),
+	}
+
+	if !cfg.Enabled() {
+		return cfg, nil
+	}
+
+	if !isAbsoluteURL(cfg.IssuerURL) {
+		return nil, fmt.Errorf("invalid OIDC_ISSUER_URL: %s", cfg.IssuerURL)
+	}
+
+	if !isAbsoluteURL(cfg.RedirectURL) {
+		return nil, fmt.Errorf("invalid OIDC_REDIRECT_URL: %s", cfg.RedirectURL)
+	}
+
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("missing oidc config: OIDC_CLIENT_ID")
+	}
+
+	if !slices.Contains(cfg.Scopes, "openid") {
+		return nil, fmt.Errorf("OIDC_SCOPES must include openid")
+	}
+
+	return cfg, nil
+}
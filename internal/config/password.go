@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// Supported password hashing algorithms.
+const (
+	PasswordHasherArgon2id = "argon2id"
+	PasswordHasherBcrypt   = "bcrypt"
+)
+
+// Password hashing and policy configuration, hashes made with other settings are upgraded on login.
+type PasswordConfig struct {
+	Hasher     string
+	BcryptCost int
+	// Argon2Memory is in KiB.
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+
+	// Policy for new passwords. MaxLength counts bytes, MinEntropy bits.
+	MinLength  int
+	MaxLength  int
+	MinClasses int
+	MinEntropy int
+	// BreachedListFile holds one known breached password per line, none are checked without it.
+	BreachedListFile string
+	// HistorySize is how many previous passwords can't be reused, 0 disables the history.
+	HistorySize int
+}
+
+func getPasswordConfig() (*PasswordConfig, error) {
+	hasher := getEnv("PASSWORD_HASHER", PasswordHasherArgon2id)
+	bcryptCost := getIntEnv("PASSWORD_BCRYPT_COST", 10)
+	memory := getIntEnv("PASSWORD_ARGON2_MEMORY", 64*1024)
+	iterations := getIntEnv("PASSWORD_ARGON2_ITERATIONS", 3)
+	parallelism := getIntEnv("PASSWORD_ARGON2_PARALLELISM", 4)
+	minLength := getIntEnv("PASSWORD_MIN_LENGTH", 8)
+	maxLength := getIntEnv("PASSWORD_MAX_LENGTH", 72)
+	minClasses := getIntEnv("PASSWORD_MIN_CLASSES", 2)
+	minEntropy := getIntEnv("PASSWORD_MIN_ENTROPY", 40)
+	historySize := getIntEnv("PASSWORD_HISTORY_SIZE", 5)
+
+	if hasher != PasswordHasherArgon2id && hasher != PasswordHasherBcrypt {
+		return nil, fmt.Errorf("unsupported PASSWORD_HASHER: %s", hasher)
+	}
+
+	// Same bounds as bcrypt.MinCost and bcrypt.MaxCost.
+	if bcryptCost < 4 || bcryptCost > 31 {
+		return nil, fmt.Errorf("PASSWORD_BCRYPT_COST must be between 4 and 31")
+	}
+
+	if parallelism < 1 || parallelism > math.MaxUint8 {
+		return nil, fmt.Errorf("PASSWORD_ARGON2_PARALLELISM must be between 1 and 255")
+	}
+
+	if iterations < 1 || iterations > math.MaxUint32 {
+		return nil, fmt.Errorf("PASSWORD_ARGON2_ITERATIONS must be positive")
+	}
+
+	// Argon2 needs at least 8 KiB per lane.
+	if memory < 8*parallelism || memory > math.MaxUint32 {
+		return nil, fmt.Errorf("PASSWORD_ARGON2_MEMORY must be at least 8 KiB per lane")
+	}
+
+	if minLength < 1 || maxLength < minLength {
+		return nil, fmt.Errorf("PASSWORD_MIN_LENGTH must be positive and not above PASSWORD_MAX_LENGTH")
+	}
+
+	// Bcrypt refuses longer passwords.
+	if hasher == PasswordHasherBcrypt && maxLength > 72 {
+		return nil, fmt.Errorf("PASSWORD_MAX_LENGTH can't be above 72 with bcrypt")
+	}
+
+	if minClasses < 0 || minClasses > 4 {
+		return nil, fmt.Errorf("PASSWORD_MIN_CLASSES must be between 0 and 4")
+	}
+
+	if minEntropy < 0 {
+		return nil, fmt.Errorf("PASSWORD_MIN_ENTROPY must not be negative")
+	}
+
+	if historySize < 0 {
+		return nil, fmt.Errorf("PASSWORD_HISTORY_SIZE must not be negative")
+	}
+
+	return &PasswordConfig{
+		Hasher:            hasher,
+		BcryptCost:        bcryptCost,
+		Argon2Memory:      uint32(memory),
+		Argon2Iterations:  uint32(iterations),
+		Argon2Parallelism: uint8(parallelism),
+		MinLength:         minLength,
+		MaxLength:         maxLength,
+		MinClasses:        minClasses,
+		MinEntropy:        minEntropy,
+		BreachedListFile:  getEnv("PASSWORD_BREACHED_LIST_FILE", ""),
+		HistorySize:       historySize,
+	}, nil
+}
@@ -11,6 +11,13 @@ type RateLimitConfig struct {
 	IPWindow   time.Duration
 	UserLimit  int
 	UserWindow time.Duration
+
+	// Failed logins allowed per email within LoginWindow before it gets locked.
+	LoginAttempts int
+	LoginWindow   time.Duration
+	// First lock, doubled on every further failure up to LoginMaxLockout.
+	LoginLockout    time.Duration
+	LoginMaxLockout time.Duration
 }
 
 func getRateLimitConfig() (*RateLimitConfig, error) {
@@ -27,13 +34,41 @@ func getRateLimitConfig() (*RateLimitConfig, error) {
 		return nil, fmt.Errorf("error parsing UserWindow: %v", err)
 	}
 
+	loginWindowDuration, err := time.ParseDuration(getEnv("RATE_LIMIT_LOGIN_WINDOW", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LoginWindow: %v", err)
+	}
+
+	loginLockoutDuration, err := time.ParseDuration(getEnv("RATE_LIMIT_LOGIN_LOCKOUT", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LoginLockout: %v", err)
+	}
+
+	loginMaxLockoutDuration, err := time.ParseDuration(getEnv("RATE_LIMIT_LOGIN_MAX_LOCKOUT", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LoginMaxLockout: %v", err)
+	}
+
+	if loginMaxLockoutDuration < loginLockoutDuration {
+		return nil, fmt.Errorf("RATE_LIMIT_LOGIN_MAX_LOCKOUT must not be lower than RATE_LIMIT_LOGIN_LOCKOUT")
+	}
+
 	ipLimit := getIntEnv("RATE_LIMIT_IP_LIMIT", 20)
 	userLimit := getIntEnv("RATE_LIMIT_USER_LIMIT", 10)
+	loginAttempts := getIntEnv("RATE_LIMIT_LOGIN_ATTEMPTS", 5)
+	if loginAttempts <= 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_LOGIN_ATTEMPTS must be positive")
+	}
 
 	return &RateLimitConfig{
 		IPLimit:    ipLimit,
 		IPWindow:   ipWindowDuration,
 		UserLimit:  userLimit,
 		UserWindow: userWindowDuration,
+
+		LoginAttempts:   loginAttempts,
+		LoginWindow:     loginWindowDuration,
+		LoginLockout:    loginLockoutDuration,
+		LoginMaxLockout: loginMaxLockoutDuration,
 	}, nil
 }
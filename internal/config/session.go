@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Places the access token is read from.
+const (
+	CredentialSourceHeader = "header"
+	CredentialSourceCookie = "cookie"
+	// CredentialSourceQuery reads the ?token= parameter, it ends up in logs and referrers.
+	CredentialSourceQuery = "query"
+)
+
+var credentialSources = []string{CredentialSourceHeader, CredentialSourceCookie, CredentialSourceQuery}
+
+// Browser session configuration.
+type SessionConfig struct {
+	// CookieMode hands the tokens out as HttpOnly cookies instead of in the response body.
+	CookieMode     bool
+	CookieDomain   string
+	CookieSameSite http.SameSite
+	// CredentialSources are where AuthMiddleware looks for the access token, in order.
+	CredentialSources []string
+}
+
+func getSessionConfig() (*SessionConfig, error) {
+	cookieMode := getBoolEnv("SESSION_COOKIE_MODE", false)
+
+	defaultSources := CredentialSourceHeader + "," + CredentialSourceQuery
+	if cookieMode {
+		defaultSources = CredentialSourceHeader + "," + CredentialSourceCookie
+	}
+
+	var sources []string
+	for source := range strings.SplitSeq(getEnv("SESSION_CREDENTIAL_SOURCES", defaultSources), ",") {
+		source = strings.TrimSpace(source)
+		if !slices.Contains(credentialSources, source) {
+			return nil, fmt.Errorf("unsupported SESSION_CREDENTIAL_SOURCES: %s", source)
+		}
+		sources = append(sources, source)
+	}
+
+	if cookieMode && !slices.Contains(sources, CredentialSourceCookie) {
+		return nil, fmt.Errorf("SESSION_CREDENTIAL_SOURCES must include cookie with SESSION_COOKIE_MODE")
+	}
+
+	var sameSite http.SameSite
+	switch s := getEnv("SESSION_COOKIE_SAME_SITE", "strict"); s {
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "lax":
+		sameSite = http.SameSiteLaxMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("unsupported SESSION_COOKIE_SAME_SITE: %s", s)
+	}
+
+	return &SessionConfig{
+		CookieMode:        cookieMode,
+		CookieDomain:      getEnv("SESSION_COOKIE_DOMAIN", ""),
+		CookieSameSite:    sameSite,
+		CredentialSources: sources,
+	}, nil
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"gomonitor/internal/pkg/identity"
 	"maps"
+	"net/http"
 	"path/filepath"
 	"testing"
 	"time"
@@ -12,17 +14,19 @@ import (
 
 func TestGetAuthConfig(t *testing.T) {
 	baseEnv := map[string]string{
-		"AUTH_ACCESS_TOKEN_SECRET":  "access",
-		"AUTH_REFRESH_TOKEN_SECRET": "refresh",
-		"AUTH_FAKE_HASH":            "fakehash",
-		"AUTH_ACCESS_TOKEN_TTL":     "1h",
-		"AUTH_REFRESH_TOKEN_TTL":    "168h",
+		"AUTH_ACCESS_TOKEN_SECRET":        "access",
+		"AUTH_REFRESH_TOKEN_SECRET":       "refresh",
+		"AUTH_ACCESS_TOKEN_TTL":           "1h",
+		"AUTH_REFRESH_TOKEN_TTL":          "168h",
+		"AUTH_MFA_ENCRYPTION_KEY":         "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+		"AUTH_SIGNING_KEY_ENCRYPTION_KEY": "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=",
 	}
 
 	tests := []struct {
 		name    string
 		env     map[string]string
 		wantErr bool
+		errMsg  string
 	}{
 		{
 			name: "valid config",
@@ -47,10 +51,151 @@ func TestGetAuthConfig(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "missing fake hash",
+			name: "missing mfa encryption key",
 			env: func() map[string]string {
 				m := maps.Clone(baseEnv)
-				delete(m, "AUTH_FAKE_HASH")
+				delete(m, "AUTH_MFA_ENCRYPTION_KEY")
+				return m
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "mfa encryption key too short",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_MFA_ENCRYPTION_KEY"] = "c2hvcnQ="
+				return m
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "missing signing key encryption key",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				delete(m, "AUTH_SIGNING_KEY_ENCRYPTION_KEY")
+				return m
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "signing key encryption key too short",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_SIGNING_KEY_ENCRYPTION_KEY"] = "c2hvcnQ="
+				return m
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid mfa challenge ttl",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_MFA_CHALLENGE_TTL"] = "-1m"
+				return m
+			}(),
+			wantErr: true,
+			errMsg:  "AUTH_MFA_CHALLENGE_TTL must be positive: -1m",
+		},
+		{
+			name: "invalid impersonation ttl",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_IMPERSONATION_TTL"] = "0s"
+				return m
+			}(),
+			wantErr: true,
+			errMsg:  "AUTH_IMPERSONATION_TTL must be positive: 0s",
+		},
+		{
+			name: "invalid password reset ttl",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_PASSWORD_RESET_TTL"] = "0s"
+				return m
+			}(),
+			wantErr: true,
+			errMsg:  "AUTH_PASSWORD_RESET_TTL must be positive: 0s",
+		},
+		{
+			name: "require verified email",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_REQUIRE_VERIFIED_EMAIL"] = "true"
+				return m
+			}(),
+		},
+		{
+			name: "invalid email verification ttl",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_EMAIL_VERIFICATION_TTL"] = "tomorrow"
+				return m
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "non-positive email verification ttl",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_EMAIL_VERIFICATION_TTL"] = "-24h"
+				return m
+			}(),
+			wantErr: true,
+			errMsg:  "AUTH_EMAIL_VERIFICATION_TTL must be positive: -24h",
+		},
+		{
+			name: "relative email verification url",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_EMAIL_VERIFICATION_URL"] = "verify-email"
+				return m
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "relative password reset url",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_PASSWORD_RESET_URL"] = "/reset-password"
+				return m
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "invalid dpop proof ttl",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_DPOP_PROOF_TTL"] = "0s"
+				return m
+			}(),
+			wantErr: true,
+			errMsg:  "AUTH_DPOP_PROOF_TTL must be positive: 0s",
+		},
+		{
+			name: "invalid magic link ttl",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_MAGIC_LINK_TTL"] = "-5m"
+				return m
+			}(),
+			wantErr: true,
+			errMsg:  "AUTH_MAGIC_LINK_TTL must be positive: -5m",
+		},
+		{
+			name: "invalid recent auth max age",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_RECENT_AUTH_MAX_AGE"] = "0s"
+				return m
+			}(),
+			wantErr: true,
+			errMsg:  "AUTH_RECENT_AUTH_MAX_AGE must be positive: 0s",
+		},
+		{
+			name: "relative magic link url",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_MAGIC_LINK_URL"] = "magic-link"
 				return m
 			}(),
 			wantErr: true,
@@ -73,6 +218,53 @@ func TestGetAuthConfig(t *testing.T) {
 			}(),
 			wantErr: true,
 		},
+		{
+			name: "invalid keyring sync interval",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_KEYRING_SYNC_INTERVAL"] = "0s"
+				return m
+			}(),
+			wantErr: true,
+			errMsg:  "AUTH_KEYRING_SYNC_INTERVAL must be positive: 0s",
+		},
+		{
+			name: "unsupported revocation fallback",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_REVOCATION_FALLBACK"] = "maybe"
+				return m
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "unsupported signing method",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_ACCESS_TOKEN_SIGNING_METHOD"] = "none"
+				return m
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "asymmetric signing without private key",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				m["AUTH_ACCESS_TOKEN_SIGNING_METHOD"] = SigningMethodRS256
+				return m
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "asymmetric signing without access secret",
+			env: func() map[string]string {
+				m := maps.Clone(baseEnv)
+				delete(m, "AUTH_ACCESS_TOKEN_SECRET")
+				m["AUTH_ACCESS_TOKEN_SIGNING_METHOD"] = SigningMethodEdDSA
+				m["AUTH_ACCESS_TOKEN_PRIVATE_KEY_FILE"] = "/keys/access.pem"
+				return m
+			}(),
+		},
 	}
 
 	for _, tt := range tests {
@@ -86,10 +278,108 @@ func TestGetAuthConfig(t *testing.T) {
 			if tt.wantErr {
 				require.Error(t, err)
 				require.Nil(t, cfg)
+				if tt.errMsg != "" {
+					assert.EqualError(t, err, tt.errMsg)
+				}
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, cfg)
 				assert.Equal(t, time.Hour, cfg.AccessTokenTTL)
+				assert.Equal(t, 15*time.Minute, cfg.ImpersonationTTL)
+				assert.Equal(t, tt.env["AUTH_REQUIRE_VERIFIED_EMAIL"] == "true", cfg.RequireVerifiedEmail)
+			}
+		})
+	}
+}
+
+func TestGetAuthEventsConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *AuthEventsConfig
+		wantErr  bool
+	}{
+		{
+			name: "defaults",
+			env:  map[string]string{},
+			expected: &AuthEventsConfig{
+				BufferSize:        10000,
+				BatchSize:         100,
+				FlushInterval:     time.Second,
+				Retention:         90 * 24 * time.Hour,
+				PruneInterval:     time.Hour,
+				NewDeviceLookback: 30 * 24 * time.Hour,
+				NewDeviceNotify:   true,
+			},
+		},
+		{
+			name: "custom",
+			env: map[string]string{
+				"AUTH_EVENTS_BUFFER_SIZE":    "500",
+				"AUTH_EVENTS_BATCH_SIZE":     "50",
+				"AUTH_EVENTS_FLUSH_INTERVAL": "5s",
+				"AUTH_EVENTS_RETENTION":      "720h",
+				"AUTH_EVENTS_PRUNE_INTERVAL": "6h",
+				"AUTH_NEW_DEVICE_LOOKBACK":   "168h",
+				"AUTH_NEW_DEVICE_NOTIFY":     "false",
+				"AUTH_NEW_DEVICE_STEP_UP":    "true",
+			},
+			expected: &AuthEventsConfig{
+				BufferSize:        500,
+				BatchSize:         50,
+				FlushInterval:     5 * time.Second,
+				Retention:         30 * 24 * time.Hour,
+				PruneInterval:     6 * time.Hour,
+				NewDeviceLookback: 7 * 24 * time.Hour,
+				NewDeviceStepUp:   true,
+			},
+		},
+		{
+			name:    "invalid batch size",
+			env:     map[string]string{"AUTH_EVENTS_BATCH_SIZE": "0"},
+			wantErr: true,
+		},
+		{
+			name:    "buffer smaller than a batch",
+			env:     map[string]string{"AUTH_EVENTS_BUFFER_SIZE": "10", "AUTH_EVENTS_BATCH_SIZE": "20"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid flush interval",
+			env:     map[string]string{"AUTH_EVENTS_FLUSH_INTERVAL": "0s"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid retention",
+			env:     map[string]string{"AUTH_EVENTS_RETENTION": "forever"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid prune interval",
+			env:     map[string]string{"AUTH_EVENTS_PRUNE_INTERVAL": "-1h"},
+			wantErr: true,
+		},
+		{
+			name:    "lookback beyond the retention",
+			env:     map[string]string{"AUTH_EVENTS_RETENTION": "24h", "AUTH_NEW_DEVICE_LOOKBACK": "48h"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := getAuthEventsConfig()
+
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, cfg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, cfg)
 			}
 		})
 	}
@@ -360,6 +650,222 @@ func TestGetLoggingConfig(t *testing.T) {
 	}
 }
 
+func TestGetMailConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *MailConfig
+		wantErr  bool
+	}{
+		{
+			name: "defaults to the log driver",
+			env:  map[string]string{},
+			expected: &MailConfig{
+				Driver:   MailDriverLog,
+				From:     "no-reply@gomonitor.local",
+				FilePath: "mail.log",
+				SMTPPort: 587,
+			},
+		},
+		{
+			name: "smtp",
+			env: map[string]string{
+				"MAIL_DRIVER":        MailDriverSMTP,
+				"MAIL_FROM":          "auth@example.com",
+				"MAIL_SMTP_HOST":     "smtp.example.com",
+				"MAIL_SMTP_PORT":     "2525",
+				"MAIL_SMTP_USERNAME": "user",
+				"MAIL_SMTP_PASSWORD": "pass",
+			},
+			expected: &MailConfig{
+				Driver:       MailDriverSMTP,
+				From:         "auth@example.com",
+				FilePath:     "mail.log",
+				SMTPHost:     "smtp.example.com",
+				SMTPPort:     2525,
+				SMTPUsername: "user",
+				SMTPPassword: "pass",
+			},
+		},
+		{
+			name:    "smtp without host",
+			env:     map[string]string{"MAIL_DRIVER": MailDriverSMTP},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported driver",
+			env:     map[string]string{"MAIL_DRIVER": "pigeon"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := getMailConfig()
+
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, cfg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, cfg)
+			}
+		})
+	}
+}
+
+func TestGetPasswordConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *PasswordConfig
+		wantErr  bool
+	}{
+		{
+			name: "defaults to argon2id",
+			env:  map[string]string{},
+			expected: &PasswordConfig{
+				Hasher:            PasswordHasherArgon2id,
+				BcryptCost:        10,
+				Argon2Memory:      64 * 1024,
+				Argon2Iterations:  3,
+				Argon2Parallelism: 4,
+				MinLength:         8,
+				MaxLength:         72,
+				MinClasses:        2,
+				MinEntropy:        40,
+				HistorySize:       5,
+			},
+		},
+		{
+			name: "bcrypt",
+			env: map[string]string{
+				"PASSWORD_HASHER":      PasswordHasherBcrypt,
+				"PASSWORD_BCRYPT_COST": "12",
+			},
+			expected: &PasswordConfig{
+				Hasher:            PasswordHasherBcrypt,
+				BcryptCost:        12,
+				Argon2Memory:      64 * 1024,
+				Argon2Iterations:  3,
+				Argon2Parallelism: 4,
+				MinLength:         8,
+				MaxLength:         72,
+				MinClasses:        2,
+				MinEntropy:        40,
+				HistorySize:       5,
+			},
+		},
+		{
+			name:    "unsupported hasher",
+			env:     map[string]string{"PASSWORD_HASHER": "md5"},
+			wantErr: true,
+		},
+		{
+			name:    "bcrypt cost too high",
+			env:     map[string]string{"PASSWORD_BCRYPT_COST": "32"},
+			wantErr: true,
+		},
+		{
+			name:    "argon2 parallelism too high",
+			env:     map[string]string{"PASSWORD_ARGON2_PARALLELISM": "256"},
+			wantErr: true,
+		},
+		{
+			name:    "argon2 iterations not positive",
+			env:     map[string]string{"PASSWORD_ARGON2_ITERATIONS": "-1"},
+			wantErr: true,
+		},
+		{
+			name: "policy",
+			env: map[string]string{
+				"PASSWORD_MIN_LENGTH":         "12",
+				"PASSWORD_MAX_LENGTH":         "128",
+				"PASSWORD_MIN_CLASSES":        "0",
+				"PASSWORD_MIN_ENTROPY":        "60",
+				"PASSWORD_BREACHED_LIST_FILE": "breached.txt",
+				"PASSWORD_HISTORY_SIZE":       "0",
+			},
+			expected: &PasswordConfig{
+				Hasher:            PasswordHasherArgon2id,
+				BcryptCost:        10,
+				Argon2Memory:      64 * 1024,
+				Argon2Iterations:  3,
+				Argon2Parallelism: 4,
+				MinLength:         12,
+				MaxLength:         128,
+				MinEntropy:        60,
+				BreachedListFile:  "breached.txt",
+			},
+		},
+		{
+			name: "bcrypt with a max length above 72",
+			env: map[string]string{
+				"PASSWORD_HASHER":     PasswordHasherBcrypt,
+				"PASSWORD_MAX_LENGTH": "73",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "min length above max length",
+			env:     map[string]string{"PASSWORD_MIN_LENGTH": "80"},
+			wantErr: true,
+		},
+		{
+			name:    "too many classes",
+			env:     map[string]string{"PASSWORD_MIN_CLASSES": "5"},
+			wantErr: true,
+		},
+		{
+			name:    "negative history",
+			env:     map[string]string{"PASSWORD_HISTORY_SIZE": "-1"},
+			wantErr: true,
+		},
+		{
+			name: "argon2 memory below 8 KiB per lane",
+			env: map[string]string{
+				"PASSWORD_ARGON2_MEMORY":      "16",
+				"PASSWORD_ARGON2_PARALLELISM": "4",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{
+				"PASSWORD_HASHER",
+				"PASSWORD_BCRYPT_COST",
+				"PASSWORD_ARGON2_MEMORY",
+				"PASSWORD_ARGON2_ITERATIONS",
+				"PASSWORD_ARGON2_PARALLELISM",
+				"PASSWORD_MIN_LENGTH",
+				"PASSWORD_MAX_LENGTH",
+				"PASSWORD_MIN_CLASSES",
+				"PASSWORD_MIN_ENTROPY",
+				"PASSWORD_BREACHED_LIST_FILE",
+				"PASSWORD_HISTORY_SIZE",
+			} {
+				t.Setenv(k, tt.env[k])
+			}
+
+			cfg, err := getPasswordConfig()
+
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, cfg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, cfg)
+			}
+		})
+	}
+}
+
 func TestGetRedisConfig(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -477,3 +983,255 @@ func TestGetTracingConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestGetOIDCConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *OIDCConfig
+		wantErr  bool
+	}{
+		{
+			name: "disabled by default",
+			env:  map[string]string{},
+			expected: &OIDCConfig{
+				RedirectURL: "http://localhost:8080/api/v1/auth/oidc/callback",
+				Scopes:      []string{"openid", "email", "profile"},
+				FlowTTL:     10 * time.Minute,
+				DefaultRole: identity.RoleUser,
+			},
+		},
+		{
+			name: "enabled",
+			env: map[string]string{
+				"OIDC_ISSUER_URL":       "https://idp.example.com/",
+				"OIDC_CLIENT_ID":        "client",
+				"OIDC_CLIENT_SECRET":    "secret",
+				"OIDC_REDIRECT_URL":     "https://app.example.com/api/v1/auth/oidc/callback",
+				"OIDC_SCOPES":           "openid email",
+				"OIDC_FLOW_TTL":         "5m",
+				"OIDC_JIT_PROVISIONING": "true",
+				"OIDC_DEFAULT_ROLE":     "support",
+			},
+			expected: &OIDCConfig{
+				IssuerURL:       "https://idp.example.com",
+				ClientID:        "client",
+				ClientSecret:    "secret",
+				RedirectURL:     "https://app.example.com/api/v1/auth/oidc/callback",
+				Scopes:          []string{"openid", "email"},
+				FlowTTL:         5 * time.Minute,
+				JITProvisioning: true,
+				DefaultRole:     "support",
+			},
+		},
+		{
+			name:    "invalid flow ttl",
+			env:     map[string]string{"OIDC_FLOW_TTL": "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "relative issuer",
+			env:     map[string]string{"OIDC_ISSUER_URL": "idp.example.com", "OIDC_CLIENT_ID": "client"},
+			wantErr: true,
+		},
+		{
+			name:    "missing client id",
+			env:     map[string]string{"OIDC_ISSUER_URL": "https://idp.example.com"},
+			wantErr: true,
+		},
+		{
+			name: "missing openid scope",
+			env: map[string]string{
+				"OIDC_ISSUER_URL": "https://idp.example.com",
+				"OIDC_CLIENT_ID":  "client",
+				"OIDC_SCOPES":     "email profile",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := getOIDCConfig()
+
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, cfg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, cfg)
+			}
+		})
+	}
+}
+
+func TestGetSessionConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *SessionConfig
+		wantErr  bool
+	}{
+		{
+			name: "token mode by default",
+			env:  map[string]string{},
+			expected: &SessionConfig{
+				CookieSameSite:    http.SameSiteStrictMode,
+				CredentialSources: []string{CredentialSourceHeader, CredentialSourceQuery},
+			},
+		},
+		{
+			name: "cookie mode reads cookies instead of the query",
+			env: map[string]string{
+				"SESSION_COOKIE_MODE":      "true",
+				"SESSION_COOKIE_DOMAIN":    "app.example.com",
+				"SESSION_COOKIE_SAME_SITE": "lax",
+			},
+			expected: &SessionConfig{
+				CookieMode:        true,
+				CookieDomain:      "app.example.com",
+				CookieSameSite:    http.SameSiteLaxMode,
+				CredentialSources: []string{CredentialSourceHeader, CredentialSourceCookie},
+			},
+		},
+		{
+			name: "query token turned off",
+			env:  map[string]string{"SESSION_CREDENTIAL_SOURCES": "header"},
+			expected: &SessionConfig{
+				CookieSameSite:    http.SameSiteStrictMode,
+				CredentialSources: []string{CredentialSourceHeader},
+			},
+		},
+		{
+			name:    "unsupported source",
+			env:     map[string]string{"SESSION_CREDENTIAL_SOURCES": "header,body"},
+			wantErr: true,
+		},
+		{
+			name: "cookie mode without the cookie source",
+			env: map[string]string{
+				"SESSION_COOKIE_MODE":        "true",
+				"SESSION_CREDENTIAL_SOURCES": "header",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported same site",
+			env:     map[string]string{"SESSION_COOKIE_SAME_SITE": "loose"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := getSessionConfig()
+
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, cfg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, cfg)
+			}
+		})
+	}
+}
+
+func TestGetWebAuthnConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *WebAuthnConfig
+		wantErr  bool
+	}{
+		{
+			name: "disabled by default",
+			env:  map[string]string{},
+			expected: &WebAuthnConfig{
+				RPName:       "gomonitor",
+				ChallengeTTL: 5 * time.Minute,
+			},
+		},
+		{
+			name: "origin defaults to the rp id",
+			env:  map[string]string{"WEBAUTHN_RP_ID": "Example.com"},
+			expected: &WebAuthnConfig{
+				RPID:         "example.com",
+				RPName:       "gomonitor",
+				Origins:      []string{"https://example.com"},
+				ChallengeTTL: 5 * time.Minute,
+			},
+		},
+		{
+			name: "enabled",
+			env: map[string]string{
+				"WEBAUTHN_RP_ID":         "example.com",
+				"WEBAUTHN_RP_NAME":       "Monitor",
+				"WEBAUTHN_ORIGINS":       "https://example.com https://admin.example.com",
+				"WEBAUTHN_CHALLENGE_TTL": "2m",
+			},
+			expected: &WebAuthnConfig{
+				RPID:         "example.com",
+				RPName:       "Monitor",
+				Origins:      []string{"https://example.com", "https://admin.example.com"},
+				ChallengeTTL: 2 * time.Minute,
+			},
+		},
+		{
+			name: "localhost over http",
+			env:  map[string]string{"WEBAUTHN_RP_ID": "localhost", "WEBAUTHN_ORIGINS": "http://localhost:8080"},
+			expected: &WebAuthnConfig{
+				RPID:         "localhost",
+				RPName:       "gomonitor",
+				Origins:      []string{"http://localhost:8080"},
+				ChallengeTTL: 5 * time.Minute,
+			},
+		},
+		{
+			name:    "invalid challenge ttl",
+			env:     map[string]string{"WEBAUTHN_CHALLENGE_TTL": "0s"},
+			wantErr: true,
+		},
+		{
+			name:    "origin of another domain",
+			env:     map[string]string{"WEBAUTHN_RP_ID": "example.com", "WEBAUTHN_ORIGINS": "https://example.org"},
+			wantErr: true,
+		},
+		{
+			name:    "insecure origin",
+			env:     map[string]string{"WEBAUTHN_RP_ID": "example.com", "WEBAUTHN_ORIGINS": "http://example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "origin with a path",
+			env:     map[string]string{"WEBAUTHN_RP_ID": "example.com", "WEBAUTHN_ORIGINS": "https://example.com/login"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := getWebAuthnConfig()
+
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, cfg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WebAuthn relying party configuration for passkeys, disabled without an RP ID.
+type WebAuthnConfig struct {
+	// RPID is the domain the credentials are scoped to, every origin must be on it or on a subdomain.
+	RPID   string
+	RPName string
+	// Origins are the exact origins of the pages running the ceremonies.
+	Origins []string
+	// ChallengeTTL bounds a ceremony, from the options to the response of the authenticator.
+	ChallengeTTL time.Duration
+}
+
+// Enabled reports if passkeys are configured.
+func (c *WebAuthnConfig) Enabled() bool {
+	return c != nil && c.RPID != ""
+}
+
+func getWebAuthnConfig() (*WebAuthnConfig, error) {
+	challengeTTL, err := time.ParseDuration(getEnv("WEBAUTHN_CHALLENGE_TTL", "5m"))
+	if err != nil || challengeTTL <= 0 {
+		return nil, fmt.Errorf("error parsing WEBAUTHN_CHALLENGE_TTL: %v", err)
+	}
+
+	cfg := &WebAuthnConfig{
+		RPID:         strings.ToLower(getEnv("WEBAUTHN_RP_ID", "")),
+		RPName:       getEnv("WEBAUTHN_RP_NAME", "gomonitor"),
+		ChallengeTTL: challengeTTL,
+	}
+
+	if !cfg.Enabled() {
+		return cfg, nil
+	}
+
+	cfg.Origins = strings.Fields(getEnv("WEBAUTHN_ORIGINS", "https://"+cfg.RPID))
+
+	for _, origin := range cfg.Origins {
+		if !isOriginOf(origin, cfg.RPID) {
+			return nil, fmt.Errorf("invalid WEBAUTHN_ORIGINS: %s is not an origin of %s", origin, cfg.RPID)
+		}
+	}
+
+	return cfg, nil
+}
+
+// isOriginOf reports if origin is a secure origin on the domain or one of its subdomains.
+// Browsers also treat localhost as secure, so it may use http.
+func isOriginOf(origin, domain string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host != domain && !strings.HasSuffix(host, "."+domain) {
+		return false
+	}
+
+	return u.Scheme == "https" || (u.Scheme == "http" && host == "localhost")
+}
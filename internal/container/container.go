@@ -1,19 +1,39 @@
 package container
 
 import (
+	apikeyhandler "gomonitor/internal/api/handlers/apikey"
 	authhandler "gomonitor/internal/api/handlers/auth"
+	autheventhandler "gomonitor/internal/api/handlers/authevent"
+	mfahandler "gomonitor/internal/api/handlers/mfa"
+	oauthhandler "gomonitor/internal/api/handlers/oauth"
+	passkeyhandler "gomonitor/internal/api/handlers/passkey"
+	signingkeyhandler "gomonitor/internal/api/handlers/signingkey"
 	userhandler "gomonitor/internal/api/handlers/user"
+	"gomonitor/internal/api/middlewares"
 	"gomonitor/internal/config"
+	"gomonitor/internal/domain/apikey"
 	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/domain/mfa"
+	"gomonitor/internal/domain/oauth"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/domain/rbac"
+	"gomonitor/internal/domain/signingkey"
 	"gomonitor/internal/domain/user"
 	"gomonitor/internal/infra/deps"
+	"gomonitor/internal/pkg/dpop"
 	"gomonitor/internal/pkg/ratelimit"
+	"gomonitor/internal/pkg/revocation"
+	"gomonitor/internal/pkg/webauthn"
 )
 
 type Container struct {
 	Deps *deps.Deps
 	Cfg  *config.Config
 
+	AuthDeps *middlewares.AuthDeps
+	// AuthEvents writes the authentication log, its Run loop is started by the app.
+	AuthEvents   *authevent.BatchRecorder
 	RateLimiters RateLimiters
 	Repositories *Repositories
 	Services     *Services
@@ -21,22 +41,47 @@ type Container struct {
 }
 
 type RateLimiters struct {
-	IPLimiter ratelimit.RateLimiter
+	IPLimiter    ratelimit.RateLimiter
+	LoginLockout ratelimit.Lockout
 }
 
 type Repositories struct {
-	User         user.UserRepository
-	RefreshToken auth.RefreshTokenRepository
+	APIKey           apikey.Repository
+	AuthEvent        authevent.Repository
+	User             user.UserRepository
+	EmailToken       user.EmailTokenRepository
+	ExternalIdentity user.ExternalIdentityRepository
+	MFA              mfa.Repository
+	OAuthClient      oauth.Repository
+	Passkey          passkey.Repository
+	PasswordHistory  user.PasswordHistoryRepository
+	PasswordReset    auth.PasswordResetRepository
+	RBAC             rbac.Repository
+	RefreshToken     auth.RefreshTokenRepository
+	SigningKey       signingkey.Repository
 }
 
 type Services struct {
-	Auth auth.Service
-	User user.Service
+	APIKey     apikey.Service
+	Auth       auth.Service
+	AuthEvent  authevent.Service
+	MFA        mfa.Service
+	OAuth      oauth.Service
+	Passkey    passkey.Service
+	RBAC       rbac.Service
+	SigningKey signingkey.Service
+	User       user.Service
 }
 
 type Handlers struct {
-	Auth *authhandler.Handler
-	User *userhandler.Handler
+	APIKey     *apikeyhandler.Handler
+	Auth       *authhandler.Handler
+	AuthEvent  *autheventhandler.Handler
+	MFA        *mfahandler.Handler
+	OAuth      *oauthhandler.Handler
+	Passkey    *passkeyhandler.Handler
+	SigningKey *signingkeyhandler.Handler
+	User       *userhandler.Handler
 }
 
 func New(deps *deps.Deps, cfg *config.Config) *Container {
@@ -66,26 +111,179 @@ func New(deps *deps.Deps, cfg *config.Config) *Container {
 		),
 	)
 
+	loginLockoutOpts := []ratelimit.LockoutOption{
+		ratelimit.WithAttempts(cfg.RateLimit.LoginAttempts),
+		ratelimit.WithLockoutPrefix("login_lockout"),
+		ratelimit.WithAttemptWindow(cfg.RateLimit.LoginWindow),
+		ratelimit.WithLockoutDuration(cfg.RateLimit.LoginLockout, cfg.RateLimit.LoginMaxLockout),
+	}
+
+	c.RateLimiters.LoginLockout = ratelimit.NewLockoutManager(
+		ratelimit.WithLockout(ratelimit.NewRedisLockout(deps.Redis, loginLockoutOpts...)),
+		ratelimit.WithLockoutFallback(ratelimit.NewMemoryLockout(loginLockoutOpts...)),
+	)
+
+	c.Repositories.APIKey = apikey.NewRepository(deps.DB)
+	c.Repositories.AuthEvent = authevent.NewRepository(deps.DB)
 	c.Repositories.User = user.NewUserRepository(deps.DB)
+	c.Repositories.EmailToken = user.NewEmailTokenRepository(deps.DB)
+	c.Repositories.ExternalIdentity = user.NewExternalIdentityRepository(deps.DB)
+	c.Repositories.MFA = mfa.NewRepository(deps.DB)
+	c.Repositories.OAuthClient = oauth.NewRepository(deps.DB)
+	c.Repositories.Passkey = passkey.NewRepository(deps.DB)
+	c.Repositories.PasswordHistory = user.NewPasswordHistoryRepository(deps.DB)
+	c.Repositories.PasswordReset = auth.NewPasswordResetRepository(deps.DB)
+	c.Repositories.RBAC = rbac.NewRepository(deps.DB)
 	c.Repositories.RefreshToken = auth.NewRefreshTokenRepository(deps.DB)
+	c.Repositories.SigningKey = signingkey.NewRepository(deps.DB)
+
+	c.Services.APIKey = apikey.NewService(&apikey.ServiceDeps{
+		Logger:   deps.Logger,
+		Repo:     c.Repositories.APIKey,
+		UserRepo: c.Repositories.User,
+	})
+
+	c.AuthEvents = authevent.NewBatchRecorder(&authevent.RecorderDeps{
+		Config:   cfg.AuthEvents,
+		Logger:   deps.Logger,
+		Repo:     c.Repositories.AuthEvent,
+		UserRepo: c.Repositories.User,
+	})
+
+	c.Services.AuthEvent = authevent.NewService(&authevent.ServiceDeps{
+		Logger:    deps.Logger,
+		Repo:      c.Repositories.AuthEvent,
+		Retention: cfg.AuthEvents.Retention,
+	})
+
+	c.Services.RBAC = rbac.NewService(&rbac.ServiceDeps{
+		Logger: deps.Logger,
+		Repo:   c.Repositories.RBAC,
+	})
+
+	c.AuthDeps = &middlewares.AuthDeps{
+		APIKeys:          c.Services.APIKey,
+		Denylist:         newDenylist(deps, cfg.Auth, c.Repositories.RefreshToken),
+		DPoP:             newDPoPVerifier(deps, cfg.Auth),
+		Permissions:      c.Services.RBAC,
+		RecentAuthMaxAge: cfg.Auth.RecentAuthMaxAge,
+		Session:          cfg.Session,
+		TokenManager:     deps.TokenManager,
+	}
+
+	passwordPolicy := user.NewPasswordPolicy(&user.PasswordPolicyDeps{
+		Hasher:      deps.Hasher,
+		HistoryRepo: c.Repositories.PasswordHistory,
+		HistorySize: cfg.Password.HistorySize,
+		Rules:       deps.PasswordRules,
+	})
+
+	c.Services.MFA = mfa.NewService(&mfa.ServiceDeps{
+		AuthConfig: cfg.Auth,
+		Cipher:     deps.Cipher,
+		Logger:     deps.Logger,
+		Lockout:    c.RateLimiters.LoginLockout,
+		Repo:       c.Repositories.MFA,
+		UserRepo:   c.Repositories.User,
+	})
+
+	c.Services.Passkey = passkey.NewService(&passkey.ServiceDeps{
+		Challenges: webauthn.NewRedisChallengeStore(deps.Redis),
+		Config:     cfg.WebAuthn,
+		Logger:     deps.Logger,
+		Repo:       c.Repositories.Passkey,
+		UserRepo:   c.Repositories.User,
+	})
 
 	c.Services.Auth = auth.NewService(&auth.ServiceDeps{
-		AuthConfig:       cfg.Auth,
-		Hasher:           deps.Hasher,
+		AuthConfig:        cfg.Auth,
+		AuthEventsConfig:  cfg.AuthEvents,
+		Cipher:            deps.Cipher,
+		Denylist:          c.AuthDeps.Denylist,
+		Events:            c.AuthEvents,
+		Hasher:            deps.Hasher,
+		IdentityRepo:      c.Repositories.ExternalIdentity,
+		Logger:            deps.Logger,
+		LoginHistory:      c.Repositories.AuthEvent,
+		LoginLockout:      c.RateLimiters.LoginLockout,
+		LoginPolicy:       newLoginPolicy(cfg.AuthEvents),
+		Mailer:            deps.Mailer,
+		MFA:               c.Services.MFA,
+		OIDC:              deps.OIDC,
+		OIDCConfig:        cfg.OIDC,
+		Passkeys:          c.Services.Passkey,
+		PasswordPolicy:    passwordPolicy,
+		PasswordResetRepo: c.Repositories.PasswordReset,
+		RBAC:              c.Services.RBAC,
+		RefreshTokenRepo:  c.Repositories.RefreshToken,
+		UsedTokens:        revocation.NewRedisUsedTokens(deps.Redis),
+		UserRepo:          c.Repositories.User,
+		TokenManager:      deps.TokenManager,
+	})
+
+	c.Services.OAuth = oauth.NewService(&oauth.ServiceDeps{
+		Denylist:         c.AuthDeps.Denylist,
 		Logger:           deps.Logger,
-		RefreshTokenRepo: c.Repositories.RefreshToken,
-		UserRepo:         c.Repositories.User,
+		Repo:             c.Repositories.OAuthClient,
 		TokenManager:     deps.TokenManager,
+		RefreshTokenRepo: c.Repositories.RefreshToken,
+	})
+
+	c.Services.SigningKey = signingkey.NewService(&signingkey.ServiceDeps{
+		AccessKeyring:  deps.AccessKeyring,
+		Cipher:         deps.SigningKeyCipher,
+		Logger:         deps.Logger,
+		RefreshKeyring: deps.RefreshKeyring,
+		Repo:           c.Repositories.SigningKey,
 	})
 
 	c.Services.User = user.NewService(&user.ServiceDeps{
-		Hasher:   deps.Hasher,
-		UserRepo: c.Repositories.User,
-		Logger:   deps.Logger,
+		AuthConfig:     cfg.Auth,
+		EmailTokenRepo: c.Repositories.EmailToken,
+		Hasher:         deps.Hasher,
+		LoginLockout:   c.RateLimiters.LoginLockout,
+		Mailer:         deps.Mailer,
+		PasswordPolicy: passwordPolicy,
+		RBAC:           c.Services.RBAC,
+		UserRepo:       c.Repositories.User,
+		Logger:         deps.Logger,
 	})
 
-	c.Handler.Auth = authhandler.NewHandler(deps.Logger, c.Services.Auth, deps.TokenManager)
-	c.Handler.User = userhandler.NewHandler(deps.Logger, c.Services.User, deps.TokenManager)
+	c.Handler.APIKey = apikeyhandler.NewHandler(deps.Logger, c.Services.APIKey, c.AuthDeps)
+	c.Handler.Auth = authhandler.NewHandler(deps.Logger, c.Services.Auth, c.AuthDeps)
+	c.Handler.AuthEvent = autheventhandler.NewHandler(deps.Logger, c.Services.AuthEvent, c.AuthDeps)
+	c.Handler.MFA = mfahandler.NewHandler(deps.Logger, c.Services.MFA, c.AuthDeps)
+	c.Handler.OAuth = oauthhandler.NewHandler(deps.Logger, c.Services.OAuth, c.AuthDeps)
+	c.Handler.Passkey = passkeyhandler.NewHandler(deps.Logger, c.Services.Passkey, c.AuthDeps)
+	c.Handler.SigningKey = signingkeyhandler.NewHandler(deps.Logger, c.Services.SigningKey, c.AuthDeps)
+	c.Handler.User = userhandler.NewHandler(deps.Logger, c.Services.User, c.AuthDeps)
 
 	return c
 }
+
+// newDenylist applies the configured policy for revoked sessions while Redis is unavailable.
+func newDenylist(deps *deps.Deps, authCfg *config.AuthConfig, store revocation.Store) revocation.Denylist {
+	var opts []revocation.ManagerOption
+	if authCfg == nil || authCfg.RevocationFallback != config.RevocationFallbackOpen {
+		opts = append(opts, revocation.WithFallback(store))
+	}
+
+	return revocation.New(revocation.NewRedisDenylist(deps.Redis), opts...)
+}
+
+// newLoginPolicy steps up the logins from new devices when configured, they are only notified otherwise.
+func newLoginPolicy(eventsCfg *config.AuthEventsConfig) auth.LoginPolicy {
+	if !eventsCfg.NewDeviceStepUp {
+		return nil
+	}
+
+	return auth.StepUpUnusualLogins
+}
+
+func newDPoPVerifier(deps *deps.Deps, authCfg *config.AuthConfig) *dpop.Verifier {
+	if authCfg == nil {
+		return nil
+	}
+
+	return dpop.NewVerifier(dpop.NewRedisReplayStore(deps.Redis), authCfg.DPoPProofTTL)
+}
@@ -22,6 +22,9 @@ func TestNewContainer(t *testing.T) {
 		TokenManager: &mocks.MockJwtManager{},
 	}
 	container := container.New(deps, &config.Config{
+		Auth:       &config.AuthConfig{RecentAuthMaxAge: 10 * time.Minute},
+		AuthEvents: &config.AuthEventsConfig{BufferSize: 10, BatchSize: 10, FlushInterval: time.Second},
+		Password:   &config.PasswordConfig{HistorySize: 5},
 		RateLimit: &config.RateLimitConfig{
 			IPLimit:    10,
 			IPWindow:   time.Minute,
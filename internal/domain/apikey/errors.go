@@ -0,0 +1,8 @@
+package apikey
+
+var (
+	MsgAPIKeyNotFound = "api key not found"
+	MsgInvalidAPIKey  = "invalid or expired api key"
+	MsgInvalidExpiry  = "expiry must be in the future"
+	MsgInvalidScope   = "unknown scope"
+)
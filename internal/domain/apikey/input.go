@@ -0,0 +1,14 @@
+package apikey
+
+import "time"
+
+// CreateInput creates a key for the caller, without ExpiresAt it doesn't expire.
+type CreateInput struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+type KeyInput struct {
+	ID uint
+}
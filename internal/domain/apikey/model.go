@@ -0,0 +1,31 @@
+package apikey
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey authenticates a machine client as its user, limited to Scopes. Only the SHA-256 of the key
+// is stored, Prefix is its start so the owner can tell the keys apart.
+type APIKey struct {
+	ID      uint   `gorm:"primaryKey"`
+	UserID  uint   `gorm:"not null;index"`
+	Name    string `gorm:"type:varchar(100);not null"`
+	Prefix  string `gorm:"type:varchar(16);not null"`
+	KeyHash string `gorm:"type:char(64);not null;uniqueIndex"`
+	// Scopes are space separated.
+	Scopes     string `gorm:"type:varchar(255);not null"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// ScopeList splits the granted scopes.
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// Expired reports if the key can't be used anymore, keys without expiry never expire.
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
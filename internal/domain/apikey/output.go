@@ -0,0 +1,7 @@
+package apikey
+
+// CreateOutput holds the plain key, it can't be retrieved again.
+type CreateOutput struct {
+	Key    string
+	APIKey *APIKey
+}
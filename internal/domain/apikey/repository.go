@@ -0,0 +1,88 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, key *APIKey) error
+	ListByUserID(ctx context.Context, userID uint) ([]APIKey, error)
+	Get(ctx context.Context, userID, id uint) (*APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	TouchLastUsed(ctx context.Context, id uint, at time.Time) error
+	Delete(ctx context.Context, userID, id uint) (bool, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) Create(ctx context.Context, key *APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// ListByUserID returns the keys of the user, newest first.
+func (r *repository) ListByUserID(ctx context.Context, userID uint) ([]APIKey, error) {
+	var keys []APIKey
+	err := r.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Find(&keys).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Get returns a key of the user, gorm.ErrRecordNotFound for keys of other users.
+func (r *repository) Get(ctx context.Context, userID, id uint) (*APIKey, error) {
+	var key APIKey
+	if err := r.db.WithContext(ctx).First(&key, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *repository) GetByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	var key APIKey
+	if err := r.db.WithContext(ctx).First(&key, "key_hash = ?", keyHash).Error; err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *repository) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	return r.db.
+		WithContext(ctx).
+		Model(&APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).
+		Error
+}
+
+// Delete reports false if the user has no such key.
+func (r *repository) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&APIKey{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
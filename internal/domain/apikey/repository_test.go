@@ -0,0 +1,115 @@
+package apikey_test
+
+import (
+	"gomonitor/internal/domain/apikey"
+	databaseinfra "gomonitor/internal/infra/database"
+	"gomonitor/internal/testutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newKey(userID uint, hashChar string) *apikey.APIKey {
+	return &apikey.APIKey{
+		UserID:  userID,
+		Name:    "ci",
+		Prefix:  "gmk_abcdefgh",
+		KeyHash: strings.Repeat(hashChar, 64),
+		Scopes:  "read write",
+	}
+}
+
+func TestRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := apikey.NewRepository(tx)
+
+	key := newKey(1, "a")
+	require.NoError(t, repo.Create(t.Context(), key))
+	assert.NotZero(t, key.ID)
+
+	// The hash is unique.
+	assert.Error(t, repo.Create(t.Context(), newKey(2, "a")))
+}
+
+func TestRepository_Lookup(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := apikey.NewRepository(tx)
+
+	first, second, other := newKey(3, "b"), newKey(3, "c"), newKey(4, "d")
+	for _, key := range []*apikey.APIKey{first, second, other} {
+		require.NoError(t, repo.Create(t.Context(), key))
+	}
+
+	keys, err := repo.ListByUserID(t.Context(), 3)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, second.ID, keys[0].ID)
+
+	got, err := repo.Get(t.Context(), 3, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read", "write"}, got.ScopeList())
+
+	_, err = repo.Get(t.Context(), 3, other.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	got, err = repo.GetByHash(t.Context(), strings.Repeat("d", 64))
+	require.NoError(t, err)
+	assert.Equal(t, other.ID, got.ID)
+
+	_, err = repo.GetByHash(t.Context(), strings.Repeat("e", 64))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	_, err = repo.ListByUserID(testutil.GetCancelledCtx(t.Context()), 3)
+	assert.Error(t, err)
+}
+
+func TestRepository_TouchLastUsed(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := apikey.NewRepository(tx)
+
+	key := newKey(5, "f")
+	require.NoError(t, repo.Create(t.Context(), key))
+
+	now := time.Now()
+	require.NoError(t, repo.TouchLastUsed(t.Context(), key.ID, now))
+
+	got, err := repo.Get(t.Context(), 5, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastUsedAt)
+	assert.WithinDuration(t, now, *got.LastUsedAt, time.Millisecond)
+}
+
+func TestRepository_Delete(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := apikey.NewRepository(tx)
+
+	key := newKey(6, "g")
+	require.NoError(t, repo.Create(t.Context(), key))
+
+	deleted, err := repo.Delete(t.Context(), 7, key.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = repo.Delete(t.Context(), 6, key.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, err = repo.GetByHash(t.Context(), strings.Repeat("g", 64))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,204 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/opaquetoken"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lastUsedPrecision limits the writes of busy keys, last_used_at is only updated once it's that old.
+const lastUsedPrecision = time.Minute
+
+// displayPrefixLength is the part of the key kept in clear, enough to tell the keys of a user apart.
+const displayPrefixLength = len(identity.APIKeyPrefix) + 8
+
+var knownScopes = []string{identity.ScopeRead, identity.ScopeWrite}
+
+type Service interface {
+	Create(ctx context.Context, input CreateInput) (*CreateOutput, error)
+	List(ctx context.Context) ([]APIKey, error)
+	Get(ctx context.Context, input KeyInput) (*APIKey, error)
+	Delete(ctx context.Context, input KeyInput) error
+	Authenticate(ctx context.Context, key string) (*identity.Principal, error)
+}
+
+type ServiceDeps struct {
+	Logger   *slog.Logger
+	Repo     Repository
+	UserRepo user.UserRepository
+}
+
+type service struct {
+	logger   *slog.Logger
+	repo     Repository
+	userRepo user.UserRepository
+}
+
+func NewService(deps *ServiceDeps) Service {
+	return &service{
+		logger:   deps.Logger,
+		repo:     deps.Repo,
+		userRepo: deps.UserRepo,
+	}
+}
+
+// Create issues a key for the caller, the plain key is only part of this output.
+func (s *service) Create(ctx context.Context, input CreateInput) (*CreateOutput, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+	// Keys act as a user, API keys and clients can't mint them.
+	if principal.Source == identity.AuthAPIKey || principal.Source == identity.AuthClient {
+		return nil, pkgerrors.NewForbiddenError()
+	}
+	// They would outlive the impersonation.
+	if principal.Impersonated() {
+		logging.FromContext(ctx).Warn("api key creation refused while impersonating")
+		return nil, pkgerrors.NewForbiddenError()
+	}
+
+	if len(input.Scopes) == 0 {
+		return nil, pkgerrors.NewBadRequestError(MsgInvalidScope)
+	}
+	for _, scope := range input.Scopes {
+		if !slices.Contains(knownScopes, scope) {
+			return nil, pkgerrors.NewBadRequestError(MsgInvalidScope)
+		}
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, pkgerrors.NewBadRequestError(MsgInvalidExpiry)
+	}
+
+	token, err := opaquetoken.New()
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+	key := identity.APIKeyPrefix + token
+
+	scopes := slices.Clone(input.Scopes)
+	slices.Sort(scopes)
+
+	apiKey := &APIKey{
+		UserID:    principal.UserID,
+		Name:      input.Name,
+		Prefix:    key[:displayPrefixLength],
+		KeyHash:   opaquetoken.Hash(key),
+		Scopes:    strings.Join(slices.Compact(scopes), " "),
+		ExpiresAt: input.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, apiKey); err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	logging.FromContext(ctx).Info("api key created", slog.Uint64("api_key_id", uint64(apiKey.ID)))
+
+	return &CreateOutput{Key: key, APIKey: apiKey}, nil
+}
+
+// List returns the keys of the caller, without their secret.
+func (s *service) List(ctx context.Context) ([]APIKey, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	keys, err := s.repo.ListByUserID(ctx, principal.UserID)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return keys, nil
+}
+
+func (s *service) Get(ctx context.Context, input KeyInput) (*APIKey, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	key, err := s.repo.Get(ctx, principal.UserID, input.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewNotFoundError(MsgAPIKeyNotFound)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return key, nil
+}
+
+// Delete revokes a key of the caller, it stops working immediately.
+func (s *service) Delete(ctx context.Context, input KeyInput) error {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		return pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	deleted, err := s.repo.Delete(ctx, principal.UserID, input.ID)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+	if !deleted {
+		return pkgerrors.NewNotFoundError(MsgAPIKeyNotFound)
+	}
+
+	logging.FromContext(ctx).Info("api key deleted", slog.Uint64("api_key_id", uint64(input.ID)))
+
+	return nil
+}
+
+// Authenticate resolves the key to the principal of its user, restricted to the scopes of the key.
+func (s *service) Authenticate(ctx context.Context, key string) (*identity.Principal, error) {
+	if !strings.HasPrefix(key, identity.APIKeyPrefix) {
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidAPIKey)
+	}
+
+	apiKey, err := s.repo.GetByHash(ctx, opaquetoken.Hash(key))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewUnauthorizedError(MsgInvalidAPIKey)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	now := time.Now()
+	if apiKey.Expired(now) {
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidAPIKey)
+	}
+
+	u, err := s.userRepo.GetByID(ctx, apiKey.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewUnauthorizedError(MsgInvalidAPIKey)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedPrecision {
+		if err := s.repo.TouchLastUsed(ctx, apiKey.ID, now); err != nil {
+			logging.FromContext(ctx).Warn("failed to record api key usage",
+				slog.Uint64("api_key_id", uint64(apiKey.ID)),
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	return &identity.Principal{
+		UserID: u.ID,
+		Role:   u.Role,
+		Source: identity.AuthAPIKey,
+		Scopes: apiKey.ScopeList(),
+	}, nil
+}
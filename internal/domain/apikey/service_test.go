@@ -0,0 +1,411 @@
+package apikey_test
+
+import (
+	"context"
+	"errors"
+	"gomonitor/internal/domain/apikey"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/opaquetoken"
+	"gomonitor/internal/testutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const testUserID = uint(7)
+
+func userCtx(ctx context.Context, source identity.AuthSource) context.Context {
+	return identity.WithPrincipal(ctx, &identity.Principal{
+		UserID: testUserID,
+		Role:   identity.RoleUser,
+		Source: source,
+	})
+}
+
+func newService(repo *mocks.MockAPIKeyRepository, userRepo *mocks.MockUserRepository) apikey.Service {
+	return apikey.NewService(&apikey.ServiceDeps{
+		Logger:   slog.Default(),
+		Repo:     repo,
+		UserRepo: userRepo,
+	})
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	var appErr *pkgerrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, status, appErr.StatusCode)
+}
+
+func TestService_Create(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		ctx            func(context.Context) context.Context
+		input          apikey.CreateInput
+		setupMocks     func(*mocks.MockAPIKeyRepository)
+		expectedStatus int
+	}{
+		{
+			name:           "unauthenticated",
+			ctx:            func(ctx context.Context) context.Context { return ctx },
+			input:          apikey.CreateInput{Name: "ci", Scopes: []string{identity.ScopeRead}},
+			setupMocks:     func(*mocks.MockAPIKeyRepository) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "api keys can't create keys",
+			ctx: func(ctx context.Context) context.Context {
+				return userCtx(ctx, identity.AuthAPIKey)
+			},
+			input:          apikey.CreateInput{Name: "ci", Scopes: []string{identity.ScopeRead}},
+			setupMocks:     func(*mocks.MockAPIKeyRepository) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "clients can't create keys",
+			ctx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{ClientID: "client", Source: identity.AuthClient})
+			},
+			input:          apikey.CreateInput{Name: "ci", Scopes: []string{identity.ScopeWrite}},
+			setupMocks:     func(*mocks.MockAPIKeyRepository) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "impersonated sessions can't create keys",
+			ctx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID:  testUserID,
+					Role:    identity.RoleUser,
+					Source:  identity.AuthExternal,
+					ActorID: 1,
+				})
+			},
+			input:          apikey.CreateInput{Name: "ci", Scopes: []string{identity.ScopeRead}},
+			setupMocks:     func(*mocks.MockAPIKeyRepository) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "no scope",
+			ctx: func(ctx context.Context) context.Context {
+				return userCtx(ctx, identity.AuthExternal)
+			},
+			input:          apikey.CreateInput{Name: "ci"},
+			setupMocks:     func(*mocks.MockAPIKeyRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown scope",
+			ctx: func(ctx context.Context) context.Context {
+				return userCtx(ctx, identity.AuthExternal)
+			},
+			input:          apikey.CreateInput{Name: "ci", Scopes: []string{"admin"}},
+			setupMocks:     func(*mocks.MockAPIKeyRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "expiry in the past",
+			ctx: func(ctx context.Context) context.Context {
+				return userCtx(ctx, identity.AuthExternal)
+			},
+			input: apikey.CreateInput{
+				Name:      "ci",
+				Scopes:    []string{identity.ScopeRead},
+				ExpiresAt: testutil.Ptr(time.Now().Add(-time.Minute)),
+			},
+			setupMocks:     func(*mocks.MockAPIKeyRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "repository error",
+			ctx: func(ctx context.Context) context.Context {
+				return userCtx(ctx, identity.AuthExternal)
+			},
+			input: apikey.CreateInput{Name: "ci", Scopes: []string{identity.ScopeRead}},
+			setupMocks: func(repo *mocks.MockAPIKeyRepository) {
+				repo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockAPIKeyRepository{}
+			tt.setupMocks(repo)
+
+			_, err := newService(repo, &mocks.MockUserRepository{}).Create(tt.ctx(t.Context()), tt.input)
+
+			assertStatus(t, err, tt.expectedStatus)
+			repo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("success stores the hash only", func(t *testing.T) {
+		repo := &mocks.MockAPIKeyRepository{}
+		var stored *apikey.APIKey
+		repo.On("Create", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*apikey.APIKey) }).
+			Return(nil)
+
+		expiresAt := time.Now().Add(time.Hour)
+		out, err := newService(repo, &mocks.MockUserRepository{}).Create(userCtx(t.Context(), identity.AuthInternal), apikey.CreateInput{
+			Name:      "ci",
+			Scopes:    []string{identity.ScopeWrite, identity.ScopeRead, identity.ScopeWrite},
+			ExpiresAt: &expiresAt,
+		})
+
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(out.Key, identity.APIKeyPrefix))
+		assert.Equal(t, opaquetoken.Hash(out.Key), stored.KeyHash)
+		assert.True(t, strings.HasPrefix(out.Key, stored.Prefix))
+		assert.NotContains(t, stored.KeyHash, out.Key)
+		assert.Equal(t, testUserID, stored.UserID)
+		assert.Equal(t, "read write", stored.Scopes)
+		assert.Equal(t, &expiresAt, stored.ExpiresAt)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestService_List(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		_, err := newService(&mocks.MockAPIKeyRepository{}, &mocks.MockUserRepository{}).List(t.Context())
+		assertStatus(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repo := &mocks.MockAPIKeyRepository{}
+		repo.On("ListByUserID", mock.Anything, testUserID).Return(nil, errors.New("db down"))
+
+		_, err := newService(repo, &mocks.MockUserRepository{}).List(userCtx(t.Context(), identity.AuthExternal))
+		assertStatus(t, err, http.StatusInternalServerError)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		repo := &mocks.MockAPIKeyRepository{}
+		repo.On("ListByUserID", mock.Anything, testUserID).Return([]apikey.APIKey{{ID: 1}}, nil)
+
+		keys, err := newService(repo, &mocks.MockUserRepository{}).List(userCtx(t.Context(), identity.AuthExternal))
+		require.NoError(t, err)
+		assert.Len(t, keys, 1)
+	})
+}
+
+func TestService_Get(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		setupMocks     func(*mocks.MockAPIKeyRepository)
+		expectedStatus int
+	}{
+		{
+			name: "not found",
+			setupMocks: func(repo *mocks.MockAPIKeyRepository) {
+				repo.On("Get", mock.Anything, testUserID, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(repo *mocks.MockAPIKeyRepository) {
+				repo.On("Get", mock.Anything, testUserID, uint(1)).Return(nil, errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.MockAPIKeyRepository) {
+				repo.On("Get", mock.Anything, testUserID, uint(1)).Return(&apikey.APIKey{ID: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockAPIKeyRepository{}
+			tt.setupMocks(repo)
+
+			key, err := newService(repo, &mocks.MockUserRepository{}).Get(userCtx(t.Context(), identity.AuthExternal), apikey.KeyInput{ID: 1})
+
+			if tt.expectedStatus == http.StatusOK {
+				require.NoError(t, err)
+				assert.Equal(t, uint(1), key.ID)
+			} else {
+				assertStatus(t, err, tt.expectedStatus)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		setupMocks     func(*mocks.MockAPIKeyRepository)
+		expectedStatus int
+	}{
+		{
+			name: "key of another user",
+			setupMocks: func(repo *mocks.MockAPIKeyRepository) {
+				repo.On("Delete", mock.Anything, testUserID, uint(1)).Return(false, nil)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(repo *mocks.MockAPIKeyRepository) {
+				repo.On("Delete", mock.Anything, testUserID, uint(1)).Return(false, errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.MockAPIKeyRepository) {
+				repo.On("Delete", mock.Anything, testUserID, uint(1)).Return(true, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockAPIKeyRepository{}
+			tt.setupMocks(repo)
+
+			err := newService(repo, &mocks.MockUserRepository{}).Delete(userCtx(t.Context(), identity.AuthExternal), apikey.KeyInput{ID: 1})
+
+			if tt.expectedStatus == 0 {
+				assert.NoError(t, err)
+			} else {
+				assertStatus(t, err, tt.expectedStatus)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	key := identity.APIKeyPrefix + "secret"
+	hash := opaquetoken.Hash(key)
+	storedUser := &user.User{ID: testUserID, Role: identity.RoleAdmin}
+
+	tests := []struct {
+		name           string
+		key            string
+		setupMocks     func(*mocks.MockAPIKeyRepository, *mocks.MockUserRepository)
+		expectedStatus int
+	}{
+		{
+			name:           "missing prefix",
+			key:            "secret",
+			setupMocks:     func(*mocks.MockAPIKeyRepository, *mocks.MockUserRepository) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "unknown key",
+			key:  key,
+			setupMocks: func(repo *mocks.MockAPIKeyRepository, _ *mocks.MockUserRepository) {
+				repo.On("GetByHash", mock.Anything, hash).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "repository error",
+			key:  key,
+			setupMocks: func(repo *mocks.MockAPIKeyRepository, _ *mocks.MockUserRepository) {
+				repo.On("GetByHash", mock.Anything, hash).Return(nil, errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "expired key",
+			key:  key,
+			setupMocks: func(repo *mocks.MockAPIKeyRepository, _ *mocks.MockUserRepository) {
+				repo.On("GetByHash", mock.Anything, hash).Return(&apikey.APIKey{
+					ID: 1, UserID: testUserID, Scopes: "read", ExpiresAt: testutil.Ptr(time.Now().Add(-time.Second)),
+				}, nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "deleted user",
+			key:  key,
+			setupMocks: func(repo *mocks.MockAPIKeyRepository, userRepo *mocks.MockUserRepository) {
+				repo.On("GetByHash", mock.Anything, hash).Return(&apikey.APIKey{ID: 1, UserID: testUserID, Scopes: "read"}, nil)
+				userRepo.On("GetByID", mock.Anything, testUserID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "records the first use",
+			key:  key,
+			setupMocks: func(repo *mocks.MockAPIKeyRepository, userRepo *mocks.MockUserRepository) {
+				repo.On("GetByHash", mock.Anything, hash).Return(&apikey.APIKey{ID: 1, UserID: testUserID, Scopes: "read"}, nil)
+				userRepo.On("GetByID", mock.Anything, testUserID).Return(storedUser, nil)
+				repo.On("TouchLastUsed", mock.Anything, uint(1), mock.Anything).Return(nil)
+			},
+		},
+		{
+			name: "recent use isn't recorded again",
+			key:  key,
+			setupMocks: func(repo *mocks.MockAPIKeyRepository, userRepo *mocks.MockUserRepository) {
+				repo.On("GetByHash", mock.Anything, hash).Return(&apikey.APIKey{
+					ID: 1, UserID: testUserID, Scopes: "read", LastUsedAt: testutil.Ptr(time.Now().Add(-time.Second)),
+				}, nil)
+				userRepo.On("GetByID", mock.Anything, testUserID).Return(storedUser, nil)
+			},
+		},
+		{
+			name: "failing to record the use doesn't fail",
+			key:  key,
+			setupMocks: func(repo *mocks.MockAPIKeyRepository, userRepo *mocks.MockUserRepository) {
+				repo.On("GetByHash", mock.Anything, hash).Return(&apikey.APIKey{ID: 1, UserID: testUserID, Scopes: "read"}, nil)
+				userRepo.On("GetByID", mock.Anything, testUserID).Return(storedUser, nil)
+				repo.On("TouchLastUsed", mock.Anything, uint(1), mock.Anything).Return(errors.New("db down"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockAPIKeyRepository{}
+			userRepo := &mocks.MockUserRepository{}
+			tt.setupMocks(repo, userRepo)
+
+			principal, err := newService(repo, userRepo).Authenticate(t.Context(), tt.key)
+
+			if tt.expectedStatus != 0 {
+				assertStatus(t, err, tt.expectedStatus)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &identity.Principal{
+					UserID: testUserID,
+					Role:   identity.RoleAdmin,
+					Source: identity.AuthAPIKey,
+					Scopes: []string{identity.ScopeRead},
+				}, principal)
+			}
+			repo.AssertExpectations(t)
+			userRepo.AssertExpectations(t)
+		})
+	}
+}
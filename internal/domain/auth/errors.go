@@ -1,6 +1,31 @@
 package auth
 
+import "errors"
+
 var (
-	MsgInvalidCredentials = "invalid credentials"
-	MsgInvalidToken       = "invalid token"
+	MsgEmailNotVerified       = "email not verified"
+	MsgImpersonateSelf        = "can't impersonate yourself"
+	MsgInvalidCredentials     = "invalid credentials"
+	MsgInvalidCurrentPassword = "invalid current password"
+	MsgInvalidToken           = "invalid token"
+	MsgInvalidMFAChallenge    = "invalid mfa challenge"
+	MsgInvalidResetToken      = "invalid or expired reset token"
+	MsgInvalidMagicLink       = "invalid or expired login link"
+	MsgMFACodeRequired        = "mfa code required"
+	MsgNotASession            = "only sessions can re-authenticate"
+	MsgOIDCDisabled           = "oidc login is not enabled"
+	MsgOIDCInvalidFlow        = "invalid or expired oidc login"
+	MsgOIDCLoginFailed        = "oidc login failed"
+	MsgOIDCNotLinked          = "no account is linked to this identity"
+	MsgPasswordUnchanged      = "new password must differ from the current one"
+	MsgSessionNotFound        = "session not found"
 )
+
+// ErrRefreshTokenRevoked is returned when rotating a token that was already revoked.
+var ErrRefreshTokenRevoked = errors.New("refresh token already revoked")
+
+// errUsedTokensStore is returned when no store is configured to exchange mfa challenges and login links once.
+var errUsedTokensStore = errors.New("no store of used tokens")
+
+// errNoDenylist is returned when no denylist is configured to end impersonations, they have no other record.
+var errNoDenylist = errors.New("no denylist to end the impersonation")
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// Impersonate issues a short-lived access token acting as the user, so support can see what they see.
+// The token records the admin as actor, sensitive operations are refused with it. Users whose role
+// grants permissions the admin lacks can't be impersonated. Logging out with the token ends it early.
+func (s *service) Impersonate(ctx context.Context, input ImpersonateInput) (*ImpersonationOutput, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Warn("unauthenticated impersonation attempt")
+		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	// Only interactive sessions impersonate, and never from an impersonated one.
+	if !principal.HasPermission(identity.PermUsersImpersonate) ||
+		principal.Source != identity.AuthExternal ||
+		principal.Impersonated() {
+		logging.FromContext(ctx).Warn("unauthorized impersonation attempt",
+			"user_id", principal.UserID,
+			"user_role", principal.Role,
+			"source", principal.Source,
+			"target_user_id", input.UserID,
+		)
+		return nil, pkgerrors.NewForbiddenError()
+	}
+
+	if input.UserID == principal.UserID {
+		return nil, pkgerrors.NewBadRequestError(MsgImpersonateSelf)
+	}
+
+	target, err := s.userRepo.GetByID(ctx, input.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewNotFoundError("User not found", err)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	permissions, err := s.rbac.Permissions(ctx, target.Role)
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range permissions {
+		if !principal.HasPermission(permission) {
+			logging.FromContext(ctx).Warn("impersonation of a more privileged user",
+				"user_id", principal.UserID,
+				"target_user_id", target.ID,
+				"target_role", target.Role,
+				"permission", permission,
+			)
+			return nil, pkgerrors.NewForbiddenError()
+		}
+	}
+
+	token, err := s.tokenManager.GenerateImpersonationToken(target.ID, target.Role, principal.UserID)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	logging.FromContext(ctx).Info("impersonation started",
+		slog.Uint64("actor_id", uint64(principal.UserID)),
+		slog.Uint64("user_id", uint64(target.ID)),
+		slog.String("jti", token.Meta.JTI.String()),
+		slog.Time("expires_at", token.Meta.ExpiresAt),
+	)
+
+	return &ImpersonationOutput{
+		AccessToken:          token.Token,
+		AccessTokenExpiresAt: token.Meta.ExpiresAt,
+	}, nil
+}
+
+// endImpersonation revokes the session of an impersonation token. It has no refresh token, so unlike a
+// login session the denylist is its only record and failing to write it fails the logout.
+func (s *service) endImpersonation(ctx context.Context, principal *identity.Principal) error {
+	if s.denylist == nil {
+		return pkgerrors.NewInternalError(errNoDenylist)
+	}
+
+	if err := s.denylist.Revoke(ctx, *principal.SessionID, s.authCfg.ImpersonationTTL); err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	logging.FromContext(ctx).Info("impersonation ended",
+		slog.Uint64("actor_id", uint64(principal.ActorID)),
+		slog.Uint64("user_id", uint64(principal.UserID)),
+		slog.String("jti", principal.SessionID.String()),
+	)
+
+	return nil
+}
+
+// refuseImpersonated bars impersonated principals from operations only the user may perform.
+func refuseImpersonated(ctx context.Context, principal *identity.Principal, action string) error {
+	if !principal.Impersonated() {
+		return nil
+	}
+
+	logging.FromContext(ctx).Warn("sensitive operation refused while impersonating", "action", action)
+	return pkgerrors.NewForbiddenError()
+}
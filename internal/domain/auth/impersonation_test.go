@@ -0,0 +1,178 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/jwt"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestService_Impersonate(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(15 * time.Minute)
+	target := &user.User{ID: 2, Role: identity.RoleModerator}
+
+	adminPrincipal := func() *identity.Principal {
+		return &identity.Principal{
+			UserID: 1,
+			Role:   identity.RoleAdmin,
+			Permissions: []identity.Permission{
+				identity.PermUsersImpersonate,
+				identity.PermUsersRead,
+				identity.PermSessionsRead,
+			},
+			Source: identity.AuthExternal,
+		}
+	}
+
+	withPrincipal := func(modify func(p *identity.Principal)) func(ctx context.Context) context.Context {
+		return func(ctx context.Context) context.Context {
+			principal := adminPrincipal()
+			modify(principal)
+			return identity.WithPrincipal(ctx, principal)
+		}
+	}
+
+	assertStatus := func(status int) func(t *testing.T, err error) {
+		return func(t *testing.T, err error) {
+			var appErr *pkgerrors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, status, appErr.StatusCode)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		input      auth.ImpersonateInput
+		setupCtx   func(ctx context.Context) context.Context
+		setupMocks func(userRepo *mocks.MockUserRepository, rbac *mocks.MockRBACService, jwtManager *mocks.MockJwtManager)
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name:      "unauthenticated",
+			input:     auth.ImpersonateInput{UserID: 2},
+			setupCtx:  func(ctx context.Context) context.Context { return ctx },
+			assertErr: assertStatus(http.StatusUnauthorized),
+		},
+		{
+			name:      "without permission",
+			input:     auth.ImpersonateInput{UserID: 2},
+			setupCtx:  withPrincipal(func(p *identity.Principal) { p.Permissions = nil }),
+			assertErr: assertStatus(http.StatusForbidden),
+		},
+		{
+			name:      "from an api key",
+			input:     auth.ImpersonateInput{UserID: 2},
+			setupCtx:  withPrincipal(func(p *identity.Principal) { p.Source = identity.AuthAPIKey }),
+			assertErr: assertStatus(http.StatusForbidden),
+		},
+		{
+			name:      "while impersonating",
+			input:     auth.ImpersonateInput{UserID: 2},
+			setupCtx:  withPrincipal(func(p *identity.Principal) { p.ActorID = 3 }),
+			assertErr: assertStatus(http.StatusForbidden),
+		},
+		{
+			name:      "self",
+			input:     auth.ImpersonateInput{UserID: 1},
+			setupCtx:  withPrincipal(func(p *identity.Principal) {}),
+			assertErr: assertStatus(http.StatusBadRequest),
+		},
+		{
+			name:     "unknown user",
+			input:    auth.ImpersonateInput{UserID: 2},
+			setupCtx: withPrincipal(func(p *identity.Principal) {}),
+			setupMocks: func(userRepo *mocks.MockUserRepository, rbac *mocks.MockRBACService, jwtManager *mocks.MockJwtManager) {
+				userRepo.On("GetByID", mock.Anything, uint(2)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			assertErr: assertStatus(http.StatusNotFound),
+		},
+		{
+			name:     "more privileged user",
+			input:    auth.ImpersonateInput{UserID: 2},
+			setupCtx: withPrincipal(func(p *identity.Principal) {}),
+			setupMocks: func(userRepo *mocks.MockUserRepository, rbac *mocks.MockRBACService, jwtManager *mocks.MockJwtManager) {
+				userRepo.On("GetByID", mock.Anything, uint(2)).Return(target, nil)
+				rbac.On("Permissions", mock.Anything, identity.RoleModerator).
+					Return([]identity.Permission{identity.PermUsersRead, identity.PermSessionsRevoke}, nil)
+			},
+			assertErr: assertStatus(http.StatusForbidden),
+		},
+		{
+			name:     "token error",
+			input:    auth.ImpersonateInput{UserID: 2},
+			setupCtx: withPrincipal(func(p *identity.Principal) {}),
+			setupMocks: func(userRepo *mocks.MockUserRepository, rbac *mocks.MockRBACService, jwtManager *mocks.MockJwtManager) {
+				userRepo.On("GetByID", mock.Anything, uint(2)).Return(target, nil)
+				rbac.On("Permissions", mock.Anything, identity.RoleModerator).
+					Return([]identity.Permission{identity.PermUsersRead}, nil)
+				jwtManager.On("GenerateImpersonationToken", uint(2), identity.RoleModerator, uint(1)).
+					Return(nil, errors.New("signing error"))
+			},
+			assertErr: assertStatus(http.StatusInternalServerError),
+		},
+		{
+			name:     "success",
+			input:    auth.ImpersonateInput{UserID: 2},
+			setupCtx: withPrincipal(func(p *identity.Principal) {}),
+			setupMocks: func(userRepo *mocks.MockUserRepository, rbac *mocks.MockRBACService, jwtManager *mocks.MockJwtManager) {
+				userRepo.On("GetByID", mock.Anything, uint(2)).Return(target, nil)
+				rbac.On("Permissions", mock.Anything, identity.RoleModerator).
+					Return([]identity.Permission{identity.PermUsersRead, identity.PermSessionsRead}, nil)
+				jwtManager.On("GenerateImpersonationToken", uint(2), identity.RoleModerator, uint(1)).
+					Return(&jwt.AccessTokenResult{Token: "impersonation", Meta: jwt.TokenMetadata{ExpiresAt: expiresAt}}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userRepo := &mocks.MockUserRepository{}
+			rbac := &mocks.MockRBACService{}
+			jwtManager := &mocks.MockJwtManager{}
+			if tt.setupMocks != nil {
+				tt.setupMocks(userRepo, rbac, jwtManager)
+			}
+
+			svc := auth.NewService(&auth.ServiceDeps{
+				Logger:       slog.Default(),
+				RBAC:         rbac,
+				TokenManager: jwtManager,
+				UserRepo:     userRepo,
+			})
+
+			output, err := svc.Impersonate(tt.setupCtx(t.Context()), tt.input)
+
+			if tt.assertErr != nil {
+				tt.assertErr(t, err)
+				assert.Nil(t, output)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &auth.ImpersonationOutput{
+					AccessToken:          "impersonation",
+					AccessTokenExpiresAt: expiresAt,
+				}, output)
+			}
+
+			userRepo.AssertExpectations(t)
+			rbac.AssertExpectations(t)
+			jwtManager.AssertExpectations(t)
+		})
+	}
+}
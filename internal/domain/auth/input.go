@@ -1,10 +1,111 @@
 package auth
 
+import (
+	"gomonitor/internal/pkg/webauthn"
+
+	"github.com/google/uuid"
+)
+
+// ClientInfo describes the client a session was started or refreshed from.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+	// DPoPJKT is the thumbprint of the DPoP key the client proved, empty without a proof.
+	DPoPJKT string
+}
+
 type LoginInput struct {
 	Email    string
 	Password string
+	Client   ClientInfo
+}
+
+type VerifyMFAInput struct {
+	ChallengeToken string
+	Code           string
+	Client         ClientInfo
+}
+
+// PasskeyLoginOptionsInput starts a passkey login, as a second factor when ChallengeToken is set.
+type PasskeyLoginOptionsInput struct {
+	ChallengeToken string
+}
+
+// PasskeyLoginInput answers the request options of StartPasskeyLogin.
+type PasskeyLoginInput struct {
+	Response *webauthn.AssertionResponse
+	Client   ClientInfo
 }
 
 type RefreshInput struct {
 	RefreshToken string
+	Client       ClientInfo
+}
+
+type LogoutInput struct {
+	Client ClientInfo
+}
+
+// ListSessionsInput targets the caller's sessions when UserID is nil.
+type ListSessionsInput struct {
+	UserID *uint
+}
+
+// RevokeSessionInput targets the caller's sessions when UserID is nil.
+type RevokeSessionInput struct {
+	UserID *uint
+	JTI    uuid.UUID
+}
+
+type ForgotPasswordInput struct {
+	Email string
+}
+
+type ResetPasswordInput struct {
+	Token    string
+	Password string
+}
+
+type MagicLinkInput struct {
+	Email string
+}
+
+type ConsumeMagicLinkInput struct {
+	Token  string
+	Client ClientInfo
+}
+
+// ChangePasswordInput changes the caller's password, the other sessions are revoked unless KeepOtherSessions.
+type ChangePasswordInput struct {
+	CurrentPassword   string
+	NewPassword       string
+	KeepOtherSessions bool
+	Client            ClientInfo
+}
+
+// OIDCCallbackInput is the redirect back from the provider, along with the flow started by StartOIDCLogin.
+type OIDCCallbackInput struct {
+	Code  string
+	State string
+	// Error is set by the provider when the user wasn't authenticated.
+	Error  string
+	Flow   string
+	Client ClientInfo
+}
+
+type ImpersonateInput struct {
+	UserID uint
+}
+
+// ReauthenticateInput proves the caller's credentials again, Code is required when TOTP is enabled.
+type ReauthenticateInput struct {
+	Password string
+	Code     string
+	Client   ClientInfo
+}
+
+// PasskeyReauthenticateInput answers the request options of StartPasskeyReauthentication.
+type PasskeyReauthenticateInput struct {
+	Response *webauthn.AssertionResponse
+	Client   ClientInfo
 }
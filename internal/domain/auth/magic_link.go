@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/mailer"
+	"gomonitor/internal/pkg/opaquetoken"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// RequestMagicLink mails a passwordless login link if the email belongs to a user. Like Login with the
+// fake hash, a token is signed for unknown emails as well and the mail is sent in the background, so the
+// response doesn't reveal which accounts exist.
+func (s *service) RequestMagicLink(ctx context.Context, input MagicLinkInput) error {
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	known := err == nil && user != nil
+
+	var (
+		userID uint
+		role   identity.UserRole
+	)
+	if known {
+		userID, role = user.ID, user.Role
+	}
+
+	token, err := s.tokenManager.GenerateMagicLinkToken(userID, role, nil)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	if !known {
+		logging.FromContext(ctx).Info("magic link requested for unknown email", slog.Any("email", input.Email))
+		return nil
+	}
+
+	link, err := opaquetoken.Link(s.authCfg.MagicLinkURL, token.Token)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf(
+			"A login link was requested for your account.\n\n"+
+				"Follow this link to log in, it can be used once and expires in %s:\n%s\n\n"+
+				"If you didn't request it, you can ignore this email.\n",
+			s.authCfg.MagicLinkTTL, link,
+		),
+	}
+
+	go s.sendMail(context.WithoutCancel(ctx), msg)
+
+	logging.FromContext(ctx).Info("magic link sent", slog.Uint64("user_id", uint64(user.ID)))
+
+	return nil
+}
+
+// ConsumeMagicLink exchanges a link from RequestMagicLink for a token pair, or for an MFA challenge when
+// the user has a second factor. The link is single use, its jti is recorded as used until it expires.
+// A link completing a stepped-up login keeps the password in the amr of the session.
+func (s *service) ConsumeMagicLink(ctx context.Context, input ConsumeMagicLinkInput) (*LoginOutput, error) {
+	claims, err := s.tokenManager.ValidateMagicLinkToken(input.Token)
+	if err != nil || claims.JTI == nil || claims.UserID == 0 {
+		logging.FromContext(ctx).Warn("invalid magic link")
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidMagicLink)
+	}
+
+	if err := s.useMagicLink(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewUnauthorizedError(MsgInvalidMagicLink)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	if s.authCfg.RequireVerifiedEmail && !user.EmailVerified() {
+		logging.FromContext(ctx).Warn("login with unverified email", slog.Uint64("user_id", uint64(user.ID)))
+		s.recordEvent(ctx, authevent.TypeLoginFailure, user.ID, input.Client, authevent.ReasonEmailNotVerified)
+		return nil, pkgerrors.NewUnauthorizedError(MsgEmailNotVerified)
+	}
+
+	return s.completeLogin(ctx, user, input.Client, append(claims.AMR, AMREmail))
+}
+
+// useMagicLink records a consumed link as used until it expires. Like useMFAChallenge the check and the
+// record are atomic, and the link is refused when it can't be used only once.
+func (s *service) useMagicLink(ctx context.Context, claims *identity.Principal) error {
+	if s.usedTokens == nil {
+		return pkgerrors.NewInternalError(errUsedTokensStore)
+	}
+
+	fresh, err := s.usedTokens.Use(ctx, *claims.JTI, s.authCfg.MagicLinkTTL)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+	if !fresh {
+		logging.FromContext(ctx).Warn("magic link reused", slog.Uint64("user_id", uint64(claims.UserID)))
+		return pkgerrors.NewUnauthorizedError(MsgInvalidMagicLink)
+	}
+
+	return nil
+}
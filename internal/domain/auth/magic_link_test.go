@@ -0,0 +1,342 @@
+package auth_test
+
+import (
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/mailer"
+	"gomonitor/internal/pkg/revocation"
+	"gomonitor/internal/testutil"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type magicLinkMocks struct {
+	usedTokens        *mocks.MockUsedTokens
+	withoutUsedTokens bool
+	jwtManager        *mocks.MockJwtManager
+	mailer            *mocks.MockMailer
+	mfa               *mocks.MockMFAService
+	refreshTokenRepo  *mocks.MockRefreshTokenRepository
+	userRepo          *mocks.MockUserRepository
+}
+
+func newMagicLinkMocks() *magicLinkMocks {
+	return &magicLinkMocks{
+		usedTokens:       &mocks.MockUsedTokens{},
+		jwtManager:       &mocks.MockJwtManager{},
+		mailer:           &mocks.MockMailer{},
+		mfa:              &mocks.MockMFAService{},
+		refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+		userRepo:         &mocks.MockUserRepository{},
+	}
+}
+
+func (m *magicLinkMocks) service(authCfg *config.AuthConfig) auth.Service {
+	var usedTokens revocation.UsedTokens = m.usedTokens
+	if m.withoutUsedTokens {
+		usedTokens = nil
+	}
+
+	return auth.NewService(&auth.ServiceDeps{
+		AuthConfig:       authCfg,
+		UsedTokens:       usedTokens,
+		Logger:           slog.Default(),
+		Mailer:           m.mailer,
+		MFA:              m.mfa,
+		RefreshTokenRepo: m.refreshTokenRepo,
+		TokenManager:     m.jwtManager,
+		UserRepo:         m.userRepo,
+	})
+}
+
+func (m *magicLinkMocks) assertExpectations(t *testing.T) {
+	m.usedTokens.AssertExpectations(t)
+	m.jwtManager.AssertExpectations(t)
+	m.mailer.AssertExpectations(t)
+	m.mfa.AssertExpectations(t)
+	m.refreshTokenRepo.AssertExpectations(t)
+	m.userRepo.AssertExpectations(t)
+}
+
+var magicLinkConfig = &config.AuthConfig{
+	MagicLinkTTL: 15 * time.Minute,
+	MagicLinkURL: "https://example.com/magic-link?lang=en",
+}
+
+func TestService_RequestMagicLink(t *testing.T) {
+	t.Parallel()
+
+	usr := &user.User{ID: 1, Email: "user@test.com", Role: identity.RoleUser}
+	link := &jwt.MagicLinkTokenResult{Token: "signed"}
+
+	tests := []struct {
+		name       string
+		setupMocks func(m *magicLinkMocks, sent chan mailer.Message)
+		expectMail bool
+		status     int
+	}{
+		{
+			name: "unknown email signs a token all the same",
+			setupMocks: func(m *magicLinkMocks, sent chan mailer.Message) {
+				m.userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(testutil.Err[*user.User](gorm.ErrRecordNotFound))
+				m.jwtManager.On("GenerateMagicLinkToken", uint(0), identity.UserRole(""), []string(nil)).Return(link, nil)
+			},
+		},
+		{
+			name: "db error",
+			setupMocks: func(m *magicLinkMocks, sent chan mailer.Message) {
+				m.userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(testutil.Err[*user.User](errors.New("db error")))
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name: "signing error",
+			setupMocks: func(m *magicLinkMocks, sent chan mailer.Message) {
+				m.userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(usr, nil)
+				m.jwtManager.On("GenerateMagicLinkToken", usr.ID, usr.Role, []string(nil)).Return(nil, errors.New("no key"))
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name: "mailing errors are only logged",
+			setupMocks: func(m *magicLinkMocks, sent chan mailer.Message) {
+				m.userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(usr, nil)
+				m.jwtManager.On("GenerateMagicLinkToken", usr.ID, usr.Role, []string(nil)).Return(link, nil)
+				m.mailer.On("Send", mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) { sent <- args.Get(1).(mailer.Message) }).
+					Return(errors.New("smtp error"))
+			},
+			expectMail: true,
+		},
+		{
+			name: "success",
+			setupMocks: func(m *magicLinkMocks, sent chan mailer.Message) {
+				m.userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(usr, nil)
+				m.jwtManager.On("GenerateMagicLinkToken", usr.ID, usr.Role, []string(nil)).Return(link, nil)
+				m.mailer.On("Send", mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) { sent <- args.Get(1).(mailer.Message) }).
+					Return(nil)
+			},
+			expectMail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMagicLinkMocks()
+			sent := make(chan mailer.Message, 1)
+			tt.setupMocks(m, sent)
+
+			err := m.service(magicLinkConfig).RequestMagicLink(t.Context(), auth.MagicLinkInput{Email: usr.Email})
+
+			if tt.status != 0 {
+				assertAppError(t, err, tt.status, "An unexpected error occurred")
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tt.expectMail {
+				select {
+				case msg := <-sent:
+					assert.Equal(t, usr.Email, msg.To)
+					assert.Contains(t, msg.Body, "https://example.com/magic-link?lang=en&token=signed\n")
+					assert.Contains(t, msg.Body, "15m0s")
+				case <-time.After(time.Second):
+					t.Fatal("magic link not sent")
+				}
+			}
+
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestService_ConsumeMagicLink(t *testing.T) {
+	t.Parallel()
+
+	jti := uuid.New()
+	verifiedAt := time.Now()
+	usr := &user.User{ID: 1, Role: identity.RoleUser, EmailVerifiedAt: &verifiedAt}
+	claims := &identity.Principal{UserID: 1, Role: identity.RoleUser, JTI: &jti}
+	stepUpClaims := &identity.Principal{UserID: 1, Role: identity.RoleUser, JTI: &jti, AMR: []string{auth.AMRPassword}}
+	ttl := magicLinkConfig.MagicLinkTTL
+
+	tests := []struct {
+		name              string
+		authCfg           *config.AuthConfig
+		withoutUsedTokens bool
+		setupMocks        func(m *magicLinkMocks)
+		status            int
+		message           string
+		challenged        bool
+	}{
+		{
+			name: "invalid token",
+			setupMocks: func(m *magicLinkMocks) {
+				m.jwtManager.On("ValidateMagicLinkToken", "link").Return(nil, jwt.ErrInvalidToken)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidMagicLink,
+		},
+		{
+			name: "token of an unknown email",
+			setupMocks: func(m *magicLinkMocks) {
+				m.jwtManager.On("ValidateMagicLinkToken", "link").Return(&identity.Principal{JTI: &jti}, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidMagicLink,
+		},
+		{
+			name: "already used",
+			setupMocks: func(m *magicLinkMocks) {
+				m.jwtManager.On("ValidateMagicLinkToken", "link").Return(claims, nil)
+				m.usedTokens.On("Use", mock.Anything, jti, ttl).Return(false, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidMagicLink,
+		},
+		{
+			name:              "no store to consume the link",
+			withoutUsedTokens: true,
+			setupMocks: func(m *magicLinkMocks) {
+				m.jwtManager.On("ValidateMagicLinkToken", "link").Return(claims, nil)
+			},
+			status:  http.StatusInternalServerError,
+			message: "An unexpected error occurred",
+		},
+		{
+			name: "redis unavailable",
+			setupMocks: func(m *magicLinkMocks) {
+				m.jwtManager.On("ValidateMagicLinkToken", "link").Return(claims, nil)
+				m.usedTokens.On("Use", mock.Anything, jti, ttl).Return(false, errors.New("redis down"))
+			},
+			status:  http.StatusInternalServerError,
+			message: "An unexpected error occurred",
+		},
+		{
+			name: "deleted user",
+			setupMocks: func(m *magicLinkMocks) {
+				m.jwtManager.On("ValidateMagicLinkToken", "link").Return(claims, nil)
+				m.usedTokens.On("Use", mock.Anything, jti, ttl).Return(true, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(testutil.Err[*user.User](gorm.ErrRecordNotFound))
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidMagicLink,
+		},
+		{
+			name:    "unverified email",
+			authCfg: &config.AuthConfig{MagicLinkTTL: ttl, RequireVerifiedEmail: true},
+			setupMocks: func(m *magicLinkMocks) {
+				m.jwtManager.On("ValidateMagicLinkToken", "link").Return(claims, nil)
+				m.usedTokens.On("Use", mock.Anything, jti, ttl).Return(true, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(&user.User{ID: 1}, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgEmailNotVerified,
+		},
+		{
+			name: "second factor required",
+			setupMocks: func(m *magicLinkMocks) {
+				m.jwtManager.On("ValidateMagicLinkToken", "link").Return(claims, nil)
+				m.usedTokens.On("Use", mock.Anything, jti, ttl).Return(true, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(usr, nil)
+				m.mfa.On("IsEnabled", mock.Anything, uint(1)).Return(true, nil)
+				m.jwtManager.On("GenerateMFAToken", uint(1), identity.RoleUser).
+					Return(&jwt.MFATokenResult{Token: "challenge"}, nil)
+			},
+			challenged: true,
+		},
+		{
+			name: "success",
+			setupMocks: func(m *magicLinkMocks) {
+				m.jwtManager.On("ValidateMagicLinkToken", "link").Return(claims, nil)
+				m.usedTokens.On("Use", mock.Anything, jti, ttl).Return(true, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(usr, nil)
+				m.mfa.On("IsEnabled", mock.Anything, uint(1)).Return(false, nil)
+				expectSession(m.jwtManager, m.refreshTokenRepo, usr, auth.AMREmail)
+			},
+		},
+		{
+			name: "stepped-up login keeps the password",
+			setupMocks: func(m *magicLinkMocks) {
+				m.jwtManager.On("ValidateMagicLinkToken", "link").Return(stepUpClaims, nil)
+				m.usedTokens.On("Use", mock.Anything, jti, ttl).Return(true, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(usr, nil)
+				m.mfa.On("IsEnabled", mock.Anything, uint(1)).Return(false, nil)
+				expectSession(m.jwtManager, m.refreshTokenRepo, usr, "pwd email")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMagicLinkMocks()
+			m.withoutUsedTokens = tt.withoutUsedTokens
+			tt.setupMocks(m)
+
+			authCfg := tt.authCfg
+			if authCfg == nil {
+				authCfg = magicLinkConfig
+			}
+
+			out, err := m.service(authCfg).ConsumeMagicLink(t.Context(), auth.ConsumeMagicLinkInput{Token: "link"})
+
+			switch {
+			case tt.status != 0:
+				assertAppError(t, err, tt.status, tt.message)
+				assert.Nil(t, out)
+			case tt.challenged:
+				require.NoError(t, err)
+				require.NotNil(t, out.MFAChallenge)
+				assert.Equal(t, "challenge", out.MFAChallenge.Token)
+				assert.Empty(t, out.AccessToken)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, "access", out.AccessToken)
+				assert.Equal(t, "refresh", out.RefreshToken)
+			}
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestService_ConsumeMagicLinkTwice(t *testing.T) {
+	t.Parallel()
+
+	jti := uuid.New()
+	verifiedAt := time.Now()
+	usr := &user.User{ID: 1, Role: identity.RoleUser, EmailVerifiedAt: &verifiedAt}
+	claims := &identity.Principal{UserID: 1, Role: identity.RoleUser, JTI: &jti}
+
+	m := newMagicLinkMocks()
+	m.jwtManager.On("ValidateMagicLinkToken", "link").Return(claims, nil)
+	m.usedTokens.On("Use", mock.Anything, jti, magicLinkConfig.MagicLinkTTL).Return(true, nil).Once()
+	m.usedTokens.On("Use", mock.Anything, jti, magicLinkConfig.MagicLinkTTL).Return(false, nil).Once()
+	m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(usr, nil).Once()
+	m.mfa.On("IsEnabled", mock.Anything, uint(1)).Return(false, nil).Once()
+	expectSession(m.jwtManager, m.refreshTokenRepo, usr, auth.AMREmail)
+
+	service := m.service(magicLinkConfig)
+
+	out, err := service.ConsumeMagicLink(t.Context(), auth.ConsumeMagicLinkInput{Token: "link"})
+	require.NoError(t, err)
+	assert.Equal(t, "access", out.AccessToken)
+
+	out, err = service.ConsumeMagicLink(t.Context(), auth.ConsumeMagicLinkInput{Token: "link"})
+	assertAppError(t, err, http.StatusUnauthorized, auth.MsgInvalidMagicLink)
+	assert.Nil(t, out)
+	m.assertExpectations(t)
+}
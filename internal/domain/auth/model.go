@@ -1,15 +1,44 @@
 package auth
 
 import (
+	"gomonitor/internal/pkg/jwt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// RefreshToken is the current token of a session, each rotation replaces it within the same family.
+// CreatedAt is when the session started and is kept on rotation, LastUsedAt is the last login or refresh.
+// JKT binds the session to the DPoP key of the client, every rotation must prove it again.
+// AuthTime and AMR (space separated methods) are the last authentication of the session, kept on rotation.
 type RefreshToken struct {
-	JTI       uuid.UUID `gorm:"type:uuid;primaryKey;column:jti"`
-	UserID    uint      `gorm:"index;column:user_id"`
+	JTI        uuid.UUID  `gorm:"type:uuid;primaryKey;column:jti"`
+	UserID     uint       `gorm:"index;column:user_id"`
+	FamilyID   uuid.UUID  `gorm:"type:uuid;index;column:family_id"`
+	ParentJTI  *uuid.UUID `gorm:"type:uuid;column:parent_jti"`
+	UserAgent  string
+	IPAddress  string    `gorm:"column:ip_address"`
+	JKT        string    `gorm:"column:jkt"`
+	AuthTime   time.Time `gorm:"column:auth_time"`
+	AMR        string    `gorm:"column:amr"`
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  *time.Time
+}
+
+// Authentication is carried by the access tokens of the session.
+func (t *RefreshToken) Authentication() jwt.Authentication {
+	return jwt.Authentication{Time: t.AuthTime, Methods: strings.Fields(t.AMR)}
+}
+
+// PasswordResetToken only stores the hash of the token, which is sent to the user by mail.
+type PasswordResetToken struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"index;column:user_id"`
+	TokenHash string `gorm:"type:char(64);uniqueIndex;column:token_hash"`
 	ExpiresAt time.Time
+	UsedAt    *time.Time
 	CreatedAt time.Time
-	RevokedAt *time.Time
 }
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/mailer"
+	"gomonitor/internal/pkg/opaquetoken"
+	"log/slog"
+	"net/netip"
+	"regexp"
+	"time"
+)
+
+// loginHistorySize bounds the successful logins a login is compared with.
+const loginHistorySize = 200
+
+// userAgentVersion matches the versions in user agents, browser updates don't make a new device.
+var userAgentVersion = regexp.MustCompile(`[0-9]+`)
+
+// LoginDecision is how an unusual login proceeds.
+type LoginDecision int
+
+const (
+	// LoginAllow issues the tokens, or the MFA challenge, like for any login.
+	LoginAllow LoginDecision = iota
+	// LoginStepUp requires the second factor, users without one are mailed a login link instead of the tokens.
+	LoginStepUp
+)
+
+// UnusualLogin is a password login from a device or a network the user didn't log in from recently.
+type UnusualLogin struct {
+	User       *user.User
+	Client     ClientInfo
+	NewDevice  bool
+	NewNetwork bool
+}
+
+// LoginPolicy decides whether unusual logins need step-up verification.
+type LoginPolicy interface {
+	Evaluate(ctx context.Context, login UnusualLogin) LoginDecision
+}
+
+// LoginPolicyFunc adapts a function to a LoginPolicy.
+type LoginPolicyFunc func(ctx context.Context, login UnusualLogin) LoginDecision
+
+func (f LoginPolicyFunc) Evaluate(ctx context.Context, login UnusualLogin) LoginDecision {
+	return f(ctx, login)
+}
+
+// StepUpUnusualLogins requires step-up verification for every unusual login.
+var StepUpUnusualLogins LoginPolicy = LoginPolicyFunc(func(context.Context, UnusualLogin) LoginDecision {
+	return LoginStepUp
+})
+
+// unusualLogin compares the login with the recent successful logins of the user, by user agent and network.
+// It returns nil for users who never logged in, they have no device yet, and when the history can't be read.
+func (s *service) unusualLogin(ctx context.Context, user *user.User, client ClientInfo) *UnusualLogin {
+	if s.loginHistory == nil || s.eventsCfg == nil || user.LastLoginAt == nil {
+		return nil
+	}
+
+	from := time.Now().Add(-s.eventsCfg.NewDeviceLookback)
+	logins, err := s.loginHistory.List(ctx, authevent.Filter{
+		UserID: &user.ID,
+		Type:   authevent.TypeLoginSuccess,
+		From:   &from,
+		Limit:  loginHistorySize,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to read the login history", slog.Uint64("user_id", uint64(user.ID)), slog.Any("err", err))
+		return nil
+	}
+
+	device := deviceOf(client.UserAgent)
+	network := networkOf(client.IPAddress)
+
+	// Without a valid address the network can't be told apart.
+	login := &UnusualLogin{User: user, Client: client, NewDevice: true, NewNetwork: network != ""}
+	for _, event := range logins {
+		if deviceOf(event.UserAgent) == device {
+			login.NewDevice = false
+		}
+		if network != "" && networkOf(event.IPAddress) == network {
+			login.NewNetwork = false
+		}
+	}
+
+	if !login.NewDevice && !login.NewNetwork {
+		return nil
+	}
+
+	return login
+}
+
+// completeUnusualLogin applies the login policy. Allowed logins are notified to the user, like the ones
+// stepped up with the second factor. Users without one are mailed a login link instead of the tokens.
+func (s *service) completeUnusualLogin(ctx context.Context, login *UnusualLogin) (*LoginOutput, error) {
+	logging.FromContext(ctx).Info(
+		"login from a new device",
+		slog.Uint64("user_id", uint64(login.User.ID)),
+		slog.Bool("new_device", login.NewDevice),
+		slog.Bool("new_network", login.NewNetwork),
+	)
+
+	if s.loginPolicy == nil || s.loginPolicy.Evaluate(ctx, *login) != LoginStepUp {
+		s.notifyNewDevice(ctx, login)
+		return s.completeLogin(ctx, login.User, login.Client, []string{AMRPassword})
+	}
+
+	methods, err := s.mfaMethods(ctx, login.User.ID)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	if len(methods) > 0 {
+		s.notifyNewDevice(ctx, login)
+		return s.completeLogin(ctx, login.User, login.Client, []string{AMRPassword})
+	}
+
+	if err := s.mailStepUpLink(ctx, login); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("login stepped up by mail", slog.Uint64("user_id", uint64(login.User.ID)))
+
+	return &LoginOutput{
+		StepUp: &StepUpOutput{Method: StepUpMethodEmail},
+	}, nil
+}
+
+// mailStepUpLink mails a login link, consumed on ConsumeMagicLink, to complete an unusual login.
+func (s *service) mailStepUpLink(ctx context.Context, login *UnusualLogin) error {
+	token, err := s.tokenManager.GenerateMagicLinkToken(login.User.ID, login.User.Role, []string{AMRPassword})
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	link, err := opaquetoken.Link(s.authCfg.MagicLinkURL, token.Token)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	msg := mailer.Message{
+		To:      login.User.Email,
+		Subject: "Confirm your login",
+		Body: fmt.Sprintf(
+			"Your password was used to log in from a new device or network:\n\n%s\n"+
+				"Follow this link to complete the login, it can be used once and expires in %s:\n%s\n\n"+
+				"If it wasn't you, change your password.\n",
+			loginDetails(login.Client), s.authCfg.MagicLinkTTL, link,
+		),
+	}
+
+	go s.sendMail(context.WithoutCancel(ctx), msg)
+
+	return nil
+}
+
+func (s *service) notifyNewDevice(ctx context.Context, login *UnusualLogin) {
+	if !s.eventsCfg.NewDeviceNotify {
+		return
+	}
+
+	msg := mailer.Message{
+		To:      login.User.Email,
+		Subject: "New login to your account",
+		Body: fmt.Sprintf(
+			"Your account was logged in from a new device or network:\n\n%s\n"+
+				"If it was you, you can ignore this email. Otherwise change your password and log out your other sessions.\n",
+			loginDetails(login.Client),
+		),
+	}
+
+	go s.sendMail(context.WithoutCancel(ctx), msg)
+}
+
+func loginDetails(client ClientInfo) string {
+	return fmt.Sprintf(
+		"Time: %s\nIP address: %s\nDevice: %s\n",
+		time.Now().UTC().Format(time.RFC1123), client.IPAddress, client.UserAgent,
+	)
+}
+
+// deviceOf fingerprints a user agent without its versions.
+func deviceOf(userAgent string) string {
+	return userAgentVersion.ReplaceAllString(userAgent, "")
+}
+
+// networkOf returns the /24 of IPv4 addresses and the /48 of IPv6 ones, or "" for invalid addresses.
+func networkOf(ipAddress string) string {
+	addr, err := netip.ParseAddr(ipAddress)
+	if err != nil {
+		return ""
+	}
+
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+
+	return prefix.String()
+}
@@ -0,0 +1,285 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/mailer"
+	"gomonitor/internal/testutil"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type newDeviceMocks struct {
+	hasher           *mocks.MockPasswordHasher
+	history          *mocks.MockAuthEventRepository
+	jwtManager       *mocks.MockJwtManager
+	mailer           *mocks.MockMailer
+	mfa              *mocks.MockMFAService
+	refreshTokenRepo *mocks.MockRefreshTokenRepository
+	userRepo         *mocks.MockUserRepository
+}
+
+func (m *newDeviceMocks) assertExpectations(t *testing.T) {
+	m.hasher.AssertExpectations(t)
+	m.history.AssertExpectations(t)
+	m.jwtManager.AssertExpectations(t)
+	m.mailer.AssertExpectations(t)
+	m.mfa.AssertExpectations(t)
+	m.refreshTokenRepo.AssertExpectations(t)
+	m.userRepo.AssertExpectations(t)
+}
+
+func TestService_LoginNewDevice(t *testing.T) {
+	t.Parallel()
+
+	lastLogin := time.Now().Add(-time.Hour)
+	client := auth.ClientInfo{IPAddress: "192.0.2.10", UserAgent: "Mozilla/5.0 Firefox/131.0"}
+	input := auth.LoginInput{Email: "user@test.com", Password: "password123", Client: client}
+
+	returningUser := &user.User{ID: 1, Email: "user@test.com", Password: "hash", Role: identity.RoleUser, LastLoginAt: &lastLogin}
+	firstUser := &user.User{ID: 1, Email: "user@test.com", Password: "hash", Role: identity.RoleUser}
+
+	historyFilter := mock.MatchedBy(func(filter authevent.Filter) bool {
+		return filter.UserID != nil && *filter.UserID == 1 &&
+			filter.Type == authevent.TypeLoginSuccess &&
+			filter.From != nil && time.Since(*filter.From) >= 30*24*time.Hour &&
+			filter.Limit > 0
+	})
+
+	// An older version of the browser, from the same network.
+	knownLogin := authevent.Event{Type: authevent.TypeLoginSuccess, IPAddress: "192.0.2.200", UserAgent: "Mozilla/5.0 Firefox/130.0"}
+	otherNetwork := authevent.Event{Type: authevent.TypeLoginSuccess, IPAddress: "198.51.100.1", UserAgent: "Mozilla/5.0 Firefox/130.0"}
+	otherDevice := authevent.Event{Type: authevent.TypeLoginSuccess, IPAddress: "192.0.2.1", UserAgent: "curl/8.0"}
+
+	stepUp := auth.LoginPolicyFunc(func(_ context.Context, login auth.UnusualLogin) auth.LoginDecision {
+		if login.NewNetwork {
+			return auth.LoginStepUp
+		}
+		return auth.LoginAllow
+	})
+
+	expectPassword := func(m *newDeviceMocks, usr *user.User) {
+		m.userRepo.On("GetByEmail", mock.Anything, input.Email).Return(testutil.Ok(usr))
+		m.hasher.On("VerifyPassword", usr.Password, input.Password).Return(nil)
+		m.hasher.On("NeedsRehash", usr.Password).Return(false)
+	}
+
+	expectSession := func(m *newDeviceMocks) {
+		m.mfa.On("IsEnabled", mock.Anything, uint(1)).Return(false, nil)
+		m.jwtManager.On("GenerateRefreshToken", uint(1), identity.RoleUser).
+			Return(&jwt.RefreshTokenResult{Token: "refresh", Meta: jwt.TokenMetadata{JTI: uuid.New()}}, nil)
+		m.refreshTokenRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+		m.jwtManager.On("GenerateAccessToken", uint(1), identity.RoleUser, "").Return(&jwt.AccessTokenResult{Token: "access"}, nil)
+	}
+
+	expectMail := func(m *newDeviceMocks, sent chan mailer.Message) {
+		m.mailer.On("Send", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { sent <- args.Get(1).(mailer.Message) }).
+			Return(nil)
+	}
+
+	tests := []struct {
+		name       string
+		notify     bool
+		policy     auth.LoginPolicy
+		setupMocks func(m *newDeviceMocks, sent chan mailer.Message)
+		assertOut  func(t *testing.T, out *auth.LoginOutput)
+		mail       string
+	}{
+		{
+			name:   "first login has nothing to compare with",
+			notify: true,
+			policy: stepUp,
+			setupMocks: func(m *newDeviceMocks, sent chan mailer.Message) {
+				expectPassword(m, firstUser)
+				expectSession(m)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput) {
+				assert.Equal(t, "access", out.AccessToken)
+			},
+		},
+		{
+			name:   "known device and network",
+			notify: true,
+			policy: stepUp,
+			setupMocks: func(m *newDeviceMocks, sent chan mailer.Message) {
+				expectPassword(m, returningUser)
+				m.history.On("List", mock.Anything, historyFilter).Return([]authevent.Event{otherDevice, knownLogin}, nil)
+				expectSession(m)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput) {
+				assert.Equal(t, "access", out.AccessToken)
+			},
+		},
+		{
+			name:   "history errors let the login through",
+			notify: true,
+			policy: stepUp,
+			setupMocks: func(m *newDeviceMocks, sent chan mailer.Message) {
+				expectPassword(m, returningUser)
+				m.history.On("List", mock.Anything, historyFilter).Return(nil, errors.New("db down"))
+				expectSession(m)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput) {
+				assert.Equal(t, "access", out.AccessToken)
+			},
+		},
+		{
+			name:   "new device is notified",
+			notify: true,
+			policy: stepUp,
+			setupMocks: func(m *newDeviceMocks, sent chan mailer.Message) {
+				expectPassword(m, returningUser)
+				m.history.On("List", mock.Anything, historyFilter).Return([]authevent.Event{otherDevice}, nil)
+				expectSession(m)
+				expectMail(m, sent)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput) {
+				assert.Equal(t, "access", out.AccessToken)
+			},
+			mail: "New login to your account",
+		},
+		{
+			name: "notifications disabled",
+			setupMocks: func(m *newDeviceMocks, sent chan mailer.Message) {
+				expectPassword(m, returningUser)
+				m.history.On("List", mock.Anything, historyFilter).Return([]authevent.Event{otherDevice}, nil)
+				expectSession(m)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput) {
+				assert.Equal(t, "access", out.AccessToken)
+			},
+		},
+		{
+			name:   "new network without a policy is notified",
+			notify: true,
+			setupMocks: func(m *newDeviceMocks, sent chan mailer.Message) {
+				expectPassword(m, returningUser)
+				m.history.On("List", mock.Anything, historyFilter).Return(nil, nil)
+				expectSession(m)
+				expectMail(m, sent)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput) {
+				assert.Equal(t, "access", out.AccessToken)
+			},
+			mail: "New login to your account",
+		},
+		{
+			name:   "stepped up with the second factor",
+			notify: true,
+			policy: stepUp,
+			setupMocks: func(m *newDeviceMocks, sent chan mailer.Message) {
+				expectPassword(m, returningUser)
+				m.history.On("List", mock.Anything, historyFilter).Return([]authevent.Event{otherNetwork}, nil)
+				m.mfa.On("IsEnabled", mock.Anything, uint(1)).Return(true, nil)
+				m.jwtManager.On("GenerateMFAToken", uint(1), identity.RoleUser).Return(&jwt.MFATokenResult{Token: "challenge"}, nil)
+				expectMail(m, sent)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput) {
+				require.NotNil(t, out.MFAChallenge)
+				assert.Equal(t, "challenge", out.MFAChallenge.Token)
+				assert.Empty(t, out.AccessToken)
+			},
+			mail: "New login to your account",
+		},
+		{
+			name:   "stepped up by mail without a second factor",
+			notify: true,
+			policy: stepUp,
+			setupMocks: func(m *newDeviceMocks, sent chan mailer.Message) {
+				expectPassword(m, returningUser)
+				m.history.On("List", mock.Anything, historyFilter).Return([]authevent.Event{otherNetwork}, nil)
+				m.mfa.On("IsEnabled", mock.Anything, uint(1)).Return(false, nil)
+				m.jwtManager.On("GenerateMagicLinkToken", uint(1), identity.RoleUser, []string{auth.AMRPassword}).Return(&jwt.MagicLinkTokenResult{Token: "signed"}, nil)
+				expectMail(m, sent)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput) {
+				require.NotNil(t, out.StepUp)
+				assert.Equal(t, auth.StepUpMethodEmail, out.StepUp.Method)
+				assert.Empty(t, out.AccessToken)
+				assert.Empty(t, out.RefreshToken)
+			},
+			mail: "https://example.com/magic-link?lang=en&token=signed\n",
+		},
+		{
+			name:   "policy allows the new device",
+			notify: true,
+			policy: stepUp,
+			setupMocks: func(m *newDeviceMocks, sent chan mailer.Message) {
+				expectPassword(m, returningUser)
+				m.history.On("List", mock.Anything, historyFilter).Return([]authevent.Event{otherDevice}, nil)
+				expectSession(m)
+				expectMail(m, sent)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput) {
+				assert.Equal(t, "access", out.AccessToken)
+			},
+			mail: "New login to your account",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &newDeviceMocks{
+				hasher:           &mocks.MockPasswordHasher{},
+				history:          &mocks.MockAuthEventRepository{},
+				jwtManager:       &mocks.MockJwtManager{},
+				mailer:           &mocks.MockMailer{},
+				mfa:              &mocks.MockMFAService{},
+				refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+				userRepo:         &mocks.MockUserRepository{},
+			}
+			sent := make(chan mailer.Message, 1)
+			tt.setupMocks(m, sent)
+
+			service := auth.NewService(&auth.ServiceDeps{
+				AuthConfig: magicLinkConfig,
+				AuthEventsConfig: &config.AuthEventsConfig{
+					NewDeviceLookback: 30 * 24 * time.Hour,
+					NewDeviceNotify:   tt.notify,
+				},
+				Hasher:           m.hasher,
+				LoginHistory:     m.history,
+				LoginPolicy:      tt.policy,
+				Logger:           slog.Default(),
+				Mailer:           m.mailer,
+				MFA:              m.mfa,
+				RefreshTokenRepo: m.refreshTokenRepo,
+				TokenManager:     m.jwtManager,
+				UserRepo:         m.userRepo,
+			})
+
+			out, err := service.Login(t.Context(), input)
+
+			require.NoError(t, err)
+			tt.assertOut(t, out)
+
+			if tt.mail != "" {
+				select {
+				case msg := <-sent:
+					assert.Equal(t, returningUser.Email, msg.To)
+					assert.Contains(t, msg.Subject+"\n"+msg.Body, tt.mail)
+					assert.Contains(t, msg.Body, client.IPAddress)
+					assert.Contains(t, msg.Body, client.UserAgent)
+				case <-time.After(time.Second):
+					t.Fatal("mail not sent")
+				}
+			}
+
+			m.assertExpectations(t)
+		})
+	}
+}
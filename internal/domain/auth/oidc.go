@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/oidc"
+	"gomonitor/internal/pkg/opaquetoken"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// oidcFlow is kept by the browser between the redirect to the provider and the callback, encrypted
+// so the verifier and nonce stay secret.
+type oidcFlow struct {
+	State     string    `json:"state"`
+	Nonce     string    `json:"nonce"`
+	Verifier  string    `json:"verifier"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StartOIDCLogin prepares the authorization request, with a PKCE verifier, a nonce and a state.
+func (s *service) StartOIDCLogin(ctx context.Context) (*OIDCLoginOutput, error) {
+	if s.oidc == nil {
+		return nil, pkgerrors.NewNotFoundError(MsgOIDCDisabled)
+	}
+
+	var secrets [3]string
+	for i := range secrets {
+		secret, err := opaquetoken.New()
+		if err != nil {
+			return nil, pkgerrors.NewInternalError(err)
+		}
+		secrets[i] = secret
+	}
+
+	flow := oidcFlow{
+		State:     secrets[0],
+		Nonce:     secrets[1],
+		Verifier:  secrets[2],
+		ExpiresAt: time.Now().Add(s.oidcCfg.FlowTTL),
+	}
+
+	raw, err := json.Marshal(flow)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	encrypted, err := s.cipher.Encrypt(raw)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	authURL, err := s.oidc.AuthCodeURL(ctx, flow.State, flow.Nonce, oidc.CodeChallenge(flow.Verifier))
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return &OIDCLoginOutput{
+		AuthURL:   authURL,
+		Flow:      encrypted,
+		ExpiresAt: flow.ExpiresAt,
+	}, nil
+}
+
+// CompleteOIDCLogin logs in the user linked to the identity, linking it by verified email or
+// provisioning the user on first login when allowed.
+func (s *service) CompleteOIDCLogin(ctx context.Context, input OIDCCallbackInput) (*LoginOutput, error) {
+	if s.oidc == nil {
+		return nil, pkgerrors.NewNotFoundError(MsgOIDCDisabled)
+	}
+
+	flow, err := s.openOIDCFlow(input.Flow)
+	if err != nil {
+		return nil, err
+	}
+
+	// The state ties the callback to the browser holding the flow, rejecting forged callbacks.
+	if subtle.ConstantTimeCompare([]byte(flow.State), []byte(input.State)) != 1 {
+		return nil, pkgerrors.NewUnauthorizedError(MsgOIDCInvalidFlow)
+	}
+
+	if input.Error != "" || input.Code == "" {
+		logging.FromContext(ctx).Warn("oidc login refused by the provider", slog.String("error", input.Error))
+		return nil, pkgerrors.NewUnauthorizedError(MsgOIDCLoginFailed)
+	}
+
+	claims, err := s.oidc.Exchange(ctx, input.Code, flow.Verifier, flow.Nonce)
+	if err != nil {
+		logging.FromContext(ctx).Warn("oidc code exchange failed", slog.Any("error", err))
+		return nil, pkgerrors.NewUnauthorizedError(MsgOIDCLoginFailed, err)
+	}
+
+	u, err := s.resolveExternalUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(ctx, u, input.Client, []string{AMRFederated})
+}
+
+func (s *service) openOIDCFlow(encrypted string) (*oidcFlow, error) {
+	if encrypted == "" {
+		return nil, pkgerrors.NewUnauthorizedError(MsgOIDCInvalidFlow)
+	}
+
+	raw, err := s.cipher.Decrypt(encrypted)
+	if err != nil {
+		return nil, pkgerrors.NewUnauthorizedError(MsgOIDCInvalidFlow, err)
+	}
+
+	var flow oidcFlow
+	if err := json.Unmarshal(raw, &flow); err != nil {
+		return nil, pkgerrors.NewUnauthorizedError(MsgOIDCInvalidFlow, err)
+	}
+
+	if !time.Now().Before(flow.ExpiresAt) {
+		return nil, pkgerrors.NewUnauthorizedError(MsgOIDCInvalidFlow)
+	}
+
+	return &flow, nil
+}
+
+// resolveExternalUser finds the user of the identity. Unlinked identities are only linked or provisioned
+// with an email verified by the provider, otherwise anyone could claim the account of an email.
+func (s *service) resolveExternalUser(ctx context.Context, claims *oidc.Claims) (*user.User, error) {
+	link, err := s.identityRepo.GetBySubject(ctx, claims.Issuer, claims.Subject)
+	if err == nil {
+		u, err := s.userRepo.GetByID(ctx, link.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, pkgerrors.NewUnauthorizedError(MsgOIDCNotLinked)
+			}
+			return nil, pkgerrors.NewInternalError(err)
+		}
+		return u, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		logging.FromContext(ctx).Warn("oidc login without verified email", slog.String("subject", claims.Subject))
+		return nil, pkgerrors.NewUnauthorizedError(MsgOIDCNotLinked)
+	}
+
+	link = &user.ExternalIdentity{
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}
+
+	u, err := s.userRepo.GetByEmail(ctx, claims.Email)
+	if err == nil {
+		link.UserID = u.ID
+		if err := s.identityRepo.Create(ctx, link); err != nil {
+			return nil, pkgerrors.NewInternalError(err)
+		}
+
+		logging.FromContext(ctx).Info("oidc identity linked", slog.Uint64("user_id", uint64(u.ID)))
+		return u, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	if !s.oidcCfg.JITProvisioning {
+		logging.FromContext(ctx).Warn("oidc login of unknown user", slog.String("email", claims.Email))
+		return nil, pkgerrors.NewUnauthorizedError(MsgOIDCNotLinked)
+	}
+
+	return s.provisionExternalUser(ctx, claims, link)
+}
+
+// provisionExternalUser creates the user with the default role. Its password is random and never
+// disclosed, a local password can still be set through the reset flow.
+func (s *service) provisionExternalUser(ctx context.Context, claims *oidc.Claims, link *user.ExternalIdentity) (*user.User, error) {
+	hash, err := s.hasher.HashPassword(rand.Text())
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	now := time.Now()
+	u := &user.User{
+		Name:            claims.Name,
+		Email:           claims.Email,
+		EmailVerifiedAt: &now,
+		Password:        hash,
+		Role:            s.oidcCfg.DefaultRole,
+	}
+	if u.Name == "" {
+		u.Name = claims.Email
+	}
+
+	if err := s.identityRepo.Provision(ctx, u, link); err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	logging.FromContext(ctx).Info("oidc user provisioned", slog.Uint64("user_id", uint64(u.ID)))
+	return u, nil
+}
@@ -0,0 +1,372 @@
+package auth_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/encryption"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/oidc"
+	"gomonitor/internal/pkg/oidc/oidctest"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const oidcRedirectURL = "http://localhost:8080/api/v1/auth/oidc/callback"
+
+type oidcMocks struct {
+	hasher           *mocks.MockPasswordHasher
+	identityRepo     *mocks.MockExternalIdentityRepository
+	jwtManager       *mocks.MockJwtManager
+	refreshTokenRepo *mocks.MockRefreshTokenRepository
+	userRepo         *mocks.MockUserRepository
+}
+
+func newOIDCMocks() *oidcMocks {
+	return &oidcMocks{
+		hasher:           &mocks.MockPasswordHasher{},
+		identityRepo:     &mocks.MockExternalIdentityRepository{},
+		jwtManager:       &mocks.MockJwtManager{},
+		refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+		userRepo:         &mocks.MockUserRepository{},
+	}
+}
+
+func (m *oidcMocks) assertExpectations(t *testing.T) {
+	m.hasher.AssertExpectations(t)
+	m.identityRepo.AssertExpectations(t)
+	m.jwtManager.AssertExpectations(t)
+	m.refreshTokenRepo.AssertExpectations(t)
+	m.userRepo.AssertExpectations(t)
+}
+
+// expectSession expects the token pair of a new session for the user.
+func (m *oidcMocks) expectSession(userID uint, role identity.UserRole) {
+	jti := uuid.New()
+	m.jwtManager.On("GenerateRefreshToken", userID, role).
+		Return(&jwt.RefreshTokenResult{Token: "refresh", Meta: jwt.TokenMetadata{JTI: jti}}, nil)
+	m.refreshTokenRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	m.jwtManager.On("GenerateAccessToken", userID, role, "").
+		Return(&jwt.AccessTokenResult{Token: "access", Meta: jwt.TokenMetadata{JTI: jti}}, nil)
+}
+
+func testCipher(t *testing.T) encryption.Cipher {
+	t.Helper()
+
+	cipher, err := encryption.NewAESCipher(bytes.Repeat([]byte("k"), encryption.KeySize))
+	require.NoError(t, err)
+	return cipher
+}
+
+func newOIDCService(t *testing.T, m *oidcMocks, provider oidc.Provider, cfg *config.OIDCConfig) auth.Service {
+	return auth.NewService(&auth.ServiceDeps{
+		AuthConfig:       &config.AuthConfig{},
+		Cipher:           testCipher(t),
+		Hasher:           m.hasher,
+		IdentityRepo:     m.identityRepo,
+		Logger:           slog.Default(),
+		OIDC:             provider,
+		OIDCConfig:       cfg,
+		RefreshTokenRepo: m.refreshTokenRepo,
+		TokenManager:     m.jwtManager,
+		UserRepo:         m.userRepo,
+	})
+}
+
+func assertAppStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	var appErr *pkgerrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, status, appErr.StatusCode)
+}
+
+func TestService_OIDCDisabled(t *testing.T) {
+	t.Parallel()
+
+	service := newOIDCService(t, newOIDCMocks(), nil, &config.OIDCConfig{})
+
+	_, err := service.StartOIDCLogin(t.Context())
+	assertAppStatus(t, err, http.StatusNotFound)
+
+	_, err = service.CompleteOIDCLogin(t.Context(), auth.OIDCCallbackInput{Code: "code", State: "state"})
+	assertAppStatus(t, err, http.StatusNotFound)
+}
+
+func TestService_StartOIDCLogin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("binds the request to an encrypted flow", func(t *testing.T) {
+		provider := &mocks.MockOIDCProvider{}
+		var state, nonce, challenge string
+		provider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				state, nonce, challenge = args.String(1), args.String(2), args.String(3)
+			}).
+			Return("https://idp.example.com/authorize", nil)
+
+		service := newOIDCService(t, newOIDCMocks(), provider, &config.OIDCConfig{FlowTTL: time.Minute})
+
+		out, err := service.StartOIDCLogin(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "https://idp.example.com/authorize", out.AuthURL)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), out.ExpiresAt, time.Second)
+
+		raw, err := testCipher(t).Decrypt(out.Flow)
+		require.NoError(t, err)
+
+		var flow map[string]any
+		require.NoError(t, json.Unmarshal(raw, &flow))
+		assert.Equal(t, state, flow["state"])
+		assert.Equal(t, nonce, flow["nonce"])
+		assert.Equal(t, oidc.CodeChallenge(flow["verifier"].(string)), challenge)
+		assert.NotContains(t, out.Flow, flow["verifier"])
+		assert.NotEqual(t, state, nonce)
+	})
+
+	t.Run("provider unavailable", func(t *testing.T) {
+		provider := &mocks.MockOIDCProvider{}
+		provider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return("", oidc.ErrDiscovery)
+
+		_, err := newOIDCService(t, newOIDCMocks(), provider, &config.OIDCConfig{FlowTTL: time.Minute}).StartOIDCLogin(t.Context())
+		assertAppStatus(t, err, http.StatusInternalServerError)
+	})
+}
+
+// TestService_CompleteOIDCLogin runs the whole flow against the in-process provider.
+func TestService_CompleteOIDCLogin(t *testing.T) {
+	t.Parallel()
+
+	ssoUser := oidctest.User{Subject: "sso-subject", Email: "sso@test.com", EmailVerified: true, Name: "SSO"}
+	localUser := &user.User{ID: 3, Email: "sso@test.com", Role: identity.RoleUser}
+
+	tests := []struct {
+		name           string
+		user           oidctest.User
+		jit            bool
+		tamper         func(*auth.OIDCCallbackInput)
+		setupMocks     func(m *oidcMocks, issuer string)
+		expectedStatus int
+	}{
+		{
+			name: "linked identity",
+			user: ssoUser,
+			setupMocks: func(m *oidcMocks, issuer string) {
+				m.identityRepo.On("GetBySubject", mock.Anything, issuer, "sso-subject").
+					Return(&user.ExternalIdentity{UserID: 3}, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(3)).Return(localUser, nil)
+				m.expectSession(3, identity.RoleUser)
+			},
+		},
+		{
+			name: "links the user with the verified email",
+			user: ssoUser,
+			setupMocks: func(m *oidcMocks, issuer string) {
+				m.identityRepo.On("GetBySubject", mock.Anything, issuer, "sso-subject").Return(nil, gorm.ErrRecordNotFound)
+				m.userRepo.On("GetByEmail", mock.Anything, "sso@test.com").Return(localUser, nil)
+				m.identityRepo.On("Create", mock.Anything, &user.ExternalIdentity{
+					UserID: 3, Issuer: issuer, Subject: "sso-subject", Email: "sso@test.com",
+				}).Return(nil)
+				m.expectSession(3, identity.RoleUser)
+			},
+		},
+		{
+			name: "provisions unknown users",
+			user: ssoUser,
+			jit:  true,
+			setupMocks: func(m *oidcMocks, issuer string) {
+				m.identityRepo.On("GetBySubject", mock.Anything, issuer, "sso-subject").Return(nil, gorm.ErrRecordNotFound)
+				m.userRepo.On("GetByEmail", mock.Anything, "sso@test.com").Return(nil, gorm.ErrRecordNotFound)
+				m.hasher.On("HashPassword", mock.Anything).Return("unusable", nil)
+				m.identityRepo.On("Provision", mock.Anything,
+					mock.MatchedBy(func(u *user.User) bool {
+						return u.Email == "sso@test.com" && u.Name == "SSO" && u.Role == identity.RoleUser &&
+							u.EmailVerified() && u.Password == "unusable"
+					}),
+					mock.MatchedBy(func(link *user.ExternalIdentity) bool {
+						return link.Issuer == issuer && link.Subject == "sso-subject"
+					}),
+				).Run(func(args mock.Arguments) { args.Get(1).(*user.User).ID = 9 }).Return(nil)
+				m.expectSession(9, identity.RoleUser)
+			},
+		},
+		{
+			name: "unknown users without provisioning",
+			user: ssoUser,
+			setupMocks: func(m *oidcMocks, issuer string) {
+				m.identityRepo.On("GetBySubject", mock.Anything, issuer, "sso-subject").Return(nil, gorm.ErrRecordNotFound)
+				m.userRepo.On("GetByEmail", mock.Anything, "sso@test.com").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "unverified email is neither linked nor provisioned",
+			user: oidctest.User{Subject: "sso-subject", Email: "sso@test.com", EmailVerified: false},
+			jit:  true,
+			setupMocks: func(m *oidcMocks, issuer string) {
+				m.identityRepo.On("GetBySubject", mock.Anything, issuer, "sso-subject").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "state mismatch",
+			user:           ssoUser,
+			tamper:         func(in *auth.OIDCCallbackInput) { in.State = "forged" },
+			setupMocks:     func(*oidcMocks, string) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing flow",
+			user:           ssoUser,
+			tamper:         func(in *auth.OIDCCallbackInput) { in.Flow = "" },
+			setupMocks:     func(*oidcMocks, string) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "tampered flow",
+			user:           ssoUser,
+			tamper:         func(in *auth.OIDCCallbackInput) { in.Flow = in.Flow[:len(in.Flow)-4] + "AAAA" },
+			setupMocks:     func(*oidcMocks, string) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "refused by the provider",
+			user:           ssoUser,
+			tamper:         func(in *auth.OIDCCallbackInput) { in.Code, in.Error = "", "access_denied" },
+			setupMocks:     func(*oidcMocks, string) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid code",
+			user:           ssoUser,
+			tamper:         func(in *auth.OIDCCallbackInput) { in.Code = "forged" },
+			setupMocks:     func(*oidcMocks, string) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "identity lookup error",
+			user: ssoUser,
+			setupMocks: func(m *oidcMocks, issuer string) {
+				m.identityRepo.On("GetBySubject", mock.Anything, issuer, "sso-subject").Return(nil, errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, err := oidctest.NewProvider("gomonitor", "secret")
+			require.NoError(t, err)
+			t.Cleanup(fake.Close)
+			fake.SetUser(tt.user)
+
+			cfg := fake.Config(oidcRedirectURL)
+			cfg.JITProvisioning = tt.jit
+
+			m := newOIDCMocks()
+			tt.setupMocks(m, fake.Issuer())
+
+			service := newOIDCService(t, m, oidc.NewProvider(cfg), cfg)
+
+			start, err := service.StartOIDCLogin(t.Context())
+			require.NoError(t, err)
+
+			code, state, err := fake.Authorize(start.AuthURL)
+			require.NoError(t, err)
+
+			input := auth.OIDCCallbackInput{Code: code, State: state, Flow: start.Flow}
+			if tt.tamper != nil {
+				tt.tamper(&input)
+			}
+
+			out, err := service.CompleteOIDCLogin(t.Context(), input)
+
+			if tt.expectedStatus != 0 {
+				assertAppStatus(t, err, tt.expectedStatus)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &auth.LoginOutput{AccessToken: "access", RefreshToken: "refresh", TokenType: auth.TokenTypeBearer}, out)
+			}
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestService_CompleteOIDCLoginExpiredFlow(t *testing.T) {
+	t.Parallel()
+
+	provider := &mocks.MockOIDCProvider{}
+	provider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return("https://idp.example.com/authorize", nil)
+
+	service := newOIDCService(t, newOIDCMocks(), provider, &config.OIDCConfig{FlowTTL: -time.Second})
+
+	start, err := service.StartOIDCLogin(t.Context())
+	require.NoError(t, err)
+
+	authURL, _ := url.Parse(start.AuthURL)
+	_, err = service.CompleteOIDCLogin(t.Context(), auth.OIDCCallbackInput{
+		Code:  "code",
+		State: authURL.Query().Get("state"),
+		Flow:  start.Flow,
+	})
+	assertAppStatus(t, err, http.StatusUnauthorized)
+	provider.AssertNotCalled(t, "Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestService_CompleteOIDCLoginMFA(t *testing.T) {
+	t.Parallel()
+
+	fake, err := oidctest.NewProvider("gomonitor", "")
+	require.NoError(t, err)
+	t.Cleanup(fake.Close)
+
+	cfg := fake.Config(oidcRedirectURL)
+	m := newOIDCMocks()
+	mfaService := &mocks.MockMFAService{}
+
+	m.identityRepo.On("GetBySubject", mock.Anything, fake.Issuer(), "subject").Return(&user.ExternalIdentity{UserID: 3}, nil)
+	m.userRepo.On("GetByID", mock.Anything, uint(3)).Return(&user.User{ID: 3, Role: identity.RoleUser}, nil)
+	mfaService.On("IsEnabled", mock.Anything, uint(3)).Return(true, nil)
+	m.jwtManager.On("GenerateMFAToken", uint(3), identity.RoleUser).
+		Return(&jwt.MFATokenResult{Token: "challenge"}, nil)
+
+	service := auth.NewService(&auth.ServiceDeps{
+		AuthConfig:   &config.AuthConfig{},
+		Cipher:       testCipher(t),
+		IdentityRepo: m.identityRepo,
+		Logger:       slog.Default(),
+		MFA:          mfaService,
+		OIDC:         oidc.NewProvider(cfg),
+		OIDCConfig:   cfg,
+		TokenManager: m.jwtManager,
+		UserRepo:     m.userRepo,
+	})
+
+	start, err := service.StartOIDCLogin(t.Context())
+	require.NoError(t, err)
+	code, state, err := fake.Authorize(start.AuthURL)
+	require.NoError(t, err)
+
+	out, err := service.CompleteOIDCLogin(t.Context(), auth.OIDCCallbackInput{Code: code, State: state, Flow: start.Flow})
+	require.NoError(t, err)
+	require.NotNil(t, out.MFAChallenge)
+	assert.Equal(t, "challenge", out.MFAChallenge.Token)
+	assert.Empty(t, out.AccessToken)
+}
@@ -1,10 +1,91 @@
 package auth
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token types of the token pairs, DPoP tokens are bound to the key of the client.
+const (
+	TokenTypeBearer = "Bearer"
+	TokenTypeDPoP   = "DPoP"
+)
+
+// LoginOutput holds either the token pair, the challenge when the user has MFA enabled, or the step-up
+// verification required for an unusual login.
 type LoginOutput struct {
-	RefreshToken string
-	AccessToken  string
+	RefreshToken          string
+	AccessToken           string
+	TokenType             string
+	RefreshTokenExpiresAt time.Time
+	AccessTokenExpiresAt  time.Time
+	MFAChallenge          *MFAChallengeOutput
+	StepUp                *StepUpOutput
+}
+
+// Step-up verifications of unusual logins.
+const (
+	// StepUpMethodEmail mails a login link to the user.
+	StepUpMethodEmail = "email"
+)
+
+// StepUpOutput replaces the tokens of an unusual login that has to be verified out of band.
+type StepUpOutput struct {
+	Method string
+}
+
+// Second factors listed in the MFA challenge.
+const (
+	MFAMethodTOTP    = "totp"
+	MFAMethodPasskey = "passkey"
+)
+
+// MFAChallengeOutput is exchanged for the token pair on VerifyMFA, along with a second factor.
+type MFAChallengeOutput struct {
+	Token     string
+	ExpiresAt time.Time
+	// Methods are the second factors the user can answer with.
+	Methods []string
 }
 
 type RefreshOutput struct {
-	AccessToken string
+	RefreshToken          string
+	AccessToken           string
+	TokenType             string
+	RefreshTokenExpiresAt time.Time
+	AccessTokenExpiresAt  time.Time
+}
+
+type SessionOutput struct {
+	JTI        uuid.UUID
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	Current    bool
+}
+
+// OIDCLoginOutput sends the user to the provider. Flow must come back with the callback,
+// it binds the callback to the browser which started the login.
+type OIDCLoginOutput struct {
+	AuthURL   string
+	Flow      string
+	ExpiresAt time.Time
+}
+
+// ImpersonationOutput is an access token acting as the user, it can't be refreshed.
+type ImpersonationOutput struct {
+	AccessToken          string
+	AccessTokenExpiresAt time.Time
+}
+
+// ReauthenticateOutput replaces the access token of the session, it carries the new AuthTime.
+type ReauthenticateOutput struct {
+	AccessToken          string
+	TokenType            string
+	AccessTokenExpiresAt time.Time
+	AuthTime             time.Time
+	AMR                  []string
 }
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/webauthn"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StartPasskeyLogin returns the request options of a passkey login. With the challenge of a password
+// login, the passkeys of its user are requested as the second factor.
+func (s *service) StartPasskeyLogin(ctx context.Context, input PasskeyLoginOptionsInput) (*webauthn.RequestOptions, error) {
+	if s.passkeys == nil {
+		return nil, pkgerrors.NewNotFoundError(passkey.MsgPasskeysDisabled)
+	}
+
+	if input.ChallengeToken == "" {
+		return s.passkeys.BeginLogin(ctx, passkey.BeginLoginInput{})
+	}
+
+	// Only checked here, the challenge is exchanged once the passkey answered.
+	challenge, err := s.mfaChallenge(ctx, input.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.passkeys.BeginLogin(ctx, passkey.BeginLoginInput{
+		UserID:  challenge.UserID,
+		Binding: challenge.JTI.String(),
+	})
+}
+
+// CompletePasskeyLogin starts the session of the user of the passkey. A passkey verifying the user is
+// a login on its own, as the second factor it exchanges the challenge it was requested for.
+func (s *service) CompletePasskeyLogin(ctx context.Context, input PasskeyLoginInput) (*LoginOutput, error) {
+	if s.passkeys == nil {
+		return nil, pkgerrors.NewNotFoundError(passkey.MsgPasskeysDisabled)
+	}
+
+	login, err := s.passkeys.FinishLogin(ctx, passkey.FinishLoginInput{Response: input.Response})
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, login.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewUnauthorizedError(passkey.MsgInvalidPasskey)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	if login.SecondFactor {
+		jti, err := uuid.Parse(login.Binding)
+		if err != nil {
+			return nil, pkgerrors.NewUnauthorizedError(MsgInvalidMFAChallenge)
+		}
+
+		// The challenge may have been exchanged with a code meanwhile.
+		if err := s.useMFAChallenge(ctx, jti); err != nil {
+			return nil, err
+		}
+
+		return s.startSession(ctx, user, input.Client, []string{AMRHardwareKey, AMRMultiFactor})
+	}
+
+	if s.authCfg.RequireVerifiedEmail && !user.EmailVerified() {
+		logging.FromContext(ctx).Warn("passkey login with unverified email", slog.Uint64("user_id", uint64(user.ID)))
+		s.recordEvent(ctx, authevent.TypeLoginFailure, user.ID, input.Client, authevent.ReasonEmailNotVerified)
+		return nil, pkgerrors.NewUnauthorizedError(MsgEmailNotVerified)
+	}
+
+	return s.startSession(ctx, user, input.Client, []string{AMRHardwareKey})
+}
@@ -0,0 +1,275 @@
+package auth_test
+
+import (
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/webauthn"
+	"gomonitor/internal/testutil"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type passkeyMocks struct {
+	usedTokens       *mocks.MockUsedTokens
+	jwtManager       *mocks.MockJwtManager
+	passkeys         *mocks.MockPasskeyService
+	refreshTokenRepo *mocks.MockRefreshTokenRepository
+	userRepo         *mocks.MockUserRepository
+}
+
+func newPasskeyMocks() *passkeyMocks {
+	return &passkeyMocks{
+		usedTokens:       &mocks.MockUsedTokens{},
+		jwtManager:       &mocks.MockJwtManager{},
+		passkeys:         &mocks.MockPasskeyService{},
+		refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+		userRepo:         &mocks.MockUserRepository{},
+	}
+}
+
+func (m *passkeyMocks) service(authCfg *config.AuthConfig) auth.Service {
+	return auth.NewService(&auth.ServiceDeps{
+		AuthConfig:       authCfg,
+		UsedTokens:       m.usedTokens,
+		Logger:           slog.Default(),
+		Passkeys:         m.passkeys,
+		RefreshTokenRepo: m.refreshTokenRepo,
+		TokenManager:     m.jwtManager,
+		UserRepo:         m.userRepo,
+	})
+}
+
+func (m *passkeyMocks) assertExpectations(t *testing.T) {
+	m.usedTokens.AssertExpectations(t)
+	m.jwtManager.AssertExpectations(t)
+	m.passkeys.AssertExpectations(t)
+	m.refreshTokenRepo.AssertExpectations(t)
+	m.userRepo.AssertExpectations(t)
+}
+
+// expectSession expects the token pair of a new session for the user, authenticated with the amr methods.
+func expectSession(jwtManager *mocks.MockJwtManager, refreshTokenRepo *mocks.MockRefreshTokenRepository, u *user.User, amr string) {
+	jti := uuid.New()
+	jwtManager.On("GenerateRefreshToken", u.ID, u.Role).
+		Return(&jwt.RefreshTokenResult{Token: "refresh", Meta: jwt.TokenMetadata{JTI: jti}}, nil)
+	refreshTokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *auth.RefreshToken) bool {
+		return token.UserID == u.ID && token.JTI == jti && token.AMR == amr
+	})).Return(nil)
+	jwtManager.On("GenerateAccessToken", u.ID, u.Role, mock.Anything).
+		Return(&jwt.AccessTokenResult{Token: "access"}, nil)
+}
+
+func assertAppError(t *testing.T, err error, status int, message string) {
+	t.Helper()
+
+	var appErr *pkgerrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, status, appErr.StatusCode)
+	assert.Equal(t, message, appErr.Message)
+}
+
+func TestService_PasskeyLoginDisabled(t *testing.T) {
+	t.Parallel()
+	service := auth.NewService(&auth.ServiceDeps{AuthConfig: &config.AuthConfig{}, Logger: slog.Default()})
+
+	_, err := service.StartPasskeyLogin(t.Context(), auth.PasskeyLoginOptionsInput{})
+	assertAppError(t, err, http.StatusNotFound, passkey.MsgPasskeysDisabled)
+
+	_, err = service.CompletePasskeyLogin(t.Context(), auth.PasskeyLoginInput{})
+	assertAppError(t, err, http.StatusNotFound, passkey.MsgPasskeysDisabled)
+}
+
+func TestService_StartPasskeyLogin(t *testing.T) {
+	t.Parallel()
+
+	challengeJti := uuid.New()
+	challenge := &identity.Principal{UserID: 1, Role: identity.RoleUser, JTI: &challengeJti}
+	options := &webauthn.RequestOptions{Challenge: "abc"}
+
+	tests := []struct {
+		name       string
+		input      auth.PasskeyLoginOptionsInput
+		setupMocks func(m *passkeyMocks)
+		status     int
+		message    string
+	}{
+		{
+			name: "discoverable",
+			setupMocks: func(m *passkeyMocks) {
+				m.passkeys.On("BeginLogin", mock.Anything, passkey.BeginLoginInput{}).Return(options, nil)
+			},
+		},
+		{
+			name:  "second factor",
+			input: auth.PasskeyLoginOptionsInput{ChallengeToken: "challenge"},
+			setupMocks: func(m *passkeyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(challenge, nil)
+				m.usedTokens.On("IsUsed", mock.Anything, challengeJti).Return(false, nil)
+				m.passkeys.On("BeginLogin", mock.Anything, passkey.BeginLoginInput{UserID: 1, Binding: challengeJti.String()}).
+					Return(options, nil)
+			},
+		},
+		{
+			name:  "invalid challenge",
+			input: auth.PasskeyLoginOptionsInput{ChallengeToken: "challenge"},
+			setupMocks: func(m *passkeyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(nil, jwt.ErrInvalidToken)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidMFAChallenge,
+		},
+		{
+			name:  "challenge already used",
+			input: auth.PasskeyLoginOptionsInput{ChallengeToken: "challenge"},
+			setupMocks: func(m *passkeyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(challenge, nil)
+				m.usedTokens.On("IsUsed", mock.Anything, challengeJti).Return(true, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidMFAChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newPasskeyMocks()
+			tt.setupMocks(m)
+
+			out, err := m.service(&config.AuthConfig{}).StartPasskeyLogin(t.Context(), tt.input)
+
+			if tt.status != 0 {
+				assertAppError(t, err, tt.status, tt.message)
+			} else {
+				require.NoError(t, err)
+				assert.Same(t, options, out)
+			}
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestService_CompletePasskeyLogin(t *testing.T) {
+	t.Parallel()
+
+	challengeJti := uuid.New()
+	verifiedAt := time.Now()
+	defaultUser := &user.User{ID: 1, Role: identity.RoleUser, EmailVerifiedAt: &verifiedAt}
+	resp := &webauthn.AssertionResponse{CredentialID: []byte{1}}
+	finishInput := passkey.FinishLoginInput{Response: resp}
+	ttl := 5 * time.Minute
+
+	tests := []struct {
+		name       string
+		authCfg    *config.AuthConfig
+		setupMocks func(m *passkeyMocks)
+		status     int
+		message    string
+	}{
+		{
+			name: "first factor",
+			setupMocks: func(m *passkeyMocks) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).
+					Return(&passkey.LoginOutput{UserID: 1, UserVerified: true}, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(defaultUser, nil)
+				expectSession(m.jwtManager, m.refreshTokenRepo, defaultUser, auth.AMRHardwareKey)
+			},
+		},
+		{
+			name: "second factor consumes the challenge",
+			setupMocks: func(m *passkeyMocks) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).
+					Return(&passkey.LoginOutput{UserID: 1, SecondFactor: true, Binding: challengeJti.String()}, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(defaultUser, nil)
+				m.usedTokens.On("Use", mock.Anything, challengeJti, ttl).Return(true, nil)
+				expectSession(m.jwtManager, m.refreshTokenRepo, defaultUser, "hwk mfa")
+			},
+		},
+		{
+			name: "second factor of a used challenge",
+			setupMocks: func(m *passkeyMocks) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).
+					Return(&passkey.LoginOutput{UserID: 1, SecondFactor: true, Binding: challengeJti.String()}, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(defaultUser, nil)
+				m.usedTokens.On("Use", mock.Anything, challengeJti, ttl).Return(false, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidMFAChallenge,
+		},
+		{
+			name: "invalid passkey",
+			setupMocks: func(m *passkeyMocks) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).
+					Return(nil, pkgerrors.NewUnauthorizedError(passkey.MsgInvalidPasskey))
+			},
+			status:  http.StatusUnauthorized,
+			message: passkey.MsgInvalidPasskey,
+		},
+		{
+			name: "deleted user",
+			setupMocks: func(m *passkeyMocks) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).Return(&passkey.LoginOutput{UserID: 1}, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(testutil.Err[*user.User](gorm.ErrRecordNotFound))
+			},
+			status:  http.StatusUnauthorized,
+			message: passkey.MsgInvalidPasskey,
+		},
+		{
+			name: "user lookup error",
+			setupMocks: func(m *passkeyMocks) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).Return(&passkey.LoginOutput{UserID: 1}, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(testutil.Err[*user.User](errors.New("db down")))
+			},
+			status:  http.StatusInternalServerError,
+			message: "An unexpected error occurred",
+		},
+		{
+			name:    "unverified email",
+			authCfg: &config.AuthConfig{MFAChallengeTTL: ttl, RequireVerifiedEmail: true},
+			setupMocks: func(m *passkeyMocks) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).Return(&passkey.LoginOutput{UserID: 2}, nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(2)).Return(&user.User{ID: 2}, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgEmailNotVerified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newPasskeyMocks()
+			tt.setupMocks(m)
+
+			authCfg := tt.authCfg
+			if authCfg == nil {
+				authCfg = &config.AuthConfig{MFAChallengeTTL: ttl}
+			}
+
+			out, err := m.service(authCfg).CompletePasskeyLogin(t.Context(), auth.PasskeyLoginInput{Response: resp})
+
+			if tt.status != 0 {
+				assertAppError(t, err, tt.status, tt.message)
+				assert.Nil(t, out)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "access", out.AccessToken)
+				assert.Equal(t, "refresh", out.RefreshToken)
+			}
+			m.assertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *PasswordResetToken) error
+	FindUserID(ctx context.Context, tokenHash string) (uint, error)
+	Consume(ctx context.Context, tokenHash string) (uint, error)
+	InvalidateByUserID(ctx context.Context, userID uint) error
+	WithTx(tx *gorm.DB) PasswordResetRepository
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db}
+}
+
+func (r *passwordResetRepository) WithTx(tx *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: tx}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindUserID returns the user of a usable token without consuming it.
+// Returns gorm.ErrRecordNotFound if the token is unknown, expired or already used.
+func (r *passwordResetRepository) FindUserID(ctx context.Context, tokenHash string) (uint, error) {
+	var token PasswordResetToken
+	err := r.db.
+		WithContext(ctx).
+		Select("user_id").
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > NOW()", tokenHash).
+		First(&token).
+		Error
+	if err != nil {
+		return 0, err
+	}
+
+	return token.UserID, nil
+}
+
+// Consume marks the token as used and returns its user, in a single statement so it can't be used twice.
+// Returns gorm.ErrRecordNotFound if the token is unknown, expired or already used.
+func (r *passwordResetRepository) Consume(ctx context.Context, tokenHash string) (uint, error) {
+	var userIDs []uint
+	err := r.db.
+		WithContext(ctx).
+		Raw(`UPDATE password_reset_tokens SET used_at = NOW()
+			WHERE token_hash = ? AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id`, tokenHash).
+		Scan(&userIDs).
+		Error
+	if err != nil {
+		return 0, err
+	}
+
+	if len(userIDs) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+
+	return userIDs[0], nil
+}
+
+// InvalidateByUserID marks the remaining tokens of the user as used.
+func (r *passwordResetRepository) InvalidateByUserID(ctx context.Context, userID uint) error {
+	return r.db.
+		WithContext(ctx).
+		Model(&PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", gorm.Expr("NOW()")).
+		Error
+}
@@ -0,0 +1,187 @@
+package auth_test
+
+import (
+	"context"
+	"gomonitor/internal/domain/auth"
+	databaseinfra "gomonitor/internal/infra/database"
+	"gomonitor/internal/testutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPasswordResetRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tests := []struct {
+		name         string
+		expectError  bool
+		contextSetup func(context.Context) context.Context
+	}{
+		{
+			name: "successfully creates a token",
+		},
+		{
+			name:         "fails if context cancelled",
+			expectError:  true,
+			contextSetup: testutil.GetCancelledCtx,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := setupTx(t, db)
+			repo := auth.NewPasswordResetRepository(tx)
+
+			ctx := t.Context()
+			if tt.contextSetup != nil {
+				ctx = tt.contextSetup(ctx)
+			}
+
+			token := &auth.PasswordResetToken{
+				UserID:    1,
+				TokenHash: strings.Repeat("a", 64),
+				ExpiresAt: time.Now().Add(time.Hour),
+			}
+			err := repo.Create(ctx, token)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotZero(t, token.ID)
+		})
+	}
+}
+
+func TestPasswordResetRepository_FindUserID(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := auth.NewPasswordResetRepository(tx)
+
+	usedAt := time.Now().Add(-time.Minute)
+	valid := strings.Repeat("d", 64)
+	expired := strings.Repeat("e", 64)
+	used := strings.Repeat("f", 64)
+	require.NoError(t, repo.Create(t.Context(), &auth.PasswordResetToken{UserID: 7, TokenHash: valid, ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, repo.Create(t.Context(), &auth.PasswordResetToken{UserID: 7, TokenHash: expired, ExpiresAt: time.Now().Add(-time.Minute)}))
+	require.NoError(t, repo.Create(t.Context(), &auth.PasswordResetToken{UserID: 7, TokenHash: used, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}))
+
+	userID, err := repo.FindUserID(t.Context(), valid)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), userID)
+
+	// Looking it up doesn't consume it.
+	userID, err = repo.Consume(t.Context(), valid)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), userID)
+
+	for _, hash := range []string{valid, expired, used, strings.Repeat("0", 64)} {
+		_, err := repo.FindUserID(t.Context(), hash)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	}
+}
+
+func TestPasswordResetRepository_Consume(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	hash := strings.Repeat("b", 64)
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name         string
+		token        *auth.PasswordResetToken
+		expectError  bool
+		contextSetup func(context.Context) context.Context
+	}{
+		{
+			name:  "consumes a valid token",
+			token: &auth.PasswordResetToken{UserID: 7, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)},
+		},
+		{
+			name:        "unknown token",
+			expectError: true,
+		},
+		{
+			name:        "expired token",
+			token:       &auth.PasswordResetToken{UserID: 7, TokenHash: hash, ExpiresAt: time.Now().Add(-time.Minute)},
+			expectError: true,
+		},
+		{
+			name:        "used token",
+			token:       &auth.PasswordResetToken{UserID: 7, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt},
+			expectError: true,
+		},
+		{
+			name:         "fails if context cancelled",
+			token:        &auth.PasswordResetToken{UserID: 7, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)},
+			expectError:  true,
+			contextSetup: testutil.GetCancelledCtx,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := setupTx(t, db)
+			repo := auth.NewPasswordResetRepository(tx)
+
+			if tt.token != nil {
+				require.NoError(t, tx.Create(tt.token).Error)
+			}
+
+			ctx := t.Context()
+			if tt.contextSetup != nil {
+				ctx = tt.contextSetup(ctx)
+			}
+
+			userID, err := repo.Consume(ctx, hash)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, uint(7), userID)
+
+			_, err = repo.Consume(t.Context(), hash)
+			assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		})
+	}
+}
+
+func TestPasswordResetRepository_InvalidateByUserID(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := auth.NewPasswordResetRepository(tx)
+
+	tokens := []auth.PasswordResetToken{
+		{UserID: 3, TokenHash: strings.Repeat("c", 64), ExpiresAt: time.Now().Add(time.Hour)},
+		{UserID: 3, TokenHash: strings.Repeat("d", 64), ExpiresAt: time.Now().Add(time.Hour)},
+		{UserID: 4, TokenHash: strings.Repeat("e", 64), ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	require.NoError(t, tx.Create(&tokens).Error)
+
+	require.NoError(t, repo.InvalidateByUserID(t.Context(), 3))
+
+	_, err := repo.Consume(t.Context(), strings.Repeat("c", 64))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	userID, err := repo.Consume(t.Context(), strings.Repeat("e", 64))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), userID)
+
+	assert.Error(t, repo.InvalidateByUserID(testutil.GetCancelledCtx(t.Context()), 3))
+}
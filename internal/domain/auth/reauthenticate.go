@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/domain/mfa"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/webauthn"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Authentication methods of the amr claim (RFC 8176), a session lists those its user last proved.
+const (
+	AMRPassword    = "pwd"
+	AMROTP         = "otp"
+	AMRHardwareKey = "hwk"
+	AMRMultiFactor = "mfa"
+	// AMREmail and AMRFederated aren't registered, they are a magic link and a login at the OIDC provider.
+	AMREmail     = "email"
+	AMRFederated = "fed"
+)
+
+// Reauthenticate proves the credentials of the caller again, for the operations requiring a recent
+// authentication. No session is started, the authentication of the current one is updated and a new
+// access token carrying it is issued. Failures count towards the same lockouts as logins do.
+// Users without a password they know, like those provisioned by OIDC, re-authenticate with a passkey.
+func (s *service) Reauthenticate(ctx context.Context, input ReauthenticateInput) (*ReauthenticateOutput, error) {
+	principal, err := reauthenticatingSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, principal.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	lockoutKey := strings.ToLower(strings.TrimSpace(user.Email))
+	locked := s.isLoginLocked(ctx, lockoutKey)
+
+	verifyErr := s.hasher.VerifyPassword(user.Password, input.Password)
+
+	if locked {
+		logging.FromContext(ctx).Warn("reauthentication on locked account", slog.Uint64("user_id", uint64(user.ID)))
+		s.recordEvent(ctx, authevent.TypeLoginFailure, user.ID, input.Client, authevent.ReasonAccountLocked)
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidCredentials)
+	}
+
+	if verifyErr != nil {
+		logging.FromContext(ctx).Warn("reauthentication with invalid password", slog.Uint64("user_id", uint64(user.ID)))
+		s.recordEvent(ctx, authevent.TypeLoginFailure, user.ID, input.Client, authevent.ReasonInvalidCredentials)
+		s.loginFailed(ctx, lockoutKey, user.ID, input.Client)
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidCredentials)
+	}
+
+	s.resetLoginFailures(ctx, lockoutKey)
+
+	amr, err := s.reauthenticateMFA(ctx, user.ID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.renewAuthentication(ctx, principal, user, input.Client, amr)
+}
+
+// StartPasskeyReauthentication returns the request options for a passkey of the caller, bound to their session.
+func (s *service) StartPasskeyReauthentication(ctx context.Context) (*webauthn.RequestOptions, error) {
+	if s.passkeys == nil {
+		return nil, pkgerrors.NewNotFoundError(passkey.MsgPasskeysDisabled)
+	}
+
+	principal, err := reauthenticatingSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.passkeys.BeginLogin(ctx, passkey.BeginLoginInput{
+		UserID:         principal.UserID,
+		Binding:        principal.SessionID.String(),
+		Reauthenticate: true,
+	})
+}
+
+// CompletePasskeyReauthentication re-authenticates the caller with a verified passkey, answering the
+// request options of StartPasskeyReauthentication for the same session.
+func (s *service) CompletePasskeyReauthentication(ctx context.Context, input PasskeyReauthenticateInput) (*ReauthenticateOutput, error) {
+	if s.passkeys == nil {
+		return nil, pkgerrors.NewNotFoundError(passkey.MsgPasskeysDisabled)
+	}
+
+	principal, err := reauthenticatingSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	login, err := s.passkeys.FinishLogin(ctx, passkey.FinishLoginInput{Response: input.Response, Reauthenticate: true})
+	if err != nil {
+		var appErr *pkgerrors.AppError
+		if errors.As(err, &appErr) && appErr.StatusCode == http.StatusUnauthorized {
+			s.recordEvent(ctx, authevent.TypeLoginFailure, principal.UserID, input.Client, authevent.ReasonInvalidCredentials)
+		}
+		return nil, err
+	}
+
+	// The ceremony was started by another session, or for another user.
+	if login.UserID != principal.UserID || login.Binding != principal.SessionID.String() {
+		logging.FromContext(ctx).Warn("passkey reauthentication of another session", slog.Uint64("user_id", uint64(principal.UserID)))
+		return nil, pkgerrors.NewUnauthorizedError(passkey.MsgInvalidPasskey)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, principal.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return s.renewAuthentication(ctx, principal, user, input.Client, []string{AMRHardwareKey})
+}
+
+// reauthenticatingSession returns the caller, who must be re-authenticating their own session.
+func reauthenticatingSession(ctx context.Context) (*identity.Principal, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Warn("unauthenticated reauthentication attempt")
+		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	if err := refuseImpersonated(ctx, principal, "reauthenticate"); err != nil {
+		return nil, err
+	}
+
+	// API keys and OAuth clients have no session to re-authenticate.
+	if principal.SessionID == nil {
+		return nil, pkgerrors.NewBadRequestError(MsgNotASession)
+	}
+
+	return principal, nil
+}
+
+// renewAuthentication records the methods just proved on the session and issues the access token carrying them.
+func (s *service) renewAuthentication(
+	ctx context.Context,
+	principal *identity.Principal,
+	user *user.User,
+	client ClientInfo,
+	amr []string,
+) (*ReauthenticateOutput, error) {
+	session, err := s.refreshTokenRepo.UpdateAuthentication(ctx, *principal.SessionID, time.Now(), strings.Join(amr, " "))
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenRevoked) {
+			return nil, pkgerrors.NewUnauthorizedError(MsgInvalidToken)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	accessTokenResult, err := s.tokenManager.GenerateAccessToken(user.ID, user.Role, session.FamilyID, session.JKT, session.Authentication())
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	s.recordEvent(ctx, authevent.TypeReauthenticate, user.ID, client, "")
+
+	return &ReauthenticateOutput{
+		AccessToken:          accessTokenResult.Token,
+		TokenType:            tokenType(session.JKT),
+		AccessTokenExpiresAt: accessTokenResult.Meta.ExpiresAt,
+		AuthTime:             session.AuthTime,
+		AMR:                  amr,
+	}, nil
+}
+
+// reauthenticateMFA checks the TOTP code when the user enabled it, the password alone is enough otherwise.
+func (s *service) reauthenticateMFA(ctx context.Context, userID uint, input ReauthenticateInput) ([]string, error) {
+	if s.mfa == nil {
+		return []string{AMRPassword}, nil
+	}
+
+	enabled, err := s.mfa.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+	if !enabled {
+		return []string{AMRPassword}, nil
+	}
+
+	if input.Code == "" {
+		return nil, pkgerrors.NewUnauthorizedError(MsgMFACodeRequired)
+	}
+
+	lockoutKey := mfa.LockoutKey(userID)
+	if s.isLoginLocked(ctx, lockoutKey) {
+		logging.FromContext(ctx).Warn("mfa attempt on locked account", slog.Any("user_id", userID))
+		return nil, pkgerrors.NewUnauthorizedError(mfa.MsgInvalidMFACode)
+	}
+
+	if err := s.mfa.Verify(ctx, mfa.VerifyInput{UserID: userID, Code: input.Code}); err != nil {
+		var appErr *pkgerrors.AppError
+		if errors.As(err, &appErr) && appErr.StatusCode == http.StatusUnauthorized {
+			logging.FromContext(ctx).Warn("invalid mfa code", slog.Any("user_id", userID))
+			s.recordEvent(ctx, authevent.TypeLoginFailure, userID, input.Client, authevent.ReasonInvalidMFACode)
+			s.loginFailed(ctx, lockoutKey, userID, input.Client)
+		}
+		return nil, err
+	}
+
+	s.resetLoginFailures(ctx, lockoutKey)
+
+	return []string{AMRPassword, AMROTP, AMRMultiFactor}, nil
+}
@@ -0,0 +1,416 @@
+package auth_test
+
+import (
+	"context"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/domain/mfa"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/webauthn"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type reauthMocks struct {
+	events           *mocks.MockAuthEventRecorder
+	hasher           *mocks.MockPasswordHasher
+	jwtManager       *mocks.MockJwtManager
+	lockout          *mocks.MockLockout
+	mfa              *mocks.MockMFAService
+	refreshTokenRepo *mocks.MockRefreshTokenRepository
+	userRepo         *mocks.MockUserRepository
+}
+
+func (m *reauthMocks) assertExpectations(t *testing.T) {
+	m.events.AssertExpectations(t)
+	m.hasher.AssertExpectations(t)
+	m.jwtManager.AssertExpectations(t)
+	m.lockout.AssertExpectations(t)
+	m.mfa.AssertExpectations(t)
+	m.refreshTokenRepo.AssertExpectations(t)
+	m.userRepo.AssertExpectations(t)
+}
+
+func TestService_Reauthenticate(t *testing.T) {
+	t.Parallel()
+
+	usr := &user.User{ID: 1, Email: "User@Test.com", Password: "hash", Role: identity.RoleUser}
+	client := auth.ClientInfo{IPAddress: "192.0.2.1", UserAgent: "curl/8.0"}
+	input := auth.ReauthenticateInput{Password: "password123", Client: client}
+	withCode := auth.ReauthenticateInput{Password: "password123", Code: "123456", Client: client}
+
+	// The family was refreshed, its current token is updated.
+	familyID := uuid.New()
+	current := &auth.RefreshToken{JTI: uuid.New(), UserID: usr.ID, FamilyID: familyID, JKT: "thumbprint"}
+
+	sessionCtx := func(ctx context.Context) context.Context {
+		return identity.WithPrincipal(ctx, &identity.Principal{
+			UserID:    usr.ID,
+			Role:      identity.RoleUser,
+			Source:    identity.AuthExternal,
+			SessionID: &familyID,
+		})
+	}
+
+	event := func(eventType authevent.Type, reason string) authevent.Event {
+		return authevent.Event{
+			Type:      eventType,
+			UserID:    &usr.ID,
+			IPAddress: client.IPAddress,
+			UserAgent: client.UserAgent,
+			Reason:    reason,
+		}
+	}
+
+	passwordOK := func(m *reauthMocks) {
+		m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+		m.lockout.On("Locked", mock.Anything, "user@test.com").Return(time.Duration(0), nil)
+		m.hasher.On("VerifyPassword", usr.Password, "password123").Return(nil)
+		m.lockout.On("Reset", mock.Anything, "user@test.com").Return(nil)
+	}
+
+	expectUpdate := func(m *reauthMocks, amr string) {
+		m.refreshTokenRepo.On("UpdateAuthentication", mock.Anything, familyID, mock.MatchedBy(func(authTime time.Time) bool {
+			return time.Since(authTime) < time.Minute
+		}), amr).
+			Return(func() *auth.RefreshToken {
+				updated := *current
+				updated.AuthTime = time.Now()
+				updated.AMR = amr
+				return &updated
+			}(), nil)
+	}
+
+	tests := []struct {
+		name       string
+		input      auth.ReauthenticateInput
+		setupCtx   func(ctx context.Context) context.Context
+		setupMocks func(m *reauthMocks)
+		status     int
+		message    string
+		amr        []string
+	}{
+		{
+			name:       "no principal",
+			input:      input,
+			setupMocks: func(m *reauthMocks) {},
+			status:     http.StatusUnauthorized,
+			message:    "unauthenticated",
+		},
+		{
+			name:  "impersonated",
+			input: input,
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{UserID: usr.ID, ActorID: 9, SessionID: &familyID})
+			},
+			setupMocks: func(m *reauthMocks) {},
+			status:     http.StatusForbidden,
+			message:    "FORBIDDEN",
+		},
+		{
+			name:  "api keys have no session",
+			input: input,
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{UserID: usr.ID, Source: identity.AuthAPIKey})
+			},
+			setupMocks: func(m *reauthMocks) {},
+			status:     http.StatusBadRequest,
+			message:    auth.MsgNotASession,
+		},
+		{
+			name:     "wrong password counts as a failed login",
+			input:    input,
+			setupCtx: sessionCtx,
+			setupMocks: func(m *reauthMocks) {
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+				m.lockout.On("Locked", mock.Anything, "user@test.com").Return(time.Duration(0), nil)
+				m.hasher.On("VerifyPassword", usr.Password, "password123").Return(bcrypt.ErrMismatchedHashAndPassword)
+				m.lockout.On("Fail", mock.Anything, "user@test.com").Return(time.Duration(0), nil)
+				m.events.On("Record", mock.Anything, event(authevent.TypeLoginFailure, authevent.ReasonInvalidCredentials)).Return()
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidCredentials,
+		},
+		{
+			name:     "locked account",
+			input:    input,
+			setupCtx: sessionCtx,
+			setupMocks: func(m *reauthMocks) {
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+				m.lockout.On("Locked", mock.Anything, "user@test.com").Return(time.Minute, nil)
+				m.hasher.On("VerifyPassword", usr.Password, "password123").Return(nil)
+				m.events.On("Record", mock.Anything, event(authevent.TypeLoginFailure, authevent.ReasonAccountLocked)).Return()
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidCredentials,
+		},
+		{
+			name:     "mfa code required",
+			input:    input,
+			setupCtx: sessionCtx,
+			setupMocks: func(m *reauthMocks) {
+				passwordOK(m)
+				m.mfa.On("IsEnabled", mock.Anything, usr.ID).Return(true, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgMFACodeRequired,
+		},
+		{
+			name:     "wrong mfa code counts a failure",
+			input:    withCode,
+			setupCtx: sessionCtx,
+			setupMocks: func(m *reauthMocks) {
+				passwordOK(m)
+				m.mfa.On("IsEnabled", mock.Anything, usr.ID).Return(true, nil)
+				m.lockout.On("Locked", mock.Anything, "mfa:1").Return(time.Duration(0), nil)
+				m.mfa.On("Verify", mock.Anything, mfa.VerifyInput{UserID: usr.ID, Code: "123456"}).
+					Return(pkgerrors.NewUnauthorizedError(mfa.MsgInvalidMFACode))
+				m.lockout.On("Fail", mock.Anything, "mfa:1").Return(time.Duration(0), nil)
+				m.events.On("Record", mock.Anything, event(authevent.TypeLoginFailure, authevent.ReasonInvalidMFACode)).Return()
+			},
+			status:  http.StatusUnauthorized,
+			message: mfa.MsgInvalidMFACode,
+		},
+		{
+			name:     "session revoked meanwhile",
+			input:    input,
+			setupCtx: sessionCtx,
+			setupMocks: func(m *reauthMocks) {
+				passwordOK(m)
+				m.mfa.On("IsEnabled", mock.Anything, usr.ID).Return(false, nil)
+				m.refreshTokenRepo.On("UpdateAuthentication", mock.Anything, familyID, mock.Anything, auth.AMRPassword).
+					Return(nil, auth.ErrRefreshTokenRevoked)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidToken,
+		},
+		{
+			name:     "password",
+			input:    input,
+			setupCtx: sessionCtx,
+			setupMocks: func(m *reauthMocks) {
+				passwordOK(m)
+				m.mfa.On("IsEnabled", mock.Anything, usr.ID).Return(false, nil)
+				expectUpdate(m, "pwd")
+				m.jwtManager.On("GenerateAccessToken", usr.ID, usr.Role, "thumbprint").
+					Return(&jwt.AccessTokenResult{Token: "access"}, nil)
+				m.events.On("Record", mock.Anything, event(authevent.TypeReauthenticate, "")).Return()
+			},
+			amr: []string{auth.AMRPassword},
+		},
+		{
+			name:     "password and mfa code",
+			input:    withCode,
+			setupCtx: sessionCtx,
+			setupMocks: func(m *reauthMocks) {
+				passwordOK(m)
+				m.mfa.On("IsEnabled", mock.Anything, usr.ID).Return(true, nil)
+				m.lockout.On("Locked", mock.Anything, "mfa:1").Return(time.Duration(0), nil)
+				m.mfa.On("Verify", mock.Anything, mfa.VerifyInput{UserID: usr.ID, Code: "123456"}).Return(nil)
+				m.lockout.On("Reset", mock.Anything, "mfa:1").Return(nil)
+				expectUpdate(m, "pwd otp mfa")
+				m.jwtManager.On("GenerateAccessToken", usr.ID, usr.Role, "thumbprint").
+					Return(&jwt.AccessTokenResult{Token: "access"}, nil)
+				m.events.On("Record", mock.Anything, event(authevent.TypeReauthenticate, "")).Return()
+			},
+			amr: []string{auth.AMRPassword, auth.AMROTP, auth.AMRMultiFactor},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &reauthMocks{
+				events:           &mocks.MockAuthEventRecorder{},
+				hasher:           &mocks.MockPasswordHasher{},
+				jwtManager:       &mocks.MockJwtManager{},
+				lockout:          &mocks.MockLockout{},
+				mfa:              &mocks.MockMFAService{},
+				refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+				userRepo:         &mocks.MockUserRepository{},
+			}
+			tt.setupMocks(m)
+
+			service := auth.NewService(&auth.ServiceDeps{
+				AuthConfig:       &config.AuthConfig{},
+				Events:           m.events,
+				Hasher:           m.hasher,
+				LoginLockout:     m.lockout,
+				Logger:           slog.Default(),
+				MFA:              m.mfa,
+				RefreshTokenRepo: m.refreshTokenRepo,
+				TokenManager:     m.jwtManager,
+				UserRepo:         m.userRepo,
+			})
+
+			ctx := t.Context()
+			if tt.setupCtx != nil {
+				ctx = tt.setupCtx(ctx)
+			}
+
+			out, err := service.Reauthenticate(ctx, tt.input)
+
+			if tt.status != 0 {
+				assertAppError(t, err, tt.status, tt.message)
+				assert.Nil(t, out)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "access", out.AccessToken)
+				assert.Equal(t, auth.TokenTypeDPoP, out.TokenType)
+				assert.Equal(t, tt.amr, out.AMR)
+				assert.WithinDuration(t, time.Now(), out.AuthTime, time.Minute)
+			}
+
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestService_PasskeyReauthentication(t *testing.T) {
+	t.Parallel()
+
+	usr := &user.User{ID: 1, Email: "user@test.com", Role: identity.RoleUser}
+	client := auth.ClientInfo{IPAddress: "192.0.2.1", UserAgent: "curl/8.0"}
+	resp := &webauthn.AssertionResponse{CredentialID: []byte{1}}
+	finishInput := passkey.FinishLoginInput{Response: resp, Reauthenticate: true}
+
+	familyID := uuid.New()
+	current := &auth.RefreshToken{JTI: uuid.New(), UserID: usr.ID, FamilyID: familyID}
+
+	sessionCtx := identity.WithPrincipal(t.Context(), &identity.Principal{
+		UserID:    usr.ID,
+		Role:      identity.RoleUser,
+		Source:    identity.AuthExternal,
+		SessionID: &familyID,
+	})
+	apiKeyCtx := identity.WithPrincipal(t.Context(), &identity.Principal{UserID: usr.ID, Source: identity.AuthAPIKey})
+
+	newService := func(m *passkeyMocks, events *mocks.MockAuthEventRecorder) auth.Service {
+		return auth.NewService(&auth.ServiceDeps{
+			AuthConfig:       &config.AuthConfig{},
+			Events:           events,
+			Logger:           slog.Default(),
+			Passkeys:         m.passkeys,
+			RefreshTokenRepo: m.refreshTokenRepo,
+			TokenManager:     m.jwtManager,
+			UserRepo:         m.userRepo,
+		})
+	}
+
+	t.Run("options are bound to the session", func(t *testing.T) {
+		m := newPasskeyMocks()
+		options := &webauthn.RequestOptions{Challenge: "challenge"}
+		m.passkeys.On("BeginLogin", mock.Anything, passkey.BeginLoginInput{
+			UserID:         usr.ID,
+			Binding:        familyID.String(),
+			Reauthenticate: true,
+		}).Return(options, nil)
+
+		out, err := newService(m, &mocks.MockAuthEventRecorder{}).StartPasskeyReauthentication(sessionCtx)
+		require.NoError(t, err)
+		assert.Equal(t, options, out)
+
+		m.assertExpectations(t)
+	})
+
+	t.Run("options without a session", func(t *testing.T) {
+		_, err := newService(newPasskeyMocks(), &mocks.MockAuthEventRecorder{}).StartPasskeyReauthentication(apiKeyCtx)
+		assertAppError(t, err, http.StatusBadRequest, auth.MsgNotASession)
+	})
+
+	t.Run("passkeys disabled", func(t *testing.T) {
+		service := auth.NewService(&auth.ServiceDeps{AuthConfig: &config.AuthConfig{}, Logger: slog.Default()})
+
+		_, err := service.StartPasskeyReauthentication(sessionCtx)
+		assertAppError(t, err, http.StatusNotFound, passkey.MsgPasskeysDisabled)
+	})
+
+	tests := []struct {
+		name       string
+		setupMocks func(m *passkeyMocks, events *mocks.MockAuthEventRecorder)
+		status     int
+		message    string
+	}{
+		{
+			name: "verified passkey",
+			setupMocks: func(m *passkeyMocks, events *mocks.MockAuthEventRecorder) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).
+					Return(&passkey.LoginOutput{UserID: usr.ID, Binding: familyID.String(), UserVerified: true}, nil)
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+				m.refreshTokenRepo.On("UpdateAuthentication", mock.Anything, familyID, mock.Anything, "hwk").
+					Return(&auth.RefreshToken{JTI: current.JTI, FamilyID: familyID, AuthTime: time.Now(), AMR: "hwk"}, nil)
+				m.jwtManager.On("GenerateAccessToken", usr.ID, usr.Role, "").
+					Return(&jwt.AccessTokenResult{Token: "access"}, nil)
+				events.On("Record", mock.Anything, mock.MatchedBy(func(e authevent.Event) bool {
+					return e.Type == authevent.TypeReauthenticate
+				})).Return()
+			},
+		},
+		{
+			name: "ceremony of another session",
+			setupMocks: func(m *passkeyMocks, _ *mocks.MockAuthEventRecorder) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).
+					Return(&passkey.LoginOutput{UserID: usr.ID, Binding: uuid.NewString(), UserVerified: true}, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: passkey.MsgInvalidPasskey,
+		},
+		{
+			name: "passkey of another user",
+			setupMocks: func(m *passkeyMocks, _ *mocks.MockAuthEventRecorder) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).
+					Return(&passkey.LoginOutput{UserID: usr.ID + 1, Binding: familyID.String(), UserVerified: true}, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: passkey.MsgInvalidPasskey,
+		},
+		{
+			name: "invalid passkey is recorded",
+			setupMocks: func(m *passkeyMocks, events *mocks.MockAuthEventRecorder) {
+				m.passkeys.On("FinishLogin", mock.Anything, finishInput).
+					Return(nil, pkgerrors.NewUnauthorizedError(passkey.MsgInvalidPasskey))
+				events.On("Record", mock.Anything, mock.MatchedBy(func(e authevent.Event) bool {
+					return e.Type == authevent.TypeLoginFailure && e.Reason == authevent.ReasonInvalidCredentials
+				})).Return()
+			},
+			status:  http.StatusUnauthorized,
+			message: passkey.MsgInvalidPasskey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newPasskeyMocks()
+			events := &mocks.MockAuthEventRecorder{}
+			tt.setupMocks(m, events)
+
+			out, err := newService(m, events).
+				CompletePasskeyReauthentication(sessionCtx, auth.PasskeyReauthenticateInput{Response: resp, Client: client})
+
+			if tt.status != 0 {
+				assertAppError(t, err, tt.status, tt.message)
+				assert.Nil(t, out)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "access", out.AccessToken)
+				assert.Equal(t, []string{auth.AMRHardwareKey}, out.AMR)
+			}
+
+			m.assertExpectations(t)
+			events.AssertExpectations(t)
+		})
+	}
+}
@@ -2,16 +2,25 @@ package auth
 
 import (
 	"context"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RefreshTokenRepository interface {
 	Create(ctx context.Context, refreshToken *RefreshToken) error
 	GetByJTI(ctx context.Context, jti uuid.UUID) (*RefreshToken, error)
-	RevokeByJTI(ctx context.Context, jti uuid.UUID) error
-	RevokeByUserID(ctx context.Context, id uint) error
+	GetActiveByFamilyID(ctx context.Context, familyID uuid.UUID) (*RefreshToken, error)
+	ListActiveByUserID(ctx context.Context, userID uint) ([]RefreshToken, error)
+	RevokeByUserID(ctx context.Context, id uint) ([]uuid.UUID, error)
+	RevokeByFamilyID(ctx context.Context, familyID uuid.UUID) ([]uuid.UUID, error)
+	RevokeOtherFamilies(ctx context.Context, userID uint, keepFamilyID uuid.UUID) ([]uuid.UUID, error)
+	IsRevoked(ctx context.Context, familyID uuid.UUID) (bool, error)
+	Rotate(ctx context.Context, oldJTI uuid.UUID, newToken *RefreshToken) error
+	UpdateAuthentication(ctx context.Context, familyID uuid.UUID, authTime time.Time, amr string) (*RefreshToken, error)
 	WithTx(tx *gorm.DB) RefreshTokenRepository
 }
 
@@ -40,20 +49,128 @@ func (r *refreshTokenRepository) GetByJTI(ctx context.Context, jti uuid.UUID) (*
 	return &refreshToken, nil
 }
 
-func (r *refreshTokenRepository) RevokeByJTI(ctx context.Context, jti uuid.UUID) error {
-	return r.db.
+// GetActiveByFamilyID returns the current token of the session, the one its rotations haven't revoked.
+// A revoked or unknown family has none, gorm.ErrRecordNotFound is returned.
+func (r *refreshTokenRepository) GetActiveByFamilyID(ctx context.Context, familyID uuid.UUID) (*RefreshToken, error) {
+	var refreshToken RefreshToken
+	err := r.db.
 		WithContext(ctx).
-		Model(&RefreshToken{}).
-		Where("jti = ? AND revoked_at IS NULL", jti).
-		Update("revoked_at", gorm.Expr("NOW()")).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Order("created_at DESC").
+		First(&refreshToken).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &refreshToken, nil
+}
+
+// ListActiveByUserID returns the current token of each live session, most recently used first.
+func (r *refreshTokenRepository) ListActiveByUserID(ctx context.Context, userID uint) ([]RefreshToken, error) {
+	var tokens []RefreshToken
+	err := r.db.
+		WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > NOW()", userID).
+		Order("last_used_at DESC").
+		Find(&tokens).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (r *refreshTokenRepository) RevokeByUserID(ctx context.Context, id uint) ([]uuid.UUID, error) {
+	return r.revokeWhere(ctx, "user_id = ? AND revoked_at IS NULL", id)
+}
+
+func (r *refreshTokenRepository) RevokeByFamilyID(ctx context.Context, familyID uuid.UUID) ([]uuid.UUID, error) {
+	return r.revokeWhere(ctx, "family_id = ? AND revoked_at IS NULL", familyID)
+}
+
+// RevokeOtherFamilies revokes every session of the user except the one of keepFamilyID.
+func (r *refreshTokenRepository) RevokeOtherFamilies(ctx context.Context, userID uint, keepFamilyID uuid.UUID) ([]uuid.UUID, error) {
+	return r.revokeWhere(ctx, "user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID)
+}
+
+// IsRevoked reports if the session of the family was revoked, it is live while one of its tokens isn't revoked.
+// Rotations always leave a live token, unknown families count as revoked.
+func (r *refreshTokenRepository) IsRevoked(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	var live bool
+	err := r.db.
+		WithContext(ctx).
+		Raw(`SELECT EXISTS (
+			SELECT 1 FROM refresh_tokens WHERE family_id = ? AND revoked_at IS NULL
+		)`, familyID).
+		Scan(&live).
 		Error
+
+	return !live, err
 }
 
-func (r *refreshTokenRepository) RevokeByUserID(ctx context.Context, id uint) error {
-	return r.db.
+// revokeWhere returns the families of the revoked tokens, so the sessions can be denylisted.
+func (r *refreshTokenRepository) revokeWhere(ctx context.Context, query string, args ...any) ([]uuid.UUID, error) {
+	var revoked []RefreshToken
+	err := r.db.
 		WithContext(ctx).
-		Model(&RefreshToken{}).
-		Where("user_id = ? AND revoked_at IS NULL", id).
+		Model(&revoked).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "family_id"}}}).
+		Where(query, args...).
 		Update("revoked_at", gorm.Expr("NOW()")).
 		Error
+	if err != nil {
+		return nil, err
+	}
+
+	families := make([]uuid.UUID, 0, len(revoked))
+	for _, token := range revoked {
+		if !slices.Contains(families, token.FamilyID) {
+			families = append(families, token.FamilyID)
+		}
+	}
+
+	return families, nil
+}
+
+// Rotate revokes the old token and stores its replacement atomically.
+// Returns ErrRefreshTokenRevoked if the old token was already revoked, so concurrent rotations are detected.
+func (r *refreshTokenRepository) Rotate(ctx context.Context, oldJTI uuid.UUID, newToken *RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&RefreshToken{}).
+			Where("jti = ? AND revoked_at IS NULL", oldJTI).
+			Update("revoked_at", gorm.Expr("NOW()"))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenRevoked
+		}
+
+		return tx.Create(newToken).Error
+	})
+}
+
+// UpdateAuthentication records a re-authentication on the current token of the session family in place,
+// the session goes on with the same tokens. Returns ErrRefreshTokenRevoked if the session was revoked.
+func (r *refreshTokenRepository) UpdateAuthentication(ctx context.Context, familyID uuid.UUID, authTime time.Time, amr string) (*RefreshToken, error) {
+	var updated []RefreshToken
+	result := r.db.
+		WithContext(ctx).
+		Model(&updated).
+		Clauses(clause.Returning{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]any{"auth_time": authTime, "amr": amr})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if len(updated) == 0 {
+		return nil, ErrRefreshTokenRevoked
+	}
+
+	return &updated[0], nil
 }
@@ -152,29 +152,55 @@ func TestRepository_GetByJTI(t *testing.T) {
 	}
 }
 
-func TestRepository_RevokeByJTI(t *testing.T) {
+func TestRepository_RevokeByUserID(t *testing.T) {
 	t.Parallel()
 	db, _ := databaseinfra.New(t.Context(), testDbCfg)
 
 	tests := []struct {
 		name         string
-		setupFunc    func(db *gorm.DB, token *auth.RefreshToken)
+		setupFunc    func(db *gorm.DB, userID uint)
+		userID       uint
 		expectError  bool
 		contextSetup func(context.Context) context.Context
 	}{
 		{
-			name: "successfully revokes refresh token by jti",
-			setupFunc: func(db *gorm.DB, token *auth.RefreshToken) {
-				err := db.Create(token).Error
+			name:   "successfully revokes all refresh tokens for user",
+			userID: 1,
+			setupFunc: func(db *gorm.DB, userID uint) {
+				first, second := uuid.New(), uuid.New()
+				tokens := []auth.RefreshToken{
+					{
+						JTI:       first,
+						UserID:    userID,
+						FamilyID:  first,
+						ExpiresAt: time.Now().Add(24 * time.Hour),
+						CreatedAt: time.Now(),
+					},
+					{
+						JTI:       second,
+						UserID:    userID,
+						FamilyID:  second,
+						ExpiresAt: time.Now().Add(24 * time.Hour),
+						CreatedAt: time.Now(),
+					},
+				}
+
+				err := db.Create(&tokens).Error
 				assert.NoError(t, err)
 			},
 		},
 		{
 			name:         "fails if context cancelled",
+			userID:       1,
 			expectError:  true,
 			contextSetup: testutil.GetCancelledCtx,
-			setupFunc: func(db *gorm.DB, token *auth.RefreshToken) {
-				err := db.Create(token).Error
+			setupFunc: func(db *gorm.DB, userID uint) {
+				err := db.Create(&auth.RefreshToken{
+					JTI:       uuid.New(),
+					UserID:    userID,
+					ExpiresAt: time.Now().Add(24 * time.Hour),
+					CreatedAt: time.Now(),
+				}).Error
 				assert.NoError(t, err)
 			},
 		},
@@ -184,15 +210,8 @@ func TestRepository_RevokeByJTI(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tx := setupTx(t, db)
 
-			token := &auth.RefreshToken{
-				JTI:       uuid.New(),
-				UserID:    1,
-				ExpiresAt: time.Now().Add(24 * time.Hour),
-				CreatedAt: time.Now(),
-			}
-
 			if tt.setupFunc != nil {
-				tt.setupFunc(tx, token)
+				tt.setupFunc(tx, tt.userID)
 			}
 
 			repo := auth.NewRefreshTokenRepository(tx)
@@ -202,7 +221,7 @@ func TestRepository_RevokeByJTI(t *testing.T) {
 				ctx = tt.contextSetup(ctx)
 			}
 
-			err := repo.RevokeByJTI(ctx, token.JTI)
+			families, err := repo.RevokeByUserID(ctx, tt.userID)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -210,40 +229,47 @@ func TestRepository_RevokeByJTI(t *testing.T) {
 			}
 
 			assert.NoError(t, err)
+			assert.Len(t, families, 2)
+
+			var count int64
+			err = tx.
+				Model(&auth.RefreshToken{}).
+				Where("user_id = ? AND revoked_at IS NULL", tt.userID).
+				Count(&count).
+				Error
 
-			var got auth.RefreshToken
-			err = tx.First(&got, "jti = ?", token.JTI).Error
 			assert.NoError(t, err)
-			assert.NotNil(t, got.RevokedAt)
+			assert.Equal(t, int64(0), count)
 		})
 	}
 }
 
-func TestRepository_RevokeByUserID(t *testing.T) {
+func TestRepository_RevokeByFamilyID(t *testing.T) {
 	t.Parallel()
 	db, _ := databaseinfra.New(t.Context(), testDbCfg)
 
 	tests := []struct {
 		name         string
-		setupFunc    func(db *gorm.DB, userID uint)
-		userID       uint
+		setupFunc    func(db *gorm.DB, familyID uuid.UUID)
 		expectError  bool
 		contextSetup func(context.Context) context.Context
 	}{
 		{
-			name:   "successfully revokes all refresh tokens for user",
-			userID: 1,
-			setupFunc: func(db *gorm.DB, userID uint) {
+			name: "successfully revokes all refresh tokens in family",
+			setupFunc: func(db *gorm.DB, familyID uuid.UUID) {
 				tokens := []auth.RefreshToken{
 					{
-						JTI:       uuid.New(),
-						UserID:    userID,
+						JTI:       familyID,
+						UserID:    1,
+						FamilyID:  familyID,
 						ExpiresAt: time.Now().Add(24 * time.Hour),
 						CreatedAt: time.Now(),
 					},
 					{
 						JTI:       uuid.New(),
-						UserID:    userID,
+						UserID:    1,
+						FamilyID:  familyID,
+						ParentJTI: &familyID,
 						ExpiresAt: time.Now().Add(24 * time.Hour),
 						CreatedAt: time.Now(),
 					},
@@ -255,27 +281,18 @@ func TestRepository_RevokeByUserID(t *testing.T) {
 		},
 		{
 			name:         "fails if context cancelled",
-			userID:       1,
 			expectError:  true,
 			contextSetup: testutil.GetCancelledCtx,
-			setupFunc: func(db *gorm.DB, userID uint) {
-				err := db.Create(&auth.RefreshToken{
-					JTI:       uuid.New(),
-					UserID:    userID,
-					ExpiresAt: time.Now().Add(24 * time.Hour),
-					CreatedAt: time.Now(),
-				}).Error
-				assert.NoError(t, err)
-			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tx := setupTx(t, db)
+			familyID := uuid.New()
 
 			if tt.setupFunc != nil {
-				tt.setupFunc(tx, tt.userID)
+				tt.setupFunc(tx, familyID)
 			}
 
 			repo := auth.NewRefreshTokenRepository(tx)
@@ -285,7 +302,7 @@ func TestRepository_RevokeByUserID(t *testing.T) {
 				ctx = tt.contextSetup(ctx)
 			}
 
-			err := repo.RevokeByUserID(ctx, tt.userID)
+			families, err := repo.RevokeByFamilyID(ctx, familyID)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -293,11 +310,12 @@ func TestRepository_RevokeByUserID(t *testing.T) {
 			}
 
 			assert.NoError(t, err)
+			assert.Equal(t, []uuid.UUID{familyID}, families)
 
 			var count int64
 			err = tx.
 				Model(&auth.RefreshToken{}).
-				Where("user_id = ? AND revoked_at IS NULL", tt.userID).
+				Where("family_id = ? AND revoked_at IS NULL", familyID).
 				Count(&count).
 				Error
 
@@ -306,3 +324,377 @@ func TestRepository_RevokeByUserID(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_RevokeOtherFamilies(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := auth.NewRefreshTokenRepository(tx)
+
+	keepFamily, otherFamily := uuid.New(), uuid.New()
+	otherUserJTI := uuid.New()
+	tokens := []auth.RefreshToken{
+		{JTI: keepFamily, UserID: 1, FamilyID: keepFamily, ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now()},
+		{JTI: otherFamily, UserID: 1, FamilyID: otherFamily, ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now()},
+		{JTI: otherUserJTI, UserID: 2, FamilyID: otherUserJTI, ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now()},
+	}
+	assert.NoError(t, tx.Create(&tokens).Error)
+
+	families, err := repo.RevokeOtherFamilies(t.Context(), 1, keepFamily)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{otherFamily}, families)
+
+	for jti, revoked := range map[uuid.UUID]bool{keepFamily: false, otherFamily: true, otherUserJTI: false} {
+		got, err := repo.GetByJTI(t.Context(), jti)
+		assert.NoError(t, err)
+		assert.Equal(t, revoked, got.RevokedAt != nil)
+	}
+
+	_, err = repo.RevokeOtherFamilies(testutil.GetCancelledCtx(t.Context()), 1, keepFamily)
+	assert.Error(t, err)
+}
+
+func TestRepository_ListActiveByUserID(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := auth.NewRefreshTokenRepository(tx)
+
+	userID := uint(42)
+	now := time.Now()
+	newToken := func(userID uint, lastUsed time.Time, expires time.Time, revoked bool) *auth.RefreshToken {
+		jti := uuid.New()
+		token := &auth.RefreshToken{
+			JTI:        jti,
+			UserID:     userID,
+			FamilyID:   jti,
+			UserAgent:  "curl/8.0",
+			IPAddress:  "192.0.2.1",
+			ExpiresAt:  expires,
+			CreatedAt:  now,
+			LastUsedAt: lastUsed,
+		}
+		if revoked {
+			token.RevokedAt = &now
+		}
+		assert.NoError(t, tx.Create(token).Error)
+		return token
+	}
+
+	older := newToken(userID, now.Add(-time.Hour), now.Add(time.Hour), false)
+	recent := newToken(userID, now, now.Add(time.Hour), false)
+	newToken(userID, now, now.Add(time.Hour), true)
+	newToken(userID, now, now.Add(-time.Minute), false)
+	newToken(userID+1, now, now.Add(time.Hour), false)
+
+	tokens, err := repo.ListActiveByUserID(t.Context(), userID)
+	assert.NoError(t, err)
+
+	if assert.Len(t, tokens, 2) {
+		assert.Equal(t, recent.JTI, tokens[0].JTI)
+		assert.Equal(t, older.JTI, tokens[1].JTI)
+		assert.Equal(t, "192.0.2.1", tokens[0].IPAddress)
+	}
+
+	_, err = repo.ListActiveByUserID(testutil.GetCancelledCtx(t.Context()), userID)
+	assert.Error(t, err)
+}
+
+func TestRepository_IsRevoked(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := auth.NewRefreshTokenRepository(tx)
+
+	newToken := func(familyID uuid.UUID, parent *uuid.UUID) *auth.RefreshToken {
+		token := &auth.RefreshToken{
+			JTI:       uuid.New(),
+			UserID:    1,
+			FamilyID:  familyID,
+			ParentJTI: parent,
+			ExpiresAt: time.Now().Add(24 * time.Hour),
+			CreatedAt: time.Now(),
+		}
+		if familyID == uuid.Nil {
+			token.FamilyID = token.JTI
+		}
+		assert.NoError(t, tx.Create(token).Error)
+		return token
+	}
+
+	rotate := func(parent *auth.RefreshToken) *auth.RefreshToken {
+		child := &auth.RefreshToken{
+			JTI:       uuid.New(),
+			UserID:    1,
+			FamilyID:  parent.FamilyID,
+			ParentJTI: &parent.JTI,
+			ExpiresAt: time.Now().Add(24 * time.Hour),
+			CreatedAt: time.Now(),
+		}
+		assert.NoError(t, repo.Rotate(t.Context(), parent.JTI, child))
+		return child
+	}
+
+	active := newToken(uuid.Nil, nil)
+
+	revoked := newToken(uuid.Nil, nil)
+	_, err := repo.RevokeByFamilyID(t.Context(), revoked.FamilyID)
+	assert.NoError(t, err)
+
+	// Revoked by a rotation, the session lives on through the child token.
+	rotated := newToken(uuid.Nil, nil)
+	rotate(rotated)
+
+	// Logged out after a rotation, the family has no live token left.
+	loggedOut := newToken(uuid.Nil, nil)
+	rotate(loggedOut)
+	_, err = repo.RevokeByFamilyID(t.Context(), loggedOut.FamilyID)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		familyID uuid.UUID
+		expected bool
+	}{
+		{name: "active session", familyID: active.FamilyID},
+		{name: "revoked session", familyID: revoked.FamilyID, expected: true},
+		{name: "rotated session", familyID: rotated.FamilyID},
+		{name: "revoked after a rotation", familyID: loggedOut.FamilyID, expected: true},
+		{name: "unknown session", familyID: uuid.New(), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isRevoked, err := repo.IsRevoked(t.Context(), tt.familyID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, isRevoked)
+		})
+	}
+
+	_, err = repo.IsRevoked(testutil.GetCancelledCtx(t.Context()), active.FamilyID)
+	assert.Error(t, err)
+}
+
+func TestRepository_GetActiveByFamilyID(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := auth.NewRefreshTokenRepository(tx)
+
+	root := &auth.RefreshToken{
+		JTI:       uuid.New(),
+		UserID:    1,
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+		CreatedAt: time.Now(),
+	}
+	root.FamilyID = root.JTI
+	assert.NoError(t, tx.Create(root).Error)
+
+	child := &auth.RefreshToken{
+		JTI:       uuid.New(),
+		UserID:    1,
+		FamilyID:  root.FamilyID,
+		ParentJTI: &root.JTI,
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+		CreatedAt: time.Now(),
+	}
+	assert.NoError(t, repo.Rotate(t.Context(), root.JTI, child))
+
+	current, err := repo.GetActiveByFamilyID(t.Context(), root.FamilyID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, child.JTI, current.JTI)
+	}
+
+	_, err = repo.RevokeByFamilyID(t.Context(), root.FamilyID)
+	assert.NoError(t, err)
+
+	_, err = repo.GetActiveByFamilyID(t.Context(), root.FamilyID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	_, err = repo.GetActiveByFamilyID(t.Context(), uuid.New())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestRepository_Rotate(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tests := []struct {
+		name         string
+		revoked      bool
+		expectedErr  error
+		expectError  bool
+		contextSetup func(context.Context) context.Context
+	}{
+		{
+			name: "successfully rotates refresh token",
+		},
+		{
+			name:        "fails if old token already revoked",
+			revoked:     true,
+			expectError: true,
+			expectedErr: auth.ErrRefreshTokenRevoked,
+		},
+		{
+			name:         "fails if context cancelled",
+			expectError:  true,
+			contextSetup: testutil.GetCancelledCtx,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := setupTx(t, db)
+
+			oldToken := &auth.RefreshToken{
+				JTI:       uuid.New(),
+				UserID:    1,
+				ExpiresAt: time.Now().Add(24 * time.Hour),
+				CreatedAt: time.Now(),
+			}
+			oldToken.FamilyID = oldToken.JTI
+			if tt.revoked {
+				oldToken.RevokedAt = testutil.Ptr(time.Now())
+			}
+			assert.NoError(t, tx.Create(oldToken).Error)
+
+			newToken := &auth.RefreshToken{
+				JTI:       uuid.New(),
+				UserID:    1,
+				FamilyID:  oldToken.FamilyID,
+				ParentJTI: &oldToken.JTI,
+				ExpiresAt: time.Now().Add(24 * time.Hour),
+				CreatedAt: time.Now(),
+			}
+
+			repo := auth.NewRefreshTokenRepository(tx)
+
+			ctx := t.Context()
+			if tt.contextSetup != nil {
+				ctx = tt.contextSetup(ctx)
+			}
+
+			err := repo.Rotate(ctx, oldToken.JTI, newToken)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				if tt.expectedErr != nil {
+					assert.ErrorIs(t, err, tt.expectedErr)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+
+			var gotOld auth.RefreshToken
+			err = tx.First(&gotOld, "jti = ?", oldToken.JTI).Error
+			assert.NoError(t, err)
+			assert.NotNil(t, gotOld.RevokedAt)
+
+			var gotNew auth.RefreshToken
+			err = tx.First(&gotNew, "jti = ?", newToken.JTI).Error
+			assert.NoError(t, err)
+			assert.Nil(t, gotNew.RevokedAt)
+			assert.Equal(t, oldToken.FamilyID, gotNew.FamilyID)
+			assert.Equal(t, oldToken.JTI, *gotNew.ParentJTI)
+		})
+	}
+}
+
+func TestRepository_UpdateAuthentication(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tests := []struct {
+		name         string
+		revoked      bool
+		expectedErr  error
+		expectError  bool
+		contextSetup func(context.Context) context.Context
+	}{
+		{
+			name: "successfully updates the current token of the session",
+		},
+		{
+			name:        "fails if session revoked",
+			revoked:     true,
+			expectError: true,
+			expectedErr: auth.ErrRefreshTokenRevoked,
+		},
+		{
+			name:         "fails if context cancelled",
+			expectError:  true,
+			contextSetup: testutil.GetCancelledCtx,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := setupTx(t, db)
+
+			// Rotated out, only the current token of the family is updated.
+			rotated := &auth.RefreshToken{
+				JTI:       uuid.New(),
+				UserID:    1,
+				AuthTime:  time.Now().Add(-time.Hour),
+				AMR:       "pwd",
+				ExpiresAt: time.Now().Add(24 * time.Hour),
+				CreatedAt: time.Now().Add(-time.Hour),
+				RevokedAt: testutil.Ptr(time.Now()),
+			}
+			rotated.FamilyID = rotated.JTI
+			assert.NoError(t, tx.Create(rotated).Error)
+
+			token := &auth.RefreshToken{
+				JTI:       uuid.New(),
+				UserID:    1,
+				FamilyID:  rotated.FamilyID,
+				ParentJTI: &rotated.JTI,
+				AuthTime:  rotated.AuthTime,
+				AMR:       rotated.AMR,
+				ExpiresAt: time.Now().Add(24 * time.Hour),
+				CreatedAt: rotated.CreatedAt,
+			}
+			if tt.revoked {
+				token.RevokedAt = testutil.Ptr(time.Now())
+			}
+			assert.NoError(t, tx.Create(token).Error)
+
+			repo := auth.NewRefreshTokenRepository(tx)
+
+			ctx := t.Context()
+			if tt.contextSetup != nil {
+				ctx = tt.contextSetup(ctx)
+			}
+
+			authTime := time.Now().Truncate(time.Microsecond)
+			got, err := repo.UpdateAuthentication(ctx, token.FamilyID, authTime, "pwd otp mfa")
+
+			if tt.expectError {
+				assert.Error(t, err)
+				if tt.expectedErr != nil {
+					assert.ErrorIs(t, err, tt.expectedErr)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, token.JTI, got.JTI)
+
+			var stored auth.RefreshToken
+			err = tx.First(&stored, "jti = ?", token.JTI).Error
+			assert.NoError(t, err)
+			assert.True(t, authTime.Equal(stored.AuthTime))
+			assert.Equal(t, "pwd otp mfa", stored.AMR)
+			assert.Nil(t, stored.RevokedAt)
+
+			err = tx.First(&stored, "jti = ?", rotated.JTI).Error
+			assert.NoError(t, err)
+			assert.Equal(t, "pwd", stored.AMR)
+		})
+	}
+}
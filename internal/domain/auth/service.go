@@ -3,139 +3,449 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"gomonitor/internal/config"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/domain/mfa"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/domain/rbac"
 	"gomonitor/internal/domain/user"
 	"gomonitor/internal/observability/logging"
+	"gomonitor/internal/pkg/encryption"
 	pkgerrors "gomonitor/internal/pkg/errors"
 	"gomonitor/internal/pkg/identity"
 	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/mailer"
+	"gomonitor/internal/pkg/oidc"
+	"gomonitor/internal/pkg/opaquetoken"
 	"gomonitor/internal/pkg/password"
+	"gomonitor/internal/pkg/ratelimit"
+	"gomonitor/internal/pkg/revocation"
+	"gomonitor/internal/pkg/webauthn"
 	"log/slog"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type Service interface {
 	Login(ctx context.Context, input LoginInput) (*LoginOutput, error)
-	Logout(ctx context.Context) error
-	LogoutAll(ctx context.Context) error
+	VerifyMFA(ctx context.Context, input VerifyMFAInput) (*LoginOutput, error)
+	Logout(ctx context.Context, input LogoutInput) error
+	LogoutAll(ctx context.Context, input LogoutInput) error
 	Refresh(ctx context.Context, input RefreshInput) (*RefreshOutput, error)
+	ListSessions(ctx context.Context, input ListSessionsInput) ([]SessionOutput, error)
+	RevokeSession(ctx context.Context, input RevokeSessionInput) error
+	ForgotPassword(ctx context.Context, input ForgotPasswordInput) error
+	ResetPassword(ctx context.Context, input ResetPasswordInput) error
+	ChangePassword(ctx context.Context, input ChangePasswordInput) error
+	RequestMagicLink(ctx context.Context, input MagicLinkInput) error
+	ConsumeMagicLink(ctx context.Context, input ConsumeMagicLinkInput) (*LoginOutput, error)
+	StartPasskeyLogin(ctx context.Context, input PasskeyLoginOptionsInput) (*webauthn.RequestOptions, error)
+	CompletePasskeyLogin(ctx context.Context, input PasskeyLoginInput) (*LoginOutput, error)
+	StartOIDCLogin(ctx context.Context) (*OIDCLoginOutput, error)
+	CompleteOIDCLogin(ctx context.Context, input OIDCCallbackInput) (*LoginOutput, error)
+	Impersonate(ctx context.Context, input ImpersonateInput) (*ImpersonationOutput, error)
+	Reauthenticate(ctx context.Context, input ReauthenticateInput) (*ReauthenticateOutput, error)
+	StartPasskeyReauthentication(ctx context.Context) (*webauthn.RequestOptions, error)
+	CompletePasskeyReauthentication(ctx context.Context, input PasskeyReauthenticateInput) (*ReauthenticateOutput, error)
 }
 
 type ServiceDeps struct {
 	AuthConfig       *config.AuthConfig
-	RefreshTokenRepo RefreshTokenRepository
-	UserRepo         user.UserRepository
-	Logger           *slog.Logger
-	Hasher           password.PasswordHasher
-	TokenManager     jwt.TokenManager
+	AuthEventsConfig *config.AuthEventsConfig
+	Cipher           encryption.Cipher
+	Denylist         revocation.Denylist
+	Events           authevent.Recorder
+	IdentityRepo     user.ExternalIdentityRepository
+	// LoginHistory is optional, without it logins from new devices aren't recognised.
+	LoginHistory authevent.Repository
+	LoginLockout ratelimit.Lockout
+	// LoginPolicy is optional, without it logins from new devices are only notified.
+	LoginPolicy LoginPolicy
+	Mailer      mailer.Mailer
+	MFA         mfa.Service
+	// OIDC is optional, without it the OpenID Connect login is disabled.
+	OIDC       oidc.Provider
+	OIDCConfig *config.OIDCConfig
+	// Passkeys is optional, without it passkeys can't log in.
+	Passkeys          passkey.Service
+	PasswordPolicy    user.PasswordPolicy
+	PasswordResetRepo PasswordResetRepository
+	RBAC              rbac.Service
+	RefreshTokenRepo  RefreshTokenRepository
+	// UsedTokens exchanges MFA challenges and login links once, without it both are refused.
+	UsedTokens   revocation.UsedTokens
+	UserRepo     user.UserRepository
+	Logger       *slog.Logger
+	Hasher       password.PasswordHasher
+	TokenManager jwt.TokenManager
 }
 
 type service struct {
-	authCfg          *config.AuthConfig
-	logger           *slog.Logger
-	hasher           password.PasswordHasher
-	refreshTokenRepo RefreshTokenRepository
-	userRepo         user.UserRepository
-	tokenManager     jwt.TokenManager
+	authCfg           *config.AuthConfig
+	cipher            encryption.Cipher
+	denylist          revocation.Denylist
+	events            authevent.Recorder
+	eventsCfg         *config.AuthEventsConfig
+	identityRepo      user.ExternalIdentityRepository
+	logger            *slog.Logger
+	loginHistory      authevent.Repository
+	loginLockout      ratelimit.Lockout
+	loginPolicy       LoginPolicy
+	mailer            mailer.Mailer
+	mfa               mfa.Service
+	oidc              oidc.Provider
+	oidcCfg           *config.OIDCConfig
+	passkeys          passkey.Service
+	hasher            password.PasswordHasher
+	passwordPolicy    user.PasswordPolicy
+	passwordResetRepo PasswordResetRepository
+	rbac              rbac.Service
+	refreshTokenRepo  RefreshTokenRepository
+	usedTokens        revocation.UsedTokens
+	userRepo          user.UserRepository
+	tokenManager      jwt.TokenManager
 }
 
 func NewService(deps *ServiceDeps) Service {
 	return &service{
-		authCfg:          deps.AuthConfig,
-		logger:           deps.Logger,
-		hasher:           deps.Hasher,
-		refreshTokenRepo: deps.RefreshTokenRepo,
-		userRepo:         deps.UserRepo,
-		tokenManager:     deps.TokenManager,
+		authCfg:           deps.AuthConfig,
+		cipher:            deps.Cipher,
+		denylist:          deps.Denylist,
+		events:            deps.Events,
+		eventsCfg:         deps.AuthEventsConfig,
+		identityRepo:      deps.IdentityRepo,
+		logger:            deps.Logger,
+		loginHistory:      deps.LoginHistory,
+		loginLockout:      deps.LoginLockout,
+		loginPolicy:       deps.LoginPolicy,
+		mailer:            deps.Mailer,
+		mfa:               deps.MFA,
+		oidc:              deps.OIDC,
+		oidcCfg:           deps.OIDCConfig,
+		passkeys:          deps.Passkeys,
+		hasher:            deps.Hasher,
+		passwordPolicy:    deps.PasswordPolicy,
+		passwordResetRepo: deps.PasswordResetRepo,
+		rbac:              deps.RBAC,
+		refreshTokenRepo:  deps.RefreshTokenRepo,
+		usedTokens:        deps.UsedTokens,
+		userRepo:          deps.UserRepo,
+		tokenManager:      deps.TokenManager,
 	}
 }
 
 func (s *service) Login(ctx context.Context, input LoginInput) (*LoginOutput, error) {
+	lockoutKey := strings.ToLower(strings.TrimSpace(input.Email))
+	locked := s.isLoginLocked(ctx, lockoutKey)
+
 	user, err := s.userRepo.GetByEmail(ctx, input.Email)
 	hash := s.authCfg.FakeHash
 	if err == nil && user != nil {
 		hash = user.Password
 	}
 
-	// First apply the hash to avoid enumeration.
+	// First apply the hash to avoid enumeration, locked accounts included.
 	verifyErr := s.hasher.VerifyPassword(hash, input.Password)
 
-	// Treat DB error or non existent user.
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, pkgerrors.NewUnauthorizedError(MsgInvalidCredentials)
-		}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, pkgerrors.NewInternalError(err)
 	}
 
-	if verifyErr != nil {
+	var userID uint
+	if err == nil {
+		userID = user.ID
+	}
+
+	// Attempts during the lock are rejected without counting, so the lock can't be extended forever.
+	if locked {
+		logging.FromContext(ctx).Warn(
+			"login attempt on locked account",
+			slog.Any("email", input.Email),
+		)
+
+		s.recordEvent(ctx, authevent.TypeLoginFailure, userID, input.Client, authevent.ReasonAccountLocked)
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidCredentials)
+	}
+
+	// Unknown emails count as well, otherwise only existing accounts would get locked.
+	if err != nil || verifyErr != nil {
 		logging.FromContext(ctx).Warn(
 			"unauthorized login request",
 			slog.Any("email", input.Email),
 		)
 
+		s.recordEvent(ctx, authevent.TypeLoginFailure, userID, input.Client, authevent.ReasonInvalidCredentials)
+		s.loginFailed(ctx, lockoutKey, userID, input.Client)
 		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidCredentials)
 	}
 
+	s.resetLoginFailures(ctx, lockoutKey)
+	s.rehashPassword(ctx, user, input.Password)
+
+	if s.authCfg.RequireVerifiedEmail && !user.EmailVerified() {
+		logging.FromContext(ctx).Warn("login with unverified email", slog.Uint64("user_id", uint64(user.ID)))
+		s.recordEvent(ctx, authevent.TypeLoginFailure, user.ID, input.Client, authevent.ReasonEmailNotVerified)
+		return nil, pkgerrors.NewUnauthorizedError(MsgEmailNotVerified)
+	}
+
+	if login := s.unusualLogin(ctx, user, input.Client); login != nil {
+		return s.completeUnusualLogin(ctx, login)
+	}
+
+	return s.completeLogin(ctx, user, input.Client, []string{AMRPassword})
+}
+
+// completeLogin starts the session of a user authenticated with the amr methods, unless a second factor is
+// still required.
+func (s *service) completeLogin(ctx context.Context, user *user.User, client ClientInfo, amr []string) (*LoginOutput, error) {
+	methods, err := s.mfaMethods(ctx, user.ID)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	// The first factor alone proves nothing more, the challenge is exchanged on VerifyMFA.
+	if len(methods) > 0 {
+		challenge, err := s.tokenManager.GenerateMFAToken(user.ID, user.Role)
+		if err != nil {
+			return nil, pkgerrors.NewInternalError(err)
+		}
+
+		return &LoginOutput{
+			MFAChallenge: &MFAChallengeOutput{
+				Token:     challenge.Token,
+				ExpiresAt: challenge.Meta.ExpiresAt,
+				Methods:   methods,
+			},
+		}, nil
+	}
+
+	return s.startSession(ctx, user, client, amr)
+}
+
+// VerifyMFA completes a login challenged for a second factor. The challenge is single use,
+// failed codes count towards a lockout per user like failed passwords do per email.
+func (s *service) VerifyMFA(ctx context.Context, input VerifyMFAInput) (*LoginOutput, error) {
+	challenge, err := s.mfaChallenge(ctx, input.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutKey := mfa.LockoutKey(challenge.UserID)
+	if s.isLoginLocked(ctx, lockoutKey) {
+		logging.FromContext(ctx).Warn("mfa attempt on locked account", slog.Any("user_id", challenge.UserID))
+		return nil, pkgerrors.NewUnauthorizedError(mfa.MsgInvalidMFACode)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewUnauthorizedError(MsgInvalidMFAChallenge)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	if err := s.mfa.Verify(ctx, mfa.VerifyInput{UserID: user.ID, Code: input.Code}); err != nil {
+		var appErr *pkgerrors.AppError
+		if errors.As(err, &appErr) && appErr.StatusCode == http.StatusUnauthorized {
+			logging.FromContext(ctx).Warn("invalid mfa code", slog.Any("user_id", user.ID))
+			s.recordEvent(ctx, authevent.TypeLoginFailure, user.ID, input.Client, authevent.ReasonInvalidMFACode)
+			s.loginFailed(ctx, lockoutKey, user.ID, input.Client)
+		}
+		return nil, err
+	}
+
+	s.resetLoginFailures(ctx, lockoutKey)
+
+	if err := s.useMFAChallenge(ctx, *challenge.JTI); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, user, input.Client, []string{AMROTP, AMRMultiFactor})
+}
+
+// mfaChallenge validates a challenge returned by a login, it must not have been exchanged yet.
+func (s *service) mfaChallenge(ctx context.Context, token string) (*identity.Principal, error) {
+	challenge, err := s.tokenManager.ValidateMFAToken(token)
+	if err != nil || challenge.JTI == nil {
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidMFAChallenge)
+	}
+
+	if err := s.checkMFAChallengeUnused(ctx, *challenge.JTI); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// checkMFAChallengeUnused rejects early a challenge already exchanged, useMFAChallenge is what prevents a second use.
+func (s *service) checkMFAChallengeUnused(ctx context.Context, jti uuid.UUID) error {
+	if s.usedTokens == nil {
+		return pkgerrors.NewInternalError(errUsedTokensStore)
+	}
+
+	used, err := s.usedTokens.IsUsed(ctx, jti)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+	if used {
+		return pkgerrors.NewUnauthorizedError(MsgInvalidMFAChallenge)
+	}
+
+	return nil
+}
+
+// useMFAChallenge records an exchanged challenge as used until it expires, it can't start another session.
+// The check and the record are atomic, of concurrent exchanges only one succeeds. Without the store of used
+// tokens, or while Redis is unavailable, a challenge can't be used only once and it is refused.
+func (s *service) useMFAChallenge(ctx context.Context, jti uuid.UUID) error {
+	if s.usedTokens == nil {
+		return pkgerrors.NewInternalError(errUsedTokensStore)
+	}
+
+	fresh, err := s.usedTokens.Use(ctx, jti, s.authCfg.MFAChallengeTTL)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+	if !fresh {
+		logging.FromContext(ctx).Warn("mfa challenge replayed", slog.String("jti", jti.String()))
+		return pkgerrors.NewUnauthorizedError(MsgInvalidMFAChallenge)
+	}
+
+	return nil
+}
+
+// startSession issues the token pair of a new session family, authenticated now with the amr methods.
+func (s *service) startSession(ctx context.Context, user *user.User, client ClientInfo, amr []string) (*LoginOutput, error) {
 	refreshTokenResult, err := s.tokenManager.GenerateRefreshToken(user.ID, user.Role)
 	if err != nil {
 		return nil, pkgerrors.NewInternalError(err)
 	}
 
 	refreshTokenDb := &RefreshToken{
-		JTI:       refreshTokenResult.Meta.JTI,
-		UserID:    user.ID,
-		ExpiresAt: refreshTokenResult.Meta.ExpiresAt,
-		CreatedAt: refreshTokenResult.Meta.IssuedAt,
+		JTI:        refreshTokenResult.Meta.JTI,
+		UserID:     user.ID,
+		FamilyID:   refreshTokenResult.Meta.JTI,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		JKT:        client.DPoPJKT,
+		AuthTime:   refreshTokenResult.Meta.IssuedAt,
+		AMR:        strings.Join(amr, " "),
+		ExpiresAt:  refreshTokenResult.Meta.ExpiresAt,
+		CreatedAt:  refreshTokenResult.Meta.IssuedAt,
+		LastUsedAt: refreshTokenResult.Meta.IssuedAt,
 	}
 
-	tokenStoreErr := s.refreshTokenRepo.Create(ctx, refreshTokenDb)
-	if tokenStoreErr != nil {
+	if err := s.refreshTokenRepo.Create(ctx, refreshTokenDb); err != nil {
 		return nil, pkgerrors.NewInternalError(err)
 	}
 
-	accessTokenResult, err := s.tokenManager.GenerateAccessToken(user.ID, user.Role, refreshTokenResult.Meta.JTI)
+	accessTokenResult, err := s.tokenManager.GenerateAccessToken(
+		user.ID,
+		user.Role,
+		refreshTokenDb.FamilyID,
+		client.DPoPJKT,
+		refreshTokenDb.Authentication(),
+	)
 	if err != nil {
 		return nil, pkgerrors.NewInternalError(err)
 	}
 
+	s.recordEvent(ctx, authevent.TypeLoginSuccess, user.ID, client, "")
+
 	return &LoginOutput{
-		RefreshToken: refreshTokenResult.Token,
-		AccessToken:  accessTokenResult.Token,
+		RefreshToken:          refreshTokenResult.Token,
+		AccessToken:           accessTokenResult.Token,
+		TokenType:             tokenType(client.DPoPJKT),
+		RefreshTokenExpiresAt: refreshTokenResult.Meta.ExpiresAt,
+		AccessTokenExpiresAt:  accessTokenResult.Meta.ExpiresAt,
 	}, nil
 }
 
-func (s *service) Logout(ctx context.Context) error {
+// tokenType tells clients whether the tokens are bound to their DPoP key.
+func tokenType(jkt string) string {
+	if jkt != "" {
+		return TokenTypeDPoP
+	}
+	return TokenTypeBearer
+}
+
+// mfaMethods lists the second factors of the user, none if MFA isn't enabled.
+func (s *service) mfaMethods(ctx context.Context, userID uint) ([]string, error) {
+	var methods []string
+
+	if s.mfa != nil {
+		enabled, err := s.mfa.IsEnabled(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if enabled {
+			methods = append(methods, MFAMethodTOTP)
+		}
+	}
+
+	if s.passkeys != nil {
+		enabled, err := s.passkeys.HasPasskeys(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if enabled {
+			methods = append(methods, MFAMethodPasskey)
+		}
+	}
+
+	return methods, nil
+}
+
+func (s *service) Logout(ctx context.Context, input LogoutInput) error {
 	principal, ok := identity.PrincipalFromContext(ctx)
 	if !ok {
 		logging.FromContext(ctx).Warn("unauthenticated user creation attempt")
 		return pkgerrors.NewUnauthorizedError("unauthenticated")
 	}
 
-	if principal.RefreshJTI == nil {
-		return pkgerrors.NewUnauthorizedError("refresh token reference required")
+	if principal.SessionID == nil {
+		return pkgerrors.NewUnauthorizedError("session reference required")
 	}
 
-	if err := s.refreshTokenRepo.RevokeByJTI(ctx, *principal.RefreshJTI); err != nil {
+	if principal.Impersonated() {
+		return s.endImpersonation(ctx, principal)
+	}
+
+	// The whole family, the access token may predate the last refresh of the session.
+	if _, err := s.refreshTokenRepo.RevokeByFamilyID(ctx, *principal.SessionID); err != nil {
 		return pkgerrors.NewInternalError(err)
 	}
 
+	s.denylistSessions(ctx, *principal.SessionID)
+	s.recordEvent(ctx, authevent.TypeLogout, principal.UserID, input.Client, "")
+
 	return nil
 }
 
-func (s *service) LogoutAll(ctx context.Context) error {
+func (s *service) LogoutAll(ctx context.Context, input LogoutInput) error {
 	principal, ok := identity.PrincipalFromContext(ctx)
 	if !ok {
 		logging.FromContext(ctx).Warn("unauthenticated user creation attempt")
 		return pkgerrors.NewUnauthorizedError("unauthenticated")
 	}
 
-	if err := s.refreshTokenRepo.RevokeByUserID(ctx, principal.UserID); err != nil {
+	if err := refuseImpersonated(ctx, principal, "logout_all"); err != nil {
+		return err
+	}
+
+	jtis, err := s.refreshTokenRepo.RevokeByUserID(ctx, principal.UserID)
+	if err != nil {
 		return pkgerrors.NewInternalError(err)
 	}
 
+	s.denylistSessions(ctx, jtis...)
+	s.recordEvent(ctx, authevent.TypeLogoutAll, principal.UserID, input.Client, "")
+
 	return nil
 }
 
@@ -169,7 +479,9 @@ func (s *service) Refresh(ctx context.Context, input RefreshInput) (*RefreshOutp
 		return nil, pkgerrors.NewInternalError(err)
 	}
 
+	// A revoked token being presented again means it was leaked, kill the whole family.
 	if storedToken.RevokedAt != nil {
+		s.revokeFamily(ctx, storedToken)
 		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidToken)
 	}
 
@@ -178,12 +490,510 @@ func (s *service) Refresh(ctx context.Context, input RefreshInput) (*RefreshOutp
 		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidToken)
 	}
 
-	accessTokenResult, err := s.tokenManager.GenerateAccessToken(user.ID, user.Role, *token.JTI)
+	// A bound session is refreshed only by the holder of its key, the binding can't change either.
+	if storedToken.JKT != "" && storedToken.JKT != input.Client.DPoPJKT {
+		logging.FromContext(ctx).Warn("refresh without the dpop key of the session", slog.Uint64("user_id", uint64(user.ID)))
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidToken)
+	}
+
+	refreshTokenResult, err := s.tokenManager.GenerateRefreshToken(user.ID, user.Role)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	refreshTokenDb := &RefreshToken{
+		JTI:        refreshTokenResult.Meta.JTI,
+		UserID:     user.ID,
+		FamilyID:   storedToken.FamilyID,
+		ParentJTI:  &storedToken.JTI,
+		UserAgent:  input.Client.UserAgent,
+		IPAddress:  input.Client.IPAddress,
+		JKT:        storedToken.JKT,
+		AuthTime:   storedToken.AuthTime,
+		AMR:        storedToken.AMR,
+		ExpiresAt:  refreshTokenResult.Meta.ExpiresAt,
+		CreatedAt:  storedToken.CreatedAt,
+		LastUsedAt: refreshTokenResult.Meta.IssuedAt,
+	}
+
+	if err := s.refreshTokenRepo.Rotate(ctx, storedToken.JTI, refreshTokenDb); err != nil {
+		// Lost a race against another rotation of the same token, also a reuse.
+		if errors.Is(err, ErrRefreshTokenRevoked) {
+			s.revokeFamily(ctx, storedToken)
+			return nil, pkgerrors.NewUnauthorizedError(MsgInvalidToken)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	accessTokenResult, err := s.tokenManager.GenerateAccessToken(
+		user.ID,
+		user.Role,
+		refreshTokenDb.FamilyID,
+		storedToken.JKT,
+		refreshTokenDb.Authentication(),
+	)
 	if err != nil {
 		return nil, pkgerrors.NewInternalError(err)
 	}
 
+	s.recordEvent(ctx, authevent.TypeRefresh, user.ID, input.Client, "")
+
 	return &RefreshOutput{
-		AccessToken: accessTokenResult.Token,
+		RefreshToken:          refreshTokenResult.Token,
+		AccessToken:           accessTokenResult.Token,
+		TokenType:             tokenType(storedToken.JKT),
+		RefreshTokenExpiresAt: refreshTokenResult.Meta.ExpiresAt,
+		AccessTokenExpiresAt:  accessTokenResult.Meta.ExpiresAt,
 	}, nil
 }
+
+func (s *service) ListSessions(ctx context.Context, input ListSessionsInput) ([]SessionOutput, error) {
+	principal, userID, err := s.sessionOwner(ctx, input.UserID, identity.PermSessionsRead)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.refreshTokenRepo.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	sessions := make([]SessionOutput, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, SessionOutput{
+			JTI:        token.JTI,
+			UserAgent:  token.UserAgent,
+			IPAddress:  token.IPAddress,
+			CreatedAt:  token.CreatedAt,
+			LastUsedAt: token.LastUsedAt,
+			ExpiresAt:  token.ExpiresAt,
+			Current:    principal.SessionID != nil && *principal.SessionID == token.FamilyID,
+		})
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes the whole token family, so the session can't be resumed with an older token.
+func (s *service) RevokeSession(ctx context.Context, input RevokeSessionInput) error {
+	principal, userID, err := s.sessionOwner(ctx, input.UserID, identity.PermSessionsRevoke)
+	if err != nil {
+		return err
+	}
+
+	if err := refuseImpersonated(ctx, principal, "revoke_session"); err != nil {
+		return err
+	}
+
+	token, err := s.refreshTokenRepo.GetByJTI(ctx, input.JTI)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pkgerrors.NewNotFoundError(MsgSessionNotFound, err)
+		}
+		return pkgerrors.NewInternalError(err)
+	}
+
+	// Other users' sessions look the same as missing ones.
+	if token.UserID != userID || token.RevokedAt != nil || token.ExpiresAt.Before(time.Now()) {
+		return pkgerrors.NewNotFoundError(MsgSessionNotFound)
+	}
+
+	jtis, err := s.refreshTokenRepo.RevokeByFamilyID(ctx, token.FamilyID)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	s.denylistSessions(ctx, jtis...)
+
+	logging.FromContext(ctx).Info(
+		"session revoked",
+		slog.Uint64("user_id", uint64(userID)),
+		slog.String("family_id", token.FamilyID.String()),
+	)
+
+	return nil
+}
+
+// ForgotPassword mails a reset link if the email belongs to a user. Unknown emails succeed the same way
+// and the mail is sent in the background, so the response doesn't reveal which accounts exist.
+func (s *service) ForgotPassword(ctx context.Context, input ForgotPasswordInput) error {
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.FromContext(ctx).Info("password reset requested for unknown email", slog.Any("email", input.Email))
+			return nil
+		}
+		return pkgerrors.NewInternalError(err)
+	}
+
+	token, err := opaquetoken.New()
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	link, err := opaquetoken.Link(s.authCfg.PasswordResetURL, token)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	resetToken := &PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: opaquetoken.Hash(token),
+		ExpiresAt: time.Now().Add(s.authCfg.PasswordResetTTL),
+	}
+
+	if err := s.passwordResetRepo.Create(ctx, resetToken); err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"A password reset was requested for your account.\n\n"+
+				"Follow this link to choose a new password, it expires in %s:\n%s\n\n"+
+				"If you didn't request it, you can ignore this email.\n",
+			s.authCfg.PasswordResetTTL, link,
+		),
+	}
+
+	go s.sendMail(context.WithoutCancel(ctx), msg)
+
+	return nil
+}
+
+// ResetPassword sets the new password with a token from ForgotPassword. The token is single use,
+// every session of the user is revoked and the other pending tokens are invalidated.
+func (s *service) ResetPassword(ctx context.Context, input ResetPasswordInput) error {
+	tokenHash := opaquetoken.Hash(input.Token)
+
+	// The token is only consumed once the password is accepted, a refused one can be retried.
+	userID, err := s.passwordResetRepo.FindUserID(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.FromContext(ctx).Warn("invalid password reset token")
+			return pkgerrors.NewBadRequestError(MsgInvalidResetToken)
+		}
+		return pkgerrors.NewInternalError(err)
+	}
+
+	if err := s.checkPassword(ctx, "password", userID, input.Password); err != nil {
+		return err
+	}
+
+	userID, err = s.passwordResetRepo.Consume(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.FromContext(ctx).Warn("invalid password reset token")
+			return pkgerrors.NewBadRequestError(MsgInvalidResetToken)
+		}
+		return pkgerrors.NewInternalError(err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pkgerrors.NewBadRequestError(MsgInvalidResetToken)
+		}
+		return pkgerrors.NewInternalError(err)
+	}
+
+	hash, err := s.hasher.HashPassword(input.Password)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hash); err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	s.rememberPassword(ctx, user.ID, hash)
+
+	jtis, err := s.refreshTokenRepo.RevokeByUserID(ctx, user.ID)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	s.denylistSessions(ctx, jtis...)
+
+	if err := s.passwordResetRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		logging.FromContext(ctx).Warn("failed to invalidate password reset tokens", slog.Any("err", err))
+	}
+
+	s.resetLoginFailures(ctx, strings.ToLower(strings.TrimSpace(user.Email)))
+
+	logging.FromContext(ctx).Info(
+		"password reset",
+		slog.Uint64("user_id", uint64(user.ID)),
+		slog.Int("revoked_sessions", len(jtis)),
+	)
+
+	return nil
+}
+
+// ChangePassword sets a new password for the caller, who has to provide the current one.
+// Wrong current passwords count towards the login lockout, the other sessions are revoked unless kept.
+func (s *service) ChangePassword(ctx context.Context, input ChangePasswordInput) error {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Warn("unauthenticated password change attempt")
+		return pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	if err := refuseImpersonated(ctx, principal, "change_password"); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, principal.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pkgerrors.NewUnauthorizedError("unauthenticated")
+		}
+		return pkgerrors.NewInternalError(err)
+	}
+
+	lockoutKey := strings.ToLower(strings.TrimSpace(user.Email))
+	locked := s.isLoginLocked(ctx, lockoutKey)
+
+	verifyErr := s.hasher.VerifyPassword(user.Password, input.CurrentPassword)
+
+	if locked {
+		logging.FromContext(ctx).Warn("password change on locked account", slog.Uint64("user_id", uint64(user.ID)))
+		return pkgerrors.NewUnauthorizedError(MsgInvalidCurrentPassword)
+	}
+
+	if verifyErr != nil {
+		logging.FromContext(ctx).Warn("password change with invalid current password", slog.Uint64("user_id", uint64(user.ID)))
+		s.loginFailed(ctx, lockoutKey, user.ID, input.Client)
+		return pkgerrors.NewUnauthorizedError(MsgInvalidCurrentPassword)
+	}
+
+	s.resetLoginFailures(ctx, lockoutKey)
+
+	if input.NewPassword == input.CurrentPassword {
+		return pkgerrors.NewBadRequestError(MsgPasswordUnchanged)
+	}
+
+	if err := s.checkPassword(ctx, "new_password", user.ID, input.NewPassword); err != nil {
+		return err
+	}
+
+	hash, err := s.hasher.HashPassword(input.NewPassword)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hash); err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	s.rememberPassword(ctx, user.ID, hash)
+
+	// A reset link requested before the change would undo it.
+	if err := s.passwordResetRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		logging.FromContext(ctx).Warn("failed to invalidate password reset tokens", slog.Any("err", err))
+	}
+
+	revoked := 0
+	if !input.KeepOtherSessions {
+		jtis, err := s.revokeOtherSessions(ctx, principal)
+		if err != nil {
+			return pkgerrors.NewInternalError(err)
+		}
+
+		s.denylistSessions(ctx, jtis...)
+		revoked = len(jtis)
+	}
+
+	logging.FromContext(ctx).Info(
+		"password changed",
+		slog.Uint64("user_id", uint64(user.ID)),
+		slog.Int("revoked_sessions", revoked),
+	)
+
+	return nil
+}
+
+// revokeOtherSessions keeps the session of the principal, all of them are revoked without one.
+func (s *service) revokeOtherSessions(ctx context.Context, principal *identity.Principal) ([]uuid.UUID, error) {
+	if principal.SessionID == nil {
+		return s.refreshTokenRepo.RevokeByUserID(ctx, principal.UserID)
+	}
+
+	return s.refreshTokenRepo.RevokeOtherFamilies(ctx, principal.UserID, *principal.SessionID)
+}
+
+func (s *service) sendMail(ctx context.Context, msg mailer.Message) {
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		logging.FromContext(ctx).Error("failed to send mail", slog.String("subject", msg.Subject), slog.Any("err", err))
+	}
+}
+
+// sessionOwner resolves whose sessions are managed, other users' sessions require the permission.
+func (s *service) sessionOwner(ctx context.Context, userID *uint, permission identity.Permission) (*identity.Principal, uint, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Warn("unauthenticated session request")
+		return nil, 0, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	if userID == nil || *userID == principal.UserID {
+		return principal, principal.UserID, nil
+	}
+
+	if !principal.HasPermission(permission) {
+		logging.FromContext(ctx).Warn("unauthorized session request",
+			"permission", permission,
+			"user_id", principal.UserID,
+			"user_role", principal.Role,
+			"target_user_id", *userID,
+		)
+		return nil, 0, pkgerrors.NewForbiddenError()
+	}
+
+	return principal, *userID, nil
+}
+
+// revokeFamily revokes every token descending from the same login after a reuse is detected.
+func (s *service) revokeFamily(ctx context.Context, token *RefreshToken) {
+	logging.FromContext(ctx).Warn(
+		"refresh token reuse detected, revoking token family",
+		slog.Uint64("user_id", uint64(token.UserID)),
+		slog.String("family_id", token.FamilyID.String()),
+		slog.String("jti", token.JTI.String()),
+	)
+
+	jtis, err := s.refreshTokenRepo.RevokeByFamilyID(ctx, token.FamilyID)
+	if err != nil {
+		logging.FromContext(ctx).Error(
+			"failed to revoke refresh token family",
+			slog.String("family_id", token.FamilyID.String()),
+			slog.Any("err", err),
+		)
+		return
+	}
+
+	s.denylistSessions(ctx, jtis...)
+}
+
+// isLoginLocked fails open, the per IP limiter still applies when the lockout is unavailable.
+func (s *service) isLoginLocked(ctx context.Context, key string) bool {
+	if s.loginLockout == nil {
+		return false
+	}
+
+	remaining, err := s.loginLockout.Locked(ctx, key)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to check login lockout", slog.Any("err", err))
+		return false
+	}
+
+	return remaining > 0
+}
+
+// loginFailed counts a failed attempt against the key, userID is 0 for attempts on unknown emails.
+func (s *service) loginFailed(ctx context.Context, key string, userID uint, client ClientInfo) {
+	if s.loginLockout == nil {
+		return
+	}
+
+	lock, err := s.loginLockout.Fail(ctx, key)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to record login failure", slog.Any("err", err))
+		return
+	}
+
+	if lock > 0 {
+		logging.FromContext(ctx).Warn(
+			"account login locked",
+			slog.Any("email", key),
+			slog.Duration("lock", lock),
+		)
+		s.recordEvent(ctx, authevent.TypeLockout, userID, client, authevent.ReasonTooManyFailures)
+	}
+}
+
+// recordEvent adds an event to the authentication log, userID is 0 when no user matched.
+func (s *service) recordEvent(ctx context.Context, eventType authevent.Type, userID uint, client ClientInfo, reason string) {
+	if s.events == nil {
+		return
+	}
+
+	event := authevent.Event{
+		Type:      eventType,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Reason:    reason,
+	}
+	if userID != 0 {
+		event.UserID = &userID
+	}
+
+	s.events.Record(ctx, event)
+}
+
+func (s *service) resetLoginFailures(ctx context.Context, key string) {
+	if s.loginLockout == nil {
+		return
+	}
+
+	if err := s.loginLockout.Reset(ctx, key); err != nil {
+		logging.FromContext(ctx).Warn("failed to reset login failures", slog.Any("err", err))
+	}
+}
+
+// checkPassword applies the password policy to a new password, field names it in the violations.
+func (s *service) checkPassword(ctx context.Context, field string, userID uint, password string) error {
+	if s.passwordPolicy == nil {
+		return nil
+	}
+
+	return s.passwordPolicy.Check(ctx, user.CheckPasswordInput{Field: field, UserID: userID, Password: password})
+}
+
+// rememberPassword adds the new hash to the password history, a failure only allows reusing it later.
+func (s *service) rememberPassword(ctx context.Context, userID uint, hash string) {
+	if s.passwordPolicy == nil {
+		return
+	}
+
+	if err := s.passwordPolicy.Remember(ctx, userID, hash); err != nil {
+		logging.FromContext(ctx).Warn("failed to record password history", slog.Uint64("user_id", uint64(userID)), slog.Any("err", err))
+	}
+}
+
+// rehashPassword upgrades a hash made with an outdated algorithm or cost, the login goes on if it fails.
+func (s *service) rehashPassword(ctx context.Context, user *user.User, password string) {
+	if !s.hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := s.hasher.HashPassword(password)
+	if err == nil {
+		err = s.userRepo.UpdatePassword(ctx, user.ID, hash)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to rehash password", slog.Uint64("user_id", uint64(user.ID)), slog.Any("err", err))
+		return
+	}
+
+	user.Password = hash
+}
+
+// denylistSessions rejects the access tokens already issued for the token families, not only future refreshes.
+// The entries live as long as an access token can, a failure only delays the revocation until they expire.
+func (s *service) denylistSessions(ctx context.Context, familyIDs ...uuid.UUID) {
+	if s.denylist == nil {
+		return
+	}
+
+	for _, familyID := range familyIDs {
+		if err := s.denylist.Revoke(ctx, familyID, s.authCfg.AccessTokenTTL); err != nil {
+			logging.FromContext(ctx).Warn(
+				"failed to denylist session",
+				slog.String("family_id", familyID.String()),
+				slog.Any("err", err),
+			)
+		}
+	}
+}
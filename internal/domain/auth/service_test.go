@@ -2,23 +2,32 @@ package auth_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"gomonitor/internal/config"
 	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/domain/mfa"
 	"gomonitor/internal/domain/user"
 	"gomonitor/internal/domain/user/testdata"
 	"gomonitor/internal/mocks"
 	pkgerrors "gomonitor/internal/pkg/errors"
 	"gomonitor/internal/pkg/identity"
 	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/mailer"
+	"gomonitor/internal/pkg/revocation"
 	"gomonitor/internal/testutil"
 	"log/slog"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -31,10 +40,15 @@ type loginMocks struct {
 }
 
 type logoutMocks struct {
+	denylist         *mocks.MockDenylist
+	events           *mocks.MockAuthEventRecorder
 	refreshTokenRepo *mocks.MockRefreshTokenRepository
 }
 
+var logoutClient = auth.ClientInfo{IPAddress: "192.0.2.1", UserAgent: "test-agent"}
+
 type refreshMocks struct {
+	denylist         *mocks.MockDenylist
 	userRepo         *mocks.MockUserRepository
 	refreshTokenRepo *mocks.MockRefreshTokenRepository
 	jwtManager       *mocks.MockJwtManager
@@ -61,6 +75,10 @@ func TestService_Login(t *testing.T) {
 	defaultInput := auth.LoginInput{
 		Email:    "test@test.com",
 		Password: "password123",
+		Client: auth.ClientInfo{
+			UserAgent: "curl/8.0",
+			IPAddress: "192.0.2.1",
+		},
 	}
 
 	defaultJti := uuid.New()
@@ -163,6 +181,10 @@ func TestService_Login(t *testing.T) {
 					On("VerifyPassword", defaultUserReturn.Password, "password123").
 					Return(nil)
 
+				m.hasher.
+					On("NeedsRehash", defaultUserReturn.Password).
+					Return(false)
+
 				m.jwtManager.
 					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
 					Return(nil, errors.New("signing error"))
@@ -184,6 +206,10 @@ func TestService_Login(t *testing.T) {
 					On("VerifyPassword", defaultUserReturn.Password, "password123").
 					Return(nil)
 
+				m.hasher.
+					On("NeedsRehash", defaultUserReturn.Password).
+					Return(false)
+
 				m.jwtManager.
 					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
 					Return(fakeRefreshTokenResult, nil)
@@ -209,6 +235,10 @@ func TestService_Login(t *testing.T) {
 					On("VerifyPassword", defaultUserReturn.Password, "password123").
 					Return(nil)
 
+				m.hasher.
+					On("NeedsRehash", defaultUserReturn.Password).
+					Return(false)
+
 				m.jwtManager.
 					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
 					Return(fakeRefreshTokenResult, nil)
@@ -238,12 +268,20 @@ func TestService_Login(t *testing.T) {
 					On("VerifyPassword", defaultUserReturn.Password, "password123").
 					Return(nil)
 
+				m.hasher.
+					On("NeedsRehash", defaultUserReturn.Password).
+					Return(false)
+
 				m.jwtManager.
 					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
 					Return(fakeRefreshTokenResult, nil)
 
 				m.refreshTokenRepo.
-					On("Create", mock.Anything, mock.Anything).
+					On("Create", mock.Anything, mock.MatchedBy(func(token *auth.RefreshToken) bool {
+						return token.JTI == defaultJti && token.FamilyID == defaultJti && token.ParentJTI == nil &&
+							token.UserAgent == "curl/8.0" && token.IPAddress == "192.0.2.1" &&
+							token.AMR == auth.AMRPassword
+					})).
 					Return(nil)
 
 				m.jwtManager.
@@ -253,6 +291,47 @@ func TestService_Login(t *testing.T) {
 			expected: &auth.LoginOutput{
 				RefreshToken: fakeRefreshToken,
 				AccessToken:  fakeAccessToken,
+				TokenType:    auth.TokenTypeBearer,
+			},
+		},
+		{
+			name: "success bound to dpop key",
+			input: auth.LoginInput{
+				Email:    "test@test.com",
+				Password: "password123",
+				Client:   auth.ClientInfo{DPoPJKT: "thumbprint"},
+			},
+			setupMocks: func(m *loginMocks) {
+				m.userRepo.
+					On("GetByEmail", mock.Anything, "test@test.com").
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.hasher.
+					On("VerifyPassword", defaultUserReturn.Password, "password123").
+					Return(nil)
+
+				m.hasher.
+					On("NeedsRehash", defaultUserReturn.Password).
+					Return(false)
+
+				m.jwtManager.
+					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
+					Return(fakeRefreshTokenResult, nil)
+
+				m.refreshTokenRepo.
+					On("Create", mock.Anything, mock.MatchedBy(func(token *auth.RefreshToken) bool {
+						return token.JKT == "thumbprint"
+					})).
+					Return(nil)
+
+				m.jwtManager.
+					On("GenerateAccessToken", defaultUserReturn.ID, defaultUserReturn.Role, "thumbprint").
+					Return(fakeAccessTokenResult, nil)
+			},
+			expected: &auth.LoginOutput{
+				RefreshToken: fakeRefreshToken,
+				AccessToken:  fakeAccessToken,
+				TokenType:    auth.TokenTypeDPoP,
 			},
 		},
 	}
@@ -304,487 +383,2451 @@ func TestService_Login(t *testing.T) {
 	}
 }
 
-func TestService_Logout(t *testing.T) {
+func TestService_LoginLockout(t *testing.T) {
 	t.Parallel()
 
-	defaultJti := uuid.New()
+	fakeHash := testdata.TestPasswordHash
+	lockErr := errors.New("redis down")
+
+	input := auth.LoginInput{
+		Email:    " Test@Test.com",
+		Password: "password123",
+	}
+
+	defaultUser := &user.User{
+		ID:       1,
+		Email:    "test@test.com",
+		Password: "userHash",
+		Role:     identity.RoleUser,
+	}
+
+	tokenResult := &jwt.RefreshTokenResult{Token: "refresh", Meta: jwt.TokenMetadata{JTI: uuid.New()}}
 
 	tests := []struct {
 		name       string
-		setupCtx   func(ctx context.Context) context.Context
-		setupMocks func(m *logoutMocks)
-		assertErr  func(t *testing.T, err error)
+		setupMocks func(m *loginMocks, lockout *mocks.MockLockout)
+		success    bool
 	}{
 		{
-			name: "no principal",
-			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+			name: "locked account rejects the right password after hashing",
+			setupMocks: func(m *loginMocks, lockout *mocks.MockLockout) {
+				lockout.On("Locked", mock.Anything, "test@test.com").Return(time.Minute, nil)
+
+				m.userRepo.
+					On("GetByEmail", mock.Anything, input.Email).
+					Return(testutil.Ok(defaultUser))
+
+				m.hasher.
+					On("VerifyPassword", defaultUser.Password, "password123").
+					Return(nil)
 			},
 		},
 		{
-			name: "missing refresh jti",
-			setupCtx: func(ctx context.Context) context.Context {
-				return identity.WithPrincipal(ctx, &identity.Principal{
-					UserID: 1,
-					Role:   identity.RoleAdmin,
-					Source: identity.AuthExternal,
-				})
-			},
-			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+			name: "locked unknown email hashes the fake hash",
+			setupMocks: func(m *loginMocks, lockout *mocks.MockLockout) {
+				lockout.On("Locked", mock.Anything, "test@test.com").Return(time.Minute, nil)
+
+				m.userRepo.
+					On("GetByEmail", mock.Anything, input.Email).
+					Return(testutil.Err[*user.User](gorm.ErrRecordNotFound))
+
+				m.hasher.
+					On("VerifyPassword", fakeHash, "password123").
+					Return(nil)
 			},
 		},
 		{
-			name: "db revoking error",
-			setupMocks: func(m *logoutMocks) {
-				m.refreshTokenRepo.
-					On("RevokeByJTI", mock.Anything, defaultJti).
-					Return(errors.New("error"))
-			},
-			setupCtx: func(ctx context.Context) context.Context {
-				return identity.WithPrincipal(ctx, &identity.Principal{
-					UserID:     1,
-					Role:       identity.RoleAdmin,
-					Source:     identity.AuthExternal,
-					RefreshJTI: &defaultJti,
-				})
+			name: "wrong password records a failure",
+			setupMocks: func(m *loginMocks, lockout *mocks.MockLockout) {
+				lockout.On("Locked", mock.Anything, "test@test.com").Return(time.Duration(0), nil)
+				lockout.On("Fail", mock.Anything, "test@test.com").Return(time.Minute, nil)
+
+				m.userRepo.
+					On("GetByEmail", mock.Anything, input.Email).
+					Return(testutil.Ok(defaultUser))
+
+				m.hasher.
+					On("VerifyPassword", defaultUser.Password, "password123").
+					Return(bcrypt.ErrMismatchedHashAndPassword)
 			},
-			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+		},
+		{
+			name: "unknown email records a failure",
+			setupMocks: func(m *loginMocks, lockout *mocks.MockLockout) {
+				lockout.On("Locked", mock.Anything, "test@test.com").Return(time.Duration(0), nil)
+				lockout.On("Fail", mock.Anything, "test@test.com").Return(time.Duration(0), lockErr)
+
+				m.userRepo.
+					On("GetByEmail", mock.Anything, input.Email).
+					Return(testutil.Err[*user.User](gorm.ErrRecordNotFound))
+
+				m.hasher.
+					On("VerifyPassword", fakeHash, "password123").
+					Return(nil)
 			},
 		},
 		{
-			name: "success",
-			setupMocks: func(m *logoutMocks) {
+			name: "lockout unavailable fails open and resets on success",
+			setupMocks: func(m *loginMocks, lockout *mocks.MockLockout) {
+				lockout.On("Locked", mock.Anything, "test@test.com").Return(time.Duration(0), lockErr)
+				lockout.On("Reset", mock.Anything, "test@test.com").Return(lockErr)
+
+				m.userRepo.
+					On("GetByEmail", mock.Anything, input.Email).
+					Return(testutil.Ok(defaultUser))
+
+				m.hasher.
+					On("VerifyPassword", defaultUser.Password, "password123").
+					Return(nil)
+
+				m.hasher.
+					On("NeedsRehash", defaultUser.Password).
+					Return(false)
+
+				m.jwtManager.
+					On("GenerateRefreshToken", defaultUser.ID, defaultUser.Role).
+					Return(tokenResult, nil)
+
 				m.refreshTokenRepo.
-					On("RevokeByJTI", mock.Anything, defaultJti).
+					On("Create", mock.Anything, mock.Anything).
 					Return(nil)
+
+				m.jwtManager.
+					On("GenerateAccessToken", defaultUser.ID, defaultUser.Role, "").
+					Return(&jwt.AccessTokenResult{Token: "access"}, nil)
 			},
-			setupCtx: func(ctx context.Context) context.Context {
-				return identity.WithPrincipal(ctx, &identity.Principal{
-					UserID:     1,
-					Role:       identity.RoleAdmin,
-					Source:     identity.AuthExternal,
-					RefreshJTI: &defaultJti,
-				})
-			},
+			success: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			refreshTokenRepo := &mocks.MockRefreshTokenRepository{}
-
-			logoutMocks := &logoutMocks{
-				refreshTokenRepo: refreshTokenRepo,
+			m := &loginMocks{
+				userRepo:         &mocks.MockUserRepository{},
+				refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+				hasher:           &mocks.MockPasswordHasher{},
+				jwtManager:       &mocks.MockJwtManager{},
 			}
+			lockout := &mocks.MockLockout{}
 
-			if tt.setupMocks != nil {
-				tt.setupMocks(logoutMocks)
-			}
+			tt.setupMocks(m, lockout)
 
-			svcDeps := &auth.ServiceDeps{
+			service := auth.NewService(&auth.ServiceDeps{
+				AuthConfig:       &config.AuthConfig{FakeHash: fakeHash},
+				Hasher:           m.hasher,
+				LoginLockout:     lockout,
+				UserRepo:         m.userRepo,
 				Logger:           slog.Default(),
-				RefreshTokenRepo: refreshTokenRepo,
-			}
-			service := auth.NewService(svcDeps)
-
-			ctx := t.Context()
-			if tt.setupCtx != nil {
-				ctx = tt.setupCtx(ctx)
-			}
+				TokenManager:     m.jwtManager,
+				RefreshTokenRepo: m.refreshTokenRepo,
+			})
 
-			err := service.Logout(ctx)
+			result, err := service.Login(t.Context(), input)
 
-			if tt.assertErr != nil {
-				assert.Error(t, err)
-				tt.assertErr(t, err)
-			} else {
+			if tt.success {
 				assert.NoError(t, err)
+				assert.NotNil(t, result)
+			} else {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, auth.MsgInvalidCredentials, appErr.Message)
+				assert.Nil(t, result)
 			}
 
-			refreshTokenRepo.AssertExpectations(t)
+			lockout.AssertExpectations(t)
+			m.userRepo.AssertExpectations(t)
+			m.hasher.AssertExpectations(t)
+			m.jwtManager.AssertExpectations(t)
+			m.refreshTokenRepo.AssertExpectations(t)
 		})
 	}
 }
 
-func TestService_LogoutAll(t *testing.T) {
+func TestService_LoginEvents(t *testing.T) {
 	t.Parallel()
 
-	userId := uint(1)
+	fakeHash := testdata.TestPasswordHash
+	client := auth.ClientInfo{IPAddress: "192.0.2.1", UserAgent: "test-agent"}
+	input := auth.LoginInput{Email: "test@test.com", Password: "password123", Client: client}
+
+	defaultUser := &user.User{
+		ID:       1,
+		Email:    "test@test.com",
+		Password: "userHash",
+		Role:     identity.RoleUser,
+	}
+
+	tokenResult := &jwt.RefreshTokenResult{Token: "refresh", Meta: jwt.TokenMetadata{JTI: uuid.New()}}
+
+	event := func(eventType authevent.Type, userID *uint, reason string) authevent.Event {
+		return authevent.Event{
+			Type:      eventType,
+			UserID:    userID,
+			IPAddress: client.IPAddress,
+			UserAgent: client.UserAgent,
+			Reason:    reason,
+		}
+	}
+
 	tests := []struct {
 		name       string
-		setupCtx   func(ctx context.Context) context.Context
-		setupMocks func(m *logoutMocks)
-		assertErr  func(t *testing.T, err error)
+		setupMocks func(m *loginMocks, lockout *mocks.MockLockout)
+		events     []authevent.Event
 	}{
 		{
-			name: "no principal",
-			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+			name: "locked account",
+			setupMocks: func(m *loginMocks, lockout *mocks.MockLockout) {
+				lockout.On("Locked", mock.Anything, "test@test.com").Return(time.Minute, nil)
+				m.userRepo.On("GetByEmail", mock.Anything, input.Email).Return(testutil.Ok(defaultUser))
+				m.hasher.On("VerifyPassword", defaultUser.Password, "password123").Return(nil)
+			},
+			events: []authevent.Event{
+				event(authevent.TypeLoginFailure, &defaultUser.ID, authevent.ReasonAccountLocked),
 			},
 		},
 		{
-			name: "db revoking error",
-			setupMocks: func(m *logoutMocks) {
-				m.refreshTokenRepo.
-					On("RevokeByUserID", mock.Anything, userId).
-					Return(errors.New("error"))
+			name: "unknown email locks the account",
+			setupMocks: func(m *loginMocks, lockout *mocks.MockLockout) {
+				lockout.On("Locked", mock.Anything, "test@test.com").Return(time.Duration(0), nil)
+				lockout.On("Fail", mock.Anything, "test@test.com").Return(time.Minute, nil)
+				m.userRepo.On("GetByEmail", mock.Anything, input.Email).Return(testutil.Err[*user.User](gorm.ErrRecordNotFound))
+				m.hasher.On("VerifyPassword", fakeHash, "password123").Return(nil)
 			},
-			setupCtx: func(ctx context.Context) context.Context {
-				return identity.WithPrincipal(ctx, &identity.Principal{
-					UserID: userId,
-					Role:   identity.RoleAdmin,
-					Source: identity.AuthExternal,
-				})
+			events: []authevent.Event{
+				event(authevent.TypeLoginFailure, nil, authevent.ReasonInvalidCredentials),
+				event(authevent.TypeLockout, nil, authevent.ReasonTooManyFailures),
 			},
-			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+		},
+		{
+			name: "wrong password",
+			setupMocks: func(m *loginMocks, lockout *mocks.MockLockout) {
+				lockout.On("Locked", mock.Anything, "test@test.com").Return(time.Duration(0), nil)
+				lockout.On("Fail", mock.Anything, "test@test.com").Return(time.Duration(0), nil)
+				m.userRepo.On("GetByEmail", mock.Anything, input.Email).Return(testutil.Ok(defaultUser))
+				m.hasher.On("VerifyPassword", defaultUser.Password, "password123").Return(bcrypt.ErrMismatchedHashAndPassword)
+			},
+			events: []authevent.Event{
+				event(authevent.TypeLoginFailure, &defaultUser.ID, authevent.ReasonInvalidCredentials),
 			},
 		},
 		{
 			name: "success",
-			setupMocks: func(m *logoutMocks) {
-				m.refreshTokenRepo.
-					On("RevokeByUserID", mock.Anything, userId).
-					Return(nil)
+			setupMocks: func(m *loginMocks, lockout *mocks.MockLockout) {
+				lockout.On("Locked", mock.Anything, "test@test.com").Return(time.Duration(0), nil)
+				lockout.On("Reset", mock.Anything, "test@test.com").Return(nil)
+				m.userRepo.On("GetByEmail", mock.Anything, input.Email).Return(testutil.Ok(defaultUser))
+				m.hasher.On("VerifyPassword", defaultUser.Password, "password123").Return(nil)
+				m.hasher.On("NeedsRehash", defaultUser.Password).Return(false)
+				m.jwtManager.On("GenerateRefreshToken", defaultUser.ID, defaultUser.Role).Return(tokenResult, nil)
+				m.refreshTokenRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+				m.jwtManager.On("GenerateAccessToken", defaultUser.ID, defaultUser.Role, "").Return(&jwt.AccessTokenResult{Token: "access"}, nil)
 			},
-			setupCtx: func(ctx context.Context) context.Context {
-				return identity.WithPrincipal(ctx, &identity.Principal{
-					UserID: userId,
-					Role:   identity.RoleAdmin,
-					Source: identity.AuthExternal,
-				})
+			events: []authevent.Event{
+				event(authevent.TypeLoginSuccess, &defaultUser.ID, ""),
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			refreshTokenRepo := &mocks.MockRefreshTokenRepository{}
-
-			logoutMocks := &logoutMocks{
-				refreshTokenRepo: refreshTokenRepo,
+			m := &loginMocks{
+				userRepo:         &mocks.MockUserRepository{},
+				refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+				hasher:           &mocks.MockPasswordHasher{},
+				jwtManager:       &mocks.MockJwtManager{},
 			}
+			lockout := &mocks.MockLockout{}
+			events := &mocks.MockAuthEventRecorder{}
 
-			if tt.setupMocks != nil {
-				tt.setupMocks(logoutMocks)
+			tt.setupMocks(m, lockout)
+			for _, event := range tt.events {
+				events.On("Record", mock.Anything, event).Return().Once()
 			}
 
-			svcDeps := &auth.ServiceDeps{
+			service := auth.NewService(&auth.ServiceDeps{
+				AuthConfig:       &config.AuthConfig{FakeHash: fakeHash},
+				Events:           events,
+				Hasher:           m.hasher,
+				LoginLockout:     lockout,
+				UserRepo:         m.userRepo,
 				Logger:           slog.Default(),
-				RefreshTokenRepo: refreshTokenRepo,
-			}
-			service := auth.NewService(svcDeps)
-
-			ctx := t.Context()
-			if tt.setupCtx != nil {
-				ctx = tt.setupCtx(ctx)
-			}
-
-			err := service.LogoutAll(ctx)
+				TokenManager:     m.jwtManager,
+				RefreshTokenRepo: m.refreshTokenRepo,
+			})
 
-			if tt.assertErr != nil {
-				assert.Error(t, err)
-				tt.assertErr(t, err)
-			} else {
-				assert.NoError(t, err)
-			}
+			_, _ = service.Login(t.Context(), input)
 
-			refreshTokenRepo.AssertExpectations(t)
+			events.AssertExpectations(t)
+			lockout.AssertExpectations(t)
+			m.userRepo.AssertExpectations(t)
 		})
 	}
 }
 
-func TestService_Refresh(t *testing.T) {
+func TestService_LoginMFAChallenge(t *testing.T) {
 	t.Parallel()
 
-	fakeHash := testdata.TestPasswordHash
-
-	fakeRefreshToken := "fakeRefresh"
-	defaultInput := auth.RefreshInput{
-		RefreshToken: fakeRefreshToken,
-	}
-
-	defaultJti := uuid.New()
-	defaultPrincipal := &identity.Principal{
-		UserID: 1,
-		Role:   identity.RoleUser,
-		Source: identity.AuthExternal,
-		JTI:    &defaultJti,
-	}
-
-	defaultUserReturn := &user.User{
-		ID:       1,
-		Name:     "test",
-		UserName: "test",
-		Email:    "test@test.com",
-		Password: fakeHash,
-		Role:     identity.RoleUser,
-	}
-
-	fakeAccessToken := "fakeAccess"
-	fakeAccessTokenResult := &jwt.AccessTokenResult{
-		Token: fakeAccessToken,
-		Meta: jwt.TokenMetadata{
-			JTI: defaultJti,
-		},
-	}
+	defaultUser := &user.User{ID: 1, Email: "test@test.com", Password: "userHash", Role: identity.RoleAdmin}
+	input := auth.LoginInput{Email: "test@test.com", Password: "password123"}
 
 	tests := []struct {
 		name       string
-		input      auth.RefreshInput
-		setupMocks func(m *refreshMocks)
-		expected   *auth.RefreshOutput
-		assertErr  func(t *testing.T, err error)
+		setupMocks func(m *loginMocks, mfaService *mocks.MockMFAService, passkeys *mocks.MockPasskeyService)
+		assertOut  func(t *testing.T, out *auth.LoginOutput, err error)
 	}{
 		{
-			name: "bad refresh token",
+			name: "mfa enabled returns a challenge instead of tokens",
+			setupMocks: func(m *loginMocks, mfaService *mocks.MockMFAService, passkeys *mocks.MockPasskeyService) {
+				mfaService.On("IsEnabled", mock.Anything, defaultUser.ID).Return(true, nil)
+				passkeys.On("HasPasskeys", mock.Anything, defaultUser.ID).Return(false, nil)
+				m.jwtManager.On("GenerateMFAToken", defaultUser.ID, defaultUser.Role).
+					Return(&jwt.MFATokenResult{Token: "challenge", Meta: jwt.TokenMetadata{ExpiresAt: time.Unix(100, 0)}}, nil)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, out.MFAChallenge)
+				assert.Equal(t, "challenge", out.MFAChallenge.Token)
+				assert.Equal(t, time.Unix(100, 0), out.MFAChallenge.ExpiresAt)
+				assert.Equal(t, []string{auth.MFAMethodTOTP}, out.MFAChallenge.Methods)
+				assert.Empty(t, out.AccessToken)
+				assert.Empty(t, out.RefreshToken)
+			},
+		},
+		{
+			name: "passkeys are a second factor",
+			setupMocks: func(m *loginMocks, mfaService *mocks.MockMFAService, passkeys *mocks.MockPasskeyService) {
+				mfaService.On("IsEnabled", mock.Anything, defaultUser.ID).Return(false, nil)
+				passkeys.On("HasPasskeys", mock.Anything, defaultUser.ID).Return(true, nil)
+				m.jwtManager.On("GenerateMFAToken", defaultUser.ID, defaultUser.Role).
+					Return(&jwt.MFATokenResult{Token: "challenge"}, nil)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, out.MFAChallenge)
+				assert.Equal(t, []string{auth.MFAMethodPasskey}, out.MFAChallenge.Methods)
+				assert.Empty(t, out.AccessToken)
+			},
+		},
+		{
+			name: "passkey lookup error",
+			setupMocks: func(m *loginMocks, mfaService *mocks.MockMFAService, passkeys *mocks.MockPasskeyService) {
+				mfaService.On("IsEnabled", mock.Anything, defaultUser.ID).Return(false, nil)
+				passkeys.On("HasPasskeys", mock.Anything, defaultUser.ID).Return(false, errors.New("db down"))
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
+				assert.Nil(t, out)
+			},
+		},
+		{
+			name: "mfa lookup error",
+			setupMocks: func(m *loginMocks, mfaService *mocks.MockMFAService, passkeys *mocks.MockPasskeyService) {
+				mfaService.On("IsEnabled", mock.Anything, defaultUser.ID).Return(false, errors.New("db down"))
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.EqualError(t, appErr.Err, "db down")
+				assert.Nil(t, out)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &loginMocks{
+				userRepo:         &mocks.MockUserRepository{},
+				refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+				hasher:           &mocks.MockPasswordHasher{},
+				jwtManager:       &mocks.MockJwtManager{},
+			}
+			mfaService := &mocks.MockMFAService{}
+			passkeys := &mocks.MockPasskeyService{}
+
+			m.userRepo.On("GetByEmail", mock.Anything, input.Email).Return(testutil.Ok(defaultUser))
+			m.hasher.On("VerifyPassword", defaultUser.Password, input.Password).Return(nil)
+			m.hasher.On("NeedsRehash", defaultUser.Password).Return(false)
+			tt.setupMocks(m, mfaService, passkeys)
+
+			service := auth.NewService(&auth.ServiceDeps{
+				AuthConfig:       &config.AuthConfig{FakeHash: "fake"},
+				Hasher:           m.hasher,
+				MFA:              mfaService,
+				Passkeys:         passkeys,
+				UserRepo:         m.userRepo,
+				Logger:           slog.Default(),
+				TokenManager:     m.jwtManager,
+				RefreshTokenRepo: m.refreshTokenRepo,
+			})
+
+			out, err := service.Login(t.Context(), input)
+			tt.assertOut(t, out, err)
+
+			mfaService.AssertExpectations(t)
+			passkeys.AssertExpectations(t)
+			m.jwtManager.AssertExpectations(t)
+			m.refreshTokenRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_LoginRequireVerifiedEmail(t *testing.T) {
+	t.Parallel()
+
+	input := auth.LoginInput{Email: "test@test.com", Password: "password123"}
+	verifiedAt := time.Now()
+
+	tests := []struct {
+		name       string
+		user       *user.User
+		setupMocks func(m *loginMocks, u *user.User)
+		assertOut  func(t *testing.T, out *auth.LoginOutput, err error)
+	}{
+		{
+			name: "unverified email is refused",
+			user: &user.User{ID: 1, Email: input.Email, Password: "userHash", Role: identity.RoleUser},
+			assertOut: func(t *testing.T, out *auth.LoginOutput, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusUnauthorized, appErr.StatusCode)
+				assert.Equal(t, auth.MsgEmailNotVerified, appErr.Message)
+				assert.Nil(t, out)
+			},
+		},
+		{
+			name: "verified email logs in",
+			user: &user.User{ID: 1, Email: input.Email, EmailVerifiedAt: &verifiedAt, Password: "userHash", Role: identity.RoleUser},
+			setupMocks: func(m *loginMocks, u *user.User) {
+				m.jwtManager.On("GenerateRefreshToken", u.ID, u.Role).
+					Return(&jwt.RefreshTokenResult{Token: "refresh", Meta: jwt.TokenMetadata{JTI: uuid.New()}}, nil)
+				m.refreshTokenRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+				m.jwtManager.On("GenerateAccessToken", u.ID, u.Role, mock.Anything).
+					Return(&jwt.AccessTokenResult{Token: "access"}, nil)
+			},
+			assertOut: func(t *testing.T, out *auth.LoginOutput, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "access", out.AccessToken)
+				assert.Equal(t, "refresh", out.RefreshToken)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &loginMocks{
+				userRepo:         &mocks.MockUserRepository{},
+				refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+				hasher:           &mocks.MockPasswordHasher{},
+				jwtManager:       &mocks.MockJwtManager{},
+			}
+
+			m.userRepo.On("GetByEmail", mock.Anything, input.Email).Return(tt.user, nil)
+			m.hasher.On("VerifyPassword", tt.user.Password, input.Password).Return(nil)
+			m.hasher.On("NeedsRehash", tt.user.Password).Return(false)
+			if tt.setupMocks != nil {
+				tt.setupMocks(m, tt.user)
+			}
+
+			service := auth.NewService(&auth.ServiceDeps{
+				AuthConfig:       &config.AuthConfig{FakeHash: "fake", RequireVerifiedEmail: true},
+				Hasher:           m.hasher,
+				UserRepo:         m.userRepo,
+				Logger:           slog.Default(),
+				TokenManager:     m.jwtManager,
+				RefreshTokenRepo: m.refreshTokenRepo,
+			})
+
+			out, err := service.Login(t.Context(), input)
+			tt.assertOut(t, out, err)
+
+			m.jwtManager.AssertExpectations(t)
+			m.refreshTokenRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_LoginRehash(t *testing.T) {
+	t.Parallel()
+
+	input := auth.LoginInput{Email: "test@test.com", Password: "password123"}
+
+	tests := []struct {
+		name       string
+		setupMocks func(m *loginMocks)
+	}{
+		{
+			name: "outdated hash is replaced",
+			setupMocks: func(m *loginMocks) {
+				m.hasher.On("HashPassword", input.Password).Return("newHash", nil)
+				m.userRepo.On("UpdatePassword", mock.Anything, uint(1), "newHash").Return(nil)
+			},
+		},
+		{
+			name: "hash error doesn't fail the login",
+			setupMocks: func(m *loginMocks) {
+				m.hasher.On("HashPassword", input.Password).Return("", errors.New("hash error"))
+			},
+		},
+		{
+			name: "update error doesn't fail the login",
+			setupMocks: func(m *loginMocks) {
+				m.hasher.On("HashPassword", input.Password).Return("newHash", nil)
+				m.userRepo.On("UpdatePassword", mock.Anything, uint(1), "newHash").Return(errors.New("db error"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &loginMocks{
+				userRepo:         &mocks.MockUserRepository{},
+				refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+				hasher:           &mocks.MockPasswordHasher{},
+				jwtManager:       &mocks.MockJwtManager{},
+			}
+			u := &user.User{ID: 1, Email: input.Email, Password: "oldHash", Role: identity.RoleUser}
+
+			m.userRepo.On("GetByEmail", mock.Anything, input.Email).Return(u, nil)
+			m.hasher.On("VerifyPassword", "oldHash", input.Password).Return(nil)
+			m.hasher.On("NeedsRehash", "oldHash").Return(true)
+			m.jwtManager.On("GenerateRefreshToken", u.ID, u.Role).
+				Return(&jwt.RefreshTokenResult{Token: "refresh", Meta: jwt.TokenMetadata{JTI: uuid.New()}}, nil)
+			m.refreshTokenRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+			m.jwtManager.On("GenerateAccessToken", u.ID, u.Role, mock.Anything).
+				Return(&jwt.AccessTokenResult{Token: "access"}, nil)
+			tt.setupMocks(m)
+
+			service := auth.NewService(&auth.ServiceDeps{
+				AuthConfig:       &config.AuthConfig{FakeHash: "fake"},
+				Hasher:           m.hasher,
+				UserRepo:         m.userRepo,
+				Logger:           slog.Default(),
+				TokenManager:     m.jwtManager,
+				RefreshTokenRepo: m.refreshTokenRepo,
+			})
+
+			out, err := service.Login(t.Context(), input)
+			require.NoError(t, err)
+			assert.Equal(t, "access", out.AccessToken)
+
+			m.hasher.AssertExpectations(t)
+			m.userRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_VerifyMFA(t *testing.T) {
+	t.Parallel()
+
+	challengeJti := uuid.New()
+	challenge := &identity.Principal{UserID: 1, Role: identity.RoleAdmin, JTI: &challengeJti}
+	defaultUser := &user.User{ID: 1, Role: identity.RoleAdmin}
+	input := auth.VerifyMFAInput{
+		ChallengeToken: "challenge",
+		Code:           "123456",
+		Client:         auth.ClientInfo{UserAgent: "curl/8.0", IPAddress: "192.0.2.1"},
+	}
+	verifyInput := mfa.VerifyInput{UserID: 1, Code: "123456"}
+	ttl := 5 * time.Minute
+
+	type verifyMocks struct {
+		loginMocks
+		usedTokens *mocks.MockUsedTokens
+		lockout    *mocks.MockLockout
+		mfa        *mocks.MockMFAService
+	}
+
+	tests := []struct {
+		name              string
+		withoutUsedTokens bool
+		setupMocks        func(m *verifyMocks)
+		status            int
+		message           string
+	}{
+		{
+			name: "invalid challenge",
+			setupMocks: func(m *verifyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(nil, jwt.ErrInvalidToken)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidMFAChallenge,
+		},
+		{
+			name: "challenge already used",
+			setupMocks: func(m *verifyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(challenge, nil)
+				m.usedTokens.On("IsUsed", mock.Anything, challengeJti).Return(true, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidMFAChallenge,
+		},
+		{
+			name: "locked user",
+			setupMocks: func(m *verifyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(challenge, nil)
+				m.usedTokens.On("IsUsed", mock.Anything, challengeJti).Return(false, nil)
+				m.lockout.On("Locked", mock.Anything, "mfa:1").Return(time.Minute, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: mfa.MsgInvalidMFACode,
+		},
+		{
+			name: "wrong code counts a failure",
+			setupMocks: func(m *verifyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(challenge, nil)
+				m.usedTokens.On("IsUsed", mock.Anything, challengeJti).Return(false, nil)
+				m.lockout.On("Locked", mock.Anything, "mfa:1").Return(time.Duration(0), nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(defaultUser, nil)
+				m.mfa.On("Verify", mock.Anything, verifyInput).Return(pkgerrors.NewUnauthorizedError(mfa.MsgInvalidMFACode))
+				m.lockout.On("Fail", mock.Anything, "mfa:1").Return(time.Duration(0), nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: mfa.MsgInvalidMFACode,
+		},
+		{
+			name:              "no store to consume the challenge",
+			withoutUsedTokens: true,
+			setupMocks: func(m *verifyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(challenge, nil)
+			},
+			status:  http.StatusInternalServerError,
+			message: "An unexpected error occurred",
+		},
+		{
+			name: "redis unavailable refuses the challenge",
+			setupMocks: func(m *verifyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(challenge, nil)
+				m.usedTokens.On("IsUsed", mock.Anything, challengeJti).Return(false, errors.New("circuit breaker is open"))
+			},
+			status:  http.StatusInternalServerError,
+			message: "An unexpected error occurred",
+		},
+		{
+			name: "challenge exchanged concurrently",
+			setupMocks: func(m *verifyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(challenge, nil)
+				m.usedTokens.On("IsUsed", mock.Anything, challengeJti).Return(false, nil)
+				m.lockout.On("Locked", mock.Anything, "mfa:1").Return(time.Duration(0), nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(defaultUser, nil)
+				m.mfa.On("Verify", mock.Anything, verifyInput).Return(nil)
+				m.lockout.On("Reset", mock.Anything, "mfa:1").Return(nil)
+				m.usedTokens.On("Use", mock.Anything, challengeJti, ttl).Return(false, nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: auth.MsgInvalidMFAChallenge,
+		},
+		{
+			name: "success consumes the challenge",
+			setupMocks: func(m *verifyMocks) {
+				m.jwtManager.On("ValidateMFAToken", "challenge").Return(challenge, nil)
+				m.usedTokens.On("IsUsed", mock.Anything, challengeJti).Return(false, nil)
+				m.lockout.On("Locked", mock.Anything, "mfa:1").Return(time.Duration(0), nil)
+				m.userRepo.On("GetByID", mock.Anything, uint(1)).Return(defaultUser, nil)
+				m.mfa.On("Verify", mock.Anything, verifyInput).Return(nil)
+				m.lockout.On("Reset", mock.Anything, "mfa:1").Return(nil)
+				m.usedTokens.On("Use", mock.Anything, challengeJti, ttl).Return(true, nil)
+
+				refreshJti := uuid.New()
+				m.jwtManager.On("GenerateRefreshToken", defaultUser.ID, defaultUser.Role).
+					Return(&jwt.RefreshTokenResult{Token: "refresh", Meta: jwt.TokenMetadata{JTI: refreshJti}}, nil)
+				m.refreshTokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *auth.RefreshToken) bool {
+					return token.JTI == refreshJti && token.UserAgent == "curl/8.0" && token.IPAddress == "192.0.2.1" &&
+						token.AMR == "otp mfa"
+				})).Return(nil)
+				m.jwtManager.On("GenerateAccessToken", defaultUser.ID, defaultUser.Role, "").
+					Return(&jwt.AccessTokenResult{Token: "access"}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &verifyMocks{
+				loginMocks: loginMocks{
+					userRepo:         &mocks.MockUserRepository{},
+					refreshTokenRepo: &mocks.MockRefreshTokenRepository{},
+					jwtManager:       &mocks.MockJwtManager{},
+				},
+				usedTokens: &mocks.MockUsedTokens{},
+				lockout:    &mocks.MockLockout{},
+				mfa:        &mocks.MockMFAService{},
+			}
+			tt.setupMocks(m)
+
+			var usedTokens revocation.UsedTokens = m.usedTokens
+			if tt.withoutUsedTokens {
+				usedTokens = nil
+			}
+
+			service := auth.NewService(&auth.ServiceDeps{
+				AuthConfig:       &config.AuthConfig{MFAChallengeTTL: ttl},
+				UsedTokens:       usedTokens,
+				LoginLockout:     m.lockout,
+				MFA:              m.mfa,
+				UserRepo:         m.userRepo,
+				Logger:           slog.Default(),
+				TokenManager:     m.jwtManager,
+				RefreshTokenRepo: m.refreshTokenRepo,
+			})
+
+			out, err := service.VerifyMFA(t.Context(), input)
+
+			if tt.status != 0 {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.status, appErr.StatusCode)
+				assert.Equal(t, tt.message, appErr.Message)
+				assert.Nil(t, out)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &auth.LoginOutput{RefreshToken: "refresh", AccessToken: "access", TokenType: auth.TokenTypeBearer}, out)
+			}
+
+			m.jwtManager.AssertExpectations(t)
+			m.usedTokens.AssertExpectations(t)
+			m.lockout.AssertExpectations(t)
+			m.mfa.AssertExpectations(t)
+			m.userRepo.AssertExpectations(t)
+			m.refreshTokenRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Logout(t *testing.T) {
+	t.Parallel()
+
+	defaultJti := uuid.New()
+
+	tests := []struct {
+		name       string
+		setupCtx   func(ctx context.Context) context.Context
+		setupMocks func(m *logoutMocks)
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name: "no principal",
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name: "missing session",
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID: 1,
+					Role:   identity.RoleAdmin,
+					Source: identity.AuthExternal,
+				})
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name: "db revoking error",
+			setupMocks: func(m *logoutMocks) {
+				m.refreshTokenRepo.
+					On("RevokeByFamilyID", mock.Anything, defaultJti).
+					Return(nil, errors.New("error"))
+			},
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID:    1,
+					Role:      identity.RoleAdmin,
+					Source:    identity.AuthExternal,
+					SessionID: &defaultJti,
+				})
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name: "denylist error only logged",
+			setupMocks: func(m *logoutMocks) {
+				m.refreshTokenRepo.
+					On("RevokeByFamilyID", mock.Anything, defaultJti).
+					Return([]uuid.UUID{defaultJti}, nil)
+
+				m.denylist.
+					On("Revoke", mock.Anything, defaultJti, time.Hour).
+					Return(errors.New("circuit breaker is open"))
+
+				m.events.
+					On("Record", mock.Anything, mock.Anything).
+					Return()
+			},
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID:    1,
+					Role:      identity.RoleAdmin,
+					Source:    identity.AuthExternal,
+					SessionID: &defaultJti,
+				})
+			},
+		},
+		{
+			name: "impersonation ended through the denylist",
+			setupMocks: func(m *logoutMocks) {
+				m.denylist.
+					On("Revoke", mock.Anything, defaultJti, 15*time.Minute).
+					Return(nil)
+			},
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID:    2,
+					Role:      identity.RoleUser,
+					Source:    identity.AuthExternal,
+					ActorID:   1,
+					SessionID: &defaultJti,
+				})
+			},
+		},
+		{
+			name: "impersonation denylist error",
+			setupMocks: func(m *logoutMocks) {
+				m.denylist.
+					On("Revoke", mock.Anything, defaultJti, 15*time.Minute).
+					Return(errors.New("circuit breaker is open"))
+			},
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID:    2,
+					Role:      identity.RoleUser,
+					Source:    identity.AuthExternal,
+					ActorID:   1,
+					SessionID: &defaultJti,
+				})
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
+			},
+		},
+		{
+			name: "success",
+			setupMocks: func(m *logoutMocks) {
+				m.refreshTokenRepo.
+					On("RevokeByFamilyID", mock.Anything, defaultJti).
+					Return([]uuid.UUID{defaultJti}, nil)
+
+				m.denylist.
+					On("Revoke", mock.Anything, defaultJti, time.Hour).
+					Return(nil)
+
+				m.events.
+					On("Record", mock.Anything, authevent.Event{
+						Type:      authevent.TypeLogout,
+						UserID:    testutil.Ptr(uint(1)),
+						IPAddress: "192.0.2.1",
+						UserAgent: "test-agent",
+					}).
+					Return()
+			},
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID:    1,
+					Role:      identity.RoleAdmin,
+					Source:    identity.AuthExternal,
+					SessionID: &defaultJti,
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denylist := &mocks.MockDenylist{}
+			refreshTokenRepo := &mocks.MockRefreshTokenRepository{}
+
+			events := &mocks.MockAuthEventRecorder{}
+
+			logoutMocks := &logoutMocks{
+				denylist:         denylist,
+				events:           events,
+				refreshTokenRepo: refreshTokenRepo,
+			}
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(logoutMocks)
+			}
+
+			svcDeps := &auth.ServiceDeps{
+				AuthConfig: &config.AuthConfig{
+					AccessTokenTTL:   time.Hour,
+					ImpersonationTTL: 15 * time.Minute,
+				},
+				Denylist:         denylist,
+				Events:           events,
+				Logger:           slog.Default(),
+				RefreshTokenRepo: refreshTokenRepo,
+			}
+			service := auth.NewService(svcDeps)
+
+			ctx := t.Context()
+			if tt.setupCtx != nil {
+				ctx = tt.setupCtx(ctx)
+			}
+
+			err := service.Logout(ctx, auth.LogoutInput{Client: logoutClient})
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			refreshTokenRepo.AssertExpectations(t)
+			denylist.AssertExpectations(t)
+			events.AssertExpectations(t)
+		})
+	}
+}
+
+// Access tokens issued before the last refresh of a session are rejected once it is logged out.
+func TestService_LogoutRevokesEarlierAccessTokens(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.AuthConfig{
+		AccessTokenSecret:  "access-secret",
+		AccessTokenTTL:     time.Hour,
+		FakeHash:           testdata.TestPasswordHash,
+		RefreshTokenSecret: "refresh-secret",
+		RefreshTokenTTL:    24 * time.Hour,
+	}
+	tokenManager := jwt.NewTokenManager(cfg)
+	usr := &user.User{ID: 1, Email: "test@test.com", Password: "hash", Role: identity.RoleUser}
+
+	userRepo := &mocks.MockUserRepository{}
+	hasher := &mocks.MockPasswordHasher{}
+	refreshTokenRepo := &mocks.MockRefreshTokenRepository{}
+	denylist := &mocks.MockDenylist{}
+
+	service := auth.NewService(&auth.ServiceDeps{
+		AuthConfig:       cfg,
+		Denylist:         denylist,
+		Hasher:           hasher,
+		Logger:           slog.Default(),
+		RefreshTokenRepo: refreshTokenRepo,
+		TokenManager:     tokenManager,
+		UserRepo:         userRepo,
+	})
+
+	var stored []*auth.RefreshToken
+	capture := func(args mock.Arguments) {
+		stored = append(stored, args.Get(len(args)-1).(*auth.RefreshToken))
+	}
+
+	userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(usr, nil)
+	userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+	hasher.On("VerifyPassword", usr.Password, "password123").Return(nil)
+	hasher.On("NeedsRehash", usr.Password).Return(false)
+	refreshTokenRepo.On("Create", mock.Anything, mock.Anything).Run(capture).Return(nil)
+
+	login, err := service.Login(t.Context(), auth.LoginInput{Email: usr.Email, Password: "password123"})
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+
+	refreshTokenRepo.On("GetByJTI", mock.Anything, stored[0].JTI).Return(stored[0], nil)
+	refreshTokenRepo.On("Rotate", mock.Anything, stored[0].JTI, mock.Anything).Run(capture).Return(nil)
+
+	refreshed, err := service.Refresh(t.Context(), auth.RefreshInput{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+
+	earlier, err := tokenManager.ValidateAccessToken(login.AccessToken)
+	require.NoError(t, err)
+	current, err := tokenManager.ValidateAccessToken(refreshed.AccessToken)
+	require.NoError(t, err)
+
+	familyID := stored[0].FamilyID
+	refreshTokenRepo.On("RevokeByFamilyID", mock.Anything, familyID).Return([]uuid.UUID{familyID}, nil)
+	denylist.On("Revoke", mock.Anything, familyID, cfg.AccessTokenTTL).Return(nil)
+
+	require.NoError(t, service.Logout(identity.WithPrincipal(t.Context(), current), auth.LogoutInput{}))
+
+	// The middleware checks the denylist with the session of the token, both tokens share it.
+	require.NotNil(t, earlier.SessionID)
+	assert.Equal(t, familyID, *earlier.SessionID)
+	assert.Equal(t, familyID, *current.SessionID)
+	denylist.AssertCalled(t, "Revoke", mock.Anything, *earlier.SessionID, cfg.AccessTokenTTL)
+
+	refreshTokenRepo.AssertExpectations(t)
+	denylist.AssertExpectations(t)
+}
+
+func TestService_LogoutAll(t *testing.T) {
+	t.Parallel()
+
+	userId := uint(1)
+	sessionJtis := []uuid.UUID{uuid.New(), uuid.New()}
+	tests := []struct {
+		name       string
+		setupCtx   func(ctx context.Context) context.Context
+		setupMocks func(m *logoutMocks)
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name: "no principal",
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name: "db revoking error",
+			setupMocks: func(m *logoutMocks) {
+				m.refreshTokenRepo.
+					On("RevokeByUserID", mock.Anything, userId).
+					Return(nil, errors.New("error"))
+			},
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID: userId,
+					Role:   identity.RoleAdmin,
+					Source: identity.AuthExternal,
+				})
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name: "success",
+			setupMocks: func(m *logoutMocks) {
+				m.refreshTokenRepo.
+					On("RevokeByUserID", mock.Anything, userId).
+					Return([]uuid.UUID{sessionJtis[0], sessionJtis[1]}, nil)
+
+				for _, jti := range sessionJtis {
+					m.denylist.
+						On("Revoke", mock.Anything, jti, time.Hour).
+						Return(nil)
+				}
+
+				m.events.
+					On("Record", mock.Anything, authevent.Event{
+						Type:      authevent.TypeLogoutAll,
+						UserID:    &userId,
+						IPAddress: "192.0.2.1",
+						UserAgent: "test-agent",
+					}).
+					Return()
+			},
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID: userId,
+					Role:   identity.RoleAdmin,
+					Source: identity.AuthExternal,
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denylist := &mocks.MockDenylist{}
+			refreshTokenRepo := &mocks.MockRefreshTokenRepository{}
+
+			events := &mocks.MockAuthEventRecorder{}
+
+			logoutMocks := &logoutMocks{
+				denylist:         denylist,
+				events:           events,
+				refreshTokenRepo: refreshTokenRepo,
+			}
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(logoutMocks)
+			}
+
+			svcDeps := &auth.ServiceDeps{
+				AuthConfig: &config.AuthConfig{
+					AccessTokenTTL: time.Hour,
+				},
+				Denylist:         denylist,
+				Events:           events,
+				Logger:           slog.Default(),
+				RefreshTokenRepo: refreshTokenRepo,
+			}
+			service := auth.NewService(svcDeps)
+
+			ctx := t.Context()
+			if tt.setupCtx != nil {
+				ctx = tt.setupCtx(ctx)
+			}
+
+			err := service.LogoutAll(ctx, auth.LogoutInput{Client: logoutClient})
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			refreshTokenRepo.AssertExpectations(t)
+			denylist.AssertExpectations(t)
+			events.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Refresh(t *testing.T) {
+	t.Parallel()
+
+	fakeHash := testdata.TestPasswordHash
+
+	fakeRefreshToken := "fakeRefresh"
+	defaultInput := auth.RefreshInput{
+		RefreshToken: fakeRefreshToken,
+		Client: auth.ClientInfo{
+			UserAgent: "curl/8.0",
+			IPAddress: "198.51.100.7",
+		},
+	}
+
+	defaultJti := uuid.New()
+	defaultPrincipal := &identity.Principal{
+		UserID: 1,
+		Role:   identity.RoleUser,
+		Source: identity.AuthExternal,
+		JTI:    &defaultJti,
+	}
+
+	defaultUserReturn := &user.User{
+		ID:       1,
+		Name:     "test",
+		UserName: "test",
+		Email:    "test@test.com",
+		Password: fakeHash,
+		Role:     identity.RoleUser,
+	}
+
+	defaultFamilyID := uuid.New()
+	defaultStoredToken := &auth.RefreshToken{
+		JTI:       defaultJti,
+		UserID:    1,
+		FamilyID:  defaultFamilyID,
+		AuthTime:  time.Now().Add(-time.Hour),
+		AMR:       "pwd",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+
+	newJti := uuid.New()
+	fakeNewRefreshToken := "fakeNewRefresh"
+	fakeRefreshTokenResult := &jwt.RefreshTokenResult{
+		Token: fakeNewRefreshToken,
+		Meta: jwt.TokenMetadata{
+			JTI: newJti,
+		},
+	}
+
+	fakeAccessToken := "fakeAccess"
+	fakeAccessTokenResult := &jwt.AccessTokenResult{
+		Token: fakeAccessToken,
+		Meta: jwt.TokenMetadata{
+			JTI: defaultJti,
+		},
+	}
+
+	tests := []struct {
+		name       string
+		input      auth.RefreshInput
+		setupMocks func(m *refreshMocks)
+		expected   *auth.RefreshOutput
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name: "bad refresh token",
+			input: auth.RefreshInput{
+				RefreshToken: "badFakeRefresh",
+			},
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", "badFakeRefresh").
+					Return(nil, errors.New("signing error"))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:  "missing jti",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(&identity.Principal{UserID: 1}))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:  "db error",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Err[*user.User](gorm.ErrInvalidDB))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name: "non existing user",
+			input: auth.RefreshInput{
+				RefreshToken: "fakeRefreshTokenNonExistentUser",
+			},
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", "fakeRefreshTokenNonExistentUser").
+					Return(testutil.Ok(&identity.Principal{
+						UserID: 999,
+						Role:   identity.RoleUser,
+						Source: identity.AuthExternal,
+						JTI:    &defaultJti,
+					}))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, uint(999)).
+					Return(testutil.Err[*user.User](gorm.ErrRecordNotFound))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:  "refresh token db error",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Err[*auth.RefreshToken](gorm.ErrInvalidDB))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:  "non existing refresh token",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Err[*auth.RefreshToken](gorm.ErrRecordNotFound))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:  "revoked refresh token",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Ok(&auth.RefreshToken{
+						JTI:       defaultJti,
+						FamilyID:  defaultFamilyID,
+						RevokedAt: testutil.Ptr(time.Now()),
+					}))
+
+				m.refreshTokenRepo.
+					On("RevokeByFamilyID", mock.Anything, defaultFamilyID).
+					Return([]uuid.UUID{newJti}, nil)
+
+				m.denylist.
+					On("Revoke", mock.Anything, newJti, time.Hour).
+					Return(nil)
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:  "expired refresh token",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Ok(&auth.RefreshToken{ExpiresAt: time.Now().Add(-1 * time.Hour)}))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:  "reused refresh token family revoke error",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Ok(&auth.RefreshToken{
+						JTI:       defaultJti,
+						FamilyID:  defaultFamilyID,
+						RevokedAt: testutil.Ptr(time.Now()),
+					}))
+
+				m.refreshTokenRepo.
+					On("RevokeByFamilyID", mock.Anything, defaultFamilyID).
+					Return(nil, errors.New("error"))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:  "new refresh token error",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Ok(defaultStoredToken))
+
+				m.jwtManager.
+					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
+					Return(nil, errors.New("signing error"))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:  "rotate db error",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Ok(defaultStoredToken))
+
+				m.jwtManager.
+					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
+					Return(fakeRefreshTokenResult, nil)
+
+				m.refreshTokenRepo.
+					On("Rotate", mock.Anything, defaultJti, mock.Anything).
+					Return(gorm.ErrInvalidDB)
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+				assert.Equal(t, http.StatusInternalServerError, nf.StatusCode)
+			},
+		},
+		{
+			name:  "concurrent rotation revokes family",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Ok(defaultStoredToken))
+
+				m.jwtManager.
+					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
+					Return(fakeRefreshTokenResult, nil)
+
+				m.refreshTokenRepo.
+					On("Rotate", mock.Anything, defaultJti, mock.Anything).
+					Return(auth.ErrRefreshTokenRevoked)
+
+				m.refreshTokenRepo.
+					On("RevokeByFamilyID", mock.Anything, defaultFamilyID).
+					Return([]uuid.UUID{newJti}, nil)
+
+				m.denylist.
+					On("Revoke", mock.Anything, newJti, time.Hour).
+					Return(nil)
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+				assert.Equal(t, http.StatusUnauthorized, nf.StatusCode)
+			},
+		},
+		{
+			name:  "access token error",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Ok(defaultStoredToken))
+
+				m.jwtManager.
+					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
+					Return(fakeRefreshTokenResult, nil)
+
+				m.refreshTokenRepo.
+					On("Rotate", mock.Anything, defaultJti, mock.Anything).
+					Return(nil)
+
+				m.jwtManager.
+					On("GenerateAccessToken", defaultUserReturn.ID, defaultUserReturn.Role, mock.Anything).
+					Return(nil, errors.New("signing error"))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:  "success",
+			input: defaultInput,
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Ok(defaultStoredToken))
+
+				m.jwtManager.
+					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
+					Return(fakeRefreshTokenResult, nil)
+
+				m.refreshTokenRepo.
+					On("Rotate", mock.Anything, defaultJti, mock.MatchedBy(func(token *auth.RefreshToken) bool {
+						return token.JTI == newJti &&
+							token.FamilyID == defaultFamilyID &&
+							token.ParentJTI != nil && *token.ParentJTI == defaultJti &&
+							token.CreatedAt.Equal(defaultStoredToken.CreatedAt) &&
+							token.AuthTime.Equal(defaultStoredToken.AuthTime) &&
+							token.AMR == defaultStoredToken.AMR &&
+							token.IPAddress == "198.51.100.7"
+					})).
+					Return(nil)
+
+				m.jwtManager.
+					On("GenerateAccessToken", defaultUserReturn.ID, defaultUserReturn.Role, mock.Anything).
+					Return(fakeAccessTokenResult, nil)
+			},
+			expected: &auth.RefreshOutput{
+				RefreshToken: fakeNewRefreshToken,
+				AccessToken:  fakeAccessToken,
+				TokenType:    auth.TokenTypeBearer,
+			},
+		},
+		{
+			name: "bound session keeps its key",
+			input: auth.RefreshInput{
+				RefreshToken: fakeRefreshToken,
+				Client:       auth.ClientInfo{DPoPJKT: "thumbprint"},
+			},
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				boundToken := *defaultStoredToken
+				boundToken.JKT = "thumbprint"
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Ok(&boundToken))
+
+				m.jwtManager.
+					On("GenerateRefreshToken", defaultUserReturn.ID, defaultUserReturn.Role).
+					Return(fakeRefreshTokenResult, nil)
+
+				m.refreshTokenRepo.
+					On("Rotate", mock.Anything, defaultJti, mock.MatchedBy(func(token *auth.RefreshToken) bool {
+						return token.JKT == "thumbprint"
+					})).
+					Return(nil)
+
+				m.jwtManager.
+					On("GenerateAccessToken", defaultUserReturn.ID, defaultUserReturn.Role, "thumbprint").
+					Return(fakeAccessTokenResult, nil)
+			},
+			expected: &auth.RefreshOutput{
+				RefreshToken: fakeNewRefreshToken,
+				AccessToken:  fakeAccessToken,
+				TokenType:    auth.TokenTypeDPoP,
+			},
+		},
+		{
+			name: "bound session without its key",
 			input: auth.RefreshInput{
-				RefreshToken: "badFakeRefresh",
+				RefreshToken: fakeRefreshToken,
+				Client:       auth.ClientInfo{DPoPJKT: "other"},
+			},
+			setupMocks: func(m *refreshMocks) {
+				m.jwtManager.
+					On("ValidateRefreshToken", fakeRefreshToken).
+					Return(testutil.Ok(defaultPrincipal))
+
+				m.userRepo.
+					On("GetByID", mock.Anything, defaultPrincipal.UserID).
+					Return(testutil.Ok(defaultUserReturn))
+
+				boundToken := *defaultStoredToken
+				boundToken.JKT = "thumbprint"
+				m.refreshTokenRepo.
+					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(testutil.Ok(&boundToken))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+				assert.Equal(t, http.StatusUnauthorized, nf.StatusCode)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			denylist := &mocks.MockDenylist{}
+			userRepo := &mocks.MockUserRepository{}
+			jwtManager := &mocks.MockJwtManager{}
+			refreshTokenRepo := &mocks.MockRefreshTokenRepository{}
+			refreshMocks := &refreshMocks{
+				denylist:         denylist,
+				userRepo:         userRepo,
+				jwtManager:       jwtManager,
+				refreshTokenRepo: refreshTokenRepo,
+			}
+			tt.setupMocks(refreshMocks)
+
+			svcDeps := &auth.ServiceDeps{
+				AuthConfig: &config.AuthConfig{
+					AccessTokenTTL: time.Hour,
+					FakeHash:       fakeHash,
+				},
+				Denylist:         denylist,
+				UserRepo:         userRepo,
+				RefreshTokenRepo: refreshTokenRepo,
+				Logger:           slog.Default(),
+				TokenManager:     jwtManager,
+			}
+			service := auth.NewService(svcDeps)
+
+			result, err := service.Refresh(t.Context(), tt.input)
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			}
+
+			userRepo.AssertExpectations(t)
+			refreshTokenRepo.AssertExpectations(t)
+			jwtManager.AssertExpectations(t)
+			denylist.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_ListSessions(t *testing.T) {
+	t.Parallel()
+
+	currentJti := uuid.New()
+	currentFamily := uuid.New()
+	otherJti := uuid.New()
+	tokens := []auth.RefreshToken{
+		{JTI: currentJti, FamilyID: currentFamily, UserID: 1, UserAgent: "curl/8.0"},
+		{JTI: otherJti, FamilyID: otherJti, UserID: 1, UserAgent: "Mozilla/5.0"},
+	}
+
+	userCtx := func(ctx context.Context) context.Context {
+		return identity.WithPrincipal(ctx, &identity.Principal{
+			UserID:    1,
+			Role:      identity.RoleUser,
+			Source:    identity.AuthExternal,
+			SessionID: &currentFamily,
+		})
+	}
+
+	adminCtx := func(ctx context.Context) context.Context {
+		return identity.WithPrincipal(ctx, &identity.Principal{
+			UserID:      2,
+			Role:        identity.RoleAdmin,
+			Permissions: []identity.Permission{identity.PermSessionsRead},
+			Source:      identity.AuthExternal,
+		})
+	}
+
+	tests := []struct {
+		name        string
+		input       auth.ListSessionsInput
+		setupCtx    func(ctx context.Context) context.Context
+		setupMocks  func(m *mocks.MockRefreshTokenRepository)
+		assertErr   func(t *testing.T, err error)
+		wantCurrent bool
+	}{
+		{
+			name: "no principal",
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:     "other user without permission",
+			input:    auth.ListSessionsInput{UserID: testutil.Ptr(uint(3))},
+			setupCtx: userCtx,
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				require.ErrorAs(t, err, &nf)
+				assert.Equal(t, http.StatusForbidden, nf.StatusCode)
+			},
+		},
+		{
+			name:     "db error",
+			setupCtx: userCtx,
+			setupMocks: func(m *mocks.MockRefreshTokenRepository) {
+				m.On("ListActiveByUserID", mock.Anything, uint(1)).Return(nil, errors.New("db down"))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				assert.ErrorAs(t, err, &nf)
+			},
+		},
+		{
+			name:     "own sessions",
+			setupCtx: userCtx,
+			setupMocks: func(m *mocks.MockRefreshTokenRepository) {
+				m.On("ListActiveByUserID", mock.Anything, uint(1)).Return(tokens, nil)
+			},
+			wantCurrent: true,
+		},
+		{
+			name:     "lists other user sessions with permission",
+			input:    auth.ListSessionsInput{UserID: testutil.Ptr(uint(1))},
+			setupCtx: adminCtx,
+			setupMocks: func(m *mocks.MockRefreshTokenRepository) {
+				m.On("ListActiveByUserID", mock.Anything, uint(1)).Return(tokens, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshTokenRepo := &mocks.MockRefreshTokenRepository{}
+			if tt.setupMocks != nil {
+				tt.setupMocks(refreshTokenRepo)
+			}
+
+			service := auth.NewService(&auth.ServiceDeps{
+				Logger:           slog.Default(),
+				RefreshTokenRepo: refreshTokenRepo,
+			})
+
+			ctx := t.Context()
+			if tt.setupCtx != nil {
+				ctx = tt.setupCtx(ctx)
+			}
+
+			sessions, err := service.ListSessions(ctx, tt.input)
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+				assert.Nil(t, sessions)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, sessions, 2)
+				assert.Equal(t, "curl/8.0", sessions[0].UserAgent)
+				assert.Equal(t, tt.wantCurrent, sessions[0].Current)
+				assert.False(t, sessions[1].Current)
+			}
+
+			refreshTokenRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_RevokeSession(t *testing.T) {
+	t.Parallel()
+
+	jti := uuid.New()
+	familyID := uuid.New()
+	activeToken := &auth.RefreshToken{
+		JTI:       jti,
+		UserID:    1,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	userCtx := func(ctx context.Context) context.Context {
+		return identity.WithPrincipal(ctx, &identity.Principal{
+			UserID: 1,
+			Role:   identity.RoleUser,
+			Source: identity.AuthExternal,
+		})
+	}
+
+	moderatorCtx := func(ctx context.Context) context.Context {
+		return identity.WithPrincipal(ctx, &identity.Principal{
+			UserID:      2,
+			Role:        identity.RoleModerator,
+			Permissions: []identity.Permission{identity.PermSessionsRevoke},
+			Source:      identity.AuthExternal,
+		})
+	}
+
+	notFound := func(t *testing.T, err error) {
+		var nf *pkgerrors.AppError
+		require.ErrorAs(t, err, &nf)
+		assert.Equal(t, auth.MsgSessionNotFound, nf.Message)
+	}
+
+	tests := []struct {
+		name       string
+		input      auth.RevokeSessionInput
+		setupCtx   func(ctx context.Context) context.Context
+		setupMocks func(m *logoutMocks)
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name:     "other user without permission",
+			input:    auth.RevokeSessionInput{UserID: testutil.Ptr(uint(3)), JTI: jti},
+			setupCtx: userCtx,
+			assertErr: func(t *testing.T, err error) {
+				var nf *pkgerrors.AppError
+				require.ErrorAs(t, err, &nf)
+				assert.Equal(t, http.StatusForbidden, nf.StatusCode)
+			},
+		},
+		{
+			name:     "unknown session",
+			input:    auth.RevokeSessionInput{JTI: jti},
+			setupCtx: userCtx,
+			setupMocks: func(m *logoutMocks) {
+				m.refreshTokenRepo.On("GetByJTI", mock.Anything, jti).Return(nil, gorm.ErrRecordNotFound)
+			},
+			assertErr: notFound,
+		},
+		{
+			name:     "session of another user",
+			input:    auth.RevokeSessionInput{JTI: jti},
+			setupCtx: userCtx,
+			setupMocks: func(m *logoutMocks) {
+				m.refreshTokenRepo.On("GetByJTI", mock.Anything, jti).Return(&auth.RefreshToken{
+					JTI:       jti,
+					UserID:    3,
+					FamilyID:  familyID,
+					ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+			},
+			assertErr: notFound,
+		},
+		{
+			name:     "already revoked",
+			input:    auth.RevokeSessionInput{JTI: jti},
+			setupCtx: userCtx,
+			setupMocks: func(m *logoutMocks) {
+				m.refreshTokenRepo.On("GetByJTI", mock.Anything, jti).Return(&auth.RefreshToken{
+					JTI:       jti,
+					UserID:    1,
+					FamilyID:  familyID,
+					ExpiresAt: time.Now().Add(time.Hour),
+					RevokedAt: testutil.Ptr(time.Now()),
+				}, nil)
+			},
+			assertErr: notFound,
+		},
+		{
+			name:     "success",
+			input:    auth.RevokeSessionInput{JTI: jti},
+			setupCtx: userCtx,
+			setupMocks: func(m *logoutMocks) {
+				m.refreshTokenRepo.On("GetByJTI", mock.Anything, jti).Return(activeToken, nil)
+				m.refreshTokenRepo.On("RevokeByFamilyID", mock.Anything, familyID).Return([]uuid.UUID{jti}, nil)
+				m.denylist.On("Revoke", mock.Anything, jti, time.Hour).Return(nil)
+			},
+		},
+		{
+			name:     "other user with permission",
+			input:    auth.RevokeSessionInput{UserID: testutil.Ptr(uint(1)), JTI: jti},
+			setupCtx: moderatorCtx,
+			setupMocks: func(m *logoutMocks) {
+				m.refreshTokenRepo.On("GetByJTI", mock.Anything, jti).Return(activeToken, nil)
+				m.refreshTokenRepo.On("RevokeByFamilyID", mock.Anything, familyID).Return([]uuid.UUID{jti}, nil)
+				m.denylist.On("Revoke", mock.Anything, jti, time.Hour).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denylist := &mocks.MockDenylist{}
+			refreshTokenRepo := &mocks.MockRefreshTokenRepository{}
+			if tt.setupMocks != nil {
+				tt.setupMocks(&logoutMocks{denylist: denylist, refreshTokenRepo: refreshTokenRepo})
+			}
+
+			service := auth.NewService(&auth.ServiceDeps{
+				AuthConfig: &config.AuthConfig{
+					AccessTokenTTL: time.Hour,
+				},
+				Denylist:         denylist,
+				Logger:           slog.Default(),
+				RefreshTokenRepo: refreshTokenRepo,
+			})
+
+			err := service.RevokeSession(tt.setupCtx(t.Context()), tt.input)
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			refreshTokenRepo.AssertExpectations(t)
+			denylist.AssertExpectations(t)
+		})
+	}
+}
+
+type passwordMocks struct {
+	denylist          *mocks.MockDenylist
+	hasher            *mocks.MockPasswordHasher
+	lockout           *mocks.MockLockout
+	mailer            *mocks.MockMailer
+	passwordPolicy    *mocks.MockPasswordPolicy
+	passwordResetRepo *mocks.MockPasswordResetRepository
+	refreshTokenRepo  *mocks.MockRefreshTokenRepository
+	userRepo          *mocks.MockUserRepository
+}
+
+func newPasswordService(m *passwordMocks) auth.Service {
+	return auth.NewService(&auth.ServiceDeps{
+		AuthConfig: &config.AuthConfig{
+			AccessTokenTTL:   time.Hour,
+			PasswordResetTTL: 30 * time.Minute,
+			PasswordResetURL: "https://example.com/reset?lang=en",
+		},
+		Denylist:          m.denylist,
+		Hasher:            m.hasher,
+		Logger:            slog.Default(),
+		LoginLockout:      m.lockout,
+		Mailer:            m.mailer,
+		PasswordPolicy:    m.passwordPolicy,
+		PasswordResetRepo: m.passwordResetRepo,
+		RefreshTokenRepo:  m.refreshTokenRepo,
+		UserRepo:          m.userRepo,
+	})
+}
+
+func TestService_ForgotPassword(t *testing.T) {
+	t.Parallel()
+
+	usr := &user.User{ID: 1, Email: "user@test.com"}
+
+	tests := []struct {
+		name       string
+		setupMocks func(m *passwordMocks, sent chan mailer.Message)
+		expectMail bool
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name: "unknown email doesn't fail",
+			setupMocks: func(m *passwordMocks, sent chan mailer.Message) {
+				m.userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(nil, gorm.ErrRecordNotFound)
+			},
+		},
+		{
+			name: "db error",
+			setupMocks: func(m *passwordMocks, sent chan mailer.Message) {
+				m.userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(nil, errors.New("db error"))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
+			},
+		},
+		{
+			name: "token storage error",
+			setupMocks: func(m *passwordMocks, sent chan mailer.Message) {
+				m.userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(usr, nil)
+				m.passwordResetRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
+			},
+		},
+		{
+			name: "mailing errors are only logged",
+			setupMocks: func(m *passwordMocks, sent chan mailer.Message) {
+				m.userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(usr, nil)
+				m.passwordResetRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+				m.mailer.On("Send", mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) { sent <- args.Get(1).(mailer.Message) }).
+					Return(errors.New("smtp error"))
+			},
+			expectMail: true,
+		},
+		{
+			name: "success",
+			setupMocks: func(m *passwordMocks, sent chan mailer.Message) {
+				m.userRepo.On("GetByEmail", mock.Anything, usr.Email).Return(usr, nil)
+				m.passwordResetRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *auth.PasswordResetToken) bool {
+					return token.UserID == usr.ID && len(token.TokenHash) == 64 &&
+						time.Until(token.ExpiresAt) > 29*time.Minute
+				})).Return(nil)
+				m.mailer.On("Send", mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) { sent <- args.Get(1).(mailer.Message) }).
+					Return(nil)
+			},
+			expectMail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &passwordMocks{
+				mailer:            &mocks.MockMailer{},
+				passwordResetRepo: &mocks.MockPasswordResetRepository{},
+				userRepo:          &mocks.MockUserRepository{},
+			}
+			sent := make(chan mailer.Message, 1)
+			tt.setupMocks(m, sent)
+
+			err := newPasswordService(m).ForgotPassword(t.Context(), auth.ForgotPasswordInput{Email: usr.Email})
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tt.expectMail {
+				select {
+				case msg := <-sent:
+					assert.Equal(t, usr.Email, msg.To)
+					assert.Contains(t, msg.Body, "https://example.com/reset?lang=en&token=")
+
+					stored := m.passwordResetRepo.Calls[0].Arguments.Get(1).(*auth.PasswordResetToken)
+					token := msg.Body[strings.Index(msg.Body, "token=")+len("token="):]
+					token = token[:strings.IndexByte(token, '\n')]
+					sum := sha256.Sum256([]byte(token))
+					assert.Equal(t, hex.EncodeToString(sum[:]), stored.TokenHash)
+				case <-time.After(time.Second):
+					t.Fatal("reset mail not sent")
+				}
+			}
+
+			m.userRepo.AssertExpectations(t)
+			m.passwordResetRepo.AssertExpectations(t)
+			m.mailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_ResetPassword(t *testing.T) {
+	t.Parallel()
+
+	token := "reset-token"
+	sum := sha256.Sum256([]byte(token))
+	tokenHash := hex.EncodeToString(sum[:])
+	usr := &user.User{ID: 1, Email: "User@Test.com"}
+	sessionJtis := []uuid.UUID{uuid.New(), uuid.New()}
+
+	tests := []struct {
+		name       string
+		setupMocks func(m *passwordMocks)
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name: "invalid or used token",
+			setupMocks: func(m *passwordMocks) {
+				m.passwordResetRepo.On("FindUserID", mock.Anything, tokenHash).Return(uint(0), gorm.ErrRecordNotFound)
 			},
-			setupMocks: func(m *refreshMocks) {
-				m.jwtManager.
-					On("ValidateRefreshToken", "badFakeRefresh").
-					Return(nil, errors.New("signing error"))
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusBadRequest, appErr.StatusCode)
+				assert.Equal(t, auth.MsgInvalidResetToken, appErr.Message)
+			},
+		},
+		{
+			name: "find db error",
+			setupMocks: func(m *passwordMocks) {
+				m.passwordResetRepo.On("FindUserID", mock.Anything, tokenHash).Return(uint(0), errors.New("db error"))
 			},
 			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
 			},
 		},
 		{
-			name:  "missing jti",
-			input: defaultInput,
-			setupMocks: func(m *refreshMocks) {
-				m.jwtManager.
-					On("ValidateRefreshToken", fakeRefreshToken).
-					Return(testutil.Ok(&identity.Principal{UserID: 1}))
+			name: "refused password keeps the token",
+			setupMocks: func(m *passwordMocks) {
+				m.passwordResetRepo.On("FindUserID", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.passwordPolicy.On("Check", mock.Anything, user.CheckPasswordInput{Field: "password", UserID: usr.ID, Password: "new-password"}).
+					Return(pkgerrors.NewValidationError(user.MsgPasswordPolicy, pkgerrors.FieldError{Field: "password", Code: "reused"}))
 			},
 			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusBadRequest, appErr.StatusCode)
+				assert.Equal(t, user.MsgPasswordPolicy, appErr.Message)
+				assert.Len(t, appErr.Fields, 1)
 			},
 		},
 		{
-			name:  "db error",
-			input: defaultInput,
-			setupMocks: func(m *refreshMocks) {
-				m.jwtManager.
-					On("ValidateRefreshToken", fakeRefreshToken).
-					Return(testutil.Ok(defaultPrincipal))
-
-				m.userRepo.
-					On("GetByID", mock.Anything, defaultPrincipal.UserID).
-					Return(testutil.Err[*user.User](gorm.ErrInvalidDB))
+			name: "token used meanwhile",
+			setupMocks: func(m *passwordMocks) {
+				m.passwordResetRepo.On("FindUserID", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.passwordPolicy.On("Check", mock.Anything, mock.Anything).Return(nil)
+				m.passwordResetRepo.On("Consume", mock.Anything, tokenHash).Return(uint(0), gorm.ErrRecordNotFound)
 			},
 			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, auth.MsgInvalidResetToken, appErr.Message)
 			},
 		},
 		{
-			name: "non existing user",
-			input: auth.RefreshInput{
-				RefreshToken: "fakeRefreshTokenNonExistentUser",
+			name: "consume db error",
+			setupMocks: func(m *passwordMocks) {
+				m.passwordResetRepo.On("FindUserID", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.passwordPolicy.On("Check", mock.Anything, mock.Anything).Return(nil)
+				m.passwordResetRepo.On("Consume", mock.Anything, tokenHash).Return(uint(0), errors.New("db error"))
 			},
-			setupMocks: func(m *refreshMocks) {
-				m.jwtManager.
-					On("ValidateRefreshToken", "fakeRefreshTokenNonExistentUser").
-					Return(testutil.Ok(&identity.Principal{
-						UserID: 999,
-						Role:   identity.RoleUser,
-						Source: identity.AuthExternal,
-						JTI:    &defaultJti,
-					}))
-
-				m.userRepo.
-					On("GetByID", mock.Anything, uint(999)).
-					Return(testutil.Err[*user.User](gorm.ErrRecordNotFound))
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
+			},
+		},
+		{
+			name: "deleted user",
+			setupMocks: func(m *passwordMocks) {
+				m.passwordResetRepo.On("FindUserID", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.passwordPolicy.On("Check", mock.Anything, mock.Anything).Return(nil)
+				m.passwordResetRepo.On("Consume", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(nil, gorm.ErrRecordNotFound)
 			},
 			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusBadRequest, appErr.StatusCode)
 			},
 		},
 		{
-			name:  "refresh token db error",
-			input: defaultInput,
-			setupMocks: func(m *refreshMocks) {
-				m.jwtManager.
-					On("ValidateRefreshToken", fakeRefreshToken).
-					Return(testutil.Ok(defaultPrincipal))
-
-				m.userRepo.
-					On("GetByID", mock.Anything, defaultPrincipal.UserID).
-					Return(testutil.Ok(defaultUserReturn))
-
-				m.refreshTokenRepo.
-					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
-					Return(testutil.Err[*auth.RefreshToken](gorm.ErrInvalidDB))
+			name: "update error",
+			setupMocks: func(m *passwordMocks) {
+				m.passwordResetRepo.On("FindUserID", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.passwordPolicy.On("Check", mock.Anything, mock.Anything).Return(nil)
+				m.passwordResetRepo.On("Consume", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+				m.hasher.On("HashPassword", "new-password").Return("new-hash", nil)
+				m.userRepo.On("UpdatePassword", mock.Anything, usr.ID, "new-hash").Return(errors.New("db error"))
 			},
 			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
 			},
 		},
 		{
-			name:  "non existing refresh token",
-			input: defaultInput,
-			setupMocks: func(m *refreshMocks) {
-				m.jwtManager.
-					On("ValidateRefreshToken", fakeRefreshToken).
-					Return(testutil.Ok(defaultPrincipal))
-
-				m.userRepo.
-					On("GetByID", mock.Anything, defaultPrincipal.UserID).
-					Return(testutil.Ok(defaultUserReturn))
-
-				m.refreshTokenRepo.
-					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
-					Return(testutil.Err[*auth.RefreshToken](gorm.ErrRecordNotFound))
+			name: "revoke error",
+			setupMocks: func(m *passwordMocks) {
+				m.passwordResetRepo.On("FindUserID", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.passwordPolicy.On("Check", mock.Anything, mock.Anything).Return(nil)
+				m.passwordResetRepo.On("Consume", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+				m.hasher.On("HashPassword", "new-password").Return("new-hash", nil)
+				m.userRepo.On("UpdatePassword", mock.Anything, usr.ID, "new-hash").Return(nil)
+				m.passwordPolicy.On("Remember", mock.Anything, usr.ID, "new-hash").Return(nil)
+				m.refreshTokenRepo.On("RevokeByUserID", mock.Anything, usr.ID).Return(nil, errors.New("db error"))
 			},
 			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
 			},
 		},
 		{
-			name:  "revoked refresh token",
-			input: defaultInput,
-			setupMocks: func(m *refreshMocks) {
-				m.jwtManager.
-					On("ValidateRefreshToken", fakeRefreshToken).
-					Return(testutil.Ok(defaultPrincipal))
+			name: "success revokes every session",
+			setupMocks: func(m *passwordMocks) {
+				m.passwordResetRepo.On("FindUserID", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.passwordPolicy.On("Check", mock.Anything, mock.Anything).Return(nil)
+				m.passwordResetRepo.On("Consume", mock.Anything, tokenHash).Return(usr.ID, nil)
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+				m.hasher.On("HashPassword", "new-password").Return("new-hash", nil)
+				m.userRepo.On("UpdatePassword", mock.Anything, usr.ID, "new-hash").Return(nil)
+				m.passwordPolicy.On("Remember", mock.Anything, usr.ID, "new-hash").Return(errors.New("db error"))
+				m.refreshTokenRepo.On("RevokeByUserID", mock.Anything, usr.ID).Return(sessionJtis, nil)
+				for _, jti := range sessionJtis {
+					m.denylist.On("Revoke", mock.Anything, jti, time.Hour).Return(nil)
+				}
+				m.passwordResetRepo.On("InvalidateByUserID", mock.Anything, usr.ID).Return(nil)
+				m.lockout.On("Reset", mock.Anything, "user@test.com").Return(nil)
+			},
+		},
+	}
 
-				m.userRepo.
-					On("GetByID", mock.Anything, defaultPrincipal.UserID).
-					Return(testutil.Ok(defaultUserReturn))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &passwordMocks{
+				denylist:          &mocks.MockDenylist{},
+				hasher:            &mocks.MockPasswordHasher{},
+				lockout:           &mocks.MockLockout{},
+				passwordPolicy:    &mocks.MockPasswordPolicy{},
+				passwordResetRepo: &mocks.MockPasswordResetRepository{},
+				refreshTokenRepo:  &mocks.MockRefreshTokenRepository{},
+				userRepo:          &mocks.MockUserRepository{},
+			}
+			tt.setupMocks(m)
 
-				m.refreshTokenRepo.
-					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
-					Return(testutil.Ok(&auth.RefreshToken{RevokedAt: testutil.Ptr(time.Now())}))
+			err := newPasswordService(m).ResetPassword(t.Context(), auth.ResetPasswordInput{
+				Token:    token,
+				Password: "new-password",
+			})
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			m.denylist.AssertExpectations(t)
+			m.hasher.AssertExpectations(t)
+			m.lockout.AssertExpectations(t)
+			m.passwordPolicy.AssertExpectations(t)
+			m.passwordResetRepo.AssertExpectations(t)
+			m.refreshTokenRepo.AssertExpectations(t)
+			m.userRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	t.Parallel()
+
+	usr := &user.User{ID: 1, Email: "User@Test.com", Password: "current-hash"}
+	familyID := uuid.New()
+	otherJtis := []uuid.UUID{uuid.New(), uuid.New()}
+	input := auth.ChangePasswordInput{CurrentPassword: "old-password", NewPassword: "new-password"}
+
+	principalCtx := func(sessionID *uuid.UUID) func(ctx context.Context) context.Context {
+		return func(ctx context.Context) context.Context {
+			return identity.WithPrincipal(ctx, &identity.Principal{
+				UserID:    usr.ID,
+				Role:      identity.RoleUser,
+				Source:    identity.AuthExternal,
+				SessionID: sessionID,
+			})
+		}
+	}
+
+	// Up to the stored hash, shared by the cases changing the password.
+	passwordChanged := func(m *passwordMocks) {
+		m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+		m.lockout.On("Locked", mock.Anything, "user@test.com").Return(time.Duration(0), nil)
+		m.hasher.On("VerifyPassword", usr.Password, input.CurrentPassword).Return(nil)
+		m.lockout.On("Reset", mock.Anything, "user@test.com").Return(nil)
+		m.passwordPolicy.On("Check", mock.Anything, user.CheckPasswordInput{Field: "new_password", UserID: usr.ID, Password: input.NewPassword}).
+			Return(nil)
+		m.hasher.On("HashPassword", input.NewPassword).Return("new-hash", nil)
+		m.userRepo.On("UpdatePassword", mock.Anything, usr.ID, "new-hash").Return(nil)
+		m.passwordPolicy.On("Remember", mock.Anything, usr.ID, "new-hash").Return(nil)
+		m.passwordResetRepo.On("InvalidateByUserID", mock.Anything, usr.ID).Return(nil)
+	}
+
+	tests := []struct {
+		name       string
+		input      auth.ChangePasswordInput
+		setupCtx   func(ctx context.Context) context.Context
+		setupMocks func(m *passwordMocks)
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name:       "no principal",
+			input:      input,
+			setupMocks: func(m *passwordMocks) {},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusUnauthorized, appErr.StatusCode)
+			},
+		},
+		{
+			name:  "impersonated",
+			input: input,
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID:  usr.ID,
+					Role:    identity.RoleUser,
+					Source:  identity.AuthExternal,
+					ActorID: 9,
+				})
 			},
+			setupMocks: func(m *passwordMocks) {},
 			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusForbidden, appErr.StatusCode)
 			},
 		},
 		{
-			name:  "expired refresh token",
-			input: defaultInput,
-			setupMocks: func(m *refreshMocks) {
-				m.jwtManager.
-					On("ValidateRefreshToken", fakeRefreshToken).
-					Return(testutil.Ok(defaultPrincipal))
-
-				m.userRepo.
-					On("GetByID", mock.Anything, defaultPrincipal.UserID).
-					Return(testutil.Ok(defaultUserReturn))
-
-				m.refreshTokenRepo.
-					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
-					Return(testutil.Ok(&auth.RefreshToken{ExpiresAt: time.Now().Add(-1 * time.Hour)}))
+			name:     "wrong current password counts as a failed login",
+			input:    input,
+			setupCtx: principalCtx(&familyID),
+			setupMocks: func(m *passwordMocks) {
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+				m.lockout.On("Locked", mock.Anything, "user@test.com").Return(time.Duration(0), nil)
+				m.hasher.On("VerifyPassword", usr.Password, input.CurrentPassword).Return(bcrypt.ErrMismatchedHashAndPassword)
+				m.lockout.On("Fail", mock.Anything, "user@test.com").Return(time.Duration(0), nil)
 			},
 			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, auth.MsgInvalidCurrentPassword, appErr.Message)
 			},
 		},
 		{
-			name:  "access token error",
-			input: defaultInput,
-			setupMocks: func(m *refreshMocks) {
-				m.jwtManager.
-					On("ValidateRefreshToken", fakeRefreshToken).
-					Return(testutil.Ok(defaultPrincipal))
-
-				m.userRepo.
-					On("GetByID", mock.Anything, defaultPrincipal.UserID).
-					Return(testutil.Ok(defaultUserReturn))
-
-				m.refreshTokenRepo.
-					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
-					Return(testutil.Ok(&auth.RefreshToken{ExpiresAt: time.Now().Add(time.Hour)}))
-
-				m.jwtManager.
-					On("GenerateAccessToken", defaultUserReturn.ID, defaultUserReturn.Role, mock.Anything).
-					Return(nil, errors.New("signing error"))
+			name:     "locked account",
+			input:    input,
+			setupCtx: principalCtx(&familyID),
+			setupMocks: func(m *passwordMocks) {
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+				m.lockout.On("Locked", mock.Anything, "user@test.com").Return(time.Minute, nil)
+				m.hasher.On("VerifyPassword", usr.Password, input.CurrentPassword).Return(nil)
 			},
 			assertErr: func(t *testing.T, err error) {
-				var nf *pkgerrors.AppError
-				assert.ErrorAs(t, err, &nf)
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusUnauthorized, appErr.StatusCode)
 			},
 		},
 		{
-			name:  "success",
-			input: defaultInput,
-			setupMocks: func(m *refreshMocks) {
-				m.jwtManager.
-					On("ValidateRefreshToken", fakeRefreshToken).
-					Return(testutil.Ok(defaultPrincipal))
-
-				m.userRepo.
-					On("GetByID", mock.Anything, defaultPrincipal.UserID).
-					Return(testutil.Ok(defaultUserReturn))
-
-				m.refreshTokenRepo.
-					On("GetByJTI", mock.Anything, mock.AnythingOfType("uuid.UUID")).
-					Return(testutil.Ok(&auth.RefreshToken{ExpiresAt: time.Now().Add(time.Hour)}))
-
-				m.jwtManager.
-					On("GenerateAccessToken", defaultUserReturn.ID, defaultUserReturn.Role, mock.Anything).
-					Return(fakeAccessTokenResult, nil)
+			name:     "same password",
+			input:    auth.ChangePasswordInput{CurrentPassword: "old-password", NewPassword: "old-password"},
+			setupCtx: principalCtx(&familyID),
+			setupMocks: func(m *passwordMocks) {
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+				m.lockout.On("Locked", mock.Anything, "user@test.com").Return(time.Duration(0), nil)
+				m.hasher.On("VerifyPassword", usr.Password, "old-password").Return(nil)
+				m.lockout.On("Reset", mock.Anything, "user@test.com").Return(nil)
 			},
-			expected: &auth.RefreshOutput{
-				AccessToken: fakeAccessToken,
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, auth.MsgPasswordUnchanged, appErr.Message)
+			},
+		},
+		{
+			name:     "refused by the policy",
+			input:    input,
+			setupCtx: principalCtx(&familyID),
+			setupMocks: func(m *passwordMocks) {
+				m.userRepo.On("GetByID", mock.Anything, usr.ID).Return(usr, nil)
+				m.lockout.On("Locked", mock.Anything, "user@test.com").Return(time.Duration(0), nil)
+				m.hasher.On("VerifyPassword", usr.Password, input.CurrentPassword).Return(nil)
+				m.lockout.On("Reset", mock.Anything, "user@test.com").Return(nil)
+				m.passwordPolicy.On("Check", mock.Anything, mock.Anything).
+					Return(pkgerrors.NewValidationError(user.MsgPasswordPolicy, pkgerrors.FieldError{Field: "new_password", Code: "too_weak"}))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, "VALIDATION_ERROR", appErr.Code)
+				assert.Equal(t, "new_password", appErr.Fields[0].Field)
+			},
+		},
+		{
+			name:     "keeps the current session",
+			input:    input,
+			setupCtx: principalCtx(&familyID),
+			setupMocks: func(m *passwordMocks) {
+				passwordChanged(m)
+				m.refreshTokenRepo.On("RevokeOtherFamilies", mock.Anything, usr.ID, familyID).Return(otherJtis, nil)
+				for _, jti := range otherJtis {
+					m.denylist.On("Revoke", mock.Anything, jti, time.Hour).Return(nil)
+				}
+			},
+		},
+		{
+			name:     "revokes every session without a current one",
+			input:    input,
+			setupCtx: principalCtx(nil),
+			setupMocks: func(m *passwordMocks) {
+				passwordChanged(m)
+				m.refreshTokenRepo.On("RevokeByUserID", mock.Anything, usr.ID).Return(otherJtis, nil)
+				for _, jti := range otherJtis {
+					m.denylist.On("Revoke", mock.Anything, jti, time.Hour).Return(nil)
+				}
+			},
+		},
+		{
+			name: "keeps the other sessions when asked",
+			input: auth.ChangePasswordInput{
+				CurrentPassword:   input.CurrentPassword,
+				NewPassword:       input.NewPassword,
+				KeepOtherSessions: true,
+			},
+			setupCtx:   principalCtx(&familyID),
+			setupMocks: passwordChanged,
+		},
+		{
+			name:     "revoke error",
+			input:    input,
+			setupCtx: principalCtx(&familyID),
+			setupMocks: func(m *passwordMocks) {
+				passwordChanged(m)
+				m.refreshTokenRepo.On("RevokeOtherFamilies", mock.Anything, usr.ID, familyID).Return(nil, errors.New("db error"))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-
-			userRepo := &mocks.MockUserRepository{}
-			jwtManager := &mocks.MockJwtManager{}
-			refreshTokenRepo := &mocks.MockRefreshTokenRepository{}
-			refreshMocks := &refreshMocks{
-				userRepo:         userRepo,
-				jwtManager:       jwtManager,
-				refreshTokenRepo: refreshTokenRepo,
+			m := &passwordMocks{
+				denylist:          &mocks.MockDenylist{},
+				hasher:            &mocks.MockPasswordHasher{},
+				lockout:           &mocks.MockLockout{},
+				passwordPolicy:    &mocks.MockPasswordPolicy{},
+				passwordResetRepo: &mocks.MockPasswordResetRepository{},
+				refreshTokenRepo:  &mocks.MockRefreshTokenRepository{},
+				userRepo:          &mocks.MockUserRepository{},
 			}
-			tt.setupMocks(refreshMocks)
+			tt.setupMocks(m)
 
-			svcDeps := &auth.ServiceDeps{
-				AuthConfig: &config.AuthConfig{
-					FakeHash: fakeHash,
-				},
-				UserRepo:         userRepo,
-				RefreshTokenRepo: refreshTokenRepo,
-				Logger:           slog.Default(),
-				TokenManager:     jwtManager,
+			ctx := t.Context()
+			if tt.setupCtx != nil {
+				ctx = tt.setupCtx(ctx)
 			}
-			service := auth.NewService(svcDeps)
 
-			result, err := service.Refresh(t.Context(), tt.input)
+			err := newPasswordService(m).ChangePassword(ctx, tt.input)
 
 			if tt.assertErr != nil {
 				assert.Error(t, err)
 				tt.assertErr(t, err)
-				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.expected, result)
 			}
 
-			userRepo.AssertExpectations(t)
-			refreshTokenRepo.AssertExpectations(t)
-			jwtManager.AssertExpectations(t)
+			m.denylist.AssertExpectations(t)
+			m.hasher.AssertExpectations(t)
+			m.lockout.AssertExpectations(t)
+			m.passwordPolicy.AssertExpectations(t)
+			m.passwordResetRepo.AssertExpectations(t)
+			m.refreshTokenRepo.AssertExpectations(t)
+			m.userRepo.AssertExpectations(t)
 		})
 	}
 }
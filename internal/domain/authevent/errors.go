@@ -0,0 +1,6 @@
+package authevent
+
+var (
+	MsgUnknownType      = "unknown event type"
+	MsgInvalidTimeRange = "from must be before to"
+)
@@ -0,0 +1,14 @@
+package authevent
+
+import "time"
+
+// ListInput filters the events, see Filter. Limit defaults to DefaultListLimit and is capped at MaxListLimit.
+type ListInput struct {
+	UserID    *uint
+	Type      Type
+	IPAddress string
+	From      *time.Time
+	To        *time.Time
+	BeforeID  uint64
+	Limit     int
+}
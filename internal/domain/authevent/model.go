@@ -0,0 +1,43 @@
+package authevent
+
+import "time"
+
+// Type is the outcome of an authentication attempt, or a change to a session.
+type Type string
+
+const (
+	TypeLoginSuccess Type = "login_success"
+	TypeLoginFailure Type = "login_failure"
+	TypeRefresh      Type = "refresh"
+	TypeLogout       Type = "logout"
+	TypeLogoutAll    Type = "logout_all"
+	// TypeLockout is recorded along the failure which locked the account.
+	TypeLockout Type = "lockout"
+	// TypeReauthenticate is a session proving its credentials again, for a sensitive operation.
+	TypeReauthenticate Type = "reauthenticate"
+)
+
+// Reasons of the failures.
+const (
+	ReasonInvalidCredentials = "invalid_credentials"
+	ReasonAccountLocked      = "account_locked"
+	ReasonEmailNotVerified   = "email_not_verified"
+	ReasonInvalidMFACode     = "invalid_mfa_code"
+	ReasonTooManyFailures    = "too_many_failures"
+)
+
+// Event is an entry of the authentication log. UserID is unset when the attempt matched no user.
+type Event struct {
+	ID        uint64 `gorm:"primaryKey"`
+	Type      Type   `gorm:"type:varchar(32);not null"`
+	UserID    *uint  `gorm:"column:user_id"`
+	IPAddress string `gorm:"type:varchar(45);not null;column:ip_address"`
+	UserAgent string `gorm:"not null"`
+	Reason    string `gorm:"type:varchar(64);not null"`
+	TraceID   string `gorm:"type:varchar(32);not null;column:trace_id"`
+	CreatedAt time.Time
+}
+
+func (Event) TableName() string {
+	return "auth_events"
+}
@@ -0,0 +1,141 @@
+package authevent
+
+import (
+	"context"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/observability/logging"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// flushTimeout bounds the write of the events still buffered on shutdown.
+const flushTimeout = 5 * time.Second
+
+// Recorder records authentication events without waiting for them to be written.
+type Recorder interface {
+	Record(ctx context.Context, event Event)
+}
+
+type RecorderDeps struct {
+	Config *config.AuthEventsConfig
+	Logger *slog.Logger
+	Repo   Repository
+	// UserRepo records the last login of the users, along their login events.
+	UserRepo user.UserRepository
+}
+
+// BatchRecorder buffers the events and writes them in batches from Run.
+type BatchRecorder struct {
+	events        chan Event
+	batchSize     int
+	flushInterval time.Duration
+	logger        *slog.Logger
+	repo          Repository
+	userRepo      user.UserRepository
+}
+
+func NewBatchRecorder(deps *RecorderDeps) *BatchRecorder {
+	return &BatchRecorder{
+		events:        make(chan Event, deps.Config.BufferSize),
+		batchSize:     deps.Config.BatchSize,
+		flushInterval: deps.Config.FlushInterval,
+		logger:        deps.Logger,
+		repo:          deps.Repo,
+		userRepo:      deps.UserRepo,
+	}
+}
+
+// Record timestamps the event and tags it with the trace of the request. It never blocks, the event
+// is dropped if the buffer is full.
+func (r *BatchRecorder) Record(ctx context.Context, event Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	if span := trace.SpanFromContext(ctx).SpanContext(); span.IsValid() {
+		event.TraceID = span.TraceID().String()
+	}
+
+	select {
+	case r.events <- event:
+	default:
+		logging.FromContext(ctx).Warn("auth event dropped, the buffer is full", slog.String("type", string(event.Type)))
+	}
+}
+
+// Run writes the events until the context is done, then the ones still buffered.
+func (r *BatchRecorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, r.batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			r.drain(ctx, batch)
+			return
+		case event := <-r.events:
+			batch = append(batch, event)
+			if len(batch) >= r.batchSize {
+				batch = r.flush(ctx, batch)
+			}
+		case <-ticker.C:
+			batch = r.flush(ctx, batch)
+		}
+	}
+}
+
+// drain writes the buffered events once Run is stopped, the context of Run is done by then.
+func (r *BatchRecorder) drain(ctx context.Context, batch []Event) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case event := <-r.events:
+			batch = append(batch, event)
+			if len(batch) >= r.batchSize {
+				batch = r.flush(ctx, batch)
+			}
+		default:
+			r.flush(ctx, batch)
+			return
+		}
+	}
+}
+
+// flush writes the batch and returns it emptied. A failed batch is lost, logins don't wait for retries.
+func (r *BatchRecorder) flush(ctx context.Context, batch []Event) []Event {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := r.repo.CreateBatch(ctx, batch); err != nil {
+		r.logger.Error("failed to write auth events", slog.Int("count", len(batch)), slog.Any("err", err))
+	}
+
+	r.updateLastLogins(ctx, batch)
+
+	return batch[:0]
+}
+
+// updateLastLogins records the latest login of each user of the batch.
+func (r *BatchRecorder) updateLastLogins(ctx context.Context, batch []Event) {
+	latest := make(map[uint]Event)
+	for _, event := range batch {
+		if event.Type != TypeLoginSuccess || event.UserID == nil {
+			continue
+		}
+		if last, ok := latest[*event.UserID]; !ok || event.CreatedAt.After(last.CreatedAt) {
+			latest[*event.UserID] = event
+		}
+	}
+
+	for userID, event := range latest {
+		if err := r.userRepo.UpdateLastLogin(ctx, userID, event.CreatedAt, event.IPAddress); err != nil {
+			r.logger.Warn("failed to record last login", slog.Uint64("user_id", uint64(userID)), slog.Any("err", err))
+		}
+	}
+}
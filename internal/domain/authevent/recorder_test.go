@@ -0,0 +1,156 @@
+package authevent_test
+
+import (
+	"context"
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/mocks"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newRecorder(repo *mocks.MockAuthEventRepository, userRepo *mocks.MockUserRepository, bufferSize, batchSize int) *authevent.BatchRecorder {
+	return authevent.NewBatchRecorder(&authevent.RecorderDeps{
+		Config: &config.AuthEventsConfig{
+			BufferSize:    bufferSize,
+			BatchSize:     batchSize,
+			FlushInterval: time.Hour,
+		},
+		Logger:   slog.Default(),
+		Repo:     repo,
+		UserRepo: userRepo,
+	})
+}
+
+// runStopped runs the recorder until it has written what was recorded before.
+func runStopped(recorder *authevent.BatchRecorder) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder.Run(ctx)
+}
+
+func TestBatchRecorder_Batches(t *testing.T) {
+	t.Parallel()
+
+	at := time.Now()
+	events := []authevent.Event{
+		{Type: authevent.TypeLogout, CreatedAt: at},
+		{Type: authevent.TypeRefresh, CreatedAt: at},
+		{Type: authevent.TypeLogoutAll, CreatedAt: at},
+	}
+
+	repo := &mocks.MockAuthEventRepository{}
+	repo.On("CreateBatch", mock.Anything, events[:2]).Return(nil).Once()
+	repo.On("CreateBatch", mock.Anything, events[2:]).Return(nil).Once()
+
+	recorder := newRecorder(repo, &mocks.MockUserRepository{}, 10, 2)
+	for _, event := range events {
+		recorder.Record(t.Context(), event)
+	}
+	runStopped(recorder)
+
+	repo.AssertExpectations(t)
+}
+
+func TestBatchRecorder_DropsWhenFull(t *testing.T) {
+	t.Parallel()
+
+	repo := &mocks.MockAuthEventRepository{}
+	repo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(events []authevent.Event) bool {
+		return len(events) == 1 && events[0].Type == authevent.TypeLogout
+	})).Return(nil).Once()
+
+	recorder := newRecorder(repo, &mocks.MockUserRepository{}, 1, 1)
+	recorder.Record(t.Context(), authevent.Event{Type: authevent.TypeLogout})
+	recorder.Record(t.Context(), authevent.Event{Type: authevent.TypeRefresh})
+	runStopped(recorder)
+
+	repo.AssertExpectations(t)
+}
+
+func TestBatchRecorder_StampsEvents(t *testing.T) {
+	t.Parallel()
+
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	ctx := trace.ContextWithSpanContext(t.Context(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+	}))
+
+	repo := &mocks.MockAuthEventRepository{}
+	repo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(events []authevent.Event) bool {
+		return len(events) == 1 && events[0].TraceID == traceID.String() && !events[0].CreatedAt.IsZero()
+	})).Return(nil)
+
+	recorder := newRecorder(repo, &mocks.MockUserRepository{}, 10, 10)
+	recorder.Record(ctx, authevent.Event{Type: authevent.TypeLogout})
+	runStopped(recorder)
+
+	repo.AssertExpectations(t)
+}
+
+func TestBatchRecorder_LastLogin(t *testing.T) {
+	t.Parallel()
+
+	userID := uint(1)
+	otherID := uint(2)
+	first := time.Now().Add(-time.Minute)
+	last := time.Now()
+
+	repo := &mocks.MockAuthEventRepository{}
+	// The last logins are recorded even if the events couldn't be written.
+	repo.On("CreateBatch", mock.Anything, mock.Anything).Return(errors.New("db down"))
+
+	userRepo := &mocks.MockUserRepository{}
+	userRepo.On("UpdateLastLogin", mock.Anything, userID, last, "192.0.2.2").Return(nil).Once()
+	userRepo.On("UpdateLastLogin", mock.Anything, otherID, first, "192.0.2.3").Return(errors.New("db down")).Once()
+
+	recorder := newRecorder(repo, userRepo, 10, 10)
+	recorder.Record(t.Context(), authevent.Event{Type: authevent.TypeLoginSuccess, UserID: &userID, IPAddress: "192.0.2.1", CreatedAt: first})
+	recorder.Record(t.Context(), authevent.Event{Type: authevent.TypeLoginSuccess, UserID: &userID, IPAddress: "192.0.2.2", CreatedAt: last})
+	recorder.Record(t.Context(), authevent.Event{Type: authevent.TypeLoginSuccess, UserID: &otherID, IPAddress: "192.0.2.3", CreatedAt: first})
+	recorder.Record(t.Context(), authevent.Event{Type: authevent.TypeLoginFailure, UserID: &userID, CreatedAt: last.Add(time.Second)})
+	runStopped(recorder)
+
+	repo.AssertExpectations(t)
+	userRepo.AssertExpectations(t)
+}
+
+func TestBatchRecorder_FlushesOnInterval(t *testing.T) {
+	t.Parallel()
+
+	written := make(chan struct{})
+	repo := &mocks.MockAuthEventRepository{}
+	repo.On("CreateBatch", mock.Anything, mock.Anything).Return(nil).Run(func(mock.Arguments) { close(written) }).Once()
+
+	recorder := authevent.NewBatchRecorder(&authevent.RecorderDeps{
+		Config:   &config.AuthEventsConfig{BufferSize: 10, BatchSize: 10, FlushInterval: 10 * time.Millisecond},
+		Logger:   slog.Default(),
+		Repo:     repo,
+		UserRepo: &mocks.MockUserRepository{},
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		recorder.Run(ctx)
+	}()
+
+	recorder.Record(t.Context(), authevent.Event{Type: authevent.TypeLogout})
+
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatal("events not written")
+	}
+
+	cancel()
+	<-done
+	repo.AssertExpectations(t)
+}
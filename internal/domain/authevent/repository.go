@@ -0,0 +1,93 @@
+package authevent
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Filter narrows a listing, zero fields match everything. Events are listed newest first, BeforeID
+// continues a listing after its last event.
+type Filter struct {
+	UserID    *uint
+	Type      Type
+	IPAddress string
+	From      *time.Time
+	To        *time.Time
+	BeforeID  uint64
+	Limit     int
+}
+
+type Repository interface {
+	CreateBatch(ctx context.Context, events []Event) error
+	List(ctx context.Context, filter Filter) ([]Event, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+// CreateBatch inserts the events with a single statement.
+func (r *repository) CreateBatch(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Create(&events).Error
+}
+
+func (r *repository) List(ctx context.Context, filter Filter) ([]Event, error) {
+	query := r.db.WithContext(ctx).Model(&Event{})
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+
+	var events []Event
+	err := query.
+		Order("id DESC").
+		Limit(filter.Limit).
+		Find(&events).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// DeleteBefore prunes the events created before the time, it returns how many were deleted.
+func (r *repository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&Event{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
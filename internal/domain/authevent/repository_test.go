@@ -0,0 +1,127 @@
+package authevent_test
+
+import (
+	"gomonitor/internal/domain/authevent"
+	databaseinfra "gomonitor/internal/infra/database"
+	"gomonitor/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_CreateBatch(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := authevent.NewRepository(tx)
+
+	events := []authevent.Event{
+		{Type: authevent.TypeLoginSuccess, UserID: testutil.Ptr(uint(1)), IPAddress: "192.0.2.1", UserAgent: "curl/8.0"},
+		{Type: authevent.TypeLoginFailure, IPAddress: "192.0.2.2", Reason: authevent.ReasonInvalidCredentials, TraceID: "0102"},
+	}
+	require.NoError(t, repo.CreateBatch(t.Context(), events))
+	assert.NotZero(t, events[0].ID)
+	assert.NotZero(t, events[1].ID)
+
+	assert.NoError(t, repo.CreateBatch(t.Context(), nil))
+	assert.Error(t, repo.CreateBatch(testutil.GetCancelledCtx(t.Context()), events))
+}
+
+func TestRepository_List(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := authevent.NewRepository(tx)
+
+	now := time.Now()
+	events := []authevent.Event{
+		{Type: authevent.TypeLoginFailure, IPAddress: "192.0.2.1", CreatedAt: now.Add(-2 * time.Hour)},
+		{Type: authevent.TypeLoginSuccess, UserID: testutil.Ptr(uint(1)), IPAddress: "192.0.2.1", CreatedAt: now.Add(-time.Hour)},
+		{Type: authevent.TypeLogout, UserID: testutil.Ptr(uint(1)), IPAddress: "192.0.2.2", CreatedAt: now},
+	}
+	require.NoError(t, repo.CreateBatch(t.Context(), events))
+
+	tests := []struct {
+		name     string
+		filter   authevent.Filter
+		expected []uint64
+	}{
+		{
+			name:     "newest first",
+			filter:   authevent.Filter{Limit: 10},
+			expected: []uint64{events[2].ID, events[1].ID, events[0].ID},
+		},
+		{
+			name:     "by user",
+			filter:   authevent.Filter{UserID: testutil.Ptr(uint(1)), Limit: 10},
+			expected: []uint64{events[2].ID, events[1].ID},
+		},
+		{
+			name:     "by type",
+			filter:   authevent.Filter{Type: authevent.TypeLoginFailure, Limit: 10},
+			expected: []uint64{events[0].ID},
+		},
+		{
+			name:     "by ip",
+			filter:   authevent.Filter{IPAddress: "192.0.2.1", Limit: 10},
+			expected: []uint64{events[1].ID, events[0].ID},
+		},
+		{
+			name:     "time range",
+			filter:   authevent.Filter{From: testutil.Ptr(now.Add(-90 * time.Minute)), To: testutil.Ptr(now.Add(-time.Minute)), Limit: 10},
+			expected: []uint64{events[1].ID},
+		},
+		{
+			name:     "next page",
+			filter:   authevent.Filter{BeforeID: events[2].ID, Limit: 1},
+			expected: []uint64{events[1].ID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.List(t.Context(), tt.filter)
+			require.NoError(t, err)
+
+			ids := make([]uint64, 0, len(got))
+			for _, event := range got {
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+
+	_, err := repo.List(testutil.GetCancelledCtx(t.Context()), authevent.Filter{Limit: 10})
+	assert.Error(t, err)
+}
+
+func TestRepository_DeleteBefore(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := authevent.NewRepository(tx)
+
+	now := time.Now()
+	events := []authevent.Event{
+		{Type: authevent.TypeLogout, CreatedAt: now.Add(-48 * time.Hour)},
+		{Type: authevent.TypeLogout, CreatedAt: now},
+	}
+	require.NoError(t, repo.CreateBatch(t.Context(), events))
+
+	deleted, err := repo.DeleteBefore(t.Context(), now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	remaining, err := repo.List(t.Context(), authevent.Filter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, events[1].ID, remaining[0].ID)
+
+	_, err = repo.DeleteBefore(testutil.GetCancelledCtx(t.Context()), now)
+	assert.Error(t, err)
+}
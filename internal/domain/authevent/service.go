@@ -0,0 +1,128 @@
+package authevent
+
+import (
+	"context"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+var knownTypes = []Type{TypeLoginSuccess, TypeLoginFailure, TypeRefresh, TypeLogout, TypeLogoutAll, TypeLockout, TypeReauthenticate}
+
+type Service interface {
+	List(ctx context.Context, input ListInput) ([]Event, error)
+	Prune(ctx context.Context) (int64, error)
+	RunRetention(ctx context.Context, interval time.Duration)
+}
+
+type ServiceDeps struct {
+	Logger *slog.Logger
+	Repo   Repository
+	// Retention is how long events are kept by Prune.
+	Retention time.Duration
+}
+
+type service struct {
+	logger    *slog.Logger
+	repo      Repository
+	retention time.Duration
+}
+
+func NewService(deps *ServiceDeps) Service {
+	return &service{
+		logger:    deps.Logger,
+		repo:      deps.Repo,
+		retention: deps.Retention,
+	}
+}
+
+// List returns the events matching the input, newest first.
+func (s *service) List(ctx context.Context, input ListInput) ([]Event, error) {
+	if err := s.requirePermission(ctx, identity.PermAuthEventsRead); err != nil {
+		return nil, err
+	}
+
+	if input.Type != "" && !slices.Contains(knownTypes, input.Type) {
+		return nil, pkgerrors.NewBadRequestError(MsgUnknownType)
+	}
+	if input.From != nil && input.To != nil && !input.From.Before(*input.To) {
+		return nil, pkgerrors.NewBadRequestError(MsgInvalidTimeRange)
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+
+	events, err := s.repo.List(ctx, Filter{
+		UserID:    input.UserID,
+		Type:      input.Type,
+		IPAddress: input.IPAddress,
+		From:      input.From,
+		To:        input.To,
+		BeforeID:  input.BeforeID,
+		Limit:     min(limit, MaxListLimit),
+	})
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return events, nil
+}
+
+// Prune deletes the events older than the retention.
+func (s *service) Prune(ctx context.Context) (int64, error) {
+	return s.repo.DeleteBefore(ctx, time.Now().Add(-s.retention))
+}
+
+// RunRetention periodically prunes the events, every instance may run it.
+func (s *service) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.Prune(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.logger.Warn("failed to prune auth events", slog.Any("err", err))
+				}
+				continue
+			}
+			if deleted > 0 {
+				s.logger.Info("pruned auth events", slog.Int64("count", deleted))
+			}
+		}
+	}
+}
+
+func (s *service) requirePermission(ctx context.Context, permission identity.Permission) error {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Warn("unauthenticated auth events request")
+		return pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	if !principal.HasPermission(permission) {
+		logging.FromContext(ctx).Warn("unauthorized auth events request",
+			"permission", permission,
+			"user_id", principal.UserID,
+			"user_role", principal.Role,
+			"source", principal.Source,
+		)
+		return pkgerrors.NewForbiddenError()
+	}
+
+	return nil
+}
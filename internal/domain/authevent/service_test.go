@@ -0,0 +1,180 @@
+package authevent_test
+
+import (
+	"context"
+	"errors"
+	"gomonitor/internal/domain/authevent"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/testutil"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func adminContext(ctx context.Context) context.Context {
+	return identity.WithPrincipal(ctx, &identity.Principal{
+		UserID:      1,
+		Role:        identity.RoleAdmin,
+		Permissions: []identity.Permission{identity.PermAuthEventsRead},
+	})
+}
+
+func TestService_List(t *testing.T) {
+	t.Parallel()
+
+	from := time.Now().Add(-time.Hour)
+	to := time.Now()
+	events := []authevent.Event{{ID: 2, Type: authevent.TypeLoginFailure}}
+
+	tests := []struct {
+		name       string
+		ctx        func(context.Context) context.Context
+		input      authevent.ListInput
+		setupMocks func(*mocks.MockAuthEventRepository)
+		status     int
+	}{
+		{
+			name: "default limit",
+			ctx:  adminContext,
+			setupMocks: func(m *mocks.MockAuthEventRepository) {
+				m.On("List", mock.Anything, authevent.Filter{Limit: authevent.DefaultListLimit}).Return(events, nil)
+			},
+		},
+		{
+			name: "filters",
+			ctx:  adminContext,
+			input: authevent.ListInput{
+				UserID:    testutil.Ptr(uint(3)),
+				Type:      authevent.TypeLoginFailure,
+				IPAddress: "192.0.2.1",
+				From:      &from,
+				To:        &to,
+				BeforeID:  10,
+				Limit:     5000,
+			},
+			setupMocks: func(m *mocks.MockAuthEventRepository) {
+				m.On("List", mock.Anything, authevent.Filter{
+					UserID:    testutil.Ptr(uint(3)),
+					Type:      authevent.TypeLoginFailure,
+					IPAddress: "192.0.2.1",
+					From:      &from,
+					To:        &to,
+					BeforeID:  10,
+					Limit:     authevent.MaxListLimit,
+				}).Return(events, nil)
+			},
+		},
+		{
+			name:   "unknown type",
+			ctx:    adminContext,
+			input:  authevent.ListInput{Type: "password_reset"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "inverted time range",
+			ctx:    adminContext,
+			input:  authevent.ListInput{From: &to, To: &from},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "repository error",
+			ctx:  adminContext,
+			setupMocks: func(m *mocks.MockAuthEventRepository) {
+				m.On("List", mock.Anything, mock.Anything).Return(nil, errors.New("db down"))
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name: "missing permission",
+			ctx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{UserID: 2, Role: identity.RoleUser})
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "unauthenticated",
+			ctx:    func(ctx context.Context) context.Context { return ctx },
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockAuthEventRepository{}
+			if tt.setupMocks != nil {
+				tt.setupMocks(repo)
+			}
+
+			service := authevent.NewService(&authevent.ServiceDeps{Logger: slog.Default(), Repo: repo})
+			out, err := service.List(tt.ctx(t.Context()), tt.input)
+
+			if tt.status != 0 {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.status, appErr.StatusCode)
+				assert.Nil(t, out)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, events, out)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Prune(t *testing.T) {
+	t.Parallel()
+
+	retention := 24 * time.Hour
+	repo := &mocks.MockAuthEventRepository{}
+	repo.On("DeleteBefore", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= retention && time.Since(before) < retention+time.Minute
+	})).Return(int64(3), nil)
+
+	service := authevent.NewService(&authevent.ServiceDeps{Logger: slog.Default(), Repo: repo, Retention: retention})
+	deleted, err := service.Prune(t.Context())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	repo.AssertExpectations(t)
+}
+
+func TestService_RunRetention(t *testing.T) {
+	t.Parallel()
+
+	pruned := make(chan struct{}, 1)
+	repo := &mocks.MockAuthEventRepository{}
+	repo.On("DeleteBefore", mock.Anything, mock.Anything).Return(int64(0), errors.New("db down")).Once()
+	repo.On("DeleteBefore", mock.Anything, mock.Anything).Return(int64(1), nil).Run(func(mock.Arguments) {
+		select {
+		case pruned <- struct{}{}:
+		default:
+		}
+	})
+
+	service := authevent.NewService(&authevent.ServiceDeps{Logger: slog.Default(), Repo: repo, Retention: time.Hour})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		service.RunRetention(ctx, 10*time.Millisecond)
+	}()
+
+	// A failed run doesn't stop the job.
+	select {
+	case <-pruned:
+	case <-time.After(time.Second):
+		t.Fatal("events not pruned")
+	}
+
+	cancel()
+	<-done
+}
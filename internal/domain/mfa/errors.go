@@ -0,0 +1,8 @@
+package mfa
+
+var (
+	MsgMFAAlreadyEnabled = "mfa is already enabled"
+	MsgMFANotEnrolled    = "mfa enrollment not started"
+	MsgMFANotEnabled     = "mfa is not enabled"
+	MsgInvalidMFACode    = "invalid mfa code"
+)
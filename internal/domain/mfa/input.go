@@ -0,0 +1,12 @@
+package mfa
+
+// CodeInput carries a TOTP code, confirming the user still holds the authenticator.
+type CodeInput struct {
+	Code string
+}
+
+// VerifyInput checks the second factor of a login, Code is a TOTP or a recovery code.
+type VerifyInput struct {
+	UserID uint
+	Code   string
+}
@@ -0,0 +1,28 @@
+package mfa
+
+import "time"
+
+// TOTPFactor is the authenticator app enrolled by a user, it only protects logins once confirmed.
+// Secret is encrypted at rest, LastUsedStep stops a code from being accepted twice.
+type TOTPFactor struct {
+	UserID       uint   `gorm:"primaryKey"`
+	Secret       string `gorm:"not null"`
+	LastUsedStep int64  `gorm:"not null;default:0"`
+	ConfirmedAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Confirmed reports if the factor is required on login.
+func (f *TOTPFactor) Confirmed() bool {
+	return f.ConfirmedAt != nil
+}
+
+// RecoveryCode replaces a TOTP code once, when the authenticator is lost. Only its SHA-256 is stored.
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null"`
+	CodeHash  string `gorm:"type:char(64);not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,12 @@
+package mfa
+
+// EnrollOutput is shown once, the URI is rendered as a QR code for the authenticator app.
+type EnrollOutput struct {
+	Secret string
+	URI    string
+}
+
+// RecoveryCodesOutput holds the plain codes, they can't be retrieved again.
+type RecoveryCodesOutput struct {
+	Codes []string
+}
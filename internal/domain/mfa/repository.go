@@ -0,0 +1,119 @@
+package mfa
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	GetFactor(ctx context.Context, userID uint) (*TOTPFactor, error)
+	SaveFactor(ctx context.Context, factor *TOTPFactor) error
+	ConfirmFactor(ctx context.Context, userID uint, step int64) (bool, error)
+	UseStep(ctx context.Context, userID uint, step int64) (bool, error)
+	Delete(ctx context.Context, userID uint) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) GetFactor(ctx context.Context, userID uint) (*TOTPFactor, error) {
+	var factor TOTPFactor
+	if err := r.db.WithContext(ctx).First(&factor, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	return &factor, nil
+}
+
+// SaveFactor replaces a pending enrollment, restarting it with a new secret.
+func (r *repository) SaveFactor(ctx context.Context, factor *TOTPFactor) error {
+	return r.db.
+		WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"secret", "last_used_step", "confirmed_at", "updated_at"}),
+		}).
+		Create(factor).
+		Error
+}
+
+// ConfirmFactor enables a pending factor, the step of the confirmation code counts as used.
+func (r *repository) ConfirmFactor(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.
+		WithContext(ctx).
+		Model(&TOTPFactor{}).
+		Where("user_id = ? AND confirmed_at IS NULL", userID).
+		Updates(map[string]any{
+			"confirmed_at":   gorm.Expr("NOW()"),
+			"last_used_step": step,
+			"updated_at":     gorm.Expr("NOW()"),
+		})
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// UseStep records the step of an accepted code, false when it, or a later one, was already used.
+func (r *repository) UseStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.
+		WithContext(ctx).
+		Model(&TOTPFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]any{
+			"last_used_step": step,
+			"updated_at":     gorm.Expr("NOW()"),
+		})
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// Delete removes the factor with its recovery codes.
+func (r *repository) Delete(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&TOTPFactor{}).Error
+	})
+}
+
+// ReplaceRecoveryCodes invalidates the previous codes of the user.
+func (r *repository) ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode marks the code as used, false when it doesn't exist or was already used.
+func (r *repository) UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error) {
+	result := r.db.
+		WithContext(ctx).
+		Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", gorm.Expr("NOW()"))
+
+	return result.RowsAffected == 1, result.Error
+}
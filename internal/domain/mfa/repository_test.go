@@ -0,0 +1,103 @@
+package mfa_test
+
+import (
+	"gomonitor/internal/domain/mfa"
+	databaseinfra "gomonitor/internal/infra/database"
+	"gomonitor/internal/testutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRepository_Factor(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := mfa.NewRepository(tx)
+
+	_, err := repo.GetFactor(t.Context(), 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	require.NoError(t, repo.SaveFactor(t.Context(), &mfa.TOTPFactor{UserID: 1, Secret: "first"}))
+
+	// A new enrollment replaces the pending one.
+	require.NoError(t, repo.SaveFactor(t.Context(), &mfa.TOTPFactor{UserID: 1, Secret: "second"}))
+
+	factor, err := repo.GetFactor(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "second", factor.Secret)
+	assert.False(t, factor.Confirmed())
+
+	confirmed, err := repo.ConfirmFactor(t.Context(), 1, 100)
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+
+	confirmed, err = repo.ConfirmFactor(t.Context(), 1, 101)
+	require.NoError(t, err)
+	assert.False(t, confirmed)
+
+	// The confirmation step and older ones are used.
+	fresh, err := repo.UseStep(t.Context(), 1, 100)
+	require.NoError(t, err)
+	assert.False(t, fresh)
+
+	fresh, err = repo.UseStep(t.Context(), 1, 101)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	factor, err = repo.GetFactor(t.Context(), 1)
+	require.NoError(t, err)
+	assert.True(t, factor.Confirmed())
+	assert.Equal(t, int64(101), factor.LastUsedStep)
+
+	_, err = repo.GetFactor(testutil.GetCancelledCtx(t.Context()), 1)
+	assert.Error(t, err)
+}
+
+func TestRepository_RecoveryCodes(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := mfa.NewRepository(tx)
+
+	// Stored hashes are 64 hex characters.
+	hash := func(c string) string {
+		return strings.Repeat(c, 64)
+	}
+
+	require.NoError(t, repo.ReplaceRecoveryCodes(t.Context(), 2, []string{hash("a"), hash("b")}))
+
+	used, err := repo.UseRecoveryCode(t.Context(), 2, hash("a"))
+	require.NoError(t, err)
+	assert.True(t, used)
+
+	used, err = repo.UseRecoveryCode(t.Context(), 2, hash("a"))
+	require.NoError(t, err)
+	assert.False(t, used, "codes are single use")
+
+	used, err = repo.UseRecoveryCode(t.Context(), 3, hash("b"))
+	require.NoError(t, err)
+	assert.False(t, used, "codes belong to one user")
+
+	// Regenerating invalidates the previous codes.
+	require.NoError(t, repo.ReplaceRecoveryCodes(t.Context(), 2, []string{hash("c")}))
+
+	used, err = repo.UseRecoveryCode(t.Context(), 2, hash("b"))
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	require.NoError(t, repo.SaveFactor(t.Context(), &mfa.TOTPFactor{UserID: 2, Secret: "secret"}))
+	require.NoError(t, repo.Delete(t.Context(), 2))
+
+	_, err = repo.GetFactor(t.Context(), 2)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	used, err = repo.UseRecoveryCode(t.Context(), 2, hash("c"))
+	require.NoError(t, err)
+	assert.False(t, used)
+}
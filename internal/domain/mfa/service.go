@@ -0,0 +1,374 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/observability/logging"
+	"gomonitor/internal/pkg/encryption"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/ratelimit"
+	"gomonitor/internal/pkg/totp"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const recoveryCodeCount = 10
+
+type Service interface {
+	Enroll(ctx context.Context) (*EnrollOutput, error)
+	Confirm(ctx context.Context, input CodeInput) (*RecoveryCodesOutput, error)
+	Disable(ctx context.Context, input CodeInput) error
+	RegenerateRecoveryCodes(ctx context.Context, input CodeInput) (*RecoveryCodesOutput, error)
+	IsEnabled(ctx context.Context, userID uint) (bool, error)
+	Verify(ctx context.Context, input VerifyInput) error
+}
+
+type ServiceDeps struct {
+	AuthConfig *config.AuthConfig
+	Cipher     encryption.Cipher
+	Logger     *slog.Logger
+	// Lockout counts failed codes when managing the factor, the same one logins use.
+	Lockout  ratelimit.Lockout
+	Repo     Repository
+	UserRepo user.UserRepository
+}
+
+type service struct {
+	authCfg  *config.AuthConfig
+	cipher   encryption.Cipher
+	logger   *slog.Logger
+	lockout  ratelimit.Lockout
+	repo     Repository
+	userRepo user.UserRepository
+}
+
+func NewService(deps *ServiceDeps) Service {
+	return &service{
+		authCfg:  deps.AuthConfig,
+		cipher:   deps.Cipher,
+		logger:   deps.Logger,
+		lockout:  deps.Lockout,
+		repo:     deps.Repo,
+		userRepo: deps.UserRepo,
+	}
+}
+
+// Enroll starts a new enrollment for the caller, replacing a pending one.
+func (s *service) Enroll(ctx context.Context) (*EnrollOutput, error) {
+	principal, err := accountOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	factor, err := s.repo.GetFactor(ctx, principal.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+	if factor != nil && factor.Confirmed() {
+		return nil, pkgerrors.NewConflictError(MsgMFAAlreadyEnabled)
+	}
+
+	u, err := s.userRepo.GetByID(ctx, principal.UserID)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	encrypted, err := s.cipher.Encrypt([]byte(secret))
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	if err := s.repo.SaveFactor(ctx, &TOTPFactor{UserID: u.ID, Secret: encrypted}); err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return &EnrollOutput{
+		Secret: secret,
+		URI:    totp.URI(s.authCfg.MFAIssuer, u.Email, secret),
+	}, nil
+}
+
+// Confirm enables the pending factor with a first code, returning the recovery codes.
+func (s *service) Confirm(ctx context.Context, input CodeInput) (*RecoveryCodesOutput, error) {
+	principal, err := accountOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	factor, err := s.getFactor(ctx, principal.UserID, MsgMFANotEnrolled)
+	if err != nil {
+		return nil, err
+	}
+	if factor.Confirmed() {
+		return nil, pkgerrors.NewConflictError(MsgMFAAlreadyEnabled)
+	}
+
+	step, err := s.validateTOTP(factor, input.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stored before enabling the factor, a failure leaves the enrollment pending.
+	codes, err := s.replaceRecoveryCodes(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed, err := s.repo.ConfirmFactor(ctx, principal.UserID, step)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+	if !confirmed {
+		return nil, pkgerrors.NewConflictError(MsgMFAAlreadyEnabled)
+	}
+
+	logging.FromContext(ctx).Info("mfa enabled", slog.Any("user_id", principal.UserID))
+
+	return codes, nil
+}
+
+// Disable removes the factor, a recovery code is accepted in case the authenticator is lost.
+func (s *service) Disable(ctx context.Context, input CodeInput) error {
+	principal, err := accountOwner(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.limitAttempts(ctx, principal.UserID, func() error {
+		return s.Verify(ctx, VerifyInput{UserID: principal.UserID, Code: input.Code})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, principal.UserID); err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	logging.FromContext(ctx).Info("mfa disabled", slog.Any("user_id", principal.UserID))
+
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates the previous codes, it requires a TOTP code.
+func (s *service) RegenerateRecoveryCodes(ctx context.Context, input CodeInput) (*RecoveryCodesOutput, error) {
+	principal, err := accountOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	factor, err := s.getFactor(ctx, principal.UserID, MsgMFANotEnabled)
+	if err != nil {
+		return nil, err
+	}
+	if !factor.Confirmed() {
+		return nil, pkgerrors.NewBadRequestError(MsgMFANotEnabled)
+	}
+
+	err = s.limitAttempts(ctx, principal.UserID, func() error {
+		return s.useTOTP(ctx, factor, input.Code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, principal.UserID)
+}
+
+func (s *service) IsEnabled(ctx context.Context, userID uint) (bool, error) {
+	factor, err := s.repo.GetFactor(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return factor.Confirmed(), nil
+}
+
+// Verify checks a TOTP code, or consumes a recovery code.
+func (s *service) Verify(ctx context.Context, input VerifyInput) error {
+	factor, err := s.getFactor(ctx, input.UserID, MsgMFANotEnabled)
+	if err != nil {
+		return err
+	}
+	if !factor.Confirmed() {
+		return pkgerrors.NewBadRequestError(MsgMFANotEnabled)
+	}
+
+	if len(input.Code) == totp.Digits {
+		return s.useTOTP(ctx, factor, input.Code)
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, input.UserID, hashRecoveryCode(input.Code))
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+	if !used {
+		return pkgerrors.NewUnauthorizedError(MsgInvalidMFACode)
+	}
+
+	logging.FromContext(ctx).Info("recovery code used", slog.Any("user_id", input.UserID))
+
+	return nil
+}
+
+// LockoutKey is the key failed codes of the user count against, on login and when managing the factor.
+func LockoutKey(userID uint) string {
+	return fmt.Sprintf("mfa:%d", userID)
+}
+
+// limitAttempts runs the code check under the user's lockout, so a stolen session can't guess codes.
+// Like the login lockout it fails open when the lockout is unavailable.
+func (s *service) limitAttempts(ctx context.Context, userID uint, check func() error) error {
+	if s.lockout == nil {
+		return check()
+	}
+
+	key := LockoutKey(userID)
+	remaining, err := s.lockout.Locked(ctx, key)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to check mfa lockout", slog.Any("err", err))
+	}
+	if remaining > 0 {
+		logging.FromContext(ctx).Warn("mfa attempt on locked account", slog.Any("user_id", userID))
+		return pkgerrors.NewUnauthorizedError(MsgInvalidMFACode)
+	}
+
+	if err := check(); err != nil {
+		var appErr *pkgerrors.AppError
+		if errors.As(err, &appErr) && appErr.StatusCode == http.StatusUnauthorized {
+			logging.FromContext(ctx).Warn("invalid mfa code", slog.Any("user_id", userID))
+			if _, err := s.lockout.Fail(ctx, key); err != nil {
+				logging.FromContext(ctx).Warn("failed to record mfa failure", slog.Any("err", err))
+			}
+		}
+		return err
+	}
+
+	if err := s.lockout.Reset(ctx, key); err != nil {
+		logging.FromContext(ctx).Warn("failed to reset mfa failures", slog.Any("err", err))
+	}
+
+	return nil
+}
+
+func (s *service) getFactor(ctx context.Context, userID uint, notFoundMsg string) (*TOTPFactor, error) {
+	factor, err := s.repo.GetFactor(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, pkgerrors.NewBadRequestError(notFoundMsg)
+	}
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return factor, nil
+}
+
+// useTOTP validates the code and records its step, so it can't be replayed.
+func (s *service) useTOTP(ctx context.Context, factor *TOTPFactor, code string) error {
+	step, err := s.validateTOTP(factor, code)
+	if err != nil {
+		return err
+	}
+
+	fresh, err := s.repo.UseStep(ctx, factor.UserID, step)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+	if !fresh {
+		logging.FromContext(ctx).Warn("mfa code replayed", slog.Any("user_id", factor.UserID))
+		return pkgerrors.NewUnauthorizedError(MsgInvalidMFACode)
+	}
+
+	return nil
+}
+
+func (s *service) validateTOTP(factor *TOTPFactor, code string) (int64, error) {
+	secret, err := s.cipher.Decrypt(factor.Secret)
+	if err != nil {
+		return 0, pkgerrors.NewInternalError(err)
+	}
+
+	step, ok := totp.Validate(string(secret), code, time.Now())
+	if !ok || step <= factor.LastUsedStep {
+		return 0, pkgerrors.NewUnauthorizedError(MsgInvalidMFACode)
+	}
+
+	return step, nil
+}
+
+func (s *service) replaceRecoveryCodes(ctx context.Context, userID uint) (*RecoveryCodesOutput, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, pkgerrors.NewInternalError(err)
+		}
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return &RecoveryCodesOutput{Codes: codes}, nil
+}
+
+// generateRecoveryCode returns 50 random bits formatted as xxxxx-xxxxx.
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, 7)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// hashRecoveryCode ignores case and separators, so codes can be typed as the user reads them.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// accountOwner returns the caller managing their own factor, which an impersonating admin can't do.
+func accountOwner(ctx context.Context) (*identity.Principal, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+	// The factor protects interactive logins, API keys and clients can't manage it.
+	if principal.Source == identity.AuthAPIKey || principal.Source == identity.AuthClient {
+		return nil, pkgerrors.NewForbiddenError()
+	}
+
+	if principal.Impersonated() {
+		logging.FromContext(ctx).Warn("mfa change refused while impersonating")
+		return nil, pkgerrors.NewForbiddenError()
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,467 @@
+package mfa_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/mfa"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/encryption"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/totp"
+	"gomonitor/internal/testutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const testUserID = uint(7)
+
+func userCtx(ctx context.Context) context.Context {
+	return identity.WithPrincipal(ctx, &identity.Principal{
+		UserID: testUserID,
+		Role:   identity.RoleAdmin,
+		Source: identity.AuthExternal,
+	})
+}
+
+func testCipher(t *testing.T) encryption.Cipher {
+	t.Helper()
+
+	cipher, err := encryption.NewAESCipher(bytes.Repeat([]byte("k"), encryption.KeySize))
+	require.NoError(t, err)
+	return cipher
+}
+
+// storedFactor returns an encrypted factor, with the secret to compute codes.
+func storedFactor(t *testing.T, confirmed bool) (*mfa.TOTPFactor, string) {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	encrypted, err := testCipher(t).Encrypt([]byte(secret))
+	require.NoError(t, err)
+
+	factor := &mfa.TOTPFactor{UserID: testUserID, Secret: encrypted}
+	if confirmed {
+		factor.ConfirmedAt = testutil.Ptr(time.Now())
+	}
+
+	return factor, secret
+}
+
+func currentCode(t *testing.T, secret string) (string, int64) {
+	t.Helper()
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	require.NoError(t, err)
+	return code, step
+}
+
+func newService(t *testing.T, repo *mocks.MockMFARepository, userRepo *mocks.MockUserRepository) mfa.Service {
+	return mfa.NewService(&mfa.ServiceDeps{
+		AuthConfig: &config.AuthConfig{MFAIssuer: "gomonitor"},
+		Cipher:     testCipher(t),
+		Logger:     slog.Default(),
+		Repo:       repo,
+		UserRepo:   userRepo,
+	})
+}
+
+func newLockedService(t *testing.T, repo *mocks.MockMFARepository, lockout *mocks.MockLockout) mfa.Service {
+	return mfa.NewService(&mfa.ServiceDeps{
+		AuthConfig: &config.AuthConfig{MFAIssuer: "gomonitor"},
+		Cipher:     testCipher(t),
+		Lockout:    lockout,
+		Logger:     slog.Default(),
+		Repo:       repo,
+		UserRepo:   &mocks.MockUserRepository{},
+	})
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	var appErr *pkgerrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, status, appErr.StatusCode)
+}
+
+func TestService_Enroll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		_, err := newService(t, &mocks.MockMFARepository{}, &mocks.MockUserRepository{}).Enroll(t.Context())
+		assertStatus(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("impersonated", func(t *testing.T) {
+		ctx := identity.WithPrincipal(t.Context(), &identity.Principal{UserID: testUserID, Source: identity.AuthExternal, ActorID: 1})
+
+		_, err := newService(t, &mocks.MockMFARepository{}, &mocks.MockUserRepository{}).Enroll(ctx)
+		assertStatus(t, err, http.StatusForbidden)
+	})
+
+	for _, source := range []identity.AuthSource{identity.AuthAPIKey, identity.AuthClient} {
+		t.Run("non interactive "+string(source), func(t *testing.T) {
+			ctx := identity.WithPrincipal(t.Context(), &identity.Principal{UserID: testUserID, Source: source})
+
+			_, err := newService(t, &mocks.MockMFARepository{}, &mocks.MockUserRepository{}).Enroll(ctx)
+			assertStatus(t, err, http.StatusForbidden)
+		})
+	}
+
+	t.Run("already enabled", func(t *testing.T) {
+		repo := &mocks.MockMFARepository{}
+		factor, _ := storedFactor(t, true)
+		repo.On("GetFactor", mock.Anything, testUserID).Return(factor, nil)
+
+		_, err := newService(t, repo, &mocks.MockUserRepository{}).Enroll(userCtx(t.Context()))
+		assertStatus(t, err, http.StatusConflict)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success stores the secret encrypted", func(t *testing.T) {
+		repo := &mocks.MockMFARepository{}
+		userRepo := &mocks.MockUserRepository{}
+
+		repo.On("GetFactor", mock.Anything, testUserID).Return(testutil.Err[*mfa.TOTPFactor](gorm.ErrRecordNotFound))
+		userRepo.On("GetByID", mock.Anything, testUserID).Return(&user.User{ID: testUserID, Email: "admin@test.com"}, nil)
+
+		var saved *mfa.TOTPFactor
+		repo.On("SaveFactor", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { saved = args.Get(1).(*mfa.TOTPFactor) }).
+			Return(nil)
+
+		out, err := newService(t, repo, userRepo).Enroll(userCtx(t.Context()))
+		require.NoError(t, err)
+
+		require.NotNil(t, saved)
+		assert.Nil(t, saved.ConfirmedAt)
+		assert.NotEqual(t, out.Secret, saved.Secret)
+
+		plain, err := testCipher(t).Decrypt(saved.Secret)
+		require.NoError(t, err)
+		assert.Equal(t, out.Secret, string(plain))
+
+		uri, err := url.Parse(out.URI)
+		require.NoError(t, err)
+		assert.Equal(t, out.Secret, uri.Query().Get("secret"))
+		assert.Contains(t, uri.Path, "admin@test.com")
+
+		repo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_Confirm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		confirmed  bool
+		missing    bool
+		wrongCode  bool
+		setupMocks func(repo *mocks.MockMFARepository, step int64)
+		status     int
+	}{
+		{
+			name:    "not enrolled",
+			missing: true,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:      "already enabled",
+			confirmed: true,
+			status:    http.StatusConflict,
+		},
+		{
+			name:      "wrong code",
+			wrongCode: true,
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name: "recovery codes store error",
+			setupMocks: func(repo *mocks.MockMFARepository, step int64) {
+				repo.On("ReplaceRecoveryCodes", mock.Anything, testUserID, mock.Anything).Return(errors.New("db down"))
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.MockMFARepository, step int64) {
+				repo.On("ReplaceRecoveryCodes", mock.Anything, testUserID, mock.MatchedBy(func(hashes []string) bool {
+					return len(hashes) == 10 && len(hashes[0]) == 64
+				})).Return(nil)
+				repo.On("ConfirmFactor", mock.Anything, testUserID, step).Return(true, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockMFARepository{}
+			factor, secret := storedFactor(t, tt.confirmed)
+			code, step := currentCode(t, secret)
+
+			if tt.missing {
+				repo.On("GetFactor", mock.Anything, testUserID).Return(testutil.Err[*mfa.TOTPFactor](gorm.ErrRecordNotFound))
+			} else {
+				repo.On("GetFactor", mock.Anything, testUserID).Return(factor, nil)
+			}
+			if tt.wrongCode {
+				code = "000000"
+				if c, _ := totp.Code(secret, step); c == code {
+					code = "111111"
+				}
+			}
+			if tt.setupMocks != nil {
+				tt.setupMocks(repo, step)
+			}
+
+			out, err := newService(t, repo, &mocks.MockUserRepository{}).Confirm(userCtx(t.Context()), mfa.CodeInput{Code: code})
+
+			if tt.status != 0 {
+				assertStatus(t, err, tt.status)
+				assert.Nil(t, out)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, out.Codes, 10)
+				assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, out.Codes[0])
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Verify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		confirmed  bool
+		code       func(secret string) string
+		setupMocks func(repo *mocks.MockMFARepository, step int64)
+		status     int
+	}{
+		{
+			name:      "factor pending",
+			confirmed: false,
+			code:      func(string) string { return "123456" },
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "valid totp",
+			confirmed: true,
+			setupMocks: func(repo *mocks.MockMFARepository, step int64) {
+				repo.On("UseStep", mock.Anything, testUserID, step).Return(true, nil)
+			},
+		},
+		{
+			name:      "replayed totp",
+			confirmed: true,
+			setupMocks: func(repo *mocks.MockMFARepository, step int64) {
+				repo.On("UseStep", mock.Anything, testUserID, step).Return(false, nil)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:      "recovery code ignores case and separators",
+			confirmed: true,
+			code:      func(string) string { return "ABCDE-FGHIJ" },
+			setupMocks: func(repo *mocks.MockMFARepository, step int64) {
+				// sha256("abcdefghij")
+				repo.On("UseRecoveryCode", mock.Anything, testUserID,
+					"72399361da6a7754fec986dca5b7cbaf1c810a28ded4abaf56b2106d06cb78b0").Return(true, nil)
+			},
+		},
+		{
+			name:      "unknown recovery code",
+			confirmed: true,
+			code:      func(string) string { return "abcde-fghij" },
+			setupMocks: func(repo *mocks.MockMFARepository, step int64) {
+				repo.On("UseRecoveryCode", mock.Anything, testUserID, mock.Anything).Return(false, nil)
+			},
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockMFARepository{}
+			factor, secret := storedFactor(t, tt.confirmed)
+			code, step := currentCode(t, secret)
+			if tt.code != nil {
+				code = tt.code(secret)
+			}
+
+			repo.On("GetFactor", mock.Anything, testUserID).Return(factor, nil)
+			if tt.setupMocks != nil {
+				tt.setupMocks(repo, step)
+			}
+
+			err := newService(t, repo, &mocks.MockUserRepository{}).
+				Verify(t.Context(), mfa.VerifyInput{UserID: testUserID, Code: code})
+
+			if tt.status != 0 {
+				assertStatus(t, err, tt.status)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_IsEnabled(t *testing.T) {
+	t.Parallel()
+
+	confirmed, _ := storedFactor(t, true)
+	pending, _ := storedFactor(t, false)
+
+	tests := []struct {
+		name     string
+		factor   *mfa.TOTPFactor
+		err      error
+		expected bool
+	}{
+		{name: "no factor", err: gorm.ErrRecordNotFound},
+		{name: "pending factor", factor: pending},
+		{name: "confirmed factor", factor: confirmed, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockMFARepository{}
+			repo.On("GetFactor", mock.Anything, testUserID).Return(tt.factor, tt.err)
+
+			enabled, err := newService(t, repo, &mocks.MockUserRepository{}).IsEnabled(t.Context(), testUserID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, enabled)
+		})
+	}
+
+	t.Run("db error", func(t *testing.T) {
+		repo := &mocks.MockMFARepository{}
+		repo.On("GetFactor", mock.Anything, testUserID).Return(nil, errors.New("db down"))
+
+		_, err := newService(t, repo, &mocks.MockUserRepository{}).IsEnabled(t.Context(), testUserID)
+		assert.Error(t, err)
+	})
+}
+
+func TestService_Disable(t *testing.T) {
+	t.Parallel()
+
+	lockoutKey := mfa.LockoutKey(testUserID)
+
+	t.Run("success resets the lockout", func(t *testing.T) {
+		repo := &mocks.MockMFARepository{}
+		lockout := &mocks.MockLockout{}
+		factor, secret := storedFactor(t, true)
+		code, step := currentCode(t, secret)
+
+		lockout.On("Locked", mock.Anything, lockoutKey).Return(time.Duration(0), nil)
+		lockout.On("Reset", mock.Anything, lockoutKey).Return(nil)
+		repo.On("GetFactor", mock.Anything, testUserID).Return(factor, nil)
+		repo.On("UseStep", mock.Anything, testUserID, step).Return(true, nil)
+		repo.On("Delete", mock.Anything, testUserID).Return(nil)
+
+		err := newLockedService(t, repo, lockout).Disable(userCtx(t.Context()), mfa.CodeInput{Code: code})
+		require.NoError(t, err)
+
+		repo.AssertExpectations(t)
+		lockout.AssertExpectations(t)
+	})
+
+	t.Run("invalid code counts towards the lockout", func(t *testing.T) {
+		repo := &mocks.MockMFARepository{}
+		lockout := &mocks.MockLockout{}
+		factor, _ := storedFactor(t, true)
+
+		lockout.On("Locked", mock.Anything, lockoutKey).Return(time.Duration(0), nil)
+		lockout.On("Fail", mock.Anything, lockoutKey).Return(time.Duration(0), nil)
+		repo.On("GetFactor", mock.Anything, testUserID).Return(factor, nil)
+		repo.On("UseRecoveryCode", mock.Anything, testUserID, mock.Anything).Return(false, nil)
+
+		err := newLockedService(t, repo, lockout).Disable(userCtx(t.Context()), mfa.CodeInput{Code: "abcde-fghij"})
+		assertStatus(t, err, http.StatusUnauthorized)
+
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		lockout.AssertExpectations(t)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		repo := &mocks.MockMFARepository{}
+		lockout := &mocks.MockLockout{}
+		lockout.On("Locked", mock.Anything, lockoutKey).Return(time.Minute, nil)
+
+		err := newLockedService(t, repo, lockout).Disable(userCtx(t.Context()), mfa.CodeInput{Code: "123456"})
+		assertStatus(t, err, http.StatusUnauthorized)
+
+		repo.AssertNotCalled(t, "GetFactor", mock.Anything, mock.Anything)
+		lockout.AssertExpectations(t)
+	})
+}
+
+func TestService_RegenerateRecoveryCodes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("requires a totp code", func(t *testing.T) {
+		repo := &mocks.MockMFARepository{}
+		factor, _ := storedFactor(t, true)
+		repo.On("GetFactor", mock.Anything, testUserID).Return(factor, nil)
+
+		_, err := newService(t, repo, &mocks.MockUserRepository{}).
+			RegenerateRecoveryCodes(userCtx(t.Context()), mfa.CodeInput{Code: "abcde-fghij"})
+		assertStatus(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("invalid code counts towards the lockout", func(t *testing.T) {
+		repo := &mocks.MockMFARepository{}
+		lockout := &mocks.MockLockout{}
+		factor, _ := storedFactor(t, true)
+
+		lockout.On("Locked", mock.Anything, mfa.LockoutKey(testUserID)).Return(time.Duration(0), nil)
+		lockout.On("Fail", mock.Anything, mfa.LockoutKey(testUserID)).Return(time.Minute, nil)
+		repo.On("GetFactor", mock.Anything, testUserID).Return(factor, nil)
+
+		_, err := newLockedService(t, repo, lockout).
+			RegenerateRecoveryCodes(userCtx(t.Context()), mfa.CodeInput{Code: "abcde-fghij"})
+		assertStatus(t, err, http.StatusUnauthorized)
+
+		repo.AssertNotCalled(t, "ReplaceRecoveryCodes", mock.Anything, mock.Anything, mock.Anything)
+		lockout.AssertExpectations(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		repo := &mocks.MockMFARepository{}
+		factor, secret := storedFactor(t, true)
+		code, step := currentCode(t, secret)
+
+		repo.On("GetFactor", mock.Anything, testUserID).Return(factor, nil)
+		repo.On("UseStep", mock.Anything, testUserID, step).Return(true, nil)
+		repo.On("ReplaceRecoveryCodes", mock.Anything, testUserID, mock.Anything).Return(nil)
+
+		out, err := newService(t, repo, &mocks.MockUserRepository{}).
+			RegenerateRecoveryCodes(userCtx(t.Context()), mfa.CodeInput{Code: code})
+		require.NoError(t, err)
+		assert.Len(t, out.Codes, 10)
+
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,14 @@
+package oauth
+
+var (
+	MsgClientNotFound = "oauth client not found"
+	MsgUnknownScope   = "unknown scope"
+)
+
+// Error codes of the token endpoint, as defined by RFC 6749 section 5.2.
+var (
+	MsgInvalidClient        = "invalid_client"
+	MsgInvalidRequest       = "invalid_request"
+	MsgInvalidScope         = "invalid_scope"
+	MsgUnsupportedGrantType = "unsupported_grant_type"
+)
@@ -0,0 +1,26 @@
+package oauth
+
+// CreateClientInput registers a client allowed to request Scopes.
+type CreateClientInput struct {
+	Name   string
+	Scopes []string
+}
+
+type ClientInput struct {
+	ID uint
+}
+
+// TokenInput is a token request, Scope is space separated and defaults to every scope of the client.
+type TokenInput struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+}
+
+// IntrospectInput is an introspection request, the client authenticates like on the token endpoint.
+type IntrospectInput struct {
+	ClientID     string
+	ClientSecret string
+	Token        string
+}
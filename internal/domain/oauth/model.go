@@ -0,0 +1,27 @@
+package oauth
+
+import (
+	"strings"
+	"time"
+)
+
+// Client is a service authenticating with the client credentials grant, it acts as no user and is
+// limited to Scopes. Only the SHA-256 of the secret is stored.
+type Client struct {
+	ID         uint   `gorm:"primaryKey"`
+	ClientID   string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Name       string `gorm:"type:varchar(100);not null"`
+	SecretHash string `gorm:"type:char(64);not null"`
+	// Scopes are space separated.
+	Scopes    string `gorm:"type:varchar(255);not null"`
+	CreatedAt time.Time
+}
+
+func (Client) TableName() string {
+	return "oauth_clients"
+}
+
+// ScopeList splits the allowed scopes.
+func (c *Client) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
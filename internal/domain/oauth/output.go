@@ -0,0 +1,43 @@
+package oauth
+
+import (
+	"gomonitor/internal/pkg/identity"
+	"time"
+)
+
+// CreateClientOutput holds the plain secret, it can't be retrieved again.
+type CreateClientOutput struct {
+	ClientSecret string
+	Client       *Client
+}
+
+type TokenOutput struct {
+	AccessToken string
+	TokenType   string
+	ExpiresIn   time.Duration
+	Scopes      []string
+}
+
+// SessionStatus is the state of the session behind a user access token.
+type SessionStatus string
+
+const (
+	SessionActive  SessionStatus = "active"
+	SessionRevoked SessionStatus = "revoked"
+	SessionExpired SessionStatus = "expired"
+)
+
+// IntrospectOutput describes an access token. Inactive tokens only carry the status of their session, if any.
+// UserID is set for user tokens and ClientID for client tokens, ActorID only for impersonation.
+type IntrospectOutput struct {
+	Active   bool
+	UserID   uint
+	Role     identity.UserRole
+	ClientID string
+	Scopes   []string
+	ActorID  uint
+	// JKT is the thumbprint of the DPoP key the token is bound to, empty for bearer tokens.
+	JKT           string
+	ExpiresAt     time.Time
+	SessionStatus SessionStatus
+}
@@ -0,0 +1,75 @@
+package oauth
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, client *Client) error
+	List(ctx context.Context) ([]Client, error)
+	Get(ctx context.Context, id uint) (*Client, error)
+	GetByClientID(ctx context.Context, clientID string) (*Client, error)
+	Delete(ctx context.Context, id uint) (bool, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) Create(ctx context.Context, client *Client) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+// List returns every client, newest first.
+func (r *repository) List(ctx context.Context) ([]Client, error) {
+	var clients []Client
+	err := r.db.
+		WithContext(ctx).
+		Order("created_at DESC, id DESC").
+		Find(&clients).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+func (r *repository) Get(ctx context.Context, id uint) (*Client, error) {
+	var client Client
+	if err := r.db.WithContext(ctx).First(&client, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+func (r *repository) GetByClientID(ctx context.Context, clientID string) (*Client, error) {
+	var client Client
+	if err := r.db.WithContext(ctx).First(&client, "client_id = ?", clientID).Error; err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+// Delete reports false if there is no such client.
+func (r *repository) Delete(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&Client{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,99 @@
+package oauth_test
+
+import (
+	"gomonitor/internal/domain/oauth"
+	databaseinfra "gomonitor/internal/infra/database"
+	"gomonitor/internal/testutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newClient(clientID string) *oauth.Client {
+	return &oauth.Client{
+		ClientID:   clientID,
+		Name:       "billing",
+		SecretHash: strings.Repeat("a", 64),
+		Scopes:     "read write",
+	}
+}
+
+func TestRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := oauth.NewRepository(tx)
+
+	client := newClient("client")
+	require.NoError(t, repo.Create(t.Context(), client))
+	assert.NotZero(t, client.ID)
+
+	// The client id is unique.
+	assert.Error(t, repo.Create(t.Context(), newClient("client")))
+}
+
+func TestRepository_List(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := oauth.NewRepository(tx)
+
+	first := newClient("first")
+	second := newClient("second")
+	require.NoError(t, repo.Create(t.Context(), first))
+	require.NoError(t, repo.Create(t.Context(), second))
+
+	clients, err := repo.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, clients, 2)
+	assert.Equal(t, second.ID, clients[0].ID)
+
+	_, err = repo.List(testutil.GetCancelledCtx(t.Context()))
+	assert.Error(t, err)
+}
+
+func TestRepository_Get(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := oauth.NewRepository(tx)
+
+	client := newClient("client")
+	require.NoError(t, repo.Create(t.Context(), client))
+
+	got, err := repo.Get(t.Context(), client.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "client", got.ClientID)
+
+	got, err = repo.GetByClientID(t.Context(), "client")
+	require.NoError(t, err)
+	assert.Equal(t, client.ID, got.ID)
+
+	_, err = repo.GetByClientID(t.Context(), "other")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestRepository_Delete(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := oauth.NewRepository(tx)
+
+	client := newClient("client")
+	require.NoError(t, repo.Create(t.Context(), client))
+
+	deleted, err := repo.Delete(t.Context(), client.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = repo.Delete(t.Context(), client.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
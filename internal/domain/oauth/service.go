@@ -0,0 +1,324 @@
+package oauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/opaquetoken"
+	"gomonitor/internal/pkg/revocation"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GrantClientCredentials is the only grant type of the token endpoint.
+const GrantClientCredentials = "client_credentials"
+
+// TokenTypeBearer is the type of every issued token.
+const TokenTypeBearer = "Bearer"
+
+var knownScopes = []string{identity.ScopeRead, identity.ScopeWrite}
+
+type Service interface {
+	CreateClient(ctx context.Context, input CreateClientInput) (*CreateClientOutput, error)
+	ListClients(ctx context.Context) ([]Client, error)
+	GetClient(ctx context.Context, input ClientInput) (*Client, error)
+	DeleteClient(ctx context.Context, input ClientInput) error
+	Token(ctx context.Context, input TokenInput) (*TokenOutput, error)
+	Introspect(ctx context.Context, input IntrospectInput) (*IntrospectOutput, error)
+}
+
+type ServiceDeps struct {
+	Logger       *slog.Logger
+	Repo         Repository
+	TokenManager jwt.TokenManager
+	// RefreshTokenRepo holds the sessions checked by introspection.
+	RefreshTokenRepo auth.RefreshTokenRepository
+	// Denylist holds the ended impersonations, they have no refresh token.
+	Denylist revocation.Denylist
+}
+
+type service struct {
+	logger           *slog.Logger
+	repo             Repository
+	tokenManager     jwt.TokenManager
+	refreshTokenRepo auth.RefreshTokenRepository
+	denylist         revocation.Denylist
+}
+
+func NewService(deps *ServiceDeps) Service {
+	return &service{
+		logger:           deps.Logger,
+		repo:             deps.Repo,
+		tokenManager:     deps.TokenManager,
+		refreshTokenRepo: deps.RefreshTokenRepo,
+		denylist:         deps.Denylist,
+	}
+}
+
+// CreateClient registers a client, the plain secret is only part of this output.
+func (s *service) CreateClient(ctx context.Context, input CreateClientInput) (*CreateClientOutput, error) {
+	if err := s.requirePermission(ctx, identity.PermOAuthClientsWrite); err != nil {
+		return nil, err
+	}
+
+	if len(input.Scopes) == 0 {
+		return nil, pkgerrors.NewBadRequestError(MsgUnknownScope)
+	}
+	for _, scope := range input.Scopes {
+		if !slices.Contains(knownScopes, scope) {
+			return nil, pkgerrors.NewBadRequestError(MsgUnknownScope)
+		}
+	}
+
+	secret, err := opaquetoken.New()
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	scopes := slices.Clone(input.Scopes)
+	slices.Sort(scopes)
+
+	client := &Client{
+		ClientID:   uuid.NewString(),
+		Name:       input.Name,
+		SecretHash: opaquetoken.Hash(secret),
+		Scopes:     strings.Join(slices.Compact(scopes), " "),
+	}
+	if err := s.repo.Create(ctx, client); err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	logging.FromContext(ctx).Info("oauth client created", slog.String("client_id", client.ClientID))
+
+	return &CreateClientOutput{ClientSecret: secret, Client: client}, nil
+}
+
+func (s *service) ListClients(ctx context.Context) ([]Client, error) {
+	if err := s.requirePermission(ctx, identity.PermOAuthClientsRead); err != nil {
+		return nil, err
+	}
+
+	clients, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return clients, nil
+}
+
+func (s *service) GetClient(ctx context.Context, input ClientInput) (*Client, error) {
+	if err := s.requirePermission(ctx, identity.PermOAuthClientsRead); err != nil {
+		return nil, err
+	}
+
+	client, err := s.repo.Get(ctx, input.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewNotFoundError(MsgClientNotFound)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return client, nil
+}
+
+// DeleteClient stops the client from getting new tokens, issued ones stay valid until they expire.
+func (s *service) DeleteClient(ctx context.Context, input ClientInput) error {
+	if err := s.requirePermission(ctx, identity.PermOAuthClientsWrite); err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.Delete(ctx, input.ID)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+	if !deleted {
+		return pkgerrors.NewNotFoundError(MsgClientNotFound)
+	}
+
+	logging.FromContext(ctx).Info("oauth client deleted", slog.Uint64("oauth_client_id", uint64(input.ID)))
+
+	return nil
+}
+
+// Token implements the client credentials grant. Errors carry the RFC 6749 error code as message.
+func (s *service) Token(ctx context.Context, input TokenInput) (*TokenOutput, error) {
+	if input.GrantType != GrantClientCredentials {
+		return nil, pkgerrors.NewBadRequestError(MsgUnsupportedGrantType)
+	}
+
+	client, err := s.authenticateClient(ctx, input.ClientID, input.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := client.ScopeList()
+	if input.Scope != "" {
+		scopes = strings.Fields(input.Scope)
+		for _, scope := range scopes {
+			if !slices.Contains(client.ScopeList(), scope) {
+				return nil, pkgerrors.NewBadRequestError(MsgInvalidScope)
+			}
+		}
+		slices.Sort(scopes)
+		scopes = slices.Compact(scopes)
+	}
+
+	token, err := s.tokenManager.GenerateClientToken(client.ClientID, scopes)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	logging.FromContext(ctx).Info("oauth client token issued",
+		slog.String("client_id", client.ClientID),
+		slog.String("scope", strings.Join(scopes, " ")),
+	)
+
+	return &TokenOutput{
+		AccessToken: token.Token,
+		TokenType:   TokenTypeBearer,
+		ExpiresIn:   token.Meta.ExpiresAt.Sub(token.Meta.IssuedAt),
+		Scopes:      scopes,
+	}, nil
+}
+
+// Introspect describes an access token to an authenticated client, following RFC 7662.
+// Unlike the token validation, the session behind user tokens is checked, so revoked sessions are reported as inactive.
+func (s *service) Introspect(ctx context.Context, input IntrospectInput) (*IntrospectOutput, error) {
+	client, err := s.authenticateClient(ctx, input.ClientID, input.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, err := s.tokenManager.ValidateAccessToken(input.Token)
+	if err != nil {
+		// Invalid, expired and foreign tokens are all just inactive.
+		return &IntrospectOutput{}, nil
+	}
+
+	out := &IntrospectOutput{
+		Active:    true,
+		UserID:    principal.UserID,
+		Role:      principal.Role,
+		ClientID:  principal.ClientID,
+		Scopes:    principal.Scopes,
+		ActorID:   principal.ActorID,
+		JKT:       principal.JKT,
+		ExpiresAt: principal.ExpiresAt,
+	}
+
+	// Client tokens have no session, they are active until they expire.
+	if principal.SessionID != nil {
+		var status SessionStatus
+		var err error
+		if principal.Impersonated() {
+			status, err = s.impersonationStatus(ctx, *principal.SessionID)
+		} else {
+			status, err = s.sessionStatus(ctx, *principal.SessionID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if status != SessionActive {
+			out = &IntrospectOutput{SessionStatus: status}
+		} else {
+			out.SessionStatus = status
+		}
+	}
+
+	logging.FromContext(ctx).Info("oauth token introspected",
+		slog.String("client_id", client.ClientID),
+		slog.Bool("active", out.Active),
+	)
+
+	return out, nil
+}
+
+// sessionStatus is the status of the session an access token was issued for, identified by its family.
+// The session lives in the one token its rotations haven't revoked, whichever token the access token came with.
+func (s *service) sessionStatus(ctx context.Context, familyID uuid.UUID) (SessionStatus, error) {
+	token, err := s.refreshTokenRepo.GetActiveByFamilyID(ctx, familyID)
+	if err != nil {
+		// Without a live token the session was logged out, or can't be trusted.
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return SessionRevoked, nil
+		}
+		return "", pkgerrors.NewInternalError(err)
+	}
+
+	if !token.ExpiresAt.After(time.Now()) {
+		return SessionExpired, nil
+	}
+
+	return SessionActive, nil
+}
+
+// impersonationStatus is the status of the session of an impersonation token, only ended through the denylist.
+func (s *service) impersonationStatus(ctx context.Context, sessionID uuid.UUID) (SessionStatus, error) {
+	if s.denylist == nil {
+		return SessionActive, nil
+	}
+
+	revoked, err := s.denylist.IsRevoked(ctx, sessionID)
+	if err != nil {
+		return "", pkgerrors.NewInternalError(err)
+	}
+	if revoked {
+		return SessionRevoked, nil
+	}
+
+	return SessionActive, nil
+}
+
+// authenticateClient checks the credentials of a client, every failure is the same invalid_client error.
+func (s *service) authenticateClient(ctx context.Context, clientID, secret string) (*Client, error) {
+	if clientID == "" || secret == "" {
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidClient)
+	}
+
+	client, err := s.repo.GetByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewUnauthorizedError(MsgInvalidClient)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(opaquetoken.Hash(secret)), []byte(client.SecretHash)) != 1 {
+		logging.FromContext(ctx).Warn("invalid oauth client secret", slog.String("client_id", client.ClientID))
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidClient)
+	}
+
+	return client, nil
+}
+
+func (s *service) requirePermission(ctx context.Context, permission identity.Permission) error {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Warn("unauthenticated oauth client request")
+		return pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	if !principal.HasPermission(permission) {
+		logging.FromContext(ctx).Warn("unauthorized oauth client request",
+			"permission", permission,
+			"user_id", principal.UserID,
+			"user_role", principal.Role,
+			"source", principal.Source,
+		)
+		return pkgerrors.NewForbiddenError()
+	}
+
+	return nil
+}
@@ -0,0 +1,617 @@
+package oauth_test
+
+import (
+	"context"
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/domain/oauth"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/opaquetoken"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func adminCtx(ctx context.Context) context.Context {
+	return identity.WithPrincipal(ctx, &identity.Principal{
+		UserID:      1,
+		Role:        identity.RoleAdmin,
+		Permissions: []identity.Permission{identity.PermOAuthClientsRead, identity.PermOAuthClientsWrite},
+		Source:      identity.AuthExternal,
+	})
+}
+
+func userCtx(ctx context.Context) context.Context {
+	return identity.WithPrincipal(ctx, &identity.Principal{
+		UserID: 2,
+		Role:   identity.RoleUser,
+		Source: identity.AuthExternal,
+	})
+}
+
+func newService(repo *mocks.MockOAuthClientRepository, tokenManager *mocks.MockJwtManager) oauth.Service {
+	return oauth.NewService(&oauth.ServiceDeps{
+		Logger:       slog.Default(),
+		Repo:         repo,
+		TokenManager: tokenManager,
+	})
+}
+
+func assertStatus(t *testing.T, err error, status int, message string) {
+	t.Helper()
+
+	var appErr *pkgerrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, status, appErr.StatusCode)
+	if message != "" {
+		assert.Equal(t, message, appErr.Message)
+	}
+}
+
+func TestService_CreateClient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		ctx            func(context.Context) context.Context
+		input          oauth.CreateClientInput
+		setupMocks     func(*mocks.MockOAuthClientRepository)
+		expectedStatus int
+	}{
+		{
+			name:           "unauthenticated",
+			ctx:            func(ctx context.Context) context.Context { return ctx },
+			input:          oauth.CreateClientInput{Name: "billing", Scopes: []string{identity.ScopeRead}},
+			setupMocks:     func(*mocks.MockOAuthClientRepository) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "not an admin",
+			ctx:            userCtx,
+			input:          oauth.CreateClientInput{Name: "billing", Scopes: []string{identity.ScopeRead}},
+			setupMocks:     func(*mocks.MockOAuthClientRepository) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "no scope",
+			ctx:            adminCtx,
+			input:          oauth.CreateClientInput{Name: "billing"},
+			setupMocks:     func(*mocks.MockOAuthClientRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown scope",
+			ctx:            adminCtx,
+			input:          oauth.CreateClientInput{Name: "billing", Scopes: []string{"admin"}},
+			setupMocks:     func(*mocks.MockOAuthClientRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "repository error",
+			ctx:   adminCtx,
+			input: oauth.CreateClientInput{Name: "billing", Scopes: []string{identity.ScopeRead}},
+			setupMocks: func(repo *mocks.MockOAuthClientRepository) {
+				repo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockOAuthClientRepository{}
+			tt.setupMocks(repo)
+
+			_, err := newService(repo, &mocks.MockJwtManager{}).CreateClient(tt.ctx(t.Context()), tt.input)
+
+			assertStatus(t, err, tt.expectedStatus, "")
+			repo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("success stores the hash only", func(t *testing.T) {
+		repo := &mocks.MockOAuthClientRepository{}
+		var stored *oauth.Client
+		repo.On("Create", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*oauth.Client) }).
+			Return(nil)
+
+		out, err := newService(repo, &mocks.MockJwtManager{}).CreateClient(adminCtx(t.Context()), oauth.CreateClientInput{
+			Name:   "billing",
+			Scopes: []string{identity.ScopeWrite, identity.ScopeRead, identity.ScopeWrite},
+		})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, out.ClientSecret)
+		assert.NotEmpty(t, stored.ClientID)
+		assert.Equal(t, opaquetoken.Hash(out.ClientSecret), stored.SecretHash)
+		assert.Equal(t, "read write", stored.Scopes)
+		assert.Equal(t, stored, out.Client)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestService_ListClients(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not an admin", func(t *testing.T) {
+		_, err := newService(&mocks.MockOAuthClientRepository{}, &mocks.MockJwtManager{}).ListClients(userCtx(t.Context()))
+		assertStatus(t, err, http.StatusForbidden, "")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		repo := &mocks.MockOAuthClientRepository{}
+		repo.On("List", mock.Anything).Return([]oauth.Client{{ID: 1}}, nil)
+
+		clients, err := newService(repo, &mocks.MockJwtManager{}).ListClients(adminCtx(t.Context()))
+		require.NoError(t, err)
+		assert.Len(t, clients, 1)
+	})
+}
+
+func TestService_GetClient(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not found", func(t *testing.T) {
+		repo := &mocks.MockOAuthClientRepository{}
+		repo.On("Get", mock.Anything, uint(3)).Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := newService(repo, &mocks.MockJwtManager{}).GetClient(adminCtx(t.Context()), oauth.ClientInput{ID: 3})
+		assertStatus(t, err, http.StatusNotFound, oauth.MsgClientNotFound)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		repo := &mocks.MockOAuthClientRepository{}
+		repo.On("Get", mock.Anything, uint(3)).Return(&oauth.Client{ID: 3}, nil)
+
+		client, err := newService(repo, &mocks.MockJwtManager{}).GetClient(adminCtx(t.Context()), oauth.ClientInput{ID: 3})
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), client.ID)
+	})
+}
+
+func TestService_DeleteClient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		ctx            func(context.Context) context.Context
+		setupMocks     func(*mocks.MockOAuthClientRepository)
+		expectedStatus int
+	}{
+		{
+			name:           "not an admin",
+			ctx:            userCtx,
+			setupMocks:     func(*mocks.MockOAuthClientRepository) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "not found",
+			ctx:  adminCtx,
+			setupMocks: func(repo *mocks.MockOAuthClientRepository) {
+				repo.On("Delete", mock.Anything, uint(3)).Return(false, nil)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			ctx:  adminCtx,
+			setupMocks: func(repo *mocks.MockOAuthClientRepository) {
+				repo.On("Delete", mock.Anything, uint(3)).Return(true, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockOAuthClientRepository{}
+			tt.setupMocks(repo)
+
+			err := newService(repo, &mocks.MockJwtManager{}).DeleteClient(tt.ctx(t.Context()), oauth.ClientInput{ID: 3})
+
+			if tt.expectedStatus == 0 {
+				assert.NoError(t, err)
+			} else {
+				assertStatus(t, err, tt.expectedStatus, "")
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Token(t *testing.T) {
+	t.Parallel()
+
+	client := &oauth.Client{
+		ID:         1,
+		ClientID:   "client",
+		SecretHash: opaquetoken.Hash("secret"),
+		Scopes:     "read write",
+	}
+
+	now := time.Now()
+	issued := &jwt.AccessTokenResult{
+		Token: "access-token",
+		Meta:  jwt.TokenMetadata{IssuedAt: now, ExpiresAt: now.Add(15 * time.Minute)},
+	}
+
+	tests := []struct {
+		name            string
+		input           oauth.TokenInput
+		setupMocks      func(*mocks.MockOAuthClientRepository, *mocks.MockJwtManager)
+		expectedStatus  int
+		expectedMessage string
+		expectedScopes  []string
+	}{
+		{
+			name:            "unsupported grant type",
+			input:           oauth.TokenInput{GrantType: "password", ClientID: "client", ClientSecret: "secret"},
+			setupMocks:      func(*mocks.MockOAuthClientRepository, *mocks.MockJwtManager) {},
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: oauth.MsgUnsupportedGrantType,
+		},
+		{
+			name:            "missing credentials",
+			input:           oauth.TokenInput{GrantType: oauth.GrantClientCredentials, ClientID: "client"},
+			setupMocks:      func(*mocks.MockOAuthClientRepository, *mocks.MockJwtManager) {},
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: oauth.MsgInvalidClient,
+		},
+		{
+			name:  "unknown client",
+			input: oauth.TokenInput{GrantType: oauth.GrantClientCredentials, ClientID: "other", ClientSecret: "secret"},
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, _ *mocks.MockJwtManager) {
+				repo.On("GetByClientID", mock.Anything, "other").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: oauth.MsgInvalidClient,
+		},
+		{
+			name:  "wrong secret",
+			input: oauth.TokenInput{GrantType: oauth.GrantClientCredentials, ClientID: "client", ClientSecret: "wrong"},
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, _ *mocks.MockJwtManager) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+			},
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: oauth.MsgInvalidClient,
+		},
+		{
+			name: "scope not allowed",
+			input: oauth.TokenInput{
+				GrantType: oauth.GrantClientCredentials, ClientID: "client", ClientSecret: "secret", Scope: "read admin",
+			},
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, _ *mocks.MockJwtManager) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+			},
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: oauth.MsgInvalidScope,
+		},
+		{
+			name:  "defaults to every scope of the client",
+			input: oauth.TokenInput{GrantType: oauth.GrantClientCredentials, ClientID: "client", ClientSecret: "secret"},
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, tm *mocks.MockJwtManager) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+				tm.On("GenerateClientToken", "client", []string{"read", "write"}).Return(issued, nil)
+			},
+			expectedScopes: []string{"read", "write"},
+		},
+		{
+			name: "requested scope",
+			input: oauth.TokenInput{
+				GrantType: oauth.GrantClientCredentials, ClientID: "client", ClientSecret: "secret", Scope: "read read",
+			},
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, tm *mocks.MockJwtManager) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+				tm.On("GenerateClientToken", "client", []string{"read"}).Return(issued, nil)
+			},
+			expectedScopes: []string{"read"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockOAuthClientRepository{}
+			tm := &mocks.MockJwtManager{}
+			tt.setupMocks(repo, tm)
+
+			out, err := newService(repo, tm).Token(t.Context(), tt.input)
+
+			if tt.expectedStatus != 0 {
+				assertStatus(t, err, tt.expectedStatus, tt.expectedMessage)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &oauth.TokenOutput{
+					AccessToken: "access-token",
+					TokenType:   oauth.TokenTypeBearer,
+					ExpiresIn:   15 * time.Minute,
+					Scopes:      tt.expectedScopes,
+				}, out)
+			}
+
+			repo.AssertExpectations(t)
+			tm.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Introspect(t *testing.T) {
+	t.Parallel()
+
+	client := &oauth.Client{
+		ID:         1,
+		ClientID:   "client",
+		SecretHash: opaquetoken.Hash("secret"),
+		Scopes:     "read",
+	}
+
+	sessionJTI := uuid.New()
+	expiresAt := time.Now().Add(15 * time.Minute).Truncate(time.Second)
+
+	sessionPrincipal := &identity.Principal{
+		UserID:    2,
+		Role:      identity.RoleUser,
+		Source:    identity.AuthExternal,
+		SessionID: &sessionJTI,
+		ExpiresAt: expiresAt,
+	}
+	input := oauth.IntrospectInput{ClientID: "client", ClientSecret: "secret", Token: "access-token"}
+
+	tests := []struct {
+		name            string
+		input           oauth.IntrospectInput
+		setupMocks      func(*mocks.MockOAuthClientRepository, *mocks.MockJwtManager, *mocks.MockRefreshTokenRepository)
+		expectedStatus  int
+		expectedMessage string
+		expected        *oauth.IntrospectOutput
+	}{
+		{
+			name:  "wrong secret",
+			input: oauth.IntrospectInput{ClientID: "client", ClientSecret: "wrong", Token: "access-token"},
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, _ *mocks.MockJwtManager, _ *mocks.MockRefreshTokenRepository) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+			},
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: oauth.MsgInvalidClient,
+		},
+		{
+			name:  "invalid token",
+			input: input,
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, tm *mocks.MockJwtManager, _ *mocks.MockRefreshTokenRepository) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+				tm.On("ValidateAccessToken", "access-token").Return(nil, jwt.ErrInvalidToken)
+			},
+			expected: &oauth.IntrospectOutput{},
+		},
+		{
+			name:  "active session",
+			input: input,
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, tm *mocks.MockJwtManager, rt *mocks.MockRefreshTokenRepository) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+				tm.On("ValidateAccessToken", "access-token").Return(sessionPrincipal, nil)
+				rt.On("GetActiveByFamilyID", mock.Anything, sessionJTI).
+					Return(&auth.RefreshToken{JTI: sessionJTI, FamilyID: sessionJTI, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+			},
+			expected: &oauth.IntrospectOutput{
+				Active:        true,
+				UserID:        2,
+				Role:          identity.RoleUser,
+				ExpiresAt:     expiresAt,
+				SessionStatus: oauth.SessionActive,
+			},
+		},
+		{
+			name:  "rotated session stays active",
+			input: input,
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, tm *mocks.MockJwtManager, rt *mocks.MockRefreshTokenRepository) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+				tm.On("ValidateAccessToken", "access-token").Return(sessionPrincipal, nil)
+				rt.On("GetActiveByFamilyID", mock.Anything, sessionJTI).
+					Return(&auth.RefreshToken{JTI: uuid.New(), FamilyID: sessionJTI, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+			},
+			expected: &oauth.IntrospectOutput{
+				Active:        true,
+				UserID:        2,
+				Role:          identity.RoleUser,
+				ExpiresAt:     expiresAt,
+				SessionStatus: oauth.SessionActive,
+			},
+		},
+		{
+			name:  "revoked session",
+			input: input,
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, tm *mocks.MockJwtManager, rt *mocks.MockRefreshTokenRepository) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+				tm.On("ValidateAccessToken", "access-token").Return(sessionPrincipal, nil)
+				rt.On("GetActiveByFamilyID", mock.Anything, sessionJTI).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expected: &oauth.IntrospectOutput{SessionStatus: oauth.SessionRevoked},
+		},
+		{
+			name:  "expired session",
+			input: input,
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, tm *mocks.MockJwtManager, rt *mocks.MockRefreshTokenRepository) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+				tm.On("ValidateAccessToken", "access-token").Return(sessionPrincipal, nil)
+				rt.On("GetActiveByFamilyID", mock.Anything, sessionJTI).
+					Return(&auth.RefreshToken{JTI: sessionJTI, FamilyID: sessionJTI, ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+			},
+			expected: &oauth.IntrospectOutput{SessionStatus: oauth.SessionExpired},
+		},
+		{
+			name:  "session lookup failure",
+			input: input,
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, tm *mocks.MockJwtManager, rt *mocks.MockRefreshTokenRepository) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+				tm.On("ValidateAccessToken", "access-token").Return(sessionPrincipal, nil)
+				rt.On("GetActiveByFamilyID", mock.Anything, sessionJTI).Return(nil, errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "client token has no session",
+			input: input,
+			setupMocks: func(repo *mocks.MockOAuthClientRepository, tm *mocks.MockJwtManager, _ *mocks.MockRefreshTokenRepository) {
+				repo.On("GetByClientID", mock.Anything, "client").Return(client, nil)
+				tm.On("ValidateAccessToken", "access-token").Return(&identity.Principal{
+					ClientID:  "other",
+					Source:    identity.AuthClient,
+					Scopes:    []string{identity.ScopeRead},
+					ExpiresAt: expiresAt,
+				}, nil)
+			},
+			expected: &oauth.IntrospectOutput{
+				Active:    true,
+				ClientID:  "other",
+				Scopes:    []string{identity.ScopeRead},
+				ExpiresAt: expiresAt,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockOAuthClientRepository{}
+			tm := &mocks.MockJwtManager{}
+			rt := &mocks.MockRefreshTokenRepository{}
+			tt.setupMocks(repo, tm, rt)
+
+			svc := oauth.NewService(&oauth.ServiceDeps{
+				Logger:           slog.Default(),
+				Repo:             repo,
+				TokenManager:     tm,
+				RefreshTokenRepo: rt,
+			})
+
+			out, err := svc.Introspect(t.Context(), tt.input)
+
+			if tt.expectedStatus != 0 {
+				assertStatus(t, err, tt.expectedStatus, tt.expectedMessage)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, out)
+			}
+
+			repo.AssertExpectations(t)
+			tm.AssertExpectations(t)
+			rt.AssertExpectations(t)
+		})
+	}
+}
+
+// An access token issued before a rotation reports the session of its family, revoked once logged out.
+func TestService_IntrospectAfterRotationAndLogout(t *testing.T) {
+	t.Parallel()
+
+	tokenManager := jwt.NewTokenManager(&config.AuthConfig{
+		AccessTokenSecret:  "access-secret",
+		AccessTokenTTL:     time.Hour,
+		RefreshTokenSecret: "refresh-secret",
+		RefreshTokenTTL:    24 * time.Hour,
+	})
+	repo := &mocks.MockOAuthClientRepository{}
+	rt := &mocks.MockRefreshTokenRepository{}
+
+	repo.On("GetByClientID", mock.Anything, "client").
+		Return(&oauth.Client{ID: 1, ClientID: "client", SecretHash: opaquetoken.Hash("secret"), Scopes: "read"}, nil)
+
+	// The family is named after the first refresh token of the session.
+	familyID := uuid.New()
+	earlier, err := tokenManager.GenerateAccessToken(2, identity.RoleUser, familyID, "", jwt.Authentication{
+		Time:    time.Now(),
+		Methods: []string{"pwd"},
+	})
+	require.NoError(t, err)
+
+	svc := oauth.NewService(&oauth.ServiceDeps{
+		Logger:           slog.Default(),
+		Repo:             repo,
+		TokenManager:     tokenManager,
+		RefreshTokenRepo: rt,
+	})
+	input := oauth.IntrospectInput{ClientID: "client", ClientSecret: "secret", Token: earlier.Token}
+
+	// Rotated, the family lives on in the successor of the first token.
+	rt.On("GetActiveByFamilyID", mock.Anything, familyID).
+		Return(&auth.RefreshToken{JTI: uuid.New(), FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour)}, nil).
+		Once()
+
+	out, err := svc.Introspect(t.Context(), input)
+	require.NoError(t, err)
+	assert.True(t, out.Active)
+	assert.Equal(t, oauth.SessionActive, out.SessionStatus)
+
+	// Logged out, no token of the family is left.
+	rt.On("GetActiveByFamilyID", mock.Anything, familyID).Return(nil, gorm.ErrRecordNotFound).Once()
+
+	out, err = svc.Introspect(t.Context(), input)
+	require.NoError(t, err)
+	assert.Equal(t, &oauth.IntrospectOutput{SessionStatus: oauth.SessionRevoked}, out)
+
+	rt.AssertExpectations(t)
+}
+
+// An impersonation token has no refresh token, it reports the denylist and is revoked once its session is.
+func TestService_IntrospectImpersonation(t *testing.T) {
+	t.Parallel()
+
+	tokenManager := jwt.NewTokenManager(&config.AuthConfig{
+		AccessTokenSecret:  "access-secret",
+		AccessTokenTTL:     time.Hour,
+		RefreshTokenSecret: "refresh-secret",
+		RefreshTokenTTL:    24 * time.Hour,
+		ImpersonationTTL:   15 * time.Minute,
+	})
+	repo := &mocks.MockOAuthClientRepository{}
+	denylist := &mocks.MockDenylist{}
+
+	repo.On("GetByClientID", mock.Anything, "client").
+		Return(&oauth.Client{ID: 1, ClientID: "client", SecretHash: opaquetoken.Hash("secret"), Scopes: "read"}, nil)
+
+	token, err := tokenManager.GenerateImpersonationToken(2, identity.RoleUser, 1)
+	require.NoError(t, err)
+
+	svc := oauth.NewService(&oauth.ServiceDeps{
+		Denylist:     denylist,
+		Logger:       slog.Default(),
+		Repo:         repo,
+		TokenManager: tokenManager,
+	})
+	input := oauth.IntrospectInput{ClientID: "client", ClientSecret: "secret", Token: token.Token}
+
+	denylist.On("IsRevoked", mock.Anything, token.Meta.JTI).Return(false, nil).Once()
+
+	out, err := svc.Introspect(t.Context(), input)
+	require.NoError(t, err)
+	assert.Equal(t, &oauth.IntrospectOutput{
+		Active:        true,
+		UserID:        2,
+		Role:          identity.RoleUser,
+		ActorID:       1,
+		ExpiresAt:     token.Meta.ExpiresAt.Truncate(time.Second),
+		SessionStatus: oauth.SessionActive,
+	}, out)
+
+	denylist.On("IsRevoked", mock.Anything, token.Meta.JTI).Return(true, nil).Once()
+
+	out, err = svc.Introspect(t.Context(), input)
+	require.NoError(t, err)
+	assert.Equal(t, &oauth.IntrospectOutput{SessionStatus: oauth.SessionRevoked}, out)
+
+	denylist.On("IsRevoked", mock.Anything, token.Meta.JTI).Return(false, errors.New("db down")).Once()
+
+	_, err = svc.Introspect(t.Context(), input)
+	assertStatus(t, err, http.StatusInternalServerError, "")
+
+	denylist.AssertExpectations(t)
+}
@@ -0,0 +1,10 @@
+package passkey
+
+var (
+	MsgPasskeysDisabled         = "passkeys are not enabled"
+	MsgPasskeyNotFound          = "passkey not found"
+	MsgPasskeyAlreadyRegistered = "passkey already registered"
+	MsgInvalidPasskeyCeremony   = "invalid or expired passkey ceremony"
+	MsgInvalidPasskeyResponse   = "invalid passkey response"
+	MsgInvalidPasskey           = "invalid passkey"
+)
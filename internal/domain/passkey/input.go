@@ -0,0 +1,29 @@
+package passkey
+
+import "gomonitor/internal/pkg/webauthn"
+
+// RegisterInput answers the creation options of BeginRegistration.
+type RegisterInput struct {
+	Name     string
+	Response *webauthn.RegistrationResponse
+}
+
+type PasskeyInput struct {
+	ID uint
+}
+
+// BeginLoginInput requests a passkey of UserID as a second factor, bound to the MFA challenge.
+// Without a user, any discoverable passkey can log in, with user verification as it is the only factor.
+// Reauthenticate requests a verified passkey of UserID instead, bound to the session it re-authenticates.
+type BeginLoginInput struct {
+	UserID         uint
+	Binding        string
+	Reauthenticate bool
+}
+
+// FinishLoginInput answers BeginLogin, Reauthenticate must match the ceremony so a re-authentication
+// can't complete a login and the other way around.
+type FinishLoginInput struct {
+	Response       *webauthn.AssertionResponse
+	Reauthenticate bool
+}
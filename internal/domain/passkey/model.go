@@ -0,0 +1,24 @@
+package passkey
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Passkey is a WebAuthn credential of a user. PublicKey is the COSE_Key returned on registration,
+// SignCount the last counter seen, to detect cloned authenticators.
+type Passkey struct {
+	ID           uint      `gorm:"primaryKey"`
+	UserID       uint      `gorm:"not null;index"`
+	CredentialID []byte    `gorm:"not null;uniqueIndex"`
+	PublicKey    []byte    `gorm:"not null"`
+	SignCount    uint32    `gorm:"type:bigint;not null"`
+	AAGUID       uuid.UUID `gorm:"column:aaguid;type:uuid;not null"`
+	Name         string    `gorm:"type:varchar(100);not null"`
+	// BackupEligible passkeys are synced between the devices of the user.
+	BackupEligible bool `gorm:"not null"`
+	BackedUp       bool `gorm:"not null"`
+	LastUsedAt     *time.Time
+	CreatedAt      time.Time
+}
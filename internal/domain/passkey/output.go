@@ -0,0 +1,10 @@
+package passkey
+
+// LoginOutput identifies the user who answered the request options of BeginLogin.
+type LoginOutput struct {
+	UserID uint
+	// SecondFactor is set when the passkey completes a challenged login, Binding is then its challenge.
+	SecondFactor bool
+	Binding      string
+	UserVerified bool
+}
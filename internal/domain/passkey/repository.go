@@ -0,0 +1,100 @@
+package passkey
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, passkey *Passkey) error
+	ListByUserID(ctx context.Context, userID uint) ([]Passkey, error)
+	GetByCredentialID(ctx context.Context, credentialID []byte) (*Passkey, error)
+	CountByUserID(ctx context.Context, userID uint) (int64, error)
+	UpdateUsage(ctx context.Context, id uint, signCount uint32, backedUp bool, at time.Time) (bool, error)
+	Delete(ctx context.Context, userID, id uint) (bool, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) Create(ctx context.Context, passkey *Passkey) error {
+	return r.db.WithContext(ctx).Create(passkey).Error
+}
+
+// ListByUserID returns the passkeys of the user, newest first.
+func (r *repository) ListByUserID(ctx context.Context, userID uint) ([]Passkey, error) {
+	var passkeys []Passkey
+	err := r.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Find(&passkeys).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return passkeys, nil
+}
+
+func (r *repository) GetByCredentialID(ctx context.Context, credentialID []byte) (*Passkey, error) {
+	var passkey Passkey
+	if err := r.db.WithContext(ctx).First(&passkey, "credential_id = ?", credentialID).Error; err != nil {
+		return nil, err
+	}
+
+	return &passkey, nil
+}
+
+func (r *repository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.
+		WithContext(ctx).
+		Model(&Passkey{}).
+		Where("user_id = ?", userID).
+		Count(&count).
+		Error
+
+	return count, err
+}
+
+// UpdateUsage records an assertion, reporting false if a concurrent one already moved the counter past
+// signCount. Authenticators without a counter always report zero.
+func (r *repository) UpdateUsage(ctx context.Context, id uint, signCount uint32, backedUp bool, at time.Time) (bool, error) {
+	result := r.db.
+		WithContext(ctx).
+		Model(&Passkey{}).
+		Where("id = ? AND (sign_count < ? OR (sign_count = 0 AND ? = 0))", id, signCount, signCount).
+		Updates(map[string]any{
+			"sign_count":   signCount,
+			"backed_up":    backedUp,
+			"last_used_at": at,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Delete reports false if the user has no such passkey.
+func (r *repository) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&Passkey{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,125 @@
+package passkey_test
+
+import (
+	"gomonitor/internal/domain/passkey"
+	databaseinfra "gomonitor/internal/infra/database"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPasskey(userID uint, credentialID byte) *passkey.Passkey {
+	return &passkey.Passkey{
+		UserID:       userID,
+		CredentialID: []byte{credentialID, 0xff},
+		PublicKey:    []byte{0xa5},
+		SignCount:    1,
+		AAGUID:       uuid.New(),
+		Name:         "Laptop",
+	}
+}
+
+func TestRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := passkey.NewRepository(tx)
+
+	p := newPasskey(1, 1)
+	require.NoError(t, repo.Create(t.Context(), p))
+	assert.NotZero(t, p.ID)
+
+	// The credential ID is unique.
+	assert.Error(t, repo.Create(t.Context(), newPasskey(2, 1)))
+}
+
+func TestRepository_Lookup(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := passkey.NewRepository(tx)
+
+	first, second, other := newPasskey(3, 2), newPasskey(3, 3), newPasskey(4, 4)
+	for _, p := range []*passkey.Passkey{first, second, other} {
+		require.NoError(t, repo.Create(t.Context(), p))
+	}
+
+	passkeys, err := repo.ListByUserID(t.Context(), 3)
+	require.NoError(t, err)
+	require.Len(t, passkeys, 2)
+	assert.Equal(t, second.ID, passkeys[0].ID)
+
+	count, err := repo.CountByUserID(t.Context(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	got, err := repo.GetByCredentialID(t.Context(), first.CredentialID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, got.ID)
+	assert.Equal(t, first.AAGUID, got.AAGUID)
+
+	_, err = repo.GetByCredentialID(t.Context(), []byte{0})
+	assert.Error(t, err)
+}
+
+func TestRepository_UpdateUsage(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := passkey.NewRepository(tx)
+
+	p := newPasskey(5, 5)
+	require.NoError(t, repo.Create(t.Context(), p))
+
+	now := time.Now()
+	updated, err := repo.UpdateUsage(t.Context(), p.ID, 2, true, now)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	// A concurrent assertion verified against the same counter loses.
+	updated, err = repo.UpdateUsage(t.Context(), p.ID, 2, true, now)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	got, err := repo.GetByCredentialID(t.Context(), p.CredentialID)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), got.SignCount)
+	assert.True(t, got.BackedUp)
+	assert.NotNil(t, got.LastUsedAt)
+
+	// Authenticators without a counter always report zero.
+	static := newPasskey(5, 6)
+	static.SignCount = 0
+	require.NoError(t, repo.Create(t.Context(), static))
+
+	for range 2 {
+		updated, err = repo.UpdateUsage(t.Context(), static.ID, 0, false, now)
+		require.NoError(t, err)
+		assert.True(t, updated)
+	}
+}
+
+func TestRepository_Delete(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := passkey.NewRepository(tx)
+
+	p := newPasskey(6, 7)
+	require.NoError(t, repo.Create(t.Context(), p))
+
+	deleted, err := repo.Delete(t.Context(), 7, p.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = repo.Delete(t.Context(), 6, p.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+}
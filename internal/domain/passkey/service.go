@@ -0,0 +1,383 @@
+package passkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/infra/database/postgres"
+	"gomonitor/internal/observability/logging"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/webauthn"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// defaultName is given to passkeys registered without one.
+const defaultName = "Passkey"
+
+type Service interface {
+	BeginRegistration(ctx context.Context) (*webauthn.CreationOptions, error)
+	FinishRegistration(ctx context.Context, input RegisterInput) (*Passkey, error)
+	List(ctx context.Context) ([]Passkey, error)
+	Delete(ctx context.Context, input PasskeyInput) error
+	BeginLogin(ctx context.Context, input BeginLoginInput) (*webauthn.RequestOptions, error)
+	FinishLogin(ctx context.Context, input FinishLoginInput) (*LoginOutput, error)
+	HasPasskeys(ctx context.Context, userID uint) (bool, error)
+}
+
+type ServiceDeps struct {
+	// Challenges keeps the pending ceremonies, it can be shared by several instances.
+	Challenges webauthn.ChallengeStore
+	Config     *config.WebAuthnConfig
+	Logger     *slog.Logger
+	Repo       Repository
+	UserRepo   user.UserRepository
+}
+
+type service struct {
+	challenges webauthn.ChallengeStore
+	cfg        *config.WebAuthnConfig
+	logger     *slog.Logger
+	repo       Repository
+	rp         *webauthn.RelyingParty
+	userRepo   user.UserRepository
+}
+
+// NewService disables passkeys when no RP ID is configured, their endpoints then answer not found.
+func NewService(deps *ServiceDeps) Service {
+	s := &service{
+		challenges: deps.Challenges,
+		cfg:        deps.Config,
+		logger:     deps.Logger,
+		repo:       deps.Repo,
+		userRepo:   deps.UserRepo,
+	}
+
+	if deps.Config != nil && deps.Config.Enabled() {
+		s.rp = webauthn.NewRelyingParty(deps.Config)
+	}
+
+	return s
+}
+
+// BeginRegistration returns the creation options of a new passkey for the caller.
+func (s *service) BeginRegistration(ctx context.Context) (*webauthn.CreationOptions, error) {
+	if s.rp == nil {
+		return nil, pkgerrors.NewNotFoundError(MsgPasskeysDisabled)
+	}
+
+	principal, err := accountOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.userRepo.GetByID(ctx, principal.UserID)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	passkeys, err := s.repo.ListByUserID(ctx, u.ID)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	exclude := make([][]byte, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		exclude = append(exclude, passkey.CredentialID)
+	}
+
+	challenge, err := s.newCeremony(ctx, &webauthn.Session{Purpose: webauthn.PurposeRegister, UserID: u.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.rp.CreationOptions(challenge, webauthn.User{
+		ID:          userHandle(u.ID),
+		Name:        u.Email,
+		DisplayName: u.Name,
+	}, exclude), nil
+}
+
+// FinishRegistration stores the passkey created for the caller. The authenticator must verify the
+// user, so the passkey is enough to log in.
+func (s *service) FinishRegistration(ctx context.Context, input RegisterInput) (*Passkey, error) {
+	if s.rp == nil {
+		return nil, pkgerrors.NewNotFoundError(MsgPasskeysDisabled)
+	}
+
+	principal, err := accountOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, session, err := s.consumeCeremony(ctx, input.Response.ClientDataJSON, pkgerrors.NewBadRequestError)
+	if err != nil {
+		return nil, err
+	}
+	if session.Purpose != webauthn.PurposeRegister || session.UserID != principal.UserID {
+		return nil, pkgerrors.NewBadRequestError(MsgInvalidPasskeyCeremony)
+	}
+
+	cred, err := s.rp.VerifyRegistration(challenge, input.Response, true)
+	if err != nil {
+		logging.FromContext(ctx).Warn("invalid passkey registration", slog.Any("error", err))
+		return nil, pkgerrors.NewBadRequestError(MsgInvalidPasskeyResponse, err)
+	}
+
+	passkey := &Passkey{
+		UserID:         principal.UserID,
+		CredentialID:   cred.ID,
+		PublicKey:      cred.PublicKey,
+		SignCount:      cred.SignCount,
+		AAGUID:         cred.AAGUID,
+		Name:           input.Name,
+		BackupEligible: cred.BackupEligible,
+		BackedUp:       cred.BackedUp,
+	}
+	if passkey.Name == "" {
+		passkey.Name = defaultName
+	}
+
+	if err := s.repo.Create(ctx, passkey); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == postgres.UniqueViolation {
+			return nil, pkgerrors.NewConflictError(MsgPasskeyAlreadyRegistered)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	logging.FromContext(ctx).Info("passkey registered", slog.Uint64("passkey_id", uint64(passkey.ID)))
+
+	return passkey, nil
+}
+
+// List returns the passkeys of the caller.
+func (s *service) List(ctx context.Context) ([]Passkey, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	passkeys, err := s.repo.ListByUserID(ctx, principal.UserID)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return passkeys, nil
+}
+
+// Delete removes a passkey of the caller, it can't log in anymore.
+func (s *service) Delete(ctx context.Context, input PasskeyInput) error {
+	principal, err := accountOwner(ctx)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.Delete(ctx, principal.UserID, input.ID)
+	if err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+	if !deleted {
+		return pkgerrors.NewNotFoundError(MsgPasskeyNotFound)
+	}
+
+	logging.FromContext(ctx).Info("passkey deleted", slog.Uint64("passkey_id", uint64(input.ID)))
+
+	return nil
+}
+
+// BeginLogin returns the request options of a login, for any discoverable passkey or for the
+// passkeys of the user as a second factor or to re-authenticate.
+func (s *service) BeginLogin(ctx context.Context, input BeginLoginInput) (*webauthn.RequestOptions, error) {
+	if s.rp == nil {
+		return nil, pkgerrors.NewNotFoundError(MsgPasskeysDisabled)
+	}
+
+	session := &webauthn.Session{Purpose: webauthn.PurposeLogin}
+	var allow [][]byte
+
+	if input.Reauthenticate && input.UserID == 0 {
+		return nil, pkgerrors.NewBadRequestError(MsgInvalidPasskeyCeremony)
+	}
+
+	if input.UserID != 0 {
+		passkeys, err := s.repo.ListByUserID(ctx, input.UserID)
+		if err != nil {
+			return nil, pkgerrors.NewInternalError(err)
+		}
+		if len(passkeys) == 0 {
+			return nil, pkgerrors.NewNotFoundError(MsgPasskeyNotFound)
+		}
+
+		for _, passkey := range passkeys {
+			allow = append(allow, passkey.CredentialID)
+		}
+		session = &webauthn.Session{Purpose: webauthn.PurposeMFA, UserID: input.UserID, Binding: input.Binding}
+		if input.Reauthenticate {
+			session.Purpose = webauthn.PurposeReauth
+		}
+	}
+
+	challenge, err := s.newCeremony(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.rp.RequestOptions(challenge, allow, requiresUserVerification(session.Purpose)), nil
+}
+
+// FinishLogin verifies the assertion and returns its user. The ceremony is consumed first, so a
+// failed assertion must start over.
+func (s *service) FinishLogin(ctx context.Context, input FinishLoginInput) (*LoginOutput, error) {
+	if s.rp == nil {
+		return nil, pkgerrors.NewNotFoundError(MsgPasskeysDisabled)
+	}
+
+	resp := input.Response
+	challenge, session, err := s.consumeCeremony(ctx, resp.ClientDataJSON, pkgerrors.NewUnauthorizedError)
+	if err != nil {
+		return nil, err
+	}
+
+	// A re-authentication never completes a login, nor a login a re-authentication.
+	reauth := session.Purpose == webauthn.PurposeReauth
+	secondFactor := session.Purpose == webauthn.PurposeMFA
+	login := session.Purpose == webauthn.PurposeLogin || secondFactor
+	if (input.Reauthenticate && !reauth) || (!input.Reauthenticate && !login) {
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidPasskeyCeremony)
+	}
+
+	passkey, err := s.repo.GetByCredentialID(ctx, resp.CredentialID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewUnauthorizedError(MsgInvalidPasskey)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	if session.UserID != 0 && passkey.UserID != session.UserID {
+		logging.FromContext(ctx).Warn("passkey of another user", slog.Uint64("passkey_id", uint64(passkey.ID)))
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidPasskey)
+	}
+
+	// Discoverable passkeys always name their user, it must be the owner of the credential.
+	if (session.UserID == 0 || len(resp.UserHandle) > 0) && !bytes.Equal(resp.UserHandle, userHandle(passkey.UserID)) {
+		logging.FromContext(ctx).Warn("passkey user handle mismatch", slog.Uint64("passkey_id", uint64(passkey.ID)))
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidPasskey)
+	}
+
+	assertion, err := s.rp.VerifyAssertion(challenge, resp, passkey.PublicKey, passkey.SignCount, requiresUserVerification(session.Purpose))
+	if err != nil {
+		if errors.Is(err, webauthn.ErrSignCountRegressed) {
+			logging.FromContext(ctx).Error("passkey sign count regressed, it may be cloned",
+				slog.Uint64("passkey_id", uint64(passkey.ID)),
+				slog.Uint64("user_id", uint64(passkey.UserID)),
+			)
+		} else {
+			logging.FromContext(ctx).Warn("invalid passkey assertion", slog.Any("error", err))
+		}
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidPasskey, err)
+	}
+
+	// Two assertions verified against the same counter, only one of them wins.
+	updated, err := s.repo.UpdateUsage(ctx, passkey.ID, assertion.SignCount, assertion.BackedUp, time.Now())
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+	if !updated {
+		logging.FromContext(ctx).Warn("concurrent passkey assertion", slog.Uint64("passkey_id", uint64(passkey.ID)))
+		return nil, pkgerrors.NewUnauthorizedError(MsgInvalidPasskey)
+	}
+
+	return &LoginOutput{
+		UserID:       passkey.UserID,
+		SecondFactor: secondFactor,
+		Binding:      session.Binding,
+		UserVerified: assertion.UserVerified,
+	}, nil
+}
+
+// requiresUserVerification tells if the passkey is the only factor of the ceremony, a second factor
+// follows a password.
+func requiresUserVerification(purpose webauthn.Purpose) bool {
+	return purpose != webauthn.PurposeMFA
+}
+
+// HasPasskeys reports if the user can complete a login with a passkey.
+func (s *service) HasPasskeys(ctx context.Context, userID uint) (bool, error) {
+	if s.rp == nil {
+		return false, nil
+	}
+
+	count, err := s.repo.CountByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (s *service) newCeremony(ctx context.Context, session *webauthn.Session) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", pkgerrors.NewInternalError(err)
+	}
+
+	if err := s.challenges.Save(ctx, challenge, session, s.cfg.ChallengeTTL); err != nil {
+		return "", pkgerrors.NewInternalError(err)
+	}
+
+	return challenge, nil
+}
+
+// consumeCeremony looks up the ceremony answered by the client data, failures are reported with newErr.
+func (s *service) consumeCeremony(
+	ctx context.Context,
+	clientDataJSON []byte,
+	newErr func(string, ...error) *pkgerrors.AppError,
+) (string, *webauthn.Session, error) {
+	challenge, err := webauthn.ParseChallenge(clientDataJSON)
+	if err != nil {
+		return "", nil, newErr(MsgInvalidPasskeyResponse, err)
+	}
+
+	session, err := s.challenges.Consume(ctx, challenge)
+	if err != nil {
+		if errors.Is(err, webauthn.ErrChallengeNotFound) {
+			return "", nil, newErr(MsgInvalidPasskeyCeremony)
+		}
+		return "", nil, pkgerrors.NewInternalError(err)
+	}
+
+	return challenge, session, nil
+}
+
+// userHandle identifies the user to the authenticator, without personal information.
+func userHandle(userID uint) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
+
+func accountOwner(ctx context.Context) (*identity.Principal, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+	// Passkeys log in as the user, API keys and clients can't manage them.
+	if principal.Source == identity.AuthAPIKey || principal.Source == identity.AuthClient {
+		return nil, pkgerrors.NewForbiddenError()
+	}
+
+	if principal.Impersonated() {
+		logging.FromContext(ctx).Warn("passkey change refused while impersonating")
+		return nil, pkgerrors.NewForbiddenError()
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,582 @@
+package passkey_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/infra/database/postgres"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/webauthn"
+	"gomonitor/internal/pkg/webauthn/webauthntest"
+	"gomonitor/internal/testutil"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const (
+	testUserID = uint(7)
+	origin     = "https://example.com"
+)
+
+var webAuthnCfg = &config.WebAuthnConfig{
+	RPID:         "example.com",
+	RPName:       "gomonitor",
+	Origins:      []string{origin},
+	ChallengeTTL: 5 * time.Minute,
+}
+
+func userCtx(ctx context.Context) context.Context {
+	return identity.WithPrincipal(ctx, &identity.Principal{
+		UserID: testUserID,
+		Role:   identity.RoleUser,
+		Source: identity.AuthExternal,
+	})
+}
+
+func assertStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	var appErr *pkgerrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, status, appErr.StatusCode)
+}
+
+type testDeps struct {
+	challenges *mocks.MockChallengeStore
+	repo       *mocks.MockPasskeyRepository
+	userRepo   *mocks.MockUserRepository
+}
+
+func newService(cfg *config.WebAuthnConfig) (passkey.Service, *testDeps) {
+	deps := &testDeps{
+		challenges: &mocks.MockChallengeStore{},
+		repo:       &mocks.MockPasskeyRepository{},
+		userRepo:   &mocks.MockUserRepository{},
+	}
+
+	return passkey.NewService(&passkey.ServiceDeps{
+		Challenges: deps.challenges,
+		Config:     cfg,
+		Logger:     slog.Default(),
+		Repo:       deps.repo,
+		UserRepo:   deps.userRepo,
+	}), deps
+}
+
+func userHandle(userID uint) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
+
+// registered creates a passkey of the user on the authenticator, as the repository would return it.
+func registered(t *testing.T, authenticator *webauthntest.Authenticator, userID uint) *passkey.Passkey {
+	t.Helper()
+
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+
+	rp := webauthn.NewRelyingParty(webAuthnCfg)
+	_, resp, err := authenticator.Create(rp.CreationOptions(challenge, webauthn.User{ID: userHandle(userID)}, nil))
+	require.NoError(t, err)
+
+	cred, err := rp.VerifyRegistration(challenge, resp, true)
+	require.NoError(t, err)
+
+	return &passkey.Passkey{
+		ID:           11,
+		UserID:       userID,
+		CredentialID: cred.ID,
+		PublicKey:    cred.PublicKey,
+		SignCount:    cred.SignCount,
+		AAGUID:       cred.AAGUID,
+		Name:         "Laptop",
+	}
+}
+
+func TestService_Disabled(t *testing.T) {
+	t.Parallel()
+	svc, _ := newService(&config.WebAuthnConfig{})
+	ctx := userCtx(t.Context())
+
+	_, err := svc.BeginRegistration(ctx)
+	assertStatus(t, err, http.StatusNotFound)
+
+	_, err = svc.FinishRegistration(ctx, passkey.RegisterInput{Response: &webauthn.RegistrationResponse{}})
+	assertStatus(t, err, http.StatusNotFound)
+
+	_, err = svc.BeginLogin(ctx, passkey.BeginLoginInput{})
+	assertStatus(t, err, http.StatusNotFound)
+
+	_, err = svc.FinishLogin(ctx, passkey.FinishLoginInput{Response: &webauthn.AssertionResponse{}})
+	assertStatus(t, err, http.StatusNotFound)
+
+	has, err := svc.HasPasskeys(ctx, testUserID)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestService_BeginRegistration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		svc, _ := newService(webAuthnCfg)
+
+		_, err := svc.BeginRegistration(t.Context())
+		assertStatus(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("impersonated", func(t *testing.T) {
+		svc, _ := newService(webAuthnCfg)
+		ctx := identity.WithPrincipal(t.Context(), &identity.Principal{UserID: testUserID, Source: identity.AuthExternal, ActorID: 1})
+
+		_, err := svc.BeginRegistration(ctx)
+		assertStatus(t, err, http.StatusForbidden)
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		svc, _ := newService(webAuthnCfg)
+		ctx := identity.WithPrincipal(t.Context(), &identity.Principal{UserID: testUserID, Source: identity.AuthAPIKey})
+
+		_, err := svc.BeginRegistration(ctx)
+		assertStatus(t, err, http.StatusForbidden)
+	})
+
+	t.Run("success excludes the existing passkeys", func(t *testing.T) {
+		svc, deps := newService(webAuthnCfg)
+		deps.userRepo.On("GetByID", mock.Anything, testUserID).
+			Return(&user.User{ID: testUserID, Name: "John Doe", Email: "jdoe@test.com"}, nil)
+		deps.repo.On("ListByUserID", mock.Anything, testUserID).
+			Return([]passkey.Passkey{{CredentialID: []byte{1, 2, 3}}}, nil)
+		deps.challenges.On("Save", mock.Anything, mock.Anything,
+			&webauthn.Session{Purpose: webauthn.PurposeRegister, UserID: testUserID}, webAuthnCfg.ChallengeTTL).
+			Return(nil)
+
+		options, err := svc.BeginRegistration(userCtx(t.Context()))
+		require.NoError(t, err)
+
+		assert.Equal(t, "AAAAAAAAAAc", options.User.ID)
+		assert.Equal(t, "jdoe@test.com", options.User.Name)
+		assert.Equal(t, []webauthn.CredentialDescriptor{{Type: "public-key", ID: "AQID"}}, options.ExcludeCredentials)
+		deps.challenges.AssertCalled(t, "Save", mock.Anything, options.Challenge, mock.Anything, mock.Anything)
+	})
+
+	t.Run("challenge store error", func(t *testing.T) {
+		svc, deps := newService(webAuthnCfg)
+		deps.userRepo.On("GetByID", mock.Anything, testUserID).Return(&user.User{ID: testUserID}, nil)
+		deps.repo.On("ListByUserID", mock.Anything, testUserID).Return(nil, nil)
+		deps.challenges.On("Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("redis down"))
+
+		_, err := svc.BeginRegistration(userCtx(t.Context()))
+		assertStatus(t, err, http.StatusInternalServerError)
+	})
+}
+
+func TestService_FinishRegistration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		setup      func(*webauthntest.Authenticator)
+		session    *webauthn.Session
+		consumeErr error
+		createErr  error
+		status     int
+	}{
+		{
+			name:    "success",
+			session: &webauthn.Session{Purpose: webauthn.PurposeRegister, UserID: testUserID},
+		},
+		{
+			name:       "unknown ceremony",
+			consumeErr: webauthn.ErrChallengeNotFound,
+			status:     http.StatusBadRequest,
+		},
+		{
+			name:    "ceremony of another user",
+			session: &webauthn.Session{Purpose: webauthn.PurposeRegister, UserID: testUserID + 1},
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "login ceremony",
+			session: &webauthn.Session{Purpose: webauthn.PurposeLogin},
+			status:  http.StatusBadRequest,
+		},
+		{
+			name: "user not verified",
+			setup: func(a *webauthntest.Authenticator) {
+				a.UserVerified = false
+			},
+			session: &webauthn.Session{Purpose: webauthn.PurposeRegister, UserID: testUserID},
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:      "already registered",
+			session:   &webauthn.Session{Purpose: webauthn.PurposeRegister, UserID: testUserID},
+			createErr: &pgconn.PgError{Code: postgres.UniqueViolation},
+			status:    http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newService(webAuthnCfg)
+			authenticator := webauthntest.New(origin)
+			authenticator.Attestation = "packed"
+			if tt.setup != nil {
+				tt.setup(authenticator)
+			}
+
+			challenge, err := webauthn.NewChallenge()
+			require.NoError(t, err)
+
+			rp := webauthn.NewRelyingParty(webAuthnCfg)
+			id, resp, err := authenticator.Create(rp.CreationOptions(challenge, webauthn.User{ID: userHandle(testUserID)}, nil))
+			require.NoError(t, err)
+
+			deps.challenges.On("Consume", mock.Anything, challenge).Return(tt.session, tt.consumeErr)
+
+			var created *passkey.Passkey
+			deps.repo.On("Create", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) { created = args.Get(1).(*passkey.Passkey) }).
+				Return(tt.createErr).
+				Maybe()
+
+			out, err := svc.FinishRegistration(userCtx(t.Context()), passkey.RegisterInput{Response: resp})
+
+			if tt.status != 0 {
+				assertStatus(t, err, tt.status)
+				return
+			}
+			require.NoError(t, err)
+			assert.Same(t, created, out)
+			assert.Equal(t, testUserID, out.UserID)
+			assert.Equal(t, id, out.CredentialID)
+			assert.Equal(t, "Passkey", out.Name)
+			assert.Equal(t, uuid.UUID(webauthntest.AAGUID), out.AAGUID)
+		})
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not found", func(t *testing.T) {
+		svc, deps := newService(webAuthnCfg)
+		deps.repo.On("Delete", mock.Anything, testUserID, uint(3)).Return(false, nil)
+
+		err := svc.Delete(userCtx(t.Context()), passkey.PasskeyInput{ID: 3})
+		assertStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		svc, deps := newService(webAuthnCfg)
+		deps.repo.On("Delete", mock.Anything, testUserID, uint(3)).Return(true, nil)
+
+		require.NoError(t, svc.Delete(userCtx(t.Context()), passkey.PasskeyInput{ID: 3}))
+		deps.repo.AssertExpectations(t)
+	})
+}
+
+func TestService_BeginLogin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("discoverable", func(t *testing.T) {
+		svc, deps := newService(webAuthnCfg)
+		deps.challenges.On("Save", mock.Anything, mock.Anything, &webauthn.Session{Purpose: webauthn.PurposeLogin}, webAuthnCfg.ChallengeTTL).
+			Return(nil)
+
+		options, err := svc.BeginLogin(t.Context(), passkey.BeginLoginInput{})
+		require.NoError(t, err)
+
+		assert.Empty(t, options.AllowCredentials)
+		assert.Equal(t, webauthn.UserVerificationRequired, options.UserVerification)
+		assert.Equal(t, "example.com", options.RPID)
+	})
+
+	t.Run("second factor", func(t *testing.T) {
+		svc, deps := newService(webAuthnCfg)
+		deps.repo.On("ListByUserID", mock.Anything, testUserID).
+			Return([]passkey.Passkey{{CredentialID: []byte{1, 2, 3}}}, nil)
+		deps.challenges.On("Save", mock.Anything, mock.Anything,
+			&webauthn.Session{Purpose: webauthn.PurposeMFA, UserID: testUserID, Binding: "jti"}, webAuthnCfg.ChallengeTTL).
+			Return(nil)
+
+		options, err := svc.BeginLogin(t.Context(), passkey.BeginLoginInput{UserID: testUserID, Binding: "jti"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []webauthn.CredentialDescriptor{{Type: "public-key", ID: "AQID"}}, options.AllowCredentials)
+		assert.Equal(t, webauthn.UserVerificationPreferred, options.UserVerification)
+	})
+
+	t.Run("reauthentication", func(t *testing.T) {
+		svc, deps := newService(webAuthnCfg)
+		deps.repo.On("ListByUserID", mock.Anything, testUserID).
+			Return([]passkey.Passkey{{CredentialID: []byte{1, 2, 3}}}, nil)
+		deps.challenges.On("Save", mock.Anything, mock.Anything,
+			&webauthn.Session{Purpose: webauthn.PurposeReauth, UserID: testUserID, Binding: "family"}, webAuthnCfg.ChallengeTTL).
+			Return(nil)
+
+		options, err := svc.BeginLogin(t.Context(), passkey.BeginLoginInput{UserID: testUserID, Binding: "family", Reauthenticate: true})
+		require.NoError(t, err)
+
+		assert.Len(t, options.AllowCredentials, 1)
+		assert.Equal(t, webauthn.UserVerificationRequired, options.UserVerification)
+	})
+
+	t.Run("reauthentication without user", func(t *testing.T) {
+		svc, _ := newService(webAuthnCfg)
+
+		_, err := svc.BeginLogin(t.Context(), passkey.BeginLoginInput{Reauthenticate: true})
+		assertStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("second factor without passkey", func(t *testing.T) {
+		svc, deps := newService(webAuthnCfg)
+		deps.repo.On("ListByUserID", mock.Anything, testUserID).Return(nil, nil)
+
+		_, err := svc.BeginLogin(t.Context(), passkey.BeginLoginInput{UserID: testUserID})
+		assertStatus(t, err, http.StatusNotFound)
+	})
+}
+
+func TestService_FinishLogin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		setup          func(*webauthntest.Authenticator)
+		owner          uint
+		session        *webauthn.Session
+		reauthenticate bool
+		mutate         func(*webauthn.AssertionResponse, *passkey.Passkey)
+		consumeErr     error
+		lookupErr      error
+		updated        bool
+		expected       *passkey.LoginOutput
+		status         int
+	}{
+		{
+			name:     "first factor",
+			owner:    testUserID,
+			session:  &webauthn.Session{Purpose: webauthn.PurposeLogin},
+			updated:  true,
+			expected: &passkey.LoginOutput{UserID: testUserID, UserVerified: true},
+		},
+		{
+			name: "second factor without user verification",
+			setup: func(a *webauthntest.Authenticator) {
+				a.UserVerified = false
+			},
+			owner:    testUserID,
+			session:  &webauthn.Session{Purpose: webauthn.PurposeMFA, UserID: testUserID, Binding: "jti"},
+			updated:  true,
+			expected: &passkey.LoginOutput{UserID: testUserID, SecondFactor: true, Binding: "jti"},
+		},
+		{
+			name: "first factor without user verification",
+			setup: func(a *webauthntest.Authenticator) {
+				a.UserVerified = false
+			},
+			owner:   testUserID,
+			session: &webauthn.Session{Purpose: webauthn.PurposeLogin},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:           "reauthentication",
+			owner:          testUserID,
+			session:        &webauthn.Session{Purpose: webauthn.PurposeReauth, UserID: testUserID, Binding: "family"},
+			reauthenticate: true,
+			mutate: func(resp *webauthn.AssertionResponse, _ *passkey.Passkey) {
+				resp.UserHandle = nil
+			},
+			updated:  true,
+			expected: &passkey.LoginOutput{UserID: testUserID, Binding: "family", UserVerified: true},
+		},
+		{
+			name: "reauthentication without user verification",
+			setup: func(a *webauthntest.Authenticator) {
+				a.UserVerified = false
+			},
+			owner:          testUserID,
+			session:        &webauthn.Session{Purpose: webauthn.PurposeReauth, UserID: testUserID, Binding: "family"},
+			reauthenticate: true,
+			status:         http.StatusUnauthorized,
+		},
+		{
+			name:    "reauthentication ceremony completing a login",
+			owner:   testUserID,
+			session: &webauthn.Session{Purpose: webauthn.PurposeReauth, UserID: testUserID, Binding: "family"},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:           "login ceremony completing a reauthentication",
+			owner:          testUserID,
+			session:        &webauthn.Session{Purpose: webauthn.PurposeMFA, UserID: testUserID, Binding: "jti"},
+			reauthenticate: true,
+			status:         http.StatusUnauthorized,
+		},
+		{
+			name:           "reauthentication",
+			owner:          testUserID,
+			session:        &webauthn.Session{Purpose: webauthn.PurposeReauth, UserID: testUserID, Binding: "family"},
+			reauthenticate: true,
+			mutate: func(resp *webauthn.AssertionResponse, _ *passkey.Passkey) {
+				resp.UserHandle = nil
+			},
+			updated:  true,
+			expected: &passkey.LoginOutput{UserID: testUserID, Binding: "family", UserVerified: true},
+		},
+		{
+			name: "reauthentication without user verification",
+			setup: func(a *webauthntest.Authenticator) {
+				a.UserVerified = false
+			},
+			owner:          testUserID,
+			session:        &webauthn.Session{Purpose: webauthn.PurposeReauth, UserID: testUserID, Binding: "family"},
+			reauthenticate: true,
+			status:         http.StatusUnauthorized,
+		},
+		{
+			name:    "reauthentication ceremony completing a login",
+			owner:   testUserID,
+			session: &webauthn.Session{Purpose: webauthn.PurposeReauth, UserID: testUserID, Binding: "family"},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:           "login ceremony completing a reauthentication",
+			owner:          testUserID,
+			session:        &webauthn.Session{Purpose: webauthn.PurposeMFA, UserID: testUserID, Binding: "jti"},
+			reauthenticate: true,
+			status:         http.StatusUnauthorized,
+		},
+		{
+			name:       "unknown ceremony",
+			owner:      testUserID,
+			consumeErr: webauthn.ErrChallengeNotFound,
+			status:     http.StatusUnauthorized,
+		},
+		{
+			name:       "challenge store error",
+			owner:      testUserID,
+			consumeErr: errors.New("redis down"),
+			status:     http.StatusInternalServerError,
+		},
+		{
+			name:    "registration ceremony",
+			owner:   testUserID,
+			session: &webauthn.Session{Purpose: webauthn.PurposeRegister, UserID: testUserID},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:      "unknown passkey",
+			owner:     testUserID,
+			session:   &webauthn.Session{Purpose: webauthn.PurposeLogin},
+			lookupErr: gorm.ErrRecordNotFound,
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:    "passkey of another user",
+			owner:   testUserID + 1,
+			session: &webauthn.Session{Purpose: webauthn.PurposeMFA, UserID: testUserID},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "user handle of another user",
+			owner:   testUserID,
+			session: &webauthn.Session{Purpose: webauthn.PurposeLogin},
+			mutate: func(resp *webauthn.AssertionResponse, _ *passkey.Passkey) {
+				resp.UserHandle = userHandle(testUserID + 1)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:    "discoverable login without user handle",
+			owner:   testUserID,
+			session: &webauthn.Session{Purpose: webauthn.PurposeLogin},
+			mutate: func(resp *webauthn.AssertionResponse, _ *passkey.Passkey) {
+				resp.UserHandle = nil
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:    "cloned authenticator",
+			owner:   testUserID,
+			session: &webauthn.Session{Purpose: webauthn.PurposeLogin},
+			mutate: func(_ *webauthn.AssertionResponse, stored *passkey.Passkey) {
+				stored.SignCount = 10
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:    "concurrent assertion",
+			owner:   testUserID,
+			session: &webauthn.Session{Purpose: webauthn.PurposeLogin},
+			status:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newService(webAuthnCfg)
+			authenticator := webauthntest.New(origin)
+			stored := registered(t, authenticator, tt.owner)
+			if tt.setup != nil {
+				tt.setup(authenticator)
+			}
+
+			challenge, err := webauthn.NewChallenge()
+			require.NoError(t, err)
+
+			rp := webauthn.NewRelyingParty(webAuthnCfg)
+			resp, err := authenticator.Get(rp.RequestOptions(challenge, nil, false))
+			require.NoError(t, err)
+
+			if tt.mutate != nil {
+				tt.mutate(resp, stored)
+			}
+
+			deps.challenges.On("Consume", mock.Anything, challenge).Return(tt.session, tt.consumeErr)
+			if tt.lookupErr != nil {
+				deps.repo.On("GetByCredentialID", mock.Anything, resp.CredentialID).Return(testutil.Err[*passkey.Passkey](tt.lookupErr))
+			} else {
+				deps.repo.On("GetByCredentialID", mock.Anything, resp.CredentialID).Return(stored, nil).Maybe()
+			}
+			deps.repo.On("UpdateUsage", mock.Anything, stored.ID, stored.SignCount+1, false, mock.Anything).
+				Return(tt.updated, nil).
+				Maybe()
+
+			out, err := svc.FinishLogin(t.Context(), passkey.FinishLoginInput{Response: resp, Reauthenticate: tt.reauthenticate})
+
+			if tt.status != 0 {
+				assertStatus(t, err, tt.status)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+			deps.repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_HasPasskeys(t *testing.T) {
+	t.Parallel()
+	svc, deps := newService(webAuthnCfg)
+	deps.repo.On("CountByUserID", mock.Anything, testUserID).Return(int64(2), nil)
+
+	has, err := svc.HasPasskeys(t.Context(), testUserID)
+	require.NoError(t, err)
+	assert.True(t, has)
+}
@@ -0,0 +1,19 @@
+package rbac
+
+import (
+	"gomonitor/internal/pkg/identity"
+	"time"
+)
+
+// Role is a named bundle of permissions, users reference it by name.
+type Role struct {
+	Name        identity.UserRole `gorm:"type:varchar(50);primaryKey"`
+	Description string            `gorm:"type:varchar(255);not null;default:''"`
+	CreatedAt   time.Time
+}
+
+// RolePermission grants one permission to a role.
+type RolePermission struct {
+	RoleName   identity.UserRole   `gorm:"type:varchar(50);primaryKey"`
+	Permission identity.Permission `gorm:"type:varchar(100);primaryKey"`
+}
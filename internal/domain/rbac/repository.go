@@ -0,0 +1,42 @@
+package rbac
+
+import (
+	"context"
+	"gomonitor/internal/pkg/identity"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	GetPermissions(ctx context.Context, role identity.UserRole) ([]identity.Permission, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+// GetPermissions returns the permissions of the role, none for an unknown role.
+func (r *repository) GetPermissions(ctx context.Context, role identity.UserRole) ([]identity.Permission, error) {
+	var permissions []identity.Permission
+	err := r.db.
+		WithContext(ctx).
+		Model(&RolePermission{}).
+		Where("role_name = ?", role).
+		Order("permission").
+		Pluck("permission", &permissions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,48 @@
+package rbac_test
+
+import (
+	"gomonitor/internal/domain/rbac"
+	databaseinfra "gomonitor/internal/infra/database"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/testutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_GetPermissions(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := rbac.NewRepository(tx)
+
+	// The schema seeds the moderator role.
+	permissions, err := repo.GetPermissions(t.Context(), identity.RoleModerator)
+	require.NoError(t, err)
+	assert.Equal(t, []identity.Permission{
+		identity.PermSessionsRead,
+		identity.PermSessionsRevoke,
+		identity.PermUsersRead,
+	}, permissions)
+
+	permissions, err = repo.GetPermissions(t.Context(), identity.RoleUser)
+	require.NoError(t, err)
+	assert.Empty(t, permissions)
+
+	// Roles added as rows work without code changes.
+	require.NoError(t, tx.Create(&rbac.Role{Name: "support"}).Error)
+	require.NoError(t, tx.Create(&rbac.RolePermission{RoleName: "support", Permission: identity.PermUsersRead}).Error)
+
+	permissions, err = repo.GetPermissions(t.Context(), "support")
+	require.NoError(t, err)
+	assert.Equal(t, []identity.Permission{identity.PermUsersRead}, permissions)
+
+	permissions, err = repo.GetPermissions(t.Context(), "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, permissions)
+
+	_, err = repo.GetPermissions(testutil.GetCancelledCtx(t.Context()), identity.RoleAdmin)
+	assert.Error(t, err)
+}
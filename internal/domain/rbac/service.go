@@ -0,0 +1,66 @@
+package rbac
+
+import (
+	"context"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// cacheTTL bounds how long a permission change takes to reach running instances.
+const cacheTTL = time.Minute
+
+type Service interface {
+	// Permissions resolves the permissions granted by a role, it is called on every authenticated request.
+	Permissions(ctx context.Context, role identity.UserRole) ([]identity.Permission, error)
+}
+
+type ServiceDeps struct {
+	Logger *slog.Logger
+	Repo   Repository
+}
+
+type cachedPermissions struct {
+	permissions []identity.Permission
+	expiresAt   time.Time
+}
+
+type service struct {
+	logger *slog.Logger
+	repo   Repository
+
+	mu    sync.Mutex
+	cache map[identity.UserRole]cachedPermissions
+}
+
+func NewService(deps *ServiceDeps) Service {
+	return &service{
+		logger: deps.Logger,
+		repo:   deps.Repo,
+		cache:  make(map[identity.UserRole]cachedPermissions),
+	}
+}
+
+func (s *service) Permissions(ctx context.Context, role identity.UserRole) ([]identity.Permission, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[role]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.permissions, nil
+	}
+
+	permissions, err := s.repo.GetPermissions(ctx, role)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	s.mu.Lock()
+	s.cache[role] = cachedPermissions{permissions: permissions, expiresAt: now.Add(cacheTTL)}
+	s.mu.Unlock()
+
+	return permissions, nil
+}
@@ -0,0 +1,67 @@
+package rbac_test
+
+import (
+	"errors"
+	"gomonitor/internal/domain/rbac"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Permissions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("caches the permissions of a role", func(t *testing.T) {
+		t.Parallel()
+
+		repo := new(mocks.MockRBACRepository)
+		repo.On("GetPermissions", mock.Anything, identity.RoleModerator).
+			Return([]identity.Permission{identity.PermUsersRead}, nil).Once()
+		repo.On("GetPermissions", mock.Anything, identity.RoleUser).
+			Return([]identity.Permission{}, nil).Once()
+
+		svc := rbac.NewService(&rbac.ServiceDeps{Logger: slog.Default(), Repo: repo})
+
+		for range 2 {
+			permissions, err := svc.Permissions(t.Context(), identity.RoleModerator)
+			require.NoError(t, err)
+			assert.Equal(t, []identity.Permission{identity.PermUsersRead}, permissions)
+		}
+
+		permissions, err := svc.Permissions(t.Context(), identity.RoleUser)
+		require.NoError(t, err)
+		assert.Empty(t, permissions)
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("repository error is not cached", func(t *testing.T) {
+		t.Parallel()
+
+		repo := new(mocks.MockRBACRepository)
+		repo.On("GetPermissions", mock.Anything, identity.RoleAdmin).
+			Return(nil, errors.New("db down")).Once()
+		repo.On("GetPermissions", mock.Anything, identity.RoleAdmin).
+			Return([]identity.Permission{identity.PermUsersCreate}, nil).Once()
+
+		svc := rbac.NewService(&rbac.ServiceDeps{Logger: slog.Default(), Repo: repo})
+
+		_, err := svc.Permissions(t.Context(), identity.RoleAdmin)
+		var appErr *pkgerrors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
+
+		permissions, err := svc.Permissions(t.Context(), identity.RoleAdmin)
+		require.NoError(t, err)
+		assert.Equal(t, []identity.Permission{identity.PermUsersCreate}, permissions)
+
+		repo.AssertExpectations(t)
+	})
+}
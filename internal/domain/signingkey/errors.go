@@ -0,0 +1,11 @@
+package signingkey
+
+import "errors"
+
+var (
+	MsgKeyNotFound      = "signing key not found"
+	MsgKeyRetired       = "signing key is retired"
+	MsgRetireCurrentKey = "the current signing key can't be retired, promote another key first"
+)
+
+var ErrKeyNotEncrypted = errors.New("signing key material is not encrypted")
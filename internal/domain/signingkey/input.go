@@ -0,0 +1,12 @@
+package signingkey
+
+import "gomonitor/internal/pkg/jwt"
+
+type CreateKeyInput struct {
+	TokenType jwt.TokenType
+	Algorithm string
+}
+
+type KeyInput struct {
+	KID string
+}
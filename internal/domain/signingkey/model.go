@@ -0,0 +1,22 @@
+package signingkey
+
+import (
+	"gomonitor/internal/pkg/jwt"
+	"time"
+)
+
+// SigningKey is a persisted token key, shared by every instance of the app.
+// A key verifies tokens until retired, the most recently promoted one signs new tokens.
+// Material is encrypted, keys stored before encryption are encrypted in place by Bootstrap.
+type SigningKey struct {
+	KID        string        `gorm:"primaryKey;column:kid"`
+	TokenType  jwt.TokenType `gorm:"type:varchar(16);not null"`
+	Algorithm  string        `gorm:"type:varchar(16);not null"`
+	Material   string        `gorm:"not null"`
+	Encrypted  bool          `gorm:"not null"`
+	CreatedAt  time.Time
+	PromotedAt *time.Time
+	RetiredAt  *time.Time
+
+	Current bool `gorm:"-"`
+}
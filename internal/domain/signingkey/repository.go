@@ -0,0 +1,113 @@
+package signingkey
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(ctx context.Context, key *SigningKey) error
+	CreateIfMissing(ctx context.Context, key *SigningKey) error
+	GetByKID(ctx context.Context, kid string) (*SigningKey, error)
+	List(ctx context.Context) ([]SigningKey, error)
+	ListActive(ctx context.Context) ([]SigningKey, error)
+	Promote(ctx context.Context, kid string) error
+	Retire(ctx context.Context, kid string) error
+	UpdateMaterial(ctx context.Context, kid, material string) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) Create(ctx context.Context, key *SigningKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// CreateIfMissing ignores conflicts, since every instance seeds the same configured keys on startup.
+func (r *repository) CreateIfMissing(ctx context.Context, key *SigningKey) error {
+	return r.db.
+		WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(key).
+		Error
+}
+
+func (r *repository) GetByKID(ctx context.Context, kid string) (*SigningKey, error) {
+	var key SigningKey
+	if err := r.db.WithContext(ctx).First(&key, "kid = ?", kid).Error; err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *repository) List(ctx context.Context) ([]SigningKey, error) {
+	var keys []SigningKey
+	err := r.db.
+		WithContext(ctx).
+		Order("created_at DESC").
+		Find(&keys).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// ListActive returns the non retired keys, the current key of each type comes first.
+func (r *repository) ListActive(ctx context.Context) ([]SigningKey, error) {
+	var keys []SigningKey
+	err := r.db.
+		WithContext(ctx).
+		Where("retired_at IS NULL").
+		Order("promoted_at DESC NULLS LAST").
+		Order("created_at DESC").
+		Find(&keys).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *repository) Promote(ctx context.Context, kid string) error {
+	return r.db.
+		WithContext(ctx).
+		Model(&SigningKey{}).
+		Where("kid = ? AND retired_at IS NULL", kid).
+		Update("promoted_at", gorm.Expr("NOW()")).
+		Error
+}
+
+func (r *repository) Retire(ctx context.Context, kid string) error {
+	return r.db.
+		WithContext(ctx).
+		Model(&SigningKey{}).
+		Where("kid = ? AND retired_at IS NULL", kid).
+		Update("retired_at", gorm.Expr("NOW()")).
+		Error
+}
+
+// UpdateMaterial replaces the plaintext material of a key stored before encryption.
+func (r *repository) UpdateMaterial(ctx context.Context, kid, material string) error {
+	return r.db.
+		WithContext(ctx).
+		Model(&SigningKey{}).
+		Where("kid = ? AND NOT encrypted", kid).
+		Updates(map[string]any{"material": material, "encrypted": true}).
+		Error
+}
@@ -0,0 +1,154 @@
+package signingkey_test
+
+import (
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/signingkey"
+	databaseinfra "gomonitor/internal/infra/database"
+	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := signingkey.NewRepository(tx)
+
+	_, key := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodEdDSA, false)
+	require.NoError(t, repo.Create(t.Context(), &key))
+
+	got, err := repo.GetByKID(t.Context(), key.KID)
+	require.NoError(t, err)
+	assert.Equal(t, key.Material, got.Material)
+	assert.Nil(t, got.PromotedAt)
+
+	// A second create with the same kid conflicts.
+	assert.Error(t, repo.Create(t.Context(), &key))
+
+	_, err = repo.GetByKID(t.Context(), "missing")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	assert.Error(t, repo.Create(testutil.GetCancelledCtx(t.Context()), &key))
+}
+
+func TestRepository_CreateIfMissing(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := signingkey.NewRepository(tx)
+
+	_, key := storedKey(t, jwt.TokenTypeRefresh, config.SigningMethodHS256, true)
+	require.NoError(t, repo.CreateIfMissing(t.Context(), &key))
+
+	duplicate := key
+	duplicate.Material = "other"
+	require.NoError(t, repo.CreateIfMissing(t.Context(), &duplicate))
+
+	got, err := repo.GetByKID(t.Context(), key.KID)
+	require.NoError(t, err)
+	assert.Equal(t, key.Material, got.Material)
+}
+
+func TestRepository_ListActive(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := signingkey.NewRepository(tx)
+
+	_, old := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, true)
+	old.PromotedAt = testutil.Ptr(time.Now().Add(-time.Hour))
+	_, pending := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, false)
+	_, current := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, true)
+	_, retired := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, true)
+
+	for _, key := range []*signingkey.SigningKey{&old, &pending, &current, &retired} {
+		require.NoError(t, repo.Create(t.Context(), key))
+	}
+	require.NoError(t, repo.Retire(t.Context(), retired.KID))
+
+	active, err := repo.ListActive(t.Context())
+	require.NoError(t, err)
+
+	var kids []string
+	for _, key := range active {
+		kids = append(kids, key.KID)
+	}
+
+	// Seeded keys from the migrations may exist as well, only the relative order matters.
+	assert.NotContains(t, kids, retired.KID)
+	require.Subset(t, kids, []string{current.KID, old.KID, pending.KID})
+	assert.Less(t, indexOf(kids, current.KID), indexOf(kids, old.KID))
+	assert.Less(t, indexOf(kids, old.KID), indexOf(kids, pending.KID))
+
+	all, err := repo.List(t.Context())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(all), 4)
+}
+
+func TestRepository_Promote(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := signingkey.NewRepository(tx)
+
+	_, key := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, false)
+	require.NoError(t, repo.Create(t.Context(), &key))
+
+	require.NoError(t, repo.Promote(t.Context(), key.KID))
+
+	got, err := repo.GetByKID(t.Context(), key.KID)
+	require.NoError(t, err)
+	assert.NotNil(t, got.PromotedAt)
+	assert.Nil(t, got.RetiredAt)
+
+	require.NoError(t, repo.Retire(t.Context(), key.KID))
+
+	got, err = repo.GetByKID(t.Context(), key.KID)
+	require.NoError(t, err)
+	assert.NotNil(t, got.RetiredAt)
+}
+
+func TestRepository_UpdateMaterial(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := signingkey.NewRepository(tx)
+
+	_, key := storedKey(t, jwt.TokenTypeRefresh, config.SigningMethodHS256, true)
+	key.Material, key.Encrypted = "plaintext", false
+	require.NoError(t, repo.Create(t.Context(), &key))
+
+	require.NoError(t, repo.UpdateMaterial(t.Context(), key.KID, "sealed"))
+
+	got, err := repo.GetByKID(t.Context(), key.KID)
+	require.NoError(t, err)
+	assert.Equal(t, "sealed", got.Material)
+	assert.True(t, got.Encrypted)
+
+	// Encrypted material is never replaced.
+	require.NoError(t, repo.UpdateMaterial(t.Context(), key.KID, "other"))
+
+	got, err = repo.GetByKID(t.Context(), key.KID)
+	require.NoError(t, err)
+	assert.Equal(t, "sealed", got.Material)
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
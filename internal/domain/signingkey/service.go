@@ -0,0 +1,369 @@
+package signingkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gomonitor/internal/observability/logging"
+	"gomonitor/internal/pkg/encryption"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/jwt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Bootstrap(ctx context.Context) error
+	Sync(ctx context.Context) error
+	Run(ctx context.Context, interval time.Duration)
+	List(ctx context.Context) ([]SigningKey, error)
+	Create(ctx context.Context, input CreateKeyInput) (*SigningKey, error)
+	Promote(ctx context.Context, input KeyInput) (*SigningKey, error)
+	Retire(ctx context.Context, input KeyInput) (*SigningKey, error)
+}
+
+type ServiceDeps struct {
+	AccessKeyring *jwt.Keyring
+	// Cipher encrypts the key material, the stored keys can't sign tokens without it.
+	Cipher         encryption.Cipher
+	Logger         *slog.Logger
+	RefreshKeyring *jwt.Keyring
+	Repo           Repository
+}
+
+type service struct {
+	cipher   encryption.Cipher
+	keyrings map[jwt.TokenType]*jwt.Keyring
+	logger   *slog.Logger
+	repo     Repository
+}
+
+func NewService(deps *ServiceDeps) Service {
+	return &service{
+		cipher: deps.Cipher,
+		keyrings: map[jwt.TokenType]*jwt.Keyring{
+			jwt.TokenTypeAccess:  deps.AccessKeyring,
+			jwt.TokenTypeRefresh: deps.RefreshKeyring,
+		},
+		logger: deps.Logger,
+		repo:   deps.Repo,
+	}
+}
+
+// Bootstrap stores the configured keys when no key exists yet for a token type, then loads the stored keyring.
+// After the first start the configured keys are only a seed, rotation happens through the stored keys: changing
+// the configured secrets or PEM file has no effect once a key is stored for their token type.
+func (s *service) Bootstrap(ctx context.Context) error {
+	if err := s.encryptStored(ctx); err != nil {
+		return err
+	}
+
+	active, err := s.repo.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	seeded := make(map[jwt.TokenType]bool)
+	for _, key := range active {
+		seeded[key.TokenType] = true
+	}
+
+	for tokenType, keyring := range s.keyrings {
+		if seeded[tokenType] {
+			continue
+		}
+
+		current := keyring.Current()
+		material, err := s.seal(current)
+		if err != nil {
+			return fmt.Errorf("error encoding %s key: %w", tokenType, err)
+		}
+
+		now := time.Now()
+		err = s.repo.CreateIfMissing(ctx, &SigningKey{
+			KID:        current.ID,
+			TokenType:  tokenType,
+			Algorithm:  current.Method.Alg(),
+			Material:   material,
+			Encrypted:  true,
+			PromotedAt: &now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.Sync(ctx)
+}
+
+// Sync replaces the in memory keyrings with the stored active keys.
+func (s *service) Sync(ctx context.Context) error {
+	active, err := s.repo.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[jwt.TokenType]*jwt.SigningKey)
+	keys := make(map[jwt.TokenType][]*jwt.SigningKey)
+	for _, stored := range active {
+		key, err := s.open(&stored)
+		if err != nil {
+			return fmt.Errorf("error decoding key %s: %w", stored.KID, err)
+		}
+
+		// Sorted by promotion, the first promoted key is the current one.
+		if current[stored.TokenType] == nil && stored.PromotedAt != nil {
+			current[stored.TokenType] = key
+		}
+		keys[stored.TokenType] = append(keys[stored.TokenType], key)
+	}
+
+	for tokenType, keyring := range s.keyrings {
+		if current[tokenType] == nil {
+			continue
+		}
+		keyring.Replace(current[tokenType], keys[tokenType])
+	}
+
+	return nil
+}
+
+// Run periodically syncs the keyrings, so changes made on other instances are picked up without a restart.
+func (s *service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Warn("failed to sync signing keys", slog.Any("err", err))
+			}
+		}
+	}
+}
+
+func (s *service) List(ctx context.Context) ([]SigningKey, error) {
+	if err := s.requirePermission(ctx, identity.PermSigningKeysRead); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	for i := range keys {
+		keys[i].Current = s.isCurrent(&keys[i])
+	}
+
+	return keys, nil
+}
+
+// Create generates a new key, it only verifies tokens until promoted.
+func (s *service) Create(ctx context.Context, input CreateKeyInput) (*SigningKey, error) {
+	if err := s.requirePermission(ctx, identity.PermSigningKeysWrite); err != nil {
+		return nil, err
+	}
+
+	key, err := jwt.GenerateSigningKey(input.Algorithm)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	material, err := s.seal(key)
+	if err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	stored := &SigningKey{
+		KID:       key.ID,
+		TokenType: input.TokenType,
+		Algorithm: input.Algorithm,
+		Material:  material,
+		Encrypted: true,
+	}
+
+	if err := s.repo.Create(ctx, stored); err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	// Publish right away, so other services can verify it before it starts signing.
+	s.sync(ctx)
+
+	logging.FromContext(ctx).Info("signing key created",
+		slog.String("kid", stored.KID),
+		slog.String("token_type", string(stored.TokenType)),
+		slog.String("algorithm", stored.Algorithm),
+	)
+
+	return stored, nil
+}
+
+// Promote makes the key the current signing key for its token type, the previous one keeps verifying.
+func (s *service) Promote(ctx context.Context, input KeyInput) (*SigningKey, error) {
+	if err := s.requirePermission(ctx, identity.PermSigningKeysWrite); err != nil {
+		return nil, err
+	}
+
+	key, err := s.getActive(ctx, input.KID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Promote(ctx, key.KID); err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	s.sync(ctx)
+
+	logging.FromContext(ctx).Info("signing key promoted",
+		slog.String("kid", key.KID),
+		slog.String("token_type", string(key.TokenType)),
+	)
+
+	return s.get(ctx, key.KID)
+}
+
+// Retire stops accepting tokens signed with the key.
+func (s *service) Retire(ctx context.Context, input KeyInput) (*SigningKey, error) {
+	if err := s.requirePermission(ctx, identity.PermSigningKeysWrite); err != nil {
+		return nil, err
+	}
+
+	key, err := s.getActive(ctx, input.KID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.isCurrent(key) {
+		return nil, pkgerrors.NewConflictError(MsgRetireCurrentKey)
+	}
+
+	if err := s.repo.Retire(ctx, key.KID); err != nil {
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	s.sync(ctx)
+
+	logging.FromContext(ctx).Info("signing key retired",
+		slog.String("kid", key.KID),
+		slog.String("token_type", string(key.TokenType)),
+	)
+
+	return s.get(ctx, key.KID)
+}
+
+func (s *service) get(ctx context.Context, kid string) (*SigningKey, error) {
+	key, err := s.repo.GetByKID(ctx, kid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewNotFoundError(MsgKeyNotFound, err)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	key.Current = s.isCurrent(key)
+	return key, nil
+}
+
+func (s *service) getActive(ctx context.Context, kid string) (*SigningKey, error) {
+	key, err := s.get(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.RetiredAt != nil {
+		return nil, pkgerrors.NewConflictError(MsgKeyRetired)
+	}
+
+	return key, nil
+}
+
+// encryptStored encrypts the material of the keys stored before it was encrypted at rest.
+func (s *service) encryptStored(ctx context.Context) error {
+	keys, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if key.Encrypted {
+			continue
+		}
+
+		material, err := s.cipher.Encrypt([]byte(key.Material))
+		if err != nil {
+			return fmt.Errorf("error encrypting key %s: %w", key.KID, err)
+		}
+
+		if err := s.repo.UpdateMaterial(ctx, key.KID, material); err != nil {
+			return err
+		}
+
+		s.logger.Info("signing key encrypted", slog.String("kid", key.KID))
+	}
+
+	return nil
+}
+
+// seal encodes the key for storage, encrypted.
+func (s *service) seal(key *jwt.SigningKey) (string, error) {
+	material, err := key.Material()
+	if err != nil {
+		return "", err
+	}
+
+	return s.cipher.Encrypt([]byte(material))
+}
+
+// open decrypts a stored key, plaintext material is refused so a key written to the table can't sign tokens.
+func (s *service) open(stored *SigningKey) (*jwt.SigningKey, error) {
+	if !stored.Encrypted {
+		return nil, ErrKeyNotEncrypted
+	}
+
+	material, err := s.cipher.Decrypt(stored.Material)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.DecodeSigningKey(stored.Algorithm, string(material))
+}
+
+func (s *service) isCurrent(key *SigningKey) bool {
+	keyring, ok := s.keyrings[key.TokenType]
+	return ok && key.RetiredAt == nil && keyring.Current().ID == key.KID
+}
+
+// sync applies a change on this instance immediately, failures are picked up by the next periodic sync.
+func (s *service) sync(ctx context.Context) {
+	if err := s.Sync(ctx); err != nil {
+		logging.FromContext(ctx).Warn("failed to sync signing keys", slog.Any("err", err))
+	}
+}
+
+func (s *service) requirePermission(ctx context.Context, permission identity.Permission) error {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Warn("unauthenticated signing key request")
+		return pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	if !principal.HasPermission(permission) {
+		logging.FromContext(ctx).Warn("unauthorized signing key request",
+			"permission", permission,
+			"user_id", principal.UserID,
+			"user_role", principal.Role,
+			"source", principal.Source,
+		)
+		return pkgerrors.NewForbiddenError()
+	}
+
+	return nil
+}
@@ -0,0 +1,572 @@
+package signingkey_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/signingkey"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/encryption"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/testutil"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testCipher, _ = encryption.NewAESCipher(bytes.Repeat([]byte("k"), encryption.KeySize))
+
+func adminCtx(ctx context.Context) context.Context {
+	return identity.WithPrincipal(ctx, &identity.Principal{
+		UserID:      1,
+		Role:        identity.RoleAdmin,
+		Permissions: []identity.Permission{identity.PermSigningKeysRead, identity.PermSigningKeysWrite},
+		Source:      identity.AuthInternal,
+	})
+}
+
+func userCtx(ctx context.Context) context.Context {
+	return identity.WithPrincipal(ctx, &identity.Principal{
+		UserID: 2,
+		Role:   identity.RoleUser,
+		Source: identity.AuthInternal,
+	})
+}
+
+// storedKey persists a generated key the same way the service does.
+func storedKey(t *testing.T, tokenType jwt.TokenType, method string, promoted bool) (*jwt.SigningKey, signingkey.SigningKey) {
+	t.Helper()
+
+	key, err := jwt.GenerateSigningKey(method)
+	require.NoError(t, err)
+
+	material, err := key.Material()
+	require.NoError(t, err)
+
+	sealed, err := testCipher.Encrypt([]byte(material))
+	require.NoError(t, err)
+
+	stored := signingkey.SigningKey{
+		KID:       key.ID,
+		TokenType: tokenType,
+		Algorithm: method,
+		Material:  sealed,
+		Encrypted: true,
+		CreatedAt: time.Now(),
+	}
+	if promoted {
+		stored.PromotedAt = testutil.Ptr(time.Now())
+	}
+
+	return key, stored
+}
+
+func newService(repo signingkey.Repository, access, refresh *jwt.Keyring) signingkey.Service {
+	return signingkey.NewService(&signingkey.ServiceDeps{
+		AccessKeyring:  access,
+		Cipher:         testCipher,
+		Logger:         slog.Default(),
+		RefreshKeyring: refresh,
+		Repo:           repo,
+	})
+}
+
+func TestNewService(t *testing.T) {
+	t.Parallel()
+	service := signingkey.NewService(&signingkey.ServiceDeps{
+		AccessKeyring:  jwt.NewKeyring(jwt.NewHMACKey("access")),
+		Logger:         slog.Default(),
+		RefreshKeyring: jwt.NewKeyring(jwt.NewHMACKey("refresh")),
+		Repo:           signingkey.NewRepository(&gorm.DB{}),
+	})
+	assert.NotNil(t, service)
+}
+
+func TestService_Bootstrap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("seeds configured keys", func(t *testing.T) {
+		accessKey := jwt.NewHMACKey("access")
+		refreshKey := jwt.NewHMACKey("refresh")
+
+		repo := &mocks.MockSigningKeyRepository{}
+		repo.On("List", mock.Anything).Return(nil, nil)
+		repo.On("ListActive", mock.Anything).Return(nil, nil).Once()
+		repo.On("CreateIfMissing", mock.Anything, mock.MatchedBy(func(k *signingkey.SigningKey) bool {
+			return k.KID == accessKey.ID && k.TokenType == jwt.TokenTypeAccess && k.PromotedAt != nil && k.Encrypted
+		})).Return(nil)
+		repo.On("CreateIfMissing", mock.Anything, mock.MatchedBy(func(k *signingkey.SigningKey) bool {
+			if k.KID != refreshKey.ID || k.TokenType != jwt.TokenTypeRefresh || !k.Encrypted {
+				return false
+			}
+			material, err := testCipher.Decrypt(k.Material)
+			return err == nil && string(material) == "refresh"
+		})).Return(nil)
+		repo.On("ListActive", mock.Anything).Return(nil, nil).Once()
+
+		service := newService(repo, jwt.NewKeyring(accessKey), jwt.NewKeyring(refreshKey))
+
+		assert.NoError(t, service.Bootstrap(t.Context()))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("keeps stored keys", func(t *testing.T) {
+		storedAccess, access := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodEdDSA, true)
+		storedRefresh, refresh := storedKey(t, jwt.TokenTypeRefresh, config.SigningMethodHS256, true)
+
+		repo := &mocks.MockSigningKeyRepository{}
+		repo.On("List", mock.Anything).Return([]signingkey.SigningKey{access, refresh}, nil)
+		repo.On("ListActive", mock.Anything).Return([]signingkey.SigningKey{access, refresh}, nil)
+
+		accessKeyring := jwt.NewKeyring(jwt.NewHMACKey("access"))
+		refreshKeyring := jwt.NewKeyring(jwt.NewHMACKey("refresh"))
+		service := newService(repo, accessKeyring, refreshKeyring)
+
+		require.NoError(t, service.Bootstrap(t.Context()))
+		assert.Equal(t, storedAccess.ID, accessKeyring.Current().ID)
+		assert.Equal(t, storedRefresh.ID, refreshKeyring.Current().ID)
+		repo.AssertNotCalled(t, "CreateIfMissing", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "UpdateMaterial", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("encrypts plaintext keys", func(t *testing.T) {
+		key := jwt.NewHMACKey("legacy")
+		legacy := signingkey.SigningKey{
+			KID:        key.ID,
+			TokenType:  jwt.TokenTypeRefresh,
+			Algorithm:  config.SigningMethodHS256,
+			Material:   "legacy",
+			PromotedAt: testutil.Ptr(time.Now()),
+		}
+		_, access := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodEdDSA, true)
+
+		repo := &mocks.MockSigningKeyRepository{}
+		repo.On("List", mock.Anything).Return([]signingkey.SigningKey{access, legacy}, nil)
+		repo.On("UpdateMaterial", mock.Anything, key.ID, mock.MatchedBy(func(material string) bool {
+			plaintext, err := testCipher.Decrypt(material)
+			return err == nil && string(plaintext) == "legacy"
+		})).Return(nil)
+		repo.On("ListActive", mock.Anything).Return([]signingkey.SigningKey{access}, nil)
+		repo.On("CreateIfMissing", mock.Anything, mock.Anything).Return(nil)
+
+		service := newService(repo, jwt.NewKeyring(jwt.NewHMACKey("access")), jwt.NewKeyring(jwt.NewHMACKey("refresh")))
+
+		require.NoError(t, service.Bootstrap(t.Context()))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repo := &mocks.MockSigningKeyRepository{}
+		repo.On("List", mock.Anything).Return(nil, nil)
+		repo.On("ListActive", mock.Anything).Return(nil, errors.New("db down"))
+
+		service := newService(repo, jwt.NewKeyring(jwt.NewHMACKey("a")), jwt.NewKeyring(jwt.NewHMACKey("r")))
+
+		assert.EqualError(t, service.Bootstrap(t.Context()), "db down")
+	})
+}
+
+func TestService_Sync(t *testing.T) {
+	t.Parallel()
+
+	current, currentStored := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodES256, true)
+	previous, previousStored := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, true)
+	pending, pendingStored := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodRS256, false)
+	previousStored.PromotedAt = testutil.Ptr(time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name       string
+		active     []signingkey.SigningKey
+		assertErr  func(t *testing.T, err error)
+		assertRing func(t *testing.T, keyring *jwt.Keyring)
+	}{
+		{
+			name:   "loads active keys",
+			active: []signingkey.SigningKey{currentStored, previousStored, pendingStored},
+			assertRing: func(t *testing.T, keyring *jwt.Keyring) {
+				assert.Equal(t, current.ID, keyring.Current().ID)
+				for _, key := range []*jwt.SigningKey{current, previous, pending} {
+					_, ok := keyring.Lookup(key.ID)
+					assert.True(t, ok)
+				}
+			},
+		},
+		{
+			name:   "keeps keyring without a promoted key",
+			active: []signingkey.SigningKey{pendingStored},
+			assertRing: func(t *testing.T, keyring *jwt.Keyring) {
+				assert.NotEqual(t, pending.ID, keyring.Current().ID)
+			},
+		},
+		{
+			name: "invalid material",
+			active: []signingkey.SigningKey{{
+				KID:       "broken",
+				TokenType: jwt.TokenTypeAccess,
+				Algorithm: config.SigningMethodEdDSA,
+				Material:  "not a pem",
+				Encrypted: true,
+			}},
+			assertErr: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "broken")
+			},
+		},
+		{
+			name: "plaintext material",
+			active: []signingkey.SigningKey{{
+				KID:        "plaintext",
+				TokenType:  jwt.TokenTypeAccess,
+				Algorithm:  config.SigningMethodHS256,
+				Material:   "secret",
+				PromotedAt: testutil.Ptr(time.Now()),
+			}},
+			assertErr: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, signingkey.ErrKeyNotEncrypted)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockSigningKeyRepository{}
+			repo.On("ListActive", mock.Anything).Return(tt.active, nil)
+
+			keyring := jwt.NewKeyring(jwt.NewHMACKey("access"))
+			service := newService(repo, keyring, jwt.NewKeyring(jwt.NewHMACKey("refresh")))
+
+			err := service.Sync(t.Context())
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			tt.assertRing(t, keyring)
+		})
+	}
+}
+
+func TestService_List(t *testing.T) {
+	t.Parallel()
+
+	current, currentStored := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, true)
+	_, otherStored := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, false)
+
+	tests := []struct {
+		name      string
+		setupMock func(repo *mocks.MockSigningKeyRepository)
+		setupCtx  func(ctx context.Context) context.Context
+		assertErr func(t *testing.T, err error)
+	}{
+		{
+			name:      "unauthorized due to missing principal",
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				assert.ErrorAs(t, err, &appErr)
+			},
+		},
+		{
+			name:      "forbidden for non admin",
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {},
+			setupCtx:  userCtx,
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				assert.ErrorAs(t, err, &appErr)
+			},
+		},
+		{
+			name: "repository error",
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {
+				repo.On("List", mock.Anything).Return(nil, errors.New("db down"))
+			},
+			setupCtx: adminCtx,
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.EqualError(t, appErr.Err, "db down")
+			},
+		},
+		{
+			name: "success",
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {
+				repo.On("List", mock.Anything).Return([]signingkey.SigningKey{currentStored, otherStored}, nil)
+			},
+			setupCtx: adminCtx,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockSigningKeyRepository{}
+			tt.setupMock(repo)
+
+			service := newService(repo, jwt.NewKeyring(current), jwt.NewKeyring(jwt.NewHMACKey("refresh")))
+
+			ctx := t.Context()
+			if tt.setupCtx != nil {
+				ctx = tt.setupCtx(ctx)
+			}
+
+			keys, err := service.List(ctx)
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+				assert.Nil(t, keys)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, keys, 2)
+				assert.True(t, keys[0].Current)
+				assert.False(t, keys[1].Current)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Create(t *testing.T) {
+	t.Parallel()
+
+	input := signingkey.CreateKeyInput{
+		TokenType: jwt.TokenTypeAccess,
+		Algorithm: config.SigningMethodEdDSA,
+	}
+
+	tests := []struct {
+		name      string
+		setupMock func(repo *mocks.MockSigningKeyRepository)
+		setupCtx  func(ctx context.Context) context.Context
+		assertErr func(t *testing.T, err error)
+	}{
+		{
+			name:      "forbidden for non admin",
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {},
+			setupCtx:  userCtx,
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				assert.ErrorAs(t, err, &appErr)
+			},
+		},
+		{
+			name: "repository error",
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {
+				repo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db down"))
+			},
+			setupCtx: adminCtx,
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.EqualError(t, appErr.Err, "db down")
+			},
+		},
+		{
+			name: "success",
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(k *signingkey.SigningKey) bool {
+					return k.KID != "" && k.Material != "" && k.Encrypted && k.PromotedAt == nil
+				})).Return(nil)
+				repo.On("ListActive", mock.Anything).Return(nil, nil)
+			},
+			setupCtx: adminCtx,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockSigningKeyRepository{}
+			tt.setupMock(repo)
+
+			service := newService(repo, jwt.NewKeyring(jwt.NewHMACKey("access")), jwt.NewKeyring(jwt.NewHMACKey("refresh")))
+
+			key, err := service.Create(tt.setupCtx(t.Context()), input)
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+				assert.Nil(t, key)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, input.TokenType, key.TokenType)
+				assert.Equal(t, input.Algorithm, key.Algorithm)
+				assert.False(t, key.Current)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Promote(t *testing.T) {
+	t.Parallel()
+
+	current, currentStored := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, true)
+	next, nextStored := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodEdDSA, false)
+	_, retiredStored := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, false)
+	retiredStored.RetiredAt = testutil.Ptr(time.Now())
+
+	currentStored.PromotedAt = testutil.Ptr(time.Now().Add(-time.Hour))
+	promotedStored := nextStored
+	promotedStored.PromotedAt = testutil.Ptr(time.Now())
+
+	tests := []struct {
+		name      string
+		kid       string
+		setupMock func(repo *mocks.MockSigningKeyRepository)
+		assertErr func(t *testing.T, err error)
+	}{
+		{
+			name: "key not found",
+			kid:  "missing",
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {
+				repo.On("GetByKID", mock.Anything, "missing").Return(nil, gorm.ErrRecordNotFound)
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, signingkey.MsgKeyNotFound, appErr.Message)
+			},
+		},
+		{
+			name: "retired key",
+			kid:  retiredStored.KID,
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {
+				repo.On("GetByKID", mock.Anything, retiredStored.KID).Return(testutil.Ptr(retiredStored), nil)
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, signingkey.MsgKeyRetired, appErr.Message)
+			},
+		},
+		{
+			name: "success",
+			kid:  next.ID,
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {
+				repo.On("GetByKID", mock.Anything, next.ID).Return(testutil.Ptr(nextStored), nil).Once()
+				repo.On("Promote", mock.Anything, next.ID).Return(nil)
+				repo.On("ListActive", mock.Anything).Return([]signingkey.SigningKey{promotedStored, currentStored}, nil)
+				repo.On("GetByKID", mock.Anything, next.ID).Return(testutil.Ptr(promotedStored), nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockSigningKeyRepository{}
+			tt.setupMock(repo)
+
+			keyring := jwt.NewKeyring(current)
+			service := newService(repo, keyring, jwt.NewKeyring(jwt.NewHMACKey("refresh")))
+
+			key, err := service.Promote(adminCtx(t.Context()), signingkey.KeyInput{KID: tt.kid})
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+				assert.Nil(t, key)
+			} else {
+				require.NoError(t, err)
+				assert.True(t, key.Current)
+				assert.Equal(t, next.ID, keyring.Current().ID)
+
+				_, ok := keyring.Lookup(current.ID)
+				assert.True(t, ok)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Retire(t *testing.T) {
+	t.Parallel()
+
+	current, currentStored := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, true)
+	old, oldStored := storedKey(t, jwt.TokenTypeAccess, config.SigningMethodHS256, true)
+	oldStored.PromotedAt = testutil.Ptr(time.Now().Add(-time.Hour))
+	retiredStored := oldStored
+	retiredStored.RetiredAt = testutil.Ptr(time.Now())
+
+	tests := []struct {
+		name      string
+		kid       string
+		setupMock func(repo *mocks.MockSigningKeyRepository)
+		assertErr func(t *testing.T, err error)
+	}{
+		{
+			name: "current key",
+			kid:  current.ID,
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {
+				repo.On("GetByKID", mock.Anything, current.ID).Return(testutil.Ptr(currentStored), nil)
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, signingkey.MsgRetireCurrentKey, appErr.Message)
+			},
+		},
+		{
+			name: "repository error",
+			kid:  old.ID,
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {
+				repo.On("GetByKID", mock.Anything, old.ID).Return(testutil.Ptr(oldStored), nil)
+				repo.On("Retire", mock.Anything, old.ID).Return(errors.New("db down"))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.EqualError(t, appErr.Err, "db down")
+			},
+		},
+		{
+			name: "success",
+			kid:  old.ID,
+			setupMock: func(repo *mocks.MockSigningKeyRepository) {
+				repo.On("GetByKID", mock.Anything, old.ID).Return(testutil.Ptr(oldStored), nil).Once()
+				repo.On("Retire", mock.Anything, old.ID).Return(nil)
+				repo.On("ListActive", mock.Anything).Return([]signingkey.SigningKey{currentStored}, nil)
+				repo.On("GetByKID", mock.Anything, old.ID).Return(testutil.Ptr(retiredStored), nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockSigningKeyRepository{}
+			tt.setupMock(repo)
+
+			keyring := jwt.NewKeyring(current)
+			keyring.Replace(current, []*jwt.SigningKey{current, old})
+			service := newService(repo, keyring, jwt.NewKeyring(jwt.NewHMACKey("refresh")))
+
+			key, err := service.Retire(adminCtx(t.Context()), signingkey.KeyInput{KID: tt.kid})
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+				assert.Nil(t, key)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, key.RetiredAt)
+				assert.False(t, key.Current)
+
+				_, ok := keyring.Lookup(old.ID)
+				assert.False(t, ok)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package user
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type EmailTokenRepository interface {
+	Create(ctx context.Context, token *EmailToken) error
+	Consume(ctx context.Context, tokenHash string) (*EmailToken, error)
+	InvalidateByUserID(ctx context.Context, userID uint) error
+	WithTx(tx *gorm.DB) EmailTokenRepository
+}
+
+type emailTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailTokenRepository(db *gorm.DB) EmailTokenRepository {
+	return &emailTokenRepository{db}
+}
+
+func (r *emailTokenRepository) WithTx(tx *gorm.DB) EmailTokenRepository {
+	return &emailTokenRepository{db: tx}
+}
+
+func (r *emailTokenRepository) Create(ctx context.Context, token *EmailToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// Consume marks the token as used and returns it, in a single statement so it can't be used twice.
+// Returns gorm.ErrRecordNotFound if the token is unknown, expired or already used.
+func (r *emailTokenRepository) Consume(ctx context.Context, tokenHash string) (*EmailToken, error) {
+	var tokens []EmailToken
+	err := r.db.
+		WithContext(ctx).
+		Raw(`UPDATE email_tokens SET used_at = NOW()
+			WHERE token_hash = ? AND used_at IS NULL AND expires_at > NOW()
+			RETURNING *`, tokenHash).
+		Scan(&tokens).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &tokens[0], nil
+}
+
+// InvalidateByUserID marks the remaining tokens of the user as used.
+func (r *emailTokenRepository) InvalidateByUserID(ctx context.Context, userID uint) error {
+	return r.db.
+		WithContext(ctx).
+		Model(&EmailToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", gorm.Expr("NOW()")).
+		Error
+}
@@ -0,0 +1,133 @@
+package user_test
+
+import (
+	"gomonitor/internal/domain/user"
+	databaseinfra "gomonitor/internal/infra/database"
+	"gomonitor/internal/testutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestEmailTokenRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := user.NewEmailTokenRepository(tx)
+
+	token := &user.EmailToken{
+		UserID:    1,
+		Email:     "test@test.com",
+		Purpose:   user.EmailTokenVerify,
+		TokenHash: strings.Repeat("a", 64),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	require.NoError(t, repo.Create(t.Context(), token))
+	assert.NotZero(t, token.ID)
+
+	assert.Error(t, repo.Create(testutil.GetCancelledCtx(t.Context()), &user.EmailToken{
+		UserID:    1,
+		Email:     "test@test.com",
+		Purpose:   user.EmailTokenVerify,
+		TokenHash: strings.Repeat("b", 64),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+}
+
+func TestEmailTokenRepository_Consume(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	hash := strings.Repeat("c", 64)
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		token       *user.EmailToken
+		expectError bool
+	}{
+		{
+			name: "consumes a valid token",
+			token: &user.EmailToken{
+				UserID: 7, Email: "new@test.com", Purpose: user.EmailTokenChange,
+				TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour),
+			},
+		},
+		{
+			name:        "unknown token",
+			expectError: true,
+		},
+		{
+			name: "expired token",
+			token: &user.EmailToken{
+				UserID: 7, Email: "new@test.com", Purpose: user.EmailTokenChange,
+				TokenHash: hash, ExpiresAt: time.Now().Add(-time.Minute),
+			},
+			expectError: true,
+		},
+		{
+			name: "used token",
+			token: &user.EmailToken{
+				UserID: 7, Email: "new@test.com", Purpose: user.EmailTokenChange,
+				TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt,
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := setupTx(t, db)
+			repo := user.NewEmailTokenRepository(tx)
+
+			if tt.token != nil {
+				require.NoError(t, tx.Create(tt.token).Error)
+			}
+
+			got, err := repo.Consume(t.Context(), hash)
+
+			if tt.expectError {
+				assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, uint(7), got.UserID)
+			assert.Equal(t, "new@test.com", got.Email)
+			assert.Equal(t, user.EmailTokenChange, got.Purpose)
+			assert.NotNil(t, got.UsedAt)
+
+			_, err = repo.Consume(t.Context(), hash)
+			assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		})
+	}
+}
+
+func TestEmailTokenRepository_InvalidateByUserID(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := user.NewEmailTokenRepository(tx)
+
+	tokens := []user.EmailToken{
+		{UserID: 3, Email: "a@test.com", Purpose: user.EmailTokenVerify, TokenHash: strings.Repeat("d", 64), ExpiresAt: time.Now().Add(time.Hour)},
+		{UserID: 4, Email: "b@test.com", Purpose: user.EmailTokenVerify, TokenHash: strings.Repeat("e", 64), ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	require.NoError(t, tx.Create(&tokens).Error)
+
+	require.NoError(t, repo.InvalidateByUserID(t.Context(), 3))
+
+	_, err := repo.Consume(t.Context(), strings.Repeat("d", 64))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	got, err := repo.Consume(t.Context(), strings.Repeat("e", 64))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), got.UserID)
+}
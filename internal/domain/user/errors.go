@@ -0,0 +1,10 @@
+package user
+
+var (
+	MsgEmailTaken        = "email already in use"
+	MsgInvalidEmailToken = "invalid or expired email token"
+	MsgInvalidPassword   = "invalid password"
+	MsgPasswordPolicy    = "password doesn't meet the policy"
+	MsgSameEmail         = "new email is the current one"
+	MsgUnknownRole       = "unknown role"
+)
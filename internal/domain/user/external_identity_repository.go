@@ -0,0 +1,51 @@
+package user
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type ExternalIdentityRepository interface {
+	GetBySubject(ctx context.Context, issuer, subject string) (*ExternalIdentity, error)
+	Create(ctx context.Context, identity *ExternalIdentity) error
+	Provision(ctx context.Context, user *User, identity *ExternalIdentity) error
+	WithTx(tx *gorm.DB) ExternalIdentityRepository
+}
+
+type externalIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewExternalIdentityRepository(db *gorm.DB) ExternalIdentityRepository {
+	return &externalIdentityRepository{db}
+}
+
+func (r *externalIdentityRepository) WithTx(tx *gorm.DB) ExternalIdentityRepository {
+	return &externalIdentityRepository{db: tx}
+}
+
+func (r *externalIdentityRepository) GetBySubject(ctx context.Context, issuer, subject string) (*ExternalIdentity, error) {
+	var identity ExternalIdentity
+	if err := r.db.WithContext(ctx).First(&identity, "issuer = ? AND subject = ?", issuer, subject).Error; err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (r *externalIdentityRepository) Create(ctx context.Context, identity *ExternalIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+// Provision creates the user along with its identity, neither exists without the other.
+func (r *externalIdentityRepository) Provision(ctx context.Context, user *User, identity *ExternalIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
@@ -0,0 +1,61 @@
+package user_test
+
+import (
+	"gomonitor/internal/domain/user"
+	databaseinfra "gomonitor/internal/infra/database"
+	"gomonitor/internal/testutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestExternalIdentityRepository_CreateAndGet(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := user.NewExternalIdentityRepository(tx)
+
+	identity := &user.ExternalIdentity{UserID: 1, Issuer: "https://idp.example.com", Subject: "sub", Email: "sso@test.com"}
+	require.NoError(t, repo.Create(t.Context(), identity))
+	assert.NotZero(t, identity.ID)
+
+	got, err := repo.GetBySubject(t.Context(), "https://idp.example.com", "sub")
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), got.UserID)
+
+	// Subjects are only unique per issuer.
+	_, err = repo.GetBySubject(t.Context(), "https://other.example.com", "sub")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	assert.Error(t, repo.Create(t.Context(), &user.ExternalIdentity{UserID: 2, Issuer: "https://idp.example.com", Subject: "sub", Email: "other@test.com"}))
+
+	_, err = repo.GetBySubject(testutil.GetCancelledCtx(t.Context()), "https://idp.example.com", "sub")
+	assert.Error(t, err)
+}
+
+func TestExternalIdentityRepository_Provision(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := user.NewExternalIdentityRepository(tx)
+	userRepo := user.NewUserRepository(tx)
+
+	u := &user.User{Name: "SSO", Email: "provisioned@test.com", Password: "hash", Role: "user"}
+	identity := &user.ExternalIdentity{Issuer: "https://idp.example.com", Subject: "new", Email: "provisioned@test.com"}
+
+	require.NoError(t, repo.Provision(t.Context(), u, identity))
+	assert.NotZero(t, u.ID)
+	assert.Equal(t, u.ID, identity.UserID)
+
+	stored, err := userRepo.GetByEmail(t.Context(), "provisioned@test.com")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, stored.ID)
+
+	// A taken email fails without leaving an identity behind.
+	duplicate := &user.User{Name: "SSO", Email: "provisioned@test.com", Password: "hash", Role: "user"}
+	assert.Error(t, repo.Provision(t.Context(), duplicate, &user.ExternalIdentity{Issuer: "https://idp.example.com", Subject: "other", Email: "provisioned@test.com"}))
+}
@@ -8,8 +8,32 @@ type CreateUserInput struct {
 	UserName string
 	Password string
 	Role     *identity.UserRole
+	// EmailVerified skips the verification mail, for emails already known to belong to the user.
+	EmailVerified bool
 }
 
 type GetUserInput struct {
 	ID uint
 }
+
+type RequestEmailVerificationInput struct {
+	Email string
+}
+
+// ChangeEmailInput changes the caller's email, Password is the current one.
+type ChangeEmailInput struct {
+	NewEmail string
+	Password string
+}
+
+type ConfirmEmailInput struct {
+	Token string
+}
+
+// CheckPasswordInput is checked against the password policy, Field names the request field in the violations.
+// The history is only checked for an existing user.
+type CheckPasswordInput struct {
+	Field    string
+	UserID   uint
+	Password string
+}
@@ -6,12 +6,64 @@ import (
 )
 
 type User struct {
-	ID        uint `gorm:"primaryKey"`
-	Name      string
-	UserName  string
-	Email     string            `gorm:"type:varchar(254);not null;uniqueIndex"`
-	Password  string            `gorm:"type:char(60);not null"`
-	Role      identity.UserRole `gorm:"type:user_role;not null;default:'user'"`
+	ID              uint `gorm:"primaryKey"`
+	Name            string
+	UserName        string
+	Email           string            `gorm:"type:varchar(254);not null;uniqueIndex"`
+	EmailVerifiedAt *time.Time        `gorm:"column:email_verified_at"`
+	Password        string            `gorm:"type:varchar(255);not null"`
+	Role            identity.UserRole `gorm:"type:varchar(50);not null;default:'user'"`
+	// LastLoginAt and LastLoginIP are recorded along the login events, shortly after the login.
+	LastLoginAt *time.Time `gorm:"column:last_login_at"`
+	LastLoginIP *string    `gorm:"type:varchar(45);column:last_login_ip"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// EmailTokenPurpose tells what confirming an EmailToken does.
+type EmailTokenPurpose string
+
+const (
+	// EmailTokenVerify verifies the current email of the user.
+	EmailTokenVerify EmailTokenPurpose = "verify"
+	// EmailTokenChange replaces the email of the user with the one of the token.
+	EmailTokenChange EmailTokenPurpose = "change"
+)
+
+// EmailToken is sent by mail to Email, only its hash is stored.
+type EmailToken struct {
+	ID        uint              `gorm:"primaryKey"`
+	UserID    uint              `gorm:"index;column:user_id"`
+	Email     string            `gorm:"type:varchar(254);not null"`
+	Purpose   EmailTokenPurpose `gorm:"type:varchar(16);not null"`
+	TokenHash string            `gorm:"type:char(64);uniqueIndex;column:token_hash"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// PasswordHistory keeps the previous password hashes of a user, so they can't be reused.
+type PasswordHistory struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"index;column:user_id"`
+	PasswordHash string `gorm:"type:varchar(255);not null;column:password_hash"`
+	CreatedAt    time.Time
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+// ExternalIdentity links a user to their account at an OpenID Connect provider, subjects are unique per issuer.
+type ExternalIdentity struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"index;column:user_id"`
+	Issuer    string `gorm:"type:varchar(255);not null;uniqueIndex:idx_external_identities_issuer_subject"`
+	Subject   string `gorm:"type:varchar(255);not null;uniqueIndex:idx_external_identities_issuer_subject"`
+	Email     string `gorm:"type:varchar(254);not null"`
 	CreatedAt time.Time
-	UpdatedAt time.Time
 }
@@ -0,0 +1,57 @@
+package user
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, entry *PasswordHistory) error
+	ListRecent(ctx context.Context, userID uint, limit int) ([]PasswordHistory, error)
+	Prune(ctx context.Context, userID uint, keep int) error
+	WithTx(tx *gorm.DB) PasswordHistoryRepository
+}
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db}
+}
+
+func (r *passwordHistoryRepository) WithTx(tx *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: tx}
+}
+
+func (r *passwordHistoryRepository) Create(ctx context.Context, entry *PasswordHistory) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// ListRecent returns the newest entries of the user first.
+func (r *passwordHistoryRepository) ListRecent(ctx context.Context, userID uint, limit int) ([]PasswordHistory, error) {
+	var entries []PasswordHistory
+	err := r.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&entries).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Prune deletes the entries of the user older than the newest keep ones.
+func (r *passwordHistoryRepository) Prune(ctx context.Context, userID uint, keep int) error {
+	return r.db.
+		WithContext(ctx).
+		Exec(`DELETE FROM password_history WHERE user_id = ? AND id NOT IN (
+				SELECT id FROM password_history WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?
+			)`, userID, userID, keep).
+		Error
+}
@@ -0,0 +1,66 @@
+package user_test
+
+import (
+	"fmt"
+	"gomonitor/internal/domain/user"
+	databaseinfra "gomonitor/internal/infra/database"
+	"gomonitor/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordHistoryRepository_Create(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := user.NewPasswordHistoryRepository(tx)
+
+	entry := &user.PasswordHistory{UserID: 1, PasswordHash: "hash"}
+
+	require.NoError(t, repo.Create(t.Context(), entry))
+	assert.NotZero(t, entry.ID)
+
+	assert.Error(t, repo.Create(testutil.GetCancelledCtx(t.Context()), &user.PasswordHistory{UserID: 1, PasswordHash: "hash"}))
+}
+
+func TestPasswordHistoryRepository_ListRecentAndPrune(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := user.NewPasswordHistoryRepository(tx)
+
+	start := time.Now().Add(-time.Hour)
+	for i := range 4 {
+		require.NoError(t, repo.Create(t.Context(), &user.PasswordHistory{
+			UserID:       42,
+			PasswordHash: fmt.Sprintf("hash-%d", i),
+			CreatedAt:    start.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+	require.NoError(t, repo.Create(t.Context(), &user.PasswordHistory{UserID: 43, PasswordHash: "other"}))
+
+	recent, err := repo.ListRecent(t.Context(), 42, 2)
+	require.NoError(t, err)
+	require.Len(t, recent, 2)
+	assert.Equal(t, "hash-3", recent[0].PasswordHash)
+	assert.Equal(t, "hash-2", recent[1].PasswordHash)
+
+	require.NoError(t, repo.Prune(t.Context(), 42, 3))
+
+	remaining, err := repo.ListRecent(t.Context(), 42, 10)
+	require.NoError(t, err)
+	require.Len(t, remaining, 3)
+	assert.Equal(t, "hash-1", remaining[2].PasswordHash)
+
+	other, err := repo.ListRecent(t.Context(), 43, 10)
+	require.NoError(t, err)
+	assert.Len(t, other, 1)
+
+	_, err = repo.ListRecent(testutil.GetCancelledCtx(t.Context()), 42, 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/password"
+)
+
+// PasswordPolicy checks new passwords against the configured rules and the previous passwords of the user.
+type PasswordPolicy interface {
+	// Check returns a validation error listing the violations of the password.
+	Check(ctx context.Context, input CheckPasswordInput) error
+	// Remember adds the hash to the history of the user, dropping the entries past the history size.
+	Remember(ctx context.Context, userID uint, hash string) error
+}
+
+type PasswordPolicyDeps struct {
+	Hasher      password.PasswordHasher
+	HistoryRepo PasswordHistoryRepository
+	HistorySize int
+	Rules       *password.Rules
+}
+
+type passwordPolicy struct {
+	hasher      password.PasswordHasher
+	historyRepo PasswordHistoryRepository
+	historySize int
+	rules       *password.Rules
+}
+
+func NewPasswordPolicy(deps *PasswordPolicyDeps) PasswordPolicy {
+	rules := deps.Rules
+	if rules == nil {
+		rules = &password.Rules{}
+	}
+
+	return &passwordPolicy{
+		hasher:      deps.Hasher,
+		historyRepo: deps.HistoryRepo,
+		historySize: deps.HistorySize,
+		rules:       rules,
+	}
+}
+
+func (p *passwordPolicy) Check(ctx context.Context, input CheckPasswordInput) error {
+	var fields []pkgerrors.FieldError
+	for _, violation := range p.rules.Validate(input.Password) {
+		fields = append(fields, pkgerrors.FieldError{
+			Field:   input.Field,
+			Code:    violation.Code,
+			Message: violation.Message,
+		})
+	}
+
+	// Every history entry costs a full hash verification, not worth it for a password refused already.
+	if len(fields) == 0 && input.UserID != 0 && p.historySize > 0 {
+		reused, err := p.reused(ctx, input.UserID, input.Password)
+		if err != nil {
+			return pkgerrors.NewInternalError(err)
+		}
+
+		if reused {
+			fields = append(fields, pkgerrors.FieldError{
+				Field:   input.Field,
+				Code:    password.ViolationReused,
+				Message: fmt.Sprintf("must differ from the last %d passwords", p.historySize),
+			})
+		}
+	}
+
+	if len(fields) > 0 {
+		return pkgerrors.NewValidationError(MsgPasswordPolicy, fields...)
+	}
+
+	return nil
+}
+
+func (p *passwordPolicy) reused(ctx context.Context, userID uint, plain string) (bool, error) {
+	entries, err := p.historyRepo.ListRecent(ctx, userID, p.historySize)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		if p.hasher.VerifyPassword(entry.PasswordHash, plain) == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (p *passwordPolicy) Remember(ctx context.Context, userID uint, hash string) error {
+	if p.historySize <= 0 {
+		return nil
+	}
+
+	if err := p.historyRepo.Create(ctx, &PasswordHistory{UserID: userID, PasswordHash: hash}); err != nil {
+		return err
+	}
+
+	return p.historyRepo.Prune(ctx, userID, p.historySize)
+}
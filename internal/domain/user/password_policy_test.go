@@ -0,0 +1,144 @@
+package user_test
+
+import (
+	"errors"
+	"gomonitor/internal/domain/user"
+	"gomonitor/internal/mocks"
+	pkgerrors "gomonitor/internal/pkg/errors"
+	"gomonitor/internal/pkg/password"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	t.Parallel()
+
+	rules := &password.Rules{MinLength: 8, MinClasses: 2}
+	history := []user.PasswordHistory{{PasswordHash: "newest"}, {PasswordHash: "oldest"}}
+
+	tests := []struct {
+		name       string
+		input      user.CheckPasswordInput
+		setupMocks func(repo *mocks.MockPasswordHistoryRepository, hasher *mocks.MockPasswordHasher)
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name:  "rule violations are listed for the field",
+			input: user.CheckPasswordInput{Field: "new_password", UserID: 1, Password: "short"},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusBadRequest, appErr.StatusCode)
+				assert.Equal(t, user.MsgPasswordPolicy, appErr.Message)
+				require.Len(t, appErr.Fields, 2)
+				assert.Equal(t, "new_password", appErr.Fields[0].Field)
+				assert.Equal(t, password.ViolationTooShort, appErr.Fields[0].Code)
+				assert.Equal(t, password.ViolationClasses, appErr.Fields[1].Code)
+			},
+		},
+		{
+			name:  "new users skip the history",
+			input: user.CheckPasswordInput{Field: "password", Password: "valid-password"},
+		},
+		{
+			name:  "reused password",
+			input: user.CheckPasswordInput{Field: "new_password", UserID: 1, Password: "valid-password"},
+			setupMocks: func(repo *mocks.MockPasswordHistoryRepository, hasher *mocks.MockPasswordHasher) {
+				repo.On("ListRecent", mock.Anything, uint(1), 3).Return(history, nil)
+				hasher.On("VerifyPassword", "newest", "valid-password").Return(password.ErrMismatchedHashAndPassword)
+				hasher.On("VerifyPassword", "oldest", "valid-password").Return(nil)
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				require.Len(t, appErr.Fields, 1)
+				assert.Equal(t, password.ViolationReused, appErr.Fields[0].Code)
+			},
+		},
+		{
+			name:  "new password",
+			input: user.CheckPasswordInput{Field: "new_password", UserID: 1, Password: "valid-password"},
+			setupMocks: func(repo *mocks.MockPasswordHistoryRepository, hasher *mocks.MockPasswordHasher) {
+				repo.On("ListRecent", mock.Anything, uint(1), 3).Return(history, nil)
+				hasher.On("VerifyPassword", mock.Anything, "valid-password").Return(password.ErrMismatchedHashAndPassword)
+			},
+		},
+		{
+			name:  "history error",
+			input: user.CheckPasswordInput{Field: "new_password", UserID: 1, Password: "valid-password"},
+			setupMocks: func(repo *mocks.MockPasswordHistoryRepository, hasher *mocks.MockPasswordHasher) {
+				repo.On("ListRecent", mock.Anything, uint(1), 3).Return(nil, errors.New("db error"))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockPasswordHistoryRepository{}
+			hasher := &mocks.MockPasswordHasher{}
+			if tt.setupMocks != nil {
+				tt.setupMocks(repo, hasher)
+			}
+
+			policy := user.NewPasswordPolicy(&user.PasswordPolicyDeps{
+				Hasher:      hasher,
+				HistoryRepo: repo,
+				HistorySize: 3,
+				Rules:       rules,
+			})
+
+			err := policy.Check(t.Context(), tt.input)
+			if tt.assertErr != nil {
+				tt.assertErr(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			hasher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPasswordPolicy_Remember(t *testing.T) {
+	t.Parallel()
+
+	t.Run("adds the hash and prunes the history", func(t *testing.T) {
+		repo := &mocks.MockPasswordHistoryRepository{}
+		repo.On("Create", mock.Anything, &user.PasswordHistory{UserID: 1, PasswordHash: "hash"}).Return(nil)
+		repo.On("Prune", mock.Anything, uint(1), 3).Return(nil)
+
+		policy := user.NewPasswordPolicy(&user.PasswordPolicyDeps{HistoryRepo: repo, HistorySize: 3})
+
+		require.NoError(t, policy.Remember(t.Context(), 1, "hash"))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("create error", func(t *testing.T) {
+		repo := &mocks.MockPasswordHistoryRepository{}
+		repo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db error"))
+
+		policy := user.NewPasswordPolicy(&user.PasswordPolicyDeps{HistoryRepo: repo, HistorySize: 3})
+
+		assert.Error(t, policy.Remember(t.Context(), 1, "hash"))
+		repo.AssertNotCalled(t, "Prune", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("disabled history stores nothing", func(t *testing.T) {
+		repo := &mocks.MockPasswordHistoryRepository{}
+
+		policy := user.NewPasswordPolicy(&user.PasswordPolicyDeps{HistoryRepo: repo})
+
+		require.NoError(t, policy.Remember(t.Context(), 1, "hash"))
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
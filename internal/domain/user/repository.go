@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,6 +12,9 @@ type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id uint) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	UpdatePassword(ctx context.Context, id uint, hash string) error
+	SetVerifiedEmail(ctx context.Context, id uint, email string) error
+	UpdateLastLogin(ctx context.Context, id uint, at time.Time, ip string) error
 	WithTx(tx *gorm.DB) UserRepository
 }
 
@@ -62,3 +66,46 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*User, e
 
 	return &usr, nil
 }
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	return r.updateByID(ctx, id, map[string]any{"password": hash})
+}
+
+// SetVerifiedEmail sets the email of the user as verified, replacing the current one on an email change.
+func (r *userRepository) SetVerifiedEmail(ctx context.Context, id uint, email string) error {
+	return r.updateByID(ctx, id, map[string]any{
+		"email":             email,
+		"email_verified_at": gorm.Expr("NOW()"),
+	})
+}
+
+// UpdateLastLogin records a login unless a later one is already recorded, logins are recorded asynchronously.
+// It doesn't touch updated_at, the profile didn't change.
+func (r *userRepository) UpdateLastLogin(ctx context.Context, id uint, at time.Time, ip string) error {
+	return r.db.
+		WithContext(ctx).
+		Model(&User{}).
+		Where("id = ? AND (last_login_at IS NULL OR last_login_at < ?)", id, at).
+		UpdateColumns(map[string]any{
+			"last_login_at": at,
+			"last_login_ip": ip,
+		}).Error
+}
+
+// updateByID returns gorm.ErrRecordNotFound if the user doesn't exist.
+func (r *userRepository) updateByID(ctx context.Context, id uint, values map[string]any) error {
+	result := r.db.
+		WithContext(ctx).
+		Model(&User{}).
+		Where("id = ?", id).
+		Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -7,9 +7,12 @@ import (
 	databaseinfra "gomonitor/internal/infra/database"
 	"gomonitor/internal/pkg/identity"
 	"gomonitor/internal/testutil"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -268,3 +271,166 @@ func TestRepository_GetByEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_UpdatePassword(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	newHash := strings.Repeat("h", 60)
+
+	tests := []struct {
+		name         string
+		setupFunc    func(db *gorm.DB) *user.User
+		idToUpdate   uint
+		expectError  bool
+		contextSetup func(ctx context.Context) context.Context
+	}{
+		{
+			name: "updates existing user",
+			setupFunc: func(db *gorm.DB) *user.User {
+				return testdata.SeedUser(t, db, 0)
+			},
+		},
+		{
+			name:        "returns error if user does not exist",
+			idToUpdate:  999,
+			expectError: true,
+		},
+		{
+			name:         "fails if context cancelled",
+			idToUpdate:   1,
+			expectError:  true,
+			contextSetup: testutil.GetCancelledCtx,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := setupTx(t, db)
+
+			if tt.setupFunc != nil {
+				tt.idToUpdate = tt.setupFunc(tx).ID
+			}
+
+			repo := user.NewUserRepository(tx)
+
+			ctx := t.Context()
+			if tt.contextSetup != nil {
+				ctx = tt.contextSetup(ctx)
+			}
+			err := repo.UpdatePassword(ctx, tt.idToUpdate, newHash)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			got, err := repo.GetByID(t.Context(), tt.idToUpdate)
+			assert.NoError(t, err)
+			assert.Equal(t, newHash, got.Password)
+		})
+	}
+}
+
+func TestRepository_SetVerifiedEmail(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tests := []struct {
+		name         string
+		setupFunc    func(db *gorm.DB) *user.User
+		email        string
+		expectError  bool
+		contextSetup func(ctx context.Context) context.Context
+	}{
+		{
+			name: "verifies the current email",
+			setupFunc: func(db *gorm.DB) *user.User {
+				return testdata.SeedUser(t, db, 0)
+			},
+		},
+		{
+			name: "replaces the email",
+			setupFunc: func(db *gorm.DB) *user.User {
+				return testdata.SeedUser(t, db, 0)
+			},
+			email: "changed@test.com",
+		},
+		{
+			name: "fails if the email belongs to another user",
+			setupFunc: func(db *gorm.DB) *user.User {
+				other := testdata.SeedUser(t, db, 1)
+				u := testdata.SeedUser(t, db, 2)
+				u.Email = other.Email
+				return u
+			},
+			expectError: true,
+		},
+		{
+			name:         "fails if context cancelled",
+			expectError:  true,
+			contextSetup: testutil.GetCancelledCtx,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := setupTx(t, db)
+
+			var id uint = 999
+			email := tt.email
+			if tt.setupFunc != nil {
+				seeded := tt.setupFunc(tx)
+				id = seeded.ID
+				if email == "" {
+					email = seeded.Email
+				}
+			}
+
+			repo := user.NewUserRepository(tx)
+
+			ctx := t.Context()
+			if tt.contextSetup != nil {
+				ctx = tt.contextSetup(ctx)
+			}
+			err := repo.SetVerifiedEmail(ctx, id, email)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			got, err := repo.GetByID(t.Context(), id)
+			assert.NoError(t, err)
+			assert.Equal(t, email, got.Email)
+			assert.True(t, got.EmailVerified())
+		})
+	}
+}
+
+func TestRepository_UpdateLastLogin(t *testing.T) {
+	t.Parallel()
+	db, _ := databaseinfra.New(t.Context(), testDbCfg)
+
+	tx := setupTx(t, db)
+	repo := user.NewUserRepository(tx)
+	seeded := testdata.SeedUser(t, tx, 0)
+
+	loginAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Microsecond)
+	require.NoError(t, repo.UpdateLastLogin(t.Context(), seeded.ID, loginAt, "192.0.2.1"))
+
+	// Recorded late, an earlier login doesn't replace the last one.
+	require.NoError(t, repo.UpdateLastLogin(t.Context(), seeded.ID, loginAt.Add(-time.Hour), "192.0.2.2"))
+
+	got, err := repo.GetByID(t.Context(), seeded.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastLoginAt)
+	assert.True(t, loginAt.Equal(*got.LastLoginAt))
+	assert.Equal(t, testutil.Ptr("192.0.2.1"), got.LastLoginIP)
+
+	assert.Error(t, repo.UpdateLastLogin(testutil.GetCancelledCtx(t.Context()), seeded.ID, loginAt, "192.0.2.1"))
+}
@@ -3,12 +3,20 @@ package user
 import (
 	"context"
 	"errors"
+	"fmt"
+	"gomonitor/internal/config"
+	"gomonitor/internal/domain/rbac"
 	"gomonitor/internal/infra/database/postgres"
 	"gomonitor/internal/observability/logging"
 	pkgerrors "gomonitor/internal/pkg/errors"
 	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/mailer"
+	"gomonitor/internal/pkg/opaquetoken"
 	"gomonitor/internal/pkg/password"
+	"gomonitor/internal/pkg/ratelimit"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
@@ -17,25 +25,48 @@ import (
 type Service interface {
 	CreateUser(ctx context.Context, input CreateUserInput) (*User, error)
 	GetUser(ctx context.Context, input GetUserInput) (*User, error)
+	CurrentUser(ctx context.Context) (*User, error)
+	RequestEmailVerification(ctx context.Context, input RequestEmailVerificationInput) error
+	ChangeEmail(ctx context.Context, input ChangeEmailInput) error
+	ConfirmEmail(ctx context.Context, input ConfirmEmailInput) error
 }
 
 type ServiceDeps struct {
-	Hasher   password.PasswordHasher
-	Logger   *slog.Logger
-	UserRepo UserRepository
+	AuthConfig     *config.AuthConfig
+	EmailTokenRepo EmailTokenRepository
+	Hasher         password.PasswordHasher
+	Logger         *slog.Logger
+	// LoginLockout is optional, without it wrong passwords on ChangeEmail aren't limited.
+	LoginLockout   ratelimit.Lockout
+	Mailer         mailer.Mailer
+	PasswordPolicy PasswordPolicy
+	RBAC           rbac.Service
+	UserRepo       UserRepository
 }
 
 type service struct {
-	hasher   password.PasswordHasher
-	logger   *slog.Logger
-	userRepo UserRepository
+	authCfg        *config.AuthConfig
+	emailTokenRepo EmailTokenRepository
+	hasher         password.PasswordHasher
+	logger         *slog.Logger
+	loginLockout   ratelimit.Lockout
+	mailer         mailer.Mailer
+	passwordPolicy PasswordPolicy
+	rbac           rbac.Service
+	userRepo       UserRepository
 }
 
 func NewService(deps *ServiceDeps) Service {
 	return &service{
-		logger:   deps.Logger,
-		hasher:   deps.Hasher,
-		userRepo: deps.UserRepo,
+		authCfg:        deps.AuthConfig,
+		emailTokenRepo: deps.EmailTokenRepo,
+		logger:         deps.Logger,
+		hasher:         deps.Hasher,
+		loginLockout:   deps.LoginLockout,
+		mailer:         deps.Mailer,
+		passwordPolicy: deps.PasswordPolicy,
+		rbac:           deps.RBAC,
+		userRepo:       deps.UserRepo,
 	}
 }
 
@@ -46,7 +77,7 @@ func (s *service) CreateUser(ctx context.Context, input CreateUserInput) (*User,
 		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
 	}
 
-	if principal.Role != identity.RoleAdmin {
+	if !principal.HasPermission(identity.PermUsersCreate) {
 		logging.FromContext(ctx).Warn("unauthorized user creation attempt",
 			"user_id", principal.UserID,
 			"user_role", principal.Role,
@@ -62,6 +93,17 @@ func (s *service) CreateUser(ctx context.Context, input CreateUserInput) (*User,
 		role = identity.RoleUser
 	}
 
+	if err := s.checkGrantable(ctx, principal, role); err != nil {
+		return nil, err
+	}
+
+	// Without a policy, like for the bootstrap admin, the password is trusted.
+	if s.passwordPolicy != nil {
+		if err := s.passwordPolicy.Check(ctx, CheckPasswordInput{Field: "password", Password: input.Password}); err != nil {
+			return nil, err
+		}
+	}
+
 	hashedPassword, err := s.hasher.HashPassword(input.Password)
 	if err != nil {
 		return nil, err
@@ -75,6 +117,11 @@ func (s *service) CreateUser(ctx context.Context, input CreateUserInput) (*User,
 		Role:     role,
 	}
 
+	if input.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
 	err = s.userRepo.Create(ctx, user)
 	if err != nil {
 		logging.FromContext(ctx).Error("failed to create user",
@@ -87,6 +134,9 @@ func (s *service) CreateUser(ctx context.Context, input CreateUserInput) (*User,
 		if errors.As(err, &pgErr) && pgErr.Code == postgres.UniqueViolation {
 			return nil, pkgerrors.NewConflictError("Duplicate entry", err)
 		}
+		if errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolation {
+			return nil, pkgerrors.NewBadRequestError(MsgUnknownRole, err)
+		}
 
 		return nil, err
 	}
@@ -97,10 +147,71 @@ func (s *service) CreateUser(ctx context.Context, input CreateUserInput) (*User,
 		"source", principal.Source,
 	)
 
+	if s.passwordPolicy != nil {
+		if err := s.passwordPolicy.Remember(ctx, user.ID, user.Password); err != nil {
+			logging.FromContext(ctx).Warn("failed to record password history",
+				"user_id", user.ID,
+				"error", err,
+			)
+		}
+	}
+
+	// The user exists already, they can ask for another link if this one fails.
+	if !user.EmailVerified() {
+		if err := s.sendEmailToken(ctx, user.ID, user.Email, EmailTokenVerify); err != nil {
+			logging.FromContext(ctx).Error("failed to send email verification",
+				"user_id", user.ID,
+				"error", err,
+			)
+		}
+	}
+
 	return user, nil
 }
 
+// checkGrantable refuses a role granting permissions the caller lacks, a user can't be created more
+// privileged than its creator. Without a resolver, like for the bootstrap admin, the role is trusted.
+func (s *service) checkGrantable(ctx context.Context, principal *identity.Principal, role identity.UserRole) error {
+	if s.rbac == nil {
+		return nil
+	}
+
+	permissions, err := s.rbac.Permissions(ctx, role)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if !principal.HasPermission(permission) {
+			logging.FromContext(ctx).Warn("creation of a more privileged user",
+				"user_id", principal.UserID,
+				"role", role,
+				"permission", permission,
+			)
+			return pkgerrors.NewForbiddenError()
+		}
+	}
+
+	return nil
+}
+
+// GetUser returns the principal's own user, other users require the users:read permission.
 func (s *service) GetUser(ctx context.Context, input GetUserInput) (*User, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Warn("unauthenticated user request")
+		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	if input.ID != principal.UserID && !principal.HasPermission(identity.PermUsersRead) {
+		logging.FromContext(ctx).Warn("unauthorized user request",
+			"user_id", principal.UserID,
+			"user_role", principal.Role,
+			"target_user_id", input.ID,
+			"source", principal.Source,
+		)
+		return nil, pkgerrors.NewForbiddenError()
+	}
+
 	user, err := s.userRepo.GetByID(ctx, input.ID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -111,3 +222,278 @@ func (s *service) GetUser(ctx context.Context, input GetUserInput) (*User, error
 
 	return user, nil
 }
+
+// CurrentUser returns the user of the caller, OAuth clients act for no user.
+func (s *service) CurrentUser(ctx context.Context) (*User, error) {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Warn("unauthenticated user request")
+		return nil, pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	if principal.Source == identity.AuthClient {
+		logging.FromContext(ctx).Warn("oauth client requested a user profile", "client_id", principal.ClientID)
+		return nil, pkgerrors.NewForbiddenError()
+	}
+
+	user, err := s.userRepo.GetByID(ctx, principal.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkgerrors.NewNotFoundError("User not found", err)
+		}
+		return nil, pkgerrors.NewInternalError(err)
+	}
+
+	return user, nil
+}
+
+// RequestEmailVerification mails a new verification link if the email belongs to an unverified user.
+// Other emails succeed the same way, so the response doesn't reveal which accounts exist.
+func (s *service) RequestEmailVerification(ctx context.Context, input RequestEmailVerificationInput) error {
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.FromContext(ctx).Info("email verification requested for unknown email", "email", input.Email)
+			return nil
+		}
+		return pkgerrors.NewInternalError(err)
+	}
+
+	if user.EmailVerified() {
+		return nil
+	}
+
+	if err := s.sendEmailToken(ctx, user.ID, user.Email, EmailTokenVerify); err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	return nil
+}
+
+// ChangeEmail mails a confirmation link to the new email, the current one is kept until it's confirmed.
+// Wrong passwords count towards the login lockout of the account.
+func (s *service) ChangeEmail(ctx context.Context, input ChangeEmailInput) error {
+	principal, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Warn("unauthenticated email change attempt")
+		return pkgerrors.NewUnauthorizedError("unauthenticated")
+	}
+
+	if principal.Impersonated() {
+		logging.FromContext(ctx).Warn("email change refused while impersonating")
+		return pkgerrors.NewForbiddenError()
+	}
+
+	user, err := s.userRepo.GetByID(ctx, principal.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pkgerrors.NewNotFoundError("User not found", err)
+		}
+		return pkgerrors.NewInternalError(err)
+	}
+
+	if err := s.verifyPassword(ctx, user, input.Password); err != nil {
+		return err
+	}
+
+	if strings.EqualFold(input.NewEmail, user.Email) {
+		return pkgerrors.NewBadRequestError(MsgSameEmail)
+	}
+
+	_, err = s.userRepo.GetByEmail(ctx, input.NewEmail)
+	if err == nil {
+		return pkgerrors.NewConflictError(MsgEmailTaken)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	if err := s.sendEmailToken(ctx, user.ID, input.NewEmail, EmailTokenChange); err != nil {
+		return pkgerrors.NewInternalError(err)
+	}
+
+	logging.FromContext(ctx).Info("email change requested", "user_id", user.ID)
+
+	return nil
+}
+
+// verifyPassword checks the password of the user under the login lockout, like ChangePassword does, so a
+// stolen session can't guess it. The password is verified even when locked to keep the same timing.
+func (s *service) verifyPassword(ctx context.Context, user *User, password string) error {
+	lockoutKey := strings.ToLower(strings.TrimSpace(user.Email))
+	locked := s.isLoginLocked(ctx, lockoutKey)
+
+	verifyErr := s.hasher.VerifyPassword(user.Password, password)
+
+	if locked {
+		logging.FromContext(ctx).Warn("email change on locked account", "user_id", user.ID)
+		return pkgerrors.NewUnauthorizedError(MsgInvalidPassword)
+	}
+
+	if verifyErr != nil {
+		logging.FromContext(ctx).Warn("email change with invalid password", "user_id", user.ID)
+		s.loginFailed(ctx, lockoutKey)
+		return pkgerrors.NewUnauthorizedError(MsgInvalidPassword)
+	}
+
+	s.resetLoginFailures(ctx, lockoutKey)
+
+	return nil
+}
+
+// isLoginLocked fails open like the login, an unavailable lockout doesn't lock users out.
+func (s *service) isLoginLocked(ctx context.Context, key string) bool {
+	if s.loginLockout == nil {
+		return false
+	}
+
+	remaining, err := s.loginLockout.Locked(ctx, key)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to check login lockout", slog.Any("err", err))
+		return false
+	}
+
+	return remaining > 0
+}
+
+func (s *service) loginFailed(ctx context.Context, key string) {
+	if s.loginLockout == nil {
+		return
+	}
+
+	lock, err := s.loginLockout.Fail(ctx, key)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to record login failure", slog.Any("err", err))
+		return
+	}
+
+	if lock > 0 {
+		logging.FromContext(ctx).Warn("account login locked", slog.Any("email", key), slog.Duration("lock", lock))
+	}
+}
+
+func (s *service) resetLoginFailures(ctx context.Context, key string) {
+	if s.loginLockout == nil {
+		return
+	}
+
+	if err := s.loginLockout.Reset(ctx, key); err != nil {
+		logging.FromContext(ctx).Warn("failed to reset login failures", slog.Any("err", err))
+	}
+}
+
+// ConfirmEmail consumes a link sent by CreateUser, RequestEmailVerification or ChangeEmail.
+// An email change takes effect now, and the previous email is notified.
+func (s *service) ConfirmEmail(ctx context.Context, input ConfirmEmailInput) error {
+	token, err := s.emailTokenRepo.Consume(ctx, opaquetoken.Hash(input.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.FromContext(ctx).Warn("invalid email token")
+			return pkgerrors.NewBadRequestError(MsgInvalidEmailToken)
+		}
+		return pkgerrors.NewInternalError(err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pkgerrors.NewBadRequestError(MsgInvalidEmailToken)
+		}
+		return pkgerrors.NewInternalError(err)
+	}
+
+	switch token.Purpose {
+	case EmailTokenVerify:
+		// The email was changed after the link was sent.
+		if !strings.EqualFold(token.Email, user.Email) {
+			return pkgerrors.NewBadRequestError(MsgInvalidEmailToken)
+		}
+
+		if err := s.userRepo.SetVerifiedEmail(ctx, user.ID, user.Email); err != nil {
+			return pkgerrors.NewInternalError(err)
+		}
+
+		logging.FromContext(ctx).Info("email verified", "user_id", user.ID)
+
+	case EmailTokenChange:
+		if err := s.userRepo.SetVerifiedEmail(ctx, user.ID, token.Email); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == postgres.UniqueViolation {
+				return pkgerrors.NewConflictError(MsgEmailTaken, err)
+			}
+			return pkgerrors.NewInternalError(err)
+		}
+
+		// Links sent to the previous email or to other requested ones are no longer valid.
+		if err := s.emailTokenRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+			logging.FromContext(ctx).Warn("failed to invalidate email tokens", "error", err)
+		}
+
+		s.sendMail(ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Your email was changed",
+			Body: fmt.Sprintf(
+				"The email of your account was changed to %s.\n\n"+
+					"If you didn't make this change, reset your password and contact an administrator.\n",
+				token.Email,
+			),
+		})
+
+		logging.FromContext(ctx).Info("email changed", "user_id", user.ID)
+
+	default:
+		return pkgerrors.NewBadRequestError(MsgInvalidEmailToken)
+	}
+
+	return nil
+}
+
+// sendEmailToken stores a new token for the email and mails its link there.
+func (s *service) sendEmailToken(ctx context.Context, userID uint, email string, purpose EmailTokenPurpose) error {
+	token, err := opaquetoken.New()
+	if err != nil {
+		return err
+	}
+
+	link, err := opaquetoken.Link(s.authCfg.EmailVerificationURL, token)
+	if err != nil {
+		return err
+	}
+
+	emailToken := &EmailToken{
+		UserID:    userID,
+		Email:     email,
+		Purpose:   purpose,
+		TokenHash: opaquetoken.Hash(token),
+		ExpiresAt: time.Now().Add(s.authCfg.EmailVerificationTTL),
+	}
+
+	if err := s.emailTokenRepo.Create(ctx, emailToken); err != nil {
+		return err
+	}
+
+	subject, intro := "Verify your email", "Follow this link to verify your email"
+	if purpose == EmailTokenChange {
+		subject, intro = "Confirm your new email", "Follow this link to use this email for your account"
+	}
+
+	s.sendMail(ctx, mailer.Message{
+		To:      email,
+		Subject: subject,
+		Body: fmt.Sprintf(
+			"%s, it expires in %s:\n%s\n\nIf you didn't request it, you can ignore this email.\n",
+			intro, s.authCfg.EmailVerificationTTL, link,
+		),
+	})
+
+	return nil
+}
+
+// sendMail doesn't wait for the delivery, so the response time doesn't depend on it.
+func (s *service) sendMail(ctx context.Context, msg mailer.Message) {
+	go func(ctx context.Context) {
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			logging.FromContext(ctx).Error("failed to send mail", "subject", msg.Subject, "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+}
@@ -3,20 +3,26 @@ package user_test
 import (
 	"context"
 	"errors"
+	"gomonitor/internal/config"
 	"gomonitor/internal/domain/user"
 	"gomonitor/internal/infra/database/postgres"
 	"gomonitor/internal/mocks"
 	pkgerrors "gomonitor/internal/pkg/errors"
 	"gomonitor/internal/pkg/identity"
+	"gomonitor/internal/pkg/mailer"
+	"gomonitor/internal/pkg/opaquetoken"
 	"gomonitor/internal/pkg/password"
 	"gomonitor/internal/testutil"
 	"log/slog"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -33,6 +39,24 @@ func TestNewService(t *testing.T) {
 	assert.NotNil(t, service)
 }
 
+var emailAuthConfig = &config.AuthConfig{
+	EmailVerificationTTL: 24 * time.Hour,
+	EmailVerificationURL: "https://example.com/verify-email",
+}
+
+// receiveMail waits for a mail sent in the background.
+func receiveMail(t *testing.T, sent chan mailer.Message) mailer.Message {
+	t.Helper()
+
+	select {
+	case msg := <-sent:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("mail not sent")
+		return mailer.Message{}
+	}
+}
+
 // Match only the user email on the returned user, matching whole struct becomes cumbersome due to hashing and timestamps.
 func matchUserEmail(email string) any {
 	return mock.MatchedBy(func(u *user.User) bool {
@@ -58,9 +82,10 @@ func TestService_CreateUser(t *testing.T) {
 
 	adminCtx := func(ctx context.Context) context.Context {
 		return identity.WithPrincipal(ctx, &identity.Principal{
-			UserID: 1,
-			Role:   identity.RoleAdmin,
-			Source: identity.AuthInternal,
+			UserID:      1,
+			Role:        identity.RoleAdmin,
+			Permissions: []identity.Permission{identity.PermUsersCreate},
+			Source:      identity.AuthInternal,
 		})
 	}
 
@@ -73,12 +98,13 @@ func TestService_CreateUser(t *testing.T) {
 	}
 
 	tests := []struct {
-		name      string
-		input     user.CreateUserInput
-		setupMock func(repo *mocks.MockUserRepository)
-		setupCtx  func(ctx context.Context) context.Context
-		expected  *user.User
-		assertErr func(t *testing.T, err error)
+		name       string
+		input      user.CreateUserInput
+		setupMock  func(repo *mocks.MockUserRepository)
+		setupCtx   func(ctx context.Context) context.Context
+		expected   *user.User
+		expectMail bool
+		assertErr  func(t *testing.T, err error)
 	}{
 		{
 			name:      "unauthorized due to missing principal",
@@ -90,7 +116,7 @@ func TestService_CreateUser(t *testing.T) {
 			},
 		},
 		{
-			name:      "unauthorized due to user without admin role",
+			name:      "unauthorized due to user without permission",
 			input:     defaultInput,
 			setupMock: func(repo *mocks.MockUserRepository) {},
 			assertErr: func(t *testing.T, err error) {
@@ -128,6 +154,22 @@ func TestService_CreateUser(t *testing.T) {
 			},
 			setupCtx: adminCtx,
 		},
+		{
+			name:  "unknown role",
+			input: defaultInput,
+			setupMock: func(repo *mocks.MockUserRepository) {
+				repo.
+					On("Create", mock.Anything, matchUserEmail(defaultInput.Email)).
+					Return(&pgconn.PgError{Code: postgres.ForeignKeyViolation})
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusBadRequest, appErr.StatusCode)
+				assert.Equal(t, user.MsgUnknownRole, appErr.Message)
+			},
+			setupCtx: adminCtx,
+		},
 		{
 			name:  "random database error",
 			input: defaultInput,
@@ -150,6 +192,24 @@ func TestService_CreateUser(t *testing.T) {
 					On("Create", mock.Anything, matchUserEmail(newAdminInput.Email)).
 					Return(nil)
 			},
+			setupCtx:   adminCtx,
+			expected:   &user.User{Email: newAdminInput.Email},
+			expectMail: true,
+		},
+		{
+			name: "verified email skips the verification mail",
+			input: func() user.CreateUserInput {
+				input := newAdminInput
+				input.EmailVerified = true
+				return input
+			}(),
+			setupMock: func(repo *mocks.MockUserRepository) {
+				repo.
+					On("Create", mock.Anything, mock.MatchedBy(func(u *user.User) bool {
+						return u.Email == newAdminInput.Email && u.EmailVerified()
+					})).
+					Return(nil)
+			},
 			setupCtx: adminCtx,
 			expected: &user.User{Email: newAdminInput.Email},
 		},
@@ -161,9 +221,24 @@ func TestService_CreateUser(t *testing.T) {
 			repo := &mocks.MockUserRepository{}
 			tt.setupMock(repo)
 
+			tokenRepo := &mocks.MockEmailTokenRepository{}
+			mailSender := &mocks.MockMailer{}
+			sent := make(chan mailer.Message, 1)
+			if tt.expectMail {
+				tokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *user.EmailToken) bool {
+					return token.Email == tt.input.Email && token.Purpose == user.EmailTokenVerify
+				})).Return(nil)
+				mailSender.On("Send", mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) { sent <- args.Get(1).(mailer.Message) }).
+					Return(nil)
+			}
+
 			svcDeps := &user.ServiceDeps{
-				Hasher:   password.NewPasswordHasher(bcrypt.DefaultCost),
-				UserRepo: repo,
+				AuthConfig:     emailAuthConfig,
+				EmailTokenRepo: tokenRepo,
+				Hasher:         password.NewPasswordHasher(bcrypt.DefaultCost),
+				Mailer:         mailSender,
+				UserRepo:       repo,
 			}
 			service := user.NewService(svcDeps)
 
@@ -174,6 +249,14 @@ func TestService_CreateUser(t *testing.T) {
 
 			result, err := service.CreateUser(ctx, tt.input)
 
+			if tt.expectMail {
+				msg := receiveMail(t, sent)
+				assert.Equal(t, tt.input.Email, msg.To)
+				assert.Contains(t, msg.Body, "https://example.com/verify-email?token=")
+			}
+			tokenRepo.AssertExpectations(t)
+			mailSender.AssertExpectations(t)
+
 			if tt.assertErr != nil {
 				assert.Error(t, err)
 				tt.assertErr(t, err)
@@ -186,12 +269,184 @@ func TestService_CreateUser(t *testing.T) {
 		})
 	}
 }
+func TestService_CreateUserPasswordPolicy(t *testing.T) {
+	t.Parallel()
+
+	input := user.CreateUserInput{
+		Name:          "test1",
+		Email:         "test@test.com",
+		UserName:      "test1",
+		Password:      "password123",
+		EmailVerified: true,
+	}
+	adminCtx := identity.WithPrincipal(t.Context(), &identity.Principal{
+		UserID:      1,
+		Role:        identity.RoleAdmin,
+		Permissions: []identity.Permission{identity.PermUsersCreate},
+	})
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.MockUserRepository, policy *mocks.MockPasswordPolicy)
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name: "refused password creates nothing",
+			setupMocks: func(repo *mocks.MockUserRepository, policy *mocks.MockPasswordPolicy) {
+				policy.On("Check", mock.Anything, user.CheckPasswordInput{Field: "password", Password: input.Password}).
+					Return(pkgerrors.NewValidationError(user.MsgPasswordPolicy, pkgerrors.FieldError{Field: "password", Code: "breached"}))
+			},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusBadRequest, appErr.StatusCode)
+				assert.Equal(t, "password", appErr.Fields[0].Field)
+			},
+		},
+		{
+			name: "accepted password starts the history",
+			setupMocks: func(repo *mocks.MockUserRepository, policy *mocks.MockPasswordPolicy) {
+				policy.On("Check", mock.Anything, mock.Anything).Return(nil)
+				repo.On("Create", mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) { args.Get(1).(*user.User).ID = 5 }).
+					Return(nil)
+				policy.On("Remember", mock.Anything, uint(5), mock.AnythingOfType("string")).Return(nil)
+			},
+		},
+		{
+			name: "history error doesn't fail the creation",
+			setupMocks: func(repo *mocks.MockUserRepository, policy *mocks.MockPasswordPolicy) {
+				policy.On("Check", mock.Anything, mock.Anything).Return(nil)
+				repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+				policy.On("Remember", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockUserRepository{}
+			policy := &mocks.MockPasswordPolicy{}
+			tt.setupMocks(repo, policy)
+
+			service := user.NewService(&user.ServiceDeps{
+				AuthConfig:     emailAuthConfig,
+				Hasher:         password.NewPasswordHasher(bcrypt.MinCost),
+				PasswordPolicy: policy,
+				UserRepo:       repo,
+			})
+
+			result, err := service.CreateUser(adminCtx, input)
+
+			if tt.assertErr != nil {
+				tt.assertErr(t, err)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, input.Email, result.Email)
+			}
+
+			repo.AssertExpectations(t)
+			policy.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_CreateUserRolePermissions(t *testing.T) {
+	t.Parallel()
+
+	input := user.CreateUserInput{
+		Name:          "test1",
+		Email:         "test@test.com",
+		UserName:      "test1",
+		Password:      "password123",
+		Role:          testutil.Ptr(identity.RoleAdmin),
+		EmailVerified: true,
+	}
+	creatorCtx := identity.WithPrincipal(t.Context(), &identity.Principal{
+		UserID:      2,
+		Role:        identity.RoleModerator,
+		Permissions: []identity.Permission{identity.PermUsersCreate, identity.PermUsersRead},
+		Source:      identity.AuthExternal,
+	})
+
+	tests := []struct {
+		name        string
+		permissions []identity.Permission
+		setupMock   func(repo *mocks.MockUserRepository)
+		status      int
+	}{
+		{
+			name:        "role with permissions the creator lacks",
+			permissions: []identity.Permission{identity.PermUsersCreate, identity.PermUsersImpersonate},
+			setupMock:   func(repo *mocks.MockUserRepository) {},
+			status:      http.StatusForbidden,
+		},
+		{
+			name:        "role within the permissions of the creator",
+			permissions: []identity.Permission{identity.PermUsersRead},
+			setupMock: func(repo *mocks.MockUserRepository) {
+				repo.On("Create", mock.Anything, matchUserEmail(input.Email)).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockUserRepository{}
+			rbac := &mocks.MockRBACService{}
+			tt.setupMock(repo)
+			rbac.On("Permissions", mock.Anything, identity.RoleAdmin).Return(tt.permissions, nil)
+
+			service := user.NewService(&user.ServiceDeps{
+				AuthConfig: emailAuthConfig,
+				Hasher:     password.NewPasswordHasher(bcrypt.MinCost),
+				RBAC:       rbac,
+				UserRepo:   repo,
+			})
+
+			result, err := service.CreateUser(creatorCtx, input)
+
+			if tt.status != 0 {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.status, appErr.StatusCode)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, identity.RoleAdmin, result.Role)
+			}
+
+			repo.AssertExpectations(t)
+			rbac.AssertExpectations(t)
+		})
+	}
+}
+
 func TestService_GetUser(t *testing.T) {
 	t.Parallel()
 
+	selfCtx := func(ctx context.Context) context.Context {
+		return identity.WithPrincipal(ctx, &identity.Principal{
+			UserID: 1,
+			Role:   identity.RoleUser,
+			Source: identity.AuthExternal,
+		})
+	}
+
+	moderatorCtx := func(ctx context.Context) context.Context {
+		return identity.WithPrincipal(ctx, &identity.Principal{
+			UserID:      10,
+			Role:        identity.RoleModerator,
+			Permissions: []identity.Permission{identity.PermUsersRead},
+			Source:      identity.AuthExternal,
+		})
+	}
+
 	tests := []struct {
 		name      string
 		input     user.GetUserInput
+		setupCtx  func(ctx context.Context) context.Context
 		setupMock func(repo *mocks.MockUserRepository)
 		expected  *user.User
 		assertErr func(t *testing.T, err error)
@@ -201,6 +456,7 @@ func TestService_GetUser(t *testing.T) {
 			input: user.GetUserInput{
 				ID: 1,
 			},
+			setupCtx: selfCtx,
 			setupMock: func(repo *mocks.MockUserRepository) {
 				repo.
 					On("GetByID", mock.Anything, uint(1)).
@@ -211,11 +467,51 @@ func TestService_GetUser(t *testing.T) {
 			},
 			expected: &user.User{ID: 1, Email: "test@test.com"},
 		},
+		{
+			name: "other user with permission",
+			input: user.GetUserInput{
+				ID: 1,
+			},
+			setupCtx: moderatorCtx,
+			setupMock: func(repo *mocks.MockUserRepository) {
+				repo.
+					On("GetByID", mock.Anything, uint(1)).
+					Return(testutil.Ok(&user.User{ID: 1}))
+			},
+			expected: &user.User{ID: 1},
+		},
+		{
+			name: "other user without permission",
+			input: user.GetUserInput{
+				ID: 2,
+			},
+			setupCtx:  selfCtx,
+			setupMock: func(repo *mocks.MockUserRepository) {},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusForbidden, appErr.StatusCode)
+			},
+		},
+		{
+			name: "unauthenticated",
+			input: user.GetUserInput{
+				ID: 1,
+			},
+			setupCtx:  func(ctx context.Context) context.Context { return ctx },
+			setupMock: func(repo *mocks.MockUserRepository) {},
+			assertErr: func(t *testing.T, err error) {
+				var appErr *pkgerrors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, http.StatusUnauthorized, appErr.StatusCode)
+			},
+		},
 		{
 			name: "user not found",
 			input: user.GetUserInput{
 				ID: 2,
 			},
+			setupCtx: moderatorCtx,
 			setupMock: func(repo *mocks.MockUserRepository) {
 				repo.
 					On("GetByID", mock.Anything, uint(2)).
@@ -231,6 +527,7 @@ func TestService_GetUser(t *testing.T) {
 			input: user.GetUserInput{
 				ID: 3,
 			},
+			setupCtx: moderatorCtx,
 			setupMock: func(repo *mocks.MockUserRepository) {
 				repo.
 					On("GetByID", mock.Anything, uint(3)).
@@ -254,7 +551,7 @@ func TestService_GetUser(t *testing.T) {
 			}
 			service := user.NewService(svcDeps)
 
-			result, err := service.GetUser(t.Context(), tt.input)
+			result, err := service.GetUser(tt.setupCtx(t.Context()), tt.input)
 
 			if tt.assertErr != nil {
 				assert.Error(t, err)
@@ -269,3 +566,442 @@ func TestService_GetUser(t *testing.T) {
 		})
 	}
 }
+
+func TestService_CurrentUser(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		principal *identity.Principal
+		setupMock func(repo *mocks.MockUserRepository)
+		expected  *user.User
+		assertErr func(t *testing.T, err error)
+	}{
+		{
+			name:      "success",
+			principal: &identity.Principal{UserID: 1, Role: identity.RoleUser, Source: identity.AuthExternal},
+			setupMock: func(repo *mocks.MockUserRepository) {
+				repo.On("GetByID", mock.Anything, uint(1)).Return(testutil.Ok(&user.User{ID: 1}))
+			},
+			expected: &user.User{ID: 1},
+		},
+		{
+			name:      "unauthenticated",
+			setupMock: func(repo *mocks.MockUserRepository) {},
+			assertErr: assertStatus(t, http.StatusUnauthorized),
+		},
+		{
+			name:      "oauth client",
+			principal: &identity.Principal{ClientID: "client", Source: identity.AuthClient},
+			setupMock: func(repo *mocks.MockUserRepository) {},
+			assertErr: assertStatus(t, http.StatusForbidden),
+		},
+		{
+			name:      "deleted user",
+			principal: &identity.Principal{UserID: 1, Role: identity.RoleUser, Source: identity.AuthExternal},
+			setupMock: func(repo *mocks.MockUserRepository) {
+				repo.On("GetByID", mock.Anything, uint(1)).Return(testutil.Err[*user.User](gorm.ErrRecordNotFound))
+			},
+			assertErr: assertStatus(t, http.StatusNotFound),
+		},
+		{
+			name:      "repository error",
+			principal: &identity.Principal{UserID: 1, Role: identity.RoleUser, Source: identity.AuthExternal},
+			setupMock: func(repo *mocks.MockUserRepository) {
+				repo.On("GetByID", mock.Anything, uint(1)).Return(nil, errors.New("db down"))
+			},
+			assertErr: assertStatus(t, http.StatusInternalServerError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.MockUserRepository{}
+			tt.setupMock(repo)
+
+			service := user.NewService(&user.ServiceDeps{Logger: slog.Default(), UserRepo: repo})
+
+			ctx := t.Context()
+			if tt.principal != nil {
+				ctx = identity.WithPrincipal(ctx, tt.principal)
+			}
+
+			result, err := service.CurrentUser(ctx)
+
+			if tt.assertErr != nil {
+				tt.assertErr(t, err)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+type emailMocks struct {
+	hasher    *mocks.MockPasswordHasher
+	lockout   *mocks.MockLockout
+	mailer    *mocks.MockMailer
+	tokenRepo *mocks.MockEmailTokenRepository
+	userRepo  *mocks.MockUserRepository
+	sent      chan mailer.Message
+}
+
+func newEmailMocks() *emailMocks {
+	return &emailMocks{
+		hasher:    &mocks.MockPasswordHasher{},
+		lockout:   &mocks.MockLockout{},
+		mailer:    &mocks.MockMailer{},
+		tokenRepo: &mocks.MockEmailTokenRepository{},
+		userRepo:  &mocks.MockUserRepository{},
+		sent:      make(chan mailer.Message, 1),
+	}
+}
+
+func (m *emailMocks) service() user.Service {
+	return user.NewService(&user.ServiceDeps{
+		AuthConfig:     emailAuthConfig,
+		EmailTokenRepo: m.tokenRepo,
+		Hasher:         m.hasher,
+		Logger:         slog.Default(),
+		LoginLockout:   m.lockout,
+		Mailer:         m.mailer,
+		UserRepo:       m.userRepo,
+	})
+}
+
+func (m *emailMocks) expectMail() {
+	m.mailer.On("Send", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { m.sent <- args.Get(1).(mailer.Message) }).
+		Return(nil)
+}
+
+func (m *emailMocks) assertExpectations(t *testing.T) {
+	m.hasher.AssertExpectations(t)
+	m.lockout.AssertExpectations(t)
+	m.mailer.AssertExpectations(t)
+	m.tokenRepo.AssertExpectations(t)
+	m.userRepo.AssertExpectations(t)
+}
+
+func assertStatus(t *testing.T, status int) func(t *testing.T, err error) {
+	return func(t *testing.T, err error) {
+		var appErr *pkgerrors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, status, appErr.StatusCode)
+	}
+}
+
+func TestService_RequestEmailVerification(t *testing.T) {
+	t.Parallel()
+
+	verifiedAt := time.Now()
+	unverified := &user.User{ID: 1, Email: "test@test.com"}
+
+	tests := []struct {
+		name       string
+		setupMocks func(m *emailMocks)
+		expectMail bool
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name: "unknown email doesn't fail",
+			setupMocks: func(m *emailMocks) {
+				m.userRepo.On("GetByEmail", mock.Anything, unverified.Email).Return(nil, gorm.ErrRecordNotFound)
+			},
+		},
+		{
+			name: "verified email isn't sent again",
+			setupMocks: func(m *emailMocks) {
+				m.userRepo.On("GetByEmail", mock.Anything, unverified.Email).
+					Return(&user.User{ID: 1, Email: unverified.Email, EmailVerifiedAt: &verifiedAt}, nil)
+			},
+		},
+		{
+			name: "db error",
+			setupMocks: func(m *emailMocks) {
+				m.userRepo.On("GetByEmail", mock.Anything, unverified.Email).Return(nil, errors.New("db error"))
+			},
+			assertErr: assertStatus(t, http.StatusInternalServerError),
+		},
+		{
+			name: "token storage error",
+			setupMocks: func(m *emailMocks) {
+				m.userRepo.On("GetByEmail", mock.Anything, unverified.Email).Return(unverified, nil)
+				m.tokenRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			assertErr: assertStatus(t, http.StatusInternalServerError),
+		},
+		{
+			name: "sends the link",
+			setupMocks: func(m *emailMocks) {
+				m.userRepo.On("GetByEmail", mock.Anything, unverified.Email).Return(unverified, nil)
+				m.tokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *user.EmailToken) bool {
+					return token.UserID == unverified.ID && token.Email == unverified.Email &&
+						token.Purpose == user.EmailTokenVerify && len(token.TokenHash) == 64 &&
+						time.Until(token.ExpiresAt) > 23*time.Hour
+				})).Return(nil)
+				m.expectMail()
+			},
+			expectMail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newEmailMocks()
+			tt.setupMocks(m)
+
+			err := m.service().RequestEmailVerification(t.Context(), user.RequestEmailVerificationInput{Email: unverified.Email})
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tt.expectMail {
+				msg := receiveMail(t, m.sent)
+				assert.Equal(t, unverified.Email, msg.To)
+
+				// The mailed token is the one stored hashed.
+				stored := m.tokenRepo.Calls[0].Arguments.Get(1).(*user.EmailToken)
+				token := msg.Body[strings.Index(msg.Body, "token=")+len("token="):]
+				token = token[:strings.IndexByte(token, '\n')]
+				assert.Equal(t, opaquetoken.Hash(token), stored.TokenHash)
+			}
+
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestService_ChangeEmail(t *testing.T) {
+	t.Parallel()
+
+	current := &user.User{ID: 1, Email: "old@test.com", Password: "hash"}
+	input := user.ChangeEmailInput{NewEmail: "new@test.com", Password: "password123"}
+
+	userCtx := func(ctx context.Context) context.Context {
+		return identity.WithPrincipal(ctx, &identity.Principal{
+			UserID: current.ID,
+			Role:   identity.RoleUser,
+			Source: identity.AuthExternal,
+		})
+	}
+
+	tests := []struct {
+		name       string
+		input      user.ChangeEmailInput
+		setupCtx   func(ctx context.Context) context.Context
+		setupMocks func(m *emailMocks)
+		expectMail bool
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name:       "no principal",
+			input:      input,
+			setupMocks: func(m *emailMocks) {},
+			assertErr:  assertStatus(t, http.StatusUnauthorized),
+		},
+		{
+			name:  "impersonated",
+			input: input,
+			setupCtx: func(ctx context.Context) context.Context {
+				return identity.WithPrincipal(ctx, &identity.Principal{
+					UserID:  current.ID,
+					Role:    identity.RoleUser,
+					Source:  identity.AuthExternal,
+					ActorID: 9,
+				})
+			},
+			setupMocks: func(m *emailMocks) {},
+			assertErr:  assertStatus(t, http.StatusForbidden),
+		},
+		{
+			name:     "wrong password",
+			input:    input,
+			setupCtx: userCtx,
+			setupMocks: func(m *emailMocks) {
+				m.userRepo.On("GetByID", mock.Anything, current.ID).Return(current, nil)
+				m.lockout.On("Locked", mock.Anything, "old@test.com").Return(time.Duration(0), nil)
+				m.hasher.On("VerifyPassword", current.Password, input.Password).Return(bcrypt.ErrMismatchedHashAndPassword)
+				m.lockout.On("Fail", mock.Anything, "old@test.com").Return(time.Duration(0), nil)
+			},
+			assertErr: assertStatus(t, http.StatusUnauthorized),
+		},
+		{
+			name:     "locked account",
+			input:    input,
+			setupCtx: userCtx,
+			setupMocks: func(m *emailMocks) {
+				m.userRepo.On("GetByID", mock.Anything, current.ID).Return(current, nil)
+				m.lockout.On("Locked", mock.Anything, "old@test.com").Return(time.Minute, nil)
+				m.hasher.On("VerifyPassword", current.Password, input.Password).Return(nil)
+			},
+			assertErr: assertStatus(t, http.StatusUnauthorized),
+		},
+		{
+			name:     "same email",
+			input:    user.ChangeEmailInput{NewEmail: "OLD@test.com", Password: input.Password},
+			setupCtx: userCtx,
+			setupMocks: func(m *emailMocks) {
+				m.userRepo.On("GetByID", mock.Anything, current.ID).Return(current, nil)
+				m.lockout.On("Locked", mock.Anything, "old@test.com").Return(time.Duration(0), nil)
+				m.hasher.On("VerifyPassword", current.Password, input.Password).Return(nil)
+				m.lockout.On("Reset", mock.Anything, "old@test.com").Return(nil)
+			},
+			assertErr: assertStatus(t, http.StatusBadRequest),
+		},
+		{
+			name:     "email taken",
+			input:    input,
+			setupCtx: userCtx,
+			setupMocks: func(m *emailMocks) {
+				m.userRepo.On("GetByID", mock.Anything, current.ID).Return(current, nil)
+				m.lockout.On("Locked", mock.Anything, "old@test.com").Return(time.Duration(0), nil)
+				m.hasher.On("VerifyPassword", current.Password, input.Password).Return(nil)
+				m.lockout.On("Reset", mock.Anything, "old@test.com").Return(nil)
+				m.userRepo.On("GetByEmail", mock.Anything, input.NewEmail).Return(&user.User{ID: 2}, nil)
+			},
+			assertErr: assertStatus(t, http.StatusConflict),
+		},
+		{
+			name:     "sends the link to the new email",
+			input:    input,
+			setupCtx: userCtx,
+			setupMocks: func(m *emailMocks) {
+				m.userRepo.On("GetByID", mock.Anything, current.ID).Return(current, nil)
+				m.lockout.On("Locked", mock.Anything, "old@test.com").Return(time.Duration(0), nil)
+				m.hasher.On("VerifyPassword", current.Password, input.Password).Return(nil)
+				m.lockout.On("Reset", mock.Anything, "old@test.com").Return(nil)
+				m.userRepo.On("GetByEmail", mock.Anything, input.NewEmail).Return(nil, gorm.ErrRecordNotFound)
+				m.tokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *user.EmailToken) bool {
+					return token.UserID == current.ID && token.Email == input.NewEmail &&
+						token.Purpose == user.EmailTokenChange
+				})).Return(nil)
+				m.expectMail()
+			},
+			expectMail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newEmailMocks()
+			tt.setupMocks(m)
+
+			ctx := t.Context()
+			if tt.setupCtx != nil {
+				ctx = tt.setupCtx(ctx)
+			}
+
+			err := m.service().ChangeEmail(ctx, tt.input)
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tt.expectMail {
+				msg := receiveMail(t, m.sent)
+				assert.Equal(t, input.NewEmail, msg.To)
+			}
+
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestService_ConfirmEmail(t *testing.T) {
+	t.Parallel()
+
+	tokenHash := opaquetoken.Hash("token")
+	current := &user.User{ID: 1, Email: "old@test.com"}
+
+	tests := []struct {
+		name       string
+		setupMocks func(m *emailMocks)
+		expectMail bool
+		assertErr  func(t *testing.T, err error)
+	}{
+		{
+			name: "invalid or used token",
+			setupMocks: func(m *emailMocks) {
+				m.tokenRepo.On("Consume", mock.Anything, tokenHash).Return(nil, gorm.ErrRecordNotFound)
+			},
+			assertErr: assertStatus(t, http.StatusBadRequest),
+		},
+		{
+			name: "verification sent before an email change",
+			setupMocks: func(m *emailMocks) {
+				m.tokenRepo.On("Consume", mock.Anything, tokenHash).
+					Return(&user.EmailToken{UserID: current.ID, Email: "older@test.com", Purpose: user.EmailTokenVerify}, nil)
+				m.userRepo.On("GetByID", mock.Anything, current.ID).Return(current, nil)
+			},
+			assertErr: assertStatus(t, http.StatusBadRequest),
+		},
+		{
+			name: "verifies the current email",
+			setupMocks: func(m *emailMocks) {
+				m.tokenRepo.On("Consume", mock.Anything, tokenHash).
+					Return(&user.EmailToken{UserID: current.ID, Email: current.Email, Purpose: user.EmailTokenVerify}, nil)
+				m.userRepo.On("GetByID", mock.Anything, current.ID).Return(current, nil)
+				m.userRepo.On("SetVerifiedEmail", mock.Anything, current.ID, current.Email).Return(nil)
+			},
+		},
+		{
+			name: "new email taken meanwhile",
+			setupMocks: func(m *emailMocks) {
+				m.tokenRepo.On("Consume", mock.Anything, tokenHash).
+					Return(&user.EmailToken{UserID: current.ID, Email: "new@test.com", Purpose: user.EmailTokenChange}, nil)
+				m.userRepo.On("GetByID", mock.Anything, current.ID).Return(current, nil)
+				m.userRepo.On("SetVerifiedEmail", mock.Anything, current.ID, "new@test.com").
+					Return(&pgconn.PgError{Code: postgres.UniqueViolation})
+			},
+			assertErr: assertStatus(t, http.StatusConflict),
+		},
+		{
+			name: "changes the email and notifies the old one",
+			setupMocks: func(m *emailMocks) {
+				m.tokenRepo.On("Consume", mock.Anything, tokenHash).
+					Return(&user.EmailToken{UserID: current.ID, Email: "new@test.com", Purpose: user.EmailTokenChange}, nil)
+				m.userRepo.On("GetByID", mock.Anything, current.ID).Return(current, nil)
+				m.userRepo.On("SetVerifiedEmail", mock.Anything, current.ID, "new@test.com").Return(nil)
+				m.tokenRepo.On("InvalidateByUserID", mock.Anything, current.ID).Return(nil)
+				m.expectMail()
+			},
+			expectMail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newEmailMocks()
+			tt.setupMocks(m)
+
+			err := m.service().ConfirmEmail(t.Context(), user.ConfirmEmailInput{Token: "token"})
+
+			if tt.assertErr != nil {
+				assert.Error(t, err)
+				tt.assertErr(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tt.expectMail {
+				msg := receiveMail(t, m.sent)
+				assert.Equal(t, current.Email, msg.To)
+				assert.Contains(t, msg.Body, "new@test.com")
+			}
+
+			m.assertExpectations(t)
+		})
+	}
+}
@@ -2,30 +2,85 @@ package deps
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"gomonitor/internal/config"
 	databaseinfra "gomonitor/internal/infra/database"
 	redisinfra "gomonitor/internal/infra/redis"
+	"gomonitor/internal/pkg/encryption"
 	"gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/mailer"
+	"gomonitor/internal/pkg/oidc"
 	"gomonitor/internal/pkg/password"
 	"log/slog"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 // Dependencies for the service.
 type Deps struct {
-	DB           *gorm.DB
-	Hasher       password.PasswordHasher
-	Logger       *slog.Logger
-	Redis        redisinfra.RedisClient
-	TokenManager jwt.TokenManager
+	AccessKeyring *jwt.Keyring
+	Cipher        encryption.Cipher
+	DB            *gorm.DB
+	Hasher        password.PasswordHasher
+	Logger        *slog.Logger
+	Mailer        mailer.Mailer
+	// OIDC is nil unless an OpenID Connect provider is configured.
+	OIDC           oidc.Provider
+	PasswordRules  *password.Rules
+	Redis          redisinfra.RedisClient
+	RefreshKeyring *jwt.Keyring
+	// SigningKeyCipher encrypts the stored signing keys, Cipher the MFA secrets and the OIDC state.
+	SigningKeyCipher encryption.Cipher
+	TokenManager     jwt.TokenManager
 }
 
 // New creates the necessary instances.
 func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*Deps, func(ctx context.Context) error, error) {
+	accessKey, err := loadAccessKey(cfg.Auth)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading token keys: %w", err)
+	}
+
+	// Seeded from the configured keys, rotated later through the stored keyring.
+	accessKeyring := jwt.NewKeyring(accessKey)
+	refreshKeyring := jwt.NewKeyring(jwt.NewHMACKey(cfg.Auth.RefreshTokenSecret))
+
+	cipher, err := encryption.NewAESCipher(cfg.Auth.MFAEncryptionKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating mfa cipher: %w", err)
+	}
+
+	signingKeyCipher, err := encryption.NewAESCipher(cfg.Auth.SigningKeyEncryptionKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating signing key cipher: %w", err)
+	}
+
+	hasher := newHasher(cfg.Password)
+
+	// Logins of unknown emails verify against this hash, so they cost the same as a real one.
+	fakeHash, err := hasher.HashPassword(rand.Text())
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating fake hash: %w", err)
+	}
+	cfg.Auth.FakeHash = fakeHash
+
+	passwordRules, err := newPasswordRules(cfg.Password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading breached password list: %w", err)
+	}
+
+	mail, err := mailer.New(cfg.Mail, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating mailer: %w", err)
+	}
+
+	var oidcProvider oidc.Provider
+	if cfg.OIDC.Enabled() {
+		oidcProvider = oidc.NewProvider(cfg.OIDC)
+	}
+
 	db, err := databaseinfra.New(ctx, cfg.Database)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error at opening db conn: %w", err)
@@ -54,10 +109,67 @@ func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*Deps, f
 	}
 
 	return &Deps{
-		DB:           db,
-		Hasher:       password.NewPasswordHasher(bcrypt.DefaultCost),
-		Logger:       logger,
-		Redis:        rdb,
-		TokenManager: jwt.NewTokenManager(cfg.Auth),
+		AccessKeyring:    accessKeyring,
+		Cipher:           cipher,
+		DB:               db,
+		Hasher:           hasher,
+		Logger:           logger,
+		Mailer:           mail,
+		OIDC:             oidcProvider,
+		PasswordRules:    passwordRules,
+		Redis:            rdb,
+		RefreshKeyring:   refreshKeyring,
+		SigningKeyCipher: signingKeyCipher,
+		TokenManager: jwt.NewTokenManager(
+			cfg.Auth,
+			jwt.WithAccessKeyring(accessKeyring),
+			jwt.WithRefreshKeyring(refreshKeyring),
+		),
 	}, cleanup, nil
 }
+
+// loadAccessKey loads the access token private key when using asymmetric signing.
+func loadAccessKey(cfg *config.AuthConfig) (*jwt.SigningKey, error) {
+	if !cfg.IsAsymmetric() {
+		return jwt.NewHMACKey(cfg.AccessTokenSecret), nil
+	}
+
+	return jwt.LoadSigningKey(cfg.AccessTokenSigningMethod, cfg.AccessTokenPrivateKeyFile)
+}
+
+// newHasher hashes with the configured algorithm, hashes of the other one are still verified.
+func newHasher(cfg *config.PasswordConfig) password.PasswordHasher {
+	if cfg.Hasher == config.PasswordHasherBcrypt {
+		return password.NewPasswordHasher(cfg.BcryptCost)
+	}
+
+	return password.NewArgon2idHasher(password.Argon2Params{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+		SaltLength:  password.DefaultArgon2Params.SaltLength,
+		KeyLength:   password.DefaultArgon2Params.KeyLength,
+	})
+}
+
+// newPasswordRules builds the rules for new passwords, the breached list is read once at startup.
+func newPasswordRules(cfg *config.PasswordConfig) (*password.Rules, error) {
+	rules := &password.Rules{
+		MinLength:  cfg.MinLength,
+		MaxLength:  cfg.MaxLength,
+		MinClasses: cfg.MinClasses,
+		MinEntropy: cfg.MinEntropy,
+	}
+
+	if cfg.BreachedListFile == "" {
+		return rules, nil
+	}
+
+	breached, err := password.LoadBreachedList(cfg.BreachedListFile)
+	if err != nil {
+		return nil, err
+	}
+	rules.Breached = breached
+
+	return rules, nil
+}
@@ -22,6 +22,7 @@ func TestNewDeps(t *testing.T) {
 	depsSuccess, _, err := deps.New(t.Context(), cfg, slog.Default())
 	assert.Nil(t, err)
 	assert.NotNil(t, depsSuccess.DB)
+	assert.False(t, depsSuccess.Hasher.NeedsRehash(cfg.Auth.FakeHash), "fake hash must match the current hasher")
 
 	if err := postgresContainer.Terminate(t.Context()); err != nil {
 		t.Logf("cleanup: failed to terminate postgres container: %v", err)
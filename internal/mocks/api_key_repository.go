@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/apikey"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *apikey.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) ListByUserID(ctx context.Context, userID uint) ([]apikey.APIKey, error) {
+	args := m.Called(ctx, userID)
+	var keys []apikey.APIKey
+	if args.Get(0) != nil {
+		keys = args.Get(0).([]apikey.APIKey)
+	}
+	return keys, args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Get(ctx context.Context, userID, id uint) (*apikey.APIKey, error) {
+	args := m.Called(ctx, userID, id)
+	var key *apikey.APIKey
+	if args.Get(0) != nil {
+		key = args.Get(0).(*apikey.APIKey)
+	}
+	return key, args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*apikey.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	var key *apikey.APIKey
+	if args.Get(0) != nil {
+		key = args.Get(0).(*apikey.APIKey)
+	}
+	return key, args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) WithTx(tx *gorm.DB) apikey.Repository {
+	args := m.Called(tx)
+	return args.Get(0).(apikey.Repository)
+}
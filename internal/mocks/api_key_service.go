@@ -0,0 +1,54 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/apikey"
+	"gomonitor/internal/pkg/identity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) Create(ctx context.Context, input apikey.CreateInput) (*apikey.CreateOutput, error) {
+	args := m.Called(ctx, input)
+	var out *apikey.CreateOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*apikey.CreateOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockAPIKeyService) List(ctx context.Context) ([]apikey.APIKey, error) {
+	args := m.Called(ctx)
+	var keys []apikey.APIKey
+	if args.Get(0) != nil {
+		keys = args.Get(0).([]apikey.APIKey)
+	}
+	return keys, args.Error(1)
+}
+
+func (m *MockAPIKeyService) Get(ctx context.Context, input apikey.KeyInput) (*apikey.APIKey, error) {
+	args := m.Called(ctx, input)
+	var key *apikey.APIKey
+	if args.Get(0) != nil {
+		key = args.Get(0).(*apikey.APIKey)
+	}
+	return key, args.Error(1)
+}
+
+func (m *MockAPIKeyService) Delete(ctx context.Context, input apikey.KeyInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) Authenticate(ctx context.Context, key string) (*identity.Principal, error) {
+	args := m.Called(ctx, key)
+	var p *identity.Principal
+	if args.Get(0) != nil {
+		p = args.Get(0).(*identity.Principal)
+	}
+	return p, args.Error(1)
+}
@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/authevent"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthEventRecorder struct {
+	mock.Mock
+}
+
+func (m *MockAuthEventRecorder) Record(ctx context.Context, event authevent.Event) {
+	m.Called(ctx, event)
+}
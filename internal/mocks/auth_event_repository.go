@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/authevent"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockAuthEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuthEventRepository) CreateBatch(ctx context.Context, events []authevent.Event) error {
+	// The recorder reuses the batch once written, expectations see a copy.
+	args := m.Called(ctx, append([]authevent.Event(nil), events...))
+	return args.Error(0)
+}
+
+func (m *MockAuthEventRepository) List(ctx context.Context, filter authevent.Filter) ([]authevent.Event, error) {
+	args := m.Called(ctx, filter)
+	var events []authevent.Event
+	if args.Get(0) != nil {
+		events = args.Get(0).([]authevent.Event)
+	}
+	return events, args.Error(1)
+}
+
+func (m *MockAuthEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAuthEventRepository) WithTx(tx *gorm.DB) authevent.Repository {
+	return m
+}
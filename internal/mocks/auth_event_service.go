@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/authevent"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthEventService struct {
+	mock.Mock
+}
+
+func (m *MockAuthEventService) List(ctx context.Context, input authevent.ListInput) ([]authevent.Event, error) {
+	args := m.Called(ctx, input)
+	var events []authevent.Event
+	if args.Get(0) != nil {
+		events = args.Get(0).([]authevent.Event)
+	}
+	return events, args.Error(1)
+}
+
+func (m *MockAuthEventService) Prune(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAuthEventService) RunRetention(ctx context.Context, interval time.Duration) {
+	m.Called(ctx, interval)
+}
@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 	"gomonitor/internal/domain/auth"
+	"gomonitor/internal/pkg/webauthn"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -20,6 +21,15 @@ func (m *MockAuthService) Login(ctx context.Context, input auth.LoginInput) (*au
 	return lo, args.Error(1)
 }
 
+func (m *MockAuthService) VerifyMFA(ctx context.Context, input auth.VerifyMFAInput) (*auth.LoginOutput, error) {
+	args := m.Called(ctx, input)
+	var lo *auth.LoginOutput
+	if args.Get(0) != nil {
+		lo = args.Get(0).(*auth.LoginOutput)
+	}
+	return lo, args.Error(1)
+}
+
 func (m *MockAuthService) Refresh(ctx context.Context, input auth.RefreshInput) (*auth.RefreshOutput, error) {
 	args := m.Called(ctx, input)
 	var ro *auth.RefreshOutput
@@ -29,12 +39,127 @@ func (m *MockAuthService) Refresh(ctx context.Context, input auth.RefreshInput)
 	return ro, args.Error(1)
 }
 
-func (m *MockAuthService) Logout(ctx context.Context) error {
-	args := m.Called(ctx)
+func (m *MockAuthService) Logout(ctx context.Context, input auth.LogoutInput) error {
+	args := m.Called(ctx, input)
 	return args.Error(0)
 }
 
-func (m *MockAuthService) LogoutAll(ctx context.Context) error {
-	args := m.Called(ctx)
+func (m *MockAuthService) LogoutAll(ctx context.Context, input auth.LogoutInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ListSessions(ctx context.Context, input auth.ListSessionsInput) ([]auth.SessionOutput, error) {
+	args := m.Called(ctx, input)
+	var sessions []auth.SessionOutput
+	if args.Get(0) != nil {
+		sessions = args.Get(0).([]auth.SessionOutput)
+	}
+	return sessions, args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(ctx context.Context, input auth.RevokeSessionInput) error {
+	args := m.Called(ctx, input)
 	return args.Error(0)
 }
+
+func (m *MockAuthService) ForgotPassword(ctx context.Context, input auth.ForgotPasswordInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResetPassword(ctx context.Context, input auth.ResetPasswordInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ChangePassword(ctx context.Context, input auth.ChangePasswordInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RequestMagicLink(ctx context.Context, input auth.MagicLinkInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ConsumeMagicLink(ctx context.Context, input auth.ConsumeMagicLinkInput) (*auth.LoginOutput, error) {
+	args := m.Called(ctx, input)
+	var out *auth.LoginOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*auth.LoginOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockAuthService) StartPasskeyLogin(ctx context.Context, input auth.PasskeyLoginOptionsInput) (*webauthn.RequestOptions, error) {
+	args := m.Called(ctx, input)
+	var out *webauthn.RequestOptions
+	if args.Get(0) != nil {
+		out = args.Get(0).(*webauthn.RequestOptions)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockAuthService) CompletePasskeyLogin(ctx context.Context, input auth.PasskeyLoginInput) (*auth.LoginOutput, error) {
+	args := m.Called(ctx, input)
+	var out *auth.LoginOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*auth.LoginOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockAuthService) StartOIDCLogin(ctx context.Context) (*auth.OIDCLoginOutput, error) {
+	args := m.Called(ctx)
+	var out *auth.OIDCLoginOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*auth.OIDCLoginOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockAuthService) CompleteOIDCLogin(ctx context.Context, input auth.OIDCCallbackInput) (*auth.LoginOutput, error) {
+	args := m.Called(ctx, input)
+	var out *auth.LoginOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*auth.LoginOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockAuthService) Impersonate(ctx context.Context, input auth.ImpersonateInput) (*auth.ImpersonationOutput, error) {
+	args := m.Called(ctx, input)
+	var out *auth.ImpersonationOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*auth.ImpersonationOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockAuthService) StartPasskeyReauthentication(ctx context.Context) (*webauthn.RequestOptions, error) {
+	args := m.Called(ctx)
+	var out *webauthn.RequestOptions
+	if args.Get(0) != nil {
+		out = args.Get(0).(*webauthn.RequestOptions)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockAuthService) CompletePasskeyReauthentication(ctx context.Context, input auth.PasskeyReauthenticateInput) (*auth.ReauthenticateOutput, error) {
+	args := m.Called(ctx, input)
+	var out *auth.ReauthenticateOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*auth.ReauthenticateOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockAuthService) Reauthenticate(ctx context.Context, input auth.ReauthenticateInput) (*auth.ReauthenticateOutput, error) {
+	args := m.Called(ctx, input)
+	var out *auth.ReauthenticateOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*auth.ReauthenticateOutput)
+	}
+	return out, args.Error(1)
+}
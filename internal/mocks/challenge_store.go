@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/pkg/webauthn"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockChallengeStore struct {
+	mock.Mock
+}
+
+func (m *MockChallengeStore) Save(ctx context.Context, challenge string, session *webauthn.Session, ttl time.Duration) error {
+	args := m.Called(ctx, challenge, session, ttl)
+	return args.Error(0)
+}
+
+func (m *MockChallengeStore) Consume(ctx context.Context, challenge string) (*webauthn.Session, error) {
+	args := m.Called(ctx, challenge)
+	var session *webauthn.Session
+	if args.Get(0) != nil {
+		session = args.Get(0).(*webauthn.Session)
+	}
+	return session, args.Error(1)
+}
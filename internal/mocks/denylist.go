@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDenylist struct {
+	mock.Mock
+}
+
+func (m *MockDenylist) Revoke(ctx context.Context, jti uuid.UUID, ttl time.Duration) error {
+	args := m.Called(ctx, jti, ttl)
+	return args.Error(0)
+}
+
+func (m *MockDenylist) IsRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
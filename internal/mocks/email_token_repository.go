@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/user"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockEmailTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailTokenRepository) Create(ctx context.Context, token *user.EmailToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockEmailTokenRepository) Consume(ctx context.Context, tokenHash string) (*user.EmailToken, error) {
+	args := m.Called(ctx, tokenHash)
+
+	var t *user.EmailToken
+	if args.Get(0) != nil {
+		t = args.Get(0).(*user.EmailToken)
+	}
+
+	return t, args.Error(1)
+}
+
+func (m *MockEmailTokenRepository) InvalidateByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockEmailTokenRepository) WithTx(tx *gorm.DB) user.EmailTokenRepository {
+	return m
+}
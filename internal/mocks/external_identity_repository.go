@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/user"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockExternalIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockExternalIdentityRepository) GetBySubject(ctx context.Context, issuer, subject string) (*user.ExternalIdentity, error) {
+	args := m.Called(ctx, issuer, subject)
+	var identity *user.ExternalIdentity
+	if args.Get(0) != nil {
+		identity = args.Get(0).(*user.ExternalIdentity)
+	}
+	return identity, args.Error(1)
+}
+
+func (m *MockExternalIdentityRepository) Create(ctx context.Context, identity *user.ExternalIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockExternalIdentityRepository) Provision(ctx context.Context, u *user.User, identity *user.ExternalIdentity) error {
+	args := m.Called(ctx, u, identity)
+	return args.Error(0)
+}
+
+func (m *MockExternalIdentityRepository) WithTx(tx *gorm.DB) user.ExternalIdentityRepository {
+	args := m.Called(tx)
+	return args.Get(0).(user.ExternalIdentityRepository)
+}
@@ -21,8 +21,26 @@ func (m *MockJwtManager) GenerateRefreshToken(userID uint, role identity.UserRol
 	return j, args.Error(1)
 }
 
-func (m *MockJwtManager) GenerateAccessToken(userID uint, role identity.UserRole, refreshTokenJTI uuid.UUID) (*jwt.AccessTokenResult, error) {
-	args := m.Called(userID, role)
+func (m *MockJwtManager) GenerateAccessToken(userID uint, role identity.UserRole, sessionID uuid.UUID, jkt string, auth jwt.Authentication) (*jwt.AccessTokenResult, error) {
+	args := m.Called(userID, role, jkt)
+	var j *jwt.AccessTokenResult
+	if args.Get(0) != nil {
+		j = args.Get(0).(*jwt.AccessTokenResult)
+	}
+	return j, args.Error(1)
+}
+
+func (m *MockJwtManager) GenerateClientToken(clientID string, scopes []string) (*jwt.AccessTokenResult, error) {
+	args := m.Called(clientID, scopes)
+	var j *jwt.AccessTokenResult
+	if args.Get(0) != nil {
+		j = args.Get(0).(*jwt.AccessTokenResult)
+	}
+	return j, args.Error(1)
+}
+
+func (m *MockJwtManager) GenerateImpersonationToken(userID uint, role identity.UserRole, actorID uint) (*jwt.AccessTokenResult, error) {
+	args := m.Called(userID, role, actorID)
 	var j *jwt.AccessTokenResult
 	if args.Get(0) != nil {
 		j = args.Get(0).(*jwt.AccessTokenResult)
@@ -51,3 +69,44 @@ func (m *MockJwtManager) ValidateAccessToken(tokenString string) (*identity.Prin
 
 	return i, args.Error(1)
 }
+
+func (m *MockJwtManager) JWKS() jwt.JWKS {
+	args := m.Called()
+	return args.Get(0).(jwt.JWKS)
+}
+
+func (m *MockJwtManager) GenerateMFAToken(userID uint, role identity.UserRole) (*jwt.MFATokenResult, error) {
+	args := m.Called(userID, role)
+	var j *jwt.MFATokenResult
+	if args.Get(0) != nil {
+		j = args.Get(0).(*jwt.MFATokenResult)
+	}
+	return j, args.Error(1)
+}
+
+func (m *MockJwtManager) GenerateMagicLinkToken(userID uint, role identity.UserRole, amr []string) (*jwt.MagicLinkTokenResult, error) {
+	args := m.Called(userID, role, amr)
+	var j *jwt.MagicLinkTokenResult
+	if args.Get(0) != nil {
+		j = args.Get(0).(*jwt.MagicLinkTokenResult)
+	}
+	return j, args.Error(1)
+}
+
+func (m *MockJwtManager) ValidateMagicLinkToken(tokenString string) (*identity.Principal, error) {
+	args := m.Called(tokenString)
+	var p *identity.Principal
+	if args.Get(0) != nil {
+		p = args.Get(0).(*identity.Principal)
+	}
+	return p, args.Error(1)
+}
+
+func (m *MockJwtManager) ValidateMFAToken(tokenString string) (*identity.Principal, error) {
+	args := m.Called(tokenString)
+	var p *identity.Principal
+	if args.Get(0) != nil {
+		p = args.Get(0).(*identity.Principal)
+	}
+	return p, args.Error(1)
+}
@@ -0,0 +1,57 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/mfa"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) GetFactor(ctx context.Context, userID uint) (*mfa.TOTPFactor, error) {
+	args := m.Called(ctx, userID)
+	var f *mfa.TOTPFactor
+	if args.Get(0) != nil {
+		f = args.Get(0).(*mfa.TOTPFactor)
+	}
+	return f, args.Error(1)
+}
+
+func (m *MockMFARepository) SaveFactor(ctx context.Context, factor *mfa.TOTPFactor) error {
+	args := m.Called(ctx, factor)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ConfirmFactor(ctx context.Context, userID uint, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) UseStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) Delete(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error {
+	args := m.Called(ctx, userID, hashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, userID uint, hash string) (bool, error) {
+	args := m.Called(ctx, userID, hash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) WithTx(tx *gorm.DB) mfa.Repository {
+	args := m.Called(tx)
+	return args.Get(0).(mfa.Repository)
+}
@@ -0,0 +1,54 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/mfa"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMFAService struct {
+	mock.Mock
+}
+
+func (m *MockMFAService) Enroll(ctx context.Context) (*mfa.EnrollOutput, error) {
+	args := m.Called(ctx)
+	var out *mfa.EnrollOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*mfa.EnrollOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockMFAService) Confirm(ctx context.Context, input mfa.CodeInput) (*mfa.RecoveryCodesOutput, error) {
+	args := m.Called(ctx, input)
+	var out *mfa.RecoveryCodesOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*mfa.RecoveryCodesOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockMFAService) Disable(ctx context.Context, input mfa.CodeInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) RegenerateRecoveryCodes(ctx context.Context, input mfa.CodeInput) (*mfa.RecoveryCodesOutput, error) {
+	args := m.Called(ctx, input)
+	var out *mfa.RecoveryCodesOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*mfa.RecoveryCodesOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockMFAService) IsEnabled(ctx context.Context, userID uint) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFAService) Verify(ctx context.Context, input mfa.VerifyInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
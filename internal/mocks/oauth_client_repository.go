@@ -0,0 +1,55 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/oauth"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockOAuthClientRepository struct {
+	mock.Mock
+}
+
+func (m *MockOAuthClientRepository) Create(ctx context.Context, client *oauth.Client) error {
+	args := m.Called(ctx, client)
+	return args.Error(0)
+}
+
+func (m *MockOAuthClientRepository) List(ctx context.Context) ([]oauth.Client, error) {
+	args := m.Called(ctx)
+	var clients []oauth.Client
+	if args.Get(0) != nil {
+		clients = args.Get(0).([]oauth.Client)
+	}
+	return clients, args.Error(1)
+}
+
+func (m *MockOAuthClientRepository) Get(ctx context.Context, id uint) (*oauth.Client, error) {
+	args := m.Called(ctx, id)
+	var client *oauth.Client
+	if args.Get(0) != nil {
+		client = args.Get(0).(*oauth.Client)
+	}
+	return client, args.Error(1)
+}
+
+func (m *MockOAuthClientRepository) GetByClientID(ctx context.Context, clientID string) (*oauth.Client, error) {
+	args := m.Called(ctx, clientID)
+	var client *oauth.Client
+	if args.Get(0) != nil {
+		client = args.Get(0).(*oauth.Client)
+	}
+	return client, args.Error(1)
+}
+
+func (m *MockOAuthClientRepository) Delete(ctx context.Context, id uint) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOAuthClientRepository) WithTx(tx *gorm.DB) oauth.Repository {
+	args := m.Called(tx)
+	return args.Get(0).(oauth.Repository)
+}
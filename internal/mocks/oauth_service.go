@@ -0,0 +1,62 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/oauth"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOAuthService struct {
+	mock.Mock
+}
+
+func (m *MockOAuthService) CreateClient(ctx context.Context, input oauth.CreateClientInput) (*oauth.CreateClientOutput, error) {
+	args := m.Called(ctx, input)
+	var out *oauth.CreateClientOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*oauth.CreateClientOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockOAuthService) ListClients(ctx context.Context) ([]oauth.Client, error) {
+	args := m.Called(ctx)
+	var clients []oauth.Client
+	if args.Get(0) != nil {
+		clients = args.Get(0).([]oauth.Client)
+	}
+	return clients, args.Error(1)
+}
+
+func (m *MockOAuthService) GetClient(ctx context.Context, input oauth.ClientInput) (*oauth.Client, error) {
+	args := m.Called(ctx, input)
+	var client *oauth.Client
+	if args.Get(0) != nil {
+		client = args.Get(0).(*oauth.Client)
+	}
+	return client, args.Error(1)
+}
+
+func (m *MockOAuthService) DeleteClient(ctx context.Context, input oauth.ClientInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockOAuthService) Token(ctx context.Context, input oauth.TokenInput) (*oauth.TokenOutput, error) {
+	args := m.Called(ctx, input)
+	var out *oauth.TokenOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*oauth.TokenOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockOAuthService) Introspect(ctx context.Context, input oauth.IntrospectInput) (*oauth.IntrospectOutput, error) {
+	args := m.Called(ctx, input)
+	var out *oauth.IntrospectOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*oauth.IntrospectOutput)
+	}
+	return out, args.Error(1)
+}
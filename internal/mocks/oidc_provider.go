@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/pkg/oidc"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOIDCProvider struct {
+	mock.Mock
+}
+
+func (m *MockOIDCProvider) Issuer() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockOIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	args := m.Called(ctx, state, nonce, codeChallenge)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*oidc.Claims, error) {
+	args := m.Called(ctx, code, codeVerifier, nonce)
+	var claims *oidc.Claims
+	if args.Get(0) != nil {
+		claims = args.Get(0).(*oidc.Claims)
+	}
+	return claims, args.Error(1)
+}
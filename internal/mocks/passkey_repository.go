@@ -0,0 +1,57 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/passkey"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockPasskeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasskeyRepository) Create(ctx context.Context, p *passkey.Passkey) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (m *MockPasskeyRepository) ListByUserID(ctx context.Context, userID uint) ([]passkey.Passkey, error) {
+	args := m.Called(ctx, userID)
+	var passkeys []passkey.Passkey
+	if args.Get(0) != nil {
+		passkeys = args.Get(0).([]passkey.Passkey)
+	}
+	return passkeys, args.Error(1)
+}
+
+func (m *MockPasskeyRepository) GetByCredentialID(ctx context.Context, credentialID []byte) (*passkey.Passkey, error) {
+	args := m.Called(ctx, credentialID)
+	var p *passkey.Passkey
+	if args.Get(0) != nil {
+		p = args.Get(0).(*passkey.Passkey)
+	}
+	return p, args.Error(1)
+}
+
+func (m *MockPasskeyRepository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPasskeyRepository) UpdateUsage(ctx context.Context, id uint, signCount uint32, backedUp bool, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, signCount, backedUp, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasskeyRepository) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasskeyRepository) WithTx(tx *gorm.DB) passkey.Repository {
+	args := m.Called(tx)
+	return args.Get(0).(passkey.Repository)
+}
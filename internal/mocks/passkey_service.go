@@ -0,0 +1,68 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/passkey"
+	"gomonitor/internal/pkg/webauthn"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPasskeyService struct {
+	mock.Mock
+}
+
+func (m *MockPasskeyService) BeginRegistration(ctx context.Context) (*webauthn.CreationOptions, error) {
+	args := m.Called(ctx)
+	var out *webauthn.CreationOptions
+	if args.Get(0) != nil {
+		out = args.Get(0).(*webauthn.CreationOptions)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockPasskeyService) FinishRegistration(ctx context.Context, input passkey.RegisterInput) (*passkey.Passkey, error) {
+	args := m.Called(ctx, input)
+	var out *passkey.Passkey
+	if args.Get(0) != nil {
+		out = args.Get(0).(*passkey.Passkey)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockPasskeyService) List(ctx context.Context) ([]passkey.Passkey, error) {
+	args := m.Called(ctx)
+	var out []passkey.Passkey
+	if args.Get(0) != nil {
+		out = args.Get(0).([]passkey.Passkey)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockPasskeyService) Delete(ctx context.Context, input passkey.PasskeyInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockPasskeyService) BeginLogin(ctx context.Context, input passkey.BeginLoginInput) (*webauthn.RequestOptions, error) {
+	args := m.Called(ctx, input)
+	var out *webauthn.RequestOptions
+	if args.Get(0) != nil {
+		out = args.Get(0).(*webauthn.RequestOptions)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockPasskeyService) FinishLogin(ctx context.Context, input passkey.FinishLoginInput) (*passkey.LoginOutput, error) {
+	args := m.Called(ctx, input)
+	var out *passkey.LoginOutput
+	if args.Get(0) != nil {
+		out = args.Get(0).(*passkey.LoginOutput)
+	}
+	return out, args.Error(1)
+}
+
+func (m *MockPasskeyService) HasPasskeys(ctx context.Context, userID uint) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
@@ -17,3 +17,8 @@ func (m *MockPasswordHasher) VerifyPassword(hashedPassword, password string) err
 	args := m.Called(hashedPassword, password)
 	return args.Error(0)
 }
+
+func (m *MockPasswordHasher) NeedsRehash(hashedPassword string) bool {
+	args := m.Called(hashedPassword)
+	return args.Bool(0)
+}
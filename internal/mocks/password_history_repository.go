@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/user"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockPasswordHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordHistoryRepository) Create(ctx context.Context, entry *user.PasswordHistory) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockPasswordHistoryRepository) ListRecent(ctx context.Context, userID uint, limit int) ([]user.PasswordHistory, error) {
+	args := m.Called(ctx, userID, limit)
+
+	var entries []user.PasswordHistory
+	if args.Get(0) != nil {
+		entries = args.Get(0).([]user.PasswordHistory)
+	}
+
+	return entries, args.Error(1)
+}
+
+func (m *MockPasswordHistoryRepository) Prune(ctx context.Context, userID uint, keep int) error {
+	args := m.Called(ctx, userID, keep)
+	return args.Error(0)
+}
+
+func (m *MockPasswordHistoryRepository) WithTx(tx *gorm.DB) user.PasswordHistoryRepository {
+	return m
+}
@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/user"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPasswordPolicy struct {
+	mock.Mock
+}
+
+func (m *MockPasswordPolicy) Check(ctx context.Context, input user.CheckPasswordInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockPasswordPolicy) Remember(ctx context.Context, userID uint, hash string) error {
+	args := m.Called(ctx, userID, hash)
+	return args.Error(0)
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/auth"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) Create(ctx context.Context, token *auth.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) FindUserID(ctx context.Context, tokenHash string) (uint, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) Consume(ctx context.Context, tokenHash string) (uint, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) InvalidateByUserID(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) WithTx(tx *gorm.DB) auth.PasswordResetRepository {
+	return m
+}
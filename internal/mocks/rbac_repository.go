@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/rbac"
+	"gomonitor/internal/pkg/identity"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockRBACRepository struct {
+	mock.Mock
+}
+
+func (m *MockRBACRepository) GetPermissions(ctx context.Context, role identity.UserRole) ([]identity.Permission, error) {
+	args := m.Called(ctx, role)
+
+	var permissions []identity.Permission
+	if args.Get(0) != nil {
+		permissions = args.Get(0).([]identity.Permission)
+	}
+
+	return permissions, args.Error(1)
+}
+
+func (m *MockRBACRepository) WithTx(tx *gorm.DB) rbac.Repository {
+	args := m.Called(tx)
+	return args.Get(0).(rbac.Repository)
+}
@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/pkg/identity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRBACService struct {
+	mock.Mock
+}
+
+func (m *MockRBACService) Permissions(ctx context.Context, role identity.UserRole) ([]identity.Permission, error) {
+	args := m.Called(ctx, role)
+
+	var permissions []identity.Permission
+	if args.Get(0) != nil {
+		permissions = args.Get(0).([]identity.Permission)
+	}
+
+	return permissions, args.Error(1)
+}
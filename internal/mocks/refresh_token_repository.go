@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 	"gomonitor/internal/domain/auth"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -18,6 +19,17 @@ func (m *MockRefreshTokenRepository) Create(ctx context.Context, refreshToken *a
 	return args.Error(0)
 }
 
+func (m *MockRefreshTokenRepository) GetActiveByFamilyID(ctx context.Context, familyID uuid.UUID) (*auth.RefreshToken, error) {
+	args := m.Called(ctx, familyID)
+
+	var t *auth.RefreshToken
+	if args.Get(0) != nil {
+		t = args.Get(0).(*auth.RefreshToken)
+	}
+
+	return t, args.Error(1)
+}
+
 func (m *MockRefreshTokenRepository) GetByJTI(ctx context.Context, jti uuid.UUID) (*auth.RefreshToken, error) {
 	args := m.Called(ctx, jti)
 
@@ -29,16 +41,69 @@ func (m *MockRefreshTokenRepository) GetByJTI(ctx context.Context, jti uuid.UUID
 	return t, args.Error(1)
 }
 
-func (m *MockRefreshTokenRepository) RevokeByJTI(ctx context.Context, jti uuid.UUID) error {
-	args := m.Called(ctx, jti)
-	return args.Error(0)
+func (m *MockRefreshTokenRepository) ListActiveByUserID(ctx context.Context, userID uint) ([]auth.RefreshToken, error) {
+	args := m.Called(ctx, userID)
+
+	var tokens []auth.RefreshToken
+	if args.Get(0) != nil {
+		tokens = args.Get(0).([]auth.RefreshToken)
+	}
+
+	return tokens, args.Error(1)
 }
 
-func (m *MockRefreshTokenRepository) RevokeByUserID(ctx context.Context, id uint) error {
+func (m *MockRefreshTokenRepository) RevokeByUserID(ctx context.Context, id uint) ([]uuid.UUID, error) {
 	args := m.Called(ctx, id)
-	return args.Error(0)
+
+	var jtis []uuid.UUID
+	if args.Get(0) != nil {
+		jtis = args.Get(0).([]uuid.UUID)
+	}
+
+	return jtis, args.Error(1)
 }
 
 func (m *MockRefreshTokenRepository) WithTx(tx *gorm.DB) auth.RefreshTokenRepository {
 	return m
 }
+
+func (m *MockRefreshTokenRepository) RevokeByFamilyID(ctx context.Context, familyID uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(ctx, familyID)
+
+	var jtis []uuid.UUID
+	if args.Get(0) != nil {
+		jtis = args.Get(0).([]uuid.UUID)
+	}
+
+	return jtis, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeOtherFamilies(ctx context.Context, userID uint, keepFamilyID uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(ctx, userID, keepFamilyID)
+
+	var jtis []uuid.UUID
+	if args.Get(0) != nil {
+		jtis = args.Get(0).([]uuid.UUID)
+	}
+
+	return jtis, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) IsRevoked(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, familyID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) Rotate(ctx context.Context, oldJTI uuid.UUID, newToken *auth.RefreshToken) error {
+	args := m.Called(ctx, oldJTI, newToken)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) UpdateAuthentication(ctx context.Context, familyID uuid.UUID, authTime time.Time, amr string) (*auth.RefreshToken, error) {
+	args := m.Called(ctx, familyID, authTime, amr)
+	var token *auth.RefreshToken
+	if args.Get(0) != nil {
+		token = args.Get(0).(*auth.RefreshToken)
+	}
+	return token, args.Error(1)
+}
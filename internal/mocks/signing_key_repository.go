@@ -0,0 +1,75 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/signingkey"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockSigningKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockSigningKeyRepository) Create(ctx context.Context, key *signingkey.SigningKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockSigningKeyRepository) CreateIfMissing(ctx context.Context, key *signingkey.SigningKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockSigningKeyRepository) GetByKID(ctx context.Context, kid string) (*signingkey.SigningKey, error) {
+	args := m.Called(ctx, kid)
+
+	var k *signingkey.SigningKey
+	if args.Get(0) != nil {
+		k = args.Get(0).(*signingkey.SigningKey)
+	}
+
+	return k, args.Error(1)
+}
+
+func (m *MockSigningKeyRepository) List(ctx context.Context) ([]signingkey.SigningKey, error) {
+	args := m.Called(ctx)
+
+	var keys []signingkey.SigningKey
+	if args.Get(0) != nil {
+		keys = args.Get(0).([]signingkey.SigningKey)
+	}
+
+	return keys, args.Error(1)
+}
+
+func (m *MockSigningKeyRepository) ListActive(ctx context.Context) ([]signingkey.SigningKey, error) {
+	args := m.Called(ctx)
+
+	var keys []signingkey.SigningKey
+	if args.Get(0) != nil {
+		keys = args.Get(0).([]signingkey.SigningKey)
+	}
+
+	return keys, args.Error(1)
+}
+
+func (m *MockSigningKeyRepository) Promote(ctx context.Context, kid string) error {
+	args := m.Called(ctx, kid)
+	return args.Error(0)
+}
+
+func (m *MockSigningKeyRepository) Retire(ctx context.Context, kid string) error {
+	args := m.Called(ctx, kid)
+	return args.Error(0)
+}
+
+func (m *MockSigningKeyRepository) UpdateMaterial(ctx context.Context, kid, material string) error {
+	args := m.Called(ctx, kid, material)
+	return args.Error(0)
+}
+
+func (m *MockSigningKeyRepository) WithTx(tx *gorm.DB) signingkey.Repository {
+	return m
+}
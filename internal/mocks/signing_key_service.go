@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"context"
+	"gomonitor/internal/domain/signingkey"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSigningKeyService struct {
+	mock.Mock
+}
+
+func (m *MockSigningKeyService) Bootstrap(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockSigningKeyService) Sync(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockSigningKeyService) Run(ctx context.Context, interval time.Duration) {
+	m.Called(ctx, interval)
+}
+
+func (m *MockSigningKeyService) List(ctx context.Context) ([]signingkey.SigningKey, error) {
+	args := m.Called(ctx)
+	var keys []signingkey.SigningKey
+	if args.Get(0) != nil {
+		keys = args.Get(0).([]signingkey.SigningKey)
+	}
+	return keys, args.Error(1)
+}
+
+func (m *MockSigningKeyService) Create(ctx context.Context, input signingkey.CreateKeyInput) (*signingkey.SigningKey, error) {
+	args := m.Called(ctx, input)
+	var k *signingkey.SigningKey
+	if args.Get(0) != nil {
+		k = args.Get(0).(*signingkey.SigningKey)
+	}
+	return k, args.Error(1)
+}
+
+func (m *MockSigningKeyService) Promote(ctx context.Context, input signingkey.KeyInput) (*signingkey.SigningKey, error) {
+	args := m.Called(ctx, input)
+	var k *signingkey.SigningKey
+	if args.Get(0) != nil {
+		k = args.Get(0).(*signingkey.SigningKey)
+	}
+	return k, args.Error(1)
+}
+
+func (m *MockSigningKeyService) Retire(ctx context.Context, input signingkey.KeyInput) (*signingkey.SigningKey, error) {
+	args := m.Called(ctx, input)
+	var k *signingkey.SigningKey
+	if args.Get(0) != nil {
+		k = args.Get(0).(*signingkey.SigningKey)
+	}
+	return k, args.Error(1)
+}
@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUsedTokens struct {
+	mock.Mock
+}
+
+func (m *MockUsedTokens) Use(ctx context.Context, jti uuid.UUID, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, jti, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUsedTokens) IsUsed(ctx context.Context, jti uuid.UUID) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
@@ -3,6 +3,7 @@ package mocks
 import (
 	"context"
 	"gomonitor/internal/domain/user"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"gorm.io/gorm"
@@ -44,6 +45,21 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*use
 	return u, args.Error(1)
 }
 
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	args := m.Called(ctx, id, hash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetVerifiedEmail(ctx context.Context, id uint, email string) error {
+	args := m.Called(ctx, id, email)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, id uint, at time.Time, ip string) error {
+	args := m.Called(ctx, id, at, ip)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) WithTx(tx *gorm.DB) user.UserRepository {
 	return m
 }
@@ -28,3 +28,27 @@ func (m *MockUserService) GetUser(ctx context.Context, input user.GetUserInput)
 	}
 	return u, args.Error(1)
 }
+
+func (m *MockUserService) CurrentUser(ctx context.Context) (*user.User, error) {
+	args := m.Called(ctx)
+	var u *user.User
+	if args.Get(0) != nil {
+		u = args.Get(0).(*user.User)
+	}
+	return u, args.Error(1)
+}
+
+func (m *MockUserService) RequestEmailVerification(ctx context.Context, input user.RequestEmailVerificationInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ChangeEmail(ctx context.Context, input user.ChangeEmailInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ConfirmEmail(ctx context.Context, input user.ConfirmEmailInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
@@ -3,6 +3,7 @@ package logging
 import (
 	"context"
 	"gomonitor/internal/config"
+	"gomonitor/internal/pkg/identity"
 	"log/slog"
 	"os"
 
@@ -44,6 +45,18 @@ func WithTrace(ctx context.Context, logger *slog.Logger) *slog.Logger {
 	)
 }
 
+// WithImpersonation adds the impersonated user and the admin acting as them to the logger.
+func WithImpersonation(logger *slog.Logger, principal *identity.Principal) *slog.Logger {
+	if !principal.Impersonated() {
+		return logger
+	}
+
+	return logger.With(slog.Group("impersonation",
+		slog.Uint64("user_id", uint64(principal.UserID)),
+		slog.Uint64("actor_id", uint64(principal.ActorID)),
+	))
+}
+
 // FromContext is a helper to extract the logger from the Gin context.
 func FromContext(ctx context.Context) *slog.Logger {
 	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok && logger != nil {
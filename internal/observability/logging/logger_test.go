@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"gomonitor/internal/config"
+	"gomonitor/internal/pkg/identity"
 	"log/slog"
 	"testing"
 
@@ -95,6 +96,19 @@ func TestWithTrace_WithSpan(t *testing.T) {
 	assert.Contains(t, output, sc.SpanID().String())
 }
 
+func TestWithImpersonation(t *testing.T) {
+	var buf bytes.Buffer
+	logger := testLogger(&buf)
+
+	require.Same(t, logger, WithImpersonation(logger, &identity.Principal{UserID: 2}))
+
+	WithImpersonation(logger, &identity.Principal{UserID: 2, ActorID: 1}).Info("hello")
+
+	output := buf.String()
+	assert.Contains(t, output, "impersonation.user_id=2")
+	assert.Contains(t, output, "impersonation.actor_id=1")
+}
+
 func testLogger(buf *bytes.Buffer) *slog.Logger {
 	handler := slog.NewTextHandler(buf, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"gomonitor/internal/config"
+	"gomonitor/internal/pkg/identity"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -57,6 +58,7 @@ func SetupOtel(ctx context.Context, cfg *config.TracingConfig) (func(context.Con
 		),
 		sdktrace.WithResource(res),
 		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSpanProcessor(ImpersonationProcessor{}),
 	)
 
 	otel.SetTextMapPropagator(
@@ -71,6 +73,29 @@ func SetupOtel(ctx context.Context, cfg *config.TracingConfig) (func(context.Con
 	return traceExporter.Shutdown, err
 }
 
+// ImpersonationAttributes identify the impersonated user and the admin acting as them.
+func ImpersonationAttributes(principal *identity.Principal) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int64("impersonation.user_id", int64(principal.UserID)),
+		attribute.Int64("impersonation.actor_id", int64(principal.ActorID)),
+	}
+}
+
+// ImpersonationProcessor tags every span started within an impersonated request.
+type ImpersonationProcessor struct{}
+
+func (ImpersonationProcessor) OnStart(parent context.Context, span sdktrace.ReadWriteSpan) {
+	if principal, ok := identity.PrincipalFromContext(parent); ok && principal.Impersonated() {
+		span.SetAttributes(ImpersonationAttributes(principal)...)
+	}
+}
+
+func (ImpersonationProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (ImpersonationProcessor) Shutdown(context.Context) error { return nil }
+
+func (ImpersonationProcessor) ForceFlush(context.Context) error { return nil }
+
 // Tracer returns the global tracer
 func Tracer(name string) trace.Tracer {
 	return otel.Tracer(name)
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"gomonitor/internal/config"
 	"gomonitor/internal/observability/tracing"
+	"gomonitor/internal/pkg/identity"
 	"testing"
 	"time"
 
@@ -158,3 +159,30 @@ func TestTrackDuration(t *testing.T) {
 
 	require.True(t, found, "duration_ms attribute not found")
 }
+
+func TestImpersonationProcessor(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSpanProcessor(tracing.ImpersonationProcessor{}),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	tracer := tp.Tracer("test")
+
+	ctx := identity.WithPrincipal(context.Background(), &identity.Principal{UserID: 2, ActorID: 1})
+	_, span := tracer.Start(ctx, "impersonated")
+	span.End()
+
+	ctx = identity.WithPrincipal(context.Background(), &identity.Principal{UserID: 2})
+	_, span = tracer.Start(ctx, "session")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.ElementsMatch(t, []attribute.KeyValue{
+		attribute.Int64("impersonation.user_id", 2),
+		attribute.Int64("impersonation.actor_id", 1),
+	}, spans[0].Attributes)
+	require.Empty(t, spans[1].Attributes)
+}
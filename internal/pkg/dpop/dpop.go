@@ -0,0 +1,148 @@
+// Package dpop verifies the proofs of possession of RFC 9449, which bind tokens to a key pair of the client.
+package dpop
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	pkgjwt "gomonitor/internal/pkg/jwt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// Header carries the proof, on the token endpoints and along bound access tokens.
+	Header = "DPoP"
+	// Scheme of the Authorization header for bound access tokens, "Bearer" ones can't be bound.
+	Scheme = "DPoP"
+	// TokenType is the token_type of bound tokens in token responses.
+	TokenType = "DPoP"
+
+	proofType    = "dpop+jwt"
+	maxJTILength = 128
+)
+
+var (
+	ErrInvalidProof = errors.New("invalid dpop proof")
+	// ErrReplayed is returned for a proof already presented, each one is single use.
+	ErrReplayed = errors.New("dpop proof replayed")
+)
+
+// Only asymmetric algorithms prove the possession of a private key.
+var algorithms = []string{"ES256", "ES384", "ES512", "RS256", "PS256", "EdDSA"}
+
+// Request is what the proof must be about.
+type Request struct {
+	Method string
+	URL    string
+	// AccessToken is set when the proof comes along an access token, the proof must then hash it.
+	AccessToken string
+}
+
+type claims struct {
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+	ATH string `json:"ath,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type Verifier struct {
+	replay   ReplayStore
+	lifetime time.Duration
+}
+
+// NewVerifier accepts proofs issued at most lifetime ago, or that far ahead to tolerate clock skew.
+func NewVerifier(replay ReplayStore, lifetime time.Duration) *Verifier {
+	return &Verifier{
+		replay:   replay,
+		lifetime: lifetime,
+	}
+}
+
+// Verify checks the proof of the request and returns the thumbprint of the key which signed it.
+// The jti of an accepted proof is remembered, presenting it again fails with ErrReplayed.
+func (v *Verifier) Verify(ctx context.Context, proof string, req Request) (string, error) {
+	var key pkgjwt.JWK
+
+	token, err := jwt.ParseWithClaims(proof, &claims{}, func(t *jwt.Token) (any, error) {
+		if typ, _ := t.Header["typ"].(string); typ != proofType {
+			return nil, ErrInvalidProof
+		}
+
+		raw, ok := t.Header["jwk"].(map[string]any)
+		if !ok {
+			return nil, ErrInvalidProof
+		}
+		// A private key has no business in a header.
+		if _, private := raw["d"]; private {
+			return nil, ErrInvalidProof
+		}
+
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return nil, ErrInvalidProof
+		}
+		if err := json.Unmarshal(encoded, &key); err != nil {
+			return nil, ErrInvalidProof
+		}
+
+		return key.PublicKey()
+	}, jwt.WithValidMethods(algorithms))
+	if err != nil || !token.Valid {
+		return "", ErrInvalidProof
+	}
+
+	// Guaranteed to be *claims due to parse with claims.
+	c, _ := token.Claims.(*claims)
+
+	if c.ID == "" || len(c.ID) > maxJTILength || c.IssuedAt == nil {
+		return "", ErrInvalidProof
+	}
+
+	if c.HTM != req.Method || c.HTU != req.URL {
+		return "", ErrInvalidProof
+	}
+
+	now := time.Now()
+	issuedAt := c.IssuedAt.Time
+	if issuedAt.Before(now.Add(-v.lifetime)) || issuedAt.After(now.Add(v.lifetime)) {
+		return "", ErrInvalidProof
+	}
+
+	if req.AccessToken != "" && c.ATH != AccessTokenHash(req.AccessToken) {
+		return "", ErrInvalidProof
+	}
+
+	jkt := key.Thumbprint()
+
+	// The proof is acceptable for two lifetimes around its iat, it has to be remembered as long.
+	if err := v.replay.Use(ctx, jkt+":"+c.ID, 2*v.lifetime); err != nil {
+		return "", err
+	}
+
+	return jkt, nil
+}
+
+// AccessTokenHash is the ath claim of proofs presented along the access token.
+func AccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// RequestURL is the htu of a proof for the request, without query nor fragment. The scheme of TLS
+// terminated by a proxy is read from X-Forwarded-Proto.
+func RequestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	} else if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.ToLower(strings.TrimSpace(strings.Split(proto, ",")[0]))
+	}
+
+	return scheme + "://" + r.Host + r.URL.Path
+}
@@ -0,0 +1,226 @@
+package dpop_test
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"gomonitor/internal/pkg/dpop"
+	"gomonitor/internal/pkg/dpop/dpoptest"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tokenURL = "https://api.example.com/api/v1/auth/login"
+
+// memoryReplayStore remembers the proofs of a single test.
+type memoryReplayStore struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (s *memoryReplayStore) Use(_ context.Context, id string, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.seen[id] {
+		return dpop.ErrReplayed
+	}
+	s.seen[id] = true
+	return nil
+}
+
+func newVerifier() *dpop.Verifier {
+	return dpop.NewVerifier(&memoryReplayStore{seen: map[string]bool{}}, time.Minute)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		proof       func(t *testing.T, key *dpoptest.Key) string
+		request     dpop.Request
+		expectedErr error
+	}{
+		{
+			name: "token endpoint",
+			proof: func(t *testing.T, key *dpoptest.Key) string {
+				proof, err := key.Proof("POST", tokenURL, "")
+				require.NoError(t, err)
+				return proof
+			},
+			request: dpop.Request{Method: "POST", URL: tokenURL},
+		},
+		{
+			name: "bound to the access token",
+			proof: func(t *testing.T, key *dpoptest.Key) string {
+				proof, err := key.Proof("GET", "https://api.example.com/api/v1/users/me", "access")
+				require.NoError(t, err)
+				return proof
+			},
+			request: dpop.Request{Method: "GET", URL: "https://api.example.com/api/v1/users/me", AccessToken: "access"},
+		},
+		{
+			name: "other access token",
+			proof: func(t *testing.T, key *dpoptest.Key) string {
+				proof, err := key.Proof("GET", "https://api.example.com/api/v1/users/me", "other")
+				require.NoError(t, err)
+				return proof
+			},
+			request:     dpop.Request{Method: "GET", URL: "https://api.example.com/api/v1/users/me", AccessToken: "access"},
+			expectedErr: dpop.ErrInvalidProof,
+		},
+		{
+			name: "other method",
+			proof: func(t *testing.T, key *dpoptest.Key) string {
+				proof, err := key.Proof("GET", tokenURL, "")
+				require.NoError(t, err)
+				return proof
+			},
+			request:     dpop.Request{Method: "POST", URL: tokenURL},
+			expectedErr: dpop.ErrInvalidProof,
+		},
+		{
+			name: "other url",
+			proof: func(t *testing.T, key *dpoptest.Key) string {
+				proof, err := key.Proof("POST", "https://api.example.com/api/v1/auth/refresh", "")
+				require.NoError(t, err)
+				return proof
+			},
+			request:     dpop.Request{Method: "POST", URL: tokenURL},
+			expectedErr: dpop.ErrInvalidProof,
+		},
+		{
+			name: "too old",
+			proof: func(t *testing.T, key *dpoptest.Key) string {
+				key.IssuedAt = time.Now().Add(-2 * time.Minute)
+				proof, err := key.Proof("POST", tokenURL, "")
+				require.NoError(t, err)
+				return proof
+			},
+			request:     dpop.Request{Method: "POST", URL: tokenURL},
+			expectedErr: dpop.ErrInvalidProof,
+		},
+		{
+			name: "issued in the future",
+			proof: func(t *testing.T, key *dpoptest.Key) string {
+				key.IssuedAt = time.Now().Add(2 * time.Minute)
+				proof, err := key.Proof("POST", tokenURL, "")
+				require.NoError(t, err)
+				return proof
+			},
+			request:     dpop.Request{Method: "POST", URL: tokenURL},
+			expectedErr: dpop.ErrInvalidProof,
+		},
+		{
+			name: "tampered",
+			proof: func(t *testing.T, key *dpoptest.Key) string {
+				proof, err := key.Proof("POST", tokenURL, "")
+				require.NoError(t, err)
+				other, err := key.Proof("GET", tokenURL, "")
+				require.NoError(t, err)
+
+				parts, otherParts := strings.Split(proof, "."), strings.Split(other, ".")
+				return parts[0] + "." + otherParts[1] + "." + parts[2]
+			},
+			request:     dpop.Request{Method: "GET", URL: tokenURL},
+			expectedErr: dpop.ErrInvalidProof,
+		},
+		{
+			name: "symmetric algorithm",
+			proof: func(t *testing.T, key *dpoptest.Key) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+					"jti": "1", "htm": "POST", "htu": tokenURL, "iat": time.Now().Unix(),
+				})
+				token.Header["typ"] = "dpop+jwt"
+				token.Header["jwk"] = key.JWK()
+				proof, err := token.SignedString([]byte("secret"))
+				require.NoError(t, err)
+				return proof
+			},
+			request:     dpop.Request{Method: "POST", URL: tokenURL},
+			expectedErr: dpop.ErrInvalidProof,
+		},
+		{
+			name: "not a proof",
+			proof: func(t *testing.T, key *dpoptest.Key) string {
+				return "not.a.proof"
+			},
+			request:     dpop.Request{Method: "POST", URL: tokenURL},
+			expectedErr: dpop.ErrInvalidProof,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			key, err := dpoptest.NewKey()
+			require.NoError(t, err)
+
+			jkt, err := newVerifier().Verify(t.Context(), tt.proof(t, key), tt.request)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Empty(t, jkt)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, key.Thumbprint(), jkt)
+		})
+	}
+}
+
+func TestVerifier_VerifyReplay(t *testing.T) {
+	t.Parallel()
+	verifier := newVerifier()
+	key, err := dpoptest.NewKey()
+	require.NoError(t, err)
+
+	proof, err := key.Proof("POST", tokenURL, "")
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(t.Context(), proof, dpop.Request{Method: "POST", URL: tokenURL})
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(t.Context(), proof, dpop.Request{Method: "POST", URL: tokenURL})
+	assert.ErrorIs(t, err, dpop.ErrReplayed)
+}
+
+func TestVerifier_VerifyReplayStoreError(t *testing.T) {
+	t.Parallel()
+	key, err := dpoptest.NewKey()
+	require.NoError(t, err)
+
+	proof, err := key.Proof("POST", tokenURL, "")
+	require.NoError(t, err)
+
+	_, err = dpop.NewVerifier(failingReplayStore{}, time.Minute).Verify(t.Context(), proof, dpop.Request{Method: "POST", URL: tokenURL})
+	assert.EqualError(t, err, "circuit breaker is open")
+}
+
+type failingReplayStore struct{}
+
+func (failingReplayStore) Use(context.Context, string, time.Duration) error {
+	return errors.New("circuit breaker is open")
+}
+
+func TestRequestURL(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest("POST", "http://api.example.com/api/v1/auth/login?x=1", nil)
+	assert.Equal(t, "http://api.example.com/api/v1/auth/login", dpop.RequestURL(req))
+
+	req.Header.Set("X-Forwarded-Proto", "HTTPS, http")
+	assert.Equal(t, "https://api.example.com/api/v1/auth/login", dpop.RequestURL(req))
+
+	req = httptest.NewRequest("POST", "http://api.example.com/api/v1/auth/login", nil)
+	req.TLS = &tls.ConnectionState{}
+	assert.Equal(t, "https://api.example.com/api/v1/auth/login", dpop.RequestURL(req))
+}
@@ -0,0 +1,71 @@
+// Package dpoptest plays the client of DPoP in tests, signing proofs with an ephemeral key.
+package dpoptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"gomonitor/internal/pkg/dpop"
+	pkgjwt "gomonitor/internal/pkg/jwt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Key is a P-256 key pair of a client.
+type Key struct {
+	private *ecdsa.PrivateKey
+	// IssuedAt overrides the iat of the proofs, now when zero.
+	IssuedAt time.Time
+}
+
+func NewKey() (*Key, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{private: private}, nil
+}
+
+// JWK is the public key sent in the header of the proofs.
+func (k *Key) JWK() pkgjwt.JWK {
+	pub := k.private.PublicKey
+	return pkgjwt.JWK{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// Thumbprint is the jkt tokens are bound to.
+func (k *Key) Thumbprint() string {
+	return k.JWK().Thumbprint()
+}
+
+// Proof signs a proof for the request, bound to the access token unless it is empty.
+func (k *Key) Proof(method, url, accessToken string) (string, error) {
+	issuedAt := k.IssuedAt
+	if issuedAt.IsZero() {
+		issuedAt = time.Now()
+	}
+
+	claims := jwt.MapClaims{
+		"jti": uuid.NewString(),
+		"htm": method,
+		"htu": url,
+		"iat": issuedAt.Unix(),
+	}
+	if accessToken != "" {
+		claims["ath"] = dpop.AccessTokenHash(accessToken)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = k.JWK()
+
+	return token.SignedString(k.private)
+}
@@ -0,0 +1,55 @@
+package dpop
+
+import (
+	"context"
+	"errors"
+	redisinfra "gomonitor/internal/infra/redis"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ReplayStore remembers the proofs already presented.
+type ReplayStore interface {
+	// Use records the proof, ErrReplayed if it already was.
+	Use(ctx context.Context, id string, ttl time.Duration) error
+}
+
+type redisReplayStore struct {
+	redisClient redisinfra.RedisClient
+	keyPrefix   string
+}
+
+type RedisOption func(*redisReplayStore)
+
+// NewRedisReplayStore shares the proofs seen between instances, a replay may reach another one.
+func NewRedisReplayStore(redisClient redisinfra.RedisClient, opts ...RedisOption) ReplayStore {
+	s := &redisReplayStore{
+		redisClient: redisClient,
+		keyPrefix:   "dpop_jti",
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func WithPrefix(prefix string) RedisOption {
+	return func(s *redisReplayStore) {
+		s.keyPrefix = prefix
+	}
+}
+
+// Use sets the key only if it doesn't exist, so concurrent replays can't both succeed.
+func (s *redisReplayStore) Use(ctx context.Context, id string, ttl time.Duration) error {
+	script := `return redis.call('SET', KEYS[1], '1', 'NX', 'PX', ARGV[1])`
+
+	_, err := s.redisClient.Eval(ctx, script, []string{s.keyPrefix + ":" + id}, ttl.Milliseconds())
+	if errors.Is(err, redis.Nil) {
+		return ErrReplayed
+	}
+
+	return err
+}
@@ -0,0 +1,61 @@
+package dpop_test
+
+import (
+	"errors"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/dpop"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRedisReplayStore_Use(t *testing.T) {
+	t.Parallel()
+	keys := []string{"dpop_jti:jkt:jti"}
+
+	tests := []struct {
+		name        string
+		setupMock   func(*mocks.MockRedisClient)
+		expectedErr error
+	}{
+		{
+			name: "first use",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Eval", mock.Anything, mock.Anything, keys, []any{int64(120000)}).Return("OK", nil)
+			},
+		},
+		{
+			name: "replayed",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Eval", mock.Anything, mock.Anything, keys, mock.Anything).Return(nil, redis.Nil)
+			},
+			expectedErr: dpop.ErrReplayed,
+		},
+		{
+			name: "redis unavailable",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Eval", mock.Anything, mock.Anything, keys, mock.Anything).Return(nil, errors.New("circuit breaker is open"))
+			},
+			expectedErr: errors.New("circuit breaker is open"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mocks.MockRedisClient{}
+			tt.setupMock(client)
+
+			err := dpop.NewRedisReplayStore(client).Use(t.Context(), "jkt:jti", 2*time.Minute)
+
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			client.AssertExpectations(t)
+		})
+	}
+}
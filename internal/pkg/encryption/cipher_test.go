@@ -0,0 +1,64 @@
+package encryption_test
+
+import (
+	"bytes"
+	"gomonitor/internal/pkg/encryption"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAESCipher(t *testing.T) {
+	_, err := encryption.NewAESCipher([]byte("short"))
+	assert.Error(t, err)
+
+	c, err := encryption.NewAESCipher(bytes.Repeat([]byte("k"), encryption.KeySize))
+	require.NoError(t, err)
+	assert.NotNil(t, c)
+}
+
+func TestAESCipher_RoundTrip(t *testing.T) {
+	c, err := encryption.NewAESCipher(bytes.Repeat([]byte("k"), encryption.KeySize))
+	require.NoError(t, err)
+
+	first, err := c.Encrypt([]byte("secret"))
+	require.NoError(t, err)
+	second, err := c.Encrypt([]byte("secret"))
+	require.NoError(t, err)
+
+	// Random nonces, the same value never encrypts twice the same way.
+	assert.NotEqual(t, first, second)
+	assert.NotContains(t, first, "secret")
+
+	plaintext, err := c.Decrypt(first)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+}
+
+func TestAESCipher_DecryptErrors(t *testing.T) {
+	c, err := encryption.NewAESCipher(bytes.Repeat([]byte("k"), encryption.KeySize))
+	require.NoError(t, err)
+
+	other, err := encryption.NewAESCipher(bytes.Repeat([]byte("o"), encryption.KeySize))
+	require.NoError(t, err)
+
+	sealed, err := other.Encrypt([]byte("secret"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		ciphertext string
+	}{
+		{name: "not base64", ciphertext: "%%%"},
+		{name: "too short", ciphertext: "AAAA"},
+		{name: "wrong key", ciphertext: sealed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.Decrypt(tt.ciphertext)
+			assert.ErrorIs(t, err, encryption.ErrInvalidCiphertext)
+		})
+	}
+}
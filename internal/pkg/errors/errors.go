@@ -2,6 +2,7 @@ package errors
 
 type AppError struct {
 	Code       string
+	Fields     []FieldError
 	File       string
 	Line       int
 	Message    string
@@ -12,3 +13,10 @@ type AppError struct {
 func (e *AppError) Error() string {
 	return e.Message
 }
+
+// FieldError tells which rule a request field breaks.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -55,6 +55,19 @@ func TestNewUnauthorizedError(t *testing.T) {
 	}
 }
 
+func TestNewReauthenticationRequiredError(t *testing.T) {
+	t.Parallel()
+	err := pkgerrors.NewReauthenticationRequiredError("recent authentication required")
+
+	if err.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, err.StatusCode)
+	}
+
+	if err.Code != "REAUTHENTICATION_REQUIRED" {
+		t.Errorf("expected code REAUTHENTICATION_REQUIRED, got %s", err.Code)
+	}
+}
+
 func TestNewNotFoundError(t *testing.T) {
 	t.Parallel()
 	err := pkgerrors.NewNotFoundError("not found")
@@ -150,3 +163,16 @@ func TestCallerFailureCoverage(t *testing.T) {
 		t.Fatalf("expected unknown file")
 	}
 }
+
+func TestNewValidationError(t *testing.T) {
+	t.Parallel()
+	field := pkgerrors.FieldError{Field: "password", Code: "too_short", Message: "too short"}
+
+	err := pkgerrors.NewValidationError("invalid password", field)
+
+	assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+	assert.Equal(t, "VALIDATION_ERROR", err.Code)
+	assert.Equal(t, "invalid password", err.Message)
+	assert.Equal(t, []pkgerrors.FieldError{field}, err.Fields)
+	assert.NotEmpty(t, err.File)
+}
@@ -11,10 +11,23 @@ func NewBadRequestError(msg string, err ...error) *AppError {
 	return newAppError("BAD_REQUEST", msg, http.StatusBadRequest, err...)
 }
 
+// NewValidationError is a bad request listing every field violation.
+func NewValidationError(msg string, fields ...FieldError) *AppError {
+	appErr := newAppError("VALIDATION_ERROR", msg, http.StatusBadRequest)
+	appErr.Fields = fields
+	return appErr
+}
+
 func NewUnauthorizedError(msg string, err ...error) *AppError {
 	return newAppError("UNAUTHORIZED", msg, http.StatusUnauthorized, err...)
 }
 
+// NewReauthenticationRequiredError is an unauthorized error telling the user to prove their credentials again,
+// their last authentication is too old for the operation.
+func NewReauthenticationRequiredError(msg string) *AppError {
+	return newAppError("REAUTHENTICATION_REQUIRED", msg, http.StatusUnauthorized)
+}
+
 func NewNotFoundError(msg string, err ...error) *AppError {
 	return newAppError("NOT_FOUND", msg, http.StatusNotFound, err...)
 }
@@ -0,0 +1,25 @@
+package identity
+
+import "slices"
+
+// Permission is an action a role may be granted, roles and their permissions are stored in the database.
+type Permission string
+
+const (
+	PermUsersCreate Permission = "users:create"
+	PermUsersRead   Permission = "users:read"
+	// PermUsersImpersonate issues tokens acting as another user, limited to users with no more permissions.
+	PermUsersImpersonate  Permission = "users:impersonate"
+	PermSessionsRead      Permission = "sessions:read"
+	PermSessionsRevoke    Permission = "sessions:revoke"
+	PermSigningKeysRead   Permission = "signing_keys:read"
+	PermSigningKeysWrite  Permission = "signing_keys:write"
+	PermOAuthClientsRead  Permission = "oauth_clients:read"
+	PermOAuthClientsWrite Permission = "oauth_clients:write"
+	PermAuthEventsRead    Permission = "auth_events:read"
+)
+
+// HasPermission reports if the role of the principal grants the permission.
+func (p *Principal) HasPermission(permission Permission) bool {
+	return slices.Contains(p.Permissions, permission)
+}
@@ -2,6 +2,8 @@ package identity
 
 import (
 	"context"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,15 +14,56 @@ type AuthSource string
 const (
 	AuthExternal AuthSource = "external"
 	AuthInternal AuthSource = "internal"
+	// AuthAPIKey authenticates machine clients with a long-lived key instead of an interactive session.
+	AuthAPIKey AuthSource = "api_key"
+	// AuthClient authenticates services with a client credentials token, they act as no user.
+	AuthClient AuthSource = "client"
+)
+
+// APIKeyPrefix starts every API key, telling them apart from access tokens in the Authorization header.
+const APIKeyPrefix = "gmk_"
+
+// Scopes granted to API keys and OAuth clients.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
 )
 
 type Principal struct {
 	UserID uint
 	Role   UserRole
 	Source AuthSource
+	// ClientID is set instead of UserID for OAuth clients.
+	ClientID string
+	// Scopes restrict what the principal can do, nil for sessions which aren't restricted.
+	Scopes []string
+	// Permissions are granted by Role, they are resolved on every request.
+	Permissions []Permission
+	// ActorID is the admin acting as UserID, zero unless the principal is impersonated.
+	ActorID uint
+
+	JTI *uuid.UUID // nil for access tokens
+	// SessionID is the refresh token family of session access tokens and the jti of impersonation tokens,
+	// nil otherwise. The denylist revokes tokens by it.
+	SessionID *uuid.UUID
+	// JKT is the thumbprint of the DPoP key the token is bound to, empty for bearer tokens.
+	JKT string
+	// ExpiresAt is the expiry of the token the principal was read from, zero for API keys.
+	ExpiresAt time.Time
+	// AuthTime is when the user last proved their credentials with the methods of AMR, zero unless the
+	// principal is a session. Sensitive operations require it to be recent.
+	AuthTime time.Time
+	AMR      []string
+}
+
+// HasScope reports if the principal was granted the scope.
+func (p *Principal) HasScope(scope string) bool {
+	return p.Scopes == nil || slices.Contains(p.Scopes, scope)
+}
 
-	JTI        *uuid.UUID // nil for access tokens
-	RefreshJTI *uuid.UUID
+// Impersonated reports if an admin acts as the user, sensitive operations are refused then.
+func (p *Principal) Impersonated() bool {
+	return p.ActorID != 0
 }
 
 type principalKeyType struct{}
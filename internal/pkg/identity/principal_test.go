@@ -34,3 +34,29 @@ func TestPrincipalFromContext(t *testing.T) {
 	assert.True(t, ok)
 	assert.NotNil(t, p)
 }
+
+func TestPrincipal_HasScope(t *testing.T) {
+	session := &Principal{UserID: 1, Source: AuthExternal}
+	assert.True(t, session.HasScope(ScopeWrite))
+
+	readOnly := &Principal{UserID: 1, Source: AuthAPIKey, Scopes: []string{ScopeRead}}
+	assert.True(t, readOnly.HasScope(ScopeRead))
+	assert.False(t, readOnly.HasScope(ScopeWrite))
+
+	none := &Principal{UserID: 1, Source: AuthAPIKey, Scopes: []string{}}
+	assert.False(t, none.HasScope(ScopeRead))
+}
+
+func TestPrincipal_HasPermission(t *testing.T) {
+	admin := &Principal{UserID: 1, Role: RoleAdmin, Permissions: []Permission{PermUsersCreate}}
+	assert.True(t, admin.HasPermission(PermUsersCreate))
+	assert.False(t, admin.HasPermission(PermSessionsRevoke))
+
+	user := &Principal{UserID: 2, Role: RoleUser}
+	assert.False(t, user.HasPermission(PermUsersCreate))
+}
+
+func TestPrincipal_Impersonated(t *testing.T) {
+	assert.False(t, (&Principal{UserID: 2}).Impersonated())
+	assert.True(t, (&Principal{UserID: 2, ActorID: 1}).Impersonated())
+}
@@ -1,8 +1,11 @@
 package identity
 
+// UserRole names a role of the roles table, other roles can be added there without code changes.
 type UserRole string
 
+// Roles created with the schema.
 const (
-	RoleAdmin UserRole = "admin"
-	RoleUser  UserRole = "user"
+	RoleAdmin     UserRole = "admin"
+	RoleModerator UserRole = "moderator"
+	RoleUser      UserRole = "user"
 )
@@ -11,13 +11,42 @@ type TokenType string
 const (
 	TokenTypeAccess  TokenType = "access"
 	TokenTypeRefresh TokenType = "refresh"
+	// TokenTypeMFA proves the password step of a login, it is only exchanged for a token pair with a second factor.
+	TokenTypeMFA TokenType = "mfa"
+	// TokenTypeMagicLink is sent by mail for a passwordless login, it is exchanged once for a token pair.
+	TokenTypeMagicLink TokenType = "magic_link"
 )
 
+// singleUse reports if the token is identified by its own jti, to be exchanged or rotated only once.
+func (t TokenType) singleUse() bool {
+	return t == TokenTypeRefresh || t == TokenTypeMFA || t == TokenTypeMagicLink
+}
+
 type CustomClaims struct {
-	Type       TokenType `json:"typ"`
-	UserID     uint      `json:"sub"`
-	Role       identity.UserRole
-	JTI        string `json:"jti,omitempty"`
-	RefreshJTI string `json:"refresh_jti,omitempty"`
+	Type   TokenType `json:"typ"`
+	UserID uint      `json:"sub,omitempty"`
+	Role   identity.UserRole
+	JTI    string `json:"jti,omitempty"`
+	// SessionID is only part of session access tokens, it is the refresh token family they were issued for.
+	SessionID string `json:"sid,omitempty"`
+	// ClientID and Scope are only part of client credentials access tokens, Scope is space separated.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// Actor is only part of impersonation tokens, it names the admin acting as the subject (RFC 8693).
+	Actor *ActorClaim `json:"act,omitempty"`
+	// Confirmation binds the access token to the DPoP key of the client (RFC 9449).
+	Confirmation *ConfirmationClaim `json:"cnf,omitempty"`
+	// AuthTime and AMR are only part of session access tokens, they tell when and how the user last
+	// proved their credentials (OpenID Connect Core, RFC 8176). Login links carry the AMR proved before them.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
 	jwt.RegisteredClaims
 }
+
+type ConfirmationClaim struct {
+	JKT string `json:"jkt"`
+}
+
+type ActorClaim struct {
+	UserID uint `json:"sub"`
+}
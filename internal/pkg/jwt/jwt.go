@@ -4,6 +4,7 @@ import (
 	"errors"
 	"gomonitor/internal/config"
 	"gomonitor/internal/pkg/identity"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,22 +15,62 @@ var (
 	ErrInvalidToken      = errors.New("invalid token")
 	ErrInvalidSignMethod = errors.New("invalid signing method")
 	ErrInvalidTokenType  = errors.New("invalid token type")
+	ErrUnknownKey        = errors.New("unknown signing key")
 )
 
 type TokenManager interface {
 	GenerateRefreshToken(userID uint, role identity.UserRole) (*RefreshTokenResult, error)
-	GenerateAccessToken(userID uint, role identity.UserRole, refreshTokenJTI uuid.UUID) (*AccessTokenResult, error)
+	// GenerateAccessToken issues a token for the session, the refresh token family sessionID, revoking the session
+	// rejects it. The token is bound to the DPoP key with the thumbprint jkt, unless it is empty.
+	// It carries the last authentication of the session in its auth_time and amr claims.
+	GenerateAccessToken(userID uint, role identity.UserRole, sessionID uuid.UUID, jkt string, auth Authentication) (*AccessTokenResult, error)
+	GenerateClientToken(clientID string, scopes []string) (*AccessTokenResult, error)
+	GenerateImpersonationToken(userID uint, role identity.UserRole, actorID uint) (*AccessTokenResult, error)
 	ValidateRefreshToken(tokenString string) (*identity.Principal, error)
 	ValidateAccessToken(tokenString string) (*identity.Principal, error)
+	GenerateMFAToken(userID uint, role identity.UserRole) (*MFATokenResult, error)
+	ValidateMFAToken(tokenString string) (*identity.Principal, error)
+	// GenerateMagicLinkToken issues the token of a login link, amr are the methods the user already proved
+	// before it was mailed, like the password of a stepped-up login.
+	GenerateMagicLinkToken(userID uint, role identity.UserRole, amr []string) (*MagicLinkTokenResult, error)
+	ValidateMagicLinkToken(tokenString string) (*identity.Principal, error)
+	JWKS() JWKS
 }
 
 type tokenManager struct {
-	cfg *config.AuthConfig
+	cfg            *config.AuthConfig
+	accessKeyring  *Keyring
+	refreshKeyring *Keyring
 }
 
-func NewTokenManager(cfg *config.AuthConfig) TokenManager {
-	return &tokenManager{
-		cfg: cfg,
+type TokenManagerOption func(*tokenManager)
+
+// NewTokenManager creates a token manager, by default both token types are signed with the configured HMAC secrets.
+func NewTokenManager(cfg *config.AuthConfig, opts ...TokenManagerOption) TokenManager {
+	tm := &tokenManager{
+		cfg:            cfg,
+		accessKeyring:  NewKeyring(NewHMACKey(cfg.AccessTokenSecret)),
+		refreshKeyring: NewKeyring(NewHMACKey(cfg.RefreshTokenSecret)),
+	}
+
+	for _, opt := range opts {
+		opt(tm)
+	}
+
+	return tm
+}
+
+// WithAccessKeyring overrides the keys used for access tokens, allowing asymmetric signing and rotation.
+func WithAccessKeyring(keyring *Keyring) TokenManagerOption {
+	return func(tm *tokenManager) {
+		tm.accessKeyring = keyring
+	}
+}
+
+// WithRefreshKeyring overrides the keys used for refresh tokens.
+func WithRefreshKeyring(keyring *Keyring) TokenManagerOption {
+	return func(tm *tokenManager) {
+		tm.refreshKeyring = keyring
 	}
 }
 
@@ -43,6 +84,22 @@ type AccessTokenResult struct {
 	Meta  TokenMetadata
 }
 
+type MFATokenResult struct {
+	Token string
+	Meta  TokenMetadata
+}
+
+type MagicLinkTokenResult struct {
+	Token string
+	Meta  TokenMetadata
+}
+
+// Authentication is when the user of a session last proved their credentials, and with which methods.
+type Authentication struct {
+	Time    time.Time
+	Methods []string
+}
+
 type TokenMetadata struct {
 	JTI       uuid.UUID
 	IssuedAt  time.Time
@@ -52,7 +109,7 @@ type TokenMetadata struct {
 func (t *tokenManager) GenerateRefreshToken(userID uint, role identity.UserRole) (*RefreshTokenResult, error) {
 	now := time.Now()
 	expiresAt := now.Add(t.cfg.RefreshTokenTTL)
-	token, metadata, err := t.generateToken(userID, role, TokenTypeRefresh, uuid.New(), expiresAt, now, t.cfg.RefreshTokenSecret)
+	token, metadata, err := t.generateToken(userID, role, TokenTypeRefresh, uuid.New(), "", Authentication{}, expiresAt, now, t.refreshKeyring.Current())
 	if err != nil {
 		return nil, err
 	}
@@ -63,28 +120,143 @@ func (t *tokenManager) GenerateRefreshToken(userID uint, role identity.UserRole)
 	}, nil
 }
 
-func (t *tokenManager) GenerateAccessToken(userID uint, role identity.UserRole, refreshTokenJTI uuid.UUID) (*AccessTokenResult, error) {
+func (t *tokenManager) GenerateAccessToken(
+	userID uint,
+	role identity.UserRole,
+	sessionID uuid.UUID,
+	jkt string,
+	auth Authentication,
+) (*AccessTokenResult, error) {
 	now := time.Now()
 	expiresAt := now.Add(t.cfg.AccessTokenTTL)
-	token, metadata, err := t.generateToken(userID, role, TokenTypeAccess, refreshTokenJTI, expiresAt, now, t.cfg.AccessTokenSecret)
+	token, metadata, err := t.generateToken(userID, role, TokenTypeAccess, sessionID, jkt, auth, expiresAt, now, t.accessKeyring.Current())
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessTokenResult{
+		Token: token,
+		Meta:  metadata,
+	}, nil
+}
+
+// GenerateClientToken issues an access token to an OAuth client, it has no session and lives until it expires.
+func (t *tokenManager) GenerateClientToken(clientID string, scopes []string) (*AccessTokenResult, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.cfg.AccessTokenTTL)
+	jti := uuid.New()
+	key := t.accessKeyring.Current()
+
+	claims := CustomClaims{
+		Type:     TokenTypeAccess,
+		JTI:      jti.String(),
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	tokenStr, err := token.SignedString(key.signKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessTokenResult{
+		Token: tokenStr,
+		Meta: TokenMetadata{
+			JTI:       jti,
+			IssuedAt:  now,
+			ExpiresAt: expiresAt,
+		},
+	}, nil
+}
+
+// GenerateImpersonationToken issues an access token acting as the user on behalf of the actor.
+// It can't be refreshed, its session is named after its jti so the denylist can end it before it expires.
+func (t *tokenManager) GenerateImpersonationToken(userID uint, role identity.UserRole, actorID uint) (*AccessTokenResult, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.cfg.ImpersonationTTL)
+	jti := uuid.New()
+	key := t.accessKeyring.Current()
+
+	claims := CustomClaims{
+		Type:      TokenTypeAccess,
+		UserID:    userID,
+		Role:      role,
+		JTI:       jti.String(),
+		SessionID: jti.String(),
+		Actor:     &ActorClaim{UserID: actorID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	tokenStr, err := token.SignedString(key.signKey)
 	if err != nil {
 		return nil, err
 	}
 
 	return &AccessTokenResult{
+		Token: tokenStr,
+		Meta: TokenMetadata{
+			JTI:       jti,
+			IssuedAt:  now,
+			ExpiresAt: expiresAt,
+		},
+	}, nil
+}
+
+// GenerateMFAToken issues the challenge returned by a login that still needs a second factor.
+// It is signed with the refresh keyring, since only this service verifies it.
+func (t *tokenManager) GenerateMFAToken(userID uint, role identity.UserRole) (*MFATokenResult, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.cfg.MFAChallengeTTL)
+	token, metadata, err := t.generateToken(userID, role, TokenTypeMFA, uuid.New(), "", Authentication{}, expiresAt, now, t.refreshKeyring.Current())
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFATokenResult{
+		Token: token,
+		Meta:  metadata,
+	}, nil
+}
+
+// GenerateMagicLinkToken issues the token of a login link, signed with the refresh keyring as well.
+func (t *tokenManager) GenerateMagicLinkToken(userID uint, role identity.UserRole, amr []string) (*MagicLinkTokenResult, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.cfg.MagicLinkTTL)
+	token, metadata, err := t.generateToken(userID, role, TokenTypeMagicLink, uuid.New(), "", Authentication{Methods: amr}, expiresAt, now, t.refreshKeyring.Current())
+	if err != nil {
+		return nil, err
+	}
+
+	return &MagicLinkTokenResult{
 		Token: token,
 		Meta:  metadata,
 	}, nil
 }
 
+// generateToken signs a token of the type, jtiUUID identifies single use tokens and is the session of access tokens.
 func (t *tokenManager) generateToken(
 	userID uint,
 	role identity.UserRole,
 	tokenType TokenType,
 	jtiUUID uuid.UUID,
+	jkt string,
+	auth Authentication,
 	expiresAt time.Time,
 	issuedAt time.Time,
-	secret string,
+	key *SigningKey,
 ) (string, TokenMetadata, error) {
 	claims := CustomClaims{
 		Type:   tokenType,
@@ -96,17 +268,27 @@ func (t *tokenManager) generateToken(
 		},
 	}
 
-	if tokenType == TokenTypeRefresh {
+	if tokenType.singleUse() {
 		claims.JTI = jtiUUID.String()
 	}
 
 	if tokenType == TokenTypeAccess {
-		claims.RefreshJTI = jtiUUID.String()
+		claims.SessionID = jtiUUID.String()
+	}
+
+	if jkt != "" {
+		claims.Confirmation = &ConfirmationClaim{JKT: jkt}
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if !auth.Time.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(auth.Time)
+	}
+	claims.AMR = auth.Methods
 
-	tokenStr, err := token.SignedString([]byte(secret))
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	tokenStr, err := token.SignedString(key.signKey)
 	tokenMetadata := TokenMetadata{
 		JTI:       jtiUUID,
 		IssuedAt:  issuedAt,
@@ -117,20 +299,40 @@ func (t *tokenManager) generateToken(
 }
 
 func (t *tokenManager) ValidateRefreshToken(tokenString string) (*identity.Principal, error) {
-	return t.validateToken(tokenString, TokenTypeRefresh, t.cfg.RefreshTokenSecret)
+	return t.validateToken(tokenString, TokenTypeRefresh, t.refreshKeyring)
 }
 
 func (t *tokenManager) ValidateAccessToken(tokenString string) (*identity.Principal, error) {
-	return t.validateToken(tokenString, TokenTypeAccess, t.cfg.AccessTokenSecret)
+	return t.validateToken(tokenString, TokenTypeAccess, t.accessKeyring)
+}
+
+func (t *tokenManager) ValidateMFAToken(tokenString string) (*identity.Principal, error) {
+	return t.validateToken(tokenString, TokenTypeMFA, t.refreshKeyring)
+}
+
+func (t *tokenManager) ValidateMagicLinkToken(tokenString string) (*identity.Principal, error) {
+	return t.validateToken(tokenString, TokenTypeMagicLink, t.refreshKeyring)
+}
+
+// JWKS returns the public access token keys, empty when signing with a shared secret.
+func (t *tokenManager) JWKS() JWKS {
+	return t.accessKeyring.JWKS()
 }
 
-func (t *tokenManager) validateToken(tokenString string, tokenType TokenType, secret string) (*identity.Principal, error) {
+func (t *tokenManager) validateToken(tokenString string, tokenType TokenType, keyring *Keyring) (*identity.Principal, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := keyring.Lookup(kid)
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+
+		// Only accept the exact algorithm of the key, avoiding algorithm confusion.
+		if t.Method.Alg() != key.Method.Alg() {
 			return nil, ErrInvalidSignMethod
 		}
-		return []byte(secret), nil
-	})
+		return key.verifyKey, nil
+	}, jwt.WithExpirationRequired())
 
 	if err != nil || !token.Valid {
 		return nil, ErrInvalidToken
@@ -142,8 +344,26 @@ func (t *tokenManager) validateToken(tokenString string, tokenType TokenType, se
 		return nil, ErrInvalidTokenType
 	}
 
+	// Guaranteed by the parser, it refuses tokens without exp.
+	expiresAt := claims.ExpiresAt.Time
+
+	if tokenType == TokenTypeAccess && claims.ClientID != "" {
+		principal := clientPrincipal(claims)
+		principal.ExpiresAt = expiresAt
+		return principal, nil
+	}
+
+	if tokenType == TokenTypeAccess && claims.Actor != nil {
+		principal, err := impersonatedPrincipal(claims)
+		if err != nil {
+			return nil, err
+		}
+		principal.ExpiresAt = expiresAt
+		return principal, nil
+	}
+
 	var jti *uuid.UUID
-	if tokenType == TokenTypeRefresh {
+	if tokenType.singleUse() {
 		parsed, err := uuid.Parse(claims.JTI)
 		if err != nil {
 			return nil, ErrInvalidToken
@@ -151,20 +371,66 @@ func (t *tokenManager) validateToken(tokenString string, tokenType TokenType, se
 		jti = &parsed
 	}
 
-	var refreshjti *uuid.UUID
+	var sessionID *uuid.UUID
 	if tokenType == TokenTypeAccess {
-		parsed, err := uuid.Parse(claims.RefreshJTI)
+		parsed, err := uuid.Parse(claims.SessionID)
 		if err != nil {
 			return nil, ErrInvalidToken
 		}
-		refreshjti = &parsed
+		sessionID = &parsed
+	}
+
+	var jkt string
+	if claims.Confirmation != nil {
+		jkt = claims.Confirmation.JKT
+	}
+
+	var authTime time.Time
+	if claims.AuthTime != nil {
+		authTime = claims.AuthTime.Time
 	}
 
 	return &identity.Principal{
-		UserID:     claims.UserID,
-		Role:       claims.Role,
-		Source:     identity.AuthExternal,
-		JTI:        jti,
-		RefreshJTI: refreshjti,
+		UserID:    claims.UserID,
+		Role:      claims.Role,
+		Source:    identity.AuthExternal,
+		JTI:       jti,
+		SessionID: sessionID,
+		JKT:       jkt,
+		ExpiresAt: expiresAt,
+		AuthTime:  authTime,
+		AMR:       claims.AMR,
 	}, nil
 }
+
+// impersonatedPrincipal is the user acted as by the admin of the actor claim.
+func impersonatedPrincipal(claims *CustomClaims) (*identity.Principal, error) {
+	jti, err := uuid.Parse(claims.JTI)
+	if err != nil || claims.Actor.UserID == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &identity.Principal{
+		UserID:    claims.UserID,
+		Role:      claims.Role,
+		Source:    identity.AuthExternal,
+		ActorID:   claims.Actor.UserID,
+		JTI:       &jti,
+		SessionID: &sessionID,
+	}, nil
+}
+
+// clientPrincipal is an OAuth client, limited to the scopes of its token.
+func clientPrincipal(claims *CustomClaims) *identity.Principal {
+	return &identity.Principal{
+		ClientID: claims.ClientID,
+		Source:   identity.AuthClient,
+		// Never nil, a token without scopes grants nothing.
+		Scopes: append([]string{}, strings.Fields(claims.Scope)...),
+	}
+}
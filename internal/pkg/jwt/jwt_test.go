@@ -20,6 +20,9 @@ var testConfig = &config.AuthConfig{
 	AccessTokenTTL:     time.Hour,
 	RefreshTokenSecret: "refresh",
 	RefreshTokenTTL:    time.Hour * 24,
+	MFAChallengeTTL:    time.Minute * 5,
+	MagicLinkTTL:       time.Minute * 15,
+	ImpersonationTTL:   time.Minute * 15,
 }
 
 func TestNewTokenManager(t *testing.T) {
@@ -44,7 +47,7 @@ func TestGenerateTokens(t *testing.T) {
 		{
 			name: "access token",
 			generate: func(tm pkgjwt.TokenManager) (tokenTestResult, error) {
-				res, err := tm.GenerateAccessToken(1, identity.RoleAdmin, uuid.UUID{})
+				res, err := tm.GenerateAccessToken(1, identity.RoleAdmin, uuid.UUID{}, "", pkgjwt.Authentication{})
 				if err != nil {
 					return tokenTestResult{}, err
 				}
@@ -106,11 +109,14 @@ func TestGenerateTokens(t *testing.T) {
 
 func TestValidateRefreshToken(t *testing.T) {
 	expectedJti := uuid.New()
+	// Claims carry whole seconds.
+	expiresAt := time.Now().Add(testConfig.RefreshTokenTTL).Truncate(time.Second)
 	expectedPrincipal := &identity.Principal{
-		UserID: 1,
-		Role:   identity.RoleAdmin,
-		Source: identity.AuthExternal,
-		JTI:    &expectedJti,
+		UserID:    1,
+		Role:      identity.RoleAdmin,
+		Source:    identity.AuthExternal,
+		JTI:       &expectedJti,
+		ExpiresAt: expiresAt,
 	}
 
 	tests := []struct {
@@ -129,7 +135,7 @@ func TestValidateRefreshToken(t *testing.T) {
 			name: "wrong secret",
 			tokenGen: func() string {
 				tm := pkgjwt.NewTokenManager(testConfig)
-				token, _ := tm.GenerateAccessToken(1, identity.RoleUser, uuid.UUID{})
+				token, _ := tm.GenerateAccessToken(1, identity.RoleUser, uuid.UUID{}, "", pkgjwt.Authentication{})
 				return token.Token
 			},
 			expectedErr: pkgjwt.ErrInvalidToken,
@@ -180,18 +186,36 @@ func TestValidateRefreshToken(t *testing.T) {
 			},
 			expectedErr: pkgjwt.ErrInvalidTokenType,
 		},
+		{
+			name: "missing expiry",
+			tokenGen: func() string {
+				claims := pkgjwt.CustomClaims{
+					Type:   pkgjwt.TokenTypeRefresh,
+					UserID: 1,
+					Role:   identity.RoleAdmin,
+					JTI:    expectedJti.String(),
+					RegisteredClaims: jwt.RegisteredClaims{
+						IssuedAt: jwt.NewNumericDate(time.Now()),
+					},
+				}
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+				tokenStr, _ := token.SignedString([]byte(testConfig.RefreshTokenSecret))
+				return tokenStr
+			},
+			expectedErr: pkgjwt.ErrInvalidToken,
+		},
 		{
 			name: "success",
 			tokenGen: func() string {
-				now := time.Now()
 				claims := pkgjwt.CustomClaims{
 					Type:   pkgjwt.TokenTypeRefresh,
 					UserID: 1,
 					Role:   identity.RoleAdmin,
 					JTI:    expectedJti.String(),
 					RegisteredClaims: jwt.RegisteredClaims{
-						ExpiresAt: jwt.NewNumericDate(now.Add(testConfig.RefreshTokenTTL)),
-						IssuedAt:  jwt.NewNumericDate(now),
+						ExpiresAt: jwt.NewNumericDate(expiresAt),
+						IssuedAt:  jwt.NewNumericDate(time.Now()),
 					},
 				}
 				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -221,13 +245,15 @@ func TestValidateRefreshToken(t *testing.T) {
 }
 
 func TestValidateAccessToken(t *testing.T) {
-	defaultJti := uuid.New()
+	defaultSessionID := uuid.New()
+	expiresAt := time.Now().Add(testConfig.AccessTokenTTL).Truncate(time.Second)
 
 	expectedPrincipal := &identity.Principal{
-		UserID:     1,
-		Role:       identity.RoleAdmin,
-		Source:     identity.AuthExternal,
-		RefreshJTI: &defaultJti,
+		UserID:    1,
+		Role:      identity.RoleAdmin,
+		Source:    identity.AuthExternal,
+		SessionID: &defaultSessionID,
+		ExpiresAt: expiresAt,
 	}
 
 	// Just success, logic always should be same as the refresh token generation, just changing type.
@@ -239,15 +265,14 @@ func TestValidateAccessToken(t *testing.T) {
 		{
 			name: "success",
 			tokenGen: func() string {
-				now := time.Now()
 				claims := pkgjwt.CustomClaims{
-					Type:       pkgjwt.TokenTypeAccess,
-					UserID:     1,
-					Role:       identity.RoleAdmin,
-					RefreshJTI: defaultJti.String(),
+					Type:      pkgjwt.TokenTypeAccess,
+					UserID:    1,
+					Role:      identity.RoleAdmin,
+					SessionID: defaultSessionID.String(),
 					RegisteredClaims: jwt.RegisteredClaims{
-						ExpiresAt: jwt.NewNumericDate(now.Add(testConfig.AccessTokenTTL)),
-						IssuedAt:  jwt.NewNumericDate(now),
+						ExpiresAt: jwt.NewNumericDate(expiresAt),
+						IssuedAt:  jwt.NewNumericDate(time.Now()),
 					},
 				}
 				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -275,3 +300,226 @@ func TestValidateAccessToken(t *testing.T) {
 		})
 	}
 }
+
+func TestBoundAccessToken(t *testing.T) {
+	tm := pkgjwt.NewTokenManager(testConfig)
+
+	res, err := tm.GenerateAccessToken(1, identity.RoleUser, uuid.New(), "thumbprint", pkgjwt.Authentication{})
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(res.Token, &pkgjwt.CustomClaims{})
+	require.NoError(t, err)
+	claims, _ := parsed.Claims.(*pkgjwt.CustomClaims)
+	assert.Equal(t, &pkgjwt.ConfirmationClaim{JKT: "thumbprint"}, claims.Confirmation)
+
+	principal, err := tm.ValidateAccessToken(res.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "thumbprint", principal.JKT)
+
+	bearer, err := tm.GenerateAccessToken(1, identity.RoleUser, uuid.New(), "", pkgjwt.Authentication{})
+	require.NoError(t, err)
+
+	principal, err = tm.ValidateAccessToken(bearer.Token)
+	require.NoError(t, err)
+	assert.Empty(t, principal.JKT)
+}
+
+func TestAccessTokenAuthentication(t *testing.T) {
+	tm := pkgjwt.NewTokenManager(testConfig)
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	res, err := tm.GenerateAccessToken(1, identity.RoleUser, uuid.New(), "", pkgjwt.Authentication{
+		Time:    authTime,
+		Methods: []string{"pwd", "otp", "mfa"},
+	})
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(res.Token, &pkgjwt.CustomClaims{})
+	require.NoError(t, err)
+	claims, _ := parsed.Claims.(*pkgjwt.CustomClaims)
+	assert.Equal(t, authTime.Unix(), claims.AuthTime.Unix())
+	assert.Equal(t, []string{"pwd", "otp", "mfa"}, claims.AMR)
+
+	principal, err := tm.ValidateAccessToken(res.Token)
+	require.NoError(t, err)
+	assert.True(t, authTime.Equal(principal.AuthTime))
+	assert.Equal(t, []string{"pwd", "otp", "mfa"}, principal.AMR)
+
+	// Tokens issued before the claims existed have no authentication, they are never recent.
+	legacy, err := tm.GenerateAccessToken(1, identity.RoleUser, uuid.New(), "", pkgjwt.Authentication{})
+	require.NoError(t, err)
+
+	principal, err = tm.ValidateAccessToken(legacy.Token)
+	require.NoError(t, err)
+	assert.True(t, principal.AuthTime.IsZero())
+	assert.Nil(t, principal.AMR)
+}
+
+func TestAsymmetricAccessToken(t *testing.T) {
+	methods := []string{config.SigningMethodRS256, config.SigningMethodES256, config.SigningMethodEdDSA}
+
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			key, err := pkgjwt.ParseSigningKey(method, generatePEM(t, method))
+			require.NoError(t, err)
+
+			tm := pkgjwt.NewTokenManager(testConfig, pkgjwt.WithAccessKeyring(pkgjwt.NewKeyring(key)))
+
+			sessionID := uuid.New()
+			res, err := tm.GenerateAccessToken(1, identity.RoleAdmin, sessionID, "", pkgjwt.Authentication{})
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(res.Token, &pkgjwt.CustomClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, method, parsed.Method.Alg())
+			assert.Equal(t, key.ID, parsed.Header["kid"])
+
+			principal, err := tm.ValidateAccessToken(res.Token)
+			require.NoError(t, err)
+			assert.Equal(t, sessionID, *principal.SessionID)
+
+			// Refresh tokens keep the shared secret.
+			refresh, err := tm.GenerateRefreshToken(1, identity.RoleAdmin)
+			require.NoError(t, err)
+			_, err = tm.ValidateRefreshToken(refresh.Token)
+			assert.NoError(t, err)
+
+			jwks := tm.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, key.ID, jwks.Keys[0].Kid)
+			assert.Equal(t, method, jwks.Keys[0].Alg)
+		})
+	}
+}
+
+func TestAsymmetricAccessTokenRejectsOtherAlgorithms(t *testing.T) {
+	key, err := pkgjwt.ParseSigningKey(config.SigningMethodEdDSA, generatePEM(t, config.SigningMethodEdDSA))
+	require.NoError(t, err)
+
+	tm := pkgjwt.NewTokenManager(testConfig, pkgjwt.WithAccessKeyring(pkgjwt.NewKeyring(key)))
+
+	// A token signed with the old shared secret must not validate anymore.
+	hmacToken, err := pkgjwt.NewTokenManager(testConfig).GenerateAccessToken(1, identity.RoleAdmin, uuid.New(), "", pkgjwt.Authentication{})
+	require.NoError(t, err)
+
+	_, err = tm.ValidateAccessToken(hmacToken.Token)
+	assert.ErrorIs(t, err, pkgjwt.ErrInvalidToken)
+}
+
+func TestJWKSWithSharedSecret(t *testing.T) {
+	tm := pkgjwt.NewTokenManager(testConfig)
+
+	assert.Empty(t, tm.JWKS().Keys)
+}
+
+func TestMFAToken(t *testing.T) {
+	tm := pkgjwt.NewTokenManager(testConfig)
+
+	res, err := tm.GenerateMFAToken(1, identity.RoleAdmin)
+	require.NoError(t, err)
+	assert.WithinDuration(t, res.Meta.IssuedAt.Add(testConfig.MFAChallengeTTL), res.Meta.ExpiresAt, time.Second)
+
+	principal, err := tm.ValidateMFAToken(res.Token)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), principal.UserID)
+	assert.Equal(t, res.Meta.JTI, *principal.JTI)
+
+	// A challenge is no session, it must not pass as any other token.
+	_, err = tm.ValidateRefreshToken(res.Token)
+	assert.ErrorIs(t, err, pkgjwt.ErrInvalidTokenType)
+
+	_, err = tm.ValidateAccessToken(res.Token)
+	assert.Error(t, err)
+
+	refresh, err := tm.GenerateRefreshToken(1, identity.RoleAdmin)
+	require.NoError(t, err)
+	_, err = tm.ValidateMFAToken(refresh.Token)
+	assert.ErrorIs(t, err, pkgjwt.ErrInvalidTokenType)
+}
+
+func TestMagicLinkToken(t *testing.T) {
+	tm := pkgjwt.NewTokenManager(testConfig)
+
+	res, err := tm.GenerateMagicLinkToken(1, identity.RoleUser, nil)
+	require.NoError(t, err)
+	assert.WithinDuration(t, res.Meta.IssuedAt.Add(testConfig.MagicLinkTTL), res.Meta.ExpiresAt, time.Second)
+
+	principal, err := tm.ValidateMagicLinkToken(res.Token)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), principal.UserID)
+	assert.Equal(t, res.Meta.JTI, *principal.JTI)
+	assert.Empty(t, principal.AMR)
+
+	// A stepped-up login link carries the password proved before it.
+	stepUp, err := tm.GenerateMagicLinkToken(1, identity.RoleUser, []string{"pwd"})
+	require.NoError(t, err)
+	principal, err = tm.ValidateMagicLinkToken(stepUp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pwd"}, principal.AMR)
+	assert.True(t, principal.AuthTime.IsZero())
+
+	_, err = tm.ValidateRefreshToken(res.Token)
+	assert.ErrorIs(t, err, pkgjwt.ErrInvalidTokenType)
+
+	_, err = tm.ValidateMFAToken(res.Token)
+	assert.ErrorIs(t, err, pkgjwt.ErrInvalidTokenType)
+
+	// Both are signed with the refresh keyring, the type alone keeps a challenge from passing as a link.
+	challenge, err := tm.GenerateMFAToken(1, identity.RoleUser)
+	require.NoError(t, err)
+	_, err = tm.ValidateMagicLinkToken(challenge.Token)
+	assert.ErrorIs(t, err, pkgjwt.ErrInvalidTokenType)
+}
+
+func TestClientToken(t *testing.T) {
+	tm := pkgjwt.NewTokenManager(testConfig)
+
+	res, err := tm.GenerateClientToken("client", []string{identity.ScopeRead})
+	require.NoError(t, err)
+	assert.WithinDuration(t, res.Meta.IssuedAt.Add(testConfig.AccessTokenTTL), res.Meta.ExpiresAt, time.Second)
+
+	principal, err := tm.ValidateAccessToken(res.Token)
+	require.NoError(t, err)
+	assert.Equal(t, &identity.Principal{
+		ClientID:  "client",
+		Source:    identity.AuthClient,
+		Scopes:    []string{identity.ScopeRead},
+		ExpiresAt: res.Meta.ExpiresAt.Truncate(time.Second),
+	}, principal)
+
+	// Without scopes the token grants nothing, rather than everything like a session.
+	res, err = tm.GenerateClientToken("client", nil)
+	require.NoError(t, err)
+
+	principal, err = tm.ValidateAccessToken(res.Token)
+	require.NoError(t, err)
+	assert.False(t, principal.HasScope(identity.ScopeRead))
+
+	_, err = tm.ValidateRefreshToken(res.Token)
+	assert.Error(t, err)
+}
+
+func TestImpersonationToken(t *testing.T) {
+	tm := pkgjwt.NewTokenManager(testConfig)
+
+	res, err := tm.GenerateImpersonationToken(2, identity.RoleUser, 1)
+	require.NoError(t, err)
+	assert.WithinDuration(t, res.Meta.IssuedAt.Add(testConfig.ImpersonationTTL), res.Meta.ExpiresAt, time.Second)
+
+	principal, err := tm.ValidateAccessToken(res.Token)
+	require.NoError(t, err)
+	assert.Equal(t, &identity.Principal{
+		UserID:    2,
+		Role:      identity.RoleUser,
+		Source:    identity.AuthExternal,
+		ActorID:   1,
+		JTI:       &res.Meta.JTI,
+		SessionID: &res.Meta.JTI,
+		ExpiresAt: res.Meta.ExpiresAt.Truncate(time.Second),
+	}, principal)
+	assert.True(t, principal.Impersonated())
+
+	// Without a refresh token it can't be refreshed.
+	_, err = tm.ValidateRefreshToken(res.Token)
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package jwt
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// Keyring holds every key still accepted for verification and the one used to sign new tokens.
+// It is safe for concurrent use, allowing keys to be swapped without a restart.
+type Keyring struct {
+	mu        sync.RWMutex
+	current   *SigningKey
+	keys      map[string]*SigningKey
+	legacyKID string
+}
+
+// NewKeyring creates a keyring signing with the given key.
+// Tokens issued before kid headers existed are verified with this initial key while it stays in the ring.
+func NewKeyring(current *SigningKey) *Keyring {
+	return &Keyring{
+		current:   current,
+		keys:      map[string]*SigningKey{current.ID: current},
+		legacyKID: current.ID,
+	}
+}
+
+// Current returns the signing key.
+func (k *Keyring) Current() *SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// Lookup returns the verification key for a kid, an empty kid resolves to the legacy key.
+func (k *Keyring) Lookup(kid string) (*SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if kid == "" {
+		kid = k.legacyKID
+	}
+
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// Replace atomically swaps the keys, the current key is always kept for verification.
+func (k *Keyring) Replace(current *SigningKey, keys []*SigningKey) {
+	ring := make(map[string]*SigningKey, len(keys)+1)
+	for _, key := range keys {
+		ring[key.ID] = key
+	}
+	ring[current.ID] = current
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.current = current
+	k.keys = ring
+}
+
+// JWKS returns the public part of every asymmetric key in the ring.
+func (k *Keyring) JWKS() JWKS {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range k.keys {
+		if jwk, ok := key.PublicJWK(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	// Stable output, current key first.
+	currentID := k.current.ID
+	slices.SortFunc(jwks.Keys, func(a, b JWK) int {
+		if a.Kid == currentID {
+			return -1
+		}
+		if b.Kid == currentID {
+			return 1
+		}
+		return cmp.Compare(a.Kid, b.Kid)
+	})
+
+	return jwks
+}
@@ -0,0 +1,140 @@
+package jwt_test
+
+import (
+	"gomonitor/internal/config"
+	"gomonitor/internal/pkg/identity"
+	pkgjwt "gomonitor/internal/pkg/jwt"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring_Lookup(t *testing.T) {
+	legacy := pkgjwt.NewHMACKey("legacy")
+	next := pkgjwt.NewHMACKey("next")
+
+	keyring := pkgjwt.NewKeyring(legacy)
+	assert.Equal(t, legacy, keyring.Current())
+
+	key, ok := keyring.Lookup("")
+	assert.True(t, ok)
+	assert.Equal(t, legacy, key)
+
+	_, ok = keyring.Lookup(next.ID)
+	assert.False(t, ok)
+
+	keyring.Replace(next, []*pkgjwt.SigningKey{legacy})
+	assert.Equal(t, next, keyring.Current())
+
+	key, ok = keyring.Lookup(next.ID)
+	assert.True(t, ok)
+	assert.Equal(t, next, key)
+
+	// Legacy tokens keep validating while the initial key is in the ring.
+	key, ok = keyring.Lookup("")
+	assert.True(t, ok)
+	assert.Equal(t, legacy, key)
+
+	keyring.Replace(next, nil)
+	_, ok = keyring.Lookup("")
+	assert.False(t, ok)
+}
+
+func TestKeyring_JWKS(t *testing.T) {
+	first, err := pkgjwt.GenerateSigningKey(config.SigningMethodEdDSA)
+	require.NoError(t, err)
+	second, err := pkgjwt.GenerateSigningKey(config.SigningMethodES256)
+	require.NoError(t, err)
+
+	keyring := pkgjwt.NewKeyring(first)
+	keyring.Replace(second, []*pkgjwt.SigningKey{first, pkgjwt.NewHMACKey("secret")})
+
+	jwks := keyring.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, second.ID, jwks.Keys[0].Kid)
+	assert.Equal(t, first.ID, jwks.Keys[1].Kid)
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	oldKey := pkgjwt.NewHMACKey(testConfig.AccessTokenSecret)
+	newKey, err := pkgjwt.GenerateSigningKey(config.SigningMethodEdDSA)
+	require.NoError(t, err)
+
+	keyring := pkgjwt.NewKeyring(oldKey)
+	tm := pkgjwt.NewTokenManager(testConfig, pkgjwt.WithAccessKeyring(keyring))
+
+	oldToken, err := tm.GenerateAccessToken(1, identity.RoleUser, uuid.New(), "", pkgjwt.Authentication{})
+	require.NoError(t, err)
+
+	// Untagged tokens issued before kid headers existed.
+	now := time.Now()
+	legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, pkgjwt.CustomClaims{
+		Type:      pkgjwt.TokenTypeAccess,
+		UserID:    1,
+		Role:      identity.RoleUser,
+		SessionID: uuid.New().String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}).SignedString([]byte(testConfig.AccessTokenSecret))
+	require.NoError(t, err)
+
+	// Promote, the old key keeps verifying.
+	keyring.Replace(newKey, []*pkgjwt.SigningKey{oldKey})
+
+	newToken, err := tm.GenerateAccessToken(1, identity.RoleUser, uuid.New(), "", pkgjwt.Authentication{})
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken.Token, &pkgjwt.CustomClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, newKey.ID, parsed.Header["kid"])
+
+	for _, token := range []string{oldToken.Token, legacyToken, newToken.Token} {
+		_, err = tm.ValidateAccessToken(token)
+		assert.NoError(t, err)
+	}
+
+	// Retire, only the new key is accepted.
+	keyring.Replace(newKey, nil)
+
+	for _, token := range []string{oldToken.Token, legacyToken} {
+		_, err = tm.ValidateAccessToken(token)
+		assert.ErrorIs(t, err, pkgjwt.ErrInvalidToken)
+	}
+
+	_, err = tm.ValidateAccessToken(newToken.Token)
+	assert.NoError(t, err)
+}
+
+func TestGenerateSigningKey(t *testing.T) {
+	methods := []string{
+		config.SigningMethodHS256,
+		config.SigningMethodRS256,
+		config.SigningMethodES256,
+		config.SigningMethodEdDSA,
+	}
+
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			key, err := pkgjwt.GenerateSigningKey(method)
+			require.NoError(t, err)
+			assert.Equal(t, method, key.Method.Alg())
+			assert.NotEmpty(t, key.ID)
+
+			material, err := key.Material()
+			require.NoError(t, err)
+
+			decoded, err := pkgjwt.DecodeSigningKey(method, material)
+			require.NoError(t, err)
+			assert.Equal(t, key.ID, decoded.ID)
+		})
+	}
+
+	_, err := pkgjwt.GenerateSigningKey("none")
+	assert.ErrorIs(t, err, pkgjwt.ErrUnsupportedKey)
+}
@@ -0,0 +1,312 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"gomonitor/internal/config"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnsupportedKey = errors.New("unsupported signing key")
+
+// SigningKey holds the material used to sign and verify tokens with a single algorithm.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	signKey   any
+	verifyKey any
+}
+
+// NewHMACKey creates a symmetric key, shared between signing and verification.
+// The key id is derived from the secret so every instance agrees on it without leaking the secret.
+func NewHMACKey(secret string) *SigningKey {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("kid"))
+
+	return &SigningKey{
+		ID:        encodeSegment(mac.Sum(nil))[:16],
+		Method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// GenerateSigningKey creates a new random key for the given method.
+func GenerateSigningKey(method string) (*SigningKey, error) {
+	var (
+		privateKey any
+		err        error
+	)
+
+	switch method {
+	case config.SigningMethodHS256:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return NewHMACKey(base64.StdEncoding.EncodeToString(secret)), nil
+	case config.SigningMethodRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case config.SigningMethodES256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case config.SigningMethodEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: method %s", ErrUnsupportedKey, method)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSigningKey(method, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// DecodeSigningKey restores a key from the material returned by SigningKey.Material.
+func DecodeSigningKey(method, material string) (*SigningKey, error) {
+	if method == config.SigningMethodHS256 {
+		return NewHMACKey(material), nil
+	}
+
+	return ParseSigningKey(method, []byte(material))
+}
+
+// Material returns the secret, or the PEM encoded private key, so the key can be persisted.
+func (k *SigningKey) Material() (string, error) {
+	if secret, ok := k.signKey.([]byte); ok {
+		return string(secret), nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(k.signKey)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// LoadSigningKey reads a PEM encoded private key from disk.
+func LoadSigningKey(method, path string) (*SigningKey, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading private key: %w", err)
+	}
+
+	return ParseSigningKey(method, pemBytes)
+}
+
+// ParseSigningKey parses a PEM encoded private key for the given method.
+// The key id is the RFC 7638 thumbprint of the public key.
+func ParseSigningKey(method string, pemBytes []byte) (*SigningKey, error) {
+	var (
+		signKey   crypto.Signer
+		verifyKey crypto.PublicKey
+		err       error
+	)
+
+	switch method {
+	case config.SigningMethodRS256:
+		var key *rsa.PrivateKey
+		key, err = jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err == nil {
+			signKey, verifyKey = key, &key.PublicKey
+		}
+	case config.SigningMethodES256:
+		var key *ecdsa.PrivateKey
+		key, err = jwt.ParseECPrivateKeyFromPEM(pemBytes)
+		if err == nil && key.Curve != elliptic.P256() {
+			err = fmt.Errorf("%w: ES256 requires a P-256 key", ErrUnsupportedKey)
+		}
+		if err == nil {
+			signKey, verifyKey = key, &key.PublicKey
+		}
+	case config.SigningMethodEdDSA:
+		var key crypto.PrivateKey
+		key, err = jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		if err == nil {
+			edKey := key.(ed25519.PrivateKey)
+			signKey, verifyKey = edKey, edKey.Public()
+		}
+	default:
+		return nil, fmt.Errorf("%w: method %s", ErrUnsupportedKey, method)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+
+	key := &SigningKey{
+		Method:    jwt.GetSigningMethod(method),
+		signKey:   signKey,
+		verifyKey: verifyKey,
+	}
+
+	jwk, _ := key.PublicJWK()
+	key.ID = jwk.Thumbprint()
+
+	return key, nil
+}
+
+// JWK is a single public key in the RFC 7517 format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is the document served on the well known endpoint.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK returns the public part of the key, false for symmetric keys.
+func (k *SigningKey) PublicJWK() (JWK, bool) {
+	jwk := JWK{
+		Use: "sig",
+		Alg: k.Method.Alg(),
+		Kid: k.ID,
+	}
+
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeSegment(pub.N.Bytes())
+		jwk.E = encodeSegment(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = encodeSegment(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeSegment(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encodeSegment(pub)
+	default:
+		return JWK{}, false
+	}
+
+	return jwk, true
+}
+
+// PublicKey decodes the key, for verifying tokens signed by others.
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(j.N)
+		e, errE := base64.RawURLEncoding.DecodeString(j.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, ErrUnsupportedKey
+		}
+
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < 2048 {
+			return nil, ErrUnsupportedKey
+		}
+		return pub, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrUnsupportedKey
+		}
+
+		x, errX := base64.RawURLEncoding.DecodeString(j.X)
+		y, errY := base64.RawURLEncoding.DecodeString(j.Y)
+		size := (curve.Params().BitSize + 7) / 8
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, ErrUnsupportedKey
+		}
+
+		// The uncompressed point encoding lets the standard library check the point is on the curve.
+		point := append([]byte{4}, append(x, y...)...)
+		pub, err := ecdsa.ParseUncompressedPublicKey(curve, point)
+		if err != nil {
+			return nil, ErrUnsupportedKey
+		}
+		return pub, nil
+
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if j.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+// Thumbprint computes the RFC 7638 thumbprint, hashing only the required members in lexical order.
+func (j JWK) Thumbprint() string {
+	var members any
+	switch j.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Crv, j.Kty, j.X, j.Y}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	}
+
+	// Marshalling plain strings into a struct can't fail.
+	raw, _ := json.Marshal(members)
+	sum := sha256.Sum256(raw)
+	return encodeSegment(sum[:])
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
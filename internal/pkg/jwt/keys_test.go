@@ -0,0 +1,200 @@
+package jwt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"gomonitor/internal/config"
+	pkgjwt "gomonitor/internal/pkg/jwt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePrivateKey(t *testing.T, key any) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func generatePEM(t *testing.T, method string) []byte {
+	t.Helper()
+
+	switch method {
+	case config.SigningMethodRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		return encodePrivateKey(t, key)
+	case config.SigningMethodES256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		return encodePrivateKey(t, key)
+	default:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		return encodePrivateKey(t, key)
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		pem         func(t *testing.T) []byte
+		expectedKty string
+		expectedErr bool
+	}{
+		{
+			name:        "rsa key",
+			method:      config.SigningMethodRS256,
+			pem:         func(t *testing.T) []byte { return generatePEM(t, config.SigningMethodRS256) },
+			expectedKty: "RSA",
+		},
+		{
+			name:        "ecdsa key",
+			method:      config.SigningMethodES256,
+			pem:         func(t *testing.T) []byte { return generatePEM(t, config.SigningMethodES256) },
+			expectedKty: "EC",
+		},
+		{
+			name:        "ed25519 key",
+			method:      config.SigningMethodEdDSA,
+			pem:         func(t *testing.T) []byte { return generatePEM(t, config.SigningMethodEdDSA) },
+			expectedKty: "OKP",
+		},
+		{
+			name:   "ecdsa key on wrong curve",
+			method: config.SigningMethodES256,
+			pem: func(t *testing.T) []byte {
+				key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+				require.NoError(t, err)
+				return encodePrivateKey(t, key)
+			},
+			expectedErr: true,
+		},
+		{
+			name:        "key does not match method",
+			method:      config.SigningMethodRS256,
+			pem:         func(t *testing.T) []byte { return generatePEM(t, config.SigningMethodEdDSA) },
+			expectedErr: true,
+		},
+		{
+			name:        "invalid pem",
+			method:      config.SigningMethodEdDSA,
+			pem:         func(t *testing.T) []byte { return []byte("not a pem") },
+			expectedErr: true,
+		},
+		{
+			name:        "hmac is not a private key",
+			method:      config.SigningMethodHS256,
+			pem:         func(t *testing.T) []byte { return generatePEM(t, config.SigningMethodEdDSA) },
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := pkgjwt.ParseSigningKey(tt.method, tt.pem(t))
+
+			if tt.expectedErr {
+				assert.Error(t, err)
+				assert.Nil(t, key)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.method, key.Method.Alg())
+			assert.NotEmpty(t, key.ID)
+
+			jwk, ok := key.PublicJWK()
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedKty, jwk.Kty)
+			assert.Equal(t, key.ID, jwk.Kid)
+			assert.Equal(t, key.ID, jwk.Thumbprint())
+		})
+	}
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, generatePEM(t, config.SigningMethodEdDSA), 0o600))
+
+	key, err := pkgjwt.LoadSigningKey(config.SigningMethodEdDSA, path)
+	assert.NoError(t, err)
+	assert.NotNil(t, key)
+
+	key, err = pkgjwt.LoadSigningKey(config.SigningMethodEdDSA, filepath.Join(t.TempDir(), "missing.pem"))
+	assert.Error(t, err)
+	assert.Nil(t, key)
+}
+
+func TestHMACKeyHasNoPublicJWK(t *testing.T) {
+	_, ok := pkgjwt.NewHMACKey("secret").PublicJWK()
+	assert.False(t, ok)
+}
+
+// Known RFC 7638 section 3.1 example.
+func TestJWKThumbprint(t *testing.T) {
+	jwk := pkgjwt.JWK{
+		Kty: "RSA",
+		E:   "AQAB",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jwk.Thumbprint())
+}
+
+func TestJWKPublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		method string
+		key    interface{ Public() crypto.PublicKey }
+	}{
+		{method: config.SigningMethodRS256, key: rsaKey},
+		{method: config.SigningMethodES256, key: ecKey},
+		{method: config.SigningMethodEdDSA, key: edKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			signingKey, err := pkgjwt.ParseSigningKey(tt.method, encodePrivateKey(t, tt.key))
+			require.NoError(t, err)
+
+			jwk, ok := signingKey.PublicJWK()
+			require.True(t, ok)
+
+			pub, err := jwk.PublicKey()
+			require.NoError(t, err)
+			assert.True(t, pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(tt.key.Public()))
+		})
+	}
+
+	invalid := []pkgjwt.JWK{
+		{Kty: "oct", X: "c2VjcmV0"},
+		{Kty: "RSA", N: "AQAB", E: "AQAB"},
+		{Kty: "EC", Crv: "P-256", X: "AQAB", Y: "AQAB"},
+		{Kty: "EC", Crv: "secp256k1"},
+		{Kty: "OKP", Crv: "X25519", X: "AQAB"},
+	}
+	for _, jwk := range invalid {
+		_, err := jwk.PublicKey()
+		assert.ErrorIs(t, err, pkgjwt.ErrUnsupportedKey)
+	}
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileMailer struct {
+	mu   sync.Mutex
+	from string
+	path string
+}
+
+// NewFileMailer appends every message to a file instead of delivering it, for local development and tests.
+func NewFileMailer(from, path string) Mailer {
+	return &fileMailer{
+		from: from,
+		path: path,
+	}
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening mail file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(format(m.from, msg, time.Now()), '\n')); err != nil {
+		return fmt.Errorf("error writing mail file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+// Package mailer delivers the transactional emails of the auth flows.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"gomonitor/internal/config"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer of the configured driver.
+func New(cfg *config.MailConfig, logger *slog.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case config.MailDriverSMTP:
+		return NewSMTPMailer(cfg), nil
+	case config.MailDriverFile:
+		return NewFileMailer(cfg.From, cfg.FilePath), nil
+	case config.MailDriverLog:
+		return NewLogMailer(logger), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver: %s", cfg.Driver)
+	}
+}
+
+// format renders the message in the RFC 5322 format, dropping line breaks from headers to avoid injection.
+func format(from string, msg Message, date time.Time) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", header.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", header.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", header.Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return []byte(b.String())
+}
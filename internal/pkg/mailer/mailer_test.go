@@ -0,0 +1,160 @@
+package mailer_test
+
+import (
+	"bufio"
+	"bytes"
+	"gomonitor/internal/config"
+	"gomonitor/internal/pkg/mailer"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = mailer.Message{
+	To:      "user@test.com",
+	Subject: "Reset your password",
+	Body:    "Use this link:\nhttps://example.com/reset?token=abc",
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		driver  string
+		wantErr bool
+	}{
+		{driver: config.MailDriverSMTP},
+		{driver: config.MailDriverFile},
+		{driver: config.MailDriverLog},
+		{driver: "pigeon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			m, err := mailer.New(&config.MailConfig{Driver: tt.driver, SMTPHost: "localhost"}, slog.Default())
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, m)
+			}
+		})
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m := mailer.NewFileMailer("no-reply@test.com", path)
+
+	require.NoError(t, m.Send(t.Context(), testMessage))
+
+	injected := testMessage
+	injected.Subject = "Hello\r\nBcc: attacker@test.com"
+	require.NoError(t, m.Send(t.Context(), injected))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(content), "From: no-reply@test.com\r\n")
+	assert.Contains(t, string(content), "To: user@test.com\r\n")
+	assert.Contains(t, string(content), "Subject: Reset your password\r\n")
+	assert.Contains(t, string(content), "https://example.com/reset?token=abc")
+	assert.NotContains(t, string(content), "\r\nBcc:")
+}
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	m := mailer.NewLogMailer(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	require.NoError(t, m.Send(t.Context(), testMessage))
+
+	assert.Contains(t, buf.String(), "to=user@test.com")
+	assert.Contains(t, buf.String(), "token=abc")
+}
+
+func TestSMTPMailer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan string, 1)
+	go serveSMTP(t, listener, received)
+
+	addr := listener.Addr().(*net.TCPAddr)
+	m := mailer.NewSMTPMailer(&config.MailConfig{
+		From:     "no-reply@test.com",
+		SMTPHost: "127.0.0.1",
+		SMTPPort: addr.Port,
+	})
+
+	require.NoError(t, m.Send(t.Context(), testMessage))
+
+	data := <-received
+	assert.Contains(t, data, "MAIL FROM:<no-reply@test.com>")
+	assert.Contains(t, data, "RCPT TO:<user@test.com>")
+	assert.Contains(t, data, "Subject: Reset your password")
+}
+
+func TestSMTPMailer_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+
+	m := mailer.NewSMTPMailer(&config.MailConfig{SMTPHost: "127.0.0.1", SMTPPort: port})
+
+	assert.Error(t, m.Send(t.Context(), testMessage))
+}
+
+// serveSMTP answers a single session with the minimal commands used by net/smtp, recording what it receives.
+func serveSMTP(t *testing.T, listener net.Listener, received chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var session strings.Builder
+	reader := bufio.NewReader(conn)
+	write := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	write("220 localhost ESMTP")
+	inData := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		session.WriteString(line)
+
+		if inData {
+			if line == ".\r\n" {
+				inData = false
+				write("250 OK")
+			}
+			continue
+		}
+
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			write("250 localhost")
+		case strings.HasPrefix(cmd, "DATA"):
+			inData = true
+			write("354 " + strconv.Quote("go ahead"))
+		case strings.HasPrefix(cmd, "QUIT"):
+			write("221 bye")
+			received <- session.String()
+			return
+		default:
+			write("250 OK")
+		}
+	}
+
+	received <- session.String()
+}
@@ -0,0 +1,134 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	pkgjwt "gomonitor/internal/pkg/jwt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRefreshInterval limits the refreshes triggered by unknown key ids.
+const minRefreshInterval = 30 * time.Second
+
+var errUnknownKey = errors.New("unknown signing key")
+
+// keySet caches the public keys of the provider, refreshed when a token names an unknown key,
+// which is how rotations are noticed.
+type keySet struct {
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]pkgjwt.JWK
+	refreshedAt time.Time
+}
+
+func newKeySet(client *http.Client) *keySet {
+	return &keySet{client: client}
+}
+
+// lookup returns the key verifying a token signed with alg. Without kid, the provider must publish a single key.
+func (k *keySet) lookup(ctx context.Context, uri, kid, alg string) (crypto.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	jwk, ok := k.find(kid)
+	if !ok && time.Since(k.refreshedAt) >= minRefreshInterval {
+		if err := k.refresh(ctx, uri); err != nil {
+			return nil, err
+		}
+		jwk, ok = k.find(kid)
+	}
+	if !ok {
+		return nil, errUnknownKey
+	}
+
+	if jwk.Use != "" && jwk.Use != "sig" {
+		return nil, errUnknownKey
+	}
+	if jwk.Alg != "" && jwk.Alg != alg {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only accept algorithms of the key type, avoiding algorithm confusion.
+	if !algMatchesKey(alg, pub) {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+
+	return pub, nil
+}
+
+func (k *keySet) find(kid string) (pkgjwt.JWK, bool) {
+	if kid == "" {
+		if len(k.keys) != 1 {
+			return pkgjwt.JWK{}, false
+		}
+		for _, jwk := range k.keys {
+			return jwk, true
+		}
+	}
+
+	jwk, ok := k.keys[kid]
+	return jwk, ok
+}
+
+func (k *keySet) refresh(ctx context.Context, uri string) error {
+	k.refreshedAt = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks status %d", resp.StatusCode)
+	}
+
+	var jwks pkgjwt.JWKS
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&jwks); err != nil {
+		return err
+	}
+
+	keys := make(map[string]pkgjwt.JWK, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		keys[jwk.Kid] = jwk
+	}
+	k.keys = keys
+
+	return nil
+}
+
+func algMatchesKey(alg string, pub crypto.PublicKey) bool {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(alg, "ES")
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	default:
+		return false
+	}
+}
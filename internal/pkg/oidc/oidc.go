@@ -0,0 +1,218 @@
+// Package oidc is a relying party for OpenID Connect providers, using the authorization code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gomonitor/internal/config"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxResponseSize bounds what is read from the provider.
+const maxResponseSize = 1 << 20
+
+var (
+	ErrDiscovery      = errors.New("oidc discovery failed")
+	ErrExchange       = errors.New("oidc code exchange failed")
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
+
+// Claims are the verified claims of an ID token identifying the user.
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider authenticates users at an OpenID Connect provider.
+type Provider interface {
+	// Issuer identifies the provider, the subjects are only unique per issuer.
+	Issuer() string
+	// AuthCodeURL is where the user is sent to authenticate, the provider redirects back with a code.
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange redeems the code and returns the claims of the ID token once verified, bound to the nonce.
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error)
+}
+
+type ProviderOption func(*provider)
+
+// WithHTTPClient replaces the client used to reach the provider.
+func WithHTTPClient(client *http.Client) ProviderOption {
+	return func(p *provider) {
+		p.client = client
+	}
+}
+
+// Discovery is the part of the provider metadata used by the relying party.
+type Discovery struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+type provider struct {
+	cfg    *config.OIDCConfig
+	client *http.Client
+	keys   *keySet
+
+	mu        sync.Mutex
+	discovery *Discovery
+}
+
+// NewProvider creates the provider, its metadata is discovered on first use so an unavailable provider
+// doesn't prevent the startup.
+func NewProvider(cfg *config.OIDCConfig, opts ...ProviderOption) Provider {
+	p := &provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.keys = newKeySet(p.client)
+	return p
+}
+
+func (p *provider) Issuer() string {
+	return p.cfg.IssuerURL
+}
+
+func (p *provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", CodeChallengeMethod)
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (p *provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	// Public clients only identify themselves, PKCE protects the code.
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token tokenResponse
+	status, err := p.doJSON(req, &token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d: %s %s", ErrExchange, status, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id token", ErrExchange)
+	}
+
+	return p.verifyIDToken(ctx, discovery, token.IDToken, nonce)
+}
+
+// discover fetches the provider metadata, keeping it once valid.
+func (p *provider) discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.IssuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	var discovery Discovery
+	status, err := p.doJSON(req, &discovery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrDiscovery, status)
+	}
+
+	// The issuer must be the exact one configured, otherwise another provider could be impersonated.
+	if discovery.Issuer != p.cfg.IssuerURL {
+		return nil, fmt.Errorf("%w: issuer mismatch %q", ErrDiscovery, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete metadata", ErrDiscovery)
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+func (p *provider) doJSON(req *http.Request, out any) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := json.Unmarshal(body, out); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, err
+	}
+
+	return resp.StatusCode, nil
+}
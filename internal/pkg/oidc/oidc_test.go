@@ -0,0 +1,181 @@
+package oidc_test
+
+import (
+	"gomonitor/internal/pkg/oidc"
+	"gomonitor/internal/pkg/oidc/oidctest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:8080/api/v1/auth/oidc/callback"
+
+func newFakeProvider(t *testing.T, secret string) *oidctest.Provider {
+	t.Helper()
+
+	fake, err := oidctest.NewProvider("gomonitor", secret)
+	require.NoError(t, err)
+	t.Cleanup(fake.Close)
+
+	return fake
+}
+
+// Known RFC 7636 appendix B example.
+func TestCodeChallenge(t *testing.T) {
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", oidc.CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	fake := newFakeProvider(t, "secret")
+	provider := oidc.NewProvider(fake.Config(redirectURL))
+
+	authURL, err := provider.AuthCodeURL(t.Context(), "state", "nonce", "challenge")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, fake.Issuer()+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "gomonitor", query.Get("client_id"))
+	assert.Equal(t, redirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state", query.Get("state"))
+	assert.Equal(t, "nonce", query.Get("nonce"))
+	assert.Equal(t, "challenge", query.Get("code_challenge"))
+	assert.Equal(t, oidc.CodeChallengeMethod, query.Get("code_challenge_method"))
+}
+
+func TestProvider_Discovery(t *testing.T) {
+	fake := newFakeProvider(t, "secret")
+
+	cfg := fake.Config(redirectURL)
+	cfg.IssuerURL = fake.Issuer() + "/"
+	_, err := oidc.NewProvider(cfg).AuthCodeURL(t.Context(), "state", "nonce", "challenge")
+	assert.ErrorIs(t, err, oidc.ErrDiscovery)
+
+	fake.Close()
+	_, err = oidc.NewProvider(fake.Config(redirectURL)).AuthCodeURL(t.Context(), "state", "nonce", "challenge")
+	assert.ErrorIs(t, err, oidc.ErrDiscovery)
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	verifier := "verifier-with-enough-entropy-for-the-tests-0123456789"
+
+	tests := []struct {
+		name        string
+		secret      string
+		verifier    string
+		nonce       string
+		mutate      func(jwt.MapClaims)
+		expectedErr error
+	}{
+		{
+			name:     "confidential client",
+			secret:   "secret",
+			verifier: verifier,
+			nonce:    "nonce",
+		},
+		{
+			name:     "public client",
+			verifier: verifier,
+			nonce:    "nonce",
+		},
+		{
+			name:        "wrong verifier",
+			secret:      "secret",
+			verifier:    "another-verifier",
+			nonce:       "nonce",
+			expectedErr: oidc.ErrExchange,
+		},
+		{
+			name:        "nonce mismatch",
+			secret:      "secret",
+			verifier:    verifier,
+			nonce:       "another-nonce",
+			expectedErr: oidc.ErrInvalidIDToken,
+		},
+		{
+			name:        "another audience",
+			secret:      "secret",
+			verifier:    verifier,
+			nonce:       "nonce",
+			mutate:      func(c jwt.MapClaims) { c["aud"] = "another-client" },
+			expectedErr: oidc.ErrInvalidIDToken,
+		},
+		{
+			name:     "another authorized party",
+			secret:   "secret",
+			verifier: verifier,
+			nonce:    "nonce",
+			mutate: func(c jwt.MapClaims) {
+				c["aud"] = []string{"gomonitor", "another-client"}
+				c["azp"] = "another-client"
+			},
+			expectedErr: oidc.ErrInvalidIDToken,
+		},
+		{
+			name:        "another issuer",
+			secret:      "secret",
+			verifier:    verifier,
+			nonce:       "nonce",
+			mutate:      func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+			expectedErr: oidc.ErrInvalidIDToken,
+		},
+		{
+			name:        "expired",
+			secret:      "secret",
+			verifier:    verifier,
+			nonce:       "nonce",
+			mutate:      func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+			expectedErr: oidc.ErrInvalidIDToken,
+		},
+		{
+			name:        "missing subject",
+			secret:      "secret",
+			verifier:    verifier,
+			nonce:       "nonce",
+			mutate:      func(c jwt.MapClaims) { delete(c, "sub") },
+			expectedErr: oidc.ErrInvalidIDToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeProvider(t, tt.secret)
+			fake.MutateClaims = tt.mutate
+			provider := oidc.NewProvider(fake.Config(redirectURL))
+
+			authURL, err := provider.AuthCodeURL(t.Context(), "state", "nonce", oidc.CodeChallenge(verifier))
+			require.NoError(t, err)
+
+			code, state, err := fake.Authorize(authURL)
+			require.NoError(t, err)
+			assert.Equal(t, "state", state)
+
+			claims, err := provider.Exchange(t.Context(), code, tt.verifier, tt.nonce)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, &oidc.Claims{
+				Issuer:        fake.Issuer(),
+				Subject:       "subject",
+				Email:         "user@example.com",
+				EmailVerified: true,
+				Name:          "User",
+			}, claims)
+
+			// Codes are single use.
+			_, err = provider.Exchange(t.Context(), code, tt.verifier, tt.nonce)
+			assert.ErrorIs(t, err, oidc.ErrExchange)
+		})
+	}
+}
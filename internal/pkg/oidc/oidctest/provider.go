@@ -0,0 +1,279 @@
+// Package oidctest runs an in-process OpenID Connect provider, enough of one to test the relying party offline.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"gomonitor/internal/config"
+	"gomonitor/internal/pkg/identity"
+	pkgjwt "gomonitor/internal/pkg/jwt"
+	"gomonitor/internal/pkg/oidc"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// User is the identity the provider authenticates on every authorization request.
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type grant struct {
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	user          User
+}
+
+// Provider is a fake provider with a single client, it approves every authorization request.
+type Provider struct {
+	ClientID     string
+	ClientSecret string
+
+	// MutateClaims edits the claims of the ID tokens, to test their validation. Set it before the requests.
+	MutateClaims func(jwt.MapClaims)
+
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	jwk    pkgjwt.JWK
+
+	mu     sync.Mutex
+	user   User
+	grants map[string]grant
+}
+
+// NewProvider starts the provider, Close stops it.
+func NewProvider(clientID, clientSecret string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey, err := pkgjwt.ParseSigningKey(config.SigningMethodRS256, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		return nil, err
+	}
+	jwk, _ := signingKey.PublicJWK()
+
+	p := &Provider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		jwk:          jwk,
+		user:         User{Subject: "subject", Email: "user@example.com", EmailVerified: true, Name: "User"},
+		grants:       make(map[string]grant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.handleDiscovery)
+	mux.HandleFunc("GET /jwks", p.handleJWKS)
+	mux.HandleFunc("GET /authorize", p.handleAuthorize)
+	mux.HandleFunc("POST /token", p.handleToken)
+	p.server = httptest.NewServer(mux)
+
+	return p, nil
+}
+
+// Issuer is the URL to configure as the issuer.
+func (p *Provider) Issuer() string {
+	return p.server.URL
+}
+
+func (p *Provider) Close() {
+	p.server.Close()
+}
+
+// Config returns the relying party configuration matching the provider.
+func (p *Provider) Config(redirectURL string) *config.OIDCConfig {
+	return &config.OIDCConfig{
+		IssuerURL:    p.Issuer(),
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		FlowTTL:      10 * time.Minute,
+		DefaultRole:  identity.RoleUser,
+	}
+}
+
+// SetUser changes the user authenticated by the next authorization requests.
+func (p *Provider) SetUser(user User) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.user = user
+}
+
+// Authorize plays the browser, following the authorization URL and returning the code and state
+// the provider redirects back with.
+func (p *Provider) Authorize(authURL string) (code, state string, err error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return "", "", fmt.Errorf("authorization refused with status %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+func (p *Provider) handleDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, oidc.Discovery{
+		Issuer:                        p.Issuer(),
+		AuthorizationEndpoint:         p.Issuer() + "/authorize",
+		TokenEndpoint:                 p.Issuer() + "/token",
+		JWKSURI:                       p.Issuer() + "/jwks",
+		CodeChallengeMethodsSupported: []string{oidc.CodeChallengeMethod},
+	})
+}
+
+func (p *Provider) handleJWKS(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, pkgjwt.JWKS{Keys: []pkgjwt.JWK{p.jwk}})
+}
+
+func (p *Provider) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != p.ClientID || query.Get("response_type") != "code" ||
+		query.Get("redirect_uri") == "" || query.Get("code_challenge") == "" ||
+		query.Get("code_challenge_method") != oidc.CodeChallengeMethod {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code := rand.Text()
+
+	p.mu.Lock()
+	p.grants[code] = grant{
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+		user:          p.user,
+	}
+	p.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	if err := p.authenticateClient(r); err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	// Codes are single use, even when the exchange fails.
+	p.mu.Lock()
+	g, ok := p.grants[r.PostForm.Get("code")]
+	delete(p.grants, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	if !ok || g.redirectURI != r.PostForm.Get("redirect_uri") ||
+		oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != g.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := p.signIDToken(g)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) authenticateClient(r *http.Request) error {
+	if p.ClientSecret == "" {
+		if r.PostForm.Get("client_id") != p.ClientID {
+			return errors.New("unknown client")
+		}
+		return nil
+	}
+
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return errors.New("missing client credentials")
+	}
+	id, _ = url.QueryUnescape(id)
+	secret, _ = url.QueryUnescape(secret)
+	if id != p.ClientID || subtle.ConstantTimeCompare([]byte(secret), []byte(p.ClientSecret)) != 1 {
+		return errors.New("invalid client credentials")
+	}
+
+	return nil
+}
+
+func (p *Provider) signIDToken(g grant) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            p.Issuer(),
+		"sub":            g.user.Subject,
+		"aud":            p.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          g.nonce,
+		"email":          g.user.Email,
+		"email_verified": g.user.EmailVerified,
+		"name":           g.user.Name,
+	}
+
+	if p.MutateClaims != nil {
+		p.MutateClaims(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.jwk.Kid
+
+	return token.SignedString(p.key)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,15 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// CodeChallengeMethod is the only PKCE method used, plain would leak the verifier.
+const CodeChallengeMethod = "S256"
+
+// CodeChallenge derives the RFC 7636 S256 challenge sent with the authorization request.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,67 @@
+package opaquetoken_test
+
+import (
+	"gomonitor/internal/pkg/opaquetoken"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	a, err := opaquetoken.New()
+	require.NoError(t, err)
+	b, err := opaquetoken.New()
+	require.NoError(t, err)
+
+	assert.Len(t, a, 43)
+	assert.NotEqual(t, a, b)
+	assert.NotContains(t, a, "=")
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t,
+		"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		opaquetoken.Hash("hello"),
+	)
+	assert.Len(t, opaquetoken.Hash(""), 64)
+}
+
+func TestLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "adds the token",
+			base:     "https://example.com/verify",
+			expected: "https://example.com/verify?token=abc",
+		},
+		{
+			name:     "keeps the query",
+			base:     "https://example.com/verify?lang=en",
+			expected: "https://example.com/verify?lang=en&token=abc",
+		},
+		{
+			name:    "invalid url",
+			base:    "://example.com",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := opaquetoken.Link(tt.base, "abc")
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, link)
+		})
+	}
+}
@@ -1,27 +1,160 @@
 package password
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrMismatchedHashAndPassword is returned by both algorithms, callers don't need to know which one stored the hash.
+var ErrMismatchedHashAndPassword = bcrypt.ErrMismatchedHashAndPassword
+
+// ErrUnknownHash is returned for hashes of an unsupported format.
+var ErrUnknownHash = errors.New("password: unknown hash format")
+
+const argon2idPrefix = "$argon2id$"
+
 type PasswordHasher interface {
 	HashPassword(password string) (string, error)
 	VerifyPassword(hashedPassword, password string) error
+	// NeedsRehash reports if the hash was made with another algorithm or cost than the current one.
+	NeedsRehash(hashedPassword string) bool
+}
+
+// Argon2Params are the argon2id cost parameters, Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow the RFC 9106 recommendation for memory constrained environments.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
 }
 
+// passwordHasher hashes with the configured algorithm and verifies hashes of any supported one.
 type passwordHasher struct {
-	cost int
+	argon2id bool
+	cost     int
+	params   Argon2Params
 }
 
+// NewPasswordHasher hashes with bcrypt.
 func NewPasswordHasher(cost int) PasswordHasher {
 	return &passwordHasher{cost: cost}
 }
 
+// NewArgon2idHasher hashes with argon2id.
+func NewArgon2idHasher(params Argon2Params) PasswordHasher {
+	return &passwordHasher{argon2id: true, params: params}
+}
+
 func (ps *passwordHasher) HashPassword(password string) (string, error) {
+	if ps.argon2id {
+		return hashArgon2id(password, ps.params)
+	}
+
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), ps.cost)
 	return string(bytes), err
 }
 
 func (ps *passwordHasher) VerifyPassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	}
+
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+
+	return nil
+}
+
+func (ps *passwordHasher) NeedsRehash(hashedPassword string) bool {
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		cost, err := bcrypt.Cost([]byte(hashedPassword))
+		return ps.argon2id || err != nil || cost != ps.cost
+	}
+
+	if !ps.argon2id {
+		return true
+	}
+
+	params, _, _, err := decodeArgon2id(hashedPassword)
+	return err != nil || params != ps.params
+}
+
+// hashArgon2id encodes the hash in the PHC string format, keeping the parameters next to it.
+func hashArgon2id(password string, params Argon2Params) (string, error) {
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func decodeArgon2id(hashedPassword string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -80,3 +81,114 @@ func TestVerifyPassword(t *testing.T) {
 		})
 	}
 }
+
+var testArgon2Params = password.Argon2Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	pm := password.NewArgon2idHasher(testArgon2Params)
+
+	// Argon2id has no 72 bytes limit.
+	long := strings.Repeat("a", 80)
+	hash, err := pm.HashPassword(long)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	assert.NoError(t, pm.VerifyPassword(hash, long))
+	assert.ErrorIs(t, pm.VerifyPassword(hash, strings.Repeat("a", 79)), password.ErrMismatchedHashAndPassword)
+
+	other, err := pm.HashPassword(long)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "salts must differ")
+}
+
+func TestVerifyPassword_AcrossAlgorithms(t *testing.T) {
+	bcryptHasher := password.NewPasswordHasher(bcrypt.MinCost)
+	argon2Hasher := password.NewArgon2idHasher(testArgon2Params)
+
+	bcryptHash, err := bcryptHasher.HashPassword("test-password")
+	require.NoError(t, err)
+	argon2Hash, err := argon2Hasher.HashPassword("test-password")
+	require.NoError(t, err)
+
+	assert.NoError(t, argon2Hasher.VerifyPassword(bcryptHash, "test-password"))
+	assert.NoError(t, bcryptHasher.VerifyPassword(argon2Hash, "test-password"))
+	assert.ErrorIs(t, argon2Hasher.VerifyPassword(bcryptHash, "other"), password.ErrMismatchedHashAndPassword)
+}
+
+func TestVerifyPassword_MalformedArgon2id(t *testing.T) {
+	pm := password.NewArgon2idHasher(testArgon2Params)
+
+	hashes := []string{
+		"$argon2id$",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$",
+	}
+	for _, hash := range hashes {
+		assert.ErrorIs(t, pm.VerifyPassword(hash, "test-password"), password.ErrUnknownHash, hash)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptHash, err := password.NewPasswordHasher(bcrypt.MinCost).HashPassword("test-password")
+	require.NoError(t, err)
+	argon2Hash, err := password.NewArgon2idHasher(testArgon2Params).HashPassword("test-password")
+	require.NoError(t, err)
+
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+
+	tests := []struct {
+		name   string
+		hasher password.PasswordHasher
+		hash   string
+		want   bool
+	}{
+		{
+			name:   "bcrypt with current cost",
+			hasher: password.NewPasswordHasher(bcrypt.MinCost),
+			hash:   bcryptHash,
+		},
+		{
+			name:   "bcrypt with outdated cost",
+			hasher: password.NewPasswordHasher(bcrypt.MinCost + 1),
+			hash:   bcryptHash,
+			want:   true,
+		},
+		{
+			name:   "bcrypt hash with argon2id hasher",
+			hasher: password.NewArgon2idHasher(testArgon2Params),
+			hash:   bcryptHash,
+			want:   true,
+		},
+		{
+			name:   "argon2id with current params",
+			hasher: password.NewArgon2idHasher(testArgon2Params),
+			hash:   argon2Hash,
+		},
+		{
+			name:   "argon2id with outdated params",
+			hasher: password.NewArgon2idHasher(stronger),
+			hash:   argon2Hash,
+			want:   true,
+		},
+		{
+			name:   "argon2id hash with bcrypt hasher",
+			hasher: password.NewPasswordHasher(bcrypt.MinCost),
+			hash:   argon2Hash,
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.hasher.NeedsRehash(tt.hash))
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Violation codes, ViolationReused is reported by the password history checks.
+const (
+	ViolationTooShort = "too_short"
+	ViolationTooLong  = "too_long"
+	ViolationClasses  = "missing_character_classes"
+	ViolationTooWeak  = "too_weak"
+	ViolationBreached = "breached"
+	ViolationReused   = "reused"
+)
+
+// Longer lines of a breached list can't be valid passwords anyway.
+const breachedListMaxLen = 256
+
+// Violation is a rule the password breaks.
+type Violation struct {
+	Code    string
+	Message string
+}
+
+// Rules are the checks a new password must pass, the zero value of a field disables its check.
+type Rules struct {
+	MinLength  int
+	MaxLength  int
+	MinClasses int
+	// MinEntropy is in bits, see Entropy.
+	MinEntropy int
+	// Breached holds the lowercased passwords known from breaches.
+	Breached map[string]struct{}
+}
+
+// Validate returns every rule the password breaks. Lengths count characters, except MaxLength
+// which counts bytes since bcrypt truncates on them.
+func (r *Rules) Validate(password string) []Violation {
+	var violations []Violation
+
+	if r.MinLength > 0 && utf8.RuneCountInString(password) < r.MinLength {
+		violations = append(violations, Violation{
+			Code:    ViolationTooShort,
+			Message: fmt.Sprintf("must be at least %d characters", r.MinLength),
+		})
+	}
+
+	if r.MaxLength > 0 && len(password) > r.MaxLength {
+		violations = append(violations, Violation{
+			Code:    ViolationTooLong,
+			Message: fmt.Sprintf("must be at most %d bytes", r.MaxLength),
+		})
+	}
+
+	if r.MinClasses > 0 && CharacterClasses(password) < r.MinClasses {
+		violations = append(violations, Violation{
+			Code: ViolationClasses,
+			Message: fmt.Sprintf(
+				"must mix at least %d of lowercase letters, uppercase letters, digits and symbols",
+				r.MinClasses,
+			),
+		})
+	}
+
+	if r.MinEntropy > 0 && Entropy(password) < float64(r.MinEntropy) {
+		violations = append(violations, Violation{
+			Code:    ViolationTooWeak,
+			Message: "is too easy to guess, use a longer or more varied password",
+		})
+	}
+
+	if _, ok := r.Breached[strings.ToLower(password)]; ok {
+		violations = append(violations, Violation{
+			Code:    ViolationBreached,
+			Message: "appears in a list of breached passwords",
+		})
+	}
+
+	return violations
+}
+
+// CharacterClasses counts which of lowercase, uppercase, digits and symbols the password uses.
+func CharacterClasses(password string) int {
+	classes := 0
+	for _, size := range classPools(password) {
+		if size > 0 {
+			classes++
+		}
+	}
+	return classes
+}
+
+// Entropy estimates the bits of a password as if its characters were picked at random from the classes it uses.
+// Repeating the previous character adds nothing, so "aaaaaaaa" scores like "a".
+func Entropy(password string) float64 {
+	pool := 0
+	for _, size := range classPools(password) {
+		pool += size
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	length := 0
+	var prev rune
+	for i, c := range password {
+		if i == 0 || c != prev {
+			length++
+		}
+		prev = c
+	}
+
+	return float64(length) * math.Log2(float64(pool))
+}
+
+// classPools returns the size of each character class used by the password, 0 for the unused ones.
+func classPools(password string) [4]int {
+	var pools [4]int
+	for _, c := range password {
+		switch {
+		case unicode.IsLower(c):
+			pools[0] = 26
+		case unicode.IsUpper(c):
+			pools[1] = 26
+		case unicode.IsDigit(c):
+			pools[2] = 10
+		default:
+			pools[3] = 33
+		}
+	}
+	return pools
+}
+
+// LoadBreachedList reads one password per line, blank lines and lines starting with # are skipped.
+func LoadBreachedList(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || len(line) > breachedListMaxLen {
+			continue
+		}
+		breached[strings.ToLower(line)] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return breached, nil
+}
@@ -0,0 +1,104 @@
+package password_test
+
+import (
+	"gomonitor/internal/pkg/password"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Validate(t *testing.T) {
+	rules := &password.Rules{
+		MinLength:  8,
+		MaxLength:  72,
+		MinClasses: 2,
+		MinEntropy: 40,
+		Breached:   map[string]struct{}{"password123": {}},
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		expected []string
+	}{
+		{
+			name:     "valid",
+			password: "correct-Horse-battery",
+		},
+		{
+			name:     "too short and weak",
+			password: "aB1",
+			expected: []string{password.ViolationTooShort, password.ViolationTooWeak},
+		},
+		{
+			name:     "too long",
+			password: strings.Repeat("aB1-", 19),
+			expected: []string{password.ViolationTooLong},
+		},
+		{
+			name:     "single class",
+			password: "abcdefghijklmnop",
+			expected: []string{password.ViolationClasses},
+		},
+		{
+			name:     "repeated characters are weak",
+			password: "aaaaaaaaaaaa1111",
+			expected: []string{password.ViolationTooWeak},
+		},
+		{
+			name:     "breached ignoring case",
+			password: "PASSWORD123",
+			expected: []string{password.ViolationBreached},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var codes []string
+			for _, v := range rules.Validate(tt.password) {
+				assert.NotEmpty(t, v.Message)
+				codes = append(codes, v.Code)
+			}
+
+			assert.Equal(t, tt.expected, codes)
+		})
+	}
+}
+
+func TestRules_ZeroValueAcceptsAnything(t *testing.T) {
+	assert.Empty(t, (&password.Rules{}).Validate(""))
+}
+
+func TestCharacterClasses(t *testing.T) {
+	assert.Equal(t, 0, password.CharacterClasses(""))
+	assert.Equal(t, 1, password.CharacterClasses("abc"))
+	assert.Equal(t, 2, password.CharacterClasses("abC"))
+	assert.Equal(t, 4, password.CharacterClasses("aB1 "))
+	assert.Equal(t, 2, password.CharacterClasses("éÉ"))
+}
+
+func TestEntropy(t *testing.T) {
+	assert.Zero(t, password.Entropy(""))
+	assert.InDelta(t, 4.7, password.Entropy("a"), 0.01)
+	assert.Equal(t, password.Entropy("a"), password.Entropy("aaaaaaaa"))
+	assert.InDelta(t, 8*5.17, password.Entropy("abcd1234"), 0.1)
+	assert.Greater(t, password.Entropy("abcd1234!"), password.Entropy("abcd12345"))
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "# common passwords\n123456\n\n  Password  \n" + strings.Repeat("x", 300) + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	breached, err := password.LoadBreachedList(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]struct{}{"123456": {}, "password": {}}, breached)
+
+	_, err = password.LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Lockout counts failed attempts per key and locks the key once they reach the limit.
+type Lockout interface {
+	// Locked returns the remaining lock time, zero when the key is not locked.
+	Locked(ctx context.Context, key string) (time.Duration, error)
+	// Fail records a failed attempt and returns the lock it caused, zero when none.
+	Fail(ctx context.Context, key string) (time.Duration, error)
+	// Reset clears the failed attempts of the key.
+	Reset(ctx context.Context, key string) error
+}
+
+type LockoutConfig struct {
+	attempts   int
+	keyPrefix  string
+	window     time.Duration
+	lockout    time.Duration
+	maxLockout time.Duration
+}
+
+type LockoutOption func(*LockoutConfig)
+
+func defaultLockoutConfig() LockoutConfig {
+	return LockoutConfig{
+		attempts:   5,
+		keyPrefix:  "login_lockout",
+		window:     15 * time.Minute,
+		lockout:    time.Minute,
+		maxLockout: time.Hour,
+	}
+}
+
+// WithAttempts sets the failed attempts allowed before the key is locked.
+func WithAttempts(attempts int) LockoutOption {
+	return func(lc *LockoutConfig) {
+		lc.attempts = attempts
+	}
+}
+
+func WithLockoutPrefix(prefix string) LockoutOption {
+	return func(lc *LockoutConfig) {
+		lc.keyPrefix = prefix
+	}
+}
+
+// WithAttemptWindow sets how long failed attempts are remembered.
+func WithAttemptWindow(window time.Duration) LockoutOption {
+	return func(lc *LockoutConfig) {
+		lc.window = window
+	}
+}
+
+// WithLockoutDuration sets the first lock, every further failure doubles it up to max.
+func WithLockoutDuration(lockout, max time.Duration) LockoutOption {
+	return func(lc *LockoutConfig) {
+		lc.lockout = lockout
+		lc.maxLockout = max
+	}
+}
+
+// lockFor returns the lock applied after the given number of failures.
+func (lc *LockoutConfig) lockFor(failures int) time.Duration {
+	if failures < lc.attempts {
+		return 0
+	}
+
+	lock := float64(lc.lockout) * math.Pow(2, float64(failures-lc.attempts))
+	if lock > float64(lc.maxLockout) {
+		return lc.maxLockout
+	}
+
+	return time.Duration(lock)
+}
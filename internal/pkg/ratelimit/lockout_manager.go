@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type lockoutManager struct {
+	lockout  Lockout
+	fallback Lockout
+}
+
+type LockoutManagerOptions func(*lockoutManager)
+
+func NewLockoutManager(opts ...LockoutManagerOptions) Lockout {
+	lm := &lockoutManager{
+		lockout: NewMemoryLockout(),
+	}
+
+	for _, opt := range opts {
+		opt(lm)
+	}
+
+	return lm
+}
+
+func (lm *lockoutManager) Locked(ctx context.Context, key string) (time.Duration, error) {
+	res, err := lm.lockout.Locked(ctx, key)
+	if err == nil {
+		return res, nil
+	}
+
+	if lm.fallback == nil {
+		return 0, errors.New("main lockout out, no fallback set")
+	}
+
+	return lm.fallback.Locked(ctx, key)
+}
+
+func (lm *lockoutManager) Fail(ctx context.Context, key string) (time.Duration, error) {
+	res, err := lm.lockout.Fail(ctx, key)
+	if err == nil {
+		return res, nil
+	}
+
+	if lm.fallback == nil {
+		return 0, errors.New("main lockout out, no fallback set")
+	}
+
+	return lm.fallback.Fail(ctx, key)
+}
+
+func (lm *lockoutManager) Reset(ctx context.Context, key string) error {
+	err := lm.lockout.Reset(ctx, key)
+
+	// Always clear the fallback too, it may hold failures recorded during an outage.
+	if lm.fallback != nil {
+		if fallbackErr := lm.fallback.Reset(ctx, key); fallbackErr != nil && err == nil {
+			err = fallbackErr
+		}
+	}
+
+	return err
+}
+
+func WithLockout(lockout Lockout) LockoutManagerOptions {
+	return func(lm *lockoutManager) {
+		lm.lockout = lockout
+	}
+}
+
+func WithLockoutFallback(fallback Lockout) LockoutManagerOptions {
+	return func(lm *lockoutManager) {
+		lm.fallback = fallback
+	}
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryLockout struct {
+	mu             sync.Mutex
+	cleanupCounter int
+	LockoutConfig
+	entries map[string]*lockoutEntry
+}
+
+type lockoutEntry struct {
+	failures    int
+	lockedUntil time.Time
+	resetAt     time.Time
+}
+
+func NewMemoryLockout(opts ...LockoutOption) Lockout {
+	ml := &memoryLockout{
+		LockoutConfig: defaultLockoutConfig(),
+		entries:       make(map[string]*lockoutEntry),
+	}
+
+	for _, opt := range opts {
+		opt(&ml.LockoutConfig)
+	}
+
+	return ml
+}
+
+func (m *memoryLockout) Locked(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[m.keyPrefix+":"+key]
+	if !ok || !now.Before(entry.lockedUntil) {
+		return 0, nil
+	}
+
+	return entry.lockedUntil.Sub(now), nil
+}
+
+func (m *memoryLockout) Fail(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	memKey := m.keyPrefix + ":" + key
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cleanupCounter++
+	if m.cleanupCounter%100 == 0 {
+		m.cleanupExpired(now)
+	}
+
+	entry, ok := m.entries[memKey]
+	if !ok || now.After(entry.resetAt) {
+		entry = &lockoutEntry{}
+		m.entries[memKey] = entry
+	}
+
+	entry.failures++
+	lock := m.lockFor(entry.failures)
+	if lock > 0 {
+		entry.lockedUntil = now.Add(lock)
+	}
+	entry.resetAt = now.Add(m.window + lock)
+
+	return lock, nil
+}
+
+func (m *memoryLockout) Reset(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, m.keyPrefix+":"+key)
+	return nil
+}
+
+func (m *memoryLockout) cleanupExpired(now time.Time) {
+	for k, v := range m.entries {
+		if now.After(v.resetAt) {
+			delete(m.entries, k)
+		}
+	}
+}
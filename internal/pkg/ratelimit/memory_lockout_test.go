@@ -0,0 +1,104 @@
+package ratelimit_test
+
+import (
+	"gomonitor/internal/pkg/ratelimit"
+	"gomonitor/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLockout(t *testing.T) {
+	t.Parallel()
+
+	attempts := 3
+	lockout := 100 * time.Millisecond
+	key := t.Name()
+	ml := ratelimit.NewMemoryLockout(
+		ratelimit.WithAttempts(attempts),
+		ratelimit.WithLockoutPrefix("lotest"),
+		ratelimit.WithAttemptWindow(time.Minute),
+		ratelimit.WithLockoutDuration(lockout, 3*lockout),
+	)
+
+	for range attempts - 1 {
+		lock, err := ml.Fail(t.Context(), key)
+		require.NoError(t, err)
+		assert.Zero(t, lock)
+	}
+
+	locked, err := ml.Locked(t.Context(), key)
+	require.NoError(t, err)
+	assert.Zero(t, locked)
+
+	// Reaching the limit locks, every further failure doubles up to the max.
+	expected := []time.Duration{lockout, 2 * lockout, 3 * lockout}
+	for _, want := range expected {
+		lock, err := ml.Fail(t.Context(), key)
+		require.NoError(t, err)
+		assert.Equal(t, want, lock)
+	}
+
+	locked, err = ml.Locked(t.Context(), key)
+	require.NoError(t, err)
+	assert.Greater(t, locked, 2*lockout)
+
+	// Other keys are not affected.
+	locked, err = ml.Locked(t.Context(), "other")
+	require.NoError(t, err)
+	assert.Zero(t, locked)
+
+	require.NoError(t, ml.Reset(t.Context(), key))
+
+	locked, err = ml.Locked(t.Context(), key)
+	require.NoError(t, err)
+	assert.Zero(t, locked)
+
+	lock, err := ml.Fail(t.Context(), key)
+	require.NoError(t, err)
+	assert.Zero(t, lock)
+}
+
+func TestMemoryLockoutExpires(t *testing.T) {
+	t.Parallel()
+
+	lockout := 50 * time.Millisecond
+	key := t.Name()
+	ml := ratelimit.NewMemoryLockout(
+		ratelimit.WithAttempts(1),
+		ratelimit.WithAttemptWindow(lockout),
+		ratelimit.WithLockoutDuration(lockout, time.Second),
+	)
+
+	lock, err := ml.Fail(t.Context(), key)
+	require.NoError(t, err)
+	assert.Equal(t, lockout, lock)
+
+	time.Sleep(lockout + 10*time.Millisecond)
+
+	locked, err := ml.Locked(t.Context(), key)
+	require.NoError(t, err)
+	assert.Zero(t, locked)
+
+	// Failures are remembered past the lock, so the next one doubles it.
+	lock, err = ml.Fail(t.Context(), key)
+	require.NoError(t, err)
+	assert.Equal(t, 2*lockout, lock)
+}
+
+func TestMemoryLockoutCancelledContext(t *testing.T) {
+	t.Parallel()
+
+	ml := ratelimit.NewMemoryLockout()
+	ctx := testutil.GetCancelledCtx(t.Context())
+
+	_, err := ml.Locked(ctx, t.Name())
+	assert.Error(t, err)
+
+	_, err = ml.Fail(ctx, t.Name())
+	assert.Error(t, err)
+
+	assert.Error(t, ml.Reset(ctx, t.Name()))
+}
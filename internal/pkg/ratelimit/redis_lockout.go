@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	redisinfra "gomonitor/internal/infra/redis"
+	"time"
+)
+
+type redisLockout struct {
+	redisClient redisinfra.RedisClient
+	LockoutConfig
+}
+
+func NewRedisLockout(redisClient redisinfra.RedisClient, opts ...LockoutOption) Lockout {
+	rl := &redisLockout{
+		redisClient:   redisClient,
+		LockoutConfig: defaultLockoutConfig(),
+	}
+
+	for _, opt := range opts {
+		opt(&rl.LockoutConfig)
+	}
+
+	return rl
+}
+
+func (r *redisLockout) Locked(ctx context.Context, key string) (time.Duration, error) {
+	script := `
+	local ttl = redis.call('PTTL', KEYS[1])
+	if ttl < 0 then
+		return 0
+	end
+	return ttl
+	`
+
+	result, err := r.redisClient.Eval(ctx, script, []string{r.lockKey(key)})
+	if err != nil {
+		return 0, err
+	}
+
+	return toDuration(result)
+}
+
+func (r *redisLockout) Fail(ctx context.Context, key string) (time.Duration, error) {
+	script := `
+	local failures = redis.call('INCR', KEYS[1])
+	local attempts = tonumber(ARGV[1])
+	local window = tonumber(ARGV[2])
+	local lockout = tonumber(ARGV[3])
+	local max = tonumber(ARGV[4])
+
+	local lock = 0
+	if failures >= attempts then
+		lock = math.min(lockout * 2 ^ (failures - attempts), max)
+		lock = math.floor(lock)
+		redis.call('SET', KEYS[2], 1, 'PX', lock)
+	end
+
+	-- Keep the count while locked, so the next failure doubles the lock.
+	redis.call('PEXPIRE', KEYS[1], window + lock)
+
+	return lock
+	`
+
+	result, err := r.redisClient.Eval(ctx, script,
+		[]string{r.failuresKey(key), r.lockKey(key)},
+		r.attempts, r.window.Milliseconds(), r.lockout.Milliseconds(), r.maxLockout.Milliseconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return toDuration(result)
+}
+
+func (r *redisLockout) Reset(ctx context.Context, key string) error {
+	script := `
+	redis.call('DEL', KEYS[1], KEYS[2])
+	return 1
+	`
+
+	_, err := r.redisClient.Eval(ctx, script, []string{r.failuresKey(key), r.lockKey(key)})
+	return err
+}
+
+func (r *redisLockout) failuresKey(key string) string {
+	return r.keyPrefix + ":failures:" + key
+}
+
+func (r *redisLockout) lockKey(key string) string {
+	return r.keyPrefix + ":lock:" + key
+}
+
+func toDuration(result any) (time.Duration, error) {
+	val, ok := result.(int64)
+	if !ok {
+		return 0, errors.New("couldn't cast eval to int64")
+	}
+
+	return time.Duration(val) * time.Millisecond, nil
+}
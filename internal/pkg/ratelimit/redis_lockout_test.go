@@ -0,0 +1,83 @@
+package ratelimit_test
+
+import (
+	redisinfra "gomonitor/internal/infra/redis"
+	"gomonitor/internal/pkg/ratelimit"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisLockout(t *testing.T) {
+	t.Parallel()
+	client := redisinfra.New(t.Context(), testRedisCfg, testCbCfg, slog.Default())
+
+	attempts := 3
+	lockout := time.Second
+	key := t.Name()
+	rl := ratelimit.NewRedisLockout(client,
+		ratelimit.WithAttempts(attempts),
+		ratelimit.WithLockoutPrefix("lotest"),
+		ratelimit.WithAttemptWindow(time.Minute),
+		ratelimit.WithLockoutDuration(lockout, 3*lockout),
+	)
+
+	for range attempts - 1 {
+		lock, err := rl.Fail(t.Context(), key)
+		require.NoError(t, err)
+		assert.Zero(t, lock)
+	}
+
+	locked, err := rl.Locked(t.Context(), key)
+	require.NoError(t, err)
+	assert.Zero(t, locked)
+
+	expected := []time.Duration{lockout, 2 * lockout, 3 * lockout}
+	for _, want := range expected {
+		lock, err := rl.Fail(t.Context(), key)
+		require.NoError(t, err)
+		assert.Equal(t, want, lock)
+	}
+
+	locked, err = rl.Locked(t.Context(), key)
+	require.NoError(t, err)
+	assert.Greater(t, locked, 2*lockout)
+
+	require.NoError(t, rl.Reset(t.Context(), key))
+
+	locked, err = rl.Locked(t.Context(), key)
+	require.NoError(t, err)
+	assert.Zero(t, locked)
+}
+
+func TestLockoutManagerWithFallback(t *testing.T) {
+	client := redisinfra.New(t.Context(), testRedisCfg, testCbCfg, slog.Default())
+
+	key := t.Name()
+	lm := ratelimit.NewLockoutManager(
+		ratelimit.WithLockout(ratelimit.NewRedisLockout(client, ratelimit.WithAttempts(1))),
+		ratelimit.WithLockoutFallback(ratelimit.NewMemoryLockout(ratelimit.WithAttempts(2))),
+	)
+
+	lock, err := lm.Fail(t.Context(), key)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, lock)
+
+	_ = client.Close()
+
+	// The fallback keeps its own count while Redis is down.
+	lock, err = lm.Fail(t.Context(), key)
+	require.NoError(t, err)
+	assert.Zero(t, lock)
+
+	lock, err = lm.Fail(t.Context(), key)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, lock)
+
+	locked, err := lm.Locked(t.Context(), key)
+	require.NoError(t, err)
+	assert.Positive(t, locked)
+}
@@ -0,0 +1,20 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Denylist records revoked sessions, keyed by the refresh token family the access tokens are bound to.
+// Every access token issued for a session is rejected, including those issued before its last refresh.
+type Denylist interface {
+	Revoke(ctx context.Context, jti uuid.UUID, ttl time.Duration) error
+	IsRevoked(ctx context.Context, jti uuid.UUID) (bool, error)
+}
+
+// Store is the durable record of revoked sessions, checked when the denylist can't answer.
+type Store interface {
+	IsRevoked(ctx context.Context, jti uuid.UUID) (bool, error)
+}
@@ -0,0 +1,56 @@
+package revocation
+
+import (
+	"context"
+	"gomonitor/internal/observability/logging"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type denylistManager struct {
+	denylist Denylist
+	fallback Store
+}
+
+type ManagerOption func(*denylistManager)
+
+// New wraps the denylist with the policy applied when it fails, e.g. while the Redis circuit breaker is open.
+// Without a fallback store it fails open: tokens are accepted and revoked sessions stay usable until their access tokens expire.
+// With a fallback store it fails closed: every request is checked against the store until the denylist recovers.
+func New(denylist Denylist, opts ...ManagerOption) Denylist {
+	dm := &denylistManager{
+		denylist: denylist,
+	}
+
+	for _, opt := range opts {
+		opt(dm)
+	}
+
+	return dm
+}
+
+func WithFallback(store Store) ManagerOption {
+	return func(dm *denylistManager) {
+		dm.fallback = store
+	}
+}
+
+func (dm *denylistManager) Revoke(ctx context.Context, jti uuid.UUID, ttl time.Duration) error {
+	return dm.denylist.Revoke(ctx, jti, ttl)
+}
+
+func (dm *denylistManager) IsRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	revoked, err := dm.denylist.IsRevoked(ctx, jti)
+	if err == nil {
+		return revoked, nil
+	}
+
+	if dm.fallback == nil {
+		logging.FromContext(ctx).Warn("denylist unavailable, accepting token", slog.Any("err", err))
+		return false, nil
+	}
+
+	return dm.fallback.IsRevoked(ctx, jti)
+}
@@ -0,0 +1,99 @@
+package revocation_test
+
+import (
+	"errors"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/revocation"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestManager_IsRevoked(t *testing.T) {
+	t.Parallel()
+	jti := uuid.New()
+	unavailable := errors.New("circuit breaker is open")
+
+	tests := []struct {
+		name         string
+		withFallback bool
+		setupMocks   func(*mocks.MockDenylist, *mocks.MockRefreshTokenRepository)
+		expected     bool
+		expectErr    bool
+	}{
+		{
+			name:         "denylist answers",
+			withFallback: true,
+			setupMocks: func(d *mocks.MockDenylist, s *mocks.MockRefreshTokenRepository) {
+				d.On("IsRevoked", mock.Anything, jti).Return(true, nil)
+			},
+			expected: true,
+		},
+		{
+			name:         "fail closed checks the store",
+			withFallback: true,
+			setupMocks: func(d *mocks.MockDenylist, s *mocks.MockRefreshTokenRepository) {
+				d.On("IsRevoked", mock.Anything, jti).Return(false, unavailable)
+				s.On("IsRevoked", mock.Anything, jti).Return(true, nil)
+			},
+			expected: true,
+		},
+		{
+			name:         "fail closed with store error",
+			withFallback: true,
+			setupMocks: func(d *mocks.MockDenylist, s *mocks.MockRefreshTokenRepository) {
+				d.On("IsRevoked", mock.Anything, jti).Return(false, unavailable)
+				s.On("IsRevoked", mock.Anything, jti).Return(false, errors.New("db down"))
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail open accepts the token",
+			setupMocks: func(d *mocks.MockDenylist, s *mocks.MockRefreshTokenRepository) {
+				d.On("IsRevoked", mock.Anything, jti).Return(false, unavailable)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denylist := &mocks.MockDenylist{}
+			store := &mocks.MockRefreshTokenRepository{}
+			tt.setupMocks(denylist, store)
+
+			var opts []revocation.ManagerOption
+			if tt.withFallback {
+				opts = append(opts, revocation.WithFallback(store))
+			}
+			manager := revocation.New(denylist, opts...)
+
+			revoked, err := manager.IsRevoked(t.Context(), jti)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, revoked)
+
+			denylist.AssertExpectations(t)
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestManager_Revoke(t *testing.T) {
+	t.Parallel()
+	jti := uuid.New()
+
+	denylist := &mocks.MockDenylist{}
+	denylist.On("Revoke", mock.Anything, jti, time.Minute).Return(nil)
+
+	manager := revocation.New(denylist, revocation.WithFallback(&mocks.MockRefreshTokenRepository{}))
+
+	assert.NoError(t, manager.Revoke(t.Context(), jti, time.Minute))
+	denylist.AssertExpectations(t)
+}
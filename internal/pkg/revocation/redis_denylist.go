@@ -0,0 +1,58 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	redisinfra "gomonitor/internal/infra/redis"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type redisDenylist struct {
+	redisClient redisinfra.RedisClient
+	keyPrefix   string
+}
+
+type RedisOption func(*redisDenylist)
+
+func NewRedisDenylist(redisClient redisinfra.RedisClient, opts ...RedisOption) Denylist {
+	d := &redisDenylist{
+		redisClient: redisClient,
+		keyPrefix:   "revoked_session",
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+func WithPrefix(prefix string) RedisOption {
+	return func(d *redisDenylist) {
+		d.keyPrefix = prefix
+	}
+}
+
+// Revoke keeps the entry only while tokens bound to the session can still be valid.
+func (d *redisDenylist) Revoke(ctx context.Context, jti uuid.UUID, ttl time.Duration) error {
+	return d.redisClient.Set(ctx, d.key(jti), 1, ttl)
+}
+
+func (d *redisDenylist) IsRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	_, err := d.redisClient.Get(ctx, d.key(jti))
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (d *redisDenylist) key(jti uuid.UUID) string {
+	return d.keyPrefix + ":" + jti.String()
+}
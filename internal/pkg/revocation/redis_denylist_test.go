@@ -0,0 +1,77 @@
+package revocation_test
+
+import (
+	"errors"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/revocation"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRedisDenylist_Revoke(t *testing.T) {
+	t.Parallel()
+	jti := uuid.New()
+
+	client := &mocks.MockRedisClient{}
+	client.On("Set", mock.Anything, "revoked:"+jti.String(), 1, time.Hour).Return(nil)
+
+	denylist := revocation.NewRedisDenylist(client, revocation.WithPrefix("revoked"))
+
+	assert.NoError(t, denylist.Revoke(t.Context(), jti, time.Hour))
+	client.AssertExpectations(t)
+}
+
+func TestRedisDenylist_IsRevoked(t *testing.T) {
+	t.Parallel()
+	jti := uuid.New()
+	key := "revoked_session:" + jti.String()
+
+	tests := []struct {
+		name      string
+		setupMock func(*mocks.MockRedisClient)
+		expected  bool
+		expectErr bool
+	}{
+		{
+			name: "revoked",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Get", mock.Anything, key).Return("1", nil)
+			},
+			expected: true,
+		},
+		{
+			name: "not revoked",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Get", mock.Anything, key).Return("", redis.Nil)
+			},
+		},
+		{
+			name: "redis unavailable",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Get", mock.Anything, key).Return("", errors.New("circuit breaker is open"))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mocks.MockRedisClient{}
+			tt.setupMock(client)
+
+			revoked, err := revocation.NewRedisDenylist(client).IsRevoked(t.Context(), jti)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, revoked)
+		})
+	}
+}
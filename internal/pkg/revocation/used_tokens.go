@@ -0,0 +1,64 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	redisinfra "gomonitor/internal/infra/redis"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// UsedTokens records the single-use tokens already exchanged, like MFA challenges and login links.
+// Unlike the Denylist of sessions it has no fallback: while Redis is unavailable a token can't be proven
+// unused, its errors are returned and the exchange is refused until Redis recovers.
+type UsedTokens interface {
+	// Use records the jti until ttl, false when it already was. Concurrent calls can't both succeed.
+	Use(ctx context.Context, jti uuid.UUID, ttl time.Duration) (bool, error)
+	IsUsed(ctx context.Context, jti uuid.UUID) (bool, error)
+}
+
+type redisUsedTokens struct {
+	redisClient redisinfra.RedisClient
+	keyPrefix   string
+}
+
+// NewRedisUsedTokens keeps the used tokens apart from the revoked sessions, under their own prefix.
+func NewRedisUsedTokens(redisClient redisinfra.RedisClient) UsedTokens {
+	return &redisUsedTokens{
+		redisClient: redisClient,
+		keyPrefix:   "used_token",
+	}
+}
+
+// Use sets the key only if it doesn't exist, so a single-use token is exchanged once.
+func (u *redisUsedTokens) Use(ctx context.Context, jti uuid.UUID, ttl time.Duration) (bool, error) {
+	script := `return redis.call('SET', KEYS[1], '1', 'NX', 'PX', ARGV[1])`
+
+	_, err := u.redisClient.Eval(ctx, script, []string{u.key(jti)}, ttl.Milliseconds())
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (u *redisUsedTokens) IsUsed(ctx context.Context, jti uuid.UUID) (bool, error) {
+	_, err := u.redisClient.Get(ctx, u.key(jti))
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (u *redisUsedTokens) key(jti uuid.UUID) string {
+	return u.keyPrefix + ":" + jti.String()
+}
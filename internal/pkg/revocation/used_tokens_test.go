@@ -0,0 +1,117 @@
+package revocation_test
+
+import (
+	"errors"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/revocation"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRedisUsedTokens_Use(t *testing.T) {
+	t.Parallel()
+	jti := uuid.New()
+	keys := []string{"used_token:" + jti.String()}
+
+	tests := []struct {
+		name      string
+		setupMock func(*mocks.MockRedisClient)
+		expected  bool
+		expectErr bool
+	}{
+		{
+			name: "first use",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Eval", mock.Anything, mock.Anything, keys, []any{int64(60000)}).Return("OK", nil)
+			},
+			expected: true,
+		},
+		{
+			name: "already used",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Eval", mock.Anything, mock.Anything, keys, []any{int64(60000)}).Return(nil, redis.Nil)
+			},
+		},
+		{
+			name: "redis unavailable",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Eval", mock.Anything, mock.Anything, keys, []any{int64(60000)}).Return(nil, errors.New("circuit breaker is open"))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mocks.MockRedisClient{}
+			tt.setupMock(client)
+
+			fresh, err := revocation.NewRedisUsedTokens(client).Use(t.Context(), jti, time.Minute)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, fresh)
+			client.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRedisUsedTokens_IsUsed(t *testing.T) {
+	t.Parallel()
+	jti := uuid.New()
+	key := "used_token:" + jti.String()
+
+	tests := []struct {
+		name      string
+		setupMock func(*mocks.MockRedisClient)
+		expected  bool
+		expectErr bool
+	}{
+		{
+			name: "used",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Get", mock.Anything, key).Return("1", nil)
+			},
+			expected: true,
+		},
+		{
+			name: "not used",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Get", mock.Anything, key).Return("", redis.Nil)
+			},
+		},
+		{
+			// No fallback answers in place of Redis, a token is neither taken as used nor as unused.
+			name: "redis unavailable",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Get", mock.Anything, key).Return("", errors.New("circuit breaker is open"))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mocks.MockRedisClient{}
+			tt.setupMock(client)
+
+			used, err := revocation.NewRedisUsedTokens(client).IsUsed(t.Context(), jti)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, used)
+			client.AssertExpectations(t)
+		})
+	}
+}
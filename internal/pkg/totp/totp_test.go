@@ -0,0 +1,90 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"gomonitor/internal/pkg/totp"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 appendix B secret for SHA1.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// RFC 6238 test vectors, truncated to 6 digits.
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, code)
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	_, err := totp.Code("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	step := totp.Step(now)
+
+	tests := []struct {
+		name  string
+		step  int64
+		valid bool
+	}{
+		{name: "current period", step: step, valid: true},
+		{name: "previous period", step: step - 1, valid: true},
+		{name: "next period", step: step + 1, valid: true},
+		{name: "too old", step: step - 2},
+		{name: "too far ahead", step: step + 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := totp.Code(secret, tt.step)
+			require.NoError(t, err)
+
+			matched, ok := totp.Validate(secret, code, now)
+			assert.Equal(t, tt.valid, ok)
+			if tt.valid {
+				assert.Equal(t, tt.step, matched)
+			}
+		})
+	}
+
+	_, ok := totp.Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("gomonitor", "admin@test.com", "SECRET")
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.Equal(t, "/gomonitor:admin@test.com", parsed.Path)
+	assert.Equal(t, "SECRET", parsed.Query().Get("secret"))
+	assert.Equal(t, "gomonitor", parsed.Query().Get("issuer"))
+	assert.Equal(t, "6", parsed.Query().Get("digits"))
+}
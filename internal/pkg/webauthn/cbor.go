@@ -0,0 +1,153 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// maxCBORDepth bounds the nesting of decoded items, authenticators never go past a few levels.
+const maxCBORDepth = 16
+
+var errInvalidCBOR = errors.New("invalid cbor")
+
+// decodeCBOR decodes the first item of data and returns the bytes after it. Only the subset of CBOR
+// written by authenticators is supported: integers, byte and text strings, arrays, maps, booleans and
+// null, all of definite length. Integers are decoded as int64 so they can be compared with COSE labels.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeItem(data, 0)
+}
+
+func decodeItem(data []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth || len(data) == 0 {
+		return nil, nil, errInvalidCBOR
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+
+	// Simple values carry no argument to read.
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data[1:], nil
+		case 21:
+			return true, data[1:], nil
+		case 22:
+			return nil, data[1:], nil
+		default:
+			return nil, nil, errInvalidCBOR
+		}
+	}
+
+	arg, rest, err := readArgument(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, nil, errInvalidCBOR
+		}
+		return int64(arg), rest, nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, nil, errInvalidCBOR
+		}
+		return -1 - int64(arg), rest, nil
+	case 2, 3:
+		if arg > uint64(len(rest)) {
+			return nil, nil, errInvalidCBOR
+		}
+		value := rest[:arg]
+		if major == 3 {
+			return string(value), rest[arg:], nil
+		}
+		return append([]byte{}, value...), rest[arg:], nil
+	case 4:
+		// Every item takes at least one byte, longer arrays can't fit.
+		if arg > uint64(len(rest)) {
+			return nil, nil, errInvalidCBOR
+		}
+		items := make([]any, 0, arg)
+		for range arg {
+			var item any
+			item, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	case 5:
+		if arg > uint64(len(rest))/2 {
+			return nil, nil, errInvalidCBOR
+		}
+		entries := make(map[any]any, arg)
+		for range arg {
+			var key, value any
+			key, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errInvalidCBOR
+			}
+			if _, ok := entries[key]; ok {
+				return nil, nil, errInvalidCBOR
+			}
+			value, rest, err = decodeItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			entries[key] = value
+		}
+		return entries, rest, nil
+	default:
+		// Tags aren't used by authenticators.
+		return nil, nil, errInvalidCBOR
+	}
+}
+
+// readArgument reads the argument following the initial byte, indefinite lengths are refused.
+func readArgument(data []byte) (uint64, []byte, error) {
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	var size int
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, nil, errInvalidCBOR
+	}
+
+	if len(data) < size {
+		return 0, nil, errInvalidCBOR
+	}
+
+	var arg uint64
+	switch size {
+	case 1:
+		arg = uint64(data[0])
+	case 2:
+		arg = uint64(binary.BigEndian.Uint16(data))
+	case 4:
+		arg = uint64(binary.BigEndian.Uint32(data))
+	case 8:
+		arg = binary.BigEndian.Uint64(data)
+	}
+
+	return arg, data[size:], nil
+}
@@ -0,0 +1,112 @@
+package webauthn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	redisinfra "gomonitor/internal/infra/redis"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrChallengeNotFound is returned for unknown, expired or already answered challenges.
+var ErrChallengeNotFound = errors.New("webauthn challenge not found")
+
+// Purpose tells which ceremony a challenge was issued for.
+type Purpose string
+
+const (
+	PurposeRegister Purpose = "register"
+	PurposeLogin    Purpose = "login"
+	PurposeMFA      Purpose = "mfa"
+	// PurposeReauth proves again the credentials of a session, it never starts one.
+	PurposeReauth Purpose = "reauth"
+)
+
+// Session is what the relying party remembers of a ceremony until the authenticator answers.
+type Session struct {
+	Purpose Purpose `json:"purpose"`
+	// UserID is the user the ceremony is for, zero for a login with a discoverable credential.
+	UserID uint `json:"user_id,omitempty"`
+	// Binding ties the ceremony to another flow, like the MFA challenge it completes.
+	Binding string `json:"binding,omitempty"`
+}
+
+// ChallengeStore keeps ceremonies by challenge, each challenge is answered at most once.
+type ChallengeStore interface {
+	Save(ctx context.Context, challenge string, session *Session, ttl time.Duration) error
+	// Consume returns the session and forgets it, ErrChallengeNotFound if there is none.
+	Consume(ctx context.Context, challenge string) (*Session, error)
+}
+
+type redisChallengeStore struct {
+	redisClient redisinfra.RedisClient
+	keyPrefix   string
+}
+
+type RedisOption func(*redisChallengeStore)
+
+// NewRedisChallengeStore shares the ceremonies between instances, the response may reach another one.
+func NewRedisChallengeStore(redisClient redisinfra.RedisClient, opts ...RedisOption) ChallengeStore {
+	s := &redisChallengeStore{
+		redisClient: redisClient,
+		keyPrefix:   "webauthn_challenge",
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func WithPrefix(prefix string) RedisOption {
+	return func(s *redisChallengeStore) {
+		s.keyPrefix = prefix
+	}
+}
+
+func (s *redisChallengeStore) Save(ctx context.Context, challenge string, session *Session, ttl time.Duration) error {
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return s.redisClient.Set(ctx, s.key(challenge), raw, ttl)
+}
+
+// Consume reads and deletes the session atomically, so concurrent answers can't both succeed.
+func (s *redisChallengeStore) Consume(ctx context.Context, challenge string) (*Session, error) {
+	script := `
+	local session = redis.call('GET', KEYS[1])
+	if session then
+		redis.call('DEL', KEYS[1])
+	end
+	return session
+	`
+
+	result, err := s.redisClient.Eval(ctx, script, []string{s.key(challenge)})
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrChallengeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := result.(string)
+	if !ok {
+		return nil, errors.New("couldn't cast eval to string")
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(raw), &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *redisChallengeStore) key(challenge string) string {
+	return s.keyPrefix + ":" + challenge
+}
@@ -0,0 +1,76 @@
+package webauthn_test
+
+import (
+	"errors"
+	"gomonitor/internal/mocks"
+	"gomonitor/internal/pkg/webauthn"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRedisChallengeStore_Save(t *testing.T) {
+	t.Parallel()
+
+	client := &mocks.MockRedisClient{}
+	client.On("Set", mock.Anything, "passkeys:abc", []byte(`{"purpose":"mfa","user_id":1,"binding":"jti"}`), time.Minute).Return(nil)
+
+	store := webauthn.NewRedisChallengeStore(client, webauthn.WithPrefix("passkeys"))
+
+	err := store.Save(t.Context(), "abc", &webauthn.Session{Purpose: webauthn.PurposeMFA, UserID: 1, Binding: "jti"}, time.Minute)
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestRedisChallengeStore_Consume(t *testing.T) {
+	t.Parallel()
+	keys := []string{"webauthn_challenge:abc"}
+
+	tests := []struct {
+		name        string
+		setupMock   func(*mocks.MockRedisClient)
+		expected    *webauthn.Session
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Eval", mock.Anything, mock.Anything, keys, mock.Anything).Return(`{"purpose":"login"}`, nil)
+			},
+			expected: &webauthn.Session{Purpose: webauthn.PurposeLogin},
+		},
+		{
+			name: "not found",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Eval", mock.Anything, mock.Anything, keys, mock.Anything).Return(nil, redis.Nil)
+			},
+			expectedErr: webauthn.ErrChallengeNotFound,
+		},
+		{
+			name: "redis unavailable",
+			setupMock: func(m *mocks.MockRedisClient) {
+				m.On("Eval", mock.Anything, mock.Anything, keys, mock.Anything).Return(nil, errors.New("circuit breaker is open"))
+			},
+			expectedErr: errors.New("circuit breaker is open"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mocks.MockRedisClient{}
+			tt.setupMock(client)
+
+			session, err := webauthn.NewRedisChallengeStore(client).Consume(t.Context(), "abc")
+
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, session)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+)
+
+// COSE algorithms accepted for credentials, in order of preference.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms are offered to the authenticator on registration.
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key labels and values, as registered by IANA.
+const (
+	coseKeyType   int64 = 1
+	coseAlgorithm int64 = 3
+	coseCurve     int64 = -1
+	coseX         int64 = -2
+	coseY         int64 = -3
+	coseModulus   int64 = -1
+	coseExponent  int64 = -2
+
+	coseKeyTypeOKP int64 = 1
+	coseKeyTypeEC2 int64 = 2
+	coseKeyTypeRSA int64 = 3
+
+	coseCurveP256    int64 = 1
+	coseCurveEd25519 int64 = 6
+)
+
+const minRSABits = 2048
+
+// publicKey is a credential public key, only verifying signatures of its algorithm.
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey reads a COSE_Key of a supported algorithm, returning the bytes after it.
+func parsePublicKey(data []byte) (*publicKey, []byte, error) {
+	item, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, nil, ErrInvalidResponse
+	}
+
+	m, ok := item.(map[any]any)
+	if !ok {
+		return nil, nil, ErrInvalidResponse
+	}
+
+	kty, _ := m[coseKeyType].(int64)
+	alg, _ := m[coseAlgorithm].(int64)
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgES256:
+		crv, _ := m[coseCurve].(int64)
+		x, _ := m[coseX].([]byte)
+		y, _ := m[coseY].([]byte)
+		if crv != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, nil, ErrInvalidResponse
+		}
+
+		point := append(append([]byte{0x04}, x...), y...)
+		key, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+		if err != nil {
+			return nil, nil, ErrInvalidResponse
+		}
+		return &publicKey{alg: alg, key: key}, rest, nil
+
+	case kty == coseKeyTypeOKP && alg == AlgEdDSA:
+		crv, _ := m[coseCurve].(int64)
+		x, _ := m[coseX].([]byte)
+		if crv != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, nil, ErrInvalidResponse
+		}
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, rest, nil
+
+	case kty == coseKeyTypeRSA && alg == AlgRS256:
+		n, _ := m[coseModulus].([]byte)
+		e, _ := m[coseExponent].([]byte)
+		if len(e) == 0 || len(e) > 4 {
+			return nil, nil, ErrInvalidResponse
+		}
+
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSABits || key.E < 3 || key.E%2 == 0 {
+			return nil, nil, ErrInvalidResponse
+		}
+		return &publicKey{alg: alg, key: key}, rest, nil
+
+	default:
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// verify checks the signature of data, in the encoding WebAuthn uses for the algorithm.
+func (k *publicKey) verify(data, sig []byte) error {
+	var ok bool
+
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		ok = ecdsa.VerifyASN1(key, digest[:], sig)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(key, data, sig)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		ok = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	}
+
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
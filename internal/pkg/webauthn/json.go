@@ -0,0 +1,35 @@
+package webauthn
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// URLEncoded is binary data in the JSON form of a credential, base64url encoded. Padding is accepted,
+// some browsers add it. An empty string decodes to nil, so that it fails a required binding.
+type URLEncoded []byte
+
+func (b URLEncoded) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *URLEncoded) UnmarshalJSON(data []byte) error {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	if encoded == "" {
+		*b = nil
+		return nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+	return nil
+}
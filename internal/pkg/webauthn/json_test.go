@@ -0,0 +1,28 @@
+package webauthn_test
+
+import (
+	"encoding/json"
+	"gomonitor/internal/pkg/webauthn"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLEncoded(t *testing.T) {
+	t.Parallel()
+
+	raw, err := json.Marshal(webauthn.URLEncoded{0xfb, 0xff})
+	require.NoError(t, err)
+	assert.Equal(t, `"-_8"`, string(raw))
+
+	var decoded webauthn.URLEncoded
+	require.NoError(t, json.Unmarshal([]byte(`"-_8="`), &decoded))
+	assert.Equal(t, webauthn.URLEncoded{0xfb, 0xff}, decoded)
+
+	require.NoError(t, json.Unmarshal([]byte(`""`), &decoded))
+	assert.Nil(t, decoded)
+
+	assert.Error(t, json.Unmarshal([]byte(`"+/8"`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`12`), &decoded))
+}
@@ -0,0 +1,121 @@
+package webauthn
+
+import "encoding/base64"
+
+// User is the account a credential is created for. ID is the user handle, it must not identify the
+// user outside of the relying party.
+type User struct {
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// CreationOptions are the options of navigator.credentials.create, in the JSON form of
+// PublicKeyCredential.parseCreationOptionsFromJSON. Binary values are base64url encoded.
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RPEntity               `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the options of navigator.credentials.get, in the JSON form of
+// PublicKeyCredential.parseRequestOptionsFromJSON. Without allowed credentials, any discoverable
+// credential of the RP ID can answer.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+type RPEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// Values of the user verification requirement.
+const (
+	UserVerificationRequired  = "required"
+	UserVerificationPreferred = "preferred"
+)
+
+const credentialType = "public-key"
+
+// CreationOptions creates a discoverable credential for the user, so it can later log in without
+// an email. The existing credentials of the user are excluded, an authenticator only holds one.
+func (rp *RelyingParty) CreationOptions(challenge string, user User, exclude [][]byte) *CreationOptions {
+	params := make([]CredentialParameter, 0, len(SupportedAlgorithms))
+	for _, alg := range SupportedAlgorithms {
+		params = append(params, CredentialParameter{Type: credentialType, Alg: alg})
+	}
+
+	return &CreationOptions{
+		Challenge: challenge,
+		RP:        RPEntity{ID: rp.cfg.RPID, Name: rp.cfg.RPName},
+		User: UserEntity{
+			ID:          base64.RawURLEncoding.EncodeToString(user.ID),
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		PubKeyCredParams:   params,
+		Timeout:            rp.cfg.ChallengeTTL.Milliseconds(),
+		ExcludeCredentials: descriptors(exclude),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "required",
+			UserVerification: UserVerificationRequired,
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions asks for an assertion of one of the allowed credentials, or of any discoverable one.
+func (rp *RelyingParty) RequestOptions(challenge string, allow [][]byte, requireUV bool) *RequestOptions {
+	userVerification := UserVerificationPreferred
+	if requireUV {
+		userVerification = UserVerificationRequired
+	}
+
+	return &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.cfg.ChallengeTTL.Milliseconds(),
+		RPID:             rp.cfg.RPID,
+		AllowCredentials: descriptors(allow),
+		UserVerification: userVerification,
+	}
+}
+
+func descriptors(ids [][]byte) []CredentialDescriptor {
+	list := make([]CredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, CredentialDescriptor{Type: credentialType, ID: base64.RawURLEncoding.EncodeToString(id)})
+	}
+
+	return list
+}
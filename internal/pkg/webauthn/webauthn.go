@@ -0,0 +1,350 @@
+// Package webauthn is a relying party for passkeys, verifying the registration and authentication
+// ceremonies of the Web Authentication API. Attestation is limited to none or self, the relying party
+// doesn't vet authenticator models.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"gomonitor/internal/config"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidResponse        = errors.New("invalid webauthn response")
+	ErrChallengeMismatch      = errors.New("webauthn challenge mismatch")
+	ErrOriginMismatch         = errors.New("webauthn origin not allowed")
+	ErrRPIDMismatch           = errors.New("webauthn rp id mismatch")
+	ErrUserNotPresent         = errors.New("webauthn user not present")
+	ErrUserNotVerified        = errors.New("webauthn user not verified")
+	ErrUnsupportedAttestation = errors.New("unsupported webauthn attestation")
+	ErrUnsupportedAlgorithm   = errors.New("unsupported webauthn algorithm")
+	ErrInvalidSignature       = errors.New("invalid webauthn signature")
+	// ErrSignCountRegressed means two authenticators hold the same credential, one of them a clone.
+	ErrSignCountRegressed = errors.New("webauthn sign count regressed")
+)
+
+// Ceremony types written by the browser in the client data.
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// Authenticator data flags.
+const (
+	flagUserPresent    byte = 0x01
+	flagUserVerified   byte = 0x04
+	flagBackupEligible byte = 0x08
+	flagBackedUp       byte = 0x10
+	flagAttestedData   byte = 0x40
+	flagExtensionData  byte = 0x80
+)
+
+const (
+	// challengeBytes is the entropy of a challenge, twice the minimum of the specification.
+	challengeBytes       = 32
+	maxCredentialIDBytes = 1023
+)
+
+// RelyingParty runs the ceremonies for the configured RP ID and origins.
+type RelyingParty struct {
+	cfg    *config.WebAuthnConfig
+	rpHash [32]byte
+}
+
+func NewRelyingParty(cfg *config.WebAuthnConfig) *RelyingParty {
+	return &RelyingParty{
+		cfg:    cfg,
+		rpHash: sha256.Sum256([]byte(cfg.RPID)),
+	}
+}
+
+// Credential is a public key credential created by an authenticator.
+type Credential struct {
+	ID []byte
+	// PublicKey is the COSE_Key of the credential, stored as is.
+	PublicKey []byte
+	SignCount uint32
+	AAGUID    uuid.UUID
+	// UserVerified reports if the authenticator verified the user, not only their presence.
+	UserVerified bool
+	// BackupEligible credentials are synced passkeys, BackedUp once they actually are.
+	BackupEligible bool
+	BackedUp       bool
+}
+
+// RegistrationResponse is the response of the authenticator to the creation options.
+type RegistrationResponse struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// AssertionResponse is the response of the authenticator to the request options.
+type AssertionResponse struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	// UserHandle is set by discoverable credentials, it must match the owner of the credential.
+	UserHandle []byte
+}
+
+// Assertion is the verified result of an authentication ceremony.
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+	BackedUp     bool
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	flags     byte
+	signCount uint32
+	// Only set when attested credential data is present.
+	aaguid       uuid.UUID
+	credentialID []byte
+	publicKey    *publicKey
+	publicKeyRaw []byte
+}
+
+// NewChallenge returns a random challenge, base64url encoded like the browser echoes it.
+func NewChallenge() (string, error) {
+	raw := make([]byte, challengeBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// ParseChallenge reads the challenge a response answers, so its ceremony can be looked up.
+// Nothing is verified yet, the response must still go through VerifyRegistration or VerifyAssertion.
+func ParseChallenge(clientDataJSON []byte) (string, error) {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil || data.Challenge == "" {
+		return "", ErrInvalidResponse
+	}
+
+	return data.Challenge, nil
+}
+
+// VerifyRegistration checks the response to the creation options sent with challenge and returns the
+// new credential. requireUV refuses authenticators which only checked the presence of the user.
+func (rp *RelyingParty) VerifyRegistration(challenge string, resp *RegistrationResponse, requireUV bool) (*Credential, error) {
+	if err := rp.verifyClientData(resp.ClientDataJSON, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	item, rest, err := decodeCBOR(resp.AttestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, ErrInvalidResponse
+	}
+
+	attestation, ok := item.(map[any]any)
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[any]any)
+	rawAuthData, _ := attestation["authData"].([]byte)
+	if statement == nil || rawAuthData == nil {
+		return nil, ErrInvalidResponse
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData, requireUV)
+	if err != nil {
+		return nil, err
+	}
+	if authData.publicKey == nil {
+		return nil, ErrInvalidResponse
+	}
+
+	if err := verifyAttestation(format, statement, authData, rawAuthData, resp.ClientDataJSON); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:             authData.credentialID,
+		PublicKey:      authData.publicKeyRaw,
+		SignCount:      authData.signCount,
+		AAGUID:         authData.aaguid,
+		UserVerified:   authData.flags&flagUserVerified != 0,
+		BackupEligible: authData.flags&flagBackupEligible != 0,
+		BackedUp:       authData.flags&flagBackedUp != 0,
+	}, nil
+}
+
+// VerifyAssertion checks the response to the request options sent with challenge, signed by the
+// credential of publicKey. The sign count must grow past signCount, unless the authenticator has none.
+func (rp *RelyingParty) VerifyAssertion(challenge string, resp *AssertionResponse, publicKey []byte, signCount uint32, requireUV bool) (*Assertion, error) {
+	if err := rp.verifyClientData(resp.ClientDataJSON, ceremonyGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := rp.verifyAuthenticatorData(resp.AuthenticatorData, requireUV)
+	if err != nil {
+		return nil, err
+	}
+
+	key, rest, err := parsePublicKey(publicKey)
+	if err != nil || len(rest) != 0 {
+		return nil, ErrInvalidResponse
+	}
+
+	if err := key.verify(signedData(resp.AuthenticatorData, resp.ClientDataJSON), resp.Signature); err != nil {
+		return nil, err
+	}
+
+	if (authData.signCount != 0 || signCount != 0) && authData.signCount <= signCount {
+		return nil, ErrSignCountRegressed
+	}
+
+	return &Assertion{
+		SignCount:    authData.signCount,
+		UserVerified: authData.flags&flagUserVerified != 0,
+		BackedUp:     authData.flags&flagBackedUp != 0,
+	}, nil
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return ErrInvalidResponse
+	}
+
+	if data.Type != ceremony {
+		return ErrInvalidResponse
+	}
+
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1 {
+		return ErrChallengeMismatch
+	}
+
+	// Ceremonies embedded in other sites' frames are refused.
+	if data.CrossOrigin || !slices.Contains(rp.cfg.Origins, data.Origin) {
+		return ErrOriginMismatch
+	}
+
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(raw []byte, requireUV bool) (*authenticatorData, error) {
+	data, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare(raw[:32], rp.rpHash[:]) != 1 {
+		return nil, ErrRPIDMismatch
+	}
+
+	if data.flags&flagUserPresent == 0 {
+		return nil, ErrUserNotPresent
+	}
+
+	if requireUV && data.flags&flagUserVerified == 0 {
+		return nil, ErrUserNotVerified
+	}
+
+	return data, nil
+}
+
+// parseAuthenticatorData reads the binary authenticator data, with the attested credential if present.
+func parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, ErrInvalidResponse
+	}
+
+	data := &authenticatorData{
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	rest := raw[37:]
+
+	if data.flags&flagAttestedData != 0 {
+		if len(rest) < 18 {
+			return nil, ErrInvalidResponse
+		}
+
+		data.aaguid = uuid.UUID(rest[:16])
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen == 0 || idLen > maxCredentialIDBytes || len(rest) < idLen {
+			return nil, ErrInvalidResponse
+		}
+		data.credentialID = append([]byte{}, rest[:idLen]...)
+		rest = rest[idLen:]
+
+		key, after, err := parsePublicKey(rest)
+		if err != nil {
+			return nil, err
+		}
+		data.publicKey = key
+		data.publicKeyRaw = append([]byte{}, rest[:len(rest)-len(after)]...)
+		rest = after
+	}
+
+	// Extension outputs aren't used, but they must be well formed and end the data.
+	if data.flags&flagExtensionData != 0 {
+		item, after, err := decodeCBOR(rest)
+		if _, ok := item.(map[any]any); err != nil || !ok {
+			return nil, ErrInvalidResponse
+		}
+		rest = after
+	}
+
+	if len(rest) != 0 {
+		return nil, ErrInvalidResponse
+	}
+
+	return data, nil
+}
+
+// verifyAttestation accepts no attestation, or a packed self attestation signed by the credential.
+func verifyAttestation(format string, statement map[any]any, authData *authenticatorData, rawAuthData, clientDataJSON []byte) error {
+	switch format {
+	case "none":
+		if len(statement) != 0 {
+			return ErrInvalidResponse
+		}
+		return nil
+
+	case "packed":
+		// A certificate chain is a full attestation, which would need vetted roots.
+		if _, ok := statement["x5c"]; ok {
+			return ErrUnsupportedAttestation
+		}
+
+		alg, _ := statement["alg"].(int64)
+		sig, _ := statement["sig"].([]byte)
+		if alg != authData.publicKey.alg || len(sig) == 0 {
+			return ErrInvalidResponse
+		}
+
+		return authData.publicKey.verify(signedData(rawAuthData, clientDataJSON), sig)
+
+	default:
+		return ErrUnsupportedAttestation
+	}
+}
+
+// signedData is what the authenticator signs, its data followed by the hash of the client data.
+func signedData(authData, clientDataJSON []byte) []byte {
+	hash := sha256.Sum256(clientDataJSON)
+	return append(bytes.Clone(authData), hash[:]...)
+}
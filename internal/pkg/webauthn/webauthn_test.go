@@ -0,0 +1,294 @@
+package webauthn_test
+
+import (
+	"gomonitor/internal/config"
+	"gomonitor/internal/pkg/webauthn"
+	"gomonitor/internal/pkg/webauthn/webauthntest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const origin = "https://example.com"
+
+func newRelyingParty() *webauthn.RelyingParty {
+	return webauthn.NewRelyingParty(&config.WebAuthnConfig{
+		RPID:         "example.com",
+		RPName:       "gomonitor",
+		Origins:      []string{origin},
+		ChallengeTTL: 5 * time.Minute,
+	})
+}
+
+var user = webauthn.User{ID: []byte{0, 0, 0, 0, 0, 0, 0, 1}, Name: "jdoe", DisplayName: "John Doe"}
+
+func register(t *testing.T, rp *webauthn.RelyingParty, authenticator *webauthntest.Authenticator) *webauthn.Credential {
+	t.Helper()
+
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+
+	_, resp, err := authenticator.Create(rp.CreationOptions(challenge, user, nil))
+	require.NoError(t, err)
+
+	cred, err := rp.VerifyRegistration(challenge, resp, true)
+	require.NoError(t, err)
+
+	return cred
+}
+
+func TestRelyingParty_VerifyRegistration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		setup       func(*webauthntest.Authenticator)
+		challenge   string
+		requireUV   bool
+		expectedErr error
+	}{
+		{
+			name: "no attestation",
+		},
+		{
+			name: "self attestation",
+			setup: func(a *webauthntest.Authenticator) {
+				a.Attestation = "packed"
+			},
+		},
+		{
+			name: "unsupported attestation",
+			setup: func(a *webauthntest.Authenticator) {
+				a.Attestation = "fido-u2f"
+			},
+			expectedErr: webauthn.ErrUnsupportedAttestation,
+		},
+		{
+			name:        "challenge mismatch",
+			challenge:   "other",
+			expectedErr: webauthn.ErrChallengeMismatch,
+		},
+		{
+			name: "origin mismatch",
+			setup: func(a *webauthntest.Authenticator) {
+				a.Origin = "https://evil.example"
+			},
+			expectedErr: webauthn.ErrOriginMismatch,
+		},
+		{
+			name: "cross origin",
+			setup: func(a *webauthntest.Authenticator) {
+				a.MutateClientData = func(data map[string]any) { data["crossOrigin"] = true }
+			},
+			expectedErr: webauthn.ErrOriginMismatch,
+		},
+		{
+			name: "wrong ceremony",
+			setup: func(a *webauthntest.Authenticator) {
+				a.MutateClientData = func(data map[string]any) { data["type"] = "webauthn.get" }
+			},
+			expectedErr: webauthn.ErrInvalidResponse,
+		},
+		{
+			name: "user not verified",
+			setup: func(a *webauthntest.Authenticator) {
+				a.UserVerified = false
+			},
+			requireUV:   true,
+			expectedErr: webauthn.ErrUserNotVerified,
+		},
+		{
+			name: "user presence is enough",
+			setup: func(a *webauthntest.Authenticator) {
+				a.UserVerified = false
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rp := newRelyingParty()
+			authenticator := webauthntest.New(origin)
+			if tt.setup != nil {
+				tt.setup(authenticator)
+			}
+
+			challenge, err := webauthn.NewChallenge()
+			require.NoError(t, err)
+
+			id, resp, err := authenticator.Create(rp.CreationOptions(challenge, user, nil))
+			require.NoError(t, err)
+
+			if tt.challenge != "" {
+				challenge = tt.challenge
+			}
+
+			cred, err := rp.VerifyRegistration(challenge, resp, tt.requireUV)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, id, cred.ID)
+			assert.Equal(t, uuid.UUID(webauthntest.AAGUID), cred.AAGUID)
+			assert.Equal(t, uint32(1), cred.SignCount)
+			assert.NotEmpty(t, cred.PublicKey)
+		})
+	}
+}
+
+func TestRelyingParty_VerifyRegistration_Tampered(t *testing.T) {
+	t.Parallel()
+	rp := newRelyingParty()
+	authenticator := webauthntest.New(origin)
+	authenticator.Attestation = "packed"
+
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+
+	_, resp, err := authenticator.Create(rp.CreationOptions(challenge, user, nil))
+	require.NoError(t, err)
+
+	// Still valid JSON, but not the client data the authenticator signed.
+	_, err = rp.VerifyRegistration(challenge, &webauthn.RegistrationResponse{
+		ClientDataJSON:    append(resp.ClientDataJSON, ' '),
+		AttestationObject: resp.AttestationObject,
+	}, true)
+	assert.ErrorIs(t, err, webauthn.ErrInvalidSignature)
+
+	_, err = rp.VerifyRegistration(challenge, &webauthn.RegistrationResponse{
+		ClientDataJSON:    resp.ClientDataJSON,
+		AttestationObject: []byte{0xa1},
+	}, true)
+	assert.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+}
+
+func TestRelyingParty_VerifyAssertion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		setup       func(*webauthntest.Authenticator)
+		signCount   func(stored uint32) uint32
+		challenge   string
+		requireUV   bool
+		expectedErr error
+	}{
+		{
+			name:      "success",
+			requireUV: true,
+		},
+		{
+			name: "authenticator without sign count",
+			setup: func(a *webauthntest.Authenticator) {
+				a.SetSignCount(0)
+				a.StaticSignCount = true
+			},
+			signCount: func(uint32) uint32 { return 0 },
+		},
+		{
+			name: "sign count regressed",
+			setup: func(a *webauthntest.Authenticator) {
+				a.SetSignCount(0)
+			},
+			signCount:   func(uint32) uint32 { return 5 },
+			expectedErr: webauthn.ErrSignCountRegressed,
+		},
+		{
+			name:        "challenge mismatch",
+			challenge:   "other",
+			expectedErr: webauthn.ErrChallengeMismatch,
+		},
+		{
+			name: "wrong ceremony",
+			setup: func(a *webauthntest.Authenticator) {
+				a.MutateClientData = func(data map[string]any) { data["type"] = "webauthn.create" }
+			},
+			expectedErr: webauthn.ErrInvalidResponse,
+		},
+		{
+			name: "user not verified",
+			setup: func(a *webauthntest.Authenticator) {
+				a.UserVerified = false
+			},
+			requireUV:   true,
+			expectedErr: webauthn.ErrUserNotVerified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rp := newRelyingParty()
+			authenticator := webauthntest.New(origin)
+			cred := register(t, rp, authenticator)
+
+			if tt.setup != nil {
+				tt.setup(authenticator)
+			}
+
+			signCount := cred.SignCount
+			if tt.signCount != nil {
+				signCount = tt.signCount(signCount)
+			}
+
+			challenge, err := webauthn.NewChallenge()
+			require.NoError(t, err)
+
+			resp, err := authenticator.Get(rp.RequestOptions(challenge, [][]byte{cred.ID}, tt.requireUV))
+			require.NoError(t, err)
+
+			if tt.challenge != "" {
+				challenge = tt.challenge
+			}
+
+			assertion, err := rp.VerifyAssertion(challenge, resp, cred.PublicKey, signCount, tt.requireUV)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, resp.UserHandle)
+			if tt.signCount == nil {
+				assert.Equal(t, cred.SignCount+1, assertion.SignCount)
+			}
+		})
+	}
+}
+
+func TestRelyingParty_VerifyAssertion_OtherCredential(t *testing.T) {
+	t.Parallel()
+	rp := newRelyingParty()
+	authenticator := webauthntest.New(origin)
+	register(t, rp, authenticator)
+	other := register(t, rp, webauthntest.New(origin))
+
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+
+	resp, err := authenticator.Get(rp.RequestOptions(challenge, nil, true))
+	require.NoError(t, err)
+
+	_, err = rp.VerifyAssertion(challenge, resp, other.PublicKey, other.SignCount, true)
+	assert.ErrorIs(t, err, webauthn.ErrInvalidSignature)
+}
+
+func TestParseChallenge(t *testing.T) {
+	t.Parallel()
+
+	challenge, err := webauthn.ParseChallenge([]byte(`{"type":"webauthn.get","challenge":"abc"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", challenge)
+
+	_, err = webauthn.ParseChallenge([]byte(`{"type":"webauthn.get"}`))
+	assert.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+
+	_, err = webauthn.ParseChallenge([]byte(`not json`))
+	assert.ErrorIs(t, err, webauthn.ErrInvalidResponse)
+}
@@ -0,0 +1,232 @@
+// Package webauthntest is a software authenticator, enough of one to run the ceremonies in tests.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"gomonitor/internal/pkg/webauthn"
+	"sync"
+)
+
+// AAGUID identifies the model of the authenticator in the attested credential data.
+var AAGUID = [16]byte{0x67, 0x6f, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x2d, 0x74, 0x65, 0x73, 0x74, 0x00, 0x01}
+
+var ErrNoCredential = errors.New("no matching credential")
+
+// Authenticator holds ES256 credentials in memory and signs with them for the origin.
+type Authenticator struct {
+	Origin string
+	// Attestation is the format of the attestation statement, none or packed for a self attestation.
+	Attestation string
+	// UserVerified sets the UV flag, a security key without a PIN only proves presence.
+	UserVerified bool
+	// StaticSignCount leaves the counter at zero, like synced passkeys do.
+	StaticSignCount bool
+	// MutateClientData edits the client data before it is signed, to test its validation.
+	MutateClientData func(map[string]any)
+
+	mu          sync.Mutex
+	credentials []*credential
+}
+
+type credential struct {
+	id         []byte
+	key        *ecdsa.PrivateKey
+	rpID       string
+	userHandle []byte
+	signCount  uint32
+}
+
+// New returns an authenticator with user verification, answering for origin without attestation.
+func New(origin string) *Authenticator {
+	return &Authenticator{
+		Origin:       origin,
+		Attestation:  "none",
+		UserVerified: true,
+	}
+}
+
+// Create answers the creation options with a new credential, returning its ID along with the response.
+func (a *Authenticator) Create(options *webauthn.CreationOptions) ([]byte, *webauthn.RegistrationResponse, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, nil, err
+	}
+
+	userHandle, err := base64.RawURLEncoding.DecodeString(options.User.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cred := &credential{id: id, key: key, rpID: options.RP.ID, userHandle: userHandle}
+
+	clientDataJSON, err := a.clientData("webauthn.create", options.Challenge)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authData, err := a.authenticatorData(cred, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	statement := map[any]any{}
+	if a.Attestation == "packed" {
+		sig, err := sign(key, authData, clientDataJSON)
+		if err != nil {
+			return nil, nil, err
+		}
+		statement = map[any]any{"alg": webauthn.AlgES256, "sig": sig}
+	}
+
+	attestation := encode(map[any]any{
+		"fmt":      a.Attestation,
+		"attStmt":  statement,
+		"authData": authData,
+	})
+
+	a.mu.Lock()
+	a.credentials = append(a.credentials, cred)
+	a.mu.Unlock()
+
+	return id, &webauthn.RegistrationResponse{
+		ClientDataJSON:    clientDataJSON,
+		AttestationObject: attestation,
+	}, nil
+}
+
+// Get answers the request options with an allowed credential, or with any credential of the RP ID
+// when none is listed.
+func (a *Authenticator) Get(options *webauthn.RequestOptions) (*webauthn.AssertionResponse, error) {
+	cred, err := a.find(options)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataJSON, err := a.clientData("webauthn.get", options.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	authData, err := a.authenticatorData(cred, false)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := sign(cred.key, authData, clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webauthn.AssertionResponse{
+		CredentialID:      cred.id,
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authData,
+		Signature:         sig,
+		UserHandle:        cred.userHandle,
+	}, nil
+}
+
+// SetSignCount moves the counter of every credential, to replay an older state like a clone would.
+func (a *Authenticator) SetSignCount(count uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, cred := range a.credentials {
+		cred.signCount = count
+	}
+}
+
+func (a *Authenticator) find(options *webauthn.RequestOptions) (*credential, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, cred := range a.credentials {
+		if cred.rpID != options.RPID {
+			continue
+		}
+		if len(options.AllowCredentials) == 0 {
+			return cred, nil
+		}
+		for _, allowed := range options.AllowCredentials {
+			if allowed.ID == base64.RawURLEncoding.EncodeToString(cred.id) {
+				return cred, nil
+			}
+		}
+	}
+
+	return nil, ErrNoCredential
+}
+
+func (a *Authenticator) clientData(ceremony, challenge string) ([]byte, error) {
+	data := map[string]any{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	}
+	if a.MutateClientData != nil {
+		a.MutateClientData(data)
+	}
+
+	return json.Marshal(data)
+}
+
+// authenticatorData counts the use of the credential, attested data is only part of its creation.
+func (a *Authenticator) authenticatorData(cred *credential, attested bool) ([]byte, error) {
+	a.mu.Lock()
+	if !a.StaticSignCount {
+		cred.signCount++
+	}
+	signCount := cred.signCount
+	a.mu.Unlock()
+
+	flags := byte(0x01)
+	if a.UserVerified {
+		flags |= 0x04
+	}
+
+	rpHash := sha256.Sum256([]byte(cred.rpID))
+	data := append(rpHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+
+	if !attested {
+		return data, nil
+	}
+
+	data[32] |= 0x40
+	data = append(data, AAGUID[:]...)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(cred.id)))
+	data = append(data, cred.id...)
+
+	point, err := cred.key.PublicKey.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, encode(map[any]any{
+		int64(1):  int64(2),
+		int64(3):  webauthn.AlgES256,
+		int64(-1): int64(1),
+		int64(-2): point[1:33],
+		int64(-3): point[33:],
+	})...), nil
+}
+
+func sign(key *ecdsa.PrivateKey, authData, clientDataJSON []byte) ([]byte, error) {
+	hash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), hash[:]...))
+
+	return ecdsa.SignASN1(rand.Reader, key, digest[:])
+}
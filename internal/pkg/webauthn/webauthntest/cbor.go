@@ -0,0 +1,68 @@
+package webauthntest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// encode writes the CBOR items the authenticator needs: integers, byte and text strings, arrays and
+// maps. Map keys are sorted by their encoding, the canonical order authenticators use.
+func encode(item any) []byte {
+	switch v := item.(type) {
+	case int:
+		return encode(int64(v))
+	case int64:
+		if v < 0 {
+			return header(1, uint64(-1-v))
+		}
+		return header(0, uint64(v))
+	case []byte:
+		return append(header(2, uint64(len(v))), v...)
+	case string:
+		return append(header(3, uint64(len(v))), v...)
+	case []any:
+		out := header(4, uint64(len(v)))
+		for _, elem := range v {
+			out = append(out, encode(elem)...)
+		}
+		return out
+	case map[any]any:
+		entries := make([][2][]byte, 0, len(v))
+		for key, value := range v {
+			entries = append(entries, [2][]byte{encode(key), encode(value)})
+		}
+		slices.SortFunc(entries, func(a, b [2][]byte) int {
+			if len(a[0]) != len(b[0]) {
+				return len(a[0]) - len(b[0])
+			}
+			return slices.Compare(a[0], b[0])
+		})
+
+		out := header(5, uint64(len(v)))
+		for _, entry := range entries {
+			out = append(out, entry[0]...)
+			out = append(out, entry[1]...)
+		}
+		return out
+	default:
+		panic(fmt.Sprintf("webauthntest: cannot encode %T", item))
+	}
+}
+
+func header(major byte, arg uint64) []byte {
+	major <<= 5
+
+	switch {
+	case arg < 24:
+		return []byte{major | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major | 25}, uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major | 26}, uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{major | 27}, arg)
+	}
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN IF EXISTS parent_jti,
+DROP COLUMN IF EXISTS family_id;
@@ -0,0 +1,16 @@
+ALTER TABLE refresh_tokens
+ADD COLUMN family_id UUID,
+ADD COLUMN parent_jti UUID;
+
+-- Existing tokens start their own family.
+UPDATE refresh_tokens
+SET
+    family_id = jti
+WHERE
+    family_id IS NULL;
+
+ALTER TABLE refresh_tokens
+ALTER COLUMN family_id
+SET NOT NULL;
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
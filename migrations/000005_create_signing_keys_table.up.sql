@@ -0,0 +1,12 @@
+CREATE TABLE
+    signing_keys (
+        kid VARCHAR(64) PRIMARY KEY,
+        token_type VARCHAR(16) NOT NULL,
+        algorithm VARCHAR(16) NOT NULL,
+        material TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        promoted_at TIMESTAMPTZ,
+        retired_at TIMESTAMPTZ
+    );
+
+CREATE INDEX idx_signing_keys_token_type ON signing_keys (token_type);
@@ -0,0 +1 @@
+CREATE INDEX idx_refresh_tokens_parent_jti ON refresh_tokens (parent_jti);
@@ -0,0 +1,4 @@
+ALTER TABLE refresh_tokens
+DROP COLUMN IF EXISTS last_used_at,
+DROP COLUMN IF EXISTS ip_address,
+DROP COLUMN IF EXISTS user_agent;
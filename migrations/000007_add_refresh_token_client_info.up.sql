@@ -0,0 +1,16 @@
+ALTER TABLE refresh_tokens
+ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+ADD COLUMN ip_address VARCHAR(45) NOT NULL DEFAULT '',
+ADD COLUMN last_used_at TIMESTAMPTZ;
+
+UPDATE refresh_tokens
+SET
+    last_used_at = created_at
+WHERE
+    last_used_at IS NULL;
+
+ALTER TABLE refresh_tokens
+ALTER COLUMN last_used_at
+SET NOT NULL,
+ALTER COLUMN last_used_at
+SET DEFAULT NOW ();
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_recovery_codes_user_id_code_hash;
+
+DROP TABLE IF EXISTS recovery_codes;
+
+DROP TABLE IF EXISTS totp_factors;
@@ -0,0 +1,20 @@
+CREATE TABLE
+    totp_factors (
+        user_id BIGINT PRIMARY KEY,
+        secret TEXT NOT NULL,
+        last_used_step BIGINT NOT NULL DEFAULT 0,
+        confirmed_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE TABLE
+    recovery_codes (
+        id BIGSERIAL PRIMARY KEY,
+        user_id BIGINT NOT NULL,
+        code_hash CHAR(64) NOT NULL,
+        used_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE UNIQUE INDEX idx_recovery_codes_user_id_code_hash ON recovery_codes (user_id, code_hash);
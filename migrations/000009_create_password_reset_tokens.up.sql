@@ -0,0 +1,13 @@
+CREATE TABLE
+    password_reset_tokens (
+        id BIGSERIAL PRIMARY KEY,
+        user_id BIGINT NOT NULL,
+        token_hash CHAR(64) NOT NULL,
+        expires_at TIMESTAMPTZ NOT NULL,
+        used_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE UNIQUE INDEX idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_email_tokens_user_id;
+
+DROP INDEX IF EXISTS idx_email_tokens_token_hash;
+
+DROP TABLE IF EXISTS email_tokens;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,18 @@
+ALTER TABLE users
+ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+CREATE TABLE
+    email_tokens (
+        id BIGSERIAL PRIMARY KEY,
+        user_id BIGINT NOT NULL,
+        email VARCHAR(254) NOT NULL,
+        purpose VARCHAR(16) NOT NULL,
+        token_hash CHAR(64) NOT NULL,
+        expires_at TIMESTAMPTZ NOT NULL,
+        used_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE UNIQUE INDEX idx_email_tokens_token_hash ON email_tokens (token_hash);
+
+CREATE INDEX idx_email_tokens_user_id ON email_tokens (user_id);
@@ -0,0 +1,3 @@
+-- Fails while argon2id hashes are stored, they don't fit in the bcrypt length.
+ALTER TABLE users
+ALTER COLUMN password TYPE CHAR(60);
@@ -0,0 +1,18 @@
+CREATE TABLE
+    password_history (
+        id BIGSERIAL PRIMARY KEY,
+        user_id BIGINT NOT NULL,
+        password_hash VARCHAR(255) NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX idx_password_history_user_id ON password_history (user_id, created_at DESC);
+
+-- The current passwords count as the first entries.
+INSERT INTO
+    password_history (user_id, password_hash)
+SELECT
+    id,
+    password
+FROM
+    users;
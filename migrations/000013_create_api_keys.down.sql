@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+
+DROP INDEX IF EXISTS idx_api_keys_key_hash;
+
+DROP TABLE IF EXISTS api_keys;
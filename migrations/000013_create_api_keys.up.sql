@@ -0,0 +1,16 @@
+CREATE TABLE
+    api_keys (
+        id BIGSERIAL PRIMARY KEY,
+        user_id BIGINT NOT NULL,
+        name VARCHAR(100) NOT NULL,
+        prefix VARCHAR(16) NOT NULL,
+        key_hash CHAR(64) NOT NULL,
+        scopes VARCHAR(255) NOT NULL,
+        expires_at TIMESTAMPTZ,
+        last_used_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE UNIQUE INDEX idx_api_keys_key_hash ON api_keys (key_hash);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
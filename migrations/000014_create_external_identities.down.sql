@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_external_identities_user_id;
+
+DROP INDEX IF EXISTS idx_external_identities_issuer_subject;
+
+DROP TABLE IF EXISTS external_identities;
@@ -0,0 +1,13 @@
+CREATE TABLE
+    external_identities (
+        id BIGSERIAL PRIMARY KEY,
+        user_id BIGINT NOT NULL,
+        issuer VARCHAR(255) NOT NULL,
+        subject VARCHAR(255) NOT NULL,
+        email VARCHAR(254) NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE UNIQUE INDEX idx_external_identities_issuer_subject ON external_identities (issuer, subject);
+
+CREATE INDEX idx_external_identities_user_id ON external_identities (user_id);
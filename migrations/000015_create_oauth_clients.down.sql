@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_oauth_clients_client_id;
+
+DROP TABLE IF EXISTS oauth_clients;
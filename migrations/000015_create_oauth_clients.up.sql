@@ -0,0 +1,11 @@
+CREATE TABLE
+    oauth_clients (
+        id BIGSERIAL PRIMARY KEY,
+        client_id VARCHAR(64) NOT NULL,
+        name VARCHAR(100) NOT NULL,
+        secret_hash CHAR(64) NOT NULL,
+        scopes VARCHAR(255) NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE UNIQUE INDEX idx_oauth_clients_client_id ON oauth_clients (client_id);
@@ -0,0 +1,19 @@
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS fk_users_role;
+
+CREATE TYPE user_role AS ENUM('admin', 'user', 'moderator');
+
+ALTER TABLE users
+ALTER COLUMN role
+DROP DEFAULT;
+
+ALTER TABLE users
+ALTER COLUMN role TYPE user_role USING role::user_role;
+
+ALTER TABLE users
+ALTER COLUMN role
+SET DEFAULT 'user';
+
+DROP TABLE IF EXISTS role_permissions;
+
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,52 @@
+CREATE TABLE
+    roles (
+        name VARCHAR(50) PRIMARY KEY,
+        description VARCHAR(255) NOT NULL DEFAULT '',
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE TABLE
+    role_permissions (
+        role_name VARCHAR(50) NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+        permission VARCHAR(100) NOT NULL,
+        PRIMARY KEY (role_name, permission)
+    );
+
+INSERT INTO
+    roles (name, description)
+VALUES
+    ('admin', 'Manages users, sessions and the auth configuration'),
+    ('moderator', 'Reads users and revokes their sessions'),
+    ('user', 'Manages their own account');
+
+INSERT INTO
+    role_permissions (role_name, permission)
+VALUES
+    ('admin', 'users:create'),
+    ('admin', 'users:read'),
+    ('admin', 'sessions:read'),
+    ('admin', 'sessions:revoke'),
+    ('admin', 'signing_keys:read'),
+    ('admin', 'signing_keys:write'),
+    ('admin', 'oauth_clients:read'),
+    ('admin', 'oauth_clients:write'),
+    ('moderator', 'users:read'),
+    ('moderator', 'sessions:read'),
+    ('moderator', 'sessions:revoke');
+
+-- Roles are rows now, new ones don't need a schema change.
+ALTER TABLE users
+ALTER COLUMN role
+DROP DEFAULT;
+
+ALTER TABLE users
+ALTER COLUMN role TYPE VARCHAR(50) USING role::TEXT;
+
+ALTER TABLE users
+ALTER COLUMN role
+SET DEFAULT 'user';
+
+ALTER TABLE users
+ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles (name);
+
+DROP TYPE IF EXISTS user_role;
@@ -0,0 +1,3 @@
+DELETE FROM role_permissions
+WHERE
+    permission = 'users:impersonate';
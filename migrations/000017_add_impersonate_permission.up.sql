@@ -0,0 +1,4 @@
+INSERT INTO
+    role_permissions (role_name, permission)
+VALUES
+    ('admin', 'users:impersonate');
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_passkeys_user_id;
+
+DROP INDEX IF EXISTS idx_passkeys_credential_id;
+
+DROP TABLE IF EXISTS passkeys;
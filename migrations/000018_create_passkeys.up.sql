@@ -0,0 +1,18 @@
+CREATE TABLE
+    passkeys (
+        id BIGSERIAL PRIMARY KEY,
+        user_id BIGINT NOT NULL,
+        credential_id BYTEA NOT NULL,
+        public_key BYTEA NOT NULL,
+        sign_count BIGINT NOT NULL DEFAULT 0,
+        aaguid UUID NOT NULL,
+        name VARCHAR(100) NOT NULL,
+        backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+        backed_up BOOLEAN NOT NULL DEFAULT FALSE,
+        last_used_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE UNIQUE INDEX idx_passkeys_credential_id ON passkeys (credential_id);
+
+CREATE INDEX idx_passkeys_user_id ON passkeys (user_id);
@@ -0,0 +1,3 @@
+-- Thumbprint of the DPoP key the session is bound to, empty for bearer sessions.
+ALTER TABLE refresh_tokens
+ADD COLUMN jkt VARCHAR(43) NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+DELETE FROM role_permissions
+WHERE
+    permission = 'auth_events:read';
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS last_login_ip,
+DROP COLUMN IF EXISTS last_login_at;
+
+DROP TABLE IF EXISTS auth_events;
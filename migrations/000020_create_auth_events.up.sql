@@ -0,0 +1,24 @@
+CREATE TABLE
+    auth_events (
+        id BIGSERIAL PRIMARY KEY,
+        type VARCHAR(32) NOT NULL,
+        user_id BIGINT,
+        ip_address VARCHAR(45) NOT NULL DEFAULT '',
+        user_agent TEXT NOT NULL DEFAULT '',
+        reason VARCHAR(64) NOT NULL DEFAULT '',
+        trace_id VARCHAR(32) NOT NULL DEFAULT '',
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX idx_auth_events_created_at ON auth_events (created_at);
+
+CREATE INDEX idx_auth_events_user_id ON auth_events (user_id, created_at);
+
+ALTER TABLE users
+ADD COLUMN last_login_at TIMESTAMPTZ,
+ADD COLUMN last_login_ip VARCHAR(45);
+
+INSERT INTO
+    role_permissions (role_name, permission)
+VALUES
+    ('admin', 'auth_events:read');
@@ -0,0 +1,5 @@
+ALTER TABLE refresh_tokens
+DROP COLUMN IF EXISTS amr;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN IF EXISTS auth_time;
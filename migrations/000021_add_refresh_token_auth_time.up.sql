@@ -0,0 +1,14 @@
+-- Last authentication of the session and its methods, re-authenticating updates them in place.
+-- Existing sessions count as authenticated when they started.
+ALTER TABLE refresh_tokens
+ADD COLUMN auth_time TIMESTAMPTZ;
+
+UPDATE refresh_tokens
+SET
+    auth_time = created_at;
+
+ALTER TABLE refresh_tokens
+ALTER COLUMN auth_time SET NOT NULL;
+
+ALTER TABLE refresh_tokens
+ADD COLUMN amr VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE signing_keys
+DROP COLUMN IF EXISTS encrypted;
@@ -0,0 +1,3 @@
+-- Key material is encrypted at rest, the keys stored before are encrypted in place on the next start.
+ALTER TABLE signing_keys
+ADD COLUMN encrypted BOOLEAN NOT NULL DEFAULT FALSE;